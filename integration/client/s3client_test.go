@@ -0,0 +1,240 @@
+package client_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+	"github.com/zhulik/d3/internal/client/s3client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("S3 data client", Label("client"), Label("s3client"), Ordered, func() {
+	const partSize = 5 << 20
+
+	var (
+		app    *testhelpers.App
+		client *s3client.Client
+		bucket string
+		opts   = s3client.TransferOptions{PartSize: partSize, Concurrency: 3}
+	)
+
+	writeFile := func(path string, content []byte) {
+		lo.Must0(os.MkdirAll(filepath.Dir(path), 0750))
+		lo.Must0(os.WriteFile(path, content, 0600))
+	}
+
+	changes := func(changes []s3client.SyncChange, err error) []s3client.SyncChange {
+		Expect(err).NotTo(HaveOccurred())
+
+		return changes
+	}
+
+	BeforeAll(func(ctx context.Context) {
+		app = testhelpers.NewApp() //nolint:contextcheck
+		client = app.S3DataClient(ctx, "admin")
+		bucket = app.BucketName()
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	Describe("ParseURL", func() {
+		It("splits bucket and key", func() {
+			bucket, key, err := s3client.ParseURL("s3://bucket/some/key")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bucket).To(Equal("bucket"))
+			Expect(key).To(Equal("some/key"))
+
+			_, key, err = s3client.ParseURL("s3://bucket")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(BeEmpty())
+		})
+
+		It("rejects local paths and URLs without a bucket", func() {
+			_, _, err := s3client.ParseURL("bucket/key")
+			Expect(err).To(MatchError(s3client.ErrInvalidS3URL))
+
+			_, _, err = s3client.ParseURL("s3:///key")
+			Expect(err).To(MatchError(s3client.ErrInvalidS3URL))
+		})
+	})
+
+	When("a large file is transferred", func() {
+		It("is uploaded and downloaded in parallel parts", func(ctx context.Context) {
+			dir := GinkgoT().TempDir()
+			content := make([]byte, 2*partSize+1234)
+			lo.Must(rand.Read(content))
+			writeFile(filepath.Join(dir, "large.bin"), content)
+
+			Expect(client.Upload(ctx, filepath.Join(dir, "large.bin"), bucket, "large.bin", opts)).To(Succeed())
+
+			objects := lo.Must(client.ListObjects(ctx, bucket, "large", false))
+			Expect(objects).To(HaveLen(1))
+			Expect(objects[0].Size).To(BeEquivalentTo(len(content)))
+
+			sum := sha256.Sum256(content)
+			Expect(objects[0].ETag).To(Equal(hex.EncodeToString(sum[:])))
+
+			Expect(client.Download(ctx, bucket, "large.bin", filepath.Join(dir, "copy.bin"), opts)).To(Succeed())
+			Expect(os.ReadFile(filepath.Join(dir, "copy.bin"))).To(Equal(content))
+		})
+	})
+
+	When("objects are listed", func() {
+		It("lists buckets", func(ctx context.Context) {
+			buckets := lo.Must(client.ListBuckets(ctx))
+			Expect(lo.Map(buckets, func(b s3client.Bucket, _ int) string { return b.Name })).To(ContainElement(bucket))
+		})
+
+		It("groups keys by prefix unless recursive", func(ctx context.Context) {
+			dir := GinkgoT().TempDir()
+			writeFile(filepath.Join(dir, "f"), []byte("data"))
+
+			for _, key := range []string{"list/a.txt", "list/sub/b.txt"} {
+				Expect(client.Upload(ctx, filepath.Join(dir, "f"), bucket, key, opts)).To(Succeed())
+			}
+
+			objects := lo.Must(client.ListObjects(ctx, bucket, "list/", false))
+			Expect(objects).To(HaveLen(2))
+			Expect(objects[0].Key).To(Equal("list/a.txt"))
+			Expect(objects[1].Key).To(Equal("list/sub/"))
+			Expect(objects[1].IsPrefix).To(BeTrue())
+
+			Expect(client.ListObjects(ctx, bucket, "list/", true)).To(HaveLen(2))
+		})
+	})
+
+	When("objects are copied and removed", func() {
+		It("copies on the server side", func(ctx context.Context) {
+			Expect(client.Copy(ctx, bucket, "list/a.txt", bucket, "copied/a.txt")).To(Succeed())
+			Expect(client.ListObjects(ctx, bucket, "copied/", true)).To(HaveLen(1))
+		})
+
+		It("removes a single object", func(ctx context.Context) {
+			Expect(client.Remove(ctx, bucket, "copied/a.txt")).To(Succeed())
+			Expect(client.ListObjects(ctx, bucket, "copied/", true)).To(BeEmpty())
+		})
+
+		It("removes by prefix", func(ctx context.Context) {
+			Expect(client.RemovePrefix(ctx, bucket, "list/")).To(ConsistOf("list/a.txt", "list/sub/b.txt"))
+			Expect(client.ListObjects(ctx, bucket, "list/", true)).To(BeEmpty())
+		})
+	})
+
+	When("a directory is synced up", func() {
+		var dir string
+
+		BeforeAll(func() {
+			dir = GinkgoT().TempDir()
+			writeFile(filepath.Join(dir, "a.txt"), []byte("a"))
+			writeFile(filepath.Join(dir, "nested", "b.txt"), []byte("b"))
+		})
+
+		It("uploads every file", func(ctx context.Context) {
+			Expect(changes(client.SyncUp(ctx, dir, bucket, "up", s3client.SyncOptions{}))).To(ConsistOf(
+				s3client.SyncChange{Operation: s3client.SyncUpload, Name: "up/a.txt"},
+				s3client.SyncChange{Operation: s3client.SyncUpload, Name: "up/nested/b.txt"},
+			))
+		})
+
+		It("uploads nothing when nothing changed", func(ctx context.Context) {
+			Expect(changes(client.SyncUp(ctx, dir, bucket, "up/", s3client.SyncOptions{}))).To(BeEmpty())
+		})
+
+		It("uploads changed files only", func(ctx context.Context) {
+			writeFile(filepath.Join(dir, "a.txt"), []byte("changed"))
+
+			Expect(changes(client.SyncUp(ctx, dir, bucket, "up", s3client.SyncOptions{}))).To(ConsistOf(
+				s3client.SyncChange{Operation: s3client.SyncUpload, Name: "up/a.txt"},
+			))
+		})
+
+		It("deletes objects missing locally when asked to", func(ctx context.Context) {
+			lo.Must0(os.Remove(filepath.Join(dir, "nested", "b.txt")))
+
+			Expect(changes(client.SyncUp(ctx, dir, bucket, "up", s3client.SyncOptions{}))).To(BeEmpty())
+			Expect(changes(client.SyncUp(ctx, dir, bucket, "up", s3client.SyncOptions{Delete: true}))).To(ConsistOf(
+				s3client.SyncChange{Operation: s3client.SyncDelete, Name: "up/nested/b.txt"},
+			))
+		})
+	})
+
+	When("a prefix is synced down", func() {
+		var dir string
+
+		BeforeAll(func(ctx context.Context) {
+			dir = filepath.Join(GinkgoT().TempDir(), "down")
+
+			src := GinkgoT().TempDir()
+			writeFile(filepath.Join(src, "x.txt"), []byte("x"))
+			writeFile(filepath.Join(src, "deep", "y.txt"), []byte("y"))
+			changes(client.SyncUp(ctx, src, bucket, "down", s3client.SyncOptions{}))
+		})
+
+		It("downloads every object", func(ctx context.Context) {
+			Expect(changes(client.SyncDown(ctx, bucket, "down", dir, s3client.SyncOptions{}))).To(HaveLen(2))
+			Expect(os.ReadFile(filepath.Join(dir, "deep", "y.txt"))).To(Equal([]byte("y")))
+		})
+
+		It("downloads nothing when nothing changed", func(ctx context.Context) {
+			Expect(changes(client.SyncDown(ctx, bucket, "down", dir, s3client.SyncOptions{}))).To(BeEmpty())
+		})
+
+		It("restores modified files and deletes extra ones when asked to", func(ctx context.Context) {
+			writeFile(filepath.Join(dir, "x.txt"), []byte("modified"))
+			writeFile(filepath.Join(dir, "extra.txt"), []byte("extra"))
+
+			Expect(changes(client.SyncDown(ctx, bucket, "down", dir, s3client.SyncOptions{Delete: true}))).To(ConsistOf(
+				s3client.SyncChange{Operation: s3client.SyncDownload, Name: filepath.Join(dir, "x.txt")},
+				s3client.SyncChange{Operation: s3client.SyncDelete, Name: filepath.Join(dir, "extra.txt")},
+			))
+			Expect(os.ReadFile(filepath.Join(dir, "x.txt"))).To(Equal([]byte("x")))
+		})
+	})
+
+	When("a URL is presigned", func() {
+		It("allows uploading and downloading without credentials", func(ctx context.Context) {
+			putURL := lo.Must(client.Presign(ctx, http.MethodPut, bucket, "presigned.txt", time.Hour))
+
+			req := lo.Must(http.NewRequestWithContext(ctx, http.MethodPut, putURL, strings.NewReader("presigned")))
+			resp := lo.Must(http.DefaultClient.Do(req))
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			getURL := lo.Must(client.Presign(ctx, http.MethodGet, bucket, "presigned.txt", time.Hour))
+
+			req = lo.Must(http.NewRequestWithContext(ctx, http.MethodGet, getURL, nil))
+			resp = lo.Must(http.DefaultClient.Do(req))
+			defer resp.Body.Close()
+
+			Expect(io.ReadAll(resp.Body)).To(Equal([]byte("presigned")))
+		})
+
+		It("rejects other methods", func(ctx context.Context) {
+			_, err := client.Presign(ctx, http.MethodDelete, bucket, "presigned.txt", time.Hour)
+			Expect(err).To(MatchError(s3client.ErrUnsupportedMethod))
+		})
+	})
+
+	When("the user has no access", func() {
+		It("fails", func(ctx context.Context) {
+			lo.Must(app.ManagementBackend(ctx).CreateUser(ctx, "nobody"))
+
+			err := app.S3DataClient(ctx, "nobody").Upload(ctx, "/dev/null", bucket, "denied", opts)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
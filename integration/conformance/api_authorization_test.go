@@ -133,6 +133,19 @@ var _ = Describe("Authorization", Label("conformance"), Label("authorization"),
 		}
 		lo.Must0(mgmtBackend.CreatePolicy(ctx, bucketMgmtPolicy))
 
+		tlsOnlyPolicy := &iampol.IAMPolicy{
+			ID: "tls-only-policy",
+			Statement: []iampol.Statement{{
+				Effect:   iampol.EffectAllow,
+				Action:   []s3actions.Action{s3actions.GetObject},
+				Resource: []string{arnPrefix + app.BucketName() + "/*"},
+				Condition: iampol.Condition{
+					iampol.ConditionOperatorBool: {iampol.ConditionKeySecureTransport: {"true"}},
+				},
+			}},
+		}
+		lo.Must0(mgmtBackend.CreatePolicy(ctx, tlsOnlyPolicy))
+
 		lo.Must(mgmtBackend.CreateUser(ctx, "reader"))
 		lo.Must0(mgmtBackend.CreateBinding(ctx, &core.PolicyBinding{UserName: "reader", PolicyID: "read-only-policy"}))
 
@@ -148,6 +161,10 @@ var _ = Describe("Authorization", Label("conformance"), Label("authorization"),
 
 		lo.Must(mgmtBackend.CreateUser(ctx, "no-permissions-user"))
 
+		// The test app does not use TLS, so the condition of the policy never holds.
+		lo.Must(mgmtBackend.CreateUser(ctx, "tls-only-user"))
+		lo.Must0(mgmtBackend.CreateBinding(ctx, &core.PolicyBinding{UserName: "tls-only-user", PolicyID: "tls-only-policy"}))
+
 		lo.Must(mgmtBackend.CreateUser(ctx, "specific-object-user"))
 		lo.Must0(mgmtBackend.CreateBinding(ctx, &core.PolicyBinding{UserName: "specific-object-user", PolicyID: "specific-object-policy"}))
 
@@ -160,6 +177,19 @@ var _ = Describe("Authorization", Label("conformance"), Label("authorization"),
 		lo.Must(mgmtBackend.CreateUser(ctx, "bucket-mgmt-user"))
 		lo.Must0(mgmtBackend.CreateBinding(ctx, &core.PolicyBinding{UserName: "bucket-mgmt-user", PolicyID: "bucket-mgmt-policy"}))
 
+		lo.Must0(mgmtBackend.CreateGroup(ctx, "readers"))
+		lo.Must0(mgmtBackend.CreateGroupBinding(ctx, &core.GroupBinding{GroupName: "readers", PolicyID: "read-only-policy"}))
+		lo.Must0(mgmtBackend.CreateGroup(ctx, "no-delete"))
+		lo.Must0(mgmtBackend.CreateGroupBinding(ctx, &core.GroupBinding{GroupName: "no-delete", PolicyID: "deny-delete-policy"}))
+
+		lo.Must(mgmtBackend.CreateUser(ctx, "group-reader"))
+		lo.Must0(mgmtBackend.AddGroupMember(ctx, "readers", "group-reader"))
+
+		// Direct Allow, group Deny: the Deny must win.
+		lo.Must(mgmtBackend.CreateUser(ctx, "group-restricted-writer"))
+		lo.Must0(mgmtBackend.CreateBinding(ctx, &core.PolicyBinding{UserName: "group-restricted-writer", PolicyID: "write-only-policy"}))
+		lo.Must0(mgmtBackend.AddGroupMember(ctx, "no-delete", "group-restricted-writer"))
+
 		// Create buckets for DeleteBucket tests.
 		tempBucketForAdminDelete = app.BucketName() + "-temp-admin-delete"
 		lo.Must(adminS3Client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: lo.ToPtr(tempBucketForAdminDelete)}))
@@ -278,8 +308,13 @@ var _ = Describe("Authorization", Label("conformance"), Label("authorization"),
 		Entry("public-reader cannot get private file", "public-reader", s3actions.GetObject, "private/file2.txt", false),
 		Entry("restricted-writer can put object", "restricted-writer", s3actions.PutObject, "restricted-new.txt", true),
 		Entry("restricted-writer cannot delete object", "restricted-writer", s3actions.DeleteObject, "public/file1.txt", false),
+		Entry("group-reader can get object via group", "group-reader", s3actions.GetObject, "private/file2.txt", true),
+		Entry("group-reader cannot put object", "group-reader", s3actions.PutObject, "group-reader-new.txt", false),
+		Entry("group-restricted-writer can put object", "group-restricted-writer", s3actions.PutObject, "group-restricted-new.txt", true),
+		Entry("group-restricted-writer cannot delete object", "group-restricted-writer", s3actions.DeleteObject, "public/file1.txt", false),
 		Entry("no-permissions-user cannot get object", "no-permissions-user", s3actions.GetObject, "public/file1.txt", false),
 		Entry("no-permissions-user cannot put object", "no-permissions-user", s3actions.PutObject, "noperm-new.txt", false),
+		Entry("tls-only-user cannot get object over plain HTTP", "tls-only-user", s3actions.GetObject, "public/file1.txt", false),
 		Entry("specific-object-user can get shared file3", "specific-object-user", s3actions.GetObject, "shared/file3.txt", true),
 		Entry("specific-object-user can head shared file3", "specific-object-user", s3actions.HeadObject, "shared/file3.txt", true),
 		Entry("specific-object-user cannot get public file", "specific-object-user", s3actions.GetObject, "public/file1.txt", false),
@@ -0,0 +1,143 @@
+package conformance_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/s3actions"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bucket logging API", Label("conformance"), Label("api-bucket-logging"), Ordered, func() {
+	const targetBucket = "access-logs"
+
+	var (
+		app      *testhelpers.App
+		s3Client *s3.Client
+	)
+
+	readLogs := func(ctx context.Context) string {
+		listed := lo.Must(s3Client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket: lo.ToPtr(targetBucket),
+			Prefix: lo.ToPtr("logs/"),
+		}))
+
+		var logs strings.Builder
+
+		for _, object := range listed.Contents {
+			output := lo.Must(s3Client.GetObject(ctx, &s3.GetObjectInput{Bucket: lo.ToPtr(targetBucket), Key: object.Key}))
+			logs.Write(lo.Must(io.ReadAll(output.Body)))
+			output.Body.Close()
+		}
+
+		return logs.String()
+	}
+
+	BeforeAll(func(ctx context.Context) {
+		app = testhelpers.NewApp(func(cfg *core.Config) { //nolint:contextcheck
+			cfg.AccessLogFlushInterval = 100 * time.Millisecond
+		})
+		s3Client = app.S3Client(ctx, "admin")
+
+		lo.Must(s3Client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: lo.ToPtr(targetBucket)}))
+
+		mgmtBackend := app.ManagementBackend(ctx)
+		lo.Must0(mgmtBackend.CreatePolicy(ctx, &iampol.IAMPolicy{
+			ID: "logging-policy",
+			Statement: []iampol.Statement{{
+				Effect:   iampol.EffectAllow,
+				Action:   []s3actions.Action{s3actions.PutBucketLogging},
+				Resource: []string{"arn:aws:s3:::" + app.BucketName()},
+			}},
+		}))
+		lo.Must(mgmtBackend.CreateUser(ctx, "logging-user"))
+		lo.Must0(mgmtBackend.CreateBinding(ctx, &core.PolicyBinding{UserName: "logging-user", PolicyID: "logging-policy"}))
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	It("is disabled by default", func(ctx context.Context) {
+		output := lo.Must(s3Client.GetBucketLogging(ctx, &s3.GetBucketLoggingInput{Bucket: lo.ToPtr(app.BucketName())}))
+		Expect(output.LoggingEnabled).To(BeNil())
+	})
+
+	It("rejects missing target buckets", func(ctx context.Context) {
+		_, err := s3Client.PutBucketLogging(ctx, &s3.PutBucketLoggingInput{
+			Bucket: lo.ToPtr(app.BucketName()),
+			BucketLoggingStatus: &types.BucketLoggingStatus{LoggingEnabled: &types.LoggingEnabled{
+				TargetBucket: lo.ToPtr("missing-bucket"),
+				TargetPrefix: lo.ToPtr("logs/"),
+			}},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects target buckets the caller cannot write to", func(ctx context.Context) {
+		_, err := app.S3Client(ctx, "logging-user").PutBucketLogging(ctx, &s3.PutBucketLoggingInput{
+			Bucket: lo.ToPtr(app.BucketName()),
+			BucketLoggingStatus: &types.BucketLoggingStatus{LoggingEnabled: &types.LoggingEnabled{
+				TargetBucket: lo.ToPtr(targetBucket),
+				TargetPrefix: lo.ToPtr("logs/"),
+			}},
+		})
+		Expect(err).To(MatchError(ContainSubstring("StatusCode: 403")))
+
+		output := lo.Must(s3Client.GetBucketLogging(ctx, &s3.GetBucketLoggingInput{Bucket: lo.ToPtr(app.BucketName())}))
+		Expect(output.LoggingEnabled).To(BeNil())
+	})
+
+	It("writes access logs to the target bucket", func(ctx context.Context) {
+		lo.Must(s3Client.PutBucketLogging(ctx, &s3.PutBucketLoggingInput{
+			Bucket: lo.ToPtr(app.BucketName()),
+			BucketLoggingStatus: &types.BucketLoggingStatus{LoggingEnabled: &types.LoggingEnabled{
+				TargetBucket: lo.ToPtr(targetBucket),
+				TargetPrefix: lo.ToPtr("logs/"),
+			}},
+		}))
+
+		output := lo.Must(s3Client.GetBucketLogging(ctx, &s3.GetBucketLoggingInput{Bucket: lo.ToPtr(app.BucketName())}))
+		Expect(output.LoggingEnabled.TargetBucket).To(Equal(lo.ToPtr(targetBucket)))
+		Expect(output.LoggingEnabled.TargetPrefix).To(Equal(lo.ToPtr("logs/")))
+
+		lo.Must(s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: lo.ToPtr(app.BucketName()),
+			Key:    lo.ToPtr("logged.txt"),
+			Body:   strings.NewReader("content"),
+		}))
+
+		_, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: lo.ToPtr(app.BucketName()),
+			Key:    lo.ToPtr("missing.txt"),
+		})
+		Expect(err).To(HaveOccurred())
+
+		Eventually(readLogs).WithContext(ctx).WithTimeout(5 * time.Second).Should(And(
+			MatchRegexp(` %s \[.+\] \S+ admin \S+ REST\.PUT\.OBJECT logged\.txt "PUT /%s/logged\.txt(\?\S*)? HTTP/1\.1" 200 `, //nolint:lll
+				app.BucketName(), app.BucketName()),
+			ContainSubstring(" REST.GET.OBJECT missing.txt "),
+			ContainSubstring(" 404 NoSuchKey "),
+		))
+	})
+
+	It("stops logging when disabled", func(ctx context.Context) {
+		lo.Must(s3Client.PutBucketLogging(ctx, &s3.PutBucketLoggingInput{
+			Bucket:              lo.ToPtr(app.BucketName()),
+			BucketLoggingStatus: &types.BucketLoggingStatus{},
+		}))
+
+		output := lo.Must(s3Client.GetBucketLogging(ctx, &s3.GetBucketLoggingInput{Bucket: lo.ToPtr(app.BucketName())}))
+		Expect(output.LoggingEnabled).To(BeNil())
+	})
+})
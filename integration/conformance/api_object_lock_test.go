@@ -0,0 +1,171 @@
+package conformance_test
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+	. "github.com/zhulik/d3/pkg/ginkgohelpers"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Object Lock", Label("conformance"), Label("api-object-lock"), Ordered, func() {
+	var (
+		app      *testhelpers.App
+		s3Client *s3.Client
+		bucket   *string
+	)
+
+	put := func(ctx context.Context, key string) {
+		lo.Must(s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: bucket,
+			Key:    lo.ToPtr(key),
+			Body:   strings.NewReader("locked content"),
+		}))
+	}
+
+	BeforeAll(func(ctx context.Context) {
+		app = testhelpers.NewApp()
+		s3Client = app.S3Client(ctx, "admin")
+		bucket = lo.ToPtr("object-lock-bucket")
+
+		lo.Must(s3Client.CreateBucket(ctx, &s3.CreateBucketInput{
+			Bucket:                     bucket,
+			ObjectLockEnabledForBucket: lo.ToPtr(true),
+		}))
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	It("reports the configuration of buckets created with Object Lock", func(ctx context.Context) {
+		output := lo.Must(s3Client.GetObjectLockConfiguration(ctx, &s3.GetObjectLockConfigurationInput{Bucket: bucket}))
+		Expect(output.ObjectLockConfiguration.ObjectLockEnabled).To(Equal(types.ObjectLockEnabledEnabled))
+		Expect(output.ObjectLockConfiguration.Rule).To(BeNil())
+
+		_, err := s3Client.GetObjectLockConfiguration(ctx, &s3.GetObjectLockConfigurationInput{
+			Bucket: lo.ToPtr(app.BucketName()),
+		})
+		Expect(err).To(BeS3HttpError(404))
+	})
+
+	It("retains objects written with a retention", func(ctx context.Context) {
+		retainUntil := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+		lo.Must(s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:                    bucket,
+			Key:                       lo.ToPtr("governed.txt"),
+			Body:                      strings.NewReader("locked content"),
+			ObjectLockMode:            types.ObjectLockModeGovernance,
+			ObjectLockRetainUntilDate: &retainUntil,
+		}))
+
+		head := lo.Must(s3Client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: bucket, Key: lo.ToPtr("governed.txt")}))
+		Expect(head.ObjectLockMode).To(Equal(types.ObjectLockModeGovernance))
+		Expect(*head.ObjectLockRetainUntilDate).To(BeTemporally("==", retainUntil))
+
+		_, err := s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: bucket, Key: lo.ToPtr("governed.txt")})
+		Expect(err).To(BeS3HttpError(403))
+
+		lo.Must(s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket:                    bucket,
+			Key:                       lo.ToPtr("governed.txt"),
+			BypassGovernanceRetention: lo.ToPtr(true),
+		}))
+	})
+
+	It("applies and extends retention of existing objects", func(ctx context.Context) {
+		put(ctx, "compliant.txt")
+
+		retainUntil := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		lo.Must(s3Client.PutObjectRetention(ctx, &s3.PutObjectRetentionInput{
+			Bucket: bucket,
+			Key:    lo.ToPtr("compliant.txt"),
+			Retention: &types.ObjectLockRetention{
+				Mode:            types.ObjectLockRetentionModeCompliance,
+				RetainUntilDate: &retainUntil,
+			},
+		}))
+
+		retention := lo.Must(s3Client.GetObjectRetention(ctx, &s3.GetObjectRetentionInput{
+			Bucket: bucket,
+			Key:    lo.ToPtr("compliant.txt"),
+		})).Retention
+		Expect(retention.Mode).To(Equal(types.ObjectLockRetentionModeCompliance))
+		Expect(*retention.RetainUntilDate).To(BeTemporally("==", retainUntil))
+
+		_, err := s3Client.PutObjectRetention(ctx, &s3.PutObjectRetentionInput{
+			Bucket:                    bucket,
+			Key:                       lo.ToPtr("compliant.txt"),
+			BypassGovernanceRetention: lo.ToPtr(true),
+			Retention: &types.ObjectLockRetention{
+				Mode:            types.ObjectLockRetentionModeCompliance,
+				RetainUntilDate: lo.ToPtr(retainUntil.Add(-time.Minute)),
+			},
+		})
+		Expect(err).To(BeS3HttpError(403))
+
+		_, err = s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket:                    bucket,
+			Key:                       lo.ToPtr("compliant.txt"),
+			BypassGovernanceRetention: lo.ToPtr(true),
+		})
+		Expect(err).To(BeS3HttpError(403))
+	})
+
+	It("protects objects under legal hold", func(ctx context.Context) {
+		put(ctx, "held.txt")
+
+		lo.Must(s3Client.PutObjectLegalHold(ctx, &s3.PutObjectLegalHoldInput{
+			Bucket:    bucket,
+			Key:       lo.ToPtr("held.txt"),
+			LegalHold: &types.ObjectLockLegalHold{Status: types.ObjectLockLegalHoldStatusOn},
+		}))
+
+		hold := lo.Must(s3Client.GetObjectLegalHold(ctx, &s3.GetObjectLegalHoldInput{
+			Bucket: bucket,
+			Key:    lo.ToPtr("held.txt"),
+		}))
+		Expect(hold.LegalHold.Status).To(Equal(types.ObjectLockLegalHoldStatusOn))
+
+		deleted := lo.Must(s3Client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: bucket,
+			Delete: &types.Delete{Objects: []types.ObjectIdentifier{{Key: lo.ToPtr("held.txt")}}},
+		}))
+		Expect(deleted.Errors).To(HaveLen(1))
+		Expect(*deleted.Errors[0].Code).To(Equal("AccessDenied"))
+
+		lo.Must(s3Client.PutObjectLegalHold(ctx, &s3.PutObjectLegalHoldInput{
+			Bucket:    bucket,
+			Key:       lo.ToPtr("held.txt"),
+			LegalHold: &types.ObjectLockLegalHold{Status: types.ObjectLockLegalHoldStatusOff},
+		}))
+		lo.Must(s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: bucket, Key: lo.ToPtr("held.txt")}))
+	})
+
+	It("applies the default retention of the bucket", func(ctx context.Context) {
+		lo.Must(s3Client.PutObjectLockConfiguration(ctx, &s3.PutObjectLockConfigurationInput{
+			Bucket: bucket,
+			ObjectLockConfiguration: &types.ObjectLockConfiguration{
+				ObjectLockEnabled: types.ObjectLockEnabledEnabled,
+				Rule: &types.ObjectLockRule{DefaultRetention: &types.DefaultRetention{
+					Mode: types.ObjectLockRetentionModeGovernance,
+					Days: lo.ToPtr[int32](1),
+				}},
+			},
+		}))
+
+		put(ctx, "defaulted.txt")
+
+		head := lo.Must(s3Client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: bucket, Key: lo.ToPtr("defaulted.txt")}))
+		Expect(head.ObjectLockMode).To(Equal(types.ObjectLockModeGovernance))
+		Expect(*head.ObjectLockRetainUntilDate).To(BeTemporally("~", time.Now().AddDate(0, 0, 1), time.Minute))
+	})
+})
@@ -1,7 +1,9 @@
 package conformance_test
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"strings"
@@ -88,6 +90,23 @@ var _ = Describe("Objects API", Label("conformance"), Label("api-objects"), Orde
 			})
 		})
 
+		When("the payload is unsigned", func() {
+			It("stores the checksum of the content", func(ctx context.Context) {
+				key := "unsigned-payload.txt"
+
+				_, err := minioClient.PutObject(ctx, bucketName, key, strings.NewReader(objectData), int64(len(objectData)), minio.PutObjectOptions{
+					DisableContentSha256: true,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				info, err := minioClient.StatObject(ctx, bucketName, key, minio.StatObjectOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.ETag).To(Equal(fmt.Sprintf("%x", sha256.Sum256([]byte(objectData)))))
+
+				lo.Must0(minioClient.RemoveObject(ctx, bucketName, key, minio.RemoveObjectOptions{}))
+			})
+		})
+
 		When("object already exists", func() {
 			overwriteKey := "overwrite-test.txt"
 
@@ -1045,7 +1064,8 @@ var _ = Describe("Objects API", Label("conformance"), Label("api-objects"), Orde
 				getObjectOutput, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
 					Bucket: &bucketName,
 					Key:    objectKeyAWS,
-					Range:  lo.ToPtr("bytes=1-5"),
+					Range:        lo.ToPtr("bytes=1-5"),
+					ChecksumMode: types.ChecksumModeEnabled,
 				})
 				Expect(err).NotTo(HaveOccurred())
 
@@ -1054,6 +1074,9 @@ var _ = Describe("Objects API", Label("conformance"), Label("api-objects"), Orde
 				bodyBytes, err := io.ReadAll(getObjectOutput.Body)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(string(bodyBytes)).To(Equal("ello "))
+				Expect(getObjectOutput.ContentRange).To(HaveValue(Equal("bytes 1-5/11")))
+				// The checksum of the whole object does not match the range.
+				Expect(getObjectOutput.ChecksumSHA256).To(BeNil())
 			})
 		})
 
@@ -1351,6 +1374,28 @@ var _ = Describe("Objects API", Label("conformance"), Label("api-objects"), Orde
 					Expect(err).To(HaveOccurred())
 				}
 			})
+
+			It("deletes objects with Minio SDK", func(ctx context.Context) {
+				// Minio SDK sends the Delete document without the S3 namespace.
+				objects := make(chan minio.ObjectInfo, len(keys))
+				for _, key := range keys {
+					objects <- minio.ObjectInfo{Key: key}
+				}
+
+				close(objects)
+
+				for result := range minioClient.RemoveObjectsWithResult(ctx, bucketName, objects, minio.RemoveObjectsOptions{}) {
+					Expect(result.Err).NotTo(HaveOccurred())
+				}
+
+				for _, key := range keys {
+					_, err := s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+						Bucket: &bucketName,
+						Key:    lo.ToPtr(key),
+					})
+					Expect(err).To(HaveOccurred())
+				}
+			})
 		})
 	})
 
@@ -1446,6 +1491,27 @@ var _ = Describe("Objects API", Label("conformance"), Label("api-objects"), Orde
 		})
 	})
 
+	Describe("Multipart upload with Minio SDK", func() {
+		It("uploads parts with streaming signatures", func(ctx context.Context) {
+			key := "streaming-multipart.bin"
+			data := bytes.Repeat([]byte("0123456789abcdef"), (5*1024*1024)/16+1)
+
+			_, err := minioClient.PutObject(ctx, bucketName, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+				PartSize: 5 * 1024 * 1024,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			object, err := minioClient.GetObject(ctx, bucketName, key, minio.GetObjectOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			defer object.Close()
+
+			Expect(io.ReadAll(object)).To(Equal(data))
+
+			lo.Must0(minioClient.RemoveObject(ctx, bucketName, key, minio.RemoveObjectOptions{}))
+		})
+	})
+
 	Describe("Multiplart Upload abort", func() {
 		var uploadID *string
 
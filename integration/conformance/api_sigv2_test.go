@@ -0,0 +1,97 @@
+package conformance_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	minioCreds "github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+	"github.com/zhulik/d3/internal/core"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// sigV2Client returns a minio client signing its requests with SigV2 as the user.
+func sigV2Client(ctx context.Context, app *testhelpers.App, username string) *minio.Client {
+	user := lo.Must(app.ManagementBackend(ctx).GetUserByName(ctx, username))
+
+	return app.MinioClient(ctx, username, func(opts *minio.Options) {
+		opts.Creds = minioCreds.NewStaticV2(user.AccessKeys[0].AccessKeyID, user.AccessKeys[0].SecretAccessKey, "")
+	})
+}
+
+var _ = Describe("SigV2 authentication", Label("conformance"), Label("api-sigv2"), func() {
+	When("SigV2 is enabled", Ordered, func() {
+		var (
+			app         *testhelpers.App
+			minioClient *minio.Client
+		)
+
+		BeforeAll(func(ctx context.Context) {
+			app = testhelpers.NewApp() //nolint:contextcheck
+			minioClient = sigV2Client(ctx, app, "admin")
+		})
+
+		AfterAll(func(ctx context.Context) {
+			app.Stop(ctx)
+		})
+
+		It("authenticates signed requests", func(ctx context.Context) {
+			lo.Must(minioClient.PutObject(ctx, app.BucketName(), "sigv2.txt", strings.NewReader("signed with v2"), 14,
+				minio.PutObjectOptions{ContentType: "text/plain"}))
+
+			object := lo.Must(minioClient.GetObject(ctx, app.BucketName(), "sigv2.txt", minio.GetObjectOptions{}))
+			defer object.Close()
+
+			Expect(io.ReadAll(object)).To(Equal([]byte("signed with v2")))
+		})
+
+		It("authenticates presigned URLs", func(ctx context.Context) {
+			url := lo.Must(minioClient.PresignedGetObject(ctx, app.BucketName(), "sigv2.txt", time.Minute, nil))
+
+			resp := lo.Must(http.Get(url.String())) //nolint:noctx
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(io.ReadAll(resp.Body)).To(Equal([]byte("signed with v2")))
+		})
+
+		It("rejects wrong secrets", func(ctx context.Context) {
+			client := app.MinioClient(ctx, "admin", func(opts *minio.Options) {
+				user := lo.Must(app.ManagementBackend(ctx).GetUserByName(ctx, "admin"))
+				opts.Creds = minioCreds.NewStaticV2(user.AccessKeys[0].AccessKeyID, "wrong", "")
+			})
+
+			_, err := client.StatObject(ctx, app.BucketName(), "sigv2.txt", minio.StatObjectOptions{})
+			Expect(err).To(HaveOccurred())
+			Expect(minio.ToErrorResponse(err).StatusCode).To(Equal(http.StatusForbidden))
+		})
+	})
+
+	When("SigV2 is disabled", Ordered, func() {
+		var app *testhelpers.App
+
+		BeforeAll(func() {
+			app = testhelpers.NewApp(func(cfg *core.Config) {
+				cfg.SigV2Enabled = false
+			})
+		})
+
+		AfterAll(func(ctx context.Context) {
+			app.Stop(ctx)
+		})
+
+		It("treats signed requests as anonymous", func(ctx context.Context) {
+			_, err := sigV2Client(ctx, app, "admin").StatObject(ctx, app.BucketName(), "missing.txt",
+				minio.StatObjectOptions{})
+			Expect(err).To(HaveOccurred())
+			Expect(minio.ToErrorResponse(err).StatusCode).To(Equal(http.StatusForbidden))
+		})
+	})
+})
@@ -0,0 +1,238 @@
+package conformance_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/base64"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/sse"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server-side encryption", Label("conformance"), Label("api-sse"), Ordered, func() {
+	var (
+		app      *testhelpers.App
+		s3Client *s3.Client
+		bucket   *string
+	)
+
+	customerKey := bytes.Repeat([]byte{42}, sse.KeySize)
+	customerKeyMD5 := md5.Sum(customerKey) //nolint:gosec
+	sseCKey := lo.ToPtr(base64.StdEncoding.EncodeToString(customerKey))
+	sseCKeyMD5 := lo.ToPtr(base64.StdEncoding.EncodeToString(customerKeyMD5[:]))
+	content := bytes.Repeat([]byte("encrypted at rest "), 5_000)
+
+	read := func(body io.ReadCloser) []byte {
+		defer body.Close()
+
+		return lo.Must(io.ReadAll(body))
+	}
+
+	BeforeAll(func(ctx context.Context) {
+		app = testhelpers.NewApp(func(cfg *core.Config) { //nolint:contextcheck
+			cfg.SSEMasterKey = "test:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, sse.KeySize))
+			// The test app serves plain HTTP.
+			cfg.SSECustomerKeysOverHTTP = true
+		})
+		s3Client = app.S3Client(ctx, "admin")
+		bucket = lo.ToPtr(app.BucketName())
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	It("encrypts objects with SSE-S3 and reads ranges of them", func(ctx context.Context) {
+		lo.Must(s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:               bucket,
+			Key:                  lo.ToPtr("sse-s3.txt"),
+			Body:                 bytes.NewReader(content),
+			ServerSideEncryption: types.ServerSideEncryptionAes256,
+		}))
+
+		head := lo.Must(s3Client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: bucket, Key: lo.ToPtr("sse-s3.txt")}))
+		Expect(head.ServerSideEncryption).To(Equal(types.ServerSideEncryptionAes256))
+
+		output := lo.Must(s3Client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: bucket,
+			Key:    lo.ToPtr("sse-s3.txt"),
+			Range:  lo.ToPtr("bytes=70000-70099"),
+		}))
+		Expect(output.ServerSideEncryption).To(Equal(types.ServerSideEncryptionAes256))
+		Expect(read(output.Body)).To(Equal(content[70000:70100]))
+	})
+
+	It("requires the customer key to read SSE-C objects", func(ctx context.Context) {
+		lo.Must(s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:               bucket,
+			Key:                  lo.ToPtr("sse-c.txt"),
+			Body:                 bytes.NewReader(content),
+			SSECustomerAlgorithm: lo.ToPtr(core.SSEAlgorithmAES256),
+			SSECustomerKey:       sseCKey,
+			SSECustomerKeyMD5:    sseCKeyMD5,
+		}))
+
+		_, err := s3Client.GetObject(ctx, &s3.GetObjectInput{Bucket: bucket, Key: lo.ToPtr("sse-c.txt")})
+		Expect(err).To(HaveOccurred())
+
+		output := lo.Must(s3Client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket:               bucket,
+			Key:                  lo.ToPtr("sse-c.txt"),
+			SSECustomerAlgorithm: lo.ToPtr(core.SSEAlgorithmAES256),
+			SSECustomerKey:       sseCKey,
+			SSECustomerKeyMD5:    sseCKeyMD5,
+		}))
+		Expect(*output.SSECustomerKeyMD5).To(Equal(*sseCKeyMD5))
+		Expect(read(output.Body)).To(Equal(content))
+	})
+
+	It("copies SSE-C objects with the key of the source", func(ctx context.Context) {
+		lo.Must(s3Client.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:                         bucket,
+			Key:                            lo.ToPtr("sse-c-copy.txt"),
+			CopySource:                     lo.ToPtr(*bucket + "/sse-c.txt"),
+			CopySourceSSECustomerAlgorithm: lo.ToPtr(core.SSEAlgorithmAES256),
+			CopySourceSSECustomerKey:       sseCKey,
+			CopySourceSSECustomerKeyMD5:    sseCKeyMD5,
+			ServerSideEncryption:           types.ServerSideEncryptionAes256,
+		}))
+
+		output := lo.Must(s3Client.GetObject(ctx, &s3.GetObjectInput{Bucket: bucket, Key: lo.ToPtr("sse-c-copy.txt")}))
+		Expect(output.ServerSideEncryption).To(Equal(types.ServerSideEncryptionAes256))
+		Expect(read(output.Body)).To(Equal(content))
+	})
+
+	It("encrypts multipart uploads with SSE-C", func(ctx context.Context) {
+		key := lo.ToPtr("sse-c-multipart.bin")
+		part := bytes.Repeat([]byte("p"), 5*1024*1024)
+
+		upload := lo.Must(s3Client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+			Bucket:               bucket,
+			Key:                  key,
+			SSECustomerAlgorithm: lo.ToPtr(core.SSEAlgorithmAES256),
+			SSECustomerKey:       sseCKey,
+			SSECustomerKeyMD5:    sseCKeyMD5,
+		}))
+
+		parts := lo.Map([][]byte{part, []byte("tail")}, func(body []byte, i int) types.CompletedPart {
+			output := lo.Must(s3Client.UploadPart(ctx, &s3.UploadPartInput{
+				Bucket:               bucket,
+				Key:                  key,
+				UploadId:             upload.UploadId,
+				PartNumber:           lo.ToPtr(int32(i + 1)), //nolint:gosec
+				Body:                 bytes.NewReader(body),
+				SSECustomerAlgorithm: lo.ToPtr(core.SSEAlgorithmAES256),
+				SSECustomerKey:       sseCKey,
+				SSECustomerKeyMD5:    sseCKeyMD5,
+			}))
+
+			return types.CompletedPart{ETag: output.ETag, PartNumber: lo.ToPtr(int32(i + 1))} //nolint:gosec
+		})
+
+		lo.Must(s3Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          bucket,
+			Key:             key,
+			UploadId:        upload.UploadId,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		}))
+
+		output := lo.Must(s3Client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket:               bucket,
+			Key:                  key,
+			Range:                lo.ToPtr("bytes=5242878-"),
+			SSECustomerAlgorithm: lo.ToPtr(core.SSEAlgorithmAES256),
+			SSECustomerKey:       sseCKey,
+			SSECustomerKeyMD5:    sseCKeyMD5,
+		}))
+		Expect(read(output.Body)).To(Equal([]byte("pptail")))
+	})
+
+	It("applies the default encryption of the bucket", func(ctx context.Context) {
+		_, err := s3Client.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{Bucket: bucket})
+		Expect(err).To(HaveOccurred())
+
+		lo.Must(s3Client.PutBucketEncryption(ctx, &s3.PutBucketEncryptionInput{
+			Bucket: bucket,
+			ServerSideEncryptionConfiguration: &types.ServerSideEncryptionConfiguration{
+				Rules: []types.ServerSideEncryptionRule{{
+					ApplyServerSideEncryptionByDefault: &types.ServerSideEncryptionByDefault{
+						SSEAlgorithm: types.ServerSideEncryptionAes256,
+					},
+				}},
+			},
+		}))
+
+		encryption := lo.Must(s3Client.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{Bucket: bucket}))
+		Expect(encryption.ServerSideEncryptionConfiguration.Rules[0].ApplyServerSideEncryptionByDefault.SSEAlgorithm).
+			To(Equal(types.ServerSideEncryptionAes256))
+
+		lo.Must(s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: bucket,
+			Key:    lo.ToPtr("default.txt"),
+			Body:   bytes.NewReader([]byte("content")),
+		}))
+
+		head := lo.Must(s3Client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: bucket, Key: lo.ToPtr("default.txt")}))
+		Expect(head.ServerSideEncryption).To(Equal(types.ServerSideEncryptionAes256))
+
+		lo.Must(s3Client.DeleteBucketEncryption(ctx, &s3.DeleteBucketEncryptionInput{Bucket: bucket}))
+
+		_, err = s3Client.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{Bucket: bucket})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects KMS encryption", func(ctx context.Context) {
+		_, err := s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:               bucket,
+			Key:                  lo.ToPtr("kms.txt"),
+			Body:                 bytes.NewReader([]byte("content")),
+			ServerSideEncryption: types.ServerSideEncryptionAwsKms,
+		})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Server-side encryption over plain HTTP", Label("conformance"), Label("api-sse"), Ordered, func() {
+	var (
+		app      *testhelpers.App
+		s3Client *s3.Client
+		bucket   *string
+	)
+
+	customerKey := bytes.Repeat([]byte{42}, sse.KeySize)
+	customerKeyMD5 := md5.Sum(customerKey) //nolint:gosec
+
+	BeforeAll(func(ctx context.Context) {
+		app = testhelpers.NewApp()
+		s3Client = app.S3Client(ctx, "admin")
+		bucket = lo.ToPtr(app.BucketName())
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	It("rejects customer-provided keys", func(ctx context.Context) {
+		_, err := s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:               bucket,
+			Key:                  lo.ToPtr("sse-c.txt"),
+			Body:                 bytes.NewReader([]byte("content")),
+			SSECustomerAlgorithm: lo.ToPtr(core.SSEAlgorithmAES256),
+			SSECustomerKey:       lo.ToPtr(base64.StdEncoding.EncodeToString(customerKey)),
+			SSECustomerKeyMD5:    lo.ToPtr(base64.StdEncoding.EncodeToString(customerKeyMD5[:])),
+		})
+		Expect(err).To(MatchError(ContainSubstring("StatusCode: 400")))
+
+		_, err = s3Client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: bucket, Key: lo.ToPtr("sse-c.txt")})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,189 @@
+package conformance_test
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/s3actions"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("STS", Label("conformance"), Label("sts"), Ordered, func() {
+	const roleArn = "arn:aws:iam:::role/sts-writer"
+
+	var (
+		app         *testhelpers.App
+		credentials *ststypes.Credentials
+	)
+
+	sessionClient := func(ctx context.Context, token string) *s3.Client {
+		return app.S3ClientWithCredentials(ctx, *credentials.AccessKeyId, *credentials.SecretAccessKey, token)
+	}
+
+	putObject := func(ctx context.Context, client *s3.Client, key string) error {
+		_, err := client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: lo.ToPtr(app.BucketName()),
+			Key:    lo.ToPtr(key),
+			Body:   strings.NewReader("data"),
+		})
+
+		return err
+	}
+
+	getObject := func(ctx context.Context, client *s3.Client, key string) error {
+		_, err := client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: lo.ToPtr(app.BucketName()),
+			Key:    lo.ToPtr(key),
+		})
+
+		return err
+	}
+
+	BeforeAll(func(ctx context.Context) {
+		app = testhelpers.NewApp() //nolint:contextcheck
+		mgmtBackend := app.ManagementBackend(ctx)
+
+		lo.Must(mgmtBackend.CreateUser(ctx, "sts-user"))
+		lo.Must(mgmtBackend.CreateUser(ctx, "sts-outsider"))
+
+		lo.Must0(mgmtBackend.CreatePolicy(ctx, &iampol.IAMPolicy{
+			ID: "sts-read-write",
+			Statement: []iampol.Statement{{
+				Effect:   iampol.EffectAllow,
+				Action:   []s3actions.Action{s3actions.GetObject, s3actions.PutObject},
+				Resource: []string{"arn:aws:s3:::" + app.BucketName(), "arn:aws:s3:::" + app.BucketName() + "/*"},
+			}},
+		}))
+
+		lo.Must0(mgmtBackend.CreateRole(ctx, &core.Role{
+			Name:         "sts-writer",
+			PolicyIDs:    []string{"sts-read-write"},
+			TrustedUsers: []string{"sts-user"},
+		}))
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	When("a trusted user assumes the role", func() {
+		It("returns temporary credentials", func(ctx context.Context) {
+			output, err := app.STSClient(ctx, "sts-user").AssumeRole(ctx, &sts.AssumeRoleInput{
+				RoleArn:         lo.ToPtr(roleArn),
+				RoleSessionName: lo.ToPtr("ci-run"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			credentials = output.Credentials
+			Expect(*credentials.AccessKeyId).To(HavePrefix("ASIA"))
+			Expect(*credentials.SessionToken).NotTo(BeEmpty())
+			Expect(*credentials.Expiration).To(BeTemporally("~", time.Now().Add(time.Hour), time.Minute))
+			Expect(*output.AssumedRoleUser.Arn).To(Equal("arn:aws:sts:::assumed-role/sts-writer/ci-run"))
+		})
+
+		It("grants the role's permissions", func(ctx context.Context) {
+			client := sessionClient(ctx, *credentials.SessionToken)
+
+			Expect(putObject(ctx, client, "sts/object.txt")).To(Succeed())
+			Expect(getObject(ctx, client, "sts/object.txt")).To(Succeed())
+		})
+
+		It("grants nothing beyond the role's permissions", func(ctx context.Context) {
+			_, err := sessionClient(ctx, *credentials.SessionToken).ListBuckets(ctx, &s3.ListBucketsInput{})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects requests with a wrong session token", func(ctx context.Context) {
+			Expect(getObject(ctx, sessionClient(ctx, "wrong-token"), "sts/object.txt")).To(HaveOccurred())
+		})
+
+		It("rejects requests without a session token", func(ctx context.Context) {
+			Expect(getObject(ctx, sessionClient(ctx, ""), "sts/object.txt")).To(HaveOccurred())
+		})
+
+		It("does not allow the temporary credentials to assume roles", func(ctx context.Context) {
+			client := app.STSClientWithCredentials(ctx,
+				*credentials.AccessKeyId, *credentials.SecretAccessKey, *credentials.SessionToken)
+
+			_, err := client.AssumeRole(ctx, &sts.AssumeRoleInput{
+				RoleArn:         lo.ToPtr(roleArn),
+				RoleSessionName: lo.ToPtr("chained"),
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("the request is signed for S3 rather than STS", func() {
+		It("is rejected by the STS handler", func(ctx context.Context) {
+			_, err := app.STSClient(ctx, "sts-user").AssumeRole(ctx, &sts.AssumeRoleInput{
+				RoleArn:         lo.ToPtr(roleArn),
+				RoleSessionName: lo.ToPtr("s3-signed"),
+			}, sts.WithSigV4SigningName("s3"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("a session policy is passed", func() {
+		It("intersects it with the role's policies", func(ctx context.Context) {
+			output := lo.Must(app.STSClient(ctx, "sts-user").AssumeRole(ctx, &sts.AssumeRoleInput{
+				RoleArn:         lo.ToPtr(roleArn),
+				RoleSessionName: lo.ToPtr("read-only"),
+				Policy: lo.ToPtr(`{
+					"Version": "2012-10-17",
+					"Statement": [{"Effect": "Allow", "Action": ["s3:GetObject"], "Resource": ["arn:aws:s3:::*"]}]
+				}`),
+			}))
+			client := app.S3ClientWithCredentials(ctx, *output.Credentials.AccessKeyId,
+				*output.Credentials.SecretAccessKey, *output.Credentials.SessionToken)
+
+			Expect(getObject(ctx, client, "sts/object.txt")).To(Succeed())
+			Expect(putObject(ctx, client, "sts/other.txt")).To(HaveOccurred())
+		})
+	})
+
+	When("the request is invalid", func() {
+		It("rejects users the role does not trust", func(ctx context.Context) {
+			_, err := app.STSClient(ctx, "sts-outsider").AssumeRole(ctx, &sts.AssumeRoleInput{
+				RoleArn:         lo.ToPtr(roleArn),
+				RoleSessionName: lo.ToPtr("outsider"),
+			})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects unknown roles", func(ctx context.Context) {
+			_, err := app.STSClient(ctx, "admin").AssumeRole(ctx, &sts.AssumeRoleInput{
+				RoleArn:         lo.ToPtr("arn:aws:iam:::role/missing"),
+				RoleSessionName: lo.ToPtr("admin"),
+			})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects durations out of bounds", func(ctx context.Context) {
+			_, err := app.STSClient(ctx, "sts-user").AssumeRole(ctx, &sts.AssumeRoleInput{
+				RoleArn:         lo.ToPtr(roleArn),
+				RoleSessionName: lo.ToPtr("too-long"),
+				DurationSeconds: aws.Int32(int32((13 * time.Hour).Seconds())),
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("the role is deleted", func() {
+		It("revokes its sessions", func(ctx context.Context) {
+			lo.Must0(app.ManagementBackend(ctx).DeleteRole(ctx, "sts-writer"))
+
+			Expect(getObject(ctx, sessionClient(ctx, *credentials.SessionToken), "sts/object.txt")).To(HaveOccurred())
+		})
+	})
+})
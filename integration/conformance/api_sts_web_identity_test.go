@@ -0,0 +1,163 @@
+package conformance_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/json"
+	"github.com/zhulik/d3/pkg/jwt"
+	"github.com/zhulik/d3/pkg/s3actions"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("STS web identity", Label("conformance"), Label("sts"), Ordered, func() {
+	const (
+		roleArn  = "arn:aws:iam:::role/ci"
+		issuer   = "https://ci.example.com"
+		audience = "d3"
+	)
+
+	var (
+		app *testhelpers.App
+		key *ecdsa.PrivateKey
+	)
+
+	token := func(claims jwt.Claims) string {
+		return lo.Must(jwt.Sign(lo.Assign(jwt.Claims{
+			"iss":        issuer,
+			"aud":        audience,
+			"sub":        "repo:org/app:ref:refs/heads/main",
+			"repository": "org/app",
+			"exp":        time.Now().Add(time.Hour).Unix(),
+		}, claims), "ci-key", key))
+	}
+
+	assumeRole := func(ctx context.Context, webIdentityToken string) (*sts.AssumeRoleWithWebIdentityOutput, error) {
+		return app.STSClientWithCredentials(ctx, "", "", "").AssumeRoleWithWebIdentity(ctx,
+			&sts.AssumeRoleWithWebIdentityInput{
+				RoleArn:          lo.ToPtr(roleArn),
+				RoleSessionName:  lo.ToPtr("build-42"),
+				WebIdentityToken: lo.ToPtr(webIdentityToken),
+			})
+	}
+
+	BeforeAll(func(ctx context.Context) {
+		key = lo.Must(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+
+		jwksPath := filepath.Join(GinkgoT().TempDir(), "jwks.json")
+		lo.Must0(json.MarshalToFile(jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{
+			lo.Must(jwt.NewJSONWebKey("ci-key", &key.PublicKey)),
+		}}, jwksPath))
+
+		app = testhelpers.NewApp(func(cfg *core.Config) { //nolint:contextcheck
+			cfg.WebIdentityJWKS = jwksPath
+			cfg.WebIdentityIssuer = issuer
+			cfg.WebIdentityAudience = audience
+		})
+		mgmtBackend := app.ManagementBackend(ctx)
+
+		lo.Must0(mgmtBackend.CreatePolicy(ctx, &iampol.IAMPolicy{
+			ID: "ci-upload",
+			Statement: []iampol.Statement{{
+				Effect:   iampol.EffectAllow,
+				Action:   []s3actions.Action{s3actions.PutObject},
+				Resource: []string{"arn:aws:s3:::" + app.BucketName()},
+			}},
+		}))
+
+		lo.Must0(mgmtBackend.CreateRole(ctx, &core.Role{
+			Name:      "ci",
+			PolicyIDs: []string{"ci-upload"},
+			WebIdentityConditions: []map[string]string{
+				{"repository": "org/app", "sub": "repo:org/app:ref:refs/heads/*"},
+			},
+		}))
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	When("the token matches a condition of the role", func() {
+		It("issues credentials usable with S3", func(ctx context.Context) {
+			output, err := assumeRole(ctx, token(nil))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*output.SubjectFromWebIdentityToken).To(Equal("repo:org/app:ref:refs/heads/main"))
+			Expect(*output.AssumedRoleUser.Arn).To(Equal("arn:aws:sts:::assumed-role/ci/build-42"))
+
+			client := app.S3ClientWithCredentials(ctx, *output.Credentials.AccessKeyId,
+				*output.Credentials.SecretAccessKey, *output.Credentials.SessionToken)
+
+			_, err = client.PutObject(ctx, &s3.PutObjectInput{
+				Bucket: lo.ToPtr(app.BucketName()),
+				Key:    lo.ToPtr("ci/artifact.txt"),
+				Body:   strings.NewReader("data"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	DescribeTable("rejects tokens it does not trust",
+		func(ctx context.Context, webIdentityToken func() string) {
+			_, err := assumeRole(ctx, webIdentityToken())
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("from another repository", func() string {
+			return token(jwt.Claims{"repository": "org/other"})
+		}),
+		Entry("from a tag", func() string {
+			return token(jwt.Claims{"sub": "repo:org/app:ref:refs/tags/v1"})
+		}),
+		Entry("for another audience", func() string {
+			return token(jwt.Claims{"aud": "sts.amazonaws.com"})
+		}),
+		Entry("from another issuer", func() string {
+			return token(jwt.Claims{"iss": "https://evil.example.com"})
+		}),
+		Entry("expired", func() string {
+			return token(jwt.Claims{"exp": time.Now().Add(-time.Hour).Unix()})
+		}),
+		Entry("signed with another key", func() string {
+			return lo.Must(jwt.Sign(jwt.Claims{
+				"iss": issuer, "aud": audience, "repository": "org/app", "sub": "repo:org/app:ref:refs/heads/main",
+				"exp": time.Now().Add(time.Hour).Unix(),
+			}, "ci-key", lo.Must(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))))
+		}),
+	)
+})
+
+var _ = Describe("STS web identity without a provider", Label("conformance"), Label("sts"), Ordered, func() {
+	var app *testhelpers.App
+
+	BeforeAll(func() {
+		app = testhelpers.NewApp()
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	It("rejects AssumeRoleWithWebIdentity", func(ctx context.Context) {
+		_, err := app.STSClientWithCredentials(ctx, "", "", "").AssumeRoleWithWebIdentity(ctx,
+			&sts.AssumeRoleWithWebIdentityInput{
+				RoleArn:          lo.ToPtr("arn:aws:iam:::role/ci"),
+				RoleSessionName:  lo.ToPtr("build-42"),
+				WebIdentityToken: lo.ToPtr("token"),
+			})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("StatusCode: 400"))
+	})
+})
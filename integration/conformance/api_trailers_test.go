@@ -0,0 +1,56 @@
+package conformance_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Streaming trailers", Label("conformance"), Label("api-trailers"), Ordered, func() {
+	var (
+		app         *testhelpers.App
+		minioClient *minio.Client
+		bucketName  string
+	)
+
+	content := bytes.Repeat([]byte("trailer"), 20000)
+
+	BeforeAll(func(ctx context.Context) {
+		app = testhelpers.NewApp() //nolint:contextcheck
+		minioClient = app.MinioClient(ctx, "admin", func(opts *minio.Options) {
+			opts.TrailingHeaders = true
+		})
+		bucketName = app.BucketName()
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	DescribeTable("PutObject with a trailing checksum",
+		func(ctx context.Context, key string, checksum minio.ChecksumType, disableContentSha256 bool) {
+			lo.Must(minioClient.PutObject(ctx, bucketName, key, bytes.NewReader(content), int64(len(content)),
+				minio.PutObjectOptions{
+					Checksum:             checksum,
+					DisableContentSha256: disableContentSha256,
+					DisableMultipart:     true,
+				}))
+
+			object := lo.Must(minioClient.GetObject(ctx, bucketName, key, minio.GetObjectOptions{}))
+			defer object.Close()
+
+			Expect(io.ReadAll(object)).To(Equal(content))
+		},
+		Entry("signed with crc32c", "signed-crc32c.txt", minio.ChecksumCRC32C, false),
+		Entry("signed with sha256", "signed-sha256.txt", minio.ChecksumSHA256, false),
+		Entry("unsigned with crc32", "unsigned-crc32.txt", minio.ChecksumCRC32, true),
+		Entry("unsigned with crc64nvme", "unsigned-crc64nvme.txt", minio.ChecksumCRC64NVME, true),
+	)
+})
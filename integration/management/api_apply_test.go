@@ -0,0 +1,140 @@
+package management_test
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+	"github.com/zhulik/d3/internal/backends/management/yaml"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/s3actions"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Apply API", Label("management"), Label("api-apply"), Ordered, func() {
+	var (
+		client *apiclient.Client
+		app    *testhelpers.App
+	)
+
+	readPolicy := func(bucket string) *iampol.IAMPolicy {
+		return &iampol.IAMPolicy{Statement: []iampol.Statement{{
+			Effect:   iampol.EffectAllow,
+			Action:   []s3actions.Action{s3actions.GetObject},
+			Resource: []string{"arn:aws:s3:::" + bucket + "/*"},
+		}}}
+	}
+
+	ciKey := core.AccessKey{AccessKeyID: "AKIAAPPLYCI", SecretAccessKey: "apply-ci-secret"}
+
+	desired := func() *yaml.ManagementConfig {
+		return &yaml.ManagementConfig{
+			Users: map[string]*core.User{
+				"apply-ci":  {AccessKeys: []core.AccessKey{ciKey}},
+				"apply-dev": {},
+			},
+			Policies: map[string]*iampol.IAMPolicy{
+				"apply-read": readPolicy("apply-bucket"),
+			},
+			Bindings: []*core.PolicyBinding{
+				{UserName: "apply-ci", PolicyID: "apply-read"},
+			},
+		}
+	}
+
+	summarize := func(changes []core.ManagementChange) []string {
+		return lo.Map(changes, func(change core.ManagementChange, _ int) string {
+			return string(change.Operation) + " " + string(change.Kind) + " " + change.Name
+		})
+	}
+
+	BeforeAll(func(ctx context.Context) {
+		app = testhelpers.NewApp() //nolint:contextcheck
+		client = app.ManagementClient(ctx)
+
+		lo.Must(client.CreateUser(ctx, "apply-legacy"))
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	It("plans the changes without applying them on a dry run", func(ctx context.Context) {
+		changes := lo.Must(client.Apply(ctx, desired(), true, false))
+		Expect(summarize(changes)).To(Equal([]string{
+			"create user apply-ci",
+			"create user apply-dev",
+			"create policy apply-read",
+			"create binding apply-ci/apply-read",
+		}))
+		Expect(changes).To(HaveEach(HaveField("GeneratedAccessKey", BeNil())))
+
+		Expect(lo.Must(client.ListUsers(ctx))).NotTo(ContainElement("apply-ci"))
+	})
+
+	It("applies the changes and returns generated keys", func(ctx context.Context) {
+		changes := lo.Must(client.Apply(ctx, desired(), false, false))
+		Expect(changes).To(HaveLen(4))
+		Expect(changes[1].GeneratedAccessKey).NotTo(BeNil())
+
+		Expect(lo.Must(client.ListUsers(ctx))).To(ContainElements("apply-ci", "apply-dev", "apply-legacy"))
+		Expect(lo.Must(client.ListAccessKeys(ctx, "apply-ci"))).To(ConsistOf(
+			HaveField("AccessKeyID", ciKey.AccessKeyID),
+		))
+		Expect(lo.Must(client.ListBindings(ctx))).To(ContainElement(
+			core.PolicyBinding{UserName: "apply-ci", PolicyID: "apply-read"},
+		))
+	})
+
+	It("is idempotent", func(ctx context.Context) {
+		Expect(lo.Must(client.Apply(ctx, desired(), false, false))).To(BeEmpty())
+	})
+
+	It("updates changed users and policies", func(ctx context.Context) {
+		cfg := desired()
+		cfg.Users["apply-ci"].AccessKeys[0].Status = core.AccessKeyStatusInactive
+		cfg.Policies["apply-read"] = readPolicy("other-bucket")
+
+		Expect(summarize(lo.Must(client.Apply(ctx, cfg, false, false)))).To(Equal([]string{
+			"update user apply-ci",
+			"update policy apply-read",
+		}))
+
+		Expect(lo.Must(client.GetPolicy(ctx, "apply-read")).Statement[0].Resource).To(
+			Equal([]string{"arn:aws:s3:::other-bucket/*"}),
+		)
+	})
+
+	It("deletes undeclared resources when pruning", func(ctx context.Context) {
+		cfg := desired()
+		cfg.Bindings = nil
+		delete(cfg.Users, "apply-ci")
+
+		Expect(summarize(lo.Must(client.Apply(ctx, cfg, false, true)))).To(Equal([]string{
+			"delete binding apply-ci/apply-read",
+			"update policy apply-read",
+			"delete user apply-ci",
+			"delete user apply-legacy",
+		}))
+
+		Expect(lo.Must(client.ListUsers(ctx))).To(ConsistOf("apply-dev", "admin"))
+		Expect(lo.Must(client.ListBindings(ctx))).To(BeEmpty())
+	})
+
+	DescribeTable("rejects invalid configs",
+		func(ctx context.Context, cfg *yaml.ManagementConfig) {
+			_, err := client.Apply(ctx, cfg, true, false)
+			Expect(err).To(MatchError(apiclient.ErrUnexpectedStatus))
+		},
+		Entry("reserved user", &yaml.ManagementConfig{Users: map[string]*core.User{"admin": {}}}),
+		Entry("mismatched user name", &yaml.ManagementConfig{Users: map[string]*core.User{"a": {Name: "b"}}}),
+		Entry("invalid policy", &yaml.ManagementConfig{Policies: map[string]*iampol.IAMPolicy{"empty": {}}}),
+		Entry("access key without secret", &yaml.ManagementConfig{Users: map[string]*core.User{
+			"a": {AccessKeys: []core.AccessKey{{AccessKeyID: "AKIA"}}},
+		}}),
+	)
+})
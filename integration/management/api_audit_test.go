@@ -0,0 +1,201 @@
+package management_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+	"github.com/zhulik/d3/internal/backends/storage/folder"
+	"github.com/zhulik/d3/internal/backends/storage/storagetest"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/core"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Audit API", Label("management"), Label("api-audit"), Ordered, func() {
+	var (
+		client *apiclient.Client
+		app    *testhelpers.App
+		start  time.Time
+	)
+
+	query := func(ctx context.Context, q core.AuditQuery) []core.AuditEvent {
+		return lo.Must(client.QueryAudit(ctx, q))
+	}
+
+	BeforeAll(func(ctx context.Context) {
+		auditPath := filepath.Join(GinkgoT().TempDir(), "audit.jsonl")
+
+		app = testhelpers.NewApp(func(cfg *core.Config) { //nolint:contextcheck
+			cfg.AuditLogSink = core.AuditLogSinkFile
+			cfg.AuditLogFilePath = auditPath
+			cfg.AuditLogFileMaxSize = 1 << 20
+			cfg.AuditS3Actions = []string{"s3:DeleteObject", "s3:CopyObject"}
+		})
+		client = app.ManagementClient(ctx)
+		start = time.Now().Add(-time.Second)
+
+		lo.Must(client.CreateUser(ctx, "audit-user"))
+
+		s3Client := app.S3Client(ctx, "admin")
+		lo.Must(s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: lo.ToPtr(app.BucketName()),
+			Key:    lo.ToPtr("original.txt"),
+			Body:   strings.NewReader("audited"),
+		}))
+		lo.Must(s3Client.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:     lo.ToPtr(app.BucketName()),
+			Key:        lo.ToPtr("copy.txt"),
+			CopySource: lo.ToPtr(app.BucketName() + "/original.txt"),
+		}))
+		lo.Must(s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: lo.ToPtr(app.BucketName()),
+			Key:    lo.ToPtr("copy.txt"),
+		}))
+
+		_, err := app.S3Client(ctx, "audit-user").DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: lo.ToPtr(app.BucketName()),
+			Key:    lo.ToPtr("original.txt"),
+		})
+		Expect(err).To(HaveOccurred())
+
+		lo.Must(client.UpdateUser(ctx, "audit-user")) //nolint:staticcheck
+
+		lo.Must0(client.SetBucketQuota(ctx, app.BucketName(), core.BucketQuota{MaxObjects: 10}))
+		lo.Must(client.RescanBucketUsage(ctx, app.BucketName()))
+		lo.Must0(client.DeleteBucketQuota(ctx, app.BucketName()))
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	It("records management changes", func(ctx context.Context) {
+		events := query(ctx, core.AuditQuery{Action: "d3:CreateUser"})
+		Expect(events).To(ConsistOf(And(
+			HaveField("User", "admin"),
+			HaveField("Resource", "/users/audit-user"),
+			HaveField("Outcome", core.AuditOutcomeSuccess),
+		)))
+	})
+
+	It("records key rotations", func(ctx context.Context) {
+		Expect(query(ctx, core.AuditQuery{Action: "d3:RotateUserKeys"})).To(ConsistOf(And(
+			HaveField("User", "admin"),
+			HaveField("Resource", "/users/audit-user"),
+			HaveField("Outcome", core.AuditOutcomeSuccess),
+		)))
+	})
+
+	DescribeTable("records bucket quota changes", func(ctx context.Context, action, resource string) {
+		Expect(query(ctx, core.AuditQuery{Action: action})).To(ConsistOf(And(
+			HaveField("User", "admin"),
+			HaveField("Resource", "/buckets/"+app.BucketName()+resource),
+			HaveField("Outcome", core.AuditOutcomeSuccess),
+		)))
+	},
+		Entry("setting the quota", "d3:PutBucketQuota", "/quota"),
+		Entry("rescanning the usage", "d3:RescanBucketUsage", "/usage/rescan"),
+		Entry("removing the quota", "d3:DeleteBucketQuota", "/quota"),
+	)
+
+	It("records copies with their source", func(ctx context.Context) {
+		Expect(query(ctx, core.AuditQuery{Action: "s3:CopyObject"})).To(ConsistOf(And(
+			HaveField("Resource", app.BucketName()+"/copy.txt"),
+			HaveField("Source", app.BucketName()+"/original.txt"),
+		)))
+	})
+
+	It("does not record unlisted actions", func(ctx context.Context) {
+		Expect(query(ctx, core.AuditQuery{Action: "s3:PutObject"})).To(BeEmpty())
+	})
+
+	It("records denied requests", func(ctx context.Context) {
+		Expect(query(ctx, core.AuditQuery{User: "audit-user"})).To(ConsistOf(And(
+			HaveField("Action", "s3:DeleteObject"),
+			HaveField("Resource", app.BucketName()+"/original.txt"),
+			HaveField("Outcome", core.AuditOutcomeDenied),
+			HaveField("Status", 403),
+		)))
+	})
+
+	It("filters by time range and limits the result", func(ctx context.Context) {
+		Expect(query(ctx, core.AuditQuery{From: start, Limit: 2})).To(HaveLen(2))
+		Expect(query(ctx, core.AuditQuery{To: start})).To(BeEmpty())
+		Expect(query(ctx, core.AuditQuery{From: start, Action: "s3:DeleteObject"})).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("Audit log bucket", Label("management"), Label("api-audit"), Ordered, func() {
+	const auditBucket = "audit-log"
+
+	var (
+		app      *testhelpers.App
+		s3Client *s3.Client
+	)
+
+	BeforeAll(func(ctx context.Context) {
+		app = testhelpers.NewApp(func(cfg *core.Config) { //nolint:contextcheck
+			cfg.AuditLogSink = core.AuditLogSinkBucket
+			cfg.AuditLogBucket = auditBucket
+			cfg.AuditLogBucketFlushInterval = 100 * time.Millisecond
+			cfg.AuditS3Actions = []string{"s3:DeleteObject"}
+
+			// The sink requires an existing bucket.
+			backend := &folder.Backend{
+				Cfg:    &core.Config{FolderStorageBackendPath: cfg.FolderStorageBackendPath},
+				Locker: storagetest.NewLocker(),
+			}
+			lo.Must0(backend.Init(ctx))
+			lo.Must0(backend.CreateBucket(ctx, auditBucket))
+		})
+		s3Client = app.S3Client(ctx, "admin")
+
+		lo.Must(s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: lo.ToPtr(app.BucketName()),
+			Key:    lo.ToPtr("audited.txt"),
+			Body:   strings.NewReader("audited"),
+		}))
+		lo.Must(s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: lo.ToPtr(app.BucketName()),
+			Key:    lo.ToPtr("audited.txt"),
+		}))
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	It("can be read", func(ctx context.Context) {
+		Eventually(func(ctx context.Context) []types.Object {
+			return lo.Must(s3Client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: lo.ToPtr(auditBucket)})).Contents
+		}).WithContext(ctx).WithTimeout(5 * time.Second).ShouldNot(BeEmpty())
+	})
+
+	It("cannot be changed, even by the admin", func(ctx context.Context) {
+		listed := lo.Must(s3Client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: lo.ToPtr(auditBucket)}))
+
+		_, err := s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: lo.ToPtr(auditBucket),
+			Key:    listed.Contents[0].Key,
+		})
+		Expect(err).To(MatchError(ContainSubstring("StatusCode: 403")))
+
+		_, err = s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: lo.ToPtr(auditBucket),
+			Key:    listed.Contents[0].Key,
+			Body:   strings.NewReader("forged"),
+		})
+		Expect(err).To(MatchError(ContainSubstring("StatusCode: 403")))
+
+		_, err = s3Client.DeleteBucket(ctx, &s3.DeleteBucketInput{Bucket: lo.ToPtr(auditBucket)})
+		Expect(err).To(MatchError(ContainSubstring("StatusCode: 403")))
+	})
+})
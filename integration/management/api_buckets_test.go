@@ -0,0 +1,206 @@
+package management_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/core"
+	. "github.com/zhulik/d3/pkg/ginkgohelpers"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Buckets API", Label("management"), Label("api-buckets"), Ordered, func() {
+	var (
+		client *apiclient.Client
+		app    *testhelpers.App
+	)
+
+	BeforeAll(func(ctx context.Context) {
+		app = testhelpers.NewApp() //nolint:contextcheck
+		client = app.ManagementClient(ctx)
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	When("the compression is not overridden", func() {
+		It("returns an empty override", func(ctx context.Context) {
+			Expect(client.GetBucketCompression(ctx, app.BucketName())).To(Equal(core.BucketCompression{}))
+		})
+	})
+
+	When("the compression is overridden", func() {
+		compression := core.BucketCompression{Algorithm: core.CompressionZstd}
+
+		It("returns the override and compresses new objects transparently", func(ctx context.Context) {
+			lo.Must0(client.SetBucketCompression(ctx, app.BucketName(), compression))
+			Expect(client.GetBucketCompression(ctx, app.BucketName())).To(Equal(compression))
+
+			s3Client := app.S3Client(ctx, "admin")
+			content := bytes.Repeat([]byte(`{"level":"info","message":"compressible"}`+"\n"), 10_000)
+
+			lo.Must(s3Client.PutObject(ctx, &s3.PutObjectInput{
+				Bucket: lo.ToPtr(app.BucketName()),
+				Key:    lo.ToPtr("logs.json"),
+				Body:   bytes.NewReader(content),
+			}))
+
+			output := lo.Must(s3Client.GetObject(ctx, &s3.GetObjectInput{
+				Bucket: lo.ToPtr(app.BucketName()),
+				Key:    lo.ToPtr("logs.json"),
+				Range:  lo.ToPtr("bytes=100000-100099"),
+			}))
+			defer output.Body.Close()
+
+			Expect(io.ReadAll(output.Body)).To(Equal(content[100000:100100]))
+		})
+
+		It("rejects unknown algorithms and buckets", func(ctx context.Context) {
+			err := client.SetBucketCompression(ctx, app.BucketName(), core.BucketCompression{Algorithm: "lz4"})
+			Expect(err).To(MatchError(apiclient.ErrUnexpectedStatus))
+
+			Expect(client.SetBucketCompression(ctx, "no-such-bucket", compression)).
+				To(MatchError(apiclient.ErrUnexpectedStatus))
+		})
+
+		It("removes the override", func(ctx context.Context) {
+			lo.Must0(client.DeleteBucketCompression(ctx, app.BucketName()))
+			Expect(client.GetBucketCompression(ctx, app.BucketName())).To(Equal(core.BucketCompression{}))
+		})
+	})
+
+	When("the bucket has a quota", func() {
+		bucket := lo.ToPtr("quota-bucket")
+
+		var s3Client *s3.Client
+
+		put := func(ctx context.Context, key string) error {
+			_, err := s3Client.PutObject(ctx, &s3.PutObjectInput{
+				Bucket: bucket,
+				Key:    lo.ToPtr(key),
+				Body:   bytes.NewReader([]byte("content")),
+			})
+
+			return err
+		}
+
+		BeforeAll(func(ctx context.Context) {
+			s3Client = app.S3Client(ctx, "admin")
+			lo.Must(s3Client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: bucket}))
+		})
+
+		It("enforces the quota and reports the usage", func(ctx context.Context) {
+			quota := core.BucketQuota{MaxBytes: 1_000, MaxObjects: 1}
+			lo.Must0(client.SetBucketQuota(ctx, *bucket, quota))
+			Expect(client.GetBucketQuota(ctx, *bucket)).To(Equal(quota))
+
+			Expect(put(ctx, "first.txt")).To(Succeed())
+			Expect(put(ctx, "second.txt")).To(BeS3HttpError(403))
+
+			// The SDK does not expose the error body, send the request without it to read the code.
+			presigned := lo.Must(s3.NewPresignClient(s3Client).PresignPutObject(ctx, &s3.PutObjectInput{
+				Bucket: bucket,
+				Key:    lo.ToPtr("second.txt"),
+			}))
+			req := lo.Must(http.NewRequestWithContext(ctx, presigned.Method, presigned.URL, strings.NewReader("content")))
+			resp := lo.Must(http.DefaultClient.Do(req))
+
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusForbidden))
+			Expect(io.ReadAll(resp.Body)).To(ContainSubstring("QuotaExceeded"))
+
+			Expect(client.GetBucketUsage(ctx, *bucket)).To(Equal(&core.BucketUsage{Bytes: 7, Objects: 1}))
+			Expect(client.RescanBucketUsage(ctx, *bucket)).To(Equal(&core.BucketUsage{Bytes: 7, Objects: 1}))
+		})
+
+		It("rejects invalid quotas", func(ctx context.Context) {
+			err := client.SetBucketQuota(ctx, *bucket, core.BucketQuota{MaxObjects: -1})
+			Expect(err).To(MatchError(apiclient.ErrUnexpectedStatus))
+		})
+
+		It("removes the quota", func(ctx context.Context) {
+			lo.Must0(client.DeleteBucketQuota(ctx, *bucket))
+			Expect(client.GetBucketQuota(ctx, *bucket)).To(Equal(core.BucketQuota{}))
+
+			Expect(put(ctx, "second.txt")).To(Succeed())
+		})
+	})
+})
+
+var _ = Describe("Storage roots API", Label("management"), Label("api-storage-roots"), Ordered, func() {
+	var (
+		client    *apiclient.Client
+		app       *testhelpers.App
+		s3Client  *s3.Client
+		extraRoot string
+	)
+
+	bucket := lo.ToPtr("moved-bucket")
+
+	BeforeAll(func(ctx context.Context) {
+		extraRoot = lo.Must(os.MkdirTemp("/tmp", "d3-extra-"))
+		DeferCleanup(func() { _ = os.RemoveAll(extraRoot) })
+
+		app = testhelpers.NewApp(func(cfg *core.Config) { //nolint:contextcheck
+			cfg.FolderStorageExtraPaths = []string{extraRoot}
+			cfg.FolderStorageBucketRoots = map[string]string{*bucket: cfg.FolderStorageBackendPath}
+		})
+		client = app.ManagementClient(ctx)
+		s3Client = app.S3Client(ctx, "admin")
+
+		lo.Must(s3Client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: bucket}))
+		lo.Must(s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: bucket,
+			Key:    lo.ToPtr("object.txt"),
+			Body:   bytes.NewReader([]byte("content")),
+		}))
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	rootOf := func(ctx context.Context, name string) string {
+		roots := lo.Must(client.GetStorageRoots(ctx))
+
+		root, _ := lo.Find(roots, func(root core.StorageRoot) bool { return lo.Contains(root.Buckets, name) })
+
+		return root.Path
+	}
+
+	It("lists the roots with their buckets", func(ctx context.Context) {
+		roots := lo.Must(client.GetStorageRoots(ctx))
+		Expect(roots).To(HaveLen(2))
+		Expect(roots[1].Path).To(Equal(extraRoot))
+		Expect(roots[0].FreeBytes).To(BeNumerically(">", 0))
+
+		Expect(rootOf(ctx, *bucket)).To(Equal(roots[0].Path))
+	})
+
+	It("moves a bucket with its objects", func(ctx context.Context) {
+		lo.Must0(client.MoveBucket(ctx, *bucket, extraRoot))
+		Expect(rootOf(ctx, *bucket)).To(Equal(extraRoot))
+
+		output := lo.Must(s3Client.GetObject(ctx, &s3.GetObjectInput{Bucket: bucket, Key: lo.ToPtr("object.txt")}))
+		defer output.Body.Close()
+
+		Expect(io.ReadAll(output.Body)).To(Equal([]byte("content")))
+	})
+
+	It("rejects unknown roots", func(ctx context.Context) {
+		err := client.MoveBucket(ctx, *bucket, "/nonexistent")
+		Expect(err).To(MatchError(apiclient.ErrUnexpectedStatus))
+	})
+})
@@ -0,0 +1,107 @@
+package management_test
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/s3actions"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Groups API", Label("management"), Label("api-groups"), Ordered, func() {
+	var (
+		client *apiclient.Client
+		app    *testhelpers.App
+	)
+
+	BeforeAll(func(ctx context.Context) {
+		app = testhelpers.NewApp() //nolint:contextcheck
+		client = app.ManagementClient(ctx)
+
+		lo.Must(client.CreateUser(ctx, "group-user"))
+		lo.Must0(client.CreatePolicy(ctx, &iampol.IAMPolicy{
+			ID: "group-policy",
+			Statement: []iampol.Statement{{
+				Effect:   iampol.EffectAllow,
+				Action:   []s3actions.Action{s3actions.GetObject},
+				Resource: []string{"arn:aws:s3:::my-bucket/*"},
+			}},
+		}))
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	When("no groups exist", func() {
+		It("returns empty list", func(ctx context.Context) {
+			Expect(client.ListGroups(ctx)).To(BeEmpty())
+		})
+	})
+
+	When("a group is created", func() {
+		It("is listed", func(ctx context.Context) {
+			lo.Must0(client.CreateGroup(ctx, "developers"))
+
+			Expect(client.ListGroups(ctx)).To(ConsistOf("developers"))
+		})
+
+		It("rejects a duplicate", func(ctx context.Context) {
+			Expect(client.CreateGroup(ctx, "developers")).To(MatchError(apiclient.ErrUnexpectedStatus))
+		})
+	})
+
+	When("a member is added", func() {
+		It("is returned with the group", func(ctx context.Context) {
+			lo.Must0(client.AddGroupMember(ctx, "developers", "group-user"))
+
+			Expect(client.GetGroup(ctx, "developers")).To(Equal(core.Group{
+				Name: "developers", Members: []string{"group-user"},
+			}))
+		})
+
+		It("rejects unknown users", func(ctx context.Context) {
+			err := client.AddGroupMember(ctx, "developers", "nobody")
+			Expect(err).To(MatchError(apiclient.ErrUnexpectedStatus))
+		})
+	})
+
+	When("a policy is bound", func() {
+		It("is listed for the group", func(ctx context.Context) {
+			lo.Must0(client.CreateGroupBinding(ctx, "developers", "group-policy"))
+
+			Expect(client.GetBindingsByGroup(ctx, "developers")).To(ConsistOf(core.GroupBinding{
+				GroupName: "developers", PolicyID: "group-policy",
+			}))
+		})
+
+		It("can be unbound", func(ctx context.Context) {
+			lo.Must0(client.DeleteGroupBinding(ctx, "developers", "group-policy"))
+
+			Expect(client.GetBindingsByGroup(ctx, "developers")).To(BeEmpty())
+		})
+	})
+
+	When("a member is removed", func() {
+		It("is no longer returned with the group", func(ctx context.Context) {
+			lo.Must0(client.RemoveGroupMember(ctx, "developers", "group-user"))
+
+			Expect(lo.Must(client.GetGroup(ctx, "developers")).Members).To(BeEmpty())
+		})
+	})
+
+	When("the group is deleted", func() {
+		It("is no longer found", func(ctx context.Context) {
+			lo.Must0(client.DeleteGroup(ctx, "developers"))
+
+			_, err := client.GetGroup(ctx, "developers")
+			Expect(err).To(MatchError(apiclient.ErrUnexpectedStatus))
+		})
+	})
+})
@@ -0,0 +1,96 @@
+package management_test
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/core"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Limits API", Label("management"), Label("api-limits"), Ordered, func() {
+	var (
+		client *apiclient.Client
+		app    *testhelpers.App
+	)
+
+	BeforeAll(func(ctx context.Context) {
+		app = testhelpers.NewApp() //nolint:contextcheck
+		client = app.ManagementClient(ctx)
+
+		lo.Must(client.CreateUser(ctx, "limited-user"))
+		lo.Must0(client.CreateGroup(ctx, "limited-group"))
+		lo.Must0(client.CreateRole(ctx, &core.Role{Name: "limited-role"}))
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	When("no limits are set", func() {
+		It("returns empty limits", func(ctx context.Context) {
+			Expect(client.GetLimits(ctx, "users", "limited-user")).To(Equal(core.RateLimits{}))
+			Expect(client.GetLimits(ctx, "groups", "limited-group")).To(Equal(core.RateLimits{}))
+			Expect(client.GetLimits(ctx, "roles", "limited-role")).To(Equal(core.RateLimits{}))
+		})
+	})
+
+	When("limits are set", func() {
+		limits := core.RateLimits{
+			RequestsPerSecond:     map[core.RequestClass]float64{core.RequestClassList: 0.01},
+			MaxConcurrentRequests: 4,
+		}
+
+		It("returns them", func(ctx context.Context) {
+			lo.Must0(client.SetLimits(ctx, "users", "limited-user", limits))
+			lo.Must0(client.SetLimits(ctx, "groups", "limited-group", limits))
+			lo.Must0(client.SetLimits(ctx, "roles", "limited-role", limits))
+
+			Expect(client.GetLimits(ctx, "users", "limited-user")).To(Equal(limits))
+			Expect(client.GetLimits(ctx, "groups", "limited-group")).To(Equal(limits))
+			Expect(client.GetLimits(ctx, "roles", "limited-role")).To(Equal(limits))
+		})
+
+		It("rejects invalid limits", func(ctx context.Context) {
+			err := client.SetLimits(ctx, "users", "limited-user", core.RateLimits{MaxConcurrentRequests: -1})
+			Expect(err).To(MatchError(apiclient.ErrUnexpectedStatus))
+		})
+
+		It("rejects unknown users and roles", func(ctx context.Context) {
+			Expect(client.SetLimits(ctx, "users", "nobody", limits)).To(MatchError(apiclient.ErrUnexpectedStatus))
+			Expect(client.SetLimits(ctx, "roles", "nobody", limits)).To(MatchError(apiclient.ErrUnexpectedStatus))
+		})
+
+		It("slows down requests over the limits", func(ctx context.Context) {
+			s3Client := app.S3Client(ctx, "limited-user")
+
+			// The first request is admitted and denied by the authorizer, the user has no policies.
+			_, err := s3Client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: lo.ToPtr(app.BucketName())})
+			Expect(err).To(MatchError(ContainSubstring("StatusCode: 403")))
+
+			_, err = s3Client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: lo.ToPtr(app.BucketName())})
+			Expect(err).To(MatchError(ContainSubstring("StatusCode: 503")))
+
+			Expect(client.GetLimitStats(ctx)).To(ContainElement(And(
+				HaveField("Principal", "limited-user"),
+				HaveField("Admitted", BeEquivalentTo(1)),
+				HaveField("RateLimited", BeNumerically(">=", 1)),
+			)))
+		})
+	})
+
+	When("limits are removed", func() {
+		It("returns empty limits", func(ctx context.Context) {
+			lo.Must0(client.DeleteLimits(ctx, "users", "limited-user"))
+			lo.Must0(client.DeleteLimits(ctx, "roles", "limited-role"))
+
+			Expect(client.GetLimits(ctx, "users", "limited-user")).To(Equal(core.RateLimits{}))
+			Expect(client.GetLimits(ctx, "roles", "limited-role")).To(Equal(core.RateLimits{}))
+		})
+	})
+})
@@ -0,0 +1,78 @@
+package management_test
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/s3actions"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Roles API", Label("management"), Label("api-roles"), Ordered, func() {
+	var (
+		client *apiclient.Client
+		app    *testhelpers.App
+	)
+
+	BeforeAll(func(ctx context.Context) {
+		app = testhelpers.NewApp() //nolint:contextcheck
+		client = app.ManagementClient(ctx)
+
+		lo.Must(client.CreateUser(ctx, "role-user"))
+		lo.Must0(client.CreatePolicy(ctx, &iampol.IAMPolicy{
+			ID: "role-policy",
+			Statement: []iampol.Statement{{
+				Effect:   iampol.EffectAllow,
+				Action:   []s3actions.Action{s3actions.GetObject},
+				Resource: []string{"arn:aws:s3:::my-bucket/*"},
+			}},
+		}))
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	When("no roles exist", func() {
+		It("returns empty list", func(ctx context.Context) {
+			Expect(client.ListRoles(ctx)).To(BeEmpty())
+		})
+	})
+
+	When("a role is created", func() {
+		It("is listed and returned", func(ctx context.Context) {
+			lo.Must0(client.CreateRole(ctx, &core.Role{
+				Name: "reader", PolicyIDs: []string{"role-policy"}, TrustedUsers: []string{"role-user"},
+			}))
+
+			Expect(client.ListRoles(ctx)).To(ConsistOf("reader"))
+			Expect(client.GetRole(ctx, "reader")).To(Equal(core.Role{
+				Name: "reader", PolicyIDs: []string{"role-policy"}, TrustedUsers: []string{"role-user"},
+			}))
+		})
+
+		It("rejects a duplicate", func(ctx context.Context) {
+			Expect(client.CreateRole(ctx, &core.Role{Name: "reader"})).To(MatchError(apiclient.ErrUnexpectedStatus))
+		})
+
+		It("rejects unknown policies", func(ctx context.Context) {
+			err := client.CreateRole(ctx, &core.Role{Name: "writer", PolicyIDs: []string{"nopolicy"}})
+			Expect(err).To(MatchError(apiclient.ErrUnexpectedStatus))
+		})
+	})
+
+	When("the role is deleted", func() {
+		It("is no longer found", func(ctx context.Context) {
+			lo.Must0(client.DeleteRole(ctx, "reader"))
+
+			_, err := client.GetRole(ctx, "reader")
+			Expect(err).To(MatchError(apiclient.ErrUnexpectedStatus))
+		})
+	})
+})
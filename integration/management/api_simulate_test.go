@@ -0,0 +1,162 @@
+package management_test
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/s3actions"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Simulate API", Label("management"), Label("api-simulate"), Ordered, func() {
+	var (
+		client *apiclient.Client
+		app    *testhelpers.App
+	)
+
+	allowRead := iampol.Statement{
+		Effect:   iampol.EffectAllow,
+		Action:   []s3actions.Action{s3actions.GetObject},
+		Resource: []string{"arn:aws:s3:::sim-bucket/*"},
+	}
+	denySecrets := iampol.Statement{
+		Effect:   iampol.EffectDeny,
+		Action:   []s3actions.Action{s3actions.All},
+		Resource: []string{"arn:aws:s3:::sim-bucket/secret/*"},
+	}
+
+	denyInsecure := iampol.Statement{
+		Effect:   iampol.EffectDeny,
+		Action:   []s3actions.Action{s3actions.All},
+		Resource: []string{"arn:aws:s3:::sim-bucket/tls/*"},
+		Condition: iampol.Condition{
+			iampol.ConditionOperatorBool: {iampol.ConditionKeySecureTransport: {"false"}},
+		},
+	}
+
+	simulateTransport := func(ctx context.Context, userName string, action s3actions.Action,
+		resource string, secureTransport bool) core.PolicySimulationResult {
+		results := lo.Must(client.SimulatePolicy(ctx, &core.PolicySimulation{
+			UserName:        userName,
+			Actions:         []s3actions.Action{action},
+			Resources:       []string{resource},
+			SecureTransport: secureTransport,
+		}))
+		Expect(results).To(HaveLen(1))
+
+		return results[0]
+	}
+
+	simulate := func(ctx context.Context, userName string, action s3actions.Action,
+		resource string) core.PolicySimulationResult {
+		return simulateTransport(ctx, userName, action, resource, false)
+	}
+
+	BeforeAll(func(ctx context.Context) {
+		app = testhelpers.NewApp() //nolint:contextcheck
+		client = app.ManagementClient(ctx)
+
+		lo.Must(client.CreateUser(ctx, "sim-user"))
+		lo.Must0(client.CreatePolicy(ctx, &iampol.IAMPolicy{ID: "sim-read", Statement: []iampol.Statement{allowRead}}))
+		lo.Must0(client.CreatePolicy(ctx, &iampol.IAMPolicy{ID: "sim-deny", Statement: []iampol.Statement{denySecrets}}))
+		lo.Must0(client.CreateBinding(ctx, &core.PolicyBinding{UserName: "sim-user", PolicyID: "sim-read"}))
+		lo.Must0(client.CreatePolicy(ctx, &iampol.IAMPolicy{ID: "sim-tls", Statement: []iampol.Statement{denyInsecure}}))
+		lo.Must0(client.CreateBinding(ctx, &core.PolicyBinding{UserName: "sim-user", PolicyID: "sim-tls"}))
+
+		lo.Must0(client.CreateGroup(ctx, "sim-group"))
+		lo.Must0(client.AddGroupMember(ctx, "sim-group", "sim-user"))
+		lo.Must0(client.CreateGroupBinding(ctx, "sim-group", "sim-deny"))
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	It("reports the statement that allowed", func(ctx context.Context) {
+		Expect(simulate(ctx, "sim-user", s3actions.GetObject, "arn:aws:s3:::sim-bucket/a.txt")).To(Equal(
+			core.PolicySimulationResult{
+				Action:   s3actions.GetObject,
+				Resource: "arn:aws:s3:::sim-bucket/a.txt",
+				AuthorizationDecision: core.AuthorizationDecision{
+					Allowed:   true,
+					Reason:    core.AuthorizationReasonExplicitAllow,
+					PolicyID:  "sim-read",
+					Statement: &allowRead,
+				},
+			},
+		))
+	})
+
+	It("reports the statement that denied, including group policies", func(ctx context.Context) {
+		result := simulate(ctx, "sim-user", s3actions.GetObject, "sim-bucket/secret/key")
+		Expect(result.AuthorizationDecision).To(Equal(core.AuthorizationDecision{
+			Reason:    core.AuthorizationReasonExplicitDeny,
+			PolicyID:  "sim-deny",
+			Statement: &denySecrets,
+		}))
+	})
+
+	It("evaluates aws:SecureTransport conditions", func(ctx context.Context) {
+		result := simulateTransport(ctx, "sim-user", s3actions.GetObject, "sim-bucket/tls/a.txt", false)
+		Expect(result.AuthorizationDecision).To(Equal(core.AuthorizationDecision{
+			Reason:    core.AuthorizationReasonExplicitDeny,
+			PolicyID:  "sim-tls",
+			Statement: &denyInsecure,
+		}))
+
+		result = simulateTransport(ctx, "sim-user", s3actions.GetObject, "sim-bucket/tls/a.txt", true)
+		Expect(result.AuthorizationDecision).To(Equal(core.AuthorizationDecision{
+			Allowed:   true,
+			Reason:    core.AuthorizationReasonExplicitAllow,
+			PolicyID:  "sim-read",
+			Statement: &allowRead,
+		}))
+	})
+
+	It("reports implicit denies", func(ctx context.Context) {
+		result := simulate(ctx, "sim-user", s3actions.PutObject, "sim-bucket/a.txt")
+		Expect(result.AuthorizationDecision).To(Equal(core.AuthorizationDecision{
+			Reason: core.AuthorizationReasonImplicitDeny,
+		}))
+	})
+
+	It("allows admin everything", func(ctx context.Context) {
+		result := simulate(ctx, "admin", s3actions.DeleteBucket, "sim-bucket")
+		Expect(result.AuthorizationDecision).To(Equal(core.AuthorizationDecision{
+			Allowed: true,
+			Reason:  core.AuthorizationReasonAdmin,
+		}))
+	})
+
+	It("returns a result per action and resource pair", func(ctx context.Context) {
+		results := lo.Must(client.SimulatePolicy(ctx, &core.PolicySimulation{
+			UserName:  "sim-user",
+			Actions:   []s3actions.Action{s3actions.GetObject, s3actions.PutObject},
+			Resources: []string{"sim-bucket/a.txt", "sim-bucket/b.txt", "other/c.txt"},
+		}))
+		Expect(results).To(HaveLen(6))
+		Expect(lo.CountBy(results, func(r core.PolicySimulationResult) bool { return r.Allowed })).To(Equal(2))
+	})
+
+	DescribeTable("rejects invalid simulations",
+		func(ctx context.Context, simulation *core.PolicySimulation) {
+			_, err := client.SimulatePolicy(ctx, simulation)
+			Expect(err).To(MatchError(apiclient.ErrUnexpectedStatus))
+		},
+		Entry("unknown user", &core.PolicySimulation{
+			UserName: "nobody", Actions: []s3actions.Action{s3actions.GetObject}, Resources: []string{"b/k"},
+		}),
+		Entry("unknown action", &core.PolicySimulation{
+			UserName: "sim-user", Actions: []s3actions.Action{"s3:Fly"}, Resources: []string{"b/k"},
+		}),
+		Entry("no resources", &core.PolicySimulation{
+			UserName: "sim-user", Actions: []s3actions.Action{s3actions.GetObject},
+		}),
+	)
+})
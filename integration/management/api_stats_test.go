@@ -0,0 +1,74 @@
+package management_test
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/integration/testhelpers"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/core"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stats API", Label("management"), Label("api-stats"), Ordered, func() {
+	var (
+		client *apiclient.Client
+		app    *testhelpers.App
+	)
+
+	BeforeAll(func(ctx context.Context) {
+		app = testhelpers.NewApp(func(cfg *core.Config) { //nolint:contextcheck
+			cfg.FolderStorageDedup = true
+		})
+		client = app.ManagementClient(ctx)
+	})
+
+	AfterAll(func(ctx context.Context) {
+		app.Stop(ctx)
+	})
+
+	It("reports the storage saved by deduplication", func(ctx context.Context) {
+		s3Client := app.S3Client(ctx, "admin")
+		content := bytes.Repeat([]byte("deduplicated"), 1_000)
+
+		for _, key := range []string{"first.txt", "second.txt"} {
+			lo.Must(s3Client.PutObject(ctx, &s3.PutObjectInput{
+				Bucket: lo.ToPtr(app.BucketName()),
+				Key:    lo.ToPtr(key),
+				Body:   bytes.NewReader(content),
+			}))
+		}
+
+		stats := lo.Must(client.GetDedupStats(ctx))
+		Expect(stats.Blobs).To(Equal(int64(1)))
+		Expect(stats.References).To(Equal(int64(2)))
+		Expect(stats.SavedBytes).To(Equal(int64(len(content))))
+	})
+
+	It("reports the objects of every bucket", func(ctx context.Context) {
+		stats := lo.Must(client.GetStorageStats(ctx, true, true))
+
+		bucket, ok := lo.Find(stats.Buckets, func(bucket core.BucketStats) bool {
+			return bucket.Name == app.BucketName()
+		})
+		Expect(ok).To(BeTrue())
+		Expect(bucket.Objects).To(BeNumerically(">=", 2))
+		Expect(bucket.Prefixes).NotTo(BeEmpty())
+
+		withoutPrefixes := lo.Must(client.GetStorageStats(ctx, false, false))
+		Expect(withoutPrefixes.ScannedAt).To(Equal(stats.ScannedAt))
+		Expect(withoutPrefixes.Buckets).To(HaveEach(HaveField("Prefixes", BeEmpty())))
+	})
+
+	It("reports the bytes users transferred", func(ctx context.Context) {
+		totals := lo.Must(client.GetTransferStats(ctx))
+
+		admin, ok := lo.Find(totals, func(stats core.TransferStats) bool { return stats.User == "admin" })
+		Expect(ok).To(BeTrue())
+		Expect(admin.UploadedBytes).To(BeNumerically(">=", 2*len("deduplicated")*1_000))
+	})
+})
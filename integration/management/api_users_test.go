@@ -2,11 +2,15 @@ package management_test
 
 import (
 	"context"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/samber/lo"
 	"github.com/zhulik/d3/integration/testhelpers"
 	"github.com/zhulik/d3/internal/client/apiclient"
 	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/s3actions"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -56,8 +60,9 @@ var _ = Describe("Users API", Label("management"), Label("api-users"), Ordered,
 				user, err := client.CreateUser(ctx, "new-user")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(user.Name).To(Equal("new-user"))
-				Expect(user.AccessKeyID).NotTo(BeEmpty())
-				Expect(user.SecretAccessKey).NotTo(BeEmpty())
+				Expect(user.AccessKeys).To(HaveLen(1))
+				Expect(user.AccessKeys[0].AccessKeyID).NotTo(BeEmpty())
+				Expect(user.AccessKeys[0].SecretAccessKey).NotTo(BeEmpty())
 
 				users, err := client.ListUsers(ctx)
 				Expect(err).NotTo(HaveOccurred())
@@ -77,29 +82,116 @@ var _ = Describe("Users API", Label("management"), Label("api-users"), Ordered,
 		})
 	})
 
+	Describe("AccessKeys", func() {
+		var (
+			originalUser core.User
+			newKey       core.AccessKey
+		)
+
+		BeforeAll(func(ctx context.Context) {
+			originalUser = lo.Must(client.CreateUser(ctx, "key-user"))
+
+			lo.Must0(client.CreatePolicy(ctx, &iampol.IAMPolicy{
+				ID: "key-user-list",
+				Statement: []iampol.Statement{{
+					Effect:   iampol.EffectAllow,
+					Action:   []s3actions.Action{s3actions.ListObjectsV2},
+					Resource: []string{iampol.ResourcePrefix + app.BucketName(), iampol.ResourcePrefix + app.BucketName() + "/*"},
+				}},
+			}))
+			lo.Must0(client.CreateBinding(ctx, &core.PolicyBinding{UserName: "key-user", PolicyID: "key-user-list"}))
+		})
+
+		listObjects := func(ctx context.Context, key core.AccessKey) error {
+			_, err := app.S3ClientWithKey(ctx, key).ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+				Bucket: lo.ToPtr(app.BucketName()),
+			})
+
+			return err
+		}
+
+		When("a second key is created", func() {
+			It("returns its secret and keeps the original key working", func(ctx context.Context) {
+				var err error
+
+				newKey, err = client.CreateAccessKey(ctx, "key-user", nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(newKey.AccessKeyID).NotTo(Equal(originalUser.AccessKeys[0].AccessKeyID))
+				Expect(newKey.SecretAccessKey).NotTo(BeEmpty())
+
+				Expect(listObjects(ctx, newKey)).To(Succeed())
+				Expect(listObjects(ctx, originalUser.AccessKeys[0])).To(Succeed())
+			})
+		})
+
+		When("keys are listed", func() {
+			It("returns them without secrets", func(ctx context.Context) {
+				keys, err := client.ListAccessKeys(ctx, "key-user")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(keys).To(HaveLen(2))
+				Expect(keys).To(HaveEach(HaveField("SecretAccessKey", BeEmpty())))
+			})
+		})
+
+		When("the original key is deactivated", func() {
+			It("rejects requests signed with it", func(ctx context.Context) {
+				accessKeyID := originalUser.AccessKeys[0].AccessKeyID
+				Expect(client.SetAccessKeyStatus(ctx, "key-user", accessKeyID, core.AccessKeyStatusInactive)).To(Succeed())
+
+				Expect(listObjects(ctx, originalUser.AccessKeys[0])).NotTo(Succeed())
+				Expect(listObjects(ctx, newKey)).To(Succeed())
+			})
+		})
+
+		When("the original key is deleted", func() {
+			It("is no longer listed", func(ctx context.Context) {
+				Expect(client.DeleteAccessKey(ctx, "key-user", originalUser.AccessKeys[0].AccessKeyID)).To(Succeed())
+
+				keys := lo.Must(client.ListAccessKeys(ctx, "key-user"))
+				Expect(keys).To(ConsistOf(HaveField("AccessKeyID", newKey.AccessKeyID)))
+			})
+		})
+
+		When("the key would expire in the past", func() {
+			It("rejects it", func(ctx context.Context) {
+				_, err := client.CreateAccessKey(ctx, "key-user", lo.ToPtr(time.Now().Add(-time.Minute)))
+				Expect(err).To(MatchError(apiclient.ErrUnexpectedStatus))
+				Expect(err).To(MatchError(ContainSubstring("400")))
+			})
+		})
+
+		When("user does not exist", func() {
+			It("returns error", func(ctx context.Context) {
+				_, err := client.CreateAccessKey(ctx, "does-not-exist", nil)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
 	Describe("UpdateUser", func() {
 		var originalUser core.User
 
 		BeforeAll(func(ctx context.Context) {
 			originalUser = lo.Must(client.CreateUser(ctx, "update-user"))
+			lo.Must(client.CreateAccessKey(ctx, "update-user", nil))
 		})
 
 		When("user exists", func() {
-			It("updates user credentials successfully", func(ctx context.Context) {
-				updatedUser, err := client.UpdateUser(ctx, "update-user")
+			It("replaces all the keys of the user with a new one", func(ctx context.Context) {
+				updatedUser, err := client.UpdateUser(ctx, "update-user") //nolint:staticcheck
 				Expect(err).NotTo(HaveOccurred())
 				Expect(updatedUser.Name).To(Equal("update-user"))
-				Expect(updatedUser.AccessKeyID).NotTo(BeEmpty())
-				Expect(updatedUser.SecretAccessKey).NotTo(BeEmpty())
-				Expect(updatedUser.AccessKeyID).NotTo(Equal(originalUser.AccessKeyID))
-				Expect(updatedUser.SecretAccessKey).NotTo(Equal(originalUser.SecretAccessKey))
+				Expect(updatedUser.AccessKeys[0].AccessKeyID).NotTo(Equal(originalUser.AccessKeys[0].AccessKeyID))
+
+				keys := lo.Must(client.ListAccessKeys(ctx, "update-user"))
+				Expect(keys).To(ConsistOf(HaveField("AccessKeyID", updatedUser.AccessKeys[0].AccessKeyID)))
 			})
 		})
 
 		When("user does not exist", func() {
 			It("returns error", func(ctx context.Context) {
-				_, err := client.UpdateUser(ctx, "does-not-exist")
-				Expect(err).To(HaveOccurred())
+				_, err := client.UpdateUser(ctx, "does-not-exist") //nolint:staticcheck
+				Expect(err).To(MatchError(apiclient.ErrUnexpectedStatus))
 			})
 		})
 	})
@@ -8,21 +8,25 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
 	minioCreds "github.com/minio/minio-go/v7/pkg/credentials"
 	"github.com/samber/lo"
 	"github.com/zhulik/d3/internal/application"
 	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/client/s3client"
 	"github.com/zhulik/d3/internal/core"
 	"github.com/zhulik/pal"
 )
 
 type App struct {
 	cancelApp      context.CancelFunc
+	stopped        chan struct{}
 	pal            *pal.Pal
 	s3Port         int
 	managementPort int
@@ -30,7 +34,8 @@ type App struct {
 	bucketName     string
 }
 
-func NewApp() *App {
+// NewApp starts d3 with a test config, configure may adjust it before the app starts.
+func NewApp(configure ...func(*core.Config)) *App {
 	ctx, cancelApp := context.WithCancel(context.Background())
 
 	tempDir := lo.Must(os.MkdirTemp("/tmp", "d3-"))
@@ -46,12 +51,21 @@ func NewApp() *App {
 		Port:                      randomPort(),
 		HealthCheckPort:           randomPort(),
 		ManagementPort:            randomPort(),
+		SigV2Enabled:              true,
+	}
+
+	for _, f := range configure {
+		f(appConfig)
 	}
 
 	pal := application.NewServer(appConfig)
 	lo.Must0(pal.Init(ctx))
 
+	stopped := make(chan struct{})
+
 	go func() {
+		defer close(stopped)
+
 		lo.Must0(pal.Run(ctx))
 	}()
 
@@ -59,6 +73,7 @@ func NewApp() *App {
 
 	app := &App{
 		cancelApp:      cancelApp,
+		stopped:        stopped,
 		pal:            pal,
 		s3Port:         appConfig.Port,
 		managementPort: appConfig.ManagementPort,
@@ -77,59 +92,110 @@ func NewApp() *App {
 
 func (a *App) Stop(_ context.Context) {
 	a.cancelApp()
+	// The services flush their buffers to the storage when they shut down.
+	<-a.stopped
 	lo.Must0(os.RemoveAll(a.tempDir))
 }
 
 func (a *App) ManagementClient(ctx context.Context) *apiclient.Client {
-	managementBackend := pal.MustInvoke[core.ManagementBackend](ctx, a.pal)
-	admin := lo.Must(managementBackend.GetUserByName(ctx, "admin"))
-
-	clientConfig := &core.ClientConfig{
-		ServerURL:       fmt.Sprintf("http://localhost:%d", a.managementPort),
-		AccessKeyID:     admin.AccessKeyID,
-		AccessKeySecret: admin.SecretAccessKey,
+	client := &apiclient.Client{
+		Config: a.ClientConfig(ctx, "admin"),
 	}
+	lo.Must0(client.Init(ctx))
 
-	client := &apiclient.Client{
-		Config: clientConfig,
+	return client
+}
+
+// S3DataClient returns the d3-client S3 data plane client acting as the user.
+func (a *App) S3DataClient(ctx context.Context, username string) *s3client.Client {
+	client := &s3client.Client{
+		Config: a.ClientConfig(ctx, username),
 	}
 	lo.Must0(client.Init(ctx))
 
 	return client
 }
 
+// ClientConfig returns the d3-client config pointing at the app, with the user's first access key.
+func (a *App) ClientConfig(ctx context.Context, username string) *core.ClientConfig {
+	managementBackend := pal.MustInvoke[core.ManagementBackend](ctx, a.pal)
+	user := lo.Must(managementBackend.GetUserByName(ctx, username))
+
+	return &core.ClientConfig{
+		ServerURL:       fmt.Sprintf("http://localhost:%d", a.managementPort),
+		S3URL:           fmt.Sprintf("http://localhost:%d", a.s3Port),
+		AccessKeyID:     user.AccessKeys[0].AccessKeyID,
+		AccessKeySecret: user.AccessKeys[0].SecretAccessKey,
+	}
+}
+
 func (a *App) S3Client(ctx context.Context, username string) *s3.Client {
 	managementBackend := pal.MustInvoke[core.ManagementBackend](ctx, a.pal)
 	user := lo.Must(managementBackend.GetUserByName(ctx, username))
 
-	cfg := lo.Must(config.LoadDefaultConfig(ctx,
+	return a.S3ClientWithKey(ctx, user.AccessKeys[0])
+}
+
+// S3ClientWithKey returns an S3 client that signs requests with the given access key.
+func (a *App) S3ClientWithKey(ctx context.Context, key core.AccessKey) *s3.Client {
+	return a.S3ClientWithCredentials(ctx, key.AccessKeyID, key.SecretAccessKey, "")
+}
+
+// S3ClientWithCredentials returns an S3 client that signs requests with the given credentials, sessionToken is
+// only set for temporary credentials.
+func (a *App) S3ClientWithCredentials(ctx context.Context,
+	accessKeyID, secretAccessKey, sessionToken string) *s3.Client {
+	return s3.NewFromConfig(a.awsConfig(ctx, accessKeyID, secretAccessKey, sessionToken), func(o *s3.Options) {
+		o.UsePathStyle = true
+		o.RetryMaxAttempts = 1
+	})
+}
+
+// STSClient returns an STS client that signs requests with the user's first access key.
+func (a *App) STSClient(ctx context.Context, username string) *sts.Client {
+	managementBackend := pal.MustInvoke[core.ManagementBackend](ctx, a.pal)
+	user := lo.Must(managementBackend.GetUserByName(ctx, username))
+	key := user.AccessKeys[0]
+
+	return a.STSClientWithCredentials(ctx, key.AccessKeyID, key.SecretAccessKey, "")
+}
+
+// STSClientWithCredentials returns an STS client that signs requests with the given credentials.
+func (a *App) STSClientWithCredentials(ctx context.Context,
+	accessKeyID, secretAccessKey, sessionToken string) *sts.Client {
+	return sts.NewFromConfig(a.awsConfig(ctx, accessKeyID, secretAccessKey, sessionToken), func(o *sts.Options) {
+		o.RetryMaxAttempts = 1
+	})
+}
+
+func (a *App) awsConfig(ctx context.Context, accessKeyID, secretAccessKey, sessionToken string) aws.Config {
+	return lo.Must(config.LoadDefaultConfig(ctx,
 		config.WithBaseEndpoint(fmt.Sprintf("http://localhost:%d", a.s3Port)),
 		config.WithRegion("local"),
 		config.WithCredentialsProvider(
-			credentials.NewStaticCredentialsProvider(user.AccessKeyID, user.SecretAccessKey, ""),
+			credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, sessionToken),
 		),
 	))
-
-	return s3.NewFromConfig(cfg, func(o *s3.Options) {
-		o.UsePathStyle = true
-		o.RetryMaxAttempts = 1
-	})
 }
 
-func (a *App) MinioClient(ctx context.Context, username string) *minio.Client {
+func (a *App) MinioClient(ctx context.Context, username string, opts ...func(*minio.Options)) *minio.Client {
 	managementBackend := pal.MustInvoke[core.ManagementBackend](ctx, a.pal)
 	user := lo.Must(managementBackend.GetUserByName(ctx, username))
 
 	endpoint := fmt.Sprintf("localhost:%d", a.s3Port)
-	client := lo.Must(minio.New(endpoint, &minio.Options{
-		Creds:        minioCreds.NewStaticV4(user.AccessKeyID, user.SecretAccessKey, ""),
+	options := &minio.Options{
+		Creds:        minioCreds.NewStaticV4(user.AccessKeys[0].AccessKeyID, user.AccessKeys[0].SecretAccessKey, ""),
 		Secure:       false, // no TLS
 		Region:       "local",
 		BucketLookup: minio.BucketLookupPath,
 		MaxRetries:   1,
-	}))
+	}
 
-	return client
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return lo.Must(minio.New(endpoint, options))
 }
 
 func (a *App) BucketName() string {
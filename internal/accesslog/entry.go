@@ -0,0 +1,103 @@
+// Package accesslog writes S3 server access logs: one line per request in the S3 access log format, batched into
+// objects in the target bucket of the logged bucket's logging config.
+package accesslog
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const (
+	timeFormat = "[02/Jan/2006:15:04:05 -0700]"
+	empty      = "-"
+)
+
+// Entry is a single access log record. Empty fields are written as "-".
+type Entry struct {
+	BucketOwner string
+	Bucket      string
+	Time        time.Time
+	RemoteIP    string
+	Requester   string
+	RequestID   string
+	// Operation is REST.<method>.<resource type>, like REST.GET.OBJECT.
+	Operation  string
+	Key        string
+	RequestURI string
+	HTTPStatus int
+	ErrorCode  string
+	BytesSent  int64
+	// ObjectSize is nil for requests that don't target an existing object.
+	ObjectSize *int64
+	TotalTime  time.Duration
+	Referer    string
+	UserAgent  string
+	// SignatureVersion is SigV4 for signed requests.
+	SignatureVersion string
+	CipherSuite      string
+	// AuthType is AuthHeader or QueryString for signed requests.
+	AuthType   string
+	HostHeader string
+	TLSVersion string
+}
+
+// String formats the entry as an S3 server access log line, without the trailing newline.
+func (e Entry) String() string {
+	fields := []string{
+		field(e.BucketOwner),
+		field(e.Bucket),
+		e.Time.UTC().Format(timeFormat),
+		field(e.RemoteIP),
+		field(e.Requester),
+		field(e.RequestID),
+		field(e.Operation),
+		field(e.Key),
+		quoted(e.RequestURI),
+		strconv.Itoa(e.HTTPStatus),
+		field(e.ErrorCode),
+		size(lo.EmptyableToPtr(e.BytesSent)),
+		size(e.ObjectSize),
+		strconv.FormatInt(e.TotalTime.Milliseconds(), 10),
+		empty, // turn-around time
+		quoted(e.Referer),
+		quoted(e.UserAgent),
+		empty, // version ID
+		empty, // host ID
+		field(e.SignatureVersion),
+		field(e.CipherSuite),
+		field(e.AuthType),
+		field(e.HostHeader),
+		field(e.TLSVersion),
+		empty, // access point ARN
+		empty, // ACL required
+	}
+
+	return strings.Join(fields, " ")
+}
+
+func field(value string) string {
+	if value == "" {
+		return empty
+	}
+
+	return strings.ReplaceAll(value, " ", "%20")
+}
+
+func quoted(value string) string {
+	if value == "" {
+		return empty
+	}
+
+	return strconv.Quote(value)
+}
+
+func size(value *int64) string {
+	if value == nil {
+		return empty
+	}
+
+	return strconv.FormatInt(*value, 10)
+}
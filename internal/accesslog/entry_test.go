@@ -0,0 +1,52 @@
+package accesslog_test
+
+import (
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/accesslog"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Entry", func() {
+	It("formats a signed object request", func() {
+		entry := accesslog.Entry{
+			Bucket:           "photos",
+			Time:             time.Date(2026, 2, 6, 0, 0, 38, 0, time.UTC),
+			RemoteIP:         "192.0.2.3",
+			Requester:        "alice",
+			RequestID:        "3E57427F3EXAMPLE",
+			Operation:        "REST.GET.OBJECT",
+			Key:              "2026/cat.jpg",
+			RequestURI:       "GET /photos/2026/cat.jpg HTTP/1.1",
+			HTTPStatus:       200,
+			BytesSent:        2662992,
+			ObjectSize:       lo.ToPtr(int64(2662992)),
+			TotalTime:        70 * time.Millisecond,
+			UserAgent:        "aws-sdk-go-v2/1.0",
+			SignatureVersion: "SigV4",
+			AuthType:         "AuthHeader",
+			HostHeader:       "localhost:8080",
+		}
+
+		Expect(entry.String()).To(Equal(`- photos [06/Feb/2026:00:00:38 +0000] 192.0.2.3 alice 3E57427F3EXAMPLE ` +
+			`REST.GET.OBJECT 2026/cat.jpg "GET /photos/2026/cat.jpg HTTP/1.1" 200 - 2662992 2662992 70 - - ` +
+			`"aws-sdk-go-v2/1.0" - - SigV4 - AuthHeader localhost:8080 - - -`))
+	})
+
+	It("writes dashes for missing values and escapes spaces in keys", func() {
+		entry := accesslog.Entry{
+			Bucket:     "photos",
+			Time:       time.Date(2026, 2, 6, 1, 0, 0, 0, time.FixedZone("CET", 3600)),
+			Operation:  "REST.GET.OBJECT",
+			Key:        "my cat.jpg",
+			HTTPStatus: 403,
+			ErrorCode:  "AccessDenied",
+		}
+
+		Expect(entry.String()).To(Equal(`- photos [06/Feb/2026:00:00:00 +0000] - - - REST.GET.OBJECT my%20cat.jpg - ` +
+			`403 AccessDenied - - 0 - - - - - - - - - - - -`))
+	})
+})
@@ -0,0 +1,9 @@
+package accesslog
+
+import (
+	"github.com/zhulik/pal"
+)
+
+func Provide() pal.ServiceDef {
+	return pal.Provide(&Writer{})
+}
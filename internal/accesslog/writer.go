@@ -0,0 +1,86 @@
+package accesslog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/batchwriter"
+)
+
+const (
+	// keyTimeFormat follows the S3 log object key format: TargetPrefixYYYY-mm-DD-HH-MM-SS-UniqueString.
+	keyTimeFormat = "2006-01-02-15-04-05-"
+	// maxBatchSize makes Run write a batch before the flush interval elapses.
+	maxBatchSize = 1000
+	// maxPending bounds the lines kept while target buckets cannot be written.
+	maxPending           = 100_000
+	defaultFlushInterval = time.Minute
+)
+
+// Writer buffers access log lines per logging target and writes them to the target buckets every
+// AccessLogFlushInterval, one object per target and flush.
+type Writer struct {
+	Config  *core.Config
+	Backend core.StorageBackend
+	Logger  *slog.Logger
+
+	batches *batchwriter.Writer[core.BucketLogging, string]
+}
+
+func (w *Writer) Init(_ context.Context) error {
+	w.batches = &batchwriter.Writer[core.BucketLogging, string]{
+		MaxBatch:   maxBatchSize,
+		MaxPending: maxPending,
+		Write:      w.write,
+		// The lines of a target bucket that is gone are dropped.
+		Retry:  func(err error) bool { return !errors.Is(err, core.ErrBucketNotFound) },
+		Logger: w.Logger.With("component", "access log"),
+	}
+
+	return nil
+}
+
+func (w *Writer) Run(ctx context.Context) error {
+	w.batches.Run(ctx, lo.CoalesceOrEmpty(w.Config.AccessLogFlushInterval, defaultFlushInterval))
+
+	return nil
+}
+
+func (w *Writer) Shutdown(ctx context.Context) error {
+	return w.Flush(ctx)
+}
+
+// Write buffers the entry for the target, Run writes it.
+func (w *Writer) Write(target core.BucketLogging, entry Entry) {
+	w.batches.Add(target, entry.String())
+}
+
+// Flush writes all buffered lines.
+func (w *Writer) Flush(ctx context.Context) error {
+	return w.batches.Flush(ctx)
+}
+
+func (w *Writer) write(ctx context.Context, target core.BucketLogging, lines []string) error {
+	bucket, err := w.Backend.HeadBucket(ctx, target.TargetBucket)
+	if err != nil {
+		return err
+	}
+
+	content := strings.Join(lines, "\n") + "\n"
+	unique := strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:16])
+	key := target.TargetPrefix + time.Now().UTC().Format(keyTimeFormat) + unique
+
+	return bucket.PutObject(ctx, key, core.PutObjectInput{
+		Reader: strings.NewReader(content),
+		Metadata: core.ObjectMetadata{
+			ContentType: "text/plain",
+			Size:        int64(len(content)),
+		},
+	})
+}
@@ -0,0 +1,77 @@
+package accesslog_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/accesslog"
+	"github.com/zhulik/d3/internal/backends/storage/folder"
+	"github.com/zhulik/d3/internal/backends/storage/storagetest"
+	"github.com/zhulik/d3/internal/core"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Writer", func() {
+	var (
+		backend *folder.Backend
+		writer  *accesslog.Writer
+	)
+
+	target := core.BucketLogging{TargetBucket: "logs", TargetPrefix: "photos/"}
+
+	entry := func(key string) accesslog.Entry {
+		return accesslog.Entry{Bucket: "photos", Time: time.Now(), Operation: "REST.GET.OBJECT", Key: key, HTTPStatus: 200}
+	}
+
+	logObjects := func(ctx context.Context) []string {
+		bucket := lo.Must(backend.HeadBucket(ctx, target.TargetBucket))
+		result := lo.Must(bucket.ListObjectsV2(ctx, core.ListObjectsV2Input{MaxKeys: core.MaxKeys}))
+
+		return lo.Map(result.Objects, func(object core.Object, _ int) string {
+			reader := lo.Must(bucket.GetObject(ctx, object.Key()))
+			defer reader.Close()
+
+			return object.Key() + "\n" + string(lo.Must(io.ReadAll(reader)))
+		})
+	}
+
+	BeforeEach(func(ctx context.Context) {
+		backend = &folder.Backend{
+			Cfg:    &core.Config{FolderStorageBackendPath: GinkgoT().TempDir()},
+			Locker: storagetest.NewLocker(),
+		}
+		Expect(backend.Init(ctx)).To(Succeed())
+		Expect(backend.CreateBucket(ctx, target.TargetBucket)).To(Succeed())
+
+		writer = &accesslog.Writer{Config: backend.Cfg, Backend: backend, Logger: slog.New(slog.DiscardHandler)}
+		Expect(writer.Init(ctx)).To(Succeed())
+	})
+
+	It("writes buffered entries as one object per flush", func(ctx context.Context) {
+		writer.Write(target, entry("a.jpg"))
+		writer.Write(target, entry("b.jpg"))
+		Expect(logObjects(ctx)).To(BeEmpty())
+
+		Expect(writer.Flush(ctx)).To(Succeed())
+
+		objects := logObjects(ctx)
+		Expect(objects).To(HaveLen(1))
+		Expect(objects[0]).To(MatchRegexp(`^photos/\d{4}(-\d{2}){5}-[0-9A-F]{16}\n`))
+		Expect(objects[0]).To(MatchRegexp(`REST\.GET\.OBJECT a\.jpg .+\n.+REST\.GET\.OBJECT b\.jpg .+\n$`))
+
+		Expect(writer.Flush(ctx)).To(Succeed())
+		Expect(logObjects(ctx)).To(HaveLen(1))
+	})
+
+	It("drops entries for missing target buckets", func(ctx context.Context) {
+		writer.Write(core.BucketLogging{TargetBucket: "missing"}, entry("a.jpg"))
+
+		Expect(writer.Flush(ctx)).To(MatchError(core.ErrBucketNotFound))
+		Expect(writer.Flush(ctx)).To(Succeed())
+	})
+})
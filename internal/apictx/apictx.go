@@ -8,6 +8,7 @@ import (
 	"github.com/labstack/echo/v5"
 	"github.com/zhulik/d3/internal/core"
 	"github.com/zhulik/d3/pkg/s3actions"
+	"github.com/zhulik/d3/pkg/sigv2"
 	"github.com/zhulik/d3/pkg/sigv4"
 )
 
@@ -28,8 +29,17 @@ type APICtx struct {
 	ContentLength int64
 	Headers       http.Header
 
+	// SecureTransport is the aws:SecureTransport condition key: whether the request was received over TLS.
+	SecureTransport bool
+
 	User       *core.User
 	AuthParams *sigv4.AuthHeaderParameters
+	// SigV2Params is set instead of AuthParams for requests signed with SigV2.
+	SigV2Params *sigv2.AuthParameters
+
+	// Resource is set by handlers when the URL does not identify the resource the request acts on, like the user
+	// created by POST /users. It is recorded in the audit log.
+	Resource string
 
 	// For AWS S3 API:
 
@@ -44,23 +54,37 @@ func Inject(c *echo.Context) context.Context {
 	req := c.Request()
 
 	apiCtx := APICtx{
-		Method:        req.Method,
-		URI:           req.RequestURI,
-		Path:          req.URL.Path,
-		QueryParams:   req.URL.Query(),
-		Host:          req.Host,
-		Scheme:        getScheme(req),
-		RemoteAddr:    req.RemoteAddr,
-		UserAgent:     req.UserAgent(),
-		RequestID:     getRequestID(req),
-		ContentType:   req.Header.Get("Content-Type"),
-		ContentLength: req.ContentLength,
-		Headers:       req.Header,
+		Method:          req.Method,
+		URI:             req.RequestURI,
+		Path:            req.URL.Path,
+		QueryParams:     req.URL.Query(),
+		Host:            req.Host,
+		Scheme:          getScheme(req),
+		RemoteAddr:      req.RemoteAddr,
+		UserAgent:       req.UserAgent(),
+		RequestID:       getRequestID(req),
+		SecureTransport: req.TLS != nil,
+		ContentType:     req.Header.Get("Content-Type"),
+		ContentLength:   req.ContentLength,
+		Headers:         req.Header,
 	}
 
 	return context.WithValue(req.Context(), ctxKey{}, &apiCtx)
 }
 
+// WithSecureTransport returns a context whose ApiCtx tells whether the request was received over TLS. It is used
+// to evaluate policies for requests other than the current one.
+func WithSecureTransport(ctx context.Context, secureTransport bool) context.Context {
+	apiCtx := APICtx{}
+	if current := FromContext(ctx); current != nil {
+		apiCtx = *current
+	}
+
+	apiCtx.SecureTransport = secureTransport
+
+	return context.WithValue(ctx, ctxKey{}, &apiCtx)
+}
+
 // FromContext retrieves ApiCtx from the context.
 // Returns nil if ApiCtx is not present in the context.
 func FromContext(ctx context.Context) *APICtx {
@@ -0,0 +1,365 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/backends/management/yaml"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/json"
+)
+
+type applyRequestBody struct {
+	Config yaml.ManagementConfig `json:"config"`
+	DryRun bool                  `json:"dry_run"`
+	// Prune deletes users, policies and bindings that are not in Config.
+	Prune bool `json:"prune"`
+}
+
+// managementState is the part of the management state apply converges: users, policies and bindings.
+type managementState struct {
+	users    map[string]*core.User
+	policies map[string]*iampol.IAMPolicy
+	bindings []*core.PolicyBinding
+}
+
+type APIApply struct {
+	Backend core.ManagementBackend
+	Echo    *Echo
+}
+
+func (a APIApply) Init(_ context.Context) error {
+	a.Echo.POST("/apply", a.Apply)
+
+	return nil
+}
+
+// Apply converges users, policies and bindings to the desired config and returns the changes it made,
+// or would make on a dry run. Groups and roles in the config are ignored.
+func (a APIApply) Apply(c *echo.Context) error {
+	ctx := c.Request().Context()
+
+	r, err := validateBodyChecksumAndParseJSON[applyRequestBody](c)
+	if err != nil {
+		return err
+	}
+
+	desired, err := desiredState(r.Config)
+	if err != nil {
+		return err
+	}
+
+	current, err := a.currentState(ctx)
+	if err != nil {
+		return err
+	}
+
+	changes := diffState(current, desired, r.Prune, time.Now().UTC())
+
+	if !r.DryRun {
+		err = a.Backend.ApplyChanges(ctx, changes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.JSON(http.StatusOK, redactChanges(changes, r.DryRun))
+}
+
+// redactChanges returns the changes without secret access keys. The keys generated for new users are only
+// returned once they are created, on a real apply.
+func redactChanges(changes []core.ManagementChange, dryRun bool) []core.ManagementChange {
+	return lo.Map(changes, func(change core.ManagementChange, _ int) core.ManagementChange {
+		if dryRun {
+			change.GeneratedAccessKey = nil
+		}
+
+		if change.User != nil {
+			user := *change.User
+			user.AccessKeys = lo.Map(user.AccessKeys, func(key core.AccessKey, _ int) core.AccessKey {
+				key.SecretAccessKey = ""
+
+				return key
+			})
+			change.User = &user
+		}
+
+		return change
+	})
+}
+
+func (a APIApply) currentState(ctx context.Context) (managementState, error) {
+	state := managementState{
+		users:    map[string]*core.User{},
+		policies: map[string]*iampol.IAMPolicy{},
+	}
+
+	userNames, err := a.Backend.GetUsers(ctx)
+	if err != nil {
+		return state, err
+	}
+
+	for _, name := range userNames {
+		if name == "admin" {
+			continue
+		}
+
+		state.users[name], err = a.Backend.GetUserByName(ctx, name)
+		if err != nil {
+			return state, err
+		}
+	}
+
+	policyIDs, err := a.Backend.GetPolicies(ctx)
+	if err != nil {
+		return state, err
+	}
+
+	for _, id := range policyIDs {
+		state.policies[id], err = a.Backend.GetPolicyByID(ctx, id)
+		if err != nil {
+			return state, err
+		}
+	}
+
+	state.bindings, err = a.Backend.GetBindings(ctx)
+
+	return state, err
+}
+
+// desiredState validates the desired config. Names may be omitted inside the entries, the map keys are used then,
+// and access keys are active unless stated otherwise.
+func desiredState(cfg yaml.ManagementConfig) (managementState, error) {
+	state := managementState{
+		users:    make(map[string]*core.User, len(cfg.Users)),
+		policies: make(map[string]*iampol.IAMPolicy, len(cfg.Policies)),
+		bindings: lo.UniqBy(lo.Compact(cfg.Bindings), func(binding *core.PolicyBinding) core.PolicyBinding {
+			return *binding
+		}),
+	}
+
+	accessKeyOwners := map[string]string{}
+
+	for name, declared := range cfg.Users {
+		user := &core.User{
+			Name:       lo.CoalesceOrEmpty(lo.FromPtr(declared).Name, name),
+			AccessKeys: lo.FromPtr(declared).AccessKeys,
+			Limits:     lo.FromPtr(declared).Limits,
+		}
+		if user.Name != name || name == "" {
+			return state, fmt.Errorf("%w: user %q has name %q", core.ErrManagementConfigInvalid, name, user.Name)
+		}
+
+		if name == "admin" {
+			return state, fmt.Errorf("%w: %s", core.ErrUserNameReserved, name)
+		}
+
+		if err := lo.FromPtr(user.Limits).Validate(); err != nil {
+			return state, fmt.Errorf("%w: user %q: %w", core.ErrManagementConfigInvalid, name, err)
+		}
+
+		user.AccessKeys = lo.Map(user.AccessKeys, func(key core.AccessKey, _ int) core.AccessKey {
+			key.Status = lo.CoalesceOrEmpty(key.Status, core.AccessKeyStatusActive)
+
+			return key
+		})
+
+		for _, key := range user.AccessKeys {
+			if key.AccessKeyID == "" || key.SecretAccessKey == "" {
+				return state, fmt.Errorf("%w: user %q has an access key without id or secret",
+					core.ErrManagementConfigInvalid, name)
+			}
+
+			if key.Status != core.AccessKeyStatusActive && key.Status != core.AccessKeyStatusInactive {
+				return state, fmt.Errorf("%w: unknown status %q", core.ErrAccessKeyInvalid, key.Status)
+			}
+
+			if owner, ok := accessKeyOwners[key.AccessKeyID]; ok {
+				return state, fmt.Errorf("%w: access key %s is declared for %q and %q",
+					core.ErrManagementConfigInvalid, key.AccessKeyID, owner, name)
+			}
+
+			accessKeyOwners[key.AccessKeyID] = name
+		}
+
+		state.users[name] = user
+	}
+
+	for id, declared := range cfg.Policies {
+		policy := &iampol.IAMPolicy{
+			ID:        lo.CoalesceOrEmpty(lo.FromPtr(declared).ID, id),
+			Statement: lo.FromPtr(declared).Statement,
+		}
+		if policy.ID != id {
+			return state, fmt.Errorf("%w: policy %q has id %q", core.ErrManagementConfigInvalid, id, policy.ID)
+		}
+
+		document, err := json.Marshal(policy)
+		if err != nil {
+			return state, err
+		}
+
+		state.policies[id], err = iampol.Parse(document)
+		if err != nil {
+			return state, fmt.Errorf("policy %s: %w", id, err)
+		}
+	}
+
+	for _, binding := range state.bindings {
+		if binding.UserName == "" || binding.PolicyID == "" {
+			return state, core.ErrBindingInvalid
+		}
+	}
+
+	return state, nil
+}
+
+// diffState returns the changes that turn current into desired. Binding deletes come first and user and policy
+// deletes last, so nothing is left referencing a deleted resource. Deletes are only planned when pruning.
+func diffState(current, desired managementState, prune bool, now time.Time) []core.ManagementChange {
+	var (
+		head []core.ManagementChange
+		tail []core.ManagementChange
+	)
+
+	bindingName := func(binding *core.PolicyBinding) string { return binding.UserName + "/" + binding.PolicyID }
+	hasBinding := func(bindings []*core.PolicyBinding, binding *core.PolicyBinding) bool {
+		return lo.ContainsBy(bindings, func(b *core.PolicyBinding) bool { return *b == *binding })
+	}
+
+	if prune {
+		for _, binding := range current.bindings {
+			if !hasBinding(desired.bindings, binding) {
+				head = append(head, core.ManagementChange{
+					Operation: core.ManagementChangeDelete, Kind: core.ManagementResourceBinding,
+					Name: bindingName(binding), Binding: binding,
+				})
+			}
+		}
+
+		for _, name := range sortedKeys(current.users) {
+			if _, ok := desired.users[name]; !ok {
+				tail = append(tail, core.ManagementChange{
+					Operation: core.ManagementChangeDelete, Kind: core.ManagementResourceUser, Name: name,
+				})
+			}
+		}
+
+		for _, id := range sortedKeys(current.policies) {
+			if _, ok := desired.policies[id]; !ok {
+				tail = append(tail, core.ManagementChange{
+					Operation: core.ManagementChangeDelete, Kind: core.ManagementResourcePolicy, Name: id,
+				})
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(desired.users) {
+		if change, ok := diffUser(current.users[name], desired.users[name], now); ok {
+			head = append(head, change)
+		}
+	}
+
+	for _, id := range sortedKeys(desired.policies) {
+		if change, ok := diffPolicy(current.policies[id], desired.policies[id]); ok {
+			head = append(head, change)
+		}
+	}
+
+	for _, binding := range desired.bindings {
+		if !hasBinding(current.bindings, binding) {
+			head = append(head, core.ManagementChange{
+				Operation: core.ManagementChangeCreate, Kind: core.ManagementResourceBinding,
+				Name: bindingName(binding), Binding: binding,
+			})
+		}
+	}
+
+	return append(head, tail...)
+}
+
+// diffUser plans the creation or update of a user. Users declared without access keys get a generated key when
+// created and keep their keys otherwise. Declared keys keep the creation and last use times they already have.
+// Rate limits are replaced with the declared ones.
+func diffUser(current, desired *core.User, now time.Time) (core.ManagementChange, bool) {
+	change := core.ManagementChange{Kind: core.ManagementResourceUser, Name: desired.Name}
+
+	if current == nil {
+		change.Operation = core.ManagementChangeCreate
+		change.User = &core.User{Name: desired.Name, AccessKeys: desired.AccessKeys, Limits: desired.Limits}
+
+		if len(desired.AccessKeys) == 0 {
+			key := core.NewAccessKey(nil)
+			change.User.AccessKeys = []core.AccessKey{key}
+			change.GeneratedAccessKey = &key
+		}
+
+		for i := range change.User.AccessKeys {
+			if change.User.AccessKeys[i].CreatedAt.IsZero() {
+				change.User.AccessKeys[i].CreatedAt = now
+			}
+		}
+
+		return change, true
+	}
+
+	keys := current.AccessKeys
+	if len(desired.AccessKeys) > 0 {
+		keys = lo.Map(desired.AccessKeys, func(key core.AccessKey, _ int) core.AccessKey {
+			existing, ok := current.AccessKey(key.AccessKeyID)
+
+			key.CreatedAt = lo.Ternary(ok, existing.CreatedAt, now)
+			key.LastUsedAt = lo.Ternary(ok, existing.LastUsedAt, nil)
+
+			return key
+		})
+	}
+
+	if slices.EqualFunc(current.AccessKeys, keys, sameAccessKey) && reflect.DeepEqual(current.Limits, desired.Limits) {
+		return change, false
+	}
+
+	change.Operation = core.ManagementChangeUpdate
+	change.User = &core.User{Name: desired.Name, AccessKeys: keys, Limits: desired.Limits}
+
+	return change, true
+}
+
+func diffPolicy(current, desired *iampol.IAMPolicy) (core.ManagementChange, bool) {
+	change := core.ManagementChange{Kind: core.ManagementResourcePolicy, Name: desired.ID, Policy: desired}
+
+	if current == nil {
+		change.Operation = core.ManagementChangeCreate
+
+		return change, true
+	}
+
+	if reflect.DeepEqual(current, desired) {
+		return change, false
+	}
+
+	change.Operation = core.ManagementChangeUpdate
+
+	return change, true
+}
+
+func sameAccessKey(a, b core.AccessKey) bool {
+	return a.AccessKeyID == b.AccessKeyID &&
+		a.SecretAccessKey == b.SecretAccessKey &&
+		a.Status == b.Status &&
+		lo.FromPtr(a.ExpiresAt).Equal(lo.FromPtr(b.ExpiresAt))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := lo.Keys(m)
+	slices.Sort(keys)
+
+	return keys
+}
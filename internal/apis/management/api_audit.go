@@ -0,0 +1,76 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/zhulik/d3/internal/core"
+)
+
+const defaultAuditQueryLimit = 1000
+
+type APIAudit struct {
+	AuditLog core.AuditLog
+	Echo     *Echo
+}
+
+func (a APIAudit) Init(_ context.Context) error {
+	a.Echo.GET("/audit", a.QueryAudit)
+
+	return nil
+}
+
+// QueryAudit returns the audit events matching the from and to RFC 3339 times, the user and the action, oldest
+// first. At most limit events are returned, 1000 by default.
+func (a APIAudit) QueryAudit(c *echo.Context) error {
+	query, err := parseAuditQuery(c)
+	if err != nil {
+		return err
+	}
+
+	events, err := a.AuditLog.Query(c.Request().Context(), query)
+	if err != nil {
+		return err
+	}
+
+	if events == nil {
+		events = []core.AuditEvent{}
+	}
+
+	return c.JSON(http.StatusOK, events)
+}
+
+func parseAuditQuery(c *echo.Context) (core.AuditQuery, error) {
+	query := core.AuditQuery{
+		User:   c.QueryParam("user"),
+		Action: c.QueryParam("action"),
+		Limit:  defaultAuditQueryLimit,
+	}
+
+	var err error
+
+	for name, target := range map[string]*time.Time{"from": &query.From, "to": &query.To} {
+		value := c.QueryParam(name)
+		if value == "" {
+			continue
+		}
+
+		*target, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return query, fmt.Errorf("%w: %s: %w", core.ErrAuditQueryInvalid, name, err)
+		}
+	}
+
+	if value := c.QueryParam("limit"); value != "" {
+		query.Limit, err = strconv.Atoi(value)
+		if err != nil || query.Limit <= 0 {
+			return query, fmt.Errorf("%w: limit must be a positive integer", core.ErrAuditQueryInvalid)
+		}
+	}
+
+	return query, nil
+}
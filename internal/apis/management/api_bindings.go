@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v5"
+	"github.com/zhulik/d3/internal/apictx"
 	"github.com/zhulik/d3/internal/core"
 )
 
@@ -76,6 +77,8 @@ func (a APIBindings) CreateBinding(c *echo.Context) error {
 		PolicyID: r.PolicyID,
 	}
 
+	apictx.FromContext(c.Request().Context()).Resource = "/bindings/user/" + r.UserName + "/policy/" + r.PolicyID
+
 	err = a.Backend.CreateBinding(c.Request().Context(), binding)
 	if err != nil {
 		return err
@@ -0,0 +1,178 @@
+package management
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+)
+
+type moveBucketRequestBody struct {
+	Root string `json:"root"`
+}
+
+type APIBuckets struct {
+	Storage core.StorageBackend
+	Echo    *Echo
+}
+
+func (a APIBuckets) Init(_ context.Context) error {
+	a.Echo.GET("/buckets/:bucketName/compression", a.GetCompression)
+	a.Echo.PUT("/buckets/:bucketName/compression", a.PutCompression)
+	a.Echo.DELETE("/buckets/:bucketName/compression", a.DeleteCompression)
+
+	a.Echo.GET("/buckets/:bucketName/quota", a.GetQuota)
+	a.Echo.PUT("/buckets/:bucketName/quota", a.PutQuota)
+	a.Echo.DELETE("/buckets/:bucketName/quota", a.DeleteQuota)
+
+	a.Echo.GET("/buckets/:bucketName/usage", a.GetUsage)
+	a.Echo.POST("/buckets/:bucketName/usage/rescan", a.RescanUsage)
+
+	a.Echo.GET("/storage/roots", a.GetStorageRoots)
+	a.Echo.PUT("/buckets/:bucketName/root", a.MoveBucket)
+
+	return nil
+}
+
+// GetCompression returns the bucket's compression override, empty when the storage backend config applies.
+func (a APIBuckets) GetCompression(c *echo.Context) error {
+	bucket, err := a.Storage.HeadBucket(c.Request().Context(), c.Param("bucketName"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lo.FromPtr(bucket.Compression()))
+}
+
+// PutCompression replaces the compression of the objects later written to the bucket.
+func (a APIBuckets) PutCompression(c *echo.Context) error {
+	compression, err := validateBodyChecksumAndParseJSON[core.BucketCompression](c)
+	if err != nil {
+		return err
+	}
+
+	bucket, err := a.Storage.HeadBucket(c.Request().Context(), c.Param("bucketName"))
+	if err != nil {
+		return err
+	}
+
+	if err := bucket.PutCompression(c.Request().Context(), compression); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, compression)
+}
+
+// DeleteCompression removes the bucket's compression override, the storage backend config applies again.
+func (a APIBuckets) DeleteCompression(c *echo.Context) error {
+	bucket, err := a.Storage.HeadBucket(c.Request().Context(), c.Param("bucketName"))
+	if err != nil {
+		return err
+	}
+
+	if err := bucket.PutCompression(c.Request().Context(), nil); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetQuota returns the bucket's quota, empty when it has none.
+func (a APIBuckets) GetQuota(c *echo.Context) error {
+	bucket, err := a.Storage.HeadBucket(c.Request().Context(), c.Param("bucketName"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lo.FromPtr(bucket.Quota()))
+}
+
+// PutQuota replaces the bucket's quota, enforced on the writes that follow.
+func (a APIBuckets) PutQuota(c *echo.Context) error {
+	quota, err := validateBodyChecksumAndParseJSON[core.BucketQuota](c)
+	if err != nil {
+		return err
+	}
+
+	bucket, err := a.Storage.HeadBucket(c.Request().Context(), c.Param("bucketName"))
+	if err != nil {
+		return err
+	}
+
+	if err := bucket.PutQuota(c.Request().Context(), quota); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, quota)
+}
+
+// DeleteQuota removes the bucket's quota.
+func (a APIBuckets) DeleteQuota(c *echo.Context) error {
+	bucket, err := a.Storage.HeadBucket(c.Request().Context(), c.Param("bucketName"))
+	if err != nil {
+		return err
+	}
+
+	if err := bucket.PutQuota(c.Request().Context(), nil); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetUsage returns the bytes and objects the bucket holds, as tracked by its writes.
+func (a APIBuckets) GetUsage(c *echo.Context) error {
+	bucket, err := a.Storage.HeadBucket(c.Request().Context(), c.Param("bucketName"))
+	if err != nil {
+		return err
+	}
+
+	usage, err := bucket.Usage(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, usage)
+}
+
+// RescanUsage recomputes the bucket's usage by walking its objects, fixing drift of the tracked usage.
+func (a APIBuckets) RescanUsage(c *echo.Context) error {
+	bucket, err := a.Storage.HeadBucket(c.Request().Context(), c.Param("bucketName"))
+	if err != nil {
+		return err
+	}
+
+	usage, err := bucket.RescanUsage(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, usage)
+}
+
+// GetStorageRoots describes the directories buckets are stored in and the buckets on each of them.
+func (a APIBuckets) GetStorageRoots(c *echo.Context) error {
+	roots, err := a.Storage.StorageRoots(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, roots)
+}
+
+// MoveBucket moves the bucket to another storage root, it responds once the bucket is moved. The bucket stays
+// readable in the meantime.
+func (a APIBuckets) MoveBucket(c *echo.Context) error {
+	r, err := validateBodyChecksumAndParseJSON[moveBucketRequestBody](c)
+	if err != nil {
+		return err
+	}
+
+	if err := a.Storage.MoveBucket(c.Request().Context(), c.Param("bucketName"), r.Root); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, r)
+}
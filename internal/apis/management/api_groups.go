@@ -0,0 +1,160 @@
+package management
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+	"github.com/zhulik/d3/internal/apictx"
+	"github.com/zhulik/d3/internal/core"
+)
+
+type createGroupRequestBody struct {
+	Name string `json:"name"`
+}
+
+type addGroupMemberRequestBody struct {
+	UserName string `json:"user_name"`
+}
+
+type createGroupBindingRequestBody struct {
+	PolicyID string `json:"policy_id"`
+}
+
+type APIGroups struct {
+	Backend core.ManagementBackend
+	Echo    *Echo
+}
+
+func (a APIGroups) Init(_ context.Context) error {
+	groups := a.Echo.Group("/groups")
+
+	groups.GET("", a.ListGroups)
+	groups.POST("", a.CreateGroup)
+	groups.GET("/:groupName", a.GetGroup)
+	groups.DELETE("/:groupName", a.DeleteGroup)
+
+	groups.POST("/:groupName/members", a.AddGroupMember)
+	groups.DELETE("/:groupName/members/:userName", a.RemoveGroupMember)
+
+	groups.GET("/:groupName/policies", a.GetBindingsByGroup)
+	groups.POST("/:groupName/policies", a.CreateGroupBinding)
+	groups.DELETE("/:groupName/policies/:policyID", a.DeleteGroupBinding)
+
+	return nil
+}
+
+// ListGroups returns a list of groups.
+func (a APIGroups) ListGroups(c *echo.Context) error {
+	groups, err := a.Backend.GetGroups(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, groups)
+}
+
+// CreateGroup creates an empty group.
+func (a APIGroups) CreateGroup(c *echo.Context) error {
+	r, err := validateBodyChecksumAndParseJSON[createGroupRequestBody](c)
+	if err != nil {
+		return err
+	}
+
+	apictx.FromContext(c.Request().Context()).Resource = "/groups/" + r.Name
+
+	err = a.Backend.CreateGroup(c.Request().Context(), r.Name)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, core.Group{Name: r.Name, Members: []string{}})
+}
+
+// GetGroup returns the group with its members.
+func (a APIGroups) GetGroup(c *echo.Context) error {
+	group, err := a.Backend.GetGroupByName(c.Request().Context(), c.Param("groupName"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, group)
+}
+
+// DeleteGroup deletes the group and its policy bindings.
+func (a APIGroups) DeleteGroup(c *echo.Context) error {
+	err := a.Backend.DeleteGroup(c.Request().Context(), c.Param("groupName"))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// AddGroupMember adds a user to the group.
+func (a APIGroups) AddGroupMember(c *echo.Context) error {
+	r, err := validateBodyChecksumAndParseJSON[addGroupMemberRequestBody](c)
+	if err != nil {
+		return err
+	}
+
+	err = a.Backend.AddGroupMember(c.Request().Context(), c.Param("groupName"), r.UserName)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RemoveGroupMember removes a user from the group.
+func (a APIGroups) RemoveGroupMember(c *echo.Context) error {
+	err := a.Backend.RemoveGroupMember(c.Request().Context(), c.Param("groupName"), c.Param("userName"))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetBindingsByGroup returns the policies bound to the group.
+func (a APIGroups) GetBindingsByGroup(c *echo.Context) error {
+	bindings, err := a.Backend.GetBindingsByGroup(c.Request().Context(), c.Param("groupName"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, bindings)
+}
+
+// CreateGroupBinding binds a policy to the group.
+func (a APIGroups) CreateGroupBinding(c *echo.Context) error {
+	r, err := validateBodyChecksumAndParseJSON[createGroupBindingRequestBody](c)
+	if err != nil {
+		return err
+	}
+
+	binding := &core.GroupBinding{
+		GroupName: c.Param("groupName"),
+		PolicyID:  r.PolicyID,
+	}
+
+	err = a.Backend.CreateGroupBinding(c.Request().Context(), binding)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, binding)
+}
+
+// DeleteGroupBinding unbinds a policy from the group.
+func (a APIGroups) DeleteGroupBinding(c *echo.Context) error {
+	err := a.Backend.DeleteGroupBinding(c.Request().Context(), &core.GroupBinding{
+		GroupName: c.Param("groupName"),
+		PolicyID:  c.Param("policyID"),
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
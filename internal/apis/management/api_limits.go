@@ -0,0 +1,146 @@
+package management
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/internal/ratelimit"
+)
+
+type APILimits struct {
+	Backend core.ManagementBackend
+	Limiter *ratelimit.Limiter
+	Echo    *Echo
+}
+
+func (a APILimits) Init(_ context.Context) error {
+	a.Echo.GET("/users/:userName/limits", a.GetUserLimits)
+	a.Echo.PUT("/users/:userName/limits", a.PutUserLimits)
+	a.Echo.DELETE("/users/:userName/limits", a.DeleteUserLimits)
+
+	a.Echo.GET("/groups/:groupName/limits", a.GetGroupLimits)
+	a.Echo.PUT("/groups/:groupName/limits", a.PutGroupLimits)
+	a.Echo.DELETE("/groups/:groupName/limits", a.DeleteGroupLimits)
+
+	a.Echo.GET("/roles/:roleName/limits", a.GetRoleLimits)
+	a.Echo.PUT("/roles/:roleName/limits", a.PutRoleLimits)
+	a.Echo.DELETE("/roles/:roleName/limits", a.DeleteRoleLimits)
+
+	a.Echo.GET("/limits/stats", a.GetStats)
+
+	return nil
+}
+
+// GetUserLimits returns the user's own rate limits, empty when it has none.
+func (a APILimits) GetUserLimits(c *echo.Context) error {
+	user, err := a.Backend.GetUserByName(c.Request().Context(), c.Param("userName"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lo.FromPtr(user.Limits))
+}
+
+// PutUserLimits replaces the user's rate limits, which take precedence over the limits of its groups.
+func (a APILimits) PutUserLimits(c *echo.Context) error {
+	limits, err := validateBodyChecksumAndParseJSON[core.RateLimits](c)
+	if err != nil {
+		return err
+	}
+
+	err = a.Backend.SetUserLimits(c.Request().Context(), c.Param("userName"), limits)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, limits)
+}
+
+// DeleteUserLimits removes the user's rate limits, the limits of its groups apply again.
+func (a APILimits) DeleteUserLimits(c *echo.Context) error {
+	err := a.Backend.SetUserLimits(c.Request().Context(), c.Param("userName"), nil)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetGroupLimits returns the rate limits of the group's members, empty when the group has none.
+func (a APILimits) GetGroupLimits(c *echo.Context) error {
+	group, err := a.Backend.GetGroupByName(c.Request().Context(), c.Param("groupName"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lo.FromPtr(group.Limits))
+}
+
+// PutGroupLimits replaces the rate limits of the group's members.
+func (a APILimits) PutGroupLimits(c *echo.Context) error {
+	limits, err := validateBodyChecksumAndParseJSON[core.RateLimits](c)
+	if err != nil {
+		return err
+	}
+
+	err = a.Backend.SetGroupLimits(c.Request().Context(), c.Param("groupName"), limits)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, limits)
+}
+
+// DeleteGroupLimits removes the rate limits of the group's members.
+func (a APILimits) DeleteGroupLimits(c *echo.Context) error {
+	err := a.Backend.SetGroupLimits(c.Request().Context(), c.Param("groupName"), nil)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetRoleLimits returns the rate limits of the role's web identity sessions, empty when the role has none.
+func (a APILimits) GetRoleLimits(c *echo.Context) error {
+	role, err := a.Backend.GetRoleByName(c.Request().Context(), c.Param("roleName"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lo.FromPtr(role.Limits))
+}
+
+// PutRoleLimits replaces the rate limits of the role's web identity sessions.
+func (a APILimits) PutRoleLimits(c *echo.Context) error {
+	limits, err := validateBodyChecksumAndParseJSON[core.RateLimits](c)
+	if err != nil {
+		return err
+	}
+
+	err = a.Backend.SetRoleLimits(c.Request().Context(), c.Param("roleName"), limits)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, limits)
+}
+
+// DeleteRoleLimits removes the rate limits of the role's web identity sessions.
+func (a APILimits) DeleteRoleLimits(c *echo.Context) error {
+	err := a.Backend.SetRoleLimits(c.Request().Context(), c.Param("roleName"), nil)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetStats returns the request and throttling counters of the users and anonymous clients this instance has
+// seen recently.
+func (a APILimits) GetStats(c *echo.Context) error {
+	return c.JSON(http.StatusOK, a.Limiter.Stats())
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v5"
+	"github.com/zhulik/d3/internal/apictx"
 	"github.com/zhulik/d3/internal/core"
 	"github.com/zhulik/d3/pkg/iampol"
 	"github.com/zhulik/d3/pkg/smartio"
@@ -54,6 +55,8 @@ func (a APIPolicies) CreatePolicy(c *echo.Context) error {
 		return err
 	}
 
+	apictx.FromContext(c.Request().Context()).Resource = "/policies/" + policy.ID
+
 	err = a.Backend.CreatePolicy(c.Request().Context(), policy)
 	if err != nil {
 		return err
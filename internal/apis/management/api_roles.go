@@ -0,0 +1,78 @@
+package management
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+	"github.com/zhulik/d3/internal/apictx"
+	"github.com/zhulik/d3/internal/core"
+)
+
+type APIRoles struct {
+	Backend core.ManagementBackend
+	Echo    *Echo
+}
+
+func (a APIRoles) Init(_ context.Context) error {
+	roles := a.Echo.Group("/roles")
+
+	roles.GET("", a.ListRoles)
+	roles.POST("", a.CreateRole)
+	roles.GET("/:roleName", a.GetRole)
+	roles.DELETE("/:roleName", a.DeleteRole)
+
+	return nil
+}
+
+// ListRoles returns a list of roles.
+func (a APIRoles) ListRoles(c *echo.Context) error {
+	roles, err := a.Backend.GetRoles(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, roles)
+}
+
+// CreateRole creates a role with its policies and trusted users.
+func (a APIRoles) CreateRole(c *echo.Context) error {
+	role, err := validateBodyChecksumAndParseJSON[core.Role](c)
+	if err != nil {
+		return err
+	}
+
+	apictx.FromContext(c.Request().Context()).Resource = "/roles/" + role.Name
+
+	err = a.Backend.CreateRole(c.Request().Context(), role)
+	if err != nil {
+		return err
+	}
+
+	created, err := a.Backend.GetRoleByName(c.Request().Context(), role.Name)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, created)
+}
+
+// GetRole returns the role.
+func (a APIRoles) GetRole(c *echo.Context) error {
+	role, err := a.Backend.GetRoleByName(c.Request().Context(), c.Param("roleName"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole deletes the role, sessions issued for it stop being authorized.
+func (a APIRoles) DeleteRole(c *echo.Context) error {
+	err := a.Backend.DeleteRole(c.Request().Context(), c.Param("roleName"))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,86 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v5"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/apictx"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/s3actions"
+)
+
+type APISimulate struct {
+	Backend    core.ManagementBackend
+	Authorizer core.Authorizer
+	Echo       *Echo
+}
+
+func (a APISimulate) Init(_ context.Context) error {
+	a.Echo.POST("/simulate", a.Simulate)
+
+	return nil
+}
+
+// Simulate evaluates the user's policies for every action and resource pair the way the S3 authorizer would.
+func (a APISimulate) Simulate(c *echo.Context) error {
+	ctx := c.Request().Context()
+
+	simulation, err := validateBodyChecksumAndParseJSON[core.PolicySimulation](c)
+	if err != nil {
+		return err
+	}
+
+	err = validateSimulation(simulation)
+	if err != nil {
+		return err
+	}
+
+	user, err := a.Backend.GetUserByName(ctx, simulation.UserName)
+	if err != nil {
+		return err
+	}
+
+	ctx = apictx.WithSecureTransport(ctx, simulation.SecureTransport)
+
+	results := make([]core.PolicySimulationResult, 0, len(simulation.Actions)*len(simulation.Resources))
+
+	for _, action := range simulation.Actions {
+		for _, resource := range simulation.Resources {
+			decision, err := a.Authorizer.Evaluate(ctx, user, action, strings.TrimPrefix(resource, iampol.ResourcePrefix))
+			if err != nil {
+				return err
+			}
+
+			results = append(results, core.PolicySimulationResult{
+				Action:                action,
+				Resource:              resource,
+				AuthorizationDecision: *decision,
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+func validateSimulation(simulation *core.PolicySimulation) error {
+	if simulation.UserName == "" {
+		return fmt.Errorf("%w: user_name is required", core.ErrPolicySimulationInvalid)
+	}
+
+	if len(simulation.Actions) == 0 || len(simulation.Resources) == 0 {
+		return fmt.Errorf("%w: actions and resources are required", core.ErrPolicySimulationInvalid)
+	}
+
+	for _, action := range simulation.Actions {
+		if action == s3actions.All || !lo.Contains(s3actions.Actions, action) {
+			return fmt.Errorf("%w: unknown action %q", core.ErrPolicySimulationInvalid, action)
+		}
+	}
+
+	return nil
+}
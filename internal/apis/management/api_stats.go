@@ -0,0 +1,98 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v5"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/internal/stats"
+)
+
+type APIStats struct {
+	Storage   core.StorageBackend
+	Scanner   *stats.Scanner
+	Transfers *stats.Transfers
+	Echo      *Echo
+}
+
+func (a APIStats) Init(_ context.Context) error {
+	a.Echo.GET("/stats/dedup", a.GetDedupStats)
+	a.Echo.GET("/stats/storage", a.GetStorageStats)
+	a.Echo.GET("/stats/transfers", a.GetTransferStats)
+
+	return nil
+}
+
+// GetDedupStats reports how much storage the deduplication of blobs saves.
+func (a APIStats) GetDedupStats(c *echo.Context) error {
+	stats, err := a.Storage.DedupStats(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
+
+// GetStorageStats reports the statistics of the last storage scan. With refresh=true the storage is scanned first,
+// with prefixes=true buckets are broken down by top-level prefix.
+func (a APIStats) GetStorageStats(c *echo.Context) error {
+	refresh, err := boolQueryParam(c, "refresh")
+	if err != nil {
+		return err
+	}
+
+	prefixes, err := boolQueryParam(c, "prefixes")
+	if err != nil {
+		return err
+	}
+
+	stats, err := a.Scanner.Stats(c.Request().Context(), refresh)
+	if err != nil {
+		return err
+	}
+
+	if !prefixes {
+		result := *stats
+		result.Buckets = make([]core.BucketStats, len(stats.Buckets))
+
+		for i, bucket := range stats.Buckets {
+			bucket.Prefixes = nil
+			result.Buckets[i] = bucket
+		}
+
+		stats = &result
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
+
+// GetTransferStats reports the bytes every user uploaded and downloaded with S3 requests.
+func (a APIStats) GetTransferStats(c *echo.Context) error {
+	totals, err := a.Transfers.Totals(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	if totals == nil {
+		totals = []core.TransferStats{}
+	}
+
+	return c.JSON(http.StatusOK, totals)
+}
+
+func boolQueryParam(c *echo.Context, name string) (bool, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return false, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s must be a boolean", core.ErrStatsQueryInvalid, name)
+	}
+
+	return parsed, nil
+}
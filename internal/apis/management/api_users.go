@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v5"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/apictx"
 	"github.com/zhulik/d3/internal/core"
-	"github.com/zhulik/d3/pkg/credentials"
 	"github.com/zhulik/d3/pkg/json"
 	"github.com/zhulik/d3/pkg/smartio"
 )
@@ -22,6 +24,23 @@ type createUserResponseBody struct {
 	SecretAccessKey string `json:"secret_access_key"`
 }
 
+type createAccessKeyRequestBody struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type updateAccessKeyRequestBody struct {
+	Status core.AccessKeyStatus `json:"status"`
+}
+
+// accessKeyResponseBody is an access key without its secret, which is only returned when the key is created.
+type accessKeyResponseBody struct {
+	AccessKeyID string               `json:"access_key_id"`
+	Status      core.AccessKeyStatus `json:"status"`
+	CreatedAt   time.Time            `json:"created_at"`
+	ExpiresAt   *time.Time           `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time           `json:"last_used_at,omitempty"`
+}
+
 type APIUsers struct {
 	Backend core.ManagementBackend
 	Echo    *Echo
@@ -35,6 +54,11 @@ func (a APIUsers) Init(_ context.Context) error {
 	users.PUT("/:userName", a.UpdateUser)
 	users.DELETE("/:userName", a.DeleteUser)
 
+	users.GET("/:userName/keys", a.ListAccessKeys)
+	users.POST("/:userName/keys", a.CreateAccessKey)
+	users.PUT("/:userName/keys/:accessKeyID", a.UpdateAccessKey)
+	users.DELETE("/:userName/keys/:accessKeyID", a.DeleteAccessKey)
+
 	return nil
 }
 
@@ -55,6 +79,8 @@ func (a APIUsers) CreateUser(c *echo.Context) error {
 		return err
 	}
 
+	apictx.FromContext(c.Request().Context()).Resource = "/users/" + r.Name
+
 	user, err := a.Backend.CreateUser(c.Request().Context(), r.Name)
 	if err != nil {
 		return err
@@ -62,34 +88,30 @@ func (a APIUsers) CreateUser(c *echo.Context) error {
 
 	return c.JSON(http.StatusOK, createUserResponseBody{
 		Name:            user.Name,
-		AccessKeyID:     user.AccessKeyID,
-		SecretAccessKey: user.SecretAccessKey,
+		AccessKeyID:     user.AccessKeys[0].AccessKeyID,
+		SecretAccessKey: user.AccessKeys[0].SecretAccessKey,
 	})
 }
 
-// UpdateUser updates the user.
+// UpdateUser replaces all the access keys of the user with a new one and returns it, as it did when users had a
+// single key.
+//
+// Deprecated: kept for older clients, rotate keys with the /users/:userName/keys routes without downtime.
 func (a APIUsers) UpdateUser(c *echo.Context) error {
 	userName := c.Param("userName")
 
-	accessKeyID, secretAccessKey := credentials.GenerateCredentials()
-	user := &core.User{
-		Name:            userName,
-		AccessKeyID:     accessKeyID,
-		SecretAccessKey: secretAccessKey,
-	}
-
-	err := a.Backend.UpdateUser(c.Request().Context(), user)
+	key, err := a.Backend.ReplaceAccessKeys(c.Request().Context(), userName)
 	if err != nil {
 		return err
 	}
 
-	response := createUserResponseBody{
-		Name:            user.Name,
-		AccessKeyID:     user.AccessKeyID,
-		SecretAccessKey: user.SecretAccessKey,
-	}
+	c.Response().Header().Set("Deprecation", "true")
 
-	return c.JSON(http.StatusOK, response)
+	return c.JSON(http.StatusOK, createUserResponseBody{
+		Name:            userName,
+		AccessKeyID:     key.AccessKeyID,
+		SecretAccessKey: key.SecretAccessKey,
+	})
 }
 
 // DeleteUser deletes the user.
@@ -104,6 +126,66 @@ func (a APIUsers) DeleteUser(c *echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// ListAccessKeys returns the user's access keys without their secrets.
+func (a APIUsers) ListAccessKeys(c *echo.Context) error {
+	user, err := a.Backend.GetUserByName(c.Request().Context(), c.Param("userName"))
+	if err != nil {
+		return err
+	}
+
+	keys := lo.Map(user.AccessKeys, func(key core.AccessKey, _ int) accessKeyResponseBody {
+		return accessKeyResponseBody{
+			AccessKeyID: key.AccessKeyID,
+			Status:      key.Status,
+			CreatedAt:   key.CreatedAt,
+			ExpiresAt:   key.ExpiresAt,
+			LastUsedAt:  key.LastUsedAt,
+		}
+	})
+
+	return c.JSON(http.StatusOK, keys)
+}
+
+// CreateAccessKey creates an access key for the user and returns it with its secret.
+func (a APIUsers) CreateAccessKey(c *echo.Context) error {
+	r, err := validateBodyChecksumAndParseJSON[createAccessKeyRequestBody](c)
+	if err != nil {
+		return err
+	}
+
+	key, err := a.Backend.CreateAccessKey(c.Request().Context(), c.Param("userName"), r.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, key)
+}
+
+// UpdateAccessKey activates or deactivates the access key.
+func (a APIUsers) UpdateAccessKey(c *echo.Context) error {
+	r, err := validateBodyChecksumAndParseJSON[updateAccessKeyRequestBody](c)
+	if err != nil {
+		return err
+	}
+
+	err = a.Backend.SetAccessKeyStatus(c.Request().Context(), c.Param("userName"), c.Param("accessKeyID"), r.Status)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DeleteAccessKey deletes the access key.
+func (a APIUsers) DeleteAccessKey(c *echo.Context) error {
+	err := a.Backend.DeleteAccessKey(c.Request().Context(), c.Param("userName"), c.Param("accessKeyID"))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
 func validateBodyChecksumAndParseJSON[T any](c *echo.Context) (*T, error) {
 	bodyBytes, calculatedHash, err := smartio.ReadAllAndHash(c.Request().Body)
 	if err != nil {
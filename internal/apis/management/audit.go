@@ -0,0 +1,64 @@
+package management
+
+import (
+	"github.com/labstack/echo/v5"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/apictx"
+	"github.com/zhulik/d3/internal/core"
+)
+
+// auditedRoutes names the management requests that change the state. They are always audited, other requests
+// are only audited when denied.
+var auditedRoutes = map[string]string{ //nolint:gochecknoglobals
+	"POST /users":                               "d3:CreateUser",
+	"PUT /users/:userName":                      "d3:RotateUserKeys",
+	"DELETE /users/:userName":                   "d3:DeleteUser",
+	"POST /users/:userName/keys":                "d3:CreateAccessKey",
+	"PUT /users/:userName/keys/:accessKeyID":    "d3:UpdateAccessKey",
+	"DELETE /users/:userName/keys/:accessKeyID": "d3:DeleteAccessKey",
+	"PUT /users/:userName/limits":               "d3:PutUserLimits",
+	"DELETE /users/:userName/limits":            "d3:DeleteUserLimits",
+
+	"POST /policies":             "d3:CreatePolicy",
+	"PUT /policies/:policyID":    "d3:UpdatePolicy",
+	"DELETE /policies/:policyID": "d3:DeletePolicy",
+
+	"POST /bindings": "d3:CreateBinding",
+	"DELETE /bindings/user/:userName/policy/:policyID": "d3:DeleteBinding",
+
+	"POST /groups":                                 "d3:CreateGroup",
+	"DELETE /groups/:groupName":                    "d3:DeleteGroup",
+	"POST /groups/:groupName/members":              "d3:AddGroupMember",
+	"DELETE /groups/:groupName/members/:userName":  "d3:RemoveGroupMember",
+	"POST /groups/:groupName/policies":             "d3:CreateGroupBinding",
+	"DELETE /groups/:groupName/policies/:policyID": "d3:DeleteGroupBinding",
+	"PUT /groups/:groupName/limits":                "d3:PutGroupLimits",
+	"DELETE /groups/:groupName/limits":             "d3:DeleteGroupLimits",
+
+	"POST /roles":                    "d3:CreateRole",
+	"DELETE /roles/:roleName":        "d3:DeleteRole",
+	"PUT /roles/:roleName/limits":    "d3:PutRoleLimits",
+	"DELETE /roles/:roleName/limits": "d3:DeleteRoleLimits",
+
+	"POST /apply": "d3:Apply",
+
+	"PUT /buckets/:bucketName/compression":    "d3:PutBucketCompression",
+	"DELETE /buckets/:bucketName/compression": "d3:DeleteBucketCompression",
+	"PUT /buckets/:bucketName/root":           "d3:MoveBucket",
+	"PUT /buckets/:bucketName/quota":          "d3:PutBucketQuota",
+	"DELETE /buckets/:bucketName/quota":       "d3:DeleteBucketQuota",
+	"POST /buckets/:bucketName/usage/rescan":  "d3:RescanBucketUsage",
+}
+
+// describeAudit picks the requests listed in auditedRoutes, and all denied requests. The resource is the request
+// path unless the handler has set a more precise one.
+func describeAudit(c *echo.Context, apiCtx *apictx.APICtx, outcome core.AuditOutcome) (string, string, bool) {
+	route := c.Request().Method + " " + c.Path()
+
+	action, audited := auditedRoutes[route]
+	if !audited && outcome != core.AuditOutcomeDenied {
+		return "", "", false
+	}
+
+	return lo.CoalesceOrEmpty(action, route), lo.CoalesceOrEmpty(apiCtx.Resource, c.Request().URL.Path), true
+}
@@ -17,6 +17,7 @@ type Echo struct {
 
 	Authenticator *middlewares.Authenticator
 	Authorizer    *managementMiddleares.Authorizer
+	Auditor       *middlewares.Auditor
 }
 
 func (e *Echo) Init(_ context.Context) error {
@@ -29,8 +30,9 @@ func (e *Echo) Init(_ context.Context) error {
 		apictx.Middleware(),
 		middlewares.Logger(),
 		middleware.Recover(),
+		e.Auditor.Middleware(describeAudit),
 		middlewares.ErrorRenderer(),
-		e.Authenticator.Middleware(),
+		e.Authenticator.Middleware(middleware.DefaultSkipper),
 		e.Authorizer.Middleware(),
 	)
 
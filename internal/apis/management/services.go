@@ -10,6 +10,14 @@ func Provide() pal.ServiceDef {
 		pal.Provide(&APIUsers{}),
 		pal.Provide(&APIPolicies{}),
 		pal.Provide(&APIBindings{}),
+		pal.Provide(&APIGroups{}),
+		pal.Provide(&APIRoles{}),
+		pal.Provide(&APISimulate{}),
+		pal.Provide(&APIApply{}),
+		pal.Provide(&APIAudit{}),
+		pal.Provide(&APILimits{}),
+		pal.Provide(&APIBuckets{}),
+		pal.Provide(&APIStats{}),
 		pal.Provide(&Server{}),
 		pal.Provide(&Echo{}),
 		middlewares.Provide(),
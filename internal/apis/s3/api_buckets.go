@@ -2,7 +2,12 @@ package s3
 
 import (
 	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
@@ -14,6 +19,11 @@ import (
 	"github.com/zhulik/d3/pkg/s3actions"
 )
 
+const (
+	loggingRequestBodyMax    = 4096
+	encryptionRequestBodyMax = 4096
+)
+
 type APIBuckets struct {
 	Backend core.StorageBackend
 
@@ -25,15 +35,28 @@ func (a APIBuckets) Init(_ context.Context) error {
 	bucketFinder := a.BucketFinder.Middleware()
 	authorizer := a.Echo.Authorizer.Middleware()
 	a.Echo.AddQueryParamRoute("location", a.GetBucketLocation, s3actions.GetBucketLocation, bucketFinder, authorizer)
+	a.Echo.AddQueryParamRoute("logging", a.GetBucketLogging, s3actions.GetBucketLogging, bucketFinder, authorizer)
+	a.Echo.AddQueryParamRoute("encryption", a.GetBucketEncryption, s3actions.GetEncryptionConfiguration,
+		bucketFinder, authorizer)
+	a.Echo.AddQueryParamRoute("object-lock", a.GetObjectLockConfiguration, s3actions.GetBucketObjectLockConfiguration,
+		bucketFinder, authorizer)
 
 	a.Echo.GET("/", a.ListBuckets, middlewares.SetAction(s3actions.ListBuckets), authorizer)
 
 	buckets := a.Echo.Group("/:bucket")
 	buckets.HEAD("", a.HeadBucket, middlewares.SetAction(s3actions.HeadBucket), bucketFinder, authorizer)
-	buckets.PUT("", a.CreateBucket, middlewares.SetAction(s3actions.CreateBucket),
-		middlewares.BucketNameValidator, authorizer)
-	buckets.DELETE("", a.DeleteBucket, middlewares.SetAction(s3actions.DeleteBucket),
-		middlewares.BucketNameValidator, authorizer)
+	buckets.PUT("", NewQueryParamsRouter().
+		SetFallbackHandler(a.CreateBucket, s3actions.CreateBucket, middlewares.BucketNameValidator, authorizer).
+		AddRoute("logging", a.PutBucketLogging, s3actions.PutBucketLogging, bucketFinder, authorizer).
+		AddRoute("encryption", a.PutBucketEncryption, s3actions.PutEncryptionConfiguration, bucketFinder, authorizer).
+		AddRoute("object-lock", a.PutObjectLockConfiguration, s3actions.PutBucketObjectLockConfiguration,
+			bucketFinder, authorizer).
+		Handle)
+	buckets.DELETE("", NewQueryParamsRouter().
+		SetFallbackHandler(a.DeleteBucket, s3actions.DeleteBucket, middlewares.BucketNameValidator, authorizer).
+		AddRoute("encryption", a.DeleteBucketEncryption, s3actions.PutEncryptionConfiguration,
+			bucketFinder, authorizer).
+		Handle)
 
 	return nil
 }
@@ -61,13 +84,25 @@ func (a APIBuckets) ListBuckets(c *echo.Context) error {
 }
 
 func (a APIBuckets) CreateBucket(c *echo.Context) error {
+	ctx := c.Request().Context()
 	name := c.Param("bucket")
 
-	err := a.Backend.CreateBucket(c.Request().Context(), name)
+	err := a.Backend.CreateBucket(ctx, name)
 	if err != nil {
 		return err
 	}
 
+	if strings.EqualFold(c.Request().Header.Get(bucketObjectLockHeader), "true") {
+		bucket, err := a.Backend.HeadBucket(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		if err := bucket.PutObjectLock(ctx, core.BucketObjectLock{}); err != nil {
+			return err
+		}
+	}
+
 	SetHeaders(c, map[string]string{
 		"Location":         "/" + name,
 		"x-amz-bucket-arn": "arn:aws:s3:::" + name,
@@ -107,3 +142,125 @@ func (a APIBuckets) HeadBucket(c *echo.Context) error {
 
 	return c.NoContent(http.StatusOK)
 }
+
+func (a APIBuckets) GetBucketLogging(c *echo.Context) error {
+	bucket := apictx.FromContext(c.Request().Context()).Bucket
+
+	response := bucketLoggingStatusXML{}
+	if logging := bucket.Logging(); logging != nil {
+		response.LoggingEnabled = &loggingEnabledXML{
+			TargetBucket: logging.TargetBucket,
+			TargetPrefix: logging.TargetPrefix,
+		}
+	}
+
+	return c.XML(http.StatusOK, response)
+}
+
+// PutBucketLogging enables server access logging to an existing target bucket, or disables it when the body has
+// no LoggingEnabled element. The caller must be allowed to write the logs to the target bucket and prefix.
+func (a APIBuckets) PutBucketLogging(c *echo.Context) error {
+	ctx := c.Request().Context()
+	apiCtx := apictx.FromContext(ctx)
+	bucket := apiCtx.Bucket
+
+	var req bucketLoggingStatusXML
+	if err := xml.NewDecoder(io.LimitReader(c.Request().Body, loggingRequestBodyMax)).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid XML body")
+	}
+
+	var logging *core.BucketLogging
+
+	if req.LoggingEnabled != nil {
+		target := req.LoggingEnabled.TargetBucket
+
+		if err := core.ValidateBucketName(target); err != nil {
+			return fmt.Errorf("%w: %w", core.ErrInvalidLoggingTarget, err)
+		}
+
+		_, err := a.Backend.HeadBucket(ctx, target)
+		if errors.Is(err, core.ErrBucketNotFound) {
+			return fmt.Errorf("%w: bucket %s does not exist", core.ErrInvalidLoggingTarget, target)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		allowed, err := a.Echo.Authorizer.Authorizer.IsAllowed(ctx, apiCtx.User, s3actions.PutObject,
+			target+"/"+req.LoggingEnabled.TargetPrefix)
+		if err != nil {
+			return err
+		}
+
+		if !allowed {
+			return core.ErrUnauthorized
+		}
+
+		logging = &core.BucketLogging{
+			TargetBucket: req.LoggingEnabled.TargetBucket,
+			TargetPrefix: req.LoggingEnabled.TargetPrefix,
+		}
+	}
+
+	if err := bucket.PutLogging(ctx, logging); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (a APIBuckets) GetBucketEncryption(c *echo.Context) error {
+	bucket := apictx.FromContext(c.Request().Context()).Bucket
+
+	encryption := bucket.Encryption()
+	if encryption == nil {
+		return core.ErrBucketEncryptionNotFound
+	}
+
+	return c.XML(http.StatusOK, serverSideEncryptionConfigurationXML{
+		Rules: []serverSideEncryptionRuleXML{{
+			ApplyServerSideEncryptionByDefault: &applyServerSideEncryptionByDefaultXML{
+				SSEAlgorithm: encryption.Algorithm,
+			},
+		}},
+	})
+}
+
+// PutBucketEncryption sets the default encryption of the bucket, only SSE-S3 (AES256) is supported.
+func (a APIBuckets) PutBucketEncryption(c *echo.Context) error {
+	ctx := c.Request().Context()
+	bucket := apictx.FromContext(ctx).Bucket
+
+	var req serverSideEncryptionConfigurationXML
+	if err := xml.NewDecoder(io.LimitReader(c.Request().Body, encryptionRequestBodyMax)).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid XML body")
+	}
+
+	if len(req.Rules) != 1 || req.Rules[0].ApplyServerSideEncryptionByDefault == nil {
+		return fmt.Errorf("%w: exactly one rule with ApplyServerSideEncryptionByDefault is expected",
+			core.ErrSSEInvalidRequest)
+	}
+
+	algorithm := req.Rules[0].ApplyServerSideEncryptionByDefault.SSEAlgorithm
+	if algorithm != core.SSEAlgorithmAES256 {
+		return fmt.Errorf("%w: unsupported algorithm %q", core.ErrSSEInvalidRequest, algorithm)
+	}
+
+	if err := bucket.PutEncryption(ctx, &core.BucketEncryption{Algorithm: algorithm}); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (a APIBuckets) DeleteBucketEncryption(c *echo.Context) error {
+	ctx := c.Request().Context()
+	bucket := apictx.FromContext(ctx).Bucket
+
+	if err := bucket.PutEncryption(ctx, nil); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
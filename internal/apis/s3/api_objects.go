@@ -28,11 +28,14 @@ import (
 )
 
 const (
-	StreamingHMACSHA256   = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
-	maxObjectTagCount     = 10
-	maxObjectTagKeyLength = 128  // Unicode characters per S3
-	maxObjectTagValLength = 256  // Unicode characters per S3
-	taggingRequestBodyMax = 1024 // 1 KB for PutObjectTagging XML
+	StreamingHMACSHA256             = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	StreamingHMACSHA256Trailer      = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	StreamingUnsignedPayloadTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+	UnsignedPayload                 = "UNSIGNED-PAYLOAD"
+	maxObjectTagCount               = 10
+	maxObjectTagKeyLength           = 128  // Unicode characters per S3
+	maxObjectTagValLength           = 256  // Unicode characters per S3
+	taggingRequestBodyMax           = 1024 // 1 KB for PutObjectTagging XML
 )
 
 type APIObjects struct {
@@ -57,6 +60,10 @@ func (a APIObjects) Init(_ context.Context) error {
 	objects.PUT("", NewQueryParamsRouter().
 		SetFallbackHandler(a.PutObject, s3actions.PutObject, bucketFinder, authorizer).
 		AddRoute("tagging", a.PutObjectTagging, s3actions.PutObjectTagging, bucketFinder, objectFinder, authorizer).
+		AddRoute("retention", a.PutObjectRetention, s3actions.PutObjectRetention, bucketFinder, objectFinder,
+			authorizer).
+		AddRoute("legal-hold", a.PutObjectLegalHold, s3actions.PutObjectLegalHold, bucketFinder, objectFinder,
+			authorizer).
 		AddRoute("uploadId", a.UploadPart, s3actions.UploadPart,
 			bucketFinder, middlewares.UploadIDValidator, authorizer).
 		Handle)
@@ -71,6 +78,10 @@ func (a APIObjects) Init(_ context.Context) error {
 		NewQueryParamsRouter().
 			SetFallbackHandler(a.GetObject, s3actions.GetObject, bucketFinder, objectFinder, authorizer).
 			AddRoute("tagging", a.GetObjectTagging, s3actions.GetObjectTagging, bucketFinder, objectFinder, authorizer).
+			AddRoute("retention", a.GetObjectRetention, s3actions.GetObjectRetention, bucketFinder, objectFinder,
+				authorizer).
+			AddRoute("legal-hold", a.GetObjectLegalHold, s3actions.GetObjectLegalHold, bucketFinder, objectFinder,
+				authorizer).
 			AddRoute("uploadId", a.ListParts, s3actions.ListParts,
 				bucketFinder, middlewares.UploadIDValidator, authorizer).
 			Handle,
@@ -95,6 +106,10 @@ func (a APIObjects) Init(_ context.Context) error {
 func (a APIObjects) HeadObject(c *echo.Context) error {
 	object := apictx.FromContext(c.Request().Context()).Object
 
+	if err := unlockObject(object, c.Request().Header, sseCustomerPrefix); err != nil {
+		return err
+	}
+
 	metadata := object.Metadata()
 
 	cond := conditionalheaders.Parse(c.Request().Header)
@@ -128,19 +143,26 @@ func (a APIObjects) PutObject(c *echo.Context) error {
 		return err
 	}
 
-	reader := c.Request().Body
 	sha256 := c.Request().Header.Get("X-Amz-Content-Sha256")
 
-	if sha256 == StreamingHMACSHA256 {
-		user := apiCtx.User
-		authParams := apiCtx.AuthParams
+	encryption, err := parseSSE(c.Request().Header)
+	if err != nil {
+		return err
+	}
 
-		signer := sigv4.NewChunkSigner(
-			authParams.ScopeRegion, authParams.ScopeService,
-			authParams.RawSignature(), authParams.RequestTime,
-			user.AccessKeyID, user.SecretAccessKey,
-		)
-		reader = sigv4.NewChunkedReader(reader, signer)
+	retention, legalHold, err := parseObjectLock(c.Request().Header)
+	if err != nil {
+		return err
+	}
+
+	bypass, err := a.bypassGovernance(c, key)
+	if err != nil {
+		return err
+	}
+
+	reader, err := payloadReader(c)
+	if err != nil {
+		return err
 	}
 
 	cond := conditionalheaders.Parse(c.Request().Header)
@@ -151,14 +173,18 @@ func (a APIObjects) PutObject(c *echo.Context) error {
 	}
 
 	err = bucket.PutObject(c.Request().Context(), key, core.PutObjectInput{
-		Reader:      reader,
-		IfNoneMatch: ifNoneMatch,
+		Reader:           reader,
+		IfNoneMatch:      ifNoneMatch,
+		Encryption:       encryption,
+		BypassGovernance: bypass,
 		Metadata: core.ObjectMetadata{
 			ContentType: c.Request().Header.Get("Content-Type"),
 			SHA256:      sha256,
 			Size:        c.Request().ContentLength,
 			Tags:        tags,
 			Meta:        parseMeta(c),
+			Retention:   retention,
+			LegalHold:   legalHold,
 		},
 	})
 	if err != nil {
@@ -168,6 +194,55 @@ func (a APIObjects) PutObject(c *echo.Context) error {
 	return c.NoContent(http.StatusOK)
 }
 
+// payloadReader returns the request body, decoding aws-chunked payloads: STREAMING-AWS4-HMAC-SHA256-PAYLOAD
+// ones and the trailer variants, whose trailing checksum is verified against the decoded data.
+func payloadReader(c *echo.Context) (io.ReadCloser, error) {
+	reader := c.Request().Body
+	trailer := c.Request().Header.Get("X-Amz-Trailer")
+
+	switch c.Request().Header.Get("X-Amz-Content-Sha256") {
+	case StreamingHMACSHA256:
+		signer, err := chunkSigner(c)
+		if err != nil {
+			return nil, err
+		}
+
+		return sigv4.NewChunkedReader(reader, signer), nil
+	case StreamingHMACSHA256Trailer:
+		signer, err := chunkSigner(c)
+		if err != nil {
+			return nil, err
+		}
+
+		return sigv4.NewTrailerChunkedReader(reader, signer, trailer)
+	case StreamingUnsignedPayloadTrailer:
+		return sigv4.NewTrailerChunkedReader(reader, nil, trailer)
+	default:
+		return reader, nil
+	}
+}
+
+// chunkSigner returns the signer of the chunks of a payload, seeded with the signature of the request.
+func chunkSigner(c *echo.Context) (*sigv4.ChunkSigner, error) {
+	apiCtx := apictx.FromContext(c.Request().Context())
+	authParams := apiCtx.AuthParams
+
+	if apiCtx.User == nil || authParams == nil {
+		return nil, fmt.Errorf("%w: signed payload of an anonymous request", sigv4.ErrSignatureDoesNotMatch)
+	}
+
+	key, ok := apiCtx.User.AccessKey(authParams.AccessKey)
+	if !ok {
+		return nil, core.ErrAccessKeyNotFound
+	}
+
+	return sigv4.NewChunkSigner(
+		authParams.ScopeRegion, authParams.ScopeService,
+		authParams.RawSignature(), authParams.RequestTime,
+		key.AccessKeyID, key.SecretAccessKey,
+	), nil
+}
+
 // headObjectBucket is the subset of core.Bucket needed for PutObject conditional evaluation.
 type headObjectBucket interface {
 	HeadObject(ctx context.Context, key string) (core.Object, error)
@@ -250,6 +325,25 @@ func (a APIObjects) CopyObject(c *echo.Context, rawCopySource string) error { //
 	}
 	defer source.Close()
 
+	if err := unlockObject(source, c.Request().Header, copySourceSSECustomerPrefix); err != nil {
+		return err
+	}
+
+	encryption, err := parseSSE(c.Request().Header)
+	if err != nil {
+		return err
+	}
+
+	retention, legalHold, err := parseObjectLock(c.Request().Header)
+	if err != nil {
+		return err
+	}
+
+	bypass, err := a.bypassGovernance(c, dstKey)
+	if err != nil {
+		return err
+	}
+
 	metadataDirective := core.CopyDirective(c.Request().Header.Get("X-Amz-Metadata-Directive"))
 	if metadataDirective == "" {
 		metadataDirective = core.CopyDirectiveCopy
@@ -265,6 +359,10 @@ func (a APIObjects) CopyObject(c *echo.Context, rawCopySource string) error { //
 		MetadataDirective: metadataDirective,
 		TaggingDirective:  taggingDirective,
 		IfNoneMatch:       c.Request().Header.Get("If-None-Match") == "*",
+		Encryption:        encryption,
+		Retention:         retention,
+		LegalHold:         legalHold,
+		BypassGovernance:  bypass,
 	}
 
 	if metadataDirective == core.CopyDirectiveReplace {
@@ -354,6 +452,10 @@ func (a APIObjects) DeleteObjectTagging(c *echo.Context) error {
 func (a APIObjects) GetObject(c *echo.Context) error {
 	object := apictx.FromContext(c.Request().Context()).Object
 
+	if err := unlockObject(object, c.Request().Header, sseCustomerPrefix); err != nil {
+		return err
+	}
+
 	metadata := object.Metadata()
 
 	cond := conditionalheaders.Parse(c.Request().Header)
@@ -366,6 +468,10 @@ func (a APIObjects) GetObject(c *echo.Context) error {
 
 	var reader io.Reader = object
 
+	status := http.StatusOK
+
+	setObjectHeaders(c, metadata)
+
 	if rangeHeader := c.Request().Header.Get("Range"); rangeHeader != "" {
 		parsedRange, err := rangeparser.Parse(rangeHeader, metadata.Size)
 		if err != nil {
@@ -382,11 +488,14 @@ func (a APIObjects) GetObject(c *echo.Context) error {
 			"Accept-Ranges":  "bytes",
 			"Content-Range":  fmt.Sprintf("bytes %d-%d/%d", parsedRange.Start, parsedRange.End, metadata.Size),
 		})
-	} else {
-		setObjectHeaders(c, metadata)
+
+		// The checksum covers the whole object, not the range.
+		c.Response().Header().Del("x-amz-checksum-sha256")
+
+		status = http.StatusPartialContent
 	}
 
-	return c.Stream(http.StatusOK, metadata.ContentType, reader)
+	return c.Stream(status, metadata.ContentType, reader)
 }
 
 func mapObjectsToTypes(objects []core.Object) []*types.Object {
@@ -563,7 +672,15 @@ func (a APIObjects) DeleteObject(c *echo.Context) error {
 	bucket := apictx.FromContext(c.Request().Context()).Bucket
 	key := c.Param("*")
 
-	results, err := bucket.DeleteObjects(c.Request().Context(), false, key)
+	bypass, err := a.bypassGovernance(c, key)
+	if err != nil {
+		return err
+	}
+
+	results, err := bucket.DeleteObjects(c.Request().Context(), core.DeleteObjectsInput{
+		Keys:             []string{key},
+		BypassGovernance: bypass,
+	})
 	if err != nil {
 		return err
 	}
@@ -603,7 +720,16 @@ func (a APIObjects) DeleteObjects(c *echo.Context) error {
 
 	quiet := deleteReq.Quiet != nil && *deleteReq.Quiet
 
-	results, err := bucket.DeleteObjects(c.Request().Context(), quiet, keys...)
+	bypass, err := a.bypassGovernance(c, keys...)
+	if err != nil {
+		return err
+	}
+
+	results, err := bucket.DeleteObjects(c.Request().Context(), core.DeleteObjectsInput{
+		Keys:             keys,
+		Quiet:            quiet,
+		BypassGovernance: bypass,
+	})
 	if err != nil {
 		return err
 	}
@@ -618,8 +744,11 @@ func (a APIObjects) DeleteObjects(c *echo.Context) error {
 			errorCode := "InternalError"
 			errorMessage := result.Error.Error()
 
-			if errors.Is(result.Error, core.ErrObjectNotFound) {
+			switch {
+			case errors.Is(result.Error, core.ErrObjectNotFound):
 				errorCode = "NoSuchKey"
+			case errors.Is(result.Error, core.ErrObjectLocked):
+				errorCode = "AccessDenied"
 			}
 
 			response.Errors = append(response.Errors, errorEntryXML{
@@ -650,12 +779,24 @@ func (a APIObjects) CreateMultipartUpload(c *echo.Context) error {
 		return err
 	}
 
+	encryption, err := parseSSE(c.Request().Header)
+	if err != nil {
+		return err
+	}
+
+	retention, legalHold, err := parseObjectLock(c.Request().Header)
+	if err != nil {
+		return err
+	}
+
 	uploadID, err := bucket.CreateMultipartUpload(c.Request().Context(), key, core.ObjectMetadata{
 		ContentType:  c.Request().Header.Get("Content-Type"),
 		Tags:         tags,
 		LastModified: time.Now(),
 		Meta:         parseMeta(c),
-	})
+		Retention:    retention,
+		LegalHold:    legalHold,
+	}, encryption)
 	if err != nil {
 		return err
 	}
@@ -684,7 +825,23 @@ func (a APIObjects) UploadPart(c *echo.Context) error {
 		return err
 	}
 
-	etag, err := bucket.UploadPart(c.Request().Context(), key, uploadID, partNumberInt, c.Request().Body)
+	// Parts of SSE-C uploads carry the customer key, the rest are encrypted like the upload was created.
+	customerKey, err := parseCustomerKey(c.Request().Header, sseCustomerPrefix)
+	if err != nil {
+		return err
+	}
+
+	var encryption *core.ServerSideEncryption
+	if customerKey != nil {
+		encryption = &core.ServerSideEncryption{CustomerKey: customerKey}
+	}
+
+	reader, err := payloadReader(c)
+	if err != nil {
+		return err
+	}
+
+	etag, err := bucket.UploadPart(c.Request().Context(), key, uploadID, partNumberInt, reader, encryption)
 	if err != nil {
 		return err
 	}
@@ -851,11 +1008,11 @@ func listPartsResultFromCore(
 	if user != nil {
 		response.Owner = &types.Owner{
 			DisplayName: &user.Name,
-			ID:          &user.AccessKeyID,
+			ID:          &user.Name,
 		}
 		response.Initiator = &types.Initiator{
 			DisplayName: &user.Name,
-			ID:          &user.AccessKeyID,
+			ID:          &user.Name,
 		}
 	}
 
@@ -933,6 +1090,14 @@ func setObjectHeaders(c *echo.Context, metadata *core.ObjectMetadata) {
 		"x-amz-tagging-count":   strconv.Itoa(len(metadata.Tags)),
 	})
 	SetHeaders(c, headers)
+
+	// The checksum of SSE-C objects is only known once they are unlocked with their key.
+	if metadata.SHA256Base64 == "" {
+		c.Response().Header().Del("x-amz-checksum-sha256")
+	}
+
+	setEncryptionHeaders(c, metadata.Encryption)
+	setObjectLockHeaders(c, metadata)
 }
 
 func SetHeaders(c *echo.Context, headers map[string]string) {
@@ -125,6 +125,14 @@ func (a *capturingAuthorizer) IsAllowed(
 	return true, nil
 }
 
+func (a *capturingAuthorizer) Evaluate(
+	ctx context.Context, user *core.User, action s3actions.Action, resource string,
+) (*core.AuthorizationDecision, error) {
+	allowed, err := a.IsAllowed(ctx, user, action, resource)
+
+	return &core.AuthorizationDecision{Allowed: allowed}, err
+}
+
 var _ = Describe("S3AuthorizerMiddleware", func() {
 	When("head object request has no preloaded object", func() {
 		It("authorizes against bucket and key from URL", func() {
@@ -0,0 +1,311 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/apictx"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/credentials"
+	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/jwt"
+	"github.com/zhulik/d3/pkg/sigv4"
+	"github.com/zhulik/d3/pkg/wld"
+)
+
+const stsService = "sts"
+
+var roleSessionNameRegexp = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+
+// APISTS serves the subset of the AWS STS API d3 supports. STS clients send form-encoded POST requests to the
+// root of the S3 endpoint, signed for the "sts" service, so the handler validates the signature itself.
+// AssumeRoleWithWebIdentity requests are not signed, the web identity token authenticates the caller.
+type APISTS struct {
+	ManagementBackend   core.ManagementBackend
+	SessionStore        core.SessionStore
+	WebIdentityVerifier core.WebIdentityVerifier
+
+	Echo *Echo
+}
+
+// assumeRoleParams are the parameters shared by the AssumeRole* actions.
+type assumeRoleParams struct {
+	roleName      string
+	sessionName   string
+	duration      time.Duration
+	sessionPolicy *iampol.IAMPolicy
+}
+
+func (a APISTS) Init(_ context.Context) error {
+	a.Echo.POST("/", a.Handle)
+
+	return nil
+}
+
+func (a APISTS) Handle(c *echo.Context) error {
+	ctx := c.Request().Context()
+
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, core.SizeLimit1Mb))
+	if err != nil {
+		return err
+	}
+
+	params, err := url.ParseQuery(string(body))
+	if err != nil {
+		return fmt.Errorf("%w: %w", core.ErrInvalidSTSRequest, err)
+	}
+
+	action := params.Get("Action")
+	if action == "AssumeRoleWithWebIdentity" {
+		return a.AssumeRoleWithWebIdentity(c, params)
+	}
+
+	hash := sha256.Sum256(body)
+
+	authParams, err := sigv4.ValidateService(ctx, c.Request(), stsService, hex.EncodeToString(hash[:]),
+		a.getAccessKeySecret)
+	if err != nil {
+		return err
+	}
+
+	user, err := a.ManagementBackend.GetUserByAccessKeyID(ctx, authParams.AccessKey)
+	if err != nil {
+		return err
+	}
+
+	apiCtx := apictx.FromContext(ctx)
+	apiCtx.User = user
+	apiCtx.AuthParams = authParams
+
+	switch action {
+	case "AssumeRole":
+		return a.AssumeRole(c, user, params)
+	default:
+		return fmt.Errorf("%w: %q", core.ErrSTSActionUnsupported, action)
+	}
+}
+
+func (a APISTS) AssumeRole(c *echo.Context, user *core.User, params url.Values) error {
+	ctx := c.Request().Context()
+
+	p, err := parseAssumeRoleParams(params)
+	if err != nil {
+		return err
+	}
+
+	role, err := a.ManagementBackend.GetRoleByName(ctx, p.roleName)
+	if err != nil {
+		return err
+	}
+
+	if user.Name != "admin" && !slices.Contains(role.TrustedUsers, user.Name) {
+		return fmt.Errorf("%w: %s is not allowed to assume %s", core.ErrUnauthorized, user.Name, role.Name)
+	}
+
+	session, err := a.issueSession(ctx, role, p, &core.Session{UserName: user.Name})
+	if err != nil {
+		return err
+	}
+
+	return c.XML(http.StatusOK, assumeRoleResponseXML{
+		AssumeRoleResult: assumeRoleResultXML{
+			Credentials:     newSTSCredentialsXML(session),
+			AssumedRoleUser: newAssumedRoleUserXML(session),
+		},
+		ResponseMetadata: stsResponseMetadataXML{
+			RequestID: apictx.FromContext(ctx).RequestID,
+		},
+	})
+}
+
+func (a APISTS) AssumeRoleWithWebIdentity(c *echo.Context, params url.Values) error {
+	ctx := c.Request().Context()
+
+	p, err := parseAssumeRoleParams(params)
+	if err != nil {
+		return err
+	}
+
+	claims, err := a.WebIdentityVerifier.Verify(ctx, params.Get("WebIdentityToken"))
+	if err != nil {
+		return err
+	}
+
+	role, err := a.ManagementBackend.GetRoleByName(ctx, p.roleName)
+	if err != nil {
+		return err
+	}
+
+	if !trustsWebIdentity(role, claims) {
+		return fmt.Errorf("%w: the token is not allowed to assume %s", core.ErrUnauthorized, role.Name)
+	}
+
+	session, err := a.issueSession(ctx, role, p, &core.Session{Subject: claims.String("sub")})
+	if err != nil {
+		return err
+	}
+
+	return c.XML(http.StatusOK, assumeRoleWithWebIdentityResponseXML{
+		AssumeRoleWithWebIdentityResult: assumeRoleWithWebIdentityResultXML{
+			Credentials:                 newSTSCredentialsXML(session),
+			AssumedRoleUser:             newAssumedRoleUserXML(session),
+			SubjectFromWebIdentityToken: session.Subject,
+			Audience:                    strings.Join(claims.Audience(), ","),
+			Provider:                    claims.String("iss"),
+		},
+		ResponseMetadata: stsResponseMetadataXML{
+			RequestID: apictx.FromContext(ctx).RequestID,
+		},
+	})
+}
+
+// issueSession generates credentials for the role and stores them along with the caller's identity taken
+// from session.
+func (a APISTS) issueSession(ctx context.Context, role *core.Role, p assumeRoleParams,
+	session *core.Session) (*core.Session, error) {
+	session.AccessKeyID, session.SecretAccessKey, session.SessionToken = credentials.GenerateTemporaryCredentials()
+	session.RoleName = role.Name
+	session.SessionName = p.sessionName
+	session.Policy = p.sessionPolicy
+	session.ExpiresAt = time.Now().UTC().Add(p.duration).Truncate(time.Second)
+
+	err := a.SessionStore.Put(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// getAccessKeySecret resolves long-lived access keys only, temporary credentials cannot assume roles.
+func (a APISTS) getAccessKeySecret(ctx context.Context, accessKey string) (string, error) {
+	if credentials.IsTemporary(accessKey) {
+		return "", fmt.Errorf("%w: temporary credentials cannot assume roles", core.ErrUnauthorized)
+	}
+
+	user, err := a.ManagementBackend.GetUserByAccessKeyID(ctx, accessKey)
+	if err != nil {
+		return "", err
+	}
+
+	key, ok := user.AccessKey(accessKey)
+	if !ok {
+		return "", core.ErrAccessKeyNotFound
+	}
+
+	return key.SecretAccessKey, nil
+}
+
+func parseAssumeRoleParams(params url.Values) (assumeRoleParams, error) {
+	_, roleName, ok := strings.Cut(params.Get("RoleArn"), ":role/")
+	if !ok || roleName == "" {
+		return assumeRoleParams{}, fmt.Errorf("%w: invalid RoleArn", core.ErrInvalidSTSRequest)
+	}
+
+	sessionName := params.Get("RoleSessionName")
+	if !roleSessionNameRegexp.MatchString(sessionName) {
+		return assumeRoleParams{}, fmt.Errorf("%w: invalid RoleSessionName", core.ErrInvalidSTSRequest)
+	}
+
+	duration, err := parseSessionDuration(params.Get("DurationSeconds"))
+	if err != nil {
+		return assumeRoleParams{}, err
+	}
+
+	var sessionPolicy *iampol.IAMPolicy
+
+	if policy := params.Get("Policy"); policy != "" {
+		sessionPolicy, err = iampol.ParseInline([]byte(policy))
+		if err != nil {
+			return assumeRoleParams{}, err
+		}
+	}
+
+	return assumeRoleParams{
+		roleName:      roleName,
+		sessionName:   sessionName,
+		duration:      duration,
+		sessionPolicy: sessionPolicy,
+	}, nil
+}
+
+func parseSessionDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return core.DefaultSessionDuration, nil
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid DurationSeconds", core.ErrInvalidSTSRequest)
+	}
+
+	duration := time.Duration(seconds) * time.Second
+	if duration < core.MinSessionDuration || duration > core.MaxSessionDuration {
+		return 0, fmt.Errorf("%w: DurationSeconds must be between %d and %d", core.ErrInvalidSTSRequest,
+			int(core.MinSessionDuration.Seconds()), int(core.MaxSessionDuration.Seconds()))
+	}
+
+	return duration, nil
+}
+
+// trustsWebIdentity reports whether every pattern of any of the role's web identity conditions matches the
+// token's claims.
+func trustsWebIdentity(role *core.Role, claims jwt.Claims) bool {
+	return lo.SomeBy(role.WebIdentityConditions, func(condition map[string]string) bool {
+		if len(condition) == 0 {
+			return false
+		}
+
+		for claim, pattern := range condition {
+			if !lo.SomeBy(claimValues(claims[claim]), func(value string) bool { return wld.Match(pattern, value) }) {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// claimValues renders a claim as strings for matching, list claims match if any of their values does.
+func claimValues(claim any) []string {
+	switch claim := claim.(type) {
+	case string:
+		return []string{claim}
+	case float64:
+		return []string{strconv.FormatFloat(claim, 'f', -1, 64)}
+	case bool:
+		return []string{strconv.FormatBool(claim)}
+	case []any:
+		return lo.FlatMap(claim, func(value any, _ int) []string { return claimValues(value) })
+	default:
+		return nil
+	}
+}
+
+func newSTSCredentialsXML(session *core.Session) stsCredentialsXML {
+	return stsCredentialsXML{
+		AccessKeyID:     session.AccessKeyID,
+		SecretAccessKey: session.SecretAccessKey,
+		SessionToken:    session.SessionToken,
+		Expiration:      session.ExpiresAt.Format(time.RFC3339),
+	}
+}
+
+func newAssumedRoleUserXML(session *core.Session) assumedRoleUserXML {
+	return assumedRoleUserXML{
+		Arn:           session.ARN(),
+		AssumedRoleID: session.RoleName + ":" + session.SessionName,
+	}
+}
@@ -2,19 +2,38 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"strconv"
 	"strings"
 
 	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/apictx"
 	"github.com/zhulik/d3/internal/core"
 	"github.com/zhulik/d3/pkg/iampol"
 	"github.com/zhulik/d3/pkg/s3actions"
 	"github.com/zhulik/d3/pkg/wld"
 )
 
-const s3ResourcePrefix = "arn:aws:s3:::"
+// auditLogActions are the actions allowed on the bucket of the audit log bucket sink, which is append-only.
+var auditLogActions = []s3actions.Action{ //nolint:gochecknoglobals
+	s3actions.HeadBucket,
+	s3actions.GetBucketLocation,
+	s3actions.GetBucketLogging,
+	s3actions.GetEncryptionConfiguration,
+	s3actions.GetBucketObjectLockConfiguration,
+	s3actions.ListObjectsV2,
+	s3actions.ListMultipartUploads,
+	s3actions.ListParts,
+	s3actions.GetObject,
+	s3actions.HeadObject,
+	s3actions.GetObjectTagging,
+	s3actions.GetObjectRetention,
+	s3actions.GetObjectLegalHold,
+}
 
 // Authorizer decides if a user is allowed to perform an S3 action on a resource.
 type Authorizer struct {
+	Config            *core.Config
 	ManagementBackend core.ManagementBackend
 }
 
@@ -23,76 +42,205 @@ type Authorizer struct {
 func (a *Authorizer) IsAllowed(
 	ctx context.Context, user *core.User, action s3actions.Action, resource string,
 ) (bool, error) {
+	decision, err := a.Evaluate(ctx, user, action, resource)
+	if err != nil {
+		return false, err
+	}
+
+	return decision.Allowed, nil
+}
+
+// Evaluate decides like IsAllowed and reports the policy statement that made the decision.
+func (a *Authorizer) Evaluate(
+	ctx context.Context, user *core.User, action s3actions.Action, resource string,
+) (*core.AuthorizationDecision, error) {
 	if user == nil {
 		// TODO: anonymous access to public buckets
-		return false, nil
+		return &core.AuthorizationDecision{Reason: core.AuthorizationReasonAnonymous}, nil
+	}
+
+	if a.auditLogWrite(action, resource) {
+		return &core.AuthorizationDecision{Reason: core.AuthorizationReasonAuditLog}, nil
+	}
+
+	conditionValues := requestConditionValues(ctx)
+
+	if user.Session != nil {
+		return a.evaluateSession(ctx, user.Session, action, resource, conditionValues)
 	}
 
 	if user.Name == "admin" {
-		return true, nil
+		return &core.AuthorizationDecision{Allowed: true, Reason: core.AuthorizationReasonAdmin}, nil
 	}
 
-	bindings, err := a.ManagementBackend.GetBindingsByUser(ctx, user.Name)
+	policies, err := a.userPolicies(ctx, user.Name)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	// First pass: any Deny that matches overrides
-	for _, binding := range bindings {
-		if ctx.Err() != nil {
-			return false, ctx.Err()
-		}
+	return a.evaluatePolicies(policies, action, resource, conditionValues), nil
+}
 
-		policy, err := a.ManagementBackend.GetPolicyByID(ctx, binding.PolicyID)
-		if err != nil {
-			return false, err
+// requestConditionValues returns the values of the policy condition keys for the request in ctx.
+func requestConditionValues(ctx context.Context) map[string]string {
+	apiCtx := apictx.FromContext(ctx)
+
+	return map[string]string{
+		iampol.ConditionKeySecureTransport: strconv.FormatBool(apiCtx != nil && apiCtx.SecureTransport),
+	}
+}
+
+// auditLogWrite tells whether the action would change the bucket of the audit log bucket sink. Only the sink
+// writes to it, not even the admin can change or delete its events.
+func (a *Authorizer) auditLogWrite(action s3actions.Action, resource string) bool {
+	if a.Config.AuditLogSink != core.AuditLogSinkBucket {
+		return false
+	}
+
+	bucket, _, _ := strings.Cut(resource, "/")
+
+	return bucket == a.Config.AuditLogBucket && !lo.Contains(auditLogActions, action)
+}
+
+// evaluateSession grants what both the session's role and its optional session policy allow.
+func (a *Authorizer) evaluateSession(
+	ctx context.Context, session *core.Session, action s3actions.Action, resource string,
+	conditionValues map[string]string,
+) (*core.AuthorizationDecision, error) {
+	role, err := a.ManagementBackend.GetRoleByName(ctx, session.RoleName)
+	if err != nil {
+		if errors.Is(err, core.ErrRoleNotFound) {
+			// Deleting a role revokes its sessions.
+			return &core.AuthorizationDecision{Reason: core.AuthorizationReasonImplicitDeny}, nil
 		}
 
+		return nil, err
+	}
+
+	policies, err := a.loadPolicies(ctx, role.PolicyIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	decision := a.evaluatePolicies(policies, action, resource, conditionValues)
+	if !decision.Allowed || session.Policy == nil {
+		return decision, nil
+	}
+
+	sessionDecision := a.evaluatePolicies([]*iampol.IAMPolicy{session.Policy}, action, resource, conditionValues)
+	// Session policies are inline, they have no ID to report.
+	sessionDecision.PolicyID = ""
+
+	return sessionDecision, nil
+}
+
+// evaluatePolicies evaluates the policies together: any matching Deny wins, otherwise a matching Allow grants
+// access.
+func (a *Authorizer) evaluatePolicies(
+	policies []*iampol.IAMPolicy, action s3actions.Action, resource string, conditionValues map[string]string,
+) *core.AuthorizationDecision {
+	// First pass: any Deny that matches overrides
+	for _, policy := range policies {
 		for _, stmt := range policy.Statement {
 			if stmt.Effect != iampol.EffectDeny {
 				continue
 			}
 
-			if a.statementMatches(stmt, action, resource) {
-				return false, nil
+			if a.statementMatches(stmt, action, resource, conditionValues) {
+				return &core.AuthorizationDecision{
+					Reason:    core.AuthorizationReasonExplicitDeny,
+					PolicyID:  policy.ID,
+					Statement: &stmt,
+				}
 			}
 		}
 	}
 
 	// Second pass: any Allow that matches grants access
-	for _, binding := range bindings {
-		if ctx.Err() != nil {
-			return false, ctx.Err()
-		}
-
-		policy, err := a.ManagementBackend.GetPolicyByID(ctx, binding.PolicyID)
-		if err != nil {
-			return false, err
-		}
-
+	for _, policy := range policies {
 		for _, stmt := range policy.Statement {
 			if stmt.Effect != iampol.EffectAllow {
 				continue
 			}
 
-			if a.statementMatches(stmt, action, resource) {
-				return true, nil
+			if a.statementMatches(stmt, action, resource, conditionValues) {
+				return &core.AuthorizationDecision{
+					Allowed:   true,
+					Reason:    core.AuthorizationReasonExplicitAllow,
+					PolicyID:  policy.ID,
+					Statement: &stmt,
+				}
 			}
 		}
 	}
 
-	return false, nil
+	return &core.AuthorizationDecision{Reason: core.AuthorizationReasonImplicitDeny}
+}
+
+// userPolicies returns the policies bound to the user directly or through any of their groups.
+// A policy reachable in several ways is returned once.
+func (a *Authorizer) userPolicies(ctx context.Context, userName string) ([]*iampol.IAMPolicy, error) {
+	bindings, err := a.ManagementBackend.GetBindingsByUser(ctx, userName)
+	if err != nil {
+		return nil, err
+	}
+
+	policyIDs := lo.Map(bindings, func(binding *core.PolicyBinding, _ int) string { return binding.PolicyID })
+
+	groups, err := a.ManagementBackend.GetGroupsByUser(ctx, userName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range groups {
+		groupBindings, err := a.ManagementBackend.GetBindingsByGroup(ctx, group)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, binding := range groupBindings {
+			policyIDs = append(policyIDs, binding.PolicyID)
+		}
+	}
+
+	return a.loadPolicies(ctx, policyIDs)
 }
 
-func (a *Authorizer) statementMatches(stmt iampol.Statement, action s3actions.Action, resourceSuffix string) bool {
+// loadPolicies returns the policies with the given IDs, loading each of them once.
+func (a *Authorizer) loadPolicies(ctx context.Context, policyIDs []string) ([]*iampol.IAMPolicy, error) {
+	policies := make([]*iampol.IAMPolicy, 0, len(policyIDs))
+
+	for _, policyID := range lo.Uniq(policyIDs) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		policy, err := a.ManagementBackend.GetPolicyByID(ctx, policyID)
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+func (a *Authorizer) statementMatches(
+	stmt iampol.Statement, action s3actions.Action, resourceSuffix string, conditionValues map[string]string,
+) bool {
 	// Policy statement's s3:* (All) matches any requested action; otherwise require explicit match
 	actionMatches := lo.Contains(stmt.Action, s3actions.All) || lo.Contains(stmt.Action, action)
 	if !actionMatches {
 		return false
 	}
 
+	if !stmt.Condition.Matches(conditionValues) {
+		return false
+	}
+
 	return lo.ContainsBy(stmt.Resource, func(res string) bool {
-		pattern, ok := strings.CutPrefix(res, s3ResourcePrefix)
+		pattern, ok := strings.CutPrefix(res, iampol.ResourcePrefix)
 		if !ok {
 			return false
 		}
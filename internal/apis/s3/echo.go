@@ -3,20 +3,30 @@ package s3
 import (
 	"context"
 	"log/slog"
+	"net/http"
 
 	"github.com/labstack/echo/v5"
 	"github.com/labstack/echo/v5/middleware"
+	"github.com/samber/lo"
 	"github.com/zhulik/d3/internal/apictx"
 	"github.com/zhulik/d3/internal/apis/s3/middlewares"
 	"github.com/zhulik/d3/internal/core"
 	"github.com/zhulik/d3/pkg/s3actions"
 )
 
+// copyObjectAuditAction tells copies apart from uploads in the audit log, both are authorized as s3:PutObject.
+const copyObjectAuditAction = "s3:CopyObject"
+
 type Echo struct {
 	*echo.Echo
 
-	Authenticator *middlewares.Authenticator
-	Authorizer    *middlewares.Authorizer
+	Config          *core.Config
+	Authenticator   *middlewares.Authenticator
+	Authorizer      *middlewares.Authorizer
+	Auditor         *middlewares.Auditor
+	AccessLogger    *middlewares.AccessLogger
+	Throttler       *middlewares.Throttler
+	TransferCounter *middlewares.TransferCounter
 
 	rootQueryRouter *QueryParamsRouter
 }
@@ -30,10 +40,14 @@ func (e *Echo) Init(_ context.Context) error {
 	e.Use(
 		middleware.BodyLimit(core.SizeLimit5Gb),
 		apictx.Middleware(),
-		middlewares.Logger(),
+		middlewares.Logger(e.AccessLogger.Log),
 		middleware.Recover(),
+		e.Auditor.Middleware(e.describeAudit),
 		middlewares.ErrorRenderer(),
-		e.Authenticator.Middleware(),
+		RequireTLSForCustomerKeys(e.Config.SSECustomerKeysOverHTTP),
+		e.Authenticator.Middleware(isSTSRequest),
+		e.TransferCounter.Middleware(),
+		e.Throttler.Middleware(),
 	)
 
 	return nil
@@ -46,3 +60,28 @@ func (e *Echo) AddQueryParamRoute(path string, handler echo.HandlerFunc, action
 func (e *Echo) SetRootFallbackHandler(handler echo.HandlerFunc, action s3actions.Action, middlewares ...echo.MiddlewareFunc) { //nolint:lll
 	e.rootQueryRouter.SetFallbackHandler(handler, action, middlewares...)
 }
+
+// isSTSRequest tells the requests of APISTS apart, they are signed for STS rather than S3, or not at all, and are
+// authenticated by the handler.
+func isSTSRequest(c *echo.Context) bool {
+	return c.Request().Method == http.MethodPost && c.Path() == "/"
+}
+
+// describeAudit picks the requests with actions listed in AuditS3Actions, and all denied requests.
+func (e *Echo) describeAudit(c *echo.Context, apiCtx *apictx.APICtx, outcome core.AuditOutcome) (string, string, bool) {
+	action := string(apiCtx.Action)
+	if apiCtx.Action == s3actions.PutObject && c.Request().Header.Get("X-Amz-Copy-Source") != "" {
+		action = copyObjectAuditAction
+	}
+
+	if outcome != core.AuditOutcomeDenied && !lo.Contains(e.Config.AuditS3Actions, action) {
+		return "", "", false
+	}
+
+	resource := c.Param("bucket")
+	if key := c.Param("*"); key != "" {
+		resource += "/" + key
+	}
+
+	return action, resource, true
+}
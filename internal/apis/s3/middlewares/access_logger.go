@@ -0,0 +1,118 @@
+package middlewares
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+	"github.com/labstack/echo/v5/middleware"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/accesslog"
+	"github.com/zhulik/d3/internal/apictx"
+)
+
+// subresourceOperations names the resource type of S3 access log operations for requests with a subresource
+// query parameter, by parameter and then by method. An empty method matches any method.
+var subresourceOperations = []struct { //nolint:gochecknoglobals
+	param     string
+	method    string
+	operation string
+}{
+	{"logging", "", "LOGGING_STATUS"},
+	{"location", "", "LOCATION"},
+	{"tagging", "", "OBJECT_TAGGING"},
+	{"delete", "", "MULTI_OBJECT_DELETE"},
+	{"uploads", "", "UPLOADS"},
+	{"uploadId", http.MethodPut, "PART"},
+	{"uploadId", "", "UPLOAD"},
+}
+
+// AccessLogger writes S3 server access log entries of requests to buckets with logging enabled.
+type AccessLogger struct {
+	Writer *accesslog.Writer
+}
+
+// Log is a RequestLogSink. Requests that were not resolved to a bucket, like ListBuckets or requests to missing
+// buckets, are not logged.
+func (a *AccessLogger) Log(c *echo.Context, v middleware.RequestLoggerValues) {
+	ctx := c.Request().Context()
+	apiCtx := apictx.FromContext(ctx)
+
+	if apiCtx.Bucket == nil || apiCtx.Bucket.Logging() == nil {
+		return
+	}
+
+	req := c.Request()
+
+	entry := accesslog.Entry{
+		Bucket:     apiCtx.Bucket.Name(),
+		Time:       v.StartTime,
+		RemoteIP:   c.RealIP(),
+		RequestID:  apiCtx.RequestID,
+		Operation:  accessLogOperation(c),
+		Key:        c.Param("*"),
+		RequestURI: req.Method + " " + apiCtx.URI + " " + req.Proto,
+		HTTPStatus: v.Status,
+		BytesSent:  v.ResponseSize,
+		TotalTime:  v.Latency,
+		Referer:    req.Referer(),
+		UserAgent:  apiCtx.UserAgent,
+		HostHeader: apiCtx.Host,
+	}
+
+	if apiCtx.User != nil {
+		entry.Requester = apiCtx.User.Name
+	}
+
+	if apiCtx.Object != nil {
+		entry.ObjectSize = lo.ToPtr(apiCtx.Object.Size())
+	}
+
+	if v.Status >= http.StatusBadRequest {
+		entry.ErrorCode = s3ErrorCode(v.Error, v.Status)
+	}
+
+	switch {
+	case apiCtx.AuthParams != nil:
+		entry.SignatureVersion = "SigV4"
+		entry.AuthType = lo.Ternary(req.URL.Query().Has("X-Amz-Signature"), "QueryString", "AuthHeader")
+	case apiCtx.SigV2Params != nil:
+		entry.SignatureVersion = "SigV2"
+		entry.AuthType = lo.Ternary(apiCtx.SigV2Params.Presigned, "QueryString", "AuthHeader")
+	}
+
+	entry.TLSVersion, entry.CipherSuite = tlsDetails(req)
+
+	a.Writer.Write(*apiCtx.Bucket.Logging(), entry)
+}
+
+// accessLogOperation returns the S3 access log operation of the request, like REST.GET.OBJECT.
+func accessLogOperation(c *echo.Context) string {
+	req := c.Request()
+	method := req.Method
+
+	if method == http.MethodPut && req.Header.Get("X-Amz-Copy-Source") != "" {
+		method = "COPY"
+	}
+
+	resource := lo.Ternary(c.Param("*") == "", "BUCKET", "OBJECT")
+
+	query := req.URL.Query()
+	for _, sub := range subresourceOperations {
+		if query.Has(sub.param) && (sub.method == "" || sub.method == req.Method) {
+			resource = sub.operation
+
+			break
+		}
+	}
+
+	return "REST." + method + "." + resource
+}
+
+func tlsDetails(req *http.Request) (string, string) {
+	if req.TLS == nil {
+		return "", ""
+	}
+
+	return tls.VersionName(req.TLS.Version), tls.CipherSuiteName(req.TLS.CipherSuite)
+}
@@ -0,0 +1,81 @@
+package middlewares
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/zhulik/d3/internal/apictx"
+	"github.com/zhulik/d3/internal/core"
+)
+
+// AuditDescriber names the action and the resource of a request for the audit log. It returns false for
+// requests that are not audited.
+type AuditDescriber func(c *echo.Context, apiCtx *apictx.APICtx, outcome core.AuditOutcome) (string, string, bool)
+
+// Auditor records audit events for the requests picked by a describer. It must run outside of ErrorRenderer
+// to see the status errors are rendered with, and outside of the authentication and authorization middlewares
+// to see the requests they deny.
+type Auditor struct {
+	AuditLog core.AuditLog
+	Logger   *slog.Logger
+}
+
+func (a *Auditor) Middleware(describe AuditDescriber) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			err := next(c)
+
+			ctx := c.Request().Context()
+			apiCtx := apictx.FromContext(ctx)
+
+			_, status := echo.ResolveResponseStatus(c.Response(), err)
+			outcome := auditOutcome(status)
+
+			action, resource, audited := describe(c, apiCtx, outcome)
+			if !audited {
+				return err
+			}
+
+			event := &core.AuditEvent{
+				Time:      time.Now().UTC(),
+				Action:    action,
+				Resource:  resource,
+				RemoteIP:  c.RealIP(),
+				RequestID: apiCtx.RequestID,
+				Outcome:   outcome,
+				Status:    status,
+				Source:    strings.TrimPrefix(c.Request().Header.Get("X-Amz-Copy-Source"), "/"),
+			}
+
+			if apiCtx.User != nil {
+				event.User = apiCtx.User.Name
+			}
+
+			if err != nil {
+				event.Error = err.Error()
+			}
+
+			// The request may be canceled once it is answered, the event must be recorded anyway.
+			if recordErr := a.AuditLog.Record(context.WithoutCancel(ctx), event); recordErr != nil {
+				a.Logger.Error("failed to record audit event", "error", recordErr, "action", action)
+			}
+
+			return err
+		}
+	}
+}
+
+func auditOutcome(status int) core.AuditOutcome {
+	switch {
+	case status == http.StatusForbidden:
+		return core.AuditOutcomeDenied
+	case status >= http.StatusBadRequest:
+		return core.AuditOutcomeFailure
+	default:
+		return core.AuditOutcomeSuccess
+	}
+}
@@ -2,26 +2,73 @@ package middlewares
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"log/slog"
+	"net/http"
+	"sync"
+	"time"
 
 	"github.com/labstack/echo/v5"
+	"github.com/labstack/echo/v5/middleware"
 	"github.com/zhulik/d3/internal/apictx"
 	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/credentials"
+	"github.com/zhulik/d3/pkg/sigv2"
 	"github.com/zhulik/d3/pkg/sigv4"
 )
 
+// lastUsedPrecision is how stale an access key's last-used timestamp may get. Recording every request would
+// turn each of them into a management backend write.
+const lastUsedPrecision = time.Hour
+
 type Authenticator struct {
+	Config            *core.Config
 	ManagementBackend core.ManagementBackend
+	SessionStore      core.SessionStore
 	Logger            *slog.Logger
+
+	// touchedAt remembers when this instance last recorded the use of an access key.
+	touchedAt sync.Map
 }
 
-func (a *Authenticator) Middleware() echo.MiddlewareFunc {
+// Middleware authenticates the requests signed with SigV4, or SigV2 when it is enabled, and lets unsigned ones
+// through as anonymous. The requests skipper picks, like the STS ones signed for another service, are left to
+// their handlers.
+func (a *Authenticator) Middleware(skipper middleware.Skipper) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c *echo.Context) error {
-			authParams, err := sigv4.Validate(c.Request().Context(), c.Request(), a.getAccessKeySecret)
+			if skipper(c) {
+				return next(c)
+			}
+
+			var (
+				session     *core.Session
+				sigV2Params *sigv2.AuthParameters
+			)
+
+			resolveSecret := func(ctx context.Context, accessKey string) (string, error) {
+				if !credentials.IsTemporary(accessKey) {
+					return a.getAccessKeySecret(ctx, accessKey)
+				}
+
+				var err error
+
+				session, err = a.SessionStore.Get(ctx, accessKey)
+				if err != nil {
+					return "", err
+				}
+
+				return session.SecretAccessKey, nil
+			}
+
+			authParams, err := sigv4.Validate(c.Request().Context(), c.Request(), resolveSecret)
+			if errors.Is(err, sigv4.ErrRequestNotSigned) && a.Config.SigV2Enabled {
+				sigV2Params, err = sigv2.Validate(c.Request().Context(), c.Request(), resolveSecret)
+			}
+
 			if err != nil {
-				if errors.Is(err, sigv4.ErrRequestNotSigned) {
+				if errors.Is(err, sigv4.ErrRequestNotSigned) || errors.Is(err, sigv2.ErrRequestNotSigned) {
 					// Allow anonymous access, actual authorization is handled by the authorizer
 					return next(c)
 				}
@@ -31,15 +78,36 @@ func (a *Authenticator) Middleware() echo.MiddlewareFunc {
 				return err
 			}
 
-			if authParams != nil {
-				user, err := a.ManagementBackend.GetUserByAccessKeyID(c.Request().Context(), authParams.AccessKey)
+			var accessKey string
+
+			switch {
+			case authParams != nil:
+				accessKey = authParams.AccessKey
+			case sigV2Params != nil:
+				accessKey = sigV2Params.AccessKey
+			default:
+				return next(c)
+			}
+
+			apiCtx := apictx.FromContext(c.Request().Context())
+			apiCtx.AuthParams = authParams
+			apiCtx.SigV2Params = sigV2Params
+
+			if session != nil {
+				if !validSessionToken(c.Request(), session) {
+					return core.ErrSessionTokenInvalid
+				}
+
+				apiCtx.User = session.User()
+			} else {
+				user, err := a.ManagementBackend.GetUserByAccessKeyID(c.Request().Context(), accessKey)
 				if err != nil {
 					return err
 				}
 
-				apiCtx := apictx.FromContext(c.Request().Context())
+				a.touchAccessKey(c.Request().Context(), user, accessKey)
+
 				apiCtx.User = user
-				apiCtx.AuthParams = authParams
 			}
 
 			return next(c)
@@ -53,5 +121,48 @@ func (a *Authenticator) getAccessKeySecret(ctx context.Context, accessKey string
 		return "", err
 	}
 
-	return user.SecretAccessKey, nil
+	key, ok := user.AccessKey(accessKey)
+	if !ok {
+		return "", core.ErrAccessKeyNotFound
+	}
+
+	return key.SecretAccessKey, nil
+}
+
+// validSessionToken checks the token sent along with temporary credentials, in a header or in a presigned URL.
+func validSessionToken(r *http.Request, session *core.Session) bool {
+	token := r.Header.Get("X-Amz-Security-Token")
+	if token == "" {
+		token = r.URL.Query().Get("X-Amz-Security-Token")
+	}
+
+	if token == "" {
+		// SigV2 presigned URLs carry it in lower case.
+		token = r.URL.Query().Get("x-amz-security-token")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(session.SessionToken)) == 1
+}
+
+// touchAccessKey records the use of an access key, at most once per lastUsedPrecision. Failing to do so must
+// not fail the request, so errors are only logged.
+func (a *Authenticator) touchAccessKey(ctx context.Context, user *core.User, accessKeyID string) {
+	now := time.Now().UTC()
+
+	key, ok := user.AccessKey(accessKeyID)
+	if ok && key.LastUsedAt != nil && now.Sub(*key.LastUsedAt) < lastUsedPrecision {
+		return
+	}
+
+	if touchedAt, ok := a.touchedAt.Load(accessKeyID); ok {
+		if now.Sub(touchedAt.(time.Time)) < lastUsedPrecision { //nolint:forcetypeassert
+			return
+		}
+	}
+
+	a.touchedAt.Store(accessKeyID, now)
+
+	if err := a.ManagementBackend.TouchAccessKey(ctx, accessKeyID, now); err != nil {
+		a.Logger.Error("failed to record access key usage", "accessKeyID", accessKeyID, "error", err)
+	}
 }
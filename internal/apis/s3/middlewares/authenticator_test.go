@@ -0,0 +1,162 @@
+package middlewares_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/labstack/echo/v5"
+	minioSigner "github.com/minio/minio-go/v7/pkg/signer"
+	"github.com/samber/lo"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/zhulik/d3/internal/apictx"
+	"github.com/zhulik/d3/internal/apis/s3/middlewares"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/sigv4"
+)
+
+// managementBackend knows a single user, the calls the authenticator does not make panic.
+type managementBackend struct {
+	core.ManagementBackend
+
+	user *core.User
+}
+
+func (m managementBackend) GetUserByAccessKeyID(_ context.Context, accessKeyID string) (*core.User, error) {
+	if _, ok := m.user.AccessKey(accessKeyID); !ok {
+		return nil, core.ErrUserNotFound
+	}
+
+	return m.user, nil
+}
+
+func (m managementBackend) TouchAccessKey(_ context.Context, _ string, _ time.Time) error {
+	return nil
+}
+
+var _ = Describe("Authenticator", func() {
+	user := &core.User{
+		Name:       "alice",
+		AccessKeys: []core.AccessKey{{AccessKeyID: "alice", SecretAccessKey: "secret", Status: core.AccessKeyStatusActive}},
+	}
+
+	var (
+		e           *echo.Echo
+		config      *core.Config
+		skipped     bool
+		handledUser *core.User
+		handled     bool
+	)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	BeforeEach(func() {
+		config = &core.Config{}
+		skipped, handled, handledUser = false, false, nil
+
+		authenticator := &middlewares.Authenticator{
+			Config:            config,
+			ManagementBackend: managementBackend{user: user},
+			Logger:            slog.New(slog.DiscardHandler),
+		}
+
+		e = echo.New()
+		e.Use(
+			apictx.Middleware(),
+			middlewares.ErrorRenderer(),
+			authenticator.Middleware(func(*echo.Context) bool { return skipped }),
+		)
+		e.GET("/bucket/key", func(c *echo.Context) error {
+			handled = true
+			handledUser = apictx.FromContext(c.Request().Context()).User
+
+			return c.NoContent(http.StatusOK)
+		})
+	})
+
+	When("the request is not signed", func() {
+		It("lets it through as anonymous", func() {
+			rec := serve(httptest.NewRequest(http.MethodGet, "/bucket/key", nil))
+
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(handled).To(BeTrue())
+			Expect(handledUser).To(BeNil())
+		})
+	})
+
+	When("the request is signed with SigV4", func() {
+		sign := func(ctx context.Context, secret string) *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8080/bucket/key", nil)
+			req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+
+			lo.Must0(v4.NewSigner().SignHTTP(ctx, aws.Credentials{AccessKeyID: "alice", SecretAccessKey: secret},
+				req, "UNSIGNED-PAYLOAD", "s3", "local", time.Now()))
+
+			return req
+		}
+
+		It("authenticates the user", func(ctx context.Context) {
+			rec := serve(sign(ctx, "secret"))
+
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(handledUser).To(Equal(user))
+		})
+
+		It("rejects a wrong signature", func(ctx context.Context) {
+			rec := serve(sign(ctx, "wrong"))
+
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+			Expect(handled).To(BeFalse())
+		})
+	})
+
+	When("the request is signed with SigV2", func() {
+		sign := func() *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8080/bucket/key", nil)
+
+			return minioSigner.SignV2(*req, "alice", "secret", false)
+		}
+
+		It("authenticates the user when SigV2 is enabled", func() {
+			config.SigV2Enabled = true
+
+			rec := serve(sign())
+
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(handledUser).To(Equal(user))
+		})
+
+		It("lets it through as anonymous when SigV2 is disabled", func() {
+			rec := serve(sign())
+
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(handledUser).To(BeNil())
+		})
+	})
+
+	When("the request is skipped", func() {
+		It("leaves it to the handler", func(ctx context.Context) {
+			skipped = true
+
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8080/bucket/key", nil)
+			req.Header.Set("Authorization", sigv4.AlgoHMAC256+" Credential=alice/garbage")
+
+			rec := serve(req)
+
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(handledUser).To(BeNil())
+		})
+	})
+
+})
@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/sigv2"
+	"github.com/zhulik/d3/pkg/sigv4"
+)
+
+// s3ErrorCodes are the S3 error codes of the errors requests fail with, the first match wins.
+var s3ErrorCodes = []struct { //nolint:gochecknoglobals
+	err  error
+	code string
+}{
+	{core.ErrBucketNotFound, "NoSuchBucket"},
+	{core.ErrObjectNotFound, "NoSuchKey"},
+	{core.ErrInvalidUploadID, "NoSuchUpload"},
+	{core.ErrBucketEncryptionNotFound, "ServerSideEncryptionConfigurationNotFoundError"},
+	{core.ErrObjectLockConfigurationNotFound, "ObjectLockConfigurationNotFoundError"},
+	{core.ErrObjectRetentionNotFound, "NoSuchObjectLockConfiguration"},
+	{core.ErrBucketAlreadyExists, "BucketAlreadyOwnedByYou"},
+	{core.ErrBucketNotEmpty, "BucketNotEmpty"},
+	{core.ErrPreconditionFailed, "PreconditionFailed"},
+	{core.ErrInvalidBucketName, "InvalidBucketName"},
+	{core.ErrInvalidTag, "InvalidTag"},
+	{core.ErrInvalidLoggingTarget, "InvalidTargetBucketForLogging"},
+	{core.ErrObjectChecksumMismatch, "BadDigest"},
+	{sigv4.ErrChecksumMismatch, "BadDigest"},
+	{sigv4.ErrSignatureDoesNotMatch, "SignatureDoesNotMatch"},
+	{sigv2.ErrSignatureDoesNotMatch, "SignatureDoesNotMatch"},
+	{sigv4.ErrInvalidAccessKeyID, "InvalidAccessKeyId"},
+	{sigv2.ErrInvalidAccessKeyID, "InvalidAccessKeyId"},
+	{sigv2.ErrRequestTimeTooSkewed, "RequestTimeTooSkewed"},
+	{core.ErrSessionTokenInvalid, "InvalidToken"},
+	{core.ErrSessionNotFound, "ExpiredToken"},
+	{core.ErrQuotaExceeded, "QuotaExceeded"},
+	{core.ErrSlowDown, "SlowDown"},
+}
+
+// statusErrorCodes are the codes of the errors without a more precise one, by HTTP status.
+var statusErrorCodes = map[int]string{ //nolint:gochecknoglobals
+	http.StatusBadRequest:                   "InvalidRequest",
+	http.StatusForbidden:                    "AccessDenied",
+	http.StatusNotFound:                     "NotFound",
+	http.StatusMethodNotAllowed:             "MethodNotAllowed",
+	http.StatusConflict:                     "OperationAborted",
+	http.StatusPreconditionFailed:           "PreconditionFailed",
+	http.StatusRequestEntityTooLarge:        "EntityTooLarge",
+	http.StatusRequestedRangeNotSatisfiable: "InvalidRange",
+	http.StatusNotImplemented:               "NotImplemented",
+	http.StatusServiceUnavailable:           "ServiceUnavailable",
+}
+
+// s3ErrorCode returns the S3 error code of a request that failed with err and the status.
+func s3ErrorCode(err error, status int) string {
+	for _, e := range s3ErrorCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+
+	if code, ok := statusErrorCodes[status]; ok {
+		return code
+	}
+
+	if status >= http.StatusInternalServerError {
+		return "InternalError"
+	}
+
+	return "InvalidRequest"
+}
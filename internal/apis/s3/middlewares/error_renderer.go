@@ -7,10 +7,11 @@ import (
 	"github.com/labstack/echo/v5"
 	"github.com/zhulik/d3/internal/core"
 	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/sigv2"
 	"github.com/zhulik/d3/pkg/sigv4"
 )
 
-func isSigV4AuthError(err error) bool {
+func isSignatureAuthError(err error) bool {
 	return errors.Is(err, sigv4.ErrSignatureDoesNotMatch) ||
 		errors.Is(err, sigv4.ErrInvalidAccessKeyID) ||
 		errors.Is(err, sigv4.ErrInvalidDigest) ||
@@ -18,7 +19,25 @@ func isSigV4AuthError(err error) bool {
 		errors.Is(err, sigv4.ErrExpiredPresignRequest) ||
 		errors.Is(err, sigv4.ErrMalformedPresignedDate) ||
 		errors.Is(err, sigv4.ErrCredMalformed) ||
-		errors.Is(err, sigv4.ErrRequestNotReadyYet)
+		errors.Is(err, sigv4.ErrRequestNotReadyYet) ||
+		errors.Is(err, sigv4.ErrInvalidChunkSignature) ||
+		errors.Is(err, sigv4.ErrInvalidTrailerSignature) ||
+		errors.Is(err, sigv2.ErrSignatureDoesNotMatch) ||
+		errors.Is(err, sigv2.ErrInvalidAccessKeyID) ||
+		errors.Is(err, sigv2.ErrMissingDateHeader) ||
+		errors.Is(err, sigv2.ErrMalformedDate) ||
+		errors.Is(err, sigv2.ErrRequestTimeTooSkewed) ||
+		errors.Is(err, sigv2.ErrExpiredPresignRequest) ||
+		errors.Is(err, sigv2.ErrAuthHeaderMalformed) ||
+		errors.Is(err, sigv2.ErrMalformedPresignedRequest) ||
+		errors.Is(err, core.ErrSessionNotFound) ||
+		errors.Is(err, core.ErrSessionTokenInvalid)
+}
+
+// httpError renders err with the status, keeping err in the chain for the middlewares that run after, like the
+// access logger.
+func httpError(status int, err error) error {
+	return echo.NewHTTPError(status, err.Error()).Wrap(err)
 }
 
 func ErrorRenderer() echo.MiddlewareFunc {
@@ -26,38 +45,80 @@ func ErrorRenderer() echo.MiddlewareFunc {
 		return func(c *echo.Context) error {
 			err := next(c)
 			switch {
-			case isSigV4AuthError(err):
-				return echo.NewHTTPError(http.StatusForbidden, err.Error())
+			case isSignatureAuthError(err):
+				return httpError(http.StatusForbidden, err)
 			case errors.Is(err, core.ErrBucketNotFound):
-				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+				return httpError(http.StatusNotFound, err)
 			case errors.Is(err, core.ErrObjectNotFound) ||
 				errors.Is(err, core.ErrPolicyNotFound) ||
-				errors.Is(err, core.ErrUserNotFound):
-				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+				errors.Is(err, core.ErrUserNotFound) ||
+				errors.Is(err, core.ErrAccessKeyNotFound) ||
+				errors.Is(err, core.ErrGroupNotFound) ||
+				errors.Is(err, core.ErrGroupMemberNotFound) ||
+				errors.Is(err, core.ErrRoleNotFound) ||
+				errors.Is(err, core.ErrBucketEncryptionNotFound) ||
+				errors.Is(err, core.ErrObjectLockConfigurationNotFound) ||
+				errors.Is(err, core.ErrObjectRetentionNotFound):
+				return httpError(http.StatusNotFound, err)
 			case errors.Is(err, core.ErrPreconditionFailed):
-				return echo.NewHTTPError(http.StatusPreconditionFailed, err.Error())
+				return httpError(http.StatusPreconditionFailed, err)
 			case errors.Is(err, core.ErrBucketAlreadyExists) ||
 				errors.Is(err, core.ErrObjectAlreadyExists) ||
 				errors.Is(err, core.ErrPolicyAlreadyExists) ||
-				errors.Is(err, core.ErrUserAlreadyExists):
-				return echo.NewHTTPError(http.StatusConflict, err.Error())
+				errors.Is(err, core.ErrUserAlreadyExists) ||
+				errors.Is(err, core.ErrGroupAlreadyExists) ||
+				errors.Is(err, core.ErrGroupMemberAlreadyExists) ||
+				errors.Is(err, core.ErrRoleAlreadyExists):
+				return httpError(http.StatusConflict, err)
 			case errors.Is(err, core.ErrBucketNotEmpty) ||
-				errors.Is(err, core.ErrObjectChecksumMismatch):
-				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+				errors.Is(err, core.ErrObjectChecksumMismatch) ||
+				errors.Is(err, sigv4.ErrChecksumMismatch) ||
+				errors.Is(err, sigv4.ErrTrailerMalformed) ||
+				errors.Is(err, sigv4.ErrUnsupportedChecksum):
+				return httpError(http.StatusBadRequest, err)
 			case errors.Is(err, core.ErrInvalidBucketName) ||
 				errors.Is(err, core.ErrInvalidObjectKey) ||
 				errors.Is(err, core.ErrInvalidUploadID) ||
 				errors.Is(err, core.ErrInvalidLimitParam) ||
 				errors.Is(err, core.ErrInvalidTag) ||
+				errors.Is(err, core.ErrInvalidLoggingTarget) ||
+				errors.Is(err, core.ErrSSEInvalidRequest) ||
+				errors.Is(err, core.ErrSSENotConfigured) ||
+				errors.Is(err, core.ErrSSECustomerKeyRequired) ||
+				errors.Is(err, core.ErrBucketCompressionInvalid) ||
+				errors.Is(err, core.ErrObjectLockInvalid) ||
+				errors.Is(err, core.ErrObjectLockNotEnabled) ||
+				errors.Is(err, core.ErrBucketQuotaInvalid) ||
+				errors.Is(err, core.ErrStorageRootNotFound) ||
 				errors.Is(err, core.ErrPathTraversal) ||
 				errors.Is(err, core.ErrSymlinkNotAllowed) ||
 				errors.Is(err, core.ErrUserInvalid) ||
-				errors.Is(err, core.ErrUserNameReserved):
-				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
-			case errors.Is(err, core.ErrUnauthorized):
-				return echo.NewHTTPError(http.StatusForbidden, err.Error())
+				errors.Is(err, core.ErrUserNameReserved) ||
+				errors.Is(err, core.ErrAccessKeyInvalid) ||
+				errors.Is(err, core.ErrGroupInvalid) ||
+				errors.Is(err, core.ErrRoleInvalid) ||
+				errors.Is(err, core.ErrRateLimitsInvalid) ||
+				errors.Is(err, core.ErrPolicySimulationInvalid) ||
+				errors.Is(err, core.ErrManagementConfigInvalid) ||
+				errors.Is(err, core.ErrInvalidSTSRequest) ||
+				errors.Is(err, core.ErrSTSActionUnsupported) ||
+				errors.Is(err, core.ErrWebIdentityDisabled) ||
+				errors.Is(err, core.ErrAuditLogDisabled) ||
+				errors.Is(err, core.ErrAuditQueryInvalid) ||
+				errors.Is(err, core.ErrStatsQueryInvalid):
+				return httpError(http.StatusBadRequest, err)
+			case errors.Is(err, core.ErrUnauthorized) ||
+				errors.Is(err, core.ErrWebIdentityTokenInvalid) ||
+				errors.Is(err, core.ErrObjectLocked) ||
+				errors.Is(err, core.ErrQuotaExceeded) ||
+				errors.Is(err, core.ErrSSECustomerKeyMismatch):
+				return httpError(http.StatusForbidden, err)
+			case errors.Is(err, core.ErrSlowDown) ||
+				errors.Is(err, core.ErrBucketMoving) ||
+				errors.Is(err, core.ErrWriteQuorum):
+				return httpError(http.StatusServiceUnavailable, err)
 			case errors.Is(err, iampol.ErrInvalidPolicy):
-				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+				return httpError(http.StatusBadRequest, err)
 			case err == nil:
 				return nil
 			default:
@@ -8,7 +8,10 @@ import (
 	"github.com/zhulik/d3/internal/apictx"
 )
 
-func Logger() echo.MiddlewareFunc {
+// RequestLogSink receives the values Logger collects for every request.
+type RequestLogSink func(c *echo.Context, v middleware.RequestLoggerValues)
+
+func Logger(sinks ...RequestLogSink) echo.MiddlewareFunc {
 	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
 		LogLatency:       true,
 		LogRemoteIP:      true,
@@ -50,6 +53,10 @@ func Logger() echo.MiddlewareFunc {
 
 			logger.LogAttrs(c.Request().Context(), slog.LevelInfo, "REQUEST", commonAttrs...)
 
+			for _, sink := range sinks {
+				sink(c, v)
+			}
+
 			return nil
 		},
 	})
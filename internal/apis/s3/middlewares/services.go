@@ -10,5 +10,9 @@ func Provide() pal.ServiceDef {
 		pal.Provide(&BucketFinder{}),
 		pal.Provide(&ObjectFinder{}),
 		pal.Provide(&Authorizer{}),
+		pal.Provide(&Auditor{}),
+		pal.Provide(&AccessLogger{}),
+		pal.Provide(&Throttler{}),
+		pal.Provide(&TransferCounter{}),
 	)
 }
@@ -0,0 +1,83 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+	"github.com/zhulik/d3/internal/apictx"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/internal/ratelimit"
+)
+
+// Throttler applies the rate limits of the authenticated user, or the anonymous limits of the client IP to
+// unsigned requests. Admin is never limited.
+type Throttler struct {
+	Config  *core.Config
+	Limiter *ratelimit.Limiter
+}
+
+func (t *Throttler) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			ctx := c.Request().Context()
+			user := apictx.FromContext(ctx).User
+
+			if user != nil && user.Name == "admin" {
+				return next(c)
+			}
+
+			principal := "ip:" + c.RealIP()
+			limits := t.anonymousLimits()
+
+			if user != nil {
+				var err error
+
+				principal, limits, err = t.Limiter.Limits(ctx, user)
+				if err != nil {
+					return err
+				}
+			}
+
+			permit, err := t.Limiter.Acquire(principal, limits, requestClass(c))
+			if err != nil {
+				return err
+			}
+			defer permit.Release()
+
+			c.Request().Body = permit.ThrottleUpload(ctx, c.Request().Body)
+			c.SetResponse(permit.ThrottleDownload(ctx, c.Response()))
+
+			return next(c)
+		}
+	}
+}
+
+func (t *Throttler) anonymousLimits() core.RateLimits {
+	limits := core.RateLimits{MaxConcurrentRequests: t.Config.AnonymousMaxConcurrentRequests}
+
+	if rps := t.Config.AnonymousRequestsPerSecond; rps > 0 {
+		limits.RequestsPerSecond = map[core.RequestClass]float64{}
+
+		for _, class := range core.RequestClasses {
+			limits.RequestsPerSecond[class] = rps
+		}
+	}
+
+	return limits
+}
+
+// requestClass tells reads of objects from listings by the object key in the path.
+func requestClass(c *echo.Context) core.RequestClass {
+	switch c.Request().Method {
+	case http.MethodGet, http.MethodHead:
+		if c.Param("*") == "" {
+			return core.RequestClassList
+		}
+
+		return core.RequestClassRead
+	case http.MethodDelete:
+		return core.RequestClassDelete
+	default:
+		return core.RequestClassWrite
+	}
+}
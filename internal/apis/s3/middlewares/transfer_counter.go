@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"io"
+
+	"github.com/labstack/echo/v5"
+	"github.com/zhulik/d3/internal/apictx"
+	"github.com/zhulik/d3/internal/stats"
+)
+
+// TransferCounter counts the bytes read from the request bodies and written to the response bodies of
+// authenticated users. Sessions count for the user that assumed the role.
+type TransferCounter struct {
+	Transfers *stats.Transfers
+}
+
+func (t *TransferCounter) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			user := apictx.FromContext(c.Request().Context()).User
+			if user == nil {
+				return next(c)
+			}
+
+			name := user.Name
+			if user.Session != nil && user.Session.UserName != "" {
+				name = user.Session.UserName
+			}
+
+			body := &countingReader{ReadCloser: c.Request().Body}
+			c.Request().Body = body
+
+			response, err := echo.UnwrapResponse(c.Response())
+			if err != nil {
+				return err
+			}
+
+			defer func() {
+				t.Transfers.Record(name, body.count, response.Size)
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+type countingReader struct {
+	io.ReadCloser
+
+	count int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.count += int64(n)
+
+	return n, err
+}
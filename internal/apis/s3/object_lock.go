@@ -0,0 +1,254 @@
+package s3
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/zhulik/d3/internal/apictx"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/s3actions"
+)
+
+const (
+	objectLockRequestBodyMax = 4096
+
+	objectLockEnabled = "Enabled"
+	legalHoldOn       = "ON"
+	legalHoldOff      = "OFF"
+
+	objectLockModeHeader        = "X-Amz-Object-Lock-Mode"
+	objectLockRetainUntilHeader = "X-Amz-Object-Lock-Retain-Until-Date"
+	objectLockLegalHoldHeader   = "X-Amz-Object-Lock-Legal-Hold"
+	bypassGovernanceHeader      = "X-Amz-Bypass-Governance-Retention"
+	bucketObjectLockHeader      = "X-Amz-Bucket-Object-Lock-Enabled"
+)
+
+func (a APIBuckets) GetObjectLockConfiguration(c *echo.Context) error {
+	bucket := apictx.FromContext(c.Request().Context()).Bucket
+
+	lock := bucket.ObjectLock()
+	if lock == nil {
+		return core.ErrObjectLockConfigurationNotFound
+	}
+
+	response := objectLockConfigurationXML{ObjectLockEnabled: objectLockEnabled}
+	if retention := lock.DefaultRetention; retention != nil {
+		response.Rule = &objectLockRuleXML{DefaultRetention: &defaultRetentionXML{
+			Mode:  string(retention.Mode),
+			Days:  retention.Days,
+			Years: retention.Years,
+		}}
+	}
+
+	return c.XML(http.StatusOK, response)
+}
+
+// PutObjectLockConfiguration enables Object Lock for the bucket and replaces its default retention.
+func (a APIBuckets) PutObjectLockConfiguration(c *echo.Context) error {
+	ctx := c.Request().Context()
+	bucket := apictx.FromContext(ctx).Bucket
+
+	var req objectLockConfigurationXML
+	if err := xml.NewDecoder(io.LimitReader(c.Request().Body, objectLockRequestBodyMax)).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid XML body")
+	}
+
+	if req.ObjectLockEnabled != objectLockEnabled {
+		return fmt.Errorf("%w: ObjectLockEnabled must be %s", core.ErrObjectLockInvalid, objectLockEnabled)
+	}
+
+	lock := core.BucketObjectLock{}
+	if req.Rule != nil && req.Rule.DefaultRetention != nil {
+		lock.DefaultRetention = &core.DefaultRetention{
+			Mode:  core.ObjectLockMode(req.Rule.DefaultRetention.Mode),
+			Days:  req.Rule.DefaultRetention.Days,
+			Years: req.Rule.DefaultRetention.Years,
+		}
+	}
+
+	if err := bucket.PutObjectLock(ctx, lock); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (a APIObjects) GetObjectRetention(c *echo.Context) error {
+	apiCtx := apictx.FromContext(c.Request().Context())
+
+	if apiCtx.Bucket.ObjectLock() == nil {
+		return core.ErrObjectLockNotEnabled
+	}
+
+	retention := apiCtx.Object.Metadata().Retention
+	if retention == nil {
+		return core.ErrObjectRetentionNotFound
+	}
+
+	return c.XML(http.StatusOK, retentionXML{
+		Mode:            string(retention.Mode),
+		RetainUntilDate: retention.RetainUntil.UTC().Format(time.RFC3339),
+	})
+}
+
+// PutObjectRetention replaces the retention of an object, an empty Retention removes it. Lifting GOVERNANCE
+// retention needs the bypass header.
+func (a APIObjects) PutObjectRetention(c *echo.Context) error {
+	ctx := c.Request().Context()
+	bucket := apictx.FromContext(ctx).Bucket
+	key := c.Param("*")
+
+	var req retentionXML
+	if err := xml.NewDecoder(io.LimitReader(c.Request().Body, objectLockRequestBodyMax)).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid XML body")
+	}
+
+	retention, err := parseRetention(req.Mode, req.RetainUntilDate)
+	if err != nil {
+		return err
+	}
+
+	bypass, err := a.bypassGovernance(c, key)
+	if err != nil {
+		return err
+	}
+
+	if err := bucket.PutObjectRetention(ctx, key, retention, bypass); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (a APIObjects) GetObjectLegalHold(c *echo.Context) error {
+	apiCtx := apictx.FromContext(c.Request().Context())
+
+	if apiCtx.Bucket.ObjectLock() == nil {
+		return core.ErrObjectLockNotEnabled
+	}
+
+	status := legalHoldOff
+	if apiCtx.Object.Metadata().LegalHold {
+		status = legalHoldOn
+	}
+
+	return c.XML(http.StatusOK, legalHoldXML{Status: status})
+}
+
+func (a APIObjects) PutObjectLegalHold(c *echo.Context) error {
+	ctx := c.Request().Context()
+	bucket := apictx.FromContext(ctx).Bucket
+
+	var req legalHoldXML
+	if err := xml.NewDecoder(io.LimitReader(c.Request().Body, objectLockRequestBodyMax)).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid XML body")
+	}
+
+	hold, err := parseLegalHold(req.Status)
+	if err != nil {
+		return err
+	}
+
+	if err := bucket.PutObjectLegalHold(ctx, c.Param("*"), hold); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// bypassGovernance tells whether the request asks to bypass GOVERNANCE retention, the user must be allowed to
+// bypass it for every key.
+func (a APIObjects) bypassGovernance(c *echo.Context, keys ...string) (bool, error) {
+	if !strings.EqualFold(c.Request().Header.Get(bypassGovernanceHeader), "true") {
+		return false, nil
+	}
+
+	apiCtx := apictx.FromContext(c.Request().Context())
+
+	for _, key := range keys {
+		allowed, err := a.Echo.Authorizer.Authorizer.IsAllowed(c.Request().Context(), apiCtx.User,
+			s3actions.BypassGovernanceRetention, apiCtx.Bucket.Name()+"/"+key)
+		if err != nil {
+			return false, err
+		}
+
+		if !allowed {
+			return false, core.ErrUnauthorized
+		}
+	}
+
+	return true, nil
+}
+
+// parseObjectLock returns the retention and legal hold a write request asks for, the mode and the retain until
+// date go together.
+func parseObjectLock(header http.Header) (*core.ObjectRetention, bool, error) {
+	retention, err := parseRetention(header.Get(objectLockModeHeader), header.Get(objectLockRetainUntilHeader))
+	if err != nil {
+		return nil, false, err
+	}
+
+	legalHold := false
+
+	if status := header.Get(objectLockLegalHoldHeader); status != "" {
+		legalHold, err = parseLegalHold(status)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	return retention, legalHold, nil
+}
+
+// parseRetention returns nil when both mode and retainUntil are empty.
+func parseRetention(mode, retainUntil string) (*core.ObjectRetention, error) {
+	if mode == "" && retainUntil == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	if mode == "" || retainUntil == "" {
+		return nil, fmt.Errorf("%w: the mode and the retain until date must be set together",
+			core.ErrObjectLockInvalid)
+	}
+
+	date, err := time.Parse(time.RFC3339, retainUntil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid retain until date %q", core.ErrObjectLockInvalid, retainUntil)
+	}
+
+	retention := &core.ObjectRetention{Mode: core.ObjectLockMode(mode), RetainUntil: date}
+	if err := retention.Mode.Validate(); err != nil {
+		return nil, err
+	}
+
+	return retention, nil
+}
+
+func parseLegalHold(status string) (bool, error) {
+	switch status {
+	case legalHoldOn:
+		return true, nil
+	case legalHoldOff:
+		return false, nil
+	default:
+		return false, fmt.Errorf("%w: invalid legal hold status %q", core.ErrObjectLockInvalid, status)
+	}
+}
+
+func setObjectLockHeaders(c *echo.Context, metadata *core.ObjectMetadata) {
+	if retention := metadata.Retention; retention != nil {
+		SetHeaders(c, map[string]string{
+			objectLockModeHeader:        string(retention.Mode),
+			objectLockRetainUntilHeader: retention.RetainUntil.UTC().Format(time.RFC3339),
+		})
+	}
+
+	if metadata.LegalHold {
+		c.Response().Header().Set(objectLockLegalHoldHeader, legalHoldOn)
+	}
+}
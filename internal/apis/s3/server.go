@@ -3,14 +3,20 @@ package s3
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"sync"
 
 	"github.com/labstack/echo/v5"
 	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/certreload"
 )
 
 type Server struct {
 	Echo   *Echo
 	Config *core.Config
+	Logger *slog.Logger
+
+	certs *certreload.Reloader
 }
 
 func (s *Server) Init(_ context.Context) error {
@@ -18,13 +24,35 @@ func (s *Server) Init(_ context.Context) error {
 
 	buckets.GET("", s.Echo.rootQueryRouter.Handle)
 
-	return nil
+	if s.Config.S3TLSCertFile == "" {
+		return nil
+	}
+
+	var err error
+
+	s.certs, err = certreload.New(s.Config.S3TLSCertFile, s.Config.S3TLSKeyFile, "", s.Logger)
+
+	return err
 }
 
 func (s *Server) Run(ctx context.Context) error {
 	address := fmt.Sprintf(":%d", s.Config.Port)
 
 	sc := echo.StartConfig{Address: address}
+
+	if s.certs != nil {
+		ctx, cancel := context.WithCancel(ctx)
+
+		var wg sync.WaitGroup
+
+		defer wg.Wait()
+		defer cancel()
+
+		wg.Go(func() { s.certs.Run(ctx) })
+
+		sc.TLSConfig = s.certs.TLSConfig(s.Config.MinTLSVersion())
+	}
+
 	if err := sc.Start(ctx, s.Echo.Echo); err != nil {
 		return err
 	}
@@ -11,6 +11,7 @@ func Provide() pal.ServiceDef {
 		pal.Provide(&Server{}),
 		pal.Provide(&APIObjects{}),
 		pal.Provide(&APIBuckets{}),
+		pal.Provide(&APISTS{}),
 		pal.Provide(&Echo{}),
 		auth.Provide(),
 		middlewares.Provide(),
@@ -0,0 +1,132 @@
+package s3
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v5"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/sse"
+)
+
+const (
+	sseHeader = "X-Amz-Server-Side-Encryption"
+	// sseCustomerPrefix starts the SSE-C headers of the object a request is about.
+	sseCustomerPrefix = "X-Amz-Server-Side-Encryption-Customer-"
+	// copySourceSSECustomerPrefix starts the SSE-C headers of the source of a copy.
+	copySourceSSECustomerPrefix = "X-Amz-Copy-Source-Server-Side-Encryption-Customer-"
+)
+
+// RequireTLSForCustomerKeys rejects the requests that send SSE-C keys over plain HTTP, unless allowOverHTTP is set
+// for a proxy that terminates TLS.
+func RequireTLSForCustomerKeys(allowOverHTTP bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			if allowOverHTTP || c.Request().TLS != nil || !hasCustomerKey(c.Request().Header) {
+				return next(c)
+			}
+
+			return fmt.Errorf("%w: customer-provided keys must be sent over HTTPS", core.ErrSSEInvalidRequest)
+		}
+	}
+}
+
+func hasCustomerKey(header http.Header) bool {
+	for name := range header {
+		if strings.HasPrefix(name, sseCustomerPrefix) || strings.HasPrefix(name, copySourceSSECustomerPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseSSE returns the encryption a write request asks for, nil when it has no encryption headers and the
+// default encryption of the bucket applies.
+func parseSSE(header http.Header) (*core.ServerSideEncryption, error) {
+	customerKey, err := parseCustomerKey(header, sseCustomerPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm := header.Get(sseHeader)
+
+	switch {
+	case algorithm != "" && customerKey != nil:
+		return nil, fmt.Errorf("%w: %s cannot be combined with customer-provided keys", core.ErrSSEInvalidRequest,
+			sseHeader)
+	case customerKey != nil:
+		return &core.ServerSideEncryption{CustomerKey: customerKey}, nil
+	case algorithm == core.SSEAlgorithmAES256:
+		return &core.ServerSideEncryption{}, nil
+	case algorithm != "":
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", core.ErrSSEInvalidRequest, algorithm)
+	default:
+		return nil, nil //nolint:nilnil
+	}
+}
+
+// parseCustomerKey returns the SSE-C key sent in the headers starting with prefix, nil when there is none. The
+// algorithm must be AES256 and the MD5 must match the key.
+func parseCustomerKey(header http.Header, prefix string) ([]byte, error) {
+	algorithm := header.Get(prefix + "Algorithm")
+	encodedKey := header.Get(prefix + "Key")
+	keyMD5 := header.Get(prefix + "Key-Md5")
+
+	if algorithm == "" && encodedKey == "" && keyMD5 == "" {
+		return nil, nil
+	}
+
+	if algorithm != core.SSEAlgorithmAES256 {
+		return nil, fmt.Errorf("%w: unsupported customer algorithm %q", core.ErrSSEInvalidRequest, algorithm)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != sse.KeySize {
+		return nil, fmt.Errorf("%w: the customer key must be %d base64 encoded bytes", core.ErrSSEInvalidRequest,
+			sse.KeySize)
+	}
+
+	if (&core.ServerSideEncryption{CustomerKey: key}).CustomerKeyMD5() != keyMD5 {
+		return nil, fmt.Errorf("%w: the customer key MD5 does not match the key", core.ErrSSEInvalidRequest)
+	}
+
+	return key, nil
+}
+
+// unlockObject provides the SSE-C key sent in the headers starting with prefix to the object. Objects encrypted
+// with a customer key cannot be read without it, and it cannot be sent for other objects.
+func unlockObject(object core.Object, header http.Header, prefix string) error {
+	key, err := parseCustomerKey(header, prefix)
+	if err != nil {
+		return err
+	}
+
+	encryption := object.Metadata().Encryption
+
+	if key == nil {
+		if encryption != nil && encryption.IsCustomer() {
+			return core.ErrSSECustomerKeyRequired
+		}
+
+		return nil
+	}
+
+	return object.SetCustomerKey(key)
+}
+
+// setEncryptionHeaders reports how an object is encrypted.
+func setEncryptionHeaders(c *echo.Context, encryption *core.ObjectEncryption) {
+	switch {
+	case encryption == nil:
+	case encryption.IsCustomer():
+		SetHeaders(c, map[string]string{
+			sseCustomerPrefix + "Algorithm": core.SSEAlgorithmAES256,
+			sseCustomerPrefix + "Key-Md5":   encryption.CustomerKeyMD5,
+		})
+	default:
+		c.Response().Header().Set(sseHeader, core.SSEAlgorithmAES256)
+	}
+}
@@ -61,8 +61,62 @@ type tagXML struct {
 	Value string `xml:"Value"`
 }
 
+// bucketLoggingStatusXML without LoggingEnabled disables logging.
+type bucketLoggingStatusXML struct {
+	XMLName        xml.Name           `xml:"BucketLoggingStatus"`
+	LoggingEnabled *loggingEnabledXML `xml:"LoggingEnabled,omitempty"`
+}
+
+type loggingEnabledXML struct {
+	TargetBucket string `xml:"TargetBucket"`
+	TargetPrefix string `xml:"TargetPrefix"`
+}
+
+type serverSideEncryptionConfigurationXML struct {
+	XMLName xml.Name                      `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ServerSideEncryptionConfiguration"` //nolint:lll
+	Rules   []serverSideEncryptionRuleXML `xml:"Rule"`
+}
+
+type serverSideEncryptionRuleXML struct {
+	ApplyServerSideEncryptionByDefault *applyServerSideEncryptionByDefaultXML `xml:"ApplyServerSideEncryptionByDefault"`
+	BucketKeyEnabled                   bool                                   `xml:"BucketKeyEnabled"`
+}
+
+type applyServerSideEncryptionByDefaultXML struct {
+	SSEAlgorithm   string `xml:"SSEAlgorithm"`
+	KMSMasterKeyID string `xml:"KMSMasterKeyID,omitempty"`
+}
+
+type objectLockConfigurationXML struct {
+	XMLName           xml.Name           `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ObjectLockConfiguration"`
+	ObjectLockEnabled string             `xml:"ObjectLockEnabled,omitempty"`
+	Rule              *objectLockRuleXML `xml:"Rule,omitempty"`
+}
+
+type objectLockRuleXML struct {
+	DefaultRetention *defaultRetentionXML `xml:"DefaultRetention"`
+}
+
+type defaultRetentionXML struct {
+	Mode  string `xml:"Mode"`
+	Days  int    `xml:"Days,omitempty"`
+	Years int    `xml:"Years,omitempty"`
+}
+
+type retentionXML struct {
+	XMLName         xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ Retention"`
+	Mode            string   `xml:"Mode,omitempty"`
+	RetainUntilDate string   `xml:"RetainUntilDate,omitempty"`
+}
+
+type legalHoldXML struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ LegalHold"`
+	Status  string   `xml:"Status"`
+}
+
+// deleteRequestXML accepts Delete in any namespace, some clients (minio-go) send it without one.
 type deleteRequestXML struct {
-	XMLName xml.Name          `xml:"http://s3.amazonaws.com/doc/2006-03-01/ Delete"`
+	XMLName xml.Name          `xml:"Delete"`
 	Objects []deleteObjectXML `xml:"Object"`
 	Quiet   *bool             `xml:"Quiet"`
 }
@@ -173,3 +227,44 @@ type listMultipartUploadsResultXML struct {
 	Uploads            []listMultipartUploadEntryXML `xml:"Upload,omitempty"`
 	CommonPrefixes     []prefixEntry                 `xml:"CommonPrefixes,omitempty"`
 }
+
+type stsCredentialsXML struct {
+	AccessKeyID     string `xml:"AccessKeyId"`
+	SecretAccessKey string `xml:"SecretAccessKey"`
+	SessionToken    string `xml:"SessionToken"`
+	Expiration      string `xml:"Expiration"`
+}
+
+type assumedRoleUserXML struct {
+	Arn           string `xml:"Arn"`
+	AssumedRoleID string `xml:"AssumedRoleId"`
+}
+
+type assumeRoleResultXML struct {
+	Credentials     stsCredentialsXML  `xml:"Credentials"`
+	AssumedRoleUser assumedRoleUserXML `xml:"AssumedRoleUser"`
+}
+
+type stsResponseMetadataXML struct {
+	RequestID string `xml:"RequestId"`
+}
+
+type assumeRoleResponseXML struct {
+	XMLName          xml.Name               `xml:"https://sts.amazonaws.com/doc/2011-06-15/ AssumeRoleResponse"`
+	AssumeRoleResult assumeRoleResultXML    `xml:"AssumeRoleResult"`
+	ResponseMetadata stsResponseMetadataXML `xml:"ResponseMetadata"`
+}
+
+type assumeRoleWithWebIdentityResultXML struct {
+	Credentials                 stsCredentialsXML  `xml:"Credentials"`
+	AssumedRoleUser             assumedRoleUserXML `xml:"AssumedRoleUser"`
+	SubjectFromWebIdentityToken string             `xml:"SubjectFromWebIdentityToken"`
+	Audience                    string             `xml:"Audience"`
+	Provider                    string             `xml:"Provider"`
+}
+
+type assumeRoleWithWebIdentityResponseXML struct {
+	XMLName                         xml.Name                           `xml:"https://sts.amazonaws.com/doc/2011-06-15/ AssumeRoleWithWebIdentityResponse"` //nolint:lll
+	AssumeRoleWithWebIdentityResult assumeRoleWithWebIdentityResultXML `xml:"AssumeRoleWithWebIdentityResult"`
+	ResponseMetadata                stsResponseMetadataXML             `xml:"ResponseMetadata"`
+}
@@ -8,12 +8,20 @@ import (
 	"time"
 
 	"github.com/golang-cz/devslog"
+	"github.com/zhulik/d3/internal/accesslog"
 	managementapi "github.com/zhulik/d3/internal/apis/management"
 	"github.com/zhulik/d3/internal/apis/s3"
+	"github.com/zhulik/d3/internal/audit"
 	managementbackend "github.com/zhulik/d3/internal/backends/management"
 	"github.com/zhulik/d3/internal/backends/storage"
 	"github.com/zhulik/d3/internal/core"
 	"github.com/zhulik/d3/internal/locker"
+	"github.com/zhulik/d3/internal/notifier"
+	"github.com/zhulik/d3/internal/ratelimit"
+	"github.com/zhulik/d3/internal/redisclient"
+	"github.com/zhulik/d3/internal/sessions"
+	"github.com/zhulik/d3/internal/stats"
+	"github.com/zhulik/d3/internal/webidentity"
 	"github.com/zhulik/pal"
 )
 
@@ -45,6 +53,14 @@ func NewServer(config *core.Config) *pal.Pal {
 		storage.Provide(config),
 		pal.Provide(config),
 		locker.Provide(),
+		notifier.Provide(),
+		redisclient.Provide(),
+		sessions.Provide(),
+		webidentity.Provide(),
+		audit.Provide(config),
+		accesslog.Provide(),
+		ratelimit.Provide(),
+		stats.Provide(),
 	).
 		InitTimeout(1*time.Minute).
 		HealthCheckTimeout(5*time.Second).
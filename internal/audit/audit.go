@@ -0,0 +1,60 @@
+// Package audit implements the sinks of the audit log.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"slices"
+
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/json"
+)
+
+// maxEventSize bounds a single JSON-lines record when reading events back.
+const maxEventSize = core.SizeLimit1Mb
+
+// Disabled is the audit log used when no sink is configured. It drops events.
+type Disabled struct{}
+
+func (d *Disabled) Record(_ context.Context, _ *core.AuditEvent) error {
+	return nil
+}
+
+func (d *Disabled) Query(_ context.Context, _ core.AuditQuery) ([]core.AuditEvent, error) {
+	return nil, core.ErrAuditLogDisabled
+}
+
+// readEvents appends the events of a JSON-lines stream matching the query to events.
+func readEvents(reader io.Reader, query core.AuditQuery, events []core.AuditEvent) ([]core.AuditEvent, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, maxEventSize)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		event, err := json.Unmarshal[core.AuditEvent](scanner.Bytes())
+		if err != nil {
+			return nil, err
+		}
+
+		if query.Matches(event) {
+			events = append(events, event)
+		}
+	}
+
+	return events, scanner.Err()
+}
+
+// finalize orders events by time and applies the query limit.
+func finalize(events []core.AuditEvent, query core.AuditQuery) []core.AuditEvent {
+	slices.SortStableFunc(events, func(a, b core.AuditEvent) int { return a.Time.Compare(b.Time) })
+
+	if query.Limit > 0 && len(events) > query.Limit {
+		events = events[:query.Limit]
+	}
+
+	return events
+}
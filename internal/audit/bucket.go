@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/batchwriter"
+	"github.com/zhulik/d3/pkg/json"
+)
+
+const (
+	bucketKeyPrefix = "audit/"
+	// bucketKeyTimeFormat is the time of the first event of a batch, it leads the batch's object key.
+	bucketKeyTimeFormat = "2006/01/02/150405.000000000"
+	// maxBatchSize makes Run write a batch before the flush interval elapses.
+	maxBatchSize = 1000
+	// defaultMaxPending applies when AuditLogBucketMaxPending is not set.
+	defaultMaxPending = 100_000
+)
+
+// BucketSink writes events to a d3 bucket in batches, one JSON-lines object per batch. Events are buffered for
+// up to AuditLogBucketFlushInterval, queries include the buffered events of this instance. While the bucket
+// cannot be written, up to AuditLogBucketMaxPending events are kept, later ones are dropped and counted.
+type BucketSink struct {
+	Config  *core.Config
+	Backend core.StorageBackend
+	Logger  *slog.Logger
+
+	batches *batchwriter.Writer[string, core.AuditEvent]
+}
+
+func (s *BucketSink) Init(ctx context.Context) error {
+	s.batches = &batchwriter.Writer[string, core.AuditEvent]{
+		MaxBatch:   maxBatchSize,
+		MaxPending: lo.CoalesceOrEmpty(s.Config.AuditLogBucketMaxPending, defaultMaxPending),
+		Write:      s.write,
+		Logger:     s.Logger.With("component", "audit log"),
+	}
+
+	_, err := s.Backend.HeadBucket(ctx, s.Config.AuditLogBucket)
+
+	return err
+}
+
+func (s *BucketSink) Run(ctx context.Context) error {
+	s.batches.Run(ctx, s.Config.AuditLogBucketFlushInterval)
+
+	return nil
+}
+
+func (s *BucketSink) Shutdown(ctx context.Context) error {
+	return s.batches.Flush(ctx)
+}
+
+func (s *BucketSink) Record(_ context.Context, event *core.AuditEvent) error {
+	s.batches.Add(s.Config.AuditLogBucket, *event)
+
+	return nil
+}
+
+func (s *BucketSink) Query(ctx context.Context, query core.AuditQuery) ([]core.AuditEvent, error) {
+	bucket, err := s.Backend.HeadBucket(ctx, s.Config.AuditLogBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []core.AuditEvent
+
+	input := core.ListObjectsV2Input{Prefix: bucketKeyPrefix, MaxKeys: core.MaxKeys}
+
+	for {
+		result, err := bucket.ListObjectsV2(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range result.Objects {
+			events, err = s.readBatch(ctx, bucket, object.Key(), query, events)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if !result.IsTruncated || result.ContinuationToken == nil {
+			break
+		}
+
+		input.ContinuationToken = *result.ContinuationToken
+	}
+
+	for _, event := range s.batches.Pending(s.Config.AuditLogBucket) {
+		if query.Matches(event) {
+			events = append(events, event)
+		}
+	}
+
+	return finalize(events, query), nil
+}
+
+func (s *BucketSink) readBatch(ctx context.Context, bucket core.Bucket, key string, query core.AuditQuery,
+	events []core.AuditEvent,
+) ([]core.AuditEvent, error) {
+	// Every event of a batch was recorded at or after the time in its key.
+	timestamp := strings.TrimPrefix(key, bucketKeyPrefix)
+	if len(timestamp) >= len(bucketKeyTimeFormat) {
+		startedAt, err := time.Parse(bucketKeyTimeFormat, timestamp[:len(bucketKeyTimeFormat)])
+		if err == nil && !query.To.IsZero() && !startedAt.Before(query.To) {
+			return events, nil
+		}
+	}
+
+	object, err := bucket.GetObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+
+	return readEvents(object, query, events)
+}
+
+func (s *BucketSink) write(ctx context.Context, bucketName string, batch []core.AuditEvent) error {
+	bucket, err := s.Backend.HeadBucket(ctx, bucketName)
+	if err != nil {
+		return err
+	}
+
+	var content bytes.Buffer
+
+	for _, event := range batch {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		content.Write(line)
+		content.WriteByte('\n')
+	}
+
+	key := bucketKeyPrefix + batch[0].Time.UTC().Format(bucketKeyTimeFormat) + "-" + uuid.NewString() + ".jsonl"
+
+	return bucket.PutObject(ctx, key, core.PutObjectInput{
+		Reader: &content,
+		Metadata: core.ObjectMetadata{
+			ContentType: "application/x-ndjson",
+			Size:        int64(content.Len()),
+		},
+	})
+}
@@ -0,0 +1,75 @@
+package audit_test
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/audit"
+	"github.com/zhulik/d3/internal/backends/storage/folder"
+	"github.com/zhulik/d3/internal/backends/storage/storagetest"
+	"github.com/zhulik/d3/internal/core"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BucketSink", func() {
+	var (
+		backend *folder.Backend
+		sink    *audit.BucketSink
+	)
+
+	event := func(action string) *core.AuditEvent {
+		return &core.AuditEvent{Time: time.Now().UTC(), User: "alice", Action: action, Outcome: core.AuditOutcomeSuccess}
+	}
+
+	actions := func(events []core.AuditEvent) []string {
+		return lo.Map(events, func(event core.AuditEvent, _ int) string { return event.Action })
+	}
+
+	BeforeEach(func(ctx context.Context) {
+		backend = &folder.Backend{
+			Cfg: &core.Config{
+				FolderStorageBackendPath: GinkgoT().TempDir(),
+				AuditLogBucket:           "audit",
+				AuditLogBucketMaxPending: 2,
+			},
+			Locker: storagetest.NewLocker(),
+		}
+		Expect(backend.Init(ctx)).To(Succeed())
+		Expect(backend.CreateBucket(ctx, "audit")).To(Succeed())
+
+		sink = &audit.BucketSink{Config: backend.Cfg, Backend: backend, Logger: slog.New(slog.DiscardHandler)}
+		Expect(sink.Init(ctx)).To(Succeed())
+	})
+
+	It("writes the buffered events to the bucket", func(ctx context.Context) {
+		Expect(sink.Record(ctx, event("s3:DeleteObject"))).To(Succeed())
+		Expect(sink.Shutdown(ctx)).To(Succeed())
+
+		bucket := lo.Must(backend.HeadBucket(ctx, "audit"))
+		Expect(lo.Must(bucket.ListObjectsV2(ctx, core.ListObjectsV2Input{MaxKeys: core.MaxKeys})).Objects).To(HaveLen(1))
+		Expect(actions(lo.Must(sink.Query(ctx, core.AuditQuery{})))).To(Equal([]string{"s3:DeleteObject"}))
+	})
+
+	When("the bucket cannot be written", func() {
+		It("keeps up to AuditLogBucketMaxPending events", func(ctx context.Context) {
+			Expect(backend.DeleteBucket(ctx, "audit")).To(Succeed())
+
+			for _, action := range []string{"s3:DeleteObject", "s3:CopyObject", "d3:CreateUser"} {
+				Expect(sink.Record(ctx, event(action))).To(Succeed())
+			}
+
+			Expect(sink.Shutdown(ctx)).To(MatchError(core.ErrBucketNotFound))
+
+			Expect(backend.CreateBucket(ctx, "audit")).To(Succeed())
+			Expect(sink.Shutdown(ctx)).To(Succeed())
+
+			Expect(actions(lo.Must(sink.Query(ctx, core.AuditQuery{})))).To(Equal([]string{
+				"s3:DeleteObject", "s3:CopyObject",
+			}))
+		})
+	})
+})
@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/json"
+)
+
+const rotatedTimeFormat = "20060102T150405.000000000Z"
+
+// FileSink appends events to a JSON-lines file. When the file grows past AuditLogFileMaxSize it is renamed with
+// the rotation time inserted before the extension, and a new file is started.
+type FileSink struct {
+	Config *core.Config
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func (s *FileSink) Init(_ context.Context) error {
+	err := os.MkdirAll(filepath.Dir(s.Config.AuditLogFilePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	return s.open()
+}
+
+func (s *FileSink) Shutdown(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+func (s *FileSink) Record(_ context.Context, event *core.AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size > 0 && s.size+int64(len(line)) > s.Config.AuditLogFileMaxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+
+	return err
+}
+
+func (s *FileSink) Query(_ context.Context, query core.AuditQuery) ([]core.AuditEvent, error) {
+	base, ext := s.nameParts()
+
+	rotated, err := filepath.Glob(base + "-*" + ext)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []core.AuditEvent
+
+	for _, path := range append(rotated, s.Config.AuditLogFilePath) {
+		// A rotated file only holds events recorded before its rotation time.
+		suffix := strings.TrimSuffix(strings.TrimPrefix(path, base+"-"), ext)
+		if rotatedAt, err := time.Parse(rotatedTimeFormat, suffix); err == nil && rotatedAt.Before(query.From) {
+			continue
+		}
+
+		events, err = readEventsFromFile(path, query, events)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return finalize(events, query), nil
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.Config.AuditLogFilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Join(err, file.Close())
+	}
+
+	s.file = file
+	s.size = info.Size()
+
+	return nil
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+
+	base, ext := s.nameParts()
+
+	err := os.Rename(s.Config.AuditLogFilePath, base+"-"+time.Now().UTC().Format(rotatedTimeFormat)+ext)
+	if err != nil {
+		// Reopen the current file, so later events are still recorded.
+		return errors.Join(err, s.open())
+	}
+
+	return s.open()
+}
+
+// nameParts splits the file path into the part before the extension and the extension.
+func (s *FileSink) nameParts() (string, string) {
+	ext := filepath.Ext(s.Config.AuditLogFilePath)
+
+	return strings.TrimSuffix(s.Config.AuditLogFilePath, ext), ext
+}
+
+func readEventsFromFile(path string, query core.AuditQuery, events []core.AuditEvent) ([]core.AuditEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return events, nil
+		}
+
+		return nil, err
+	}
+	defer file.Close()
+
+	return readEvents(file, query, events)
+}
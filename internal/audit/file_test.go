@@ -0,0 +1,100 @@
+package audit_test
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/audit"
+	"github.com/zhulik/d3/internal/core"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileSink", func() {
+	var (
+		sink *audit.FileSink
+		dir  string
+		base time.Time
+	)
+
+	event := func(offset time.Duration, user, action string) *core.AuditEvent {
+		return &core.AuditEvent{
+			Time:     base.Add(offset),
+			User:     user,
+			Action:   action,
+			Resource: "bucket/key",
+			Outcome:  core.AuditOutcomeSuccess,
+			Status:   204,
+		}
+	}
+
+	actions := func(events []core.AuditEvent) []string {
+		return lo.Map(events, func(event core.AuditEvent, _ int) string { return event.Action })
+	}
+
+	BeforeEach(func(ctx context.Context) {
+		dir = GinkgoT().TempDir()
+		base = time.Now().UTC().Truncate(time.Second)
+
+		sink = &audit.FileSink{Config: &core.Config{
+			AuditLogFilePath:    filepath.Join(dir, "audit", "audit.jsonl"),
+			AuditLogFileMaxSize: 1 << 20,
+		}}
+		Expect(sink.Init(ctx)).To(Succeed())
+
+		DeferCleanup(func(ctx context.Context) {
+			Expect(sink.Shutdown(ctx)).To(Succeed())
+		})
+
+		Expect(sink.Record(ctx, event(2*time.Minute, "alice", "s3:DeleteObject"))).To(Succeed())
+		Expect(sink.Record(ctx, event(0, "bob", "s3:CopyObject"))).To(Succeed())
+		Expect(sink.Record(ctx, event(time.Minute, "alice", "d3:CreateUser"))).To(Succeed())
+	})
+
+	It("returns all events oldest first", func(ctx context.Context) {
+		events := lo.Must(sink.Query(ctx, core.AuditQuery{}))
+		Expect(actions(events)).To(Equal([]string{"s3:CopyObject", "d3:CreateUser", "s3:DeleteObject"}))
+		Expect(events[0].User).To(Equal("bob"))
+		Expect(events[0].Time).To(BeTemporally("==", base))
+	})
+
+	DescribeTable("filters events",
+		func(ctx context.Context, query func() core.AuditQuery, expected []string) {
+			Expect(actions(lo.Must(sink.Query(ctx, query())))).To(Equal(expected))
+		},
+		Entry("by user", func() core.AuditQuery { return core.AuditQuery{User: "alice"} },
+			[]string{"d3:CreateUser", "s3:DeleteObject"}),
+		Entry("by action", func() core.AuditQuery { return core.AuditQuery{Action: "s3:CopyObject"} },
+			[]string{"s3:CopyObject"}),
+		Entry("by time range", func() core.AuditQuery {
+			return core.AuditQuery{From: base.Add(time.Minute), To: base.Add(2 * time.Minute)}
+		}, []string{"d3:CreateUser"}),
+		Entry("with a limit", func() core.AuditQuery { return core.AuditQuery{Limit: 2} },
+			[]string{"s3:CopyObject", "d3:CreateUser"}),
+	)
+
+	It("rotates the file when it grows past the max size", func(ctx context.Context) {
+		sink.Config.AuditLogFileMaxSize = 1
+
+		Expect(sink.Record(ctx, event(3*time.Minute, "carol", "s3:DeleteBucket"))).To(Succeed())
+		Expect(sink.Record(ctx, event(4*time.Minute, "carol", "s3:DeleteObjects"))).To(Succeed())
+
+		rotated := lo.Must(filepath.Glob(filepath.Join(dir, "audit", "audit-*.jsonl")))
+		Expect(rotated).To(HaveLen(2))
+
+		Expect(actions(lo.Must(sink.Query(ctx, core.AuditQuery{User: "carol"})))).To(Equal(
+			[]string{"s3:DeleteBucket", "s3:DeleteObjects"},
+		))
+		Expect(lo.Must(sink.Query(ctx, core.AuditQuery{}))).To(HaveLen(5))
+	})
+})
+
+var _ = Describe("Disabled", func() {
+	It("rejects queries", func(ctx context.Context) {
+		_, err := (&audit.Disabled{}).Query(ctx, core.AuditQuery{})
+		Expect(err).To(MatchError(core.ErrAuditLogDisabled))
+	})
+})
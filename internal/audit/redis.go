@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/internal/redisclient"
+	"github.com/zhulik/d3/pkg/json"
+)
+
+const (
+	eventField = "event"
+	// queryBatchSize is the number of stream entries read per XRANGE call.
+	queryBatchSize = 1000
+)
+
+// RedisSink appends events to a Redis stream shared by all d3 instances. Stream IDs are generated by Redis, so
+// queries by time range only scan the matching part of the stream.
+type RedisSink struct {
+	Config *core.Config
+	Redis  *redisclient.Client
+}
+
+func (s *RedisSink) Record(ctx context.Context, event *core.AuditEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	cmd := s.Redis.B().Xadd().Key(s.Config.AuditLogRedisStream).Id("*").FieldValue().
+		FieldValue(eventField, string(data)).Build()
+
+	return s.Redis.Do(ctx, cmd).Error()
+}
+
+func (s *RedisSink) Query(ctx context.Context, query core.AuditQuery) ([]core.AuditEvent, error) {
+	start, end := "-", "+"
+
+	if !query.From.IsZero() {
+		start = strconv.FormatInt(query.From.UnixMilli(), 10)
+	}
+
+	if !query.To.IsZero() {
+		end = strconv.FormatInt(query.To.UnixMilli(), 10)
+	}
+
+	var events []core.AuditEvent
+
+	for query.Limit <= 0 || len(events) < query.Limit {
+		cmd := s.Redis.B().Xrange().Key(s.Config.AuditLogRedisStream).Start(start).End(end).
+			Count(queryBatchSize).Build()
+
+		entries, err := s.Redis.Do(ctx, cmd).AsXRange()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			event, err := json.Unmarshal[core.AuditEvent]([]byte(entry.FieldValues[eventField]))
+			if err != nil {
+				return nil, err
+			}
+
+			if query.Matches(event) {
+				events = append(events, event)
+			}
+		}
+
+		if len(entries) < queryBatchSize {
+			break
+		}
+
+		start = "(" + entries[len(entries)-1].ID
+	}
+
+	return finalize(events, query), nil
+}
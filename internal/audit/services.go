@@ -0,0 +1,23 @@
+package audit
+
+import (
+	"fmt"
+
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/pal"
+)
+
+func Provide(config *core.Config) pal.ServiceDef {
+	switch config.AuditLogSink {
+	case core.AuditLogSinkNone:
+		return pal.Provide[core.AuditLog](&Disabled{})
+	case core.AuditLogSinkFile:
+		return pal.Provide[core.AuditLog](&FileSink{})
+	case core.AuditLogSinkBucket:
+		return pal.Provide[core.AuditLog](&BucketSink{})
+	case core.AuditLogSinkRedis:
+		return pal.Provide[core.AuditLog](&RedisSink{})
+	default:
+		panic(fmt.Sprintf("unknown audit log sink: %s", config.AuditLogSink))
+	}
+}
@@ -3,6 +3,7 @@ package management
 import (
 	"fmt"
 
+	"github.com/zhulik/d3/internal/backends/management/sqlite"
 	"github.com/zhulik/d3/internal/backends/management/yaml"
 	"github.com/zhulik/d3/internal/core"
 	"github.com/zhulik/pal"
@@ -12,6 +13,8 @@ func Provide(config *core.Config) pal.ServiceDef {
 	switch config.ManagementBackend {
 	case core.ManagementBackendYAML:
 		return yaml.Provide()
+	case core.ManagementBackendSQLite:
+		return sqlite.Provide()
 	default:
 		panic(fmt.Sprintf("unknown management backend: %s", config.ManagementBackend))
 	}
@@ -0,0 +1,1260 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/backends/management/yaml"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/json"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// Backend stores users, policies and bindings in a SQLite database. Unlike the YAML backend it does not keep
+// an in-memory copy: every read goes to the database, so all instances sharing the file see changes at once.
+type Backend struct {
+	Config *core.Config
+	Locker core.Locker
+	Logger *slog.Logger
+
+	adminUser *core.User
+	db        *sql.DB
+}
+
+func (b *Backend) Init(ctx context.Context) error {
+	// Lock the backend to prevent concurrent initialization
+	ctx, cancel, err := b.Locker.Lock(ctx, "sqlite-management-backend-init")
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	adminUser, err := yaml.ResolveAdminUser(b.Config, b.Logger)
+	if err != nil {
+		return err
+	}
+
+	b.adminUser = adminUser
+
+	path := b.Config.ManagementBackendSQLitePath
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	b.db, err = sql.Open("sqlite", dsn(path))
+	if err != nil {
+		return err
+	}
+
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		version, err := migrate(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		if version == 0 {
+			return b.importYAML(ctx, tx, b.Config.ManagementBackendYAMLPath)
+		}
+
+		return nil
+	})
+}
+
+func (b *Backend) Shutdown(_ context.Context) error {
+	if b.db == nil {
+		return nil
+	}
+
+	return b.db.Close()
+}
+
+func (b *Backend) GetUsers(ctx context.Context) ([]string, error) {
+	rows, err := b.db.QueryContext(ctx, "SELECT name FROM users ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return append(names, "admin"), nil
+}
+
+func (b *Backend) GetUserByName(ctx context.Context, name string) (*core.User, error) {
+	if name == b.adminUser.Name {
+		return b.adminUser, nil
+	}
+
+	return b.getUser(ctx, name)
+}
+
+func (b *Backend) GetUserByAccessKeyID(ctx context.Context, accessKeyID string) (*core.User, error) {
+	user, err := b.getUserByAccessKeyID(ctx, accessKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, _ := user.AccessKey(accessKeyID)
+	if err := key.Usable(time.Now()); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (b *Backend) CreateUser(ctx context.Context, username string) (*core.User, error) {
+	if username == "" {
+		return nil, core.ErrUserInvalid
+	}
+
+	if username == "admin" {
+		return nil, core.ErrUserNameReserved
+	}
+
+	newUser := &core.User{
+		Name:       username,
+		AccessKeys: []core.AccessKey{core.NewAccessKey(nil)},
+	}
+
+	err := b.inTx(ctx, func(tx *sql.Tx) error {
+		found, err := exists(ctx, tx, "SELECT 1 FROM users WHERE name = ?", username)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			return core.ErrUserAlreadyExists
+		}
+
+		return insertUser(ctx, tx, newUser)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newUser, nil
+}
+
+func (b *Backend) DeleteUser(ctx context.Context, userName string) error {
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		err := execAffecting(ctx, tx, core.ErrUserNotFound, "DELETE FROM users WHERE name = ?", userName)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM access_keys WHERE user_name = ?", userName)
+		if err != nil {
+			return err
+		}
+
+		// A user created later under the same name must not inherit the memberships or role trust.
+		_, err = tx.ExecContext(ctx, "DELETE FROM group_members WHERE user_name = ?", userName)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM role_trusted_users WHERE user_name = ?", userName)
+
+		return err
+	})
+}
+
+func (b *Backend) SetUserLimits(ctx context.Context, name string, limits *core.RateLimits) error {
+	if name == b.adminUser.Name {
+		return core.ErrUserNameReserved
+	}
+
+	return b.setLimits(ctx, core.ErrUserNotFound, "UPDATE users SET limits = ? WHERE name = ?", name, limits)
+}
+
+func (b *Backend) CreateAccessKey(ctx context.Context, userName string, expiresAt *time.Time) (*core.AccessKey, error) {
+	if userName == b.adminUser.Name {
+		return nil, core.ErrUserNameReserved
+	}
+
+	if err := core.ValidateAccessKeyExpiry(expiresAt); err != nil {
+		return nil, err
+	}
+
+	newKey := core.NewAccessKey(expiresAt)
+
+	err := b.inTx(ctx, func(tx *sql.Tx) error {
+		found, err := exists(ctx, tx, "SELECT 1 FROM users WHERE name = ?", userName)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			return core.ErrUserNotFound
+		}
+
+		return insertAccessKey(ctx, tx, userName, newKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &newKey, nil
+}
+
+func (b *Backend) SetAccessKeyStatus(ctx context.Context, userName string, accessKeyID string,
+	status core.AccessKeyStatus,
+) error {
+	if status != core.AccessKeyStatusActive && status != core.AccessKeyStatusInactive {
+		return fmt.Errorf("%w: unknown status %q", core.ErrAccessKeyInvalid, status)
+	}
+
+	if userName == b.adminUser.Name {
+		return core.ErrUserNameReserved
+	}
+
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		if err := userExists(ctx, tx, userName); err != nil {
+			return err
+		}
+
+		return execAffecting(ctx, tx, core.ErrAccessKeyNotFound,
+			"UPDATE access_keys SET status = ? WHERE user_name = ? AND access_key_id = ?",
+			string(status), userName, accessKeyID,
+		)
+	})
+}
+
+func (b *Backend) DeleteAccessKey(ctx context.Context, userName string, accessKeyID string) error {
+	if userName == b.adminUser.Name {
+		return core.ErrUserNameReserved
+	}
+
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		if err := userExists(ctx, tx, userName); err != nil {
+			return err
+		}
+
+		return execAffecting(ctx, tx, core.ErrAccessKeyNotFound,
+			"DELETE FROM access_keys WHERE user_name = ? AND access_key_id = ?", userName, accessKeyID,
+		)
+	})
+}
+
+func (b *Backend) ReplaceAccessKeys(ctx context.Context, userName string) (*core.AccessKey, error) {
+	if userName == b.adminUser.Name {
+		return nil, core.ErrUserNameReserved
+	}
+
+	newKey := core.NewAccessKey(nil)
+
+	err := b.inTx(ctx, func(tx *sql.Tx) error {
+		if err := userExists(ctx, tx, userName); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, "DELETE FROM access_keys WHERE user_name = ?", userName)
+		if err != nil {
+			return err
+		}
+
+		return insertAccessKey(ctx, tx, userName, newKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &newKey, nil
+}
+
+func (b *Backend) TouchAccessKey(ctx context.Context, accessKeyID string, usedAt time.Time) error {
+	// The admin key comes from the environment and is not persisted, so there is nothing to record.
+	if _, ok := b.adminUser.AccessKey(accessKeyID); ok {
+		return nil
+	}
+
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		return execAffecting(ctx, tx, core.ErrAccessKeyNotFound,
+			"UPDATE access_keys SET last_used_at = ? WHERE access_key_id = ?", usedAt.UTC(), accessKeyID,
+		)
+	})
+}
+
+func (b *Backend) GetPolicies(ctx context.Context) ([]string, error) {
+	rows, err := b.db.QueryContext(ctx, "SELECT id FROM policies ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (b *Backend) GetPolicyByID(ctx context.Context, id string) (*iampol.IAMPolicy, error) {
+	var document []byte
+
+	err := b.db.QueryRowContext(ctx, "SELECT document FROM policies WHERE id = ?", id).Scan(&document)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, core.ErrPolicyNotFound
+		}
+
+		return nil, err
+	}
+
+	policy, err := json.Unmarshal[iampol.IAMPolicy](document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode policy %s: %w", id, err)
+	}
+
+	return &policy, nil
+}
+
+func (b *Backend) CreatePolicy(ctx context.Context, newPolicy *iampol.IAMPolicy) error {
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		found, err := exists(ctx, tx, "SELECT 1 FROM policies WHERE id = ?", newPolicy.ID)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			return core.ErrPolicyAlreadyExists
+		}
+
+		return insertPolicy(ctx, tx, newPolicy)
+	})
+}
+
+func (b *Backend) UpdatePolicy(ctx context.Context, updatedPolicy *iampol.IAMPolicy) error {
+	document, err := json.Marshal(updatedPolicy)
+	if err != nil {
+		return err
+	}
+
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		return execAffecting(ctx, tx, core.ErrPolicyNotFound,
+			"UPDATE policies SET document = ? WHERE id = ?", document, updatedPolicy.ID,
+		)
+	})
+}
+
+func (b *Backend) DeletePolicy(ctx context.Context, policyID string) error {
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		return execAffecting(ctx, tx, core.ErrPolicyNotFound, "DELETE FROM policies WHERE id = ?", policyID)
+	})
+}
+
+func (b *Backend) GetBindings(ctx context.Context) ([]*core.PolicyBinding, error) {
+	return b.getBindings(ctx, "SELECT user_name, policy_id FROM bindings ORDER BY rowid")
+}
+
+func (b *Backend) GetBindingsByUser(ctx context.Context, userName string) ([]*core.PolicyBinding, error) {
+	return b.getBindings(ctx, "SELECT user_name, policy_id FROM bindings WHERE user_name = ? ORDER BY rowid", userName)
+}
+
+func (b *Backend) GetBindingsByPolicy(ctx context.Context, policyID string) ([]*core.PolicyBinding, error) {
+	return b.getBindings(ctx, "SELECT user_name, policy_id FROM bindings WHERE policy_id = ? ORDER BY rowid", policyID)
+}
+
+func (b *Backend) CreateBinding(ctx context.Context, binding *core.PolicyBinding) error {
+	if binding.UserName == "" || binding.PolicyID == "" {
+		return core.ErrBindingInvalid
+	}
+
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		if binding.UserName != b.adminUser.Name {
+			found, err := exists(ctx, tx, "SELECT 1 FROM users WHERE name = ?", binding.UserName)
+			if err != nil {
+				return err
+			}
+
+			if !found {
+				return core.ErrUserNotFound
+			}
+		}
+
+		found, err := exists(ctx, tx, "SELECT 1 FROM policies WHERE id = ?", binding.PolicyID)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			return core.ErrPolicyNotFound
+		}
+
+		found, err = exists(ctx, tx, "SELECT 1 FROM bindings WHERE user_name = ? AND policy_id = ?",
+			binding.UserName, binding.PolicyID)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			return core.ErrBindingAlreadyExists
+		}
+
+		return insertBinding(ctx, tx, binding)
+	})
+}
+
+func (b *Backend) DeleteBinding(ctx context.Context, binding *core.PolicyBinding) error {
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		return execAffecting(ctx, tx, core.ErrBindingNotFound,
+			"DELETE FROM bindings WHERE user_name = ? AND policy_id = ?", binding.UserName, binding.PolicyID,
+		)
+	})
+}
+
+func (b *Backend) GetGroups(ctx context.Context) ([]string, error) {
+	return b.getNames(ctx, "SELECT name FROM groups ORDER BY name")
+}
+
+func (b *Backend) GetGroupByName(ctx context.Context, name string) (*core.Group, error) {
+	group := &core.Group{}
+
+	var limits []byte
+
+	err := b.db.QueryRowContext(ctx, "SELECT name, limits FROM groups WHERE name = ?", name).
+		Scan(&group.Name, &limits)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, core.ErrGroupNotFound
+		}
+
+		return nil, err
+	}
+
+	group.Limits, err = json.Unmarshal[*core.RateLimits](limits)
+	if err != nil {
+		return nil, err
+	}
+
+	group.Members, err = b.getNames(ctx,
+		"SELECT user_name FROM group_members WHERE group_name = ? ORDER BY rowid", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (b *Backend) GetGroupsByUser(ctx context.Context, userName string) ([]string, error) {
+	return b.getNames(ctx, "SELECT group_name FROM group_members WHERE user_name = ? ORDER BY group_name", userName)
+}
+
+func (b *Backend) CreateGroup(ctx context.Context, name string) error {
+	if name == "" {
+		return core.ErrGroupInvalid
+	}
+
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		found, err := exists(ctx, tx, "SELECT 1 FROM groups WHERE name = ?", name)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			return core.ErrGroupAlreadyExists
+		}
+
+		return insertGroup(ctx, tx, &core.Group{Name: name})
+	})
+}
+
+func (b *Backend) DeleteGroup(ctx context.Context, name string) error {
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		err := execAffecting(ctx, tx, core.ErrGroupNotFound, "DELETE FROM groups WHERE name = ?", name)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM group_members WHERE group_name = ?", name)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM group_bindings WHERE group_name = ?", name)
+
+		return err
+	})
+}
+
+func (b *Backend) AddGroupMember(ctx context.Context, groupName string, userName string) error {
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		if err := groupExists(ctx, tx, groupName); err != nil {
+			return err
+		}
+
+		if userName != b.adminUser.Name {
+			if err := userExists(ctx, tx, userName); err != nil {
+				return err
+			}
+		}
+
+		found, err := exists(ctx, tx, "SELECT 1 FROM group_members WHERE group_name = ? AND user_name = ?",
+			groupName, userName)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			return core.ErrGroupMemberAlreadyExists
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO group_members (group_name, user_name) VALUES (?, ?)",
+			groupName, userName)
+
+		return err
+	})
+}
+
+func (b *Backend) RemoveGroupMember(ctx context.Context, groupName string, userName string) error {
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		if err := groupExists(ctx, tx, groupName); err != nil {
+			return err
+		}
+
+		return execAffecting(ctx, tx, core.ErrGroupMemberNotFound,
+			"DELETE FROM group_members WHERE group_name = ? AND user_name = ?", groupName, userName,
+		)
+	})
+}
+
+func (b *Backend) SetGroupLimits(ctx context.Context, name string, limits *core.RateLimits) error {
+	return b.setLimits(ctx, core.ErrGroupNotFound, "UPDATE groups SET limits = ? WHERE name = ?", name, limits)
+}
+
+func (b *Backend) GetGroupBindings(ctx context.Context) ([]*core.GroupBinding, error) {
+	return b.getGroupBindings(ctx, "SELECT group_name, policy_id FROM group_bindings ORDER BY rowid")
+}
+
+func (b *Backend) GetBindingsByGroup(ctx context.Context, groupName string) ([]*core.GroupBinding, error) {
+	return b.getGroupBindings(ctx,
+		"SELECT group_name, policy_id FROM group_bindings WHERE group_name = ? ORDER BY rowid", groupName)
+}
+
+func (b *Backend) CreateGroupBinding(ctx context.Context, binding *core.GroupBinding) error {
+	if binding.GroupName == "" || binding.PolicyID == "" {
+		return core.ErrBindingInvalid
+	}
+
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		if err := groupExists(ctx, tx, binding.GroupName); err != nil {
+			return err
+		}
+
+		found, err := exists(ctx, tx, "SELECT 1 FROM policies WHERE id = ?", binding.PolicyID)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			return core.ErrPolicyNotFound
+		}
+
+		found, err = exists(ctx, tx, "SELECT 1 FROM group_bindings WHERE group_name = ? AND policy_id = ?",
+			binding.GroupName, binding.PolicyID)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			return core.ErrBindingAlreadyExists
+		}
+
+		return insertGroupBinding(ctx, tx, binding)
+	})
+}
+
+func (b *Backend) DeleteGroupBinding(ctx context.Context, binding *core.GroupBinding) error {
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		return execAffecting(ctx, tx, core.ErrBindingNotFound,
+			"DELETE FROM group_bindings WHERE group_name = ? AND policy_id = ?", binding.GroupName, binding.PolicyID,
+		)
+	})
+}
+
+func (b *Backend) GetRoles(ctx context.Context) ([]string, error) {
+	return b.getNames(ctx, "SELECT name FROM roles ORDER BY name")
+}
+
+func (b *Backend) GetRoleByName(ctx context.Context, name string) (*core.Role, error) {
+	role := &core.Role{}
+
+	var conditions, limits []byte
+
+	err := b.db.QueryRowContext(ctx, "SELECT name, web_identity_conditions, limits FROM roles WHERE name = ?", name).
+		Scan(&role.Name, &conditions, &limits)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, core.ErrRoleNotFound
+		}
+
+		return nil, err
+	}
+
+	role.WebIdentityConditions, err = json.Unmarshal[[]map[string]string](conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	role.Limits, err = json.Unmarshal[*core.RateLimits](limits)
+	if err != nil {
+		return nil, err
+	}
+
+	role.PolicyIDs, err = b.getNames(ctx,
+		"SELECT policy_id FROM role_policies WHERE role_name = ? ORDER BY rowid", name)
+	if err != nil {
+		return nil, err
+	}
+
+	role.TrustedUsers, err = b.getNames(ctx,
+		"SELECT user_name FROM role_trusted_users WHERE role_name = ? ORDER BY rowid", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (b *Backend) CreateRole(ctx context.Context, role *core.Role) error {
+	if err := role.Validate(); err != nil {
+		return err
+	}
+
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		found, err := exists(ctx, tx, "SELECT 1 FROM roles WHERE name = ?", role.Name)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			return core.ErrRoleAlreadyExists
+		}
+
+		for _, policyID := range role.PolicyIDs {
+			found, err := exists(ctx, tx, "SELECT 1 FROM policies WHERE id = ?", policyID)
+			if err != nil {
+				return err
+			}
+
+			if !found {
+				return fmt.Errorf("%w: %s", core.ErrPolicyNotFound, policyID)
+			}
+		}
+
+		for _, userName := range role.TrustedUsers {
+			if err := userExists(ctx, tx, userName); err != nil {
+				return fmt.Errorf("%w: %s", err, userName)
+			}
+		}
+
+		return insertRole(ctx, tx, &core.Role{
+			Name:                  role.Name,
+			PolicyIDs:             lo.Uniq(role.PolicyIDs),
+			TrustedUsers:          lo.Uniq(role.TrustedUsers),
+			WebIdentityConditions: role.WebIdentityConditions,
+			Limits:                role.Limits,
+		})
+	})
+}
+
+func (b *Backend) SetRoleLimits(ctx context.Context, name string, limits *core.RateLimits) error {
+	return b.setLimits(ctx, core.ErrRoleNotFound, "UPDATE roles SET limits = ? WHERE name = ?", name, limits)
+}
+
+func (b *Backend) DeleteRole(ctx context.Context, name string) error {
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		err := execAffecting(ctx, tx, core.ErrRoleNotFound, "DELETE FROM roles WHERE name = ?", name)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM role_policies WHERE role_name = ?", name)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM role_trusted_users WHERE role_name = ?", name)
+
+		return err
+	})
+}
+
+func (b *Backend) ApplyChanges(ctx context.Context, changes []core.ManagementChange) error {
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		for _, change := range changes {
+			var err error
+
+			switch change.Kind {
+			case core.ManagementResourceUser:
+				err = b.applyUserChange(ctx, tx, change)
+			case core.ManagementResourcePolicy:
+				err = applyPolicyChange(ctx, tx, change)
+			case core.ManagementResourceBinding:
+				err = b.applyBindingChange(ctx, tx, change)
+			default:
+				err = fmt.Errorf("%w: unknown resource kind %q", core.ErrManagementConfigInvalid, change.Kind)
+			}
+
+			if err != nil {
+				return fmt.Errorf("failed to %s %s %s: %w", change.Operation, change.Kind, change.Name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (b *Backend) applyUserChange(ctx context.Context, tx *sql.Tx, change core.ManagementChange) error {
+	if change.Name == b.adminUser.Name {
+		return core.ErrUserNameReserved
+	}
+
+	found, err := exists(ctx, tx, "SELECT 1 FROM users WHERE name = ?", change.Name)
+	if err != nil {
+		return err
+	}
+
+	switch change.Operation {
+	case core.ManagementChangeCreate:
+		if found {
+			return core.ErrUserAlreadyExists
+		}
+
+		return insertUser(ctx, tx, change.User)
+	case core.ManagementChangeUpdate:
+		if !found {
+			return core.ErrUserNotFound
+		}
+
+		limits, err := json.Marshal(change.User.Limits)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE users SET limits = ? WHERE name = ?", limits, change.Name)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM access_keys WHERE user_name = ?", change.Name)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range change.User.AccessKeys {
+			if err := insertAccessKey(ctx, tx, change.Name, key); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case core.ManagementChangeDelete:
+		if !found {
+			return core.ErrUserNotFound
+		}
+
+		for _, query := range []string{
+			"DELETE FROM users WHERE name = ?",
+			"DELETE FROM access_keys WHERE user_name = ?",
+			"DELETE FROM group_members WHERE user_name = ?",
+			"DELETE FROM role_trusted_users WHERE user_name = ?",
+		} {
+			if _, err := tx.ExecContext(ctx, query, change.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func applyPolicyChange(ctx context.Context, tx *sql.Tx, change core.ManagementChange) error {
+	switch change.Operation {
+	case core.ManagementChangeCreate:
+		found, err := exists(ctx, tx, "SELECT 1 FROM policies WHERE id = ?", change.Name)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			return core.ErrPolicyAlreadyExists
+		}
+
+		return insertPolicy(ctx, tx, change.Policy)
+	case core.ManagementChangeUpdate:
+		document, err := json.Marshal(change.Policy)
+		if err != nil {
+			return err
+		}
+
+		return execAffecting(ctx, tx, core.ErrPolicyNotFound,
+			"UPDATE policies SET document = ? WHERE id = ?", document, change.Name,
+		)
+	case core.ManagementChangeDelete:
+		err := execAffecting(ctx, tx, core.ErrPolicyNotFound, "DELETE FROM policies WHERE id = ?", change.Name)
+		if err != nil {
+			return err
+		}
+
+		// Groups and roles are not managed by apply, they lose the deleted policy.
+		for _, query := range []string{
+			"DELETE FROM group_bindings WHERE policy_id = ?",
+			"DELETE FROM role_policies WHERE policy_id = ?",
+		} {
+			if _, err := tx.ExecContext(ctx, query, change.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (b *Backend) applyBindingChange(ctx context.Context, tx *sql.Tx, change core.ManagementChange) error {
+	binding := change.Binding
+
+	switch change.Operation {
+	case core.ManagementChangeCreate:
+		if binding.UserName != b.adminUser.Name {
+			if err := userExists(ctx, tx, binding.UserName); err != nil {
+				return err
+			}
+		}
+
+		found, err := exists(ctx, tx, "SELECT 1 FROM policies WHERE id = ?", binding.PolicyID)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			return core.ErrPolicyNotFound
+		}
+
+		found, err = exists(ctx, tx, "SELECT 1 FROM bindings WHERE user_name = ? AND policy_id = ?",
+			binding.UserName, binding.PolicyID)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			return core.ErrBindingAlreadyExists
+		}
+
+		return insertBinding(ctx, tx, binding)
+	case core.ManagementChangeDelete:
+		return execAffecting(ctx, tx, core.ErrBindingNotFound,
+			"DELETE FROM bindings WHERE user_name = ? AND policy_id = ?", binding.UserName, binding.PolicyID,
+		)
+	default:
+		return fmt.Errorf("%w: bindings cannot be updated", core.ErrManagementConfigInvalid)
+	}
+}
+
+// inTx runs fn in a write transaction. Transactions take the database write lock upfront (see dsn),
+// so the existence checks fn performs hold until it commits, even across instances sharing the file.
+func (b *Backend) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+
+	return tx.Commit()
+}
+
+// setLimits stores the limits with query, which takes them and the name of the user, group or role.
+func (b *Backend) setLimits(ctx context.Context, notFoundErr error, query string, name string,
+	limits *core.RateLimits,
+) error {
+	if err := lo.FromPtr(limits).Validate(); err != nil {
+		return err
+	}
+
+	document, err := json.Marshal(limits)
+	if err != nil {
+		return err
+	}
+
+	return b.inTx(ctx, func(tx *sql.Tx) error {
+		return execAffecting(ctx, tx, notFoundErr, query, document, name)
+	})
+}
+
+func (b *Backend) getUser(ctx context.Context, name string) (*core.User, error) {
+	user := &core.User{}
+
+	var limits []byte
+
+	err := b.db.QueryRowContext(ctx, "SELECT name, limits FROM users WHERE name = ?", name).
+		Scan(&user.Name, &limits)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, core.ErrUserNotFound
+		}
+
+		return nil, err
+	}
+
+	user.Limits, err = json.Unmarshal[*core.RateLimits](limits)
+	if err != nil {
+		return nil, err
+	}
+
+	user.AccessKeys, err = b.getAccessKeys(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (b *Backend) getUserByAccessKeyID(ctx context.Context, accessKeyID string) (*core.User, error) {
+	if _, ok := b.adminUser.AccessKey(accessKeyID); ok {
+		return b.adminUser, nil
+	}
+
+	var userName string
+
+	err := b.db.QueryRowContext(ctx,
+		"SELECT user_name FROM access_keys WHERE access_key_id = ?", accessKeyID).Scan(&userName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, core.ErrUserNotFound
+		}
+
+		return nil, err
+	}
+
+	return b.getUser(ctx, userName)
+}
+
+func (b *Backend) getAccessKeys(ctx context.Context, userName string) ([]core.AccessKey, error) {
+	rows, err := b.db.QueryContext(ctx, `
+		SELECT access_key_id, secret_access_key, status, created_at, expires_at, last_used_at
+		FROM access_keys WHERE user_name = ? ORDER BY rowid`, userName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []core.AccessKey{}
+
+	for rows.Next() {
+		var (
+			key        core.AccessKey
+			expiresAt  sql.NullTime
+			lastUsedAt sql.NullTime
+		)
+
+		err := rows.Scan(&key.AccessKeyID, &key.SecretAccessKey, &key.Status, &key.CreatedAt, &expiresAt, &lastUsedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if expiresAt.Valid {
+			key.ExpiresAt = &expiresAt.Time
+		}
+
+		if lastUsedAt.Valid {
+			key.LastUsedAt = &lastUsedAt.Time
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (b *Backend) getGroupBindings(ctx context.Context, query string, args ...any) ([]*core.GroupBinding, error) {
+	rows, err := b.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bindings []*core.GroupBinding
+
+	for rows.Next() {
+		binding := &core.GroupBinding{}
+		if err := rows.Scan(&binding.GroupName, &binding.PolicyID); err != nil {
+			return nil, err
+		}
+
+		bindings = append(bindings, binding)
+	}
+
+	return bindings, rows.Err()
+}
+
+// getNames returns the single text column selected by query.
+func (b *Backend) getNames(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := b.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+func (b *Backend) getBindings(ctx context.Context, query string, args ...any) ([]*core.PolicyBinding, error) {
+	rows, err := b.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bindings []*core.PolicyBinding
+
+	for rows.Next() {
+		binding := &core.PolicyBinding{}
+		if err := rows.Scan(&binding.UserName, &binding.PolicyID); err != nil {
+			return nil, err
+		}
+
+		bindings = append(bindings, binding)
+	}
+
+	return bindings, rows.Err()
+}
+
+func insertUser(ctx context.Context, tx *sql.Tx, user *core.User) error {
+	limits, err := json.Marshal(user.Limits)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO users (name, limits) VALUES (?, ?)", user.Name, limits)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range user.AccessKeys {
+		if err := insertAccessKey(ctx, tx, user.Name, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func insertAccessKey(ctx context.Context, tx *sql.Tx, userName string, key core.AccessKey) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO access_keys
+			(access_key_id, user_name, secret_access_key, status, created_at, expires_at, last_used_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		key.AccessKeyID, userName, key.SecretAccessKey, string(key.Status), key.CreatedAt.UTC(),
+		nullTime(key.ExpiresAt), nullTime(key.LastUsedAt),
+	)
+
+	return err
+}
+
+func insertGroup(ctx context.Context, tx *sql.Tx, group *core.Group) error {
+	limits, err := json.Marshal(group.Limits)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO groups (name, limits) VALUES (?, ?)", group.Name, limits)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range group.Members {
+		_, err := tx.ExecContext(ctx, "INSERT INTO group_members (group_name, user_name) VALUES (?, ?)",
+			group.Name, member)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func insertRole(ctx context.Context, tx *sql.Tx, role *core.Role) error {
+	conditions, err := json.Marshal(role.WebIdentityConditions)
+	if err != nil {
+		return err
+	}
+
+	limits, err := json.Marshal(role.Limits)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO roles (name, web_identity_conditions, limits) VALUES (?, ?, ?)",
+		role.Name, conditions, limits)
+	if err != nil {
+		return err
+	}
+
+	for _, policyID := range role.PolicyIDs {
+		_, err := tx.ExecContext(ctx, "INSERT INTO role_policies (role_name, policy_id) VALUES (?, ?)",
+			role.Name, policyID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, userName := range role.TrustedUsers {
+		_, err := tx.ExecContext(ctx, "INSERT INTO role_trusted_users (role_name, user_name) VALUES (?, ?)",
+			role.Name, userName)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func insertGroupBinding(ctx context.Context, tx *sql.Tx, binding *core.GroupBinding) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO group_bindings (group_name, policy_id) VALUES (?, ?)",
+		binding.GroupName, binding.PolicyID)
+
+	return err
+}
+
+func insertPolicy(ctx context.Context, tx *sql.Tx, policy *iampol.IAMPolicy) error {
+	document, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO policies (id, document) VALUES (?, ?)", policy.ID, document)
+
+	return err
+}
+
+func insertBinding(ctx context.Context, tx *sql.Tx, binding *core.PolicyBinding) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO bindings (user_name, policy_id) VALUES (?, ?)",
+		binding.UserName, binding.PolicyID)
+
+	return err
+}
+
+func groupExists(ctx context.Context, tx *sql.Tx, groupName string) error {
+	found, err := exists(ctx, tx, "SELECT 1 FROM groups WHERE name = ?", groupName)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return core.ErrGroupNotFound
+	}
+
+	return nil
+}
+
+func userExists(ctx context.Context, tx *sql.Tx, userName string) error {
+	found, err := exists(ctx, tx, "SELECT 1 FROM users WHERE name = ?", userName)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return core.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func exists(ctx context.Context, tx *sql.Tx, query string, args ...any) (bool, error) {
+	var one int
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&one)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// execAffecting executes a statement and returns notFoundErr if it did not touch any rows.
+func execAffecting(ctx context.Context, tx *sql.Tx, notFoundErr error, query string, args ...any) error {
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return notFoundErr
+	}
+
+	return nil
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// dsn enables WAL so readers are not blocked by writers, waits on a busy database instead of failing,
+// and makes every transaction take the write lock when it begins.
+func dsn(path string) string {
+	query := url.Values{}
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_txlock", "immediate")
+
+	return "file:" + path + "?" + query.Encode()
+}
@@ -0,0 +1,779 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/mock"
+	"github.com/zhulik/d3/internal/backends/management/sqlite"
+	"github.com/zhulik/d3/internal/backends/management/yaml"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/internal/core/mocks"
+	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/s3actions"
+	yamlPkg "github.com/zhulik/d3/pkg/yaml"
+)
+
+var _ = Describe("SQLite Backend", func() {
+	var (
+		tempDir string
+		cfg     *core.Config
+		backend *sqlite.Backend
+	)
+
+	uninitializedBackend := func() *sqlite.Backend {
+		mockLocker := mocks.NewMockLocker(GinkgoT())
+
+		mockLocker.EXPECT().Lock(mock.Anything, mock.Anything).Maybe().Return(
+			func(ctx context.Context, _ string) (context.Context, context.CancelFunc, error) {
+				return ctx, func() {}, nil
+			},
+		)
+
+		return &sqlite.Backend{
+			Config: cfg,
+			Locker: mockLocker,
+			Logger: slog.New(slog.DiscardHandler),
+		}
+	}
+
+	newBackend := func(ctx context.Context) *sqlite.Backend {
+		b := uninitializedBackend()
+		lo.Must0(b.Init(ctx))
+
+		DeferCleanup(func(ctx context.Context) {
+			lo.Must0(b.Shutdown(ctx))
+		})
+
+		return b
+	}
+
+	policy := func(id string) *iampol.IAMPolicy {
+		return &iampol.IAMPolicy{
+			ID: id,
+			Statement: []iampol.Statement{{
+				Effect:   iampol.EffectAllow,
+				Action:   []s3actions.Action{s3actions.GetObject},
+				Resource: []string{"arn:aws:s3:::bucket/*"},
+			}},
+		}
+	}
+
+	// matchKey compares keys by value: timestamps read back from the database are equal instants,
+	// but not necessarily identical time.Time values.
+	matchKey := func(key core.AccessKey) OmegaMatcher {
+		return SatisfyAll(
+			HaveField("AccessKeyID", key.AccessKeyID),
+			HaveField("SecretAccessKey", key.SecretAccessKey),
+			HaveField("Status", key.Status),
+			HaveField("CreatedAt", BeTemporally("==", key.CreatedAt)),
+		)
+	}
+
+	BeforeEach(func() {
+		tempDir = lo.Must(os.MkdirTemp("", "sqlite-backend-test-"))
+		DeferCleanup(func() {
+			lo.Must0(os.RemoveAll(tempDir))
+		})
+
+		cfg = &core.Config{
+			ManagementBackendSQLitePath: filepath.Join(tempDir, "data", "management.db"),
+			ManagementBackendYAMLPath:   filepath.Join(tempDir, "data", "management.yaml"),
+			Environment:                 "test",
+		}
+	})
+
+	Describe("Init", func() {
+		When("the database does not exist", func() {
+			It("creates it with the current schema", func(ctx context.Context) {
+				backend = newBackend(ctx)
+
+				Expect(cfg.ManagementBackendSQLitePath).To(BeAnExistingFile())
+				Expect(lo.Must(backend.GetUsers(ctx))).To(Equal([]string{"admin"}))
+				Expect(lo.Must(backend.GetPolicies(ctx))).To(BeEmpty())
+				Expect(lo.Must(backend.GetBindings(ctx))).To(BeEmpty())
+			})
+		})
+
+		When("a YAML management config exists next to a new database", func() {
+			BeforeEach(func() {
+				lo.Must0(os.MkdirAll(filepath.Dir(cfg.ManagementBackendYAMLPath), 0755))
+				lo.Must0(yamlPkg.MarshalToFile(yaml.ManagementConfig{
+					Version: yaml.ConfigVersion,
+					Users: map[string]*core.User{
+						"ci": {AccessKeys: []core.AccessKey{{
+							AccessKeyID:     "AKIACI",
+							SecretAccessKey: "ci-secret",
+							Status:          core.AccessKeyStatusActive,
+							CreatedAt:       time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+						}}},
+					},
+					Policies: map[string]*iampol.IAMPolicy{"readonly": policy("readonly")},
+					Bindings: []*core.PolicyBinding{{UserName: "ci", PolicyID: "readonly"}},
+					Groups:   map[string]*core.Group{"builders": {Members: []string{"ci"}}},
+					GroupBindings: []*core.GroupBinding{
+						{GroupName: "builders", PolicyID: "readonly"},
+					},
+					Roles: map[string]*core.Role{
+						"deployer": {PolicyIDs: []string{"readonly"}, TrustedUsers: []string{"ci"}},
+					},
+				}, cfg.ManagementBackendYAMLPath))
+			})
+
+			It("imports users, groups, roles, policies and bindings", func(ctx context.Context) {
+				backend = newBackend(ctx)
+
+				user := lo.Must(backend.GetUserByAccessKeyID(ctx, "AKIACI"))
+				Expect(user.Name).To(Equal("ci"))
+				Expect(user.AccessKeys).To(HaveLen(1))
+				Expect(user.AccessKeys[0].SecretAccessKey).To(Equal("ci-secret"))
+				Expect(user.AccessKeys[0].CreatedAt).To(BeTemporally("==", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+				Expect(lo.Must(backend.GetPolicyByID(ctx, "readonly"))).To(Equal(policy("readonly")))
+				Expect(lo.Must(backend.GetBindingsByUser(ctx, "ci"))).To(Equal([]*core.PolicyBinding{
+					{UserName: "ci", PolicyID: "readonly"},
+				}))
+
+				Expect(lo.Must(backend.GetGroupsByUser(ctx, "ci"))).To(Equal([]string{"builders"}))
+				Expect(lo.Must(backend.GetBindingsByGroup(ctx, "builders"))).To(Equal([]*core.GroupBinding{
+					{GroupName: "builders", PolicyID: "readonly"},
+				}))
+
+				Expect(lo.Must(backend.GetRoleByName(ctx, "deployer"))).To(Equal(&core.Role{
+					Name: "deployer", PolicyIDs: []string{"readonly"}, TrustedUsers: []string{"ci"},
+				}))
+			})
+
+			It("imports only once", func(ctx context.Context) {
+				backend = newBackend(ctx)
+				lo.Must0(backend.DeleteUser(ctx, "ci"))
+
+				backend = newBackend(ctx)
+
+				_, err := backend.GetUserByName(ctx, "ci")
+				Expect(err).To(MatchError(core.ErrUserNotFound))
+			})
+		})
+
+		When("a version 1 YAML management config exists next to a new database", func() {
+			It("imports every user key as an active access key", func(ctx context.Context) {
+				lo.Must0(os.MkdirAll(filepath.Dir(cfg.ManagementBackendYAMLPath), 0755))
+				lo.Must0(os.WriteFile(cfg.ManagementBackendYAMLPath, []byte(`version: 1
+users:
+  ci:
+    name: ci
+    access_key_id: AKIACI
+    secret_access_key: ci-secret
+`), 0600))
+
+				backend = newBackend(ctx)
+
+				user := lo.Must(backend.GetUserByAccessKeyID(ctx, "AKIACI"))
+				Expect(user.Name).To(Equal("ci"))
+				Expect(user.AccessKeys[0].SecretAccessKey).To(Equal("ci-secret"))
+			})
+		})
+
+		When("the database has the version 1 schema", func() {
+			It("moves every user key into the access keys table", func(ctx context.Context) {
+				lo.Must0(os.MkdirAll(filepath.Dir(cfg.ManagementBackendSQLitePath), 0755))
+
+				db := lo.Must(sql.Open("sqlite", cfg.ManagementBackendSQLitePath))
+				lo.Must(db.ExecContext(ctx, `
+					CREATE TABLE schema_migrations (version INTEGER NOT NULL PRIMARY KEY, applied_at TIMESTAMP NOT NULL);
+					INSERT INTO schema_migrations (version, applied_at) VALUES (1, CURRENT_TIMESTAMP);
+					CREATE TABLE users (
+						name TEXT NOT NULL PRIMARY KEY,
+						access_key_id TEXT NOT NULL UNIQUE,
+						secret_access_key TEXT NOT NULL
+					);
+					CREATE TABLE policies (id TEXT NOT NULL PRIMARY KEY, document TEXT NOT NULL);
+					CREATE TABLE bindings (user_name TEXT NOT NULL, policy_id TEXT NOT NULL, PRIMARY KEY (user_name, policy_id));
+					INSERT INTO users (name, access_key_id, secret_access_key) VALUES ('ci', 'AKIACI', 'ci-secret');
+				`))
+				lo.Must0(db.Close())
+
+				backend = newBackend(ctx)
+
+				user := lo.Must(backend.GetUserByAccessKeyID(ctx, "AKIACI"))
+				Expect(user.Name).To(Equal("ci"))
+				Expect(user.AccessKeys).To(HaveLen(1))
+				Expect(user.AccessKeys[0].SecretAccessKey).To(Equal("ci-secret"))
+				Expect(user.AccessKeys[0].Status).To(Equal(core.AccessKeyStatusActive))
+				Expect(user.AccessKeys[0].CreatedAt).NotTo(BeZero())
+			})
+		})
+
+		When("the YAML management config has a different version", func() {
+			It("fails and leaves the database uninitialized", func(ctx context.Context) {
+				lo.Must0(os.MkdirAll(filepath.Dir(cfg.ManagementBackendYAMLPath), 0755))
+				lo.Must0(os.WriteFile(cfg.ManagementBackendYAMLPath, []byte("version: 999\n"), 0600))
+
+				b := uninitializedBackend()
+				Expect(b.Init(ctx)).To(MatchError(core.ErrConfigVersionMismatch))
+				Expect(b.Shutdown(ctx)).To(Succeed())
+
+				db := lo.Must(sql.Open("sqlite", cfg.ManagementBackendSQLitePath))
+				defer db.Close()
+
+				var tables int
+				lo.Must0(db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables))
+				Expect(tables).To(BeZero())
+			})
+		})
+
+		When("the database schema is newer than supported", func() {
+			It("returns a version mismatch error", func(ctx context.Context) {
+				backend = newBackend(ctx)
+
+				db := lo.Must(sql.Open("sqlite", cfg.ManagementBackendSQLitePath))
+				lo.Must(db.ExecContext(ctx, "INSERT INTO schema_migrations (version, applied_at) VALUES (999, 0)"))
+				lo.Must0(db.Close())
+
+				b := uninitializedBackend()
+				Expect(b.Init(ctx)).To(MatchError(core.ErrConfigVersionMismatch))
+				Expect(b.Shutdown(ctx)).To(Succeed())
+			})
+		})
+	})
+
+	Describe("users", func() {
+		BeforeEach(func(ctx context.Context) {
+			backend = newBackend(ctx)
+		})
+
+		It("creates, finds and deletes a user", func(ctx context.Context) {
+			created := lo.Must(backend.CreateUser(ctx, "alice"))
+			Expect(created.Name).To(Equal("alice"))
+			Expect(created.AccessKeys).To(HaveLen(1))
+
+			accessKeyID := created.AccessKeys[0].AccessKeyID
+
+			Expect(lo.Must(backend.GetUsers(ctx))).To(Equal([]string{"alice", "admin"}))
+			Expect(lo.Must(backend.GetUserByName(ctx, "alice")).AccessKeys).To(ConsistOf(matchKey(created.AccessKeys[0])))
+			Expect(lo.Must(backend.GetUserByAccessKeyID(ctx, accessKeyID)).Name).To(Equal("alice"))
+
+			Expect(backend.DeleteUser(ctx, "alice")).To(Succeed())
+
+			_, err := backend.GetUserByName(ctx, "alice")
+			Expect(err).To(MatchError(core.ErrUserNotFound))
+
+			_, err = backend.GetUserByAccessKeyID(ctx, accessKeyID)
+			Expect(err).To(MatchError(core.ErrUserNotFound))
+		})
+
+		It("resolves the admin user by name and access key", func(ctx context.Context) {
+			admin := lo.Must(backend.GetUserByName(ctx, "admin"))
+
+			Expect(lo.Must(backend.GetUserByAccessKeyID(ctx, admin.AccessKeys[0].AccessKeyID))).To(Equal(admin))
+		})
+
+		DescribeTable("rejects invalid changes",
+			func(ctx context.Context, op func(ctx context.Context, b *sqlite.Backend) error, expected error) {
+				lo.Must(backend.CreateUser(ctx, "existing"))
+
+				Expect(op(ctx, backend)).To(MatchError(expected))
+			},
+			Entry("empty name", func(ctx context.Context, b *sqlite.Backend) error {
+				_, err := b.CreateUser(ctx, "")
+
+				return err
+			}, core.ErrUserInvalid),
+			Entry("reserved name", func(ctx context.Context, b *sqlite.Backend) error {
+				_, err := b.CreateUser(ctx, "admin")
+
+				return err
+			}, core.ErrUserNameReserved),
+			Entry("duplicate name", func(ctx context.Context, b *sqlite.Backend) error {
+				_, err := b.CreateUser(ctx, "existing")
+
+				return err
+			}, core.ErrUserAlreadyExists),
+			Entry("delete of a missing user", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.DeleteUser(ctx, "missing")
+			}, core.ErrUserNotFound),
+		)
+	})
+
+	Describe("access keys", func() {
+		var accessKeyID string
+
+		BeforeEach(func(ctx context.Context) {
+			backend = newBackend(ctx)
+
+			accessKeyID = lo.Must(backend.CreateUser(ctx, "alice")).AccessKeys[0].AccessKeyID
+		})
+
+		It("creates, deactivates, touches and deletes keys", func(ctx context.Context) {
+			expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+			key := lo.Must(backend.CreateAccessKey(ctx, "alice", &expiresAt))
+			Expect(key.Status).To(Equal(core.AccessKeyStatusActive))
+
+			keys := lo.Must(backend.GetUserByName(ctx, "alice")).AccessKeys
+			Expect(keys).To(HaveLen(2))
+			Expect(keys[1]).To(matchKey(*key))
+
+			Expect(backend.SetAccessKeyStatus(ctx, "alice", accessKeyID, core.AccessKeyStatusInactive)).To(Succeed())
+
+			_, err := backend.GetUserByAccessKeyID(ctx, accessKeyID)
+			Expect(err).To(MatchError(core.ErrAccessKeyInactive))
+			Expect(lo.Must(backend.GetUserByAccessKeyID(ctx, key.AccessKeyID)).Name).To(Equal("alice"))
+
+			usedAt := time.Now().UTC().Truncate(time.Second)
+			Expect(backend.TouchAccessKey(ctx, key.AccessKeyID, usedAt)).To(Succeed())
+
+			keys = lo.Must(backend.GetUserByName(ctx, "alice")).AccessKeys
+			Expect(keys[1].LastUsedAt).To(HaveValue(BeTemporally("==", usedAt)))
+
+			Expect(backend.DeleteAccessKey(ctx, "alice", accessKeyID)).To(Succeed())
+			Expect(lo.Must(backend.GetUserByName(ctx, "alice")).AccessKeys).To(ConsistOf(HaveField("AccessKeyID", key.AccessKeyID)))
+		})
+
+		It("replaces all the keys with a new one", func(ctx context.Context) {
+			lo.Must(backend.CreateAccessKey(ctx, "alice", nil))
+
+			key := lo.Must(backend.ReplaceAccessKeys(ctx, "alice"))
+			Expect(lo.Must(backend.GetUserByName(ctx, "alice")).AccessKeys).To(ConsistOf(matchKey(*key)))
+
+			_, err := backend.GetUserByAccessKeyID(ctx, accessKeyID)
+			Expect(err).To(MatchError(core.ErrUserNotFound))
+		})
+
+		It("rejects expired keys", func(ctx context.Context) {
+			key := lo.Must(backend.CreateAccessKey(ctx, "alice", lo.ToPtr(time.Now().Add(100*time.Millisecond))))
+
+			Eventually(func() error {
+				_, err := backend.GetUserByAccessKeyID(ctx, key.AccessKeyID)
+
+				return err
+			}).Should(MatchError(core.ErrAccessKeyExpired))
+		})
+
+		It("ignores touches of the admin key", func(ctx context.Context) {
+			admin := lo.Must(backend.GetUserByName(ctx, "admin"))
+
+			Expect(backend.TouchAccessKey(ctx, admin.AccessKeys[0].AccessKeyID, time.Now())).To(Succeed())
+		})
+
+		DescribeTable("rejects invalid changes",
+			func(ctx context.Context, op func(ctx context.Context, b *sqlite.Backend, accessKeyID string) error, expected error) {
+				lo.Must(backend.CreateUser(ctx, "bob"))
+
+				Expect(op(ctx, backend, accessKeyID)).To(MatchError(expected))
+			},
+			Entry("key for a missing user", func(ctx context.Context, b *sqlite.Backend, _ string) error {
+				_, err := b.CreateAccessKey(ctx, "missing", nil)
+
+				return err
+			}, core.ErrUserNotFound),
+			Entry("key for admin", func(ctx context.Context, b *sqlite.Backend, _ string) error {
+				_, err := b.CreateAccessKey(ctx, "admin", nil)
+
+				return err
+			}, core.ErrUserNameReserved),
+			Entry("key expiring in the past", func(ctx context.Context, b *sqlite.Backend, _ string) error {
+				_, err := b.CreateAccessKey(ctx, "alice", lo.ToPtr(time.Now().Add(-time.Minute)))
+
+				return err
+			}, core.ErrAccessKeyInvalid),
+			Entry("unknown status", func(ctx context.Context, b *sqlite.Backend, accessKeyID string) error {
+				return b.SetAccessKeyStatus(ctx, "alice", accessKeyID, "Paused")
+			}, core.ErrAccessKeyInvalid),
+			Entry("status of another user's key", func(ctx context.Context, b *sqlite.Backend, accessKeyID string) error {
+				return b.SetAccessKeyStatus(ctx, "bob", accessKeyID, core.AccessKeyStatusInactive)
+			}, core.ErrAccessKeyNotFound),
+			Entry("delete of another user's key", func(ctx context.Context, b *sqlite.Backend, accessKeyID string) error {
+				return b.DeleteAccessKey(ctx, "bob", accessKeyID)
+			}, core.ErrAccessKeyNotFound),
+			Entry("delete of a key of a missing user", func(ctx context.Context, b *sqlite.Backend, accessKeyID string) error {
+				return b.DeleteAccessKey(ctx, "missing", accessKeyID)
+			}, core.ErrUserNotFound),
+			Entry("replacement of the keys of a missing user", func(ctx context.Context, b *sqlite.Backend, _ string) error {
+				_, err := b.ReplaceAccessKeys(ctx, "missing")
+
+				return err
+			}, core.ErrUserNotFound),
+			Entry("touch of a missing key", func(ctx context.Context, b *sqlite.Backend, _ string) error {
+				return b.TouchAccessKey(ctx, "AKIAMISSING", time.Now())
+			}, core.ErrAccessKeyNotFound),
+		)
+	})
+
+	Describe("policies", func() {
+		BeforeEach(func(ctx context.Context) {
+			backend = newBackend(ctx)
+		})
+
+		It("creates, updates and deletes a policy", func(ctx context.Context) {
+			Expect(backend.CreatePolicy(ctx, policy("readonly"))).To(Succeed())
+			Expect(lo.Must(backend.GetPolicies(ctx))).To(Equal([]string{"readonly"}))
+
+			updated := policy("readonly")
+			updated.Statement[0].Action = []s3actions.Action{s3actions.GetObject, s3actions.ListObjectsV2}
+			Expect(backend.UpdatePolicy(ctx, updated)).To(Succeed())
+			Expect(lo.Must(backend.GetPolicyByID(ctx, "readonly"))).To(Equal(updated))
+
+			Expect(backend.DeletePolicy(ctx, "readonly")).To(Succeed())
+
+			_, err := backend.GetPolicyByID(ctx, "readonly")
+			Expect(err).To(MatchError(core.ErrPolicyNotFound))
+		})
+
+		It("rejects duplicates and missing policies", func(ctx context.Context) {
+			lo.Must0(backend.CreatePolicy(ctx, policy("readonly")))
+
+			Expect(backend.CreatePolicy(ctx, policy("readonly"))).To(MatchError(core.ErrPolicyAlreadyExists))
+			Expect(backend.UpdatePolicy(ctx, policy("missing"))).To(MatchError(core.ErrPolicyNotFound))
+			Expect(backend.DeletePolicy(ctx, "missing")).To(MatchError(core.ErrPolicyNotFound))
+		})
+	})
+
+	Describe("bindings", func() {
+		BeforeEach(func(ctx context.Context) {
+			backend = newBackend(ctx)
+
+			lo.Must(backend.CreateUser(ctx, "alice"))
+			lo.Must(backend.CreateUser(ctx, "bob"))
+			lo.Must0(backend.CreatePolicy(ctx, policy("readonly")))
+			lo.Must0(backend.CreatePolicy(ctx, policy("writer")))
+		})
+
+		It("indexes bindings by user and policy in creation order", func(ctx context.Context) {
+			bindings := []*core.PolicyBinding{
+				{UserName: "bob", PolicyID: "writer"},
+				{UserName: "alice", PolicyID: "readonly"},
+				{UserName: "bob", PolicyID: "readonly"},
+				{UserName: "admin", PolicyID: "writer"},
+			}
+			for _, binding := range bindings {
+				Expect(backend.CreateBinding(ctx, binding)).To(Succeed())
+			}
+
+			Expect(lo.Must(backend.GetBindings(ctx))).To(Equal(bindings))
+			Expect(lo.Must(backend.GetBindingsByUser(ctx, "bob"))).To(Equal([]*core.PolicyBinding{
+				{UserName: "bob", PolicyID: "writer"},
+				{UserName: "bob", PolicyID: "readonly"},
+			}))
+			Expect(lo.Must(backend.GetBindingsByPolicy(ctx, "readonly"))).To(Equal([]*core.PolicyBinding{
+				{UserName: "alice", PolicyID: "readonly"},
+				{UserName: "bob", PolicyID: "readonly"},
+			}))
+			Expect(lo.Must(backend.GetBindingsByUser(ctx, "nobody"))).To(BeNil())
+
+			Expect(backend.DeleteBinding(ctx, &core.PolicyBinding{UserName: "bob", PolicyID: "writer"})).To(Succeed())
+			Expect(lo.Must(backend.GetBindingsByPolicy(ctx, "writer"))).To(Equal([]*core.PolicyBinding{
+				{UserName: "admin", PolicyID: "writer"},
+			}))
+		})
+
+		DescribeTable("rejects invalid bindings",
+			func(ctx context.Context, binding *core.PolicyBinding, expected error) {
+				lo.Must0(backend.CreateBinding(ctx, &core.PolicyBinding{UserName: "alice", PolicyID: "readonly"}))
+
+				Expect(backend.CreateBinding(ctx, binding)).To(MatchError(expected))
+			},
+			Entry("empty user", &core.PolicyBinding{PolicyID: "readonly"}, core.ErrBindingInvalid),
+			Entry("missing user", &core.PolicyBinding{UserName: "nobody", PolicyID: "readonly"}, core.ErrUserNotFound),
+			Entry("missing policy", &core.PolicyBinding{UserName: "alice", PolicyID: "missing"}, core.ErrPolicyNotFound),
+			Entry("duplicate", &core.PolicyBinding{UserName: "alice", PolicyID: "readonly"}, core.ErrBindingAlreadyExists),
+		)
+
+		It("returns ErrBindingNotFound when deleting a missing binding", func(ctx context.Context) {
+			Expect(backend.DeleteBinding(ctx, &core.PolicyBinding{UserName: "alice", PolicyID: "writer"})).
+				To(MatchError(core.ErrBindingNotFound))
+		})
+	})
+
+	Describe("groups", func() {
+		BeforeEach(func(ctx context.Context) {
+			backend = newBackend(ctx)
+
+			lo.Must(backend.CreateUser(ctx, "alice"))
+			lo.Must(backend.CreateUser(ctx, "bob"))
+			lo.Must0(backend.CreatePolicy(ctx, policy("readonly")))
+			lo.Must0(backend.CreateGroup(ctx, "team"))
+		})
+
+		It("manages members and bindings", func(ctx context.Context) {
+			Expect(backend.AddGroupMember(ctx, "team", "bob")).To(Succeed())
+			Expect(backend.AddGroupMember(ctx, "team", "alice")).To(Succeed())
+			Expect(backend.CreateGroupBinding(ctx, &core.GroupBinding{GroupName: "team", PolicyID: "readonly"})).
+				To(Succeed())
+
+			Expect(lo.Must(backend.GetGroups(ctx))).To(Equal([]string{"team"}))
+			Expect(lo.Must(backend.GetGroupByName(ctx, "team"))).To(Equal(&core.Group{
+				Name: "team", Members: []string{"bob", "alice"},
+			}))
+			Expect(lo.Must(backend.GetGroupsByUser(ctx, "alice"))).To(Equal([]string{"team"}))
+			Expect(lo.Must(backend.GetGroupBindings(ctx))).To(Equal([]*core.GroupBinding{
+				{GroupName: "team", PolicyID: "readonly"},
+			}))
+
+			Expect(backend.RemoveGroupMember(ctx, "team", "bob")).To(Succeed())
+			Expect(lo.Must(backend.GetGroupsByUser(ctx, "bob"))).To(BeEmpty())
+
+			Expect(backend.DeleteGroupBinding(ctx, &core.GroupBinding{GroupName: "team", PolicyID: "readonly"})).
+				To(Succeed())
+			Expect(lo.Must(backend.GetBindingsByGroup(ctx, "team"))).To(BeEmpty())
+		})
+
+		It("deletes memberships and bindings with the group", func(ctx context.Context) {
+			lo.Must0(backend.AddGroupMember(ctx, "team", "alice"))
+			lo.Must0(backend.CreateGroupBinding(ctx, &core.GroupBinding{GroupName: "team", PolicyID: "readonly"}))
+
+			Expect(backend.DeleteGroup(ctx, "team")).To(Succeed())
+
+			_, err := backend.GetGroupByName(ctx, "team")
+			Expect(err).To(MatchError(core.ErrGroupNotFound))
+			Expect(lo.Must(backend.GetGroupsByUser(ctx, "alice"))).To(BeEmpty())
+			Expect(lo.Must(backend.GetGroupBindings(ctx))).To(BeEmpty())
+		})
+
+		It("drops the memberships of deleted users", func(ctx context.Context) {
+			lo.Must0(backend.AddGroupMember(ctx, "team", "alice"))
+
+			lo.Must0(backend.DeleteUser(ctx, "alice"))
+
+			Expect(lo.Must(backend.GetGroupByName(ctx, "team")).Members).To(BeEmpty())
+		})
+
+		It("stores the limits of users and groups", func(ctx context.Context) {
+			userLimits := &core.RateLimits{
+				RequestsPerSecond:     map[core.RequestClass]float64{core.RequestClassRead: 100},
+				MaxConcurrentRequests: 2,
+			}
+			groupLimits := &core.RateLimits{DownloadBytesPerSecond: 1 << 20}
+
+			Expect(backend.SetUserLimits(ctx, "alice", userLimits)).To(Succeed())
+			Expect(backend.SetGroupLimits(ctx, "team", groupLimits)).To(Succeed())
+
+			Expect(lo.Must(backend.GetUserByName(ctx, "alice")).Limits).To(Equal(userLimits))
+			Expect(lo.Must(backend.GetGroupByName(ctx, "team")).Limits).To(Equal(groupLimits))
+
+			Expect(backend.SetUserLimits(ctx, "alice", nil)).To(Succeed())
+			Expect(lo.Must(backend.GetUserByName(ctx, "alice")).Limits).To(BeNil())
+
+			Expect(backend.SetUserLimits(ctx, "nobody", userLimits)).To(MatchError(core.ErrUserNotFound))
+			Expect(backend.SetGroupLimits(ctx, "missing", groupLimits)).To(MatchError(core.ErrGroupNotFound))
+			Expect(backend.SetGroupLimits(ctx, "team", &core.RateLimits{
+				RequestsPerSecond: map[core.RequestClass]float64{"upload": 1},
+			})).To(MatchError(core.ErrRateLimitsInvalid))
+		})
+
+		DescribeTable("rejects invalid changes",
+			func(ctx context.Context, change func(context.Context, *sqlite.Backend) error, expected error) {
+				lo.Must0(backend.AddGroupMember(ctx, "team", "alice"))
+				lo.Must0(backend.CreateGroupBinding(ctx, &core.GroupBinding{GroupName: "team", PolicyID: "readonly"}))
+
+				Expect(change(ctx, backend)).To(MatchError(expected))
+			},
+			Entry("empty group name", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.CreateGroup(ctx, "")
+			}, core.ErrGroupInvalid),
+			Entry("duplicate group", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.CreateGroup(ctx, "team")
+			}, core.ErrGroupAlreadyExists),
+			Entry("missing group", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.DeleteGroup(ctx, "missing")
+			}, core.ErrGroupNotFound),
+			Entry("member of missing group", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.AddGroupMember(ctx, "missing", "alice")
+			}, core.ErrGroupNotFound),
+			Entry("missing member", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.AddGroupMember(ctx, "team", "nobody")
+			}, core.ErrUserNotFound),
+			Entry("duplicate member", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.AddGroupMember(ctx, "team", "alice")
+			}, core.ErrGroupMemberAlreadyExists),
+			Entry("removing a non-member", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.RemoveGroupMember(ctx, "team", "bob")
+			}, core.ErrGroupMemberNotFound),
+			Entry("binding a missing policy", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.CreateGroupBinding(ctx, &core.GroupBinding{GroupName: "team", PolicyID: "missing"})
+			}, core.ErrPolicyNotFound),
+			Entry("duplicate binding", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.CreateGroupBinding(ctx, &core.GroupBinding{GroupName: "team", PolicyID: "readonly"})
+			}, core.ErrBindingAlreadyExists),
+			Entry("missing binding", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.DeleteGroupBinding(ctx, &core.GroupBinding{GroupName: "team", PolicyID: "writer"})
+			}, core.ErrBindingNotFound),
+		)
+	})
+
+	Describe("roles", func() {
+		BeforeEach(func(ctx context.Context) {
+			backend = newBackend(ctx)
+
+			lo.Must(backend.CreateUser(ctx, "alice"))
+			lo.Must0(backend.CreatePolicy(ctx, policy("readonly")))
+			lo.Must0(backend.CreateRole(ctx, &core.Role{
+				Name: "reader", PolicyIDs: []string{"readonly"}, TrustedUsers: []string{"alice"},
+				WebIdentityConditions: []map[string]string{{"sub": "repo:org/*", "ref": "refs/heads/main"}},
+			}))
+		})
+
+		It("creates and deletes a role", func(ctx context.Context) {
+			Expect(lo.Must(backend.GetRoles(ctx))).To(Equal([]string{"reader"}))
+			Expect(lo.Must(backend.GetRoleByName(ctx, "reader"))).To(Equal(&core.Role{
+				Name: "reader", PolicyIDs: []string{"readonly"}, TrustedUsers: []string{"alice"},
+				WebIdentityConditions: []map[string]string{{"sub": "repo:org/*", "ref": "refs/heads/main"}},
+			}))
+
+			Expect(backend.DeleteRole(ctx, "reader")).To(Succeed())
+
+			_, err := backend.GetRoleByName(ctx, "reader")
+			Expect(err).To(MatchError(core.ErrRoleNotFound))
+		})
+
+		It("drops the trust of deleted users", func(ctx context.Context) {
+			lo.Must0(backend.DeleteUser(ctx, "alice"))
+
+			Expect(lo.Must(backend.GetRoleByName(ctx, "reader")).TrustedUsers).To(BeEmpty())
+		})
+
+		It("stores the limits of roles", func(ctx context.Context) {
+			limits := &core.RateLimits{RequestsPerSecond: map[core.RequestClass]float64{core.RequestClassWrite: 10}}
+
+			Expect(backend.SetRoleLimits(ctx, "reader", limits)).To(Succeed())
+			Expect(lo.Must(backend.GetRoleByName(ctx, "reader")).Limits).To(Equal(limits))
+
+			Expect(backend.SetRoleLimits(ctx, "reader", nil)).To(Succeed())
+			Expect(lo.Must(backend.GetRoleByName(ctx, "reader")).Limits).To(BeNil())
+
+			Expect(backend.SetRoleLimits(ctx, "missing", limits)).To(MatchError(core.ErrRoleNotFound))
+		})
+
+		DescribeTable("rejects invalid changes",
+			func(ctx context.Context, change func(context.Context, *sqlite.Backend) error, expected error) {
+				Expect(change(ctx, backend)).To(MatchError(expected))
+			},
+			Entry("empty role name", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.CreateRole(ctx, &core.Role{})
+			}, core.ErrRoleInvalid),
+			Entry("empty web identity condition", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.CreateRole(ctx, &core.Role{Name: "writer", WebIdentityConditions: []map[string]string{{}}})
+			}, core.ErrRoleInvalid),
+			Entry("duplicate role", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.CreateRole(ctx, &core.Role{Name: "reader"})
+			}, core.ErrRoleAlreadyExists),
+			Entry("missing policy", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.CreateRole(ctx, &core.Role{Name: "writer", PolicyIDs: []string{"missing"}})
+			}, core.ErrPolicyNotFound),
+			Entry("missing trusted user", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.CreateRole(ctx, &core.Role{Name: "writer", TrustedUsers: []string{"nobody"}})
+			}, core.ErrUserNotFound),
+			Entry("missing role", func(ctx context.Context, b *sqlite.Backend) error {
+				return b.DeleteRole(ctx, "missing")
+			}, core.ErrRoleNotFound),
+		)
+	})
+
+	Describe("apply changes", func() {
+		BeforeEach(func(ctx context.Context) {
+			backend = newBackend(ctx)
+
+			lo.Must(backend.CreateUser(ctx, "alice"))
+			lo.Must0(backend.CreatePolicy(ctx, policy("readonly")))
+			lo.Must0(backend.CreateBinding(ctx, &core.PolicyBinding{UserName: "alice", PolicyID: "readonly"}))
+		})
+
+		It("applies all changes in order", func(ctx context.Context) {
+			key := core.NewAccessKey(nil)
+			binding := &core.PolicyBinding{UserName: "alice", PolicyID: "readonly"}
+
+			Expect(backend.ApplyChanges(ctx, []core.ManagementChange{
+				{
+					Operation: core.ManagementChangeCreate, Kind: core.ManagementResourceUser, Name: "bob",
+					User: &core.User{Name: "bob", AccessKeys: []core.AccessKey{key}},
+				},
+				{
+					Operation: core.ManagementChangeCreate, Kind: core.ManagementResourcePolicy, Name: "writer",
+					Policy: policy("writer"),
+				},
+				{
+					Operation: core.ManagementChangeCreate, Kind: core.ManagementResourceBinding, Name: "bob/writer",
+					Binding: &core.PolicyBinding{UserName: "bob", PolicyID: "writer"},
+				},
+				{
+					Operation: core.ManagementChangeDelete, Kind: core.ManagementResourceBinding, Name: "alice/readonly",
+					Binding: binding,
+				},
+				{Operation: core.ManagementChangeDelete, Kind: core.ManagementResourceUser, Name: "alice"},
+				{Operation: core.ManagementChangeDelete, Kind: core.ManagementResourcePolicy, Name: "readonly"},
+			})).To(Succeed())
+
+			Expect(lo.Must(backend.GetUsers(ctx))).To(Equal([]string{"bob", "admin"}))
+			Expect(lo.Must(backend.GetUserByAccessKeyID(ctx, key.AccessKeyID)).AccessKeys).To(ConsistOf(matchKey(key)))
+			Expect(lo.Must(backend.GetPolicies(ctx))).To(Equal([]string{"writer"}))
+			Expect(lo.Must(backend.GetBindings(ctx))).To(Equal([]*core.PolicyBinding{
+				{UserName: "bob", PolicyID: "writer"},
+			}))
+		})
+
+		It("replaces the access keys of updated users", func(ctx context.Context) {
+			key := core.NewAccessKey(nil)
+
+			Expect(backend.ApplyChanges(ctx, []core.ManagementChange{{
+				Operation: core.ManagementChangeUpdate, Kind: core.ManagementResourceUser, Name: "alice",
+				User: &core.User{Name: "alice", AccessKeys: []core.AccessKey{key}},
+			}})).To(Succeed())
+
+			Expect(lo.Must(backend.GetUserByName(ctx, "alice")).AccessKeys).To(ConsistOf(matchKey(key)))
+		})
+
+		It("removes deleted policies from groups and roles", func(ctx context.Context) {
+			lo.Must0(backend.CreateGroup(ctx, "readers"))
+			lo.Must0(backend.CreateGroupBinding(ctx, &core.GroupBinding{GroupName: "readers", PolicyID: "readonly"}))
+			lo.Must0(backend.CreateRole(ctx, &core.Role{Name: "reader", PolicyIDs: []string{"readonly"}}))
+
+			Expect(backend.ApplyChanges(ctx, []core.ManagementChange{
+				{
+					Operation: core.ManagementChangeDelete, Kind: core.ManagementResourceBinding, Name: "alice/readonly",
+					Binding: &core.PolicyBinding{UserName: "alice", PolicyID: "readonly"},
+				},
+				{Operation: core.ManagementChangeDelete, Kind: core.ManagementResourcePolicy, Name: "readonly"},
+			})).To(Succeed())
+
+			Expect(lo.Must(backend.GetGroupBindings(ctx))).To(BeEmpty())
+			Expect(lo.Must(backend.GetRoleByName(ctx, "reader")).PolicyIDs).To(BeEmpty())
+		})
+
+		It("applies nothing if a change fails", func(ctx context.Context) {
+			err := backend.ApplyChanges(ctx, []core.ManagementChange{
+				{
+					Operation: core.ManagementChangeCreate, Kind: core.ManagementResourcePolicy, Name: "writer",
+					Policy: policy("writer"),
+				},
+				{
+					Operation: core.ManagementChangeCreate, Kind: core.ManagementResourceUser, Name: "alice",
+					User: &core.User{Name: "alice"},
+				},
+			})
+			Expect(err).To(MatchError(core.ErrUserAlreadyExists))
+
+			Expect(lo.Must(backend.GetPolicies(ctx))).To(Equal([]string{"readonly"}))
+		})
+	})
+
+	When("two instances share the database", func() {
+		It("sees changes made by the other one immediately", func(ctx context.Context) {
+			first := newBackend(ctx)
+			second := newBackend(ctx)
+
+			user := lo.Must(first.CreateUser(ctx, "shared"))
+
+			Expect(lo.Must(second.GetUserByAccessKeyID(ctx, user.AccessKeys[0].AccessKeyID)).Name).To(Equal("shared"))
+
+			_, err := second.CreateUser(ctx, "shared")
+			Expect(err).To(MatchError(core.ErrUserAlreadyExists))
+		})
+	})
+})
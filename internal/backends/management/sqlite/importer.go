@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/zhulik/d3/internal/backends/management/yaml"
+)
+
+// importYAML copies users, groups, roles, policies and bindings from a YAML management backend config into a
+// freshly created database. It runs in the migration transaction, so a failed import leaves no database behind
+// and is retried on the next start. A missing file is not an error: there is simply nothing to import.
+func (b *Backend) importYAML(ctx context.Context, tx *sql.Tx, path string) error {
+	cfg, err := yaml.LoadManagementConfig(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to read management config %s for import: %w", path, err)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Users)) {
+		user := cfg.Users[name]
+		if user.Name == "" {
+			user.Name = name
+		}
+
+		if err := insertUser(ctx, tx, user); err != nil {
+			return fmt.Errorf("failed to import user %s: %w", name, err)
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(cfg.Policies)) {
+		if err := insertPolicy(ctx, tx, cfg.Policies[id]); err != nil {
+			return fmt.Errorf("failed to import policy %s: %w", id, err)
+		}
+	}
+
+	for _, binding := range cfg.Bindings {
+		if err := insertBinding(ctx, tx, binding); err != nil {
+			return fmt.Errorf("failed to import binding %s/%s: %w", binding.UserName, binding.PolicyID, err)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Groups)) {
+		group := cfg.Groups[name]
+		if group.Name == "" {
+			group.Name = name
+		}
+
+		if err := insertGroup(ctx, tx, group); err != nil {
+			return fmt.Errorf("failed to import group %s: %w", name, err)
+		}
+	}
+
+	for _, binding := range cfg.GroupBindings {
+		if err := insertGroupBinding(ctx, tx, binding); err != nil {
+			return fmt.Errorf("failed to import group binding %s/%s: %w", binding.GroupName, binding.PolicyID, err)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Roles)) {
+		role := cfg.Roles[name]
+		if role.Name == "" {
+			role.Name = name
+		}
+
+		if err := insertRole(ctx, tx, role); err != nil {
+			return fmt.Errorf("failed to import role %s: %w", name, err)
+		}
+	}
+
+	b.Logger.Info("imported management config into sqlite database",
+		"from", path, "users", len(cfg.Users), "groups", len(cfg.Groups), "roles", len(cfg.Roles),
+		"policies", len(cfg.Policies), "bindings", len(cfg.Bindings)+len(cfg.GroupBindings))
+
+	return nil
+}
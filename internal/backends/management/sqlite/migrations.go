@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/zhulik/d3/internal/core"
+)
+
+// migrations are applied in order, each one exactly once. The schema version of a database is the number
+// of applied migrations. Never edit a released migration, append a new one instead.
+var migrations = []string{ //nolint:gochecknoglobals
+	`
+	CREATE TABLE users (
+		name              TEXT NOT NULL PRIMARY KEY,
+		access_key_id     TEXT NOT NULL UNIQUE,
+		secret_access_key TEXT NOT NULL
+	);
+
+	CREATE TABLE policies (
+		id       TEXT NOT NULL PRIMARY KEY,
+		document TEXT NOT NULL
+	);
+
+	CREATE TABLE bindings (
+		user_name TEXT NOT NULL,
+		policy_id TEXT NOT NULL,
+		PRIMARY KEY (user_name, policy_id)
+	);
+
+	CREATE INDEX bindings_policy_id ON bindings (policy_id);
+	`,
+	`
+	CREATE TABLE access_keys (
+		access_key_id     TEXT NOT NULL PRIMARY KEY,
+		user_name         TEXT NOT NULL,
+		secret_access_key TEXT NOT NULL,
+		status            TEXT NOT NULL,
+		created_at        TIMESTAMP NOT NULL,
+		expires_at        TIMESTAMP,
+		last_used_at      TIMESTAMP
+	);
+
+	CREATE INDEX access_keys_user_name ON access_keys (user_name);
+
+	INSERT INTO access_keys (access_key_id, user_name, secret_access_key, status, created_at)
+	SELECT access_key_id, name, secret_access_key, 'Active', CURRENT_TIMESTAMP FROM users;
+
+	CREATE TABLE users_v2 (
+		name TEXT NOT NULL PRIMARY KEY
+	);
+
+	INSERT INTO users_v2 (name) SELECT name FROM users;
+	DROP TABLE users;
+	ALTER TABLE users_v2 RENAME TO users;
+	`,
+	`
+	CREATE TABLE groups (
+		name TEXT NOT NULL PRIMARY KEY
+	);
+
+	CREATE TABLE group_members (
+		group_name TEXT NOT NULL,
+		user_name  TEXT NOT NULL,
+		PRIMARY KEY (group_name, user_name)
+	);
+
+	CREATE INDEX group_members_user_name ON group_members (user_name);
+
+	CREATE TABLE group_bindings (
+		group_name TEXT NOT NULL,
+		policy_id  TEXT NOT NULL,
+		PRIMARY KEY (group_name, policy_id)
+	);
+	`,
+	`
+	CREATE TABLE roles (
+		name TEXT NOT NULL PRIMARY KEY
+	);
+
+	CREATE TABLE role_policies (
+		role_name TEXT NOT NULL,
+		policy_id TEXT NOT NULL,
+		PRIMARY KEY (role_name, policy_id)
+	);
+
+	CREATE TABLE role_trusted_users (
+		role_name TEXT NOT NULL,
+		user_name TEXT NOT NULL,
+		PRIMARY KEY (role_name, user_name)
+	);
+
+	CREATE INDEX role_trusted_users_user_name ON role_trusted_users (user_name);
+	`,
+	`
+	ALTER TABLE roles ADD COLUMN web_identity_conditions TEXT NOT NULL DEFAULT 'null';
+	`,
+	`
+	ALTER TABLE users ADD COLUMN limits TEXT NOT NULL DEFAULT 'null';
+	ALTER TABLE groups ADD COLUMN limits TEXT NOT NULL DEFAULT 'null';
+	`,
+	`
+	ALTER TABLE roles ADD COLUMN limits TEXT NOT NULL DEFAULT 'null';
+	`,
+}
+
+// migrate brings the schema up to date and returns the version the database had before.
+func migrate(ctx context.Context, tx *sql.Tx) (int, error) {
+	_, err := tx.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER NOT NULL PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL
+		)`)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+
+	if version > len(migrations) {
+		return 0, fmt.Errorf("%w: management database schema version %d is newer than supported %d",
+			core.ErrConfigVersionMismatch, version, len(migrations))
+	}
+
+	for i, migration := range migrations[version:] {
+		next := version + i + 1
+
+		if _, err := tx.ExecContext(ctx, migration); err != nil {
+			return 0, fmt.Errorf("failed to apply management database migration %d: %w", next, err)
+		}
+
+		_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)",
+			next, time.Now().UTC())
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return version, nil
+}
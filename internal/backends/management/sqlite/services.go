@@ -0,0 +1,12 @@
+package sqlite
+
+import (
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/pal"
+)
+
+func Provide() pal.ServiceDef {
+	return pal.ProvideList(
+		pal.Provide[core.ManagementBackend](&Backend{}),
+	)
+}
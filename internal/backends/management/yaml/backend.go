@@ -2,12 +2,12 @@ package yaml
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
 	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
@@ -20,14 +20,11 @@ import (
 	"github.com/zhulik/d3/pkg/yaml"
 )
 
-const (
-	pollInterval = 5 * time.Second
-)
-
 type Backend struct {
-	Config *core.Config
-	Locker core.Locker
-	Logger *slog.Logger
+	Config   *core.Config
+	Locker   core.Locker
+	Notifier core.Notifier
+	Logger   *slog.Logger
 
 	lastUpdated        time.Time
 	adminUser          *core.User
@@ -40,6 +37,13 @@ type Backend struct {
 	bindingsByUser   map[string][]*core.PolicyBinding
 	bindingsByPolicy map[string][]*core.PolicyBinding
 
+	groupsByName         map[string]*core.Group
+	groupsByUser         map[string][]string
+	groupBindings        []*core.GroupBinding
+	groupBindingsByGroup map[string][]*core.GroupBinding
+
+	rolesByName map[string]*core.Role
+
 	rwLock sync.RWMutex
 	writer *atomicwriter.AtomicWriter
 }
@@ -66,21 +70,24 @@ func (b *Backend) Init(ctx context.Context) error {
 		return err
 	}
 
-	adminUser, err := b.resolveAdminUser()
+	adminUser, err := ResolveAdminUser(b.Config, b.Logger)
 	if err != nil {
 		return err
 	}
 
 	b.adminUser = adminUser
 
-	_, err = os.Stat(managementConfigPath)
+	info, err := os.Stat(managementConfigPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			cfg := ManagementConfig{
-				Version:  ConfigVersion,
-				Policies: map[string]*iampol.IAMPolicy{},
-				Bindings: []*core.PolicyBinding{},
-				Users:    map[string]*core.User{},
+				Version:       ConfigVersion,
+				Policies:      map[string]*iampol.IAMPolicy{},
+				Bindings:      []*core.PolicyBinding{},
+				Users:         map[string]*core.User{},
+				Groups:        map[string]*core.Group{},
+				GroupBindings: []*core.GroupBinding{},
+				Roles:         map[string]*core.Role{},
 			}
 
 			err := yaml.MarshalToFile(cfg, managementConfigPath)
@@ -94,14 +101,23 @@ func (b *Backend) Init(ctx context.Context) error {
 		return err
 	}
 
-	existingConfig, err := yaml.UnmarshalFromFile[ManagementConfig](managementConfigPath)
+	content, err := os.ReadFile(managementConfigPath)
+	if err != nil {
+		return err
+	}
+
+	existingConfig, version, err := parseManagementConfig(content, info.ModTime())
 	if err != nil {
 		return fmt.Errorf("failed to unmarshal management backend config file %s: %w", managementConfigPath, err)
 	}
 
-	if existingConfig.Version != ConfigVersion {
-		return fmt.Errorf("%w: management config version mismatch: expected %d, got %d",
-			core.ErrConfigVersionMismatch, ConfigVersion, existingConfig.Version)
+	if version != ConfigVersion {
+		b.Logger.Info("upgrading management config", "path", managementConfigPath, "from", version, "to", ConfigVersion)
+
+		err = yaml.MarshalToFile(existingConfig, managementConfigPath)
+		if err != nil {
+			return err
+		}
 	}
 
 	return b.reload(ctx)
@@ -134,15 +150,16 @@ func (b *Backend) GetUserByAccessKeyID(_ context.Context, accessKeyID string) (*
 	b.rwLock.RLock()
 	defer b.rwLock.RUnlock()
 
-	if accessKeyID == b.adminUser.AccessKeyID {
-		return b.adminUser, nil
-	}
-
 	user, ok := b.usersByAccessKeyID[accessKeyID]
 	if !ok {
 		return nil, core.ErrUserNotFound
 	}
 
+	key, _ := user.AccessKey(accessKeyID)
+	if err := key.Usable(time.Now()); err != nil {
+		return nil, err
+	}
+
 	return user, nil
 }
 
@@ -155,11 +172,9 @@ func (b *Backend) CreateUser(ctx context.Context, username string) (*core.User,
 		return nil, core.ErrUserNameReserved
 	}
 
-	accessKeyID, secretAccessKey := credentials.GenerateCredentials()
 	newUser := &core.User{
-		Name:            username,
-		AccessKeyID:     accessKeyID,
-		SecretAccessKey: secretAccessKey,
+		Name:       username,
+		AccessKeys: []core.AccessKey{core.NewAccessKey(nil)},
 	}
 
 	err := b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
@@ -178,33 +193,176 @@ func (b *Backend) CreateUser(ctx context.Context, username string) (*core.User,
 	return newUser, nil
 }
 
-func (b *Backend) UpdateUser(ctx context.Context, updatedUser *core.User) error {
-	if updatedUser.Name == "" || updatedUser.AccessKeyID == "" || updatedUser.SecretAccessKey == "" {
-		return core.ErrUserInvalid
+func (b *Backend) DeleteUser(ctx context.Context, userName string) error {
+	return b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
+		if _, ok := cfg.Users[userName]; !ok {
+			return cfg, core.ErrUserNotFound
+		}
+
+		delete(cfg.Users, userName)
+
+		// A user created later under the same name must not inherit the memberships.
+		for _, group := range cfg.Groups {
+			group.Members = lo.Without(group.Members, userName)
+		}
+
+		for _, role := range cfg.Roles {
+			role.TrustedUsers = lo.Without(role.TrustedUsers, userName)
+		}
+
+		return cfg, nil
+	})
+}
+
+func (b *Backend) SetUserLimits(ctx context.Context, name string, limits *core.RateLimits) error {
+	if name == b.adminUser.Name {
+		return core.ErrUserNameReserved
+	}
+
+	if err := lo.FromPtr(limits).Validate(); err != nil {
+		return err
 	}
 
 	return b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
-		if _, ok := cfg.Users[updatedUser.Name]; !ok {
+		user, ok := cfg.Users[name]
+		if !ok {
 			return cfg, core.ErrUserNotFound
 		}
 
-		cfg.Users[updatedUser.Name] = &core.User{
-			Name:            updatedUser.Name,
-			AccessKeyID:     updatedUser.AccessKeyID,
-			SecretAccessKey: updatedUser.SecretAccessKey,
+		user.Limits = limits
+
+		return cfg, nil
+	})
+}
+
+func (b *Backend) CreateAccessKey(ctx context.Context, userName string, expiresAt *time.Time) (*core.AccessKey, error) {
+	if userName == b.adminUser.Name {
+		return nil, core.ErrUserNameReserved
+	}
+
+	if err := core.ValidateAccessKeyExpiry(expiresAt); err != nil {
+		return nil, err
+	}
+
+	newKey := core.NewAccessKey(expiresAt)
+
+	err := b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
+		user, ok := cfg.Users[userName]
+		if !ok {
+			return cfg, core.ErrUserNotFound
 		}
 
+		user.AccessKeys = append(user.AccessKeys, newKey)
+
 		return cfg, nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &newKey, nil
 }
 
-func (b *Backend) DeleteUser(ctx context.Context, userName string) error {
+func (b *Backend) SetAccessKeyStatus(ctx context.Context, userName string, accessKeyID string,
+	status core.AccessKeyStatus,
+) error {
+	if status != core.AccessKeyStatusActive && status != core.AccessKeyStatusInactive {
+		return fmt.Errorf("%w: unknown status %q", core.ErrAccessKeyInvalid, status)
+	}
+
+	if userName == b.adminUser.Name {
+		return core.ErrUserNameReserved
+	}
+
+	return b.updateAccessKey(ctx, userName, accessKeyID, func(key *core.AccessKey) {
+		key.Status = status
+	})
+}
+
+func (b *Backend) DeleteAccessKey(ctx context.Context, userName string, accessKeyID string) error {
+	if userName == b.adminUser.Name {
+		return core.ErrUserNameReserved
+	}
+
 	return b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
-		if _, ok := cfg.Users[userName]; !ok {
+		user, ok := cfg.Users[userName]
+		if !ok {
 			return cfg, core.ErrUserNotFound
 		}
 
-		delete(cfg.Users, userName)
+		_, index, found := lo.FindIndexOf(user.AccessKeys, func(key core.AccessKey) bool {
+			return key.AccessKeyID == accessKeyID
+		})
+		if !found {
+			return cfg, core.ErrAccessKeyNotFound
+		}
+
+		user.AccessKeys = slices.Delete(user.AccessKeys, index, index+1)
+
+		return cfg, nil
+	})
+}
+
+func (b *Backend) ReplaceAccessKeys(ctx context.Context, userName string) (*core.AccessKey, error) {
+	if userName == b.adminUser.Name {
+		return nil, core.ErrUserNameReserved
+	}
+
+	newKey := core.NewAccessKey(nil)
+
+	err := b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
+		user, ok := cfg.Users[userName]
+		if !ok {
+			return cfg, core.ErrUserNotFound
+		}
+
+		user.AccessKeys = []core.AccessKey{newKey}
+
+		return cfg, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &newKey, nil
+}
+
+func (b *Backend) TouchAccessKey(ctx context.Context, accessKeyID string, usedAt time.Time) error {
+	b.rwLock.RLock()
+	user, ok := b.usersByAccessKeyID[accessKeyID]
+	b.rwLock.RUnlock()
+
+	if !ok {
+		return core.ErrAccessKeyNotFound
+	}
+
+	// The admin key comes from the environment and is not persisted, so there is nothing to record.
+	if user == b.adminUser {
+		return nil
+	}
+
+	return b.updateAccessKey(ctx, user.Name, accessKeyID, func(key *core.AccessKey) {
+		key.LastUsedAt = &usedAt
+	})
+}
+
+func (b *Backend) updateAccessKey(ctx context.Context, userName string, accessKeyID string,
+	update func(key *core.AccessKey),
+) error {
+	return b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
+		user, ok := cfg.Users[userName]
+		if !ok {
+			return cfg, core.ErrUserNotFound
+		}
+
+		index := slices.IndexFunc(user.AccessKeys, func(key core.AccessKey) bool {
+			return key.AccessKeyID == accessKeyID
+		})
+		if index < 0 {
+			return cfg, core.ErrAccessKeyNotFound
+		}
+
+		update(&user.AccessKeys[index])
 
 		return cfg, nil
 	})
@@ -352,72 +510,450 @@ func (b *Backend) DeleteBinding(ctx context.Context, binding *core.PolicyBinding
 	})
 }
 
-// Run watches the main config file and reloads the user repository when it changes.
-func (b *Backend) Run(ctx context.Context) error {
-	ticker := time.NewTicker(pollInterval)
-	defer ticker.Stop()
+func (b *Backend) GetGroups(_ context.Context) ([]string, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
 
-	errorsCount := 0
+	return lo.Keys(b.groupsByName), nil
+}
 
-	var allErrors error
+func (b *Backend) GetGroupByName(_ context.Context, name string) (*core.Group, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
 
-	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		case <-ticker.C:
-			err := b.checkAndReload(ctx)
-			if err != nil {
-				errorsCount++
-				allErrors = errors.Join(allErrors, err)
-				b.Logger.Error("failed to check and reload user repository", "error", err)
+	group, ok := b.groupsByName[name]
+	if !ok {
+		return nil, core.ErrGroupNotFound
+	}
+
+	return group, nil
+}
+
+func (b *Backend) GetGroupsByUser(_ context.Context, userName string) ([]string, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	return b.groupsByUser[userName], nil
+}
+
+func (b *Backend) CreateGroup(ctx context.Context, name string) error {
+	if name == "" {
+		return core.ErrGroupInvalid
+	}
+
+	return b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
+		if _, ok := cfg.Groups[name]; ok {
+			return cfg, core.ErrGroupAlreadyExists
+		}
+
+		cfg.Groups[name] = &core.Group{Name: name, Members: []string{}}
+
+		return cfg, nil
+	})
+}
+
+func (b *Backend) DeleteGroup(ctx context.Context, name string) error {
+	return b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
+		if _, ok := cfg.Groups[name]; !ok {
+			return cfg, core.ErrGroupNotFound
+		}
+
+		delete(cfg.Groups, name)
+
+		cfg.GroupBindings = lo.Filter(cfg.GroupBindings, func(binding *core.GroupBinding, _ int) bool {
+			return binding.GroupName != name
+		})
+
+		return cfg, nil
+	})
+}
+
+func (b *Backend) AddGroupMember(ctx context.Context, groupName string, userName string) error {
+	b.rwLock.RLock()
+	userExists := userName == b.adminUser.Name || b.usersByName[userName] != nil
+	b.rwLock.RUnlock()
+
+	if !userExists {
+		return core.ErrUserNotFound
+	}
+
+	return b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
+		group, ok := cfg.Groups[groupName]
+		if !ok {
+			return cfg, core.ErrGroupNotFound
+		}
+
+		if lo.Contains(group.Members, userName) {
+			return cfg, core.ErrGroupMemberAlreadyExists
+		}
+
+		group.Members = append(group.Members, userName)
+
+		return cfg, nil
+	})
+}
+
+func (b *Backend) RemoveGroupMember(ctx context.Context, groupName string, userName string) error {
+	return b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
+		group, ok := cfg.Groups[groupName]
+		if !ok {
+			return cfg, core.ErrGroupNotFound
+		}
+
+		if !lo.Contains(group.Members, userName) {
+			return cfg, core.ErrGroupMemberNotFound
+		}
+
+		group.Members = lo.Without(group.Members, userName)
+
+		return cfg, nil
+	})
+}
+
+func (b *Backend) SetGroupLimits(ctx context.Context, name string, limits *core.RateLimits) error {
+	if err := lo.FromPtr(limits).Validate(); err != nil {
+		return err
+	}
+
+	return b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
+		group, ok := cfg.Groups[name]
+		if !ok {
+			return cfg, core.ErrGroupNotFound
+		}
+
+		group.Limits = limits
+
+		return cfg, nil
+	})
+}
+
+func (b *Backend) GetGroupBindings(_ context.Context) ([]*core.GroupBinding, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	return b.groupBindings, nil
+}
+
+func (b *Backend) GetBindingsByGroup(_ context.Context, groupName string) ([]*core.GroupBinding, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	return b.groupBindingsByGroup[groupName], nil
+}
+
+func (b *Backend) CreateGroupBinding(ctx context.Context, binding *core.GroupBinding) error {
+	if binding.GroupName == "" || binding.PolicyID == "" {
+		return core.ErrBindingInvalid
+	}
+
+	return b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
+		if _, ok := cfg.Groups[binding.GroupName]; !ok {
+			return cfg, core.ErrGroupNotFound
+		}
+
+		if _, ok := cfg.Policies[binding.PolicyID]; !ok {
+			return cfg, core.ErrPolicyNotFound
+		}
+
+		exists := lo.ContainsBy(cfg.GroupBindings, func(existingBinding *core.GroupBinding) bool {
+			return *existingBinding == *binding
+		})
+		if exists {
+			return cfg, core.ErrBindingAlreadyExists
+		}
+
+		cfg.GroupBindings = append(cfg.GroupBindings, binding)
+
+		return cfg, nil
+	})
+}
+
+func (b *Backend) DeleteGroupBinding(ctx context.Context, binding *core.GroupBinding) error {
+	return b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
+		found := lo.ContainsBy(cfg.GroupBindings, func(existingBinding *core.GroupBinding) bool {
+			return *existingBinding == *binding
+		})
+		if !found {
+			return cfg, core.ErrBindingNotFound
+		}
+
+		cfg.GroupBindings = lo.Filter(cfg.GroupBindings, func(existingBinding *core.GroupBinding, _ int) bool {
+			return *existingBinding != *binding
+		})
+
+		return cfg, nil
+	})
+}
+
+func (b *Backend) GetRoles(_ context.Context) ([]string, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	return lo.Keys(b.rolesByName), nil
+}
+
+func (b *Backend) GetRoleByName(_ context.Context, name string) (*core.Role, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	role, ok := b.rolesByName[name]
+	if !ok {
+		return nil, core.ErrRoleNotFound
+	}
+
+	return role, nil
+}
+
+func (b *Backend) CreateRole(ctx context.Context, role *core.Role) error {
+	if err := role.Validate(); err != nil {
+		return err
+	}
+
+	return b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
+		if _, ok := cfg.Roles[role.Name]; ok {
+			return cfg, core.ErrRoleAlreadyExists
+		}
+
+		for _, policyID := range role.PolicyIDs {
+			if _, ok := cfg.Policies[policyID]; !ok {
+				return cfg, fmt.Errorf("%w: %s", core.ErrPolicyNotFound, policyID)
 			}
+		}
 
-			if errorsCount > 3 {
-				return fmt.Errorf("failed to check and reload user repository after 3 attempts: %w", allErrors)
+		for _, userName := range role.TrustedUsers {
+			if _, ok := cfg.Users[userName]; !ok {
+				return cfg, fmt.Errorf("%w: %s", core.ErrUserNotFound, userName)
 			}
 		}
+
+		cfg.Roles[role.Name] = &core.Role{
+			Name:                  role.Name,
+			PolicyIDs:             lo.Uniq(role.PolicyIDs),
+			TrustedUsers:          lo.Uniq(role.TrustedUsers),
+			WebIdentityConditions: role.WebIdentityConditions,
+			Limits:                role.Limits,
+		}
+
+		return cfg, nil
+	})
+}
+
+func (b *Backend) SetRoleLimits(ctx context.Context, name string, limits *core.RateLimits) error {
+	if err := lo.FromPtr(limits).Validate(); err != nil {
+		return err
 	}
+
+	return b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
+		role, ok := cfg.Roles[name]
+		if !ok {
+			return cfg, core.ErrRoleNotFound
+		}
+
+		role.Limits = limits
+
+		return cfg, nil
+	})
 }
 
-func (b *Backend) resolveAdminUser() (*core.User, error) {
-	if b.Config.AdminCredentialsPath != "" {
-		creds, err := yaml.UnmarshalFromFile[AdminCredentialsConfig](b.Config.AdminCredentialsPath)
+func (b *Backend) DeleteRole(ctx context.Context, name string) error {
+	return b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
+		if _, ok := cfg.Roles[name]; !ok {
+			return cfg, core.ErrRoleNotFound
+		}
+
+		delete(cfg.Roles, name)
+
+		return cfg, nil
+	})
+}
+
+func (b *Backend) ApplyChanges(ctx context.Context, changes []core.ManagementChange) error {
+	return b.readWriteConfig(ctx, func(cfg ManagementConfig) (ManagementConfig, error) {
+		for _, change := range changes {
+			var err error
+
+			switch change.Kind {
+			case core.ManagementResourceUser:
+				err = b.applyUserChange(cfg, change)
+			case core.ManagementResourcePolicy:
+				cfg.GroupBindings, err = applyPolicyChange(cfg, change)
+			case core.ManagementResourceBinding:
+				cfg.Bindings, err = b.applyBindingChange(cfg, change)
+			default:
+				err = fmt.Errorf("%w: unknown resource kind %q", core.ErrManagementConfigInvalid, change.Kind)
+			}
+
+			if err != nil {
+				return cfg, fmt.Errorf("failed to %s %s %s: %w", change.Operation, change.Kind, change.Name, err)
+			}
+		}
+
+		return cfg, nil
+	})
+}
+
+func (b *Backend) applyUserChange(cfg ManagementConfig, change core.ManagementChange) error {
+	if change.Name == b.adminUser.Name {
+		return core.ErrUserNameReserved
+	}
+
+	_, exists := cfg.Users[change.Name]
+
+	switch change.Operation {
+	case core.ManagementChangeCreate:
+		if exists {
+			return core.ErrUserAlreadyExists
+		}
+
+		cfg.Users[change.Name] = change.User
+	case core.ManagementChangeUpdate:
+		if !exists {
+			return core.ErrUserNotFound
+		}
+
+		cfg.Users[change.Name] = change.User
+	case core.ManagementChangeDelete:
+		if !exists {
+			return core.ErrUserNotFound
+		}
+
+		delete(cfg.Users, change.Name)
+
+		for _, group := range cfg.Groups {
+			group.Members = lo.Without(group.Members, change.Name)
+		}
+
+		for _, role := range cfg.Roles {
+			role.TrustedUsers = lo.Without(role.TrustedUsers, change.Name)
+		}
+	}
+
+	return nil
+}
+
+// applyPolicyChange returns the group bindings, deleted policies are removed from the groups and roles, which are
+// not managed by apply.
+func applyPolicyChange(cfg ManagementConfig, change core.ManagementChange) ([]*core.GroupBinding, error) {
+	_, exists := cfg.Policies[change.Name]
+
+	switch change.Operation {
+	case core.ManagementChangeCreate:
+		if exists {
+			return cfg.GroupBindings, core.ErrPolicyAlreadyExists
+		}
+
+		cfg.Policies[change.Name] = change.Policy
+	case core.ManagementChangeUpdate:
+		if !exists {
+			return cfg.GroupBindings, core.ErrPolicyNotFound
+		}
+
+		cfg.Policies[change.Name] = change.Policy
+	case core.ManagementChangeDelete:
+		if !exists {
+			return cfg.GroupBindings, core.ErrPolicyNotFound
+		}
+
+		delete(cfg.Policies, change.Name)
+
+		for _, role := range cfg.Roles {
+			role.PolicyIDs = lo.Without(role.PolicyIDs, change.Name)
+		}
+
+		return lo.Reject(cfg.GroupBindings, func(binding *core.GroupBinding, _ int) bool {
+			return binding.PolicyID == change.Name
+		}), nil
+	}
+
+	return cfg.GroupBindings, nil
+}
+
+func (b *Backend) applyBindingChange(cfg ManagementConfig, change core.ManagementChange) ([]*core.PolicyBinding, error) {
+	binding := change.Binding
+
+	exists := lo.ContainsBy(cfg.Bindings, func(existingBinding *core.PolicyBinding) bool {
+		return *existingBinding == *binding
+	})
+
+	switch change.Operation {
+	case core.ManagementChangeCreate:
+		if exists {
+			return cfg.Bindings, core.ErrBindingAlreadyExists
+		}
+
+		if _, ok := cfg.Users[binding.UserName]; !ok && binding.UserName != b.adminUser.Name {
+			return cfg.Bindings, core.ErrUserNotFound
+		}
+
+		if _, ok := cfg.Policies[binding.PolicyID]; !ok {
+			return cfg.Bindings, core.ErrPolicyNotFound
+		}
+
+		return append(cfg.Bindings, binding), nil
+	case core.ManagementChangeDelete:
+		if !exists {
+			return cfg.Bindings, core.ErrBindingNotFound
+		}
+
+		return lo.Filter(cfg.Bindings, func(existingBinding *core.PolicyBinding, _ int) bool {
+			return *existingBinding != *binding
+		}), nil
+	default:
+		return cfg.Bindings, fmt.Errorf("%w: bindings cannot be updated", core.ErrManagementConfigInvalid)
+	}
+}
+
+// ResolveAdminUser returns the admin user from AdminCredentialsPath, or temporary credentials
+// in development and test environments. It is shared by all management backends.
+func ResolveAdminUser(cfg *core.Config, logger *slog.Logger) (*core.User, error) {
+	if cfg.AdminCredentialsPath != "" {
+		creds, err := yaml.UnmarshalFromFile[AdminCredentialsConfig](cfg.AdminCredentialsPath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read admin credentials from %s: %w",
-				b.Config.AdminCredentialsPath, err)
+				cfg.AdminCredentialsPath, err)
 		}
 
-		if err := core.ValidateAdminUser(&creds.AdminUser); err != nil {
+		if err := core.ValidateAdminAccessKey(&creds.AdminUser); err != nil {
 			return nil, err
 		}
 
-		adminUser := &creds.AdminUser
-		adminUser.Name = "admin"
-
-		return adminUser, nil
+		return adminUser(creds.AdminUser.AccessKeyID, creds.AdminUser.SecretAccessKey), nil
 	}
 
-	if b.Config.ShouldCreateTemporaryAdminCredentials() {
+	if cfg.ShouldCreateTemporaryAdminCredentials() {
 		accessKeyID, secretAccessKey := credentials.GenerateCredentials()
 
-		b.Logger.Info("Using temporary admin credentials (not persisted)",
+		logger.Info("Using temporary admin credentials (not persisted)",
 			"AWS_ACCESS_KEY_ID", accessKeyID, "AWS_SECRET_ACCESS_KEY", secretAccessKey)
 
-		return &core.User{
-			Name:            "admin",
-			AccessKeyID:     accessKeyID,
-			SecretAccessKey: secretAccessKey,
-		}, nil
+		return adminUser(accessKeyID, secretAccessKey), nil
 	}
 
 	return nil, core.ErrAdminCredentialsNotSet
 }
 
+func adminUser(accessKeyID, secretAccessKey string) *core.User {
+	return &core.User{
+		Name: "admin",
+		AccessKeys: []core.AccessKey{{
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: secretAccessKey,
+			Status:          core.AccessKeyStatusActive,
+		}},
+	}
+}
+
 func (b *Backend) readWriteConfig(ctx context.Context, op func(ManagementConfig) (ManagementConfig, error)) error {
-	err := b.writer.ReadWrite(ctx, b.Config.ManagementBackendYAMLPath,
+	path := b.Config.ManagementBackendYAMLPath
+
+	err := b.writer.ReadWrite(ctx, path,
 		func(_ context.Context, content []byte) ([]byte, error) {
-			managementConfig, err := yaml.Unmarshal[ManagementConfig](content)
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, err
+			}
+
+			managementConfig, _, err := parseManagementConfig(content, info.ModTime())
 			if err != nil {
 				return nil, err
 			}
@@ -433,7 +969,17 @@ func (b *Backend) readWriteConfig(ctx context.Context, op func(ManagementConfig)
 		return err
 	}
 
-	return b.reload(ctx)
+	err = b.reload(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The change is already persisted, other instances will pick it up with the fallback poll if this fails.
+	if err := b.Notifier.Publish(ctx, invalidationChannel, path); err != nil {
+		b.Logger.Error("failed to publish management config invalidation", "error", err)
+	}
+
+	return nil
 }
 
 func (b *Backend) checkAndReload(ctx context.Context) error {
@@ -474,7 +1020,7 @@ func (b *Backend) reload(ctx context.Context) error {
 		return err
 	}
 
-	managementConfig, err := yaml.UnmarshalFromFile[ManagementConfig](path)
+	managementConfig, err := LoadManagementConfig(path)
 	if err != nil {
 		return err
 	}
@@ -493,12 +1039,50 @@ func (b *Backend) reload(ctx context.Context) error {
 		}
 
 		b.usersByName[userName] = user
-		b.usersByAccessKeyID[user.AccessKeyID] = b.usersByName[userName]
+
+		for _, key := range user.AccessKeys {
+			b.usersByAccessKeyID[key.AccessKeyID] = user
+		}
+	}
+
+	for _, key := range b.adminUser.AccessKeys {
+		b.usersByAccessKeyID[key.AccessKeyID] = b.adminUser
 	}
 
 	maps.Copy(b.policiesByID, managementConfig.Policies)
 	b.bindings = managementConfig.Bindings
 
+	b.groupsByName = map[string]*core.Group{}
+	b.groupsByUser = map[string][]string{}
+	b.groupBindings = managementConfig.GroupBindings
+	b.groupBindingsByGroup = map[string][]*core.GroupBinding{}
+
+	for groupName, group := range managementConfig.Groups {
+		if group.Name == "" {
+			group.Name = groupName
+		}
+
+		b.groupsByName[groupName] = group
+
+		for _, member := range group.Members {
+			b.groupsByUser[member] = append(b.groupsByUser[member], groupName)
+		}
+	}
+
+	for _, binding := range managementConfig.GroupBindings {
+		b.groupBindingsByGroup[binding.GroupName] = append(b.groupBindingsByGroup[binding.GroupName], binding)
+	}
+
+	b.rolesByName = map[string]*core.Role{}
+
+	for roleName, role := range managementConfig.Roles {
+		if role.Name == "" {
+			role.Name = roleName
+		}
+
+		b.rolesByName[roleName] = role
+	}
+
 	// Index bindings by user and policy
 	for _, binding := range managementConfig.Bindings {
 		b.bindingsByUser[binding.UserName] = append(b.bindingsByUser[binding.UserName], binding)
@@ -1,25 +1,148 @@
 package yaml
 
 import (
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/zhulik/d3/internal/core"
 	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/yaml"
 )
 
 const (
-	ConfigVersion = 1
+	ConfigVersion = 5
 )
 
 // Use core.User directly for YAML marshaling/unmarshaling. core.User has yaml tags.
 
+// ManagementConfig is also the format of the desired state that d3-client apply sends to the management API.
 type ManagementConfig struct {
-	Version  int                          `yaml:"version"`
-	Users    map[string]*core.User        `yaml:"users"`
-	Policies map[string]*iampol.IAMPolicy `yaml:"policies"`
-	Bindings []*core.PolicyBinding        `yaml:"bindings"`
+	Version       int                          `json:"version"        yaml:"version"`
+	Users         map[string]*core.User        `json:"users"          yaml:"users"`
+	Policies      map[string]*iampol.IAMPolicy `json:"policies"       yaml:"policies"`
+	Bindings      []*core.PolicyBinding        `json:"bindings"       yaml:"bindings"`
+	Groups        map[string]*core.Group       `json:"groups"         yaml:"groups"`
+	GroupBindings []*core.GroupBinding         `json:"group_bindings" yaml:"group_bindings"`
+	Roles         map[string]*core.Role        `json:"roles"          yaml:"roles"`
 }
 
 // AdminCredentialsConfig is the structure for the admin credentials YAML file
-// specified by ADMIN_CREDENTIALS_PATH.
+// specified by ADMIN_CREDENTIALS_PATH. Only access_key_id and secret_access_key are read.
 type AdminCredentialsConfig struct {
-	AdminUser core.User `yaml:"admin_user"`
+	AdminUser core.AccessKey `yaml:"admin_user"`
+}
+
+// managementConfigV1 is the layout of version 1 configs, where every user had exactly one access key.
+type managementConfigV1 struct {
+	Users    map[string]*userV1           `yaml:"users"`
+	Policies map[string]*iampol.IAMPolicy `yaml:"policies"`
+	Bindings []*core.PolicyBinding        `yaml:"bindings"`
+}
+
+type userV1 struct {
+	Name            string `yaml:"name"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+}
+
+// LoadManagementConfig reads a management config file, upgrading older versions in memory.
+func LoadManagementConfig(path string) (ManagementConfig, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ManagementConfig{}, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ManagementConfig{}, err
+	}
+
+	cfg, _, err := parseManagementConfig(content, info.ModTime())
+
+	return cfg, err
+}
+
+// parseManagementConfig decodes a management config of any supported version into the current layout.
+// It also returns the version the content was stored with. modTime is the modification time of the file the
+// content was read from.
+func parseManagementConfig(content []byte, modTime time.Time) (ManagementConfig, int, error) {
+	header, err := yaml.Unmarshal[struct {
+		Version int `yaml:"version"`
+	}](content)
+	if err != nil {
+		return ManagementConfig{}, 0, err
+	}
+
+	var cfg ManagementConfig
+
+	switch header.Version {
+	case ConfigVersion, 4, 3, 2: // versions 3 to 5 only added groups, roles and rate limits
+		cfg, err = yaml.Unmarshal[ManagementConfig](content)
+		if err != nil {
+			return ManagementConfig{}, 0, err
+		}
+	case 1:
+		legacy, err := yaml.Unmarshal[managementConfigV1](content)
+		if err != nil {
+			return ManagementConfig{}, 0, err
+		}
+
+		cfg = upgradeV1(legacy, modTime)
+	default:
+		return ManagementConfig{}, 0, fmt.Errorf("%w: management config version mismatch: expected %d, got %d",
+			core.ErrConfigVersionMismatch, ConfigVersion, header.Version)
+	}
+
+	cfg.Version = ConfigVersion
+
+	if cfg.Groups == nil {
+		cfg.Groups = map[string]*core.Group{}
+	}
+
+	if cfg.GroupBindings == nil {
+		cfg.GroupBindings = []*core.GroupBinding{}
+	}
+
+	if cfg.Roles == nil {
+		cfg.Roles = map[string]*core.Role{}
+	}
+
+	return cfg, header.Version, nil
+}
+
+// upgradeV1 turns the single key of every version 1 user into an active access key. The creation time of
+// those keys is unknown, so the modification time of the file is used instead: it stays the same every time the
+// file is read until it is upgraded on disk.
+func upgradeV1(legacy managementConfigV1, modTime time.Time) ManagementConfig {
+	createdAt := modTime.UTC()
+
+	cfg := ManagementConfig{
+		Version:  ConfigVersion,
+		Users:    make(map[string]*core.User, len(legacy.Users)),
+		Policies: legacy.Policies,
+		Bindings: legacy.Bindings,
+	}
+
+	for name, user := range legacy.Users {
+		cfg.Users[name] = &core.User{
+			Name: user.Name,
+			AccessKeys: []core.AccessKey{{
+				AccessKeyID:     user.AccessKeyID,
+				SecretAccessKey: user.SecretAccessKey,
+				Status:          core.AccessKeyStatusActive,
+				CreatedAt:       createdAt,
+			}},
+		}
+	}
+
+	if cfg.Policies == nil {
+		cfg.Policies = map[string]*iampol.IAMPolicy{}
+	}
+
+	if cfg.Bindings == nil {
+		cfg.Bindings = []*core.PolicyBinding{}
+	}
+
+	return cfg
 }
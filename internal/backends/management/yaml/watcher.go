@@ -0,0 +1,153 @@
+package yaml
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/zhulik/d3/pkg/backoff"
+)
+
+const (
+	// invalidationChannel is the Redis pub/sub channel writers announce config changes on.
+	invalidationChannel = "d3:management:yaml:invalidate"
+
+	// fallbackPollInterval bounds staleness when both file events and invalidation messages are missed,
+	// e.g. on network filesystems without inotify support or while Redis is unreachable.
+	fallbackPollInterval = time.Minute
+
+	minRetryDelay = 100 * time.Millisecond
+	maxRetryDelay = 30 * time.Second
+)
+
+// Run reloads the config as soon as the file changes locally or another instance announces a change.
+// Failed reloads are retried with backoff until they succeed or ctx is done.
+func (b *Backend) Run(ctx context.Context) error {
+	changes := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	wg.Go(func() { b.watchFile(ctx, notify) })
+	wg.Go(func() { b.subscribeInvalidations(ctx, notify) })
+
+	defer wg.Wait()
+
+	ticker := time.NewTicker(fallbackPollInterval)
+	defer ticker.Stop()
+
+	retryDelay := backoff.New(minRetryDelay, maxRetryDelay)
+
+	var retry <-chan time.Time
+
+	for {
+		var err error
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			err = b.checkAndReload(ctx)
+		case <-changes:
+			err = b.reload(ctx)
+		case <-retry:
+			err = b.reload(ctx)
+		}
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			delay := retryDelay.Next()
+			b.Logger.Error("failed to reload management config, retrying", "error", err, "retryIn", delay)
+
+			retry = time.After(delay)
+
+			continue
+		}
+
+		retry = nil
+
+		retryDelay.Reset()
+	}
+}
+
+// watchFile notifies about changes of the config file. The directory is watched instead of the file because
+// writers replace the file with a rename, which would silently end a watch on the old inode.
+func (b *Backend) watchFile(ctx context.Context, notify func()) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		b.Logger.Warn("file watching is unavailable, relying on polling", "error", err)
+
+		return
+	}
+	defer watcher.Close()
+
+	path := filepath.Clean(b.Config.ManagementBackendYAMLPath)
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		b.Logger.Warn("file watching is unavailable, relying on polling", "error", err)
+
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			if filepath.Clean(event.Name) == path && !event.Has(fsnotify.Chmod) {
+				notify()
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+
+			b.Logger.Error("management config watcher error", "error", err)
+		}
+	}
+}
+
+// subscribeInvalidations notifies about changes announced by other instances. It resubscribes with backoff
+// when the subscription breaks and forces a reload afterwards, as messages sent in between are lost.
+func (b *Backend) subscribeInvalidations(ctx context.Context, notify func()) {
+	retryDelay := backoff.New(minRetryDelay, maxRetryDelay)
+
+	for {
+		subscribedAt := time.Now()
+
+		err := b.Notifier.Subscribe(ctx, invalidationChannel, func(string) { notify() })
+		if ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(subscribedAt) > maxRetryDelay {
+			retryDelay.Reset()
+		}
+
+		delay := retryDelay.Next()
+		b.Logger.Error("management config invalidation subscription failed, resubscribing",
+			"error", err, "retryIn", delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		notify()
+	}
+}
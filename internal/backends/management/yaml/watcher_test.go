@@ -0,0 +1,188 @@
+package yaml_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/mock"
+	"github.com/zhulik/d3/internal/backends/management/yaml"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/internal/core/mocks"
+	"github.com/zhulik/d3/pkg/iampol"
+	yamlPkg "github.com/zhulik/d3/pkg/yaml"
+)
+
+var _ = Describe("YAML Backend change detection", func() {
+	var (
+		configPath    string
+		backend       *yaml.Backend
+		mockNotifier  *mocks.MockNotifier
+		subscriptions chan func(string)
+		runDone       chan error
+	)
+
+	writeConfig := func(userNames ...string) {
+		users := map[string]*core.User{}
+		for _, name := range userNames {
+			users[name] = &core.User{
+				Name: name,
+				AccessKeys: []core.AccessKey{{
+					AccessKeyID: "AKIA" + name, SecretAccessKey: "secret-" + name, Status: core.AccessKeyStatusActive,
+				}},
+			}
+		}
+
+		lo.Must0(yamlPkg.MarshalToFile(yaml.ManagementConfig{
+			Version:  yaml.ConfigVersion,
+			Users:    users,
+			Policies: map[string]*iampol.IAMPolicy{},
+			Bindings: []*core.PolicyBinding{},
+		}, configPath))
+	}
+
+	userExists := func(ctx context.Context, name string) func() error {
+		return func() error {
+			_, err := backend.GetUserByName(ctx, name)
+
+			return err
+		}
+	}
+
+	BeforeEach(func(ctx context.Context) {
+		tempDir := lo.Must(os.MkdirTemp("", "yaml-watcher-test-"))
+		DeferCleanup(func() {
+			lo.Must0(os.RemoveAll(tempDir))
+		})
+
+		configPath = filepath.Join(tempDir, "management.yaml")
+		writeConfig()
+
+		lo.Must0(os.MkdirAll(filepath.Join(tempDir, "tmp", "tmp"), 0755))
+
+		mockLocker := mocks.NewMockLocker(GinkgoT())
+		mockLocker.EXPECT().Lock(mock.Anything, mock.Anything).Maybe().Return(
+			func(ctx context.Context, _ string) (context.Context, context.CancelFunc, error) {
+				return ctx, func() {}, nil
+			},
+		)
+
+		subscriptions = make(chan func(string), 10)
+
+		mockNotifier = mocks.NewMockNotifier(GinkgoT())
+		mockNotifier.EXPECT().Subscribe(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(
+			func(ctx context.Context, _ string, handler func(string)) error {
+				subscriptions <- handler
+				<-ctx.Done()
+
+				return ctx.Err()
+			},
+		).Maybe()
+
+		backend = &yaml.Backend{
+			Config: &core.Config{
+				ManagementBackendYAMLPath: configPath,
+				ManagementBackendTmpPath:  filepath.Join(tempDir, "tmp"),
+				Environment:               "test",
+			},
+			Locker:   mockLocker,
+			Notifier: mockNotifier,
+			Logger:   slog.New(slog.DiscardHandler),
+		}
+		lo.Must0(backend.Init(ctx))
+	})
+
+	startRun := func() func(string) {
+		runCtx, cancel := context.WithCancel(context.Background())
+		runDone = make(chan error, 1)
+
+		go func() {
+			runDone <- backend.Run(runCtx)
+		}()
+
+		DeferCleanup(func() {
+			cancel()
+			Eventually(runDone).Should(Receive(BeNil()))
+		})
+
+		var handler func(string)
+		Eventually(subscriptions).Should(Receive(&handler))
+
+		return handler
+	}
+
+	When("the file is replaced locally", func() {
+		It("reloads without waiting for the poll interval", func(ctx context.Context) {
+			startRun()
+
+			writeConfig("alice")
+
+			Eventually(userExists(ctx, "alice")).Should(Succeed())
+		})
+	})
+
+	When("another instance announces a change", func() {
+		It("reloads even if the modification time did not change", func(ctx context.Context) {
+			info := lo.Must(os.Stat(configPath))
+
+			writeConfig("bob")
+			lo.Must0(os.Chtimes(configPath, info.ModTime(), info.ModTime()))
+
+			invalidate := startRun()
+			Consistently(userExists(ctx, "bob"), 200*time.Millisecond).Should(MatchError(core.ErrUserNotFound))
+
+			invalidate(configPath)
+
+			Eventually(userExists(ctx, "bob")).Should(Succeed())
+		})
+	})
+
+	When("a write succeeds", func() {
+		It("announces the change to other instances", func(ctx context.Context) {
+			mockNotifier.EXPECT().Publish(mock.Anything, mock.Anything, configPath).Return(nil).Once()
+
+			lo.Must(backend.CreateUser(ctx, "carol"))
+		})
+	})
+
+	When("reloading keeps failing", func() {
+		It("keeps retrying instead of stopping", func(ctx context.Context) {
+			invalidate := startRun()
+
+			lo.Must0(os.Remove(configPath))
+
+			for range 5 {
+				invalidate(configPath)
+			}
+
+			Consistently(runDone, 500*time.Millisecond).ShouldNot(Receive())
+
+			writeConfig("dave")
+
+			Eventually(userExists(ctx, "dave"), 5*time.Second).Should(Succeed())
+		})
+	})
+
+	When("the subscription breaks", func() {
+		It("resubscribes", func() {
+			mockNotifier = mocks.NewMockNotifier(GinkgoT())
+			mockNotifier.EXPECT().Subscribe(mock.Anything, mock.Anything, mock.Anything).Return(core.ErrInvalidConfig).Once()
+			mockNotifier.EXPECT().Subscribe(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(
+				func(ctx context.Context, _ string, handler func(string)) error {
+					subscriptions <- handler
+					<-ctx.Done()
+
+					return ctx.Err()
+				},
+			)
+			backend.Notifier = mockNotifier
+
+			startRun()
+		})
+	})
+})
@@ -6,10 +6,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/samber/lo"
 	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/sse"
 	"github.com/zhulik/d3/pkg/xiter"
 	"github.com/zhulik/d3/pkg/yaml"
 )
@@ -23,7 +27,12 @@ type configYaml struct {
 }
 
 type bucketMetadata struct {
-	CreationDate time.Time `yaml:"creationDate"`
+	CreationDate time.Time               `yaml:"creationDate"`
+	Logging      *core.BucketLogging     `yaml:"logging,omitempty"`
+	Encryption   *core.BucketEncryption  `yaml:"encryption,omitempty"`
+	Compression  *core.BucketCompression `yaml:"compression,omitempty"`
+	ObjectLock   *core.BucketObjectLock  `yaml:"object_lock,omitempty"`
+	Quota        *core.BucketQuota       `yaml:"quota,omitempty"`
 }
 
 type Backend struct {
@@ -31,11 +40,25 @@ type Backend struct {
 
 	Locker core.Locker
 
-	config *Config
+	// config is the config of the first storage root, roots the configs of all of them.
+	config   *Config
+	roots    []*Config
+	nextRoot atomic.Uint64
+	keyring  *sse.Keyring
 }
 
 func (b *Backend) Init(ctx context.Context) error {
-	b.config = &Config{b.Cfg}
+	b.roots = lo.Map(b.Cfg.FolderStorageRoots(), func(root string, _ int) *Config {
+		return &Config{Config: b.Cfg, Root: root}
+	})
+	b.config = b.roots[0]
+
+	keyring, err := loadKeyring(b.Cfg)
+	if err != nil {
+		return err
+	}
+
+	b.keyring = keyring
 
 	// Lock the backend to prevent concurrent initialization
 	ctx, cancel, err := b.Locker.Lock(ctx, "folder-storage-backend-init")
@@ -44,32 +67,83 @@ func (b *Backend) Init(ctx context.Context) error {
 	}
 	defer cancel()
 
-	_, err = os.Stat(b.Cfg.FolderStorageBackendPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			err = os.MkdirAll(b.Cfg.FolderStorageBackendPath, 0755)
-			if err != nil {
+	for _, root := range b.roots {
+		_, err = os.Stat(root.root())
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return fmt.Errorf("%w: unable to access storage root %s: %w", core.ErrInvalidConfig, root.root(), err)
+			}
+
+			if err := os.MkdirAll(root.root(), 0755); err != nil {
 				return err
 			}
-		} else {
-			return fmt.Errorf("%w: unable to access FolderStorageBackendPath: %w", core.ErrInvalidConfig, err)
+		}
+
+		if err := b.prepareFileStructure(ctx, root); err != nil {
+			return err
 		}
 	}
 
-	return b.prepareFileStructure(ctx)
+	return nil
 }
 
+// ListBuckets lists the buckets of all roots by name. A bucket being moved is listed once.
 func (b *Backend) ListBuckets(_ context.Context) ([]core.Bucket, error) {
-	entries, err := os.ReadDir(b.config.bucketsPath())
+	var names []string
+
+	for _, root := range b.roots {
+		entries, err := os.ReadDir(root.bucketsPath())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.Type()&os.ModeSymlink == 0 {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+
+	slices.Sort(names)
+
+	return xiter.ErrFilterMap(slices.Compact(names), func(name string) (core.Bucket, bool, error) {
+		bucket, err := b.headBucket(name)
+		if errors.Is(err, core.ErrBucketNotFound) {
+			// Deleted, or moved between the roots, since the listing.
+			return nil, false, nil
+		}
+
+		return bucket, err == nil, err
+	})
+}
+
+// CreateBucket creates the bucket on the root it is pinned to, or on the one picked by the placement policy.
+func (b *Backend) CreateBucket(ctx context.Context, name string) error {
+	if _, err := b.config.bucketPath(name); err != nil {
+		return err
+	}
+
+	_, cancel, err := b.Locker.Lock(ctx, bucketLockKey(name))
 	if err != nil {
-		return nil, err
+		return err
+	}
+	defer cancel()
+
+	if _, err := b.bucketConfig(name); !errors.Is(err, core.ErrBucketNotFound) {
+		return lo.Ternary(err == nil, core.ErrBucketAlreadyExists, err)
 	}
 
-	return xiter.ErrFilterMap(entries, b.dirEntryToBucket)
+	return b.createBucket(name, bucketMetadata{CreationDate: time.Now()})
 }
 
-func (b *Backend) CreateBucket(_ context.Context, name string) error {
-	path, err := b.config.bucketPath(name)
+// createBucket places a new empty bucket with the given metadata, the caller must hold the bucket lock.
+func (b *Backend) createBucket(name string, metadata bucketMetadata) error {
+	root, err := b.placeBucket(name)
+	if err != nil {
+		return err
+	}
+
+	path, err := root.bucketPath(name)
 	if err != nil {
 		return err
 	}
@@ -83,29 +157,75 @@ func (b *Backend) CreateBucket(_ context.Context, name string) error {
 		return err
 	}
 
-	err = yaml.MarshalToFile(bucketMetadata{CreationDate: time.Now()}, b.bucketMetadataPath(name))
+	err = yaml.MarshalToFile(metadata, root.bucketMetadataPath(name))
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// Usage is only tracked for buckets with a quota.
+	if metadata.Quota == nil {
+		return nil
+	}
+
+	return yaml.MarshalToFile(core.BucketUsage{}, root.bucketUsagePath(name))
 }
 
-func (b *Backend) DeleteBucket(_ context.Context, name string) error {
-	path, err := b.config.bucketPath(name)
+func (b *Backend) DeleteBucket(ctx context.Context, name string) error {
+	bucket, err := b.headBucket(name)
+	if err != nil {
+		return err
+	}
+
+	root := bucket.config
+
+	path, err := root.bucketPath(name)
 	if err != nil {
 		return err
 	}
 
-	metadataPath := b.bucketMetadataPath(name)
+	metadataPath := root.bucketMetadataPath(name)
 	if err := rejectSymlink(metadataPath); err != nil {
 		return err
 	}
 
+	// Buckets being moved are not deleted, the usage lock is held by the move until it is done.
+	usagePath := root.bucketUsagePath(name)
+
+	_, cancel, err := b.Locker.Lock(ctx, usagePath)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	if err := bucket.checkNotMoving(); err != nil {
+		return err
+	}
+
+	// Deleting the last object or finishing an upload leaves empty staging and object roots behind,
+	// they must not keep an otherwise empty bucket alive. Non-empty directories fail to be removed,
+	// so the errors are ignored on purpose and the final bucket removal decides.
+	for _, dir := range []string{
+		filepath.Join(path, uploadsFolder, regularUploadsFolder),
+		filepath.Join(path, uploadsFolder, multipartFolder),
+		filepath.Join(path, uploadsFolder),
+		filepath.Join(path, objectsFolder),
+	} {
+		os.Remove(dir)
+	}
+
 	if err := os.Remove(metadataPath); err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
 
+	// The usage of a bucket that turns out not to be empty is rescanned the next time it is needed.
+	if err := rejectSymlink(usagePath); err != nil {
+		return err
+	}
+
+	if err := os.Remove(usagePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
 	err = os.Remove(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -126,7 +246,16 @@ func (b *Backend) DeleteBucket(_ context.Context, name string) error {
 }
 
 func (b *Backend) HeadBucket(_ context.Context, name string) (core.Bucket, error) {
-	path, err := b.config.bucketPath(name)
+	return b.headBucket(name)
+}
+
+func (b *Backend) headBucket(name string) (*Bucket, error) {
+	root, err := b.bucketConfig(name)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := root.bucketPath(name)
 	if err != nil {
 		return nil, err
 	}
@@ -144,85 +273,65 @@ func (b *Backend) HeadBucket(_ context.Context, name string) (core.Bucket, error
 		return nil, err
 	}
 
-	creationDate, err := b.bucketCreationDate(name, info)
+	metadata, err := bucketMetadataOf(root, name, info)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Bucket{
 		name:         name,
-		creationDate: creationDate,
-		config:       b.config,
+		creationDate: metadata.CreationDate,
+		logging:      metadata.Logging,
+		encryption:   metadata.Encryption,
+		compression:  metadata.Compression,
+		objectLock:   metadata.ObjectLock,
+		quota:        metadata.Quota,
+		config:       root,
+		keyring:      b.keyring,
+		roots:        b.roots,
 		Locker:       b.Locker,
 	}, nil
 }
 
-func (b *Backend) dirEntryToBucket(entry os.DirEntry) (core.Bucket, bool, error) {
-	if entry.Type()&os.ModeSymlink != 0 {
-		return nil, false, nil
-	}
-
-	info, err := entry.Info()
-	if err != nil {
-		return nil, false, err
-	}
-
-	creationDate, err := b.bucketCreationDate(entry.Name(), info)
-	if err != nil {
-		return nil, false, err
-	}
-
-	return &Bucket{
-		name:         entry.Name(),
-		creationDate: creationDate,
-		config:       b.config,
-		Locker:       b.Locker,
-	}, true, nil
-}
-
-func (b *Backend) bucketMetadataPath(name string) string {
-	return filepath.Join(b.config.bucketsPath(), name, "bucket.yaml")
-}
-
-func (b *Backend) bucketCreationDate(name string, info os.FileInfo) (time.Time, error) {
-	path := b.bucketMetadataPath(name)
+func bucketMetadataOf(root *Config, name string, info os.FileInfo) (bucketMetadata, error) {
+	path := root.bucketMetadataPath(name)
 
 	if err := rejectSymlink(path); err != nil {
-		return time.Time{}, err
+		return bucketMetadata{}, err
 	}
 
 	metadata, err := yaml.UnmarshalFromFile[bucketMetadata](path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			// Buckets created before bucket metadata support use mtime as a best-effort fallback.
-			return info.ModTime(), nil
+			return bucketMetadata{CreationDate: info.ModTime()}, nil
 		}
 
-		return time.Time{}, err
+		return bucketMetadata{}, err
 	}
 
-	return metadata.CreationDate, nil
+	return metadata, nil
 }
 
-func (b *Backend) prepareFileStructure(ctx context.Context) error {
-	err := b.prepareConfigYaml(ctx)
+func (b *Backend) prepareFileStructure(ctx context.Context, root *Config) error {
+	err := b.prepareConfigYaml(ctx, root)
 	if err != nil {
 		return err
 	}
 
-	if err := os.MkdirAll(b.config.bucketsPath(), 0755); err != nil {
+	if err := os.MkdirAll(root.bucketsPath(), 0755); err != nil {
 		return err
 	}
 
-	if err := os.MkdirAll(b.config.binPath(), 0755); err != nil {
+	if err := os.MkdirAll(root.binPath(), 0755); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (b *Backend) prepareConfigYaml(_ context.Context) error {
-	configPath := b.config.configYamlPath()
+func (b *Backend) prepareConfigYaml(_ context.Context, root *Config) error {
+	configPath := root.configYamlPath()
 
 	_, err := os.Stat(configPath)
 	if err != nil {
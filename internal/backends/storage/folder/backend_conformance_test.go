@@ -0,0 +1,44 @@
+package folder_test
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	"github.com/samber/lo"
+
+	"github.com/zhulik/d3/internal/backends/storage/folder"
+	"github.com/zhulik/d3/internal/backends/storage/storagetest"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/sse"
+)
+
+func newConformanceBackend(compression core.CompressionAlgorithm, dedup bool) storagetest.BackendFactory {
+	return func(ctx context.Context) core.StorageBackend {
+		tmpDir := lo.Must(os.MkdirTemp("", "folder-conformance-*"))
+		DeferCleanup(func() {
+			os.RemoveAll(tmpDir)
+		})
+
+		backend := &folder.Backend{
+			Cfg: &core.Config{
+				FolderStorageBackendPath: tmpDir,
+				FolderStorageCompression: compression,
+				FolderStorageDedup:       dedup,
+				SSEMasterKey:             "conformance:" + base64.StdEncoding.EncodeToString(make([]byte, sse.KeySize)),
+			},
+			Locker: storagetest.NewLocker(),
+		}
+		lo.Must0(backend.Init(ctx))
+
+		return backend
+	}
+}
+
+var _ = storagetest.DescribeStorageBackend("folder", newConformanceBackend(core.CompressionNone, false))
+
+var _ = storagetest.DescribeStorageBackend("folder with zstd compression",
+	newConformanceBackend(core.CompressionZstd, false))
+
+var _ = storagetest.DescribeStorageBackend("folder with deduplication", newConformanceBackend(core.CompressionNone, true))
@@ -0,0 +1,153 @@
+package folder
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/smartio"
+	"github.com/zhulik/d3/pkg/sse"
+	"github.com/zhulik/d3/pkg/zstdseek"
+)
+
+// blobSegment describes what writeBlob wrote: the size and checksum of the content and how it is stored.
+type blobSegment struct {
+	size   int64
+	sha256 string
+	// storedSHA256 is the checksum of the bytes written to dst, empty when they are encrypted.
+	storedSHA256 string
+	// encrypted is set when the content is encrypted, compressed when compression was attempted.
+	encrypted  *core.EncryptedSegment
+	compressed *core.CompressedSegment
+}
+
+// writeBlob copies src to dst like smartio.Copy. When compress is set and the content compresses well, it is
+// compressed into a seekable zstd stream, and when key is set the result is encrypted into a new segment.
+func writeBlob(ctx context.Context, dst io.Writer, src io.Reader, key []byte, compress bool) (*blobSegment, error) { //nolint:funlen,lll
+	compressible := false
+
+	if compress {
+		buffered := bufio.NewReaderSize(src, zstdseek.FrameSize)
+		src = buffered
+
+		// The first frame decides whether the whole content is worth compressing.
+		sample, err := buffered.Peek(zstdseek.FrameSize)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		compressible, err = zstdseek.Compressible(sample)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	writer := dst
+
+	var (
+		encryptor  *sse.Writer
+		compressor *zstdseek.Writer
+		storedHash hash.Hash
+		nonce      []byte
+		err        error
+	)
+
+	if key != nil {
+		nonce, err = sse.NewNonce()
+		if err != nil {
+			return nil, err
+		}
+
+		encryptor, err = sse.NewWriter(dst, key, nonce)
+		if err != nil {
+			return nil, err
+		}
+
+		writer = encryptor
+	}
+
+	if compressible {
+		// Uncompressed plaintext is stored as is, its checksum is computed by the copy.
+		if key == nil {
+			storedHash = sha256.New()
+			writer = io.MultiWriter(writer, storedHash)
+		}
+
+		compressor, err = zstdseek.NewWriter(writer)
+		if err != nil {
+			return nil, err
+		}
+
+		writer = compressor
+	}
+
+	size, sha256sum, err := smartio.Copy(ctx, writer, src)
+	if err != nil {
+		return nil, err
+	}
+
+	segment := &blobSegment{size: size, sha256: sha256sum}
+	storedSize := size
+
+	if key == nil {
+		segment.storedSHA256 = sha256sum
+	}
+
+	if compress {
+		segment.compressed = &core.CompressedSegment{Size: size, StoredSize: size, Raw: true}
+	}
+
+	if compressor != nil {
+		if err := compressor.Close(); err != nil {
+			return nil, err
+		}
+
+		storedSize = compressor.Size()
+		segment.compressed = &core.CompressedSegment{Size: size, StoredSize: storedSize}
+
+		if storedHash != nil {
+			segment.storedSHA256 = hex.EncodeToString(storedHash.Sum(nil))
+		}
+	}
+
+	if encryptor != nil {
+		if err := encryptor.Close(); err != nil {
+			return nil, err
+		}
+
+		segment.encrypted = &core.EncryptedSegment{Nonce: base64.StdEncoding.EncodeToString(nonce), Size: storedSize}
+	}
+
+	return segment, nil
+}
+
+// openBlob returns the content of the blob of an object, decrypted and decompressed as needed. customerKey is
+// required for SSE-C objects.
+func (b *Bucket) openBlob(blob io.ReadSeekCloser, metadata *core.ObjectMetadata,
+	customerKey []byte) (io.ReadSeekCloser, error) {
+	reader := blob
+
+	if encryption := metadata.Encryption; encryption != nil {
+		key, err := b.dataKey(encryption, customerKey)
+		if err != nil {
+			return nil, err
+		}
+
+		reader, err = newDecryptingReader(reader, key, encryption)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if compression := metadata.Compression; compression != nil {
+		return newDecompressingReader(reader, compression)
+	}
+
+	return reader, nil
+}
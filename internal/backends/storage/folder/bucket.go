@@ -13,23 +13,37 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/samber/lo"
 	"github.com/zhulik/d3/internal/apis/s3"
 	"github.com/zhulik/d3/internal/core"
 	"github.com/zhulik/d3/pkg/smartio"
+	"github.com/zhulik/d3/pkg/sse"
 	"github.com/zhulik/d3/pkg/yaml"
 )
 
 type Bucket struct {
 	name         string
 	creationDate time.Time
+	logging      *core.BucketLogging
+	encryption   *core.BucketEncryption
+	compression  *core.BucketCompression
+	objectLock   *core.BucketObjectLock
+	quota        *core.BucketQuota
 	config       *Config
+	keyring      *sse.Keyring
+	// roots are the configs of all storage roots, config is one of them.
+	roots []*Config
 
 	Locker core.Locker
 }
 
 type partMetadata struct {
 	ETag string `yaml:"etag"`
+	// Segment is set for parts of encrypted uploads.
+	Segment *core.EncryptedSegment `yaml:"segment,omitempty"`
+	// Compression is set for parts of uploads that are compressed.
+	Compression *core.CompressedSegment `yaml:"compression,omitempty"`
 }
 
 func (b *Bucket) Name() string {
@@ -68,17 +82,28 @@ func (b *Bucket) PutObject(ctx context.Context, key string, input core.PutObject
 		return err
 	}
 
+	now := time.Now()
+
+	retention, err := b.newRetention(input.Metadata.Retention, input.Metadata.LegalHold, now)
+	if err != nil {
+		return err
+	}
+
+	var existing *Object
+
 	if _, err := os.Lstat(path); err == nil {
 		if input.IfNoneMatch {
 			return core.ErrPreconditionFailed
 		}
 
-		existing, err := ObjectFromPath(b, key)
+		existing, err = ObjectFromPath(b, key)
 		if err != nil {
 			return err
 		}
+	}
 
-		if err := existing.Delete(); err != nil {
+	if existing != nil {
+		if err := existing.Metadata().CheckLock(now, input.BypassGovernance); err != nil {
 			return err
 		}
 	}
@@ -93,18 +118,28 @@ func (b *Bucket) PutObject(ctx context.Context, key string, input core.PutObject
 	}
 	defer os.RemoveAll(uploadPath)
 
+	encryption, dataKey, err := b.newEncryption(input.Encryption)
+	if err != nil {
+		return err
+	}
+
 	uploadFile, err := createFileNoFollow(filepath.Join(uploadPath, blobFilename), 0644)
 	if err != nil {
 		return err
 	}
 	defer uploadFile.Close()
 
-	actualSize, sha256sum, err := smartio.Copy(ctx, uploadFile, input.Reader)
+	segment, err := writeBlob(ctx, uploadFile, input.Reader, dataKey, b.compresses())
 	if err != nil {
 		return err
 	}
 
-	if input.Metadata.SHA256 == s3.StreamingHMACSHA256 {
+	actualSize, sha256sum := segment.size, segment.sha256
+
+	// Streaming, unsigned and presigned uploads declare no payload hash, there is nothing to verify.
+	switch input.Metadata.SHA256 {
+	case s3.StreamingHMACSHA256, s3.StreamingHMACSHA256Trailer, s3.StreamingUnsignedPayloadTrailer,
+		s3.UnsignedPayload, "":
 		input.Metadata.SHA256 = sha256sum
 	}
 
@@ -119,22 +154,56 @@ func (b *Bucket) PutObject(ctx context.Context, key string, input core.PutObject
 		return err
 	}
 
-	err = yaml.MarshalToFile(metadata, filepath.Join(uploadPath, metadataYamlFilename))
+	metadata.Retention = retention
+	metadata.LegalHold = input.Metadata.LegalHold
+
+	if encryption != nil {
+		encryption.Segments = []core.EncryptedSegment{*segment.encrypted}
+		metadata.Encryption = encryption
+
+		if err := sealChecksum(&metadata, dataKey); err != nil {
+			return err
+		}
+	}
+
+	if segment.compressed != nil {
+		metadata.Compression, err = objectCompression(segment.compressed)
+		if err != nil {
+			return err
+		}
+	}
+
+	if b.dedups() && segment.storedSHA256 != "" {
+		metadata.BlobSHA256, err = b.sharedBlobSHA256(ctx, filepath.Join(uploadPath, blobFilename), segment.storedSHA256)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writeObjectMetadata(metadata, filepath.Join(uploadPath, metadataYamlFilename))
 	if err != nil {
 		return err
 	}
 
-	parentDir := filepath.Dir(path)
-	if err := mkdirAllNoFollow(parentDir, 0755); err != nil {
-		return err
+	return b.commitUsage(ctx, usageDelta(existing, metadata.Size), func() error {
+		return b.replaceObject(ctx, existing, uploadPath, path)
+	})
+}
+
+// replaceObject moves the staged object at uploadPath to path, deleting existing first. Deleting an object removes
+// its emptied parent directories, they are created again afterward.
+func (b *Bucket) replaceObject(ctx context.Context, existing *Object, uploadPath, path string) error {
+	if existing != nil {
+		if err := existing.Delete(ctx); err != nil {
+			return err
+		}
 	}
 
-	err = renameNoFollow(uploadPath, path)
-	if err != nil {
+	if err := mkdirAllNoFollow(filepath.Dir(path), 0755); err != nil {
 		return err
 	}
 
-	return nil
+	return renameNoFollow(uploadPath, path)
 }
 
 func (b *Bucket) CopyObject(ctx context.Context, dstKey string, input core.CopyObjectInput) (*core.CopyObjectResult, error) { //nolint:funlen,lll
@@ -161,6 +230,17 @@ func (b *Bucket) CopyObject(ctx context.Context, dstKey string, input core.CopyO
 		return nil, err
 	}
 
+	now := time.Now()
+
+	retention, err := b.newRetention(input.Retention, input.LegalHold, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.checkOverwrite(dstKey, input.BypassGovernance); err != nil {
+		return nil, err
+	}
+
 	uploadPath, err := b.config.newUploadPath(b.name)
 	if err != nil {
 		return nil, err
@@ -172,20 +252,45 @@ func (b *Bucket) CopyObject(ctx context.Context, dstKey string, input core.CopyO
 	defer os.RemoveAll(uploadPath)
 
 	srcObj := input.Source.(*Object) //nolint:forcetypeassert
+	srcMeta := input.Source.Metadata()
 
-	blobDst := filepath.Join(uploadPath, blobFilename)
-
-	if err := os.Link(filepath.Join(srcObj.path, blobFilename), blobDst); err != nil {
+	encryption, dataKey, err := b.newEncryption(input.Encryption)
+	if err != nil {
 		return nil, err
 	}
 
-	srcMeta := input.Source.Metadata()
-
 	metadata := core.ObjectMetadata{
 		SHA256:       srcMeta.SHA256,
 		SHA256Base64: srcMeta.SHA256Base64,
 		Size:         srcMeta.Size,
-		LastModified: time.Now(),
+		LastModified: now,
+		Encryption:   encryption,
+		Retention:    retention,
+		LegalHold:    input.LegalHold,
+	}
+
+	blobDst := filepath.Join(uploadPath, blobFilename)
+
+	// Unencrypted blobs are shared, compressed or not.
+	if srcMeta.Encryption == nil && encryption == nil {
+		if err := os.Link(filepath.Join(srcObj.path, blobFilename), blobDst); err != nil {
+			return nil, err
+		}
+
+		metadata.Compression = srcMeta.Compression
+		metadata.BlobSHA256 = srcMeta.BlobSHA256
+	} else {
+		storedSHA256, err := copyBlob(ctx, srcObj, blobDst, &metadata, dataKey, b.compresses())
+		if err != nil {
+			return nil, err
+		}
+
+		if b.dedups() && storedSHA256 != "" {
+			metadata.BlobSHA256, err = b.sharedBlobSHA256(ctx, blobDst, storedSHA256)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	if input.MetadataDirective == core.CopyDirectiveReplace {
@@ -202,7 +307,7 @@ func (b *Bucket) CopyObject(ctx context.Context, dstKey string, input core.CopyO
 		metadata.Tags = srcMeta.Tags
 	}
 
-	if err := yaml.MarshalToFile(metadata, filepath.Join(uploadPath, metadataYamlFilename)); err != nil {
+	if err := writeObjectMetadata(metadata, filepath.Join(uploadPath, metadataYamlFilename)); err != nil {
 		return nil, err
 	}
 
@@ -211,17 +316,12 @@ func (b *Bucket) CopyObject(ctx context.Context, dstKey string, input core.CopyO
 		return nil, err
 	}
 
-	if existing, _ := ObjectFromPath(b, dstKey); existing != nil {
-		if err := existing.Delete(); err != nil {
-			return nil, err
-		}
-	}
+	existing, _ := ObjectFromPath(b, dstKey)
 
-	if err := os.MkdirAll(parentDir, 0755); err != nil {
-		return nil, err
-	}
-
-	if err := os.Rename(uploadPath, dstPath); err != nil {
+	err = b.commitUsage(ctx, usageDelta(existing, metadata.Size), func() error {
+		return b.replaceObject(ctx, existing, uploadPath, dstPath)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -303,25 +403,18 @@ func (b *Bucket) ListObjectsV2(ctx context.Context, input core.ListObjectsV2Inpu
 	}, nil
 }
 
-func (b *Bucket) DeleteObjects(ctx context.Context, quiet bool, keys ...string) ([]core.DeleteResult, error) { //nolint:lll
+func (b *Bucket) DeleteObjects(ctx context.Context, input core.DeleteObjectsInput) ([]core.DeleteResult, error) { //nolint:lll
 	results := []core.DeleteResult{}
 
-	for _, key := range keys {
+	for _, key := range input.Keys {
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
 
-		object, err := b.getObject(key)
-		if err != nil {
-			results = append(results, core.DeleteResult{Key: key, Error: err})
-
-			continue
-		}
-
-		err = object.Delete()
+		err := b.deleteObject(ctx, key, input.BypassGovernance)
 		if err != nil {
 			results = append(results, core.DeleteResult{Key: key, Error: err})
-		} else if !quiet {
+		} else if !input.Quiet {
 			results = append(results, core.DeleteResult{Key: key, Error: nil})
 		}
 	}
@@ -329,21 +422,79 @@ func (b *Bucket) DeleteObjects(ctx context.Context, quiet bool, keys ...string)
 	return results, nil
 }
 
-func (b *Bucket) CreateMultipartUpload(_ context.Context, key string, metadata core.ObjectMetadata) (string, error) { //nolint:lll
-	id, uploadPath, err := b.config.newMultipartUploadPath(b.name, key)
+// deleteObject deletes an object unless Object Lock protects it.
+func (b *Bucket) deleteObject(ctx context.Context, key string, bypassGovernance bool) error {
+	path, err := b.config.objectPath(b.name, key)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	if err := mkdirAllNoFollow(uploadPath, 0755); err != nil {
-		return "", err
+	_, cancel, err := b.Locker.Lock(ctx, path)
+	if err != nil {
+		return err
 	}
+	defer cancel()
 
-	if err := yaml.MarshalToFile(metadata, filepath.Join(uploadPath, metadataYamlFilename)); err != nil {
-		return "", err
+	object, err := b.getObject(key)
+	if err != nil {
+		return err
+	}
+
+	if err := object.Metadata().CheckLock(time.Now(), bypassGovernance); err != nil {
+		return err
+	}
+
+	delta := core.BucketUsage{Bytes: -object.Metadata().Size, Objects: -1}
+
+	return b.commitUsage(ctx, delta, func() error { return object.Delete(ctx) })
+}
+
+func (b *Bucket) CreateMultipartUpload(ctx context.Context, key string, metadata core.ObjectMetadata,
+	encryption *core.ServerSideEncryption) (string, error) {
+	id := uuid.NewString()
+
+	return id, b.CreateMultipartUploadWithID(ctx, key, id, metadata, encryption)
+}
+
+// CreateMultipartUploadWithID starts an upload like CreateMultipartUpload, with the given ID, which must be a UUID.
+func (b *Bucket) CreateMultipartUploadWithID(ctx context.Context, key, id string, metadata core.ObjectMetadata,
+	encryption *core.ServerSideEncryption) error {
+	if err := uuid.Validate(id); err != nil {
+		return fmt.Errorf("%w: %w", core.ErrInvalidUploadID, err)
 	}
 
-	return id, nil
+	uploadPath, err := b.config.newMultipartUploadPath(b.name, key, id)
+	if err != nil {
+		return err
+	}
+
+	// The default retention of the bucket is only applied on completion, counting from then.
+	if _, err := b.newRetention(metadata.Retention, metadata.LegalHold, time.Now()); err != nil {
+		return err
+	}
+
+	// Every part is encrypted with the data key of the upload, into a segment of its own.
+	metadata.Encryption, _, err = b.newEncryption(encryption)
+	if err != nil {
+		return err
+	}
+
+	// Whether the upload is compressed is decided now, each part is then compressed into a segment of its own
+	// unless it does not compress well.
+	metadata.Compression = nil
+	if b.compresses() {
+		metadata.Compression = &core.ObjectCompression{Algorithm: core.CompressionZstd}
+	}
+
+	err = b.commitChange(ctx, func() error {
+		if err := mkdirAllNoFollow(uploadPath, 0755); err != nil {
+			return err
+		}
+
+		return yaml.MarshalToFile(metadata, filepath.Join(uploadPath, metadataYamlFilename))
+	})
+
+	return err
 }
 
 func loadPartMetadata(uploadPath string, partNumber int) (partMetadata, error) {
@@ -377,7 +528,8 @@ func validateAllPartETags(uploadPath string, parts []core.CompletePart) error {
 	return nil
 }
 
-func (b *Bucket) UploadPart(ctx context.Context, key string, uploadID string, partNumber int, body io.Reader) (string, error) { //nolint:lll
+func (b *Bucket) UploadPart(ctx context.Context, key string, uploadID string, partNumber int, body io.Reader, //nolint:funlen,lll
+	encryption *core.ServerSideEncryption) (string, error) {
 	uploadPath, err := b.multipartUploadPath(key, uploadID)
 	if err != nil {
 		return "", err
@@ -405,21 +557,52 @@ func (b *Bucket) UploadPart(ctx context.Context, key string, uploadID string, pa
 		return "", core.ErrObjectAlreadyExists
 	}
 
+	uploadMetadata, err := yaml.UnmarshalFromFile[core.ObjectMetadata](filepath.Join(uploadPath, metadataYamlFilename))
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := b.uploadDataKey(uploadMetadata, encryption)
+	if err != nil {
+		return "", err
+	}
+
 	uploadFile, err := createFileNoFollow(path, 0644)
 	if err != nil {
 		return "", err
 	}
 	defer uploadFile.Close()
 
-	_, checksum, err := smartio.Copy(ctx, uploadFile, body)
+	segment, err := writeBlob(ctx, uploadFile, body, dataKey, uploadMetadata.Compression != nil)
 	if err != nil {
+		// A part that failed to upload, for instance on a checksum mismatch, may be uploaded again.
+		os.Remove(path)
+
 		return "", err
 	}
 
-	partMeta := partMetadata{ETag: checksum}
+	checksum := segment.sha256
+	if dataKey != nil {
+		checksum = encryptedPartETag(dataKey, checksum)
+	}
 
+	partMeta := partMetadata{ETag: checksum, Segment: segment.encrypted, Compression: segment.compressed}
 	metaPath := filepath.Join(uploadPath, fmt.Sprintf("part-%d.yaml", partNumber))
-	if err := yaml.MarshalToFile(partMeta, metaPath); err != nil {
+
+	// Parts do not count toward the usage until the upload is completed, a part is only rejected when it could
+	// never fit. Completing the upload checks the quota again. The part is committed by writing its metadata.
+	err = b.withUsage(ctx, func(usage *core.BucketUsage) error {
+		if usage != nil {
+			if err := b.quota.Check(*usage, core.BucketUsage{Bytes: segment.size}); err != nil {
+				return err
+			}
+		}
+
+		return yaml.MarshalToFile(partMeta, metaPath)
+	})
+	if err != nil {
+		os.Remove(path)
+
 		return "", err
 	}
 
@@ -456,12 +639,22 @@ func (b *Bucket) CompleteMultipartUpload(ctx context.Context, key string, upload
 		return nil, err
 	}
 
-	blobFile, err := createFileNoFollow(filepath.Join(uploadPath, blobFilename), 0644)
+	// The quota is checked again when the object is committed, this saves assembling uploads that cannot fit.
+	if err := b.checkUploadQuota(ctx, key, uploadPath, parts); err != nil {
+		return nil, err
+	}
+
+	blobPath := filepath.Join(uploadPath, blobFilename)
+
+	blobFile, err := createFileNoFollow(blobPath, 0644)
 	if err != nil {
 		return nil, err
 	}
 	defer blobFile.Close()
 
+	// The assembled blob is moved along with the upload when it is completed, a failed completion may be retried.
+	defer os.Remove(blobPath)
+
 	partFiles := make([]io.ReadCloser, 0, len(parts))
 
 	closeAll := func() {
@@ -470,9 +663,22 @@ func (b *Bucket) CompleteMultipartUpload(ctx context.Context, key string, upload
 		}
 	}
 
+	segments := make([]*core.EncryptedSegment, 0, len(parts))
+	compressedSegments := make([]*core.CompressedSegment, 0, len(parts))
+
 	for _, part := range parts {
 		path := filepath.Join(uploadPath, fmt.Sprintf("part-%d", part.PartNumber))
 
+		partMeta, err := loadPartMetadata(uploadPath, part.PartNumber)
+		if err != nil {
+			closeAll()
+
+			return nil, err
+		}
+
+		segments = append(segments, partMeta.Segment)
+		compressedSegments = append(compressedSegments, partMeta.Compression)
+
 		partFile, err := openFileNoFollow(path)
 		if err != nil {
 			closeAll()
@@ -492,20 +698,6 @@ func (b *Bucket) CompleteMultipartUpload(ctx context.Context, key string, upload
 		return nil, err
 	}
 
-	files, err := os.ReadDir(uploadPath)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, file := range files {
-		if strings.HasPrefix(file.Name(), "part-") {
-			err := os.Remove(filepath.Join(uploadPath, file.Name()))
-			if err != nil {
-				return nil, err
-			}
-		}
-	}
-
 	blobFileStat, err := blobFile.Stat()
 	if err != nil {
 		return nil, err
@@ -518,8 +710,6 @@ func (b *Bucket) CompleteMultipartUpload(ctx context.Context, key string, upload
 
 	metadata.Size = blobFileStat.Size()
 
-	blobPath := filepath.Join(uploadPath, blobFilename)
-
 	blobReader, err := openFileNoFollow(blobPath)
 	if err != nil {
 		return nil, err
@@ -535,9 +725,37 @@ func (b *Bucket) CompleteMultipartUpload(ctx context.Context, key string, upload
 	metadata.SHA256Base64 = base64.StdEncoding.EncodeToString(rawSha256)
 	metadata.LastModified = time.Now()
 
-	err = yaml.MarshalToFile(metadata, filepath.Join(uploadPath, metadataYamlFilename))
-	if err != nil {
-		return nil, err
+	if metadata.Retention == nil {
+		metadata.Retention, err = b.newRetention(nil, metadata.LegalHold, metadata.LastModified)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if metadata.Encryption != nil {
+		if err := encryptedUploadMetadata(&metadata, segments); err != nil {
+			return nil, err
+		}
+	}
+
+	if metadata.Compression != nil {
+		if err := compressedUploadMetadata(ctx, &metadata, blobReader, compressedSegments); err != nil {
+			return nil, err
+		}
+	}
+
+	if metadata.Encryption != nil {
+		if err := b.encryptedUploadChecksum(ctx, &metadata, blobReader); err != nil {
+			return nil, err
+		}
+	}
+
+	// Parts are concatenated as they are stored, the checksum of the copy is the one of the stored blob.
+	if b.dedups() && metadata.Encryption == nil {
+		metadata.BlobSHA256, err = b.sharedBlobSHA256(ctx, blobPath, sha256sum)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	objPath, err := b.config.objectPath(b.name, key)
@@ -550,18 +768,60 @@ func (b *Bucket) CompleteMultipartUpload(ctx context.Context, key string, upload
 		return nil, err
 	}
 
-	if err := mkdirAllNoFollow(parentDir, 0755); err != nil {
+	_, cancel, err := b.Locker.Lock(ctx, objPath)
+	if err != nil {
 		return nil, err
 	}
+	defer cancel()
 
-	if err := renameNoFollow(uploadPath, objPath); err != nil {
+	existing, err := ObjectFromPath(b, key)
+	if err != nil && !errors.Is(err, core.ErrObjectNotFound) {
+		return nil, err
+	}
+
+	if existing != nil {
+		if err := existing.Metadata().CheckLock(time.Now(), false); err != nil {
+			return nil, err
+		}
+	}
+
+	err = b.commitUsage(ctx, usageDelta(existing, metadata.Size), func() error {
+		if err := removeParts(uploadPath); err != nil {
+			return err
+		}
+
+		if err := writeObjectMetadata(metadata, filepath.Join(uploadPath, metadataYamlFilename)); err != nil {
+			return err
+		}
+
+		return b.replaceObject(ctx, existing, uploadPath, objPath)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return &metadata, nil
 }
 
-func (b *Bucket) AbortMultipartUpload(_ context.Context, key string, uploadID string) error {
+func removeParts(uploadPath string) error {
+	files, err := os.ReadDir(uploadPath)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), "part-") {
+			err := os.Remove(filepath.Join(uploadPath, file.Name()))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (b *Bucket) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
 	uploadPath, err := b.multipartUploadPath(key, uploadID)
 	if err != nil {
 		return err
@@ -576,7 +836,7 @@ func (b *Bucket) AbortMultipartUpload(_ context.Context, key string, uploadID st
 		return err
 	}
 
-	return os.RemoveAll(uploadPath)
+	return b.commitChange(ctx, func() error { return os.RemoveAll(uploadPath) })
 }
 
 func (b *Bucket) ListParts(ctx context.Context, key string, input core.ListPartsInput) (*core.ListPartsResult, error) {
@@ -770,10 +1030,19 @@ func collectPartInfos(ctx context.Context, uploadPath string) ([]core.PartInfo,
 			return nil, err
 		}
 
+		size := info.Size()
+
+		switch {
+		case partMeta.Compression != nil:
+			size = partMeta.Compression.Size
+		case partMeta.Segment != nil:
+			size = partMeta.Segment.Size
+		}
+
 		allParts = append(allParts, core.PartInfo{
 			PartNumber:   partNumber,
 			ETag:         partMeta.ETag,
-			Size:         info.Size(),
+			Size:         size,
 			LastModified: info.ModTime(),
 		})
 	}
@@ -782,6 +1051,24 @@ func collectPartInfos(ctx context.Context, uploadPath string) ([]core.PartInfo,
 }
 
 func (b *Bucket) PutObjectTagging(ctx context.Context, key string, tags map[string]string) error {
+	return b.updateObjectMetadata(ctx, key, func(metadata *core.ObjectMetadata) error {
+		metadata.Tags = tags
+
+		return nil
+	})
+}
+
+func (b *Bucket) DeleteObjectTagging(ctx context.Context, key string) error {
+	return b.updateObjectMetadata(ctx, key, func(metadata *core.ObjectMetadata) error {
+		metadata.Tags = nil
+
+		return nil
+	})
+}
+
+// updateObjectMetadata changes the metadata file of an object under lock, nothing is written when update fails.
+func (b *Bucket) updateObjectMetadata(ctx context.Context, key string,
+	update func(*core.ObjectMetadata) error) error {
 	path, err := b.config.objectPath(b.name, key)
 	if err != nil {
 		return err
@@ -813,46 +1100,108 @@ func (b *Bucket) PutObjectTagging(ctx context.Context, key string, tags map[stri
 		return err
 	}
 
-	metadata.Tags = tags
+	if err := update(&metadata); err != nil {
+		return err
+	}
 
-	return yaml.MarshalToFile(metadata, metadataPath)
+	return b.commitChange(ctx, func() error { return yaml.MarshalToFile(metadata, metadataPath) })
 }
 
-func (b *Bucket) DeleteObjectTagging(ctx context.Context, key string) error {
-	path, err := b.config.objectPath(b.name, key)
+func (b *Bucket) Logging() *core.BucketLogging {
+	return b.logging
+}
+
+func (b *Bucket) PutLogging(ctx context.Context, logging *core.BucketLogging) error {
+	err := b.updateMetadata(ctx, func(metadata *bucketMetadata) { metadata.Logging = logging })
 	if err != nil {
 		return err
 	}
 
-	_, cancel, err := b.Locker.Lock(ctx, path)
+	b.logging = logging
+
+	return nil
+}
+
+func (b *Bucket) Encryption() *core.BucketEncryption {
+	return b.encryption
+}
+
+func (b *Bucket) PutEncryption(ctx context.Context, encryption *core.BucketEncryption) error {
+	if encryption != nil && b.keyring == nil {
+		return core.ErrSSENotConfigured
+	}
+
+	err := b.updateMetadata(ctx, func(metadata *bucketMetadata) { metadata.Encryption = encryption })
 	if err != nil {
 		return err
 	}
-	defer cancel()
 
-	if err := rejectSymlinkInPath(path); err != nil {
-		return err
+	b.encryption = encryption
+
+	return nil
+}
+
+func (b *Bucket) Compression() *core.BucketCompression {
+	return b.compression
+}
+
+func (b *Bucket) PutCompression(ctx context.Context, compression *core.BucketCompression) error {
+	if compression != nil {
+		if err := compression.Validate(); err != nil {
+			return err
+		}
 	}
 
-	ok, err := IsObjectPath(path)
+	err := b.updateMetadata(ctx, func(metadata *bucketMetadata) { metadata.Compression = compression })
 	if err != nil {
 		return err
 	}
 
-	if !ok {
-		return core.ErrObjectNotFound
-	}
+	b.compression = compression
 
-	metadataPath := filepath.Join(path, metadataYamlFilename)
+	return nil
+}
 
-	metadata, err := yaml.UnmarshalFromFile[core.ObjectMetadata](metadataPath)
+// updateMetadata changes the bucket metadata file under lock.
+func (b *Bucket) updateMetadata(ctx context.Context, update func(*bucketMetadata)) error {
+	path := b.config.bucketMetadataPath(b.name)
+
+	_, cancel, err := b.Locker.Lock(ctx, path)
 	if err != nil {
 		return err
 	}
+	defer cancel()
 
-	metadata.Tags = nil
+	if err := rejectSymlink(path); err != nil {
+		return err
+	}
 
-	return yaml.MarshalToFile(metadata, metadataPath)
+	metadata, err := yaml.UnmarshalFromFile[bucketMetadata](path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		metadata.CreationDate = b.creationDate
+	}
+
+	update(&metadata)
+
+	return b.commitChange(ctx, func() error { return yaml.MarshalToFile(metadata, path) })
+}
+
+// uploadDataKey returns the data key of a multipart upload, nil when it is not encrypted.
+func (b *Bucket) uploadDataKey(metadata core.ObjectMetadata, encryption *core.ServerSideEncryption) ([]byte, error) {
+	if metadata.Encryption == nil {
+		if customerKey(encryption) != nil {
+			return nil, fmt.Errorf("%w: the upload is not encrypted with a customer-provided key",
+				core.ErrSSEInvalidRequest)
+		}
+
+		return nil, nil
+	}
+
+	return b.dataKey(metadata.Encryption, customerKey(encryption))
 }
 
 func (b *Bucket) getObject(key string) (*Object, error) {
@@ -945,3 +1294,83 @@ func objectMetadata(input core.PutObjectInput, sha256 string) (core.ObjectMetada
 		Meta:         input.Metadata.Meta,
 	}, nil
 }
+
+// encryptedUploadChecksum sets the checksums of a completed encrypted upload to the ones of its plaintext, read
+// back from the blob. SSE-C uploads are completed without their key, they have none until they are first read
+// with it, see Object.SetCustomerKey.
+func (b *Bucket) encryptedUploadChecksum(ctx context.Context, metadata *core.ObjectMetadata,
+	blob io.ReadSeeker) error {
+	metadata.SHA256, metadata.SHA256Base64 = "", ""
+
+	if metadata.Encryption.IsCustomer() {
+		return nil
+	}
+
+	dataKey, err := b.dataKey(metadata.Encryption, nil)
+	if err != nil {
+		return err
+	}
+
+	return checksumPlaintext(ctx, blob, metadata, dataKey)
+}
+
+// copyBlob writes the plaintext of src to dst, encrypted with dataKey when it is set, and updates the checksum,
+// size and encryption of the metadata of the copy. It returns the checksum of the stored blob, empty when the copy
+// is encrypted.
+func copyBlob(ctx context.Context, src *Object, dst string, metadata *core.ObjectMetadata, dataKey []byte,
+	compress bool) (string, error) {
+	blobFile, err := createFileNoFollow(dst, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer blobFile.Close()
+
+	segment, err := writeBlob(ctx, blobFile, src, dataKey, compress)
+	if err != nil {
+		return "", err
+	}
+
+	rawSha256, err := hex.DecodeString(segment.sha256)
+	if err != nil {
+		return "", err
+	}
+
+	metadata.SHA256 = segment.sha256
+	metadata.SHA256Base64 = base64.StdEncoding.EncodeToString(rawSha256)
+	metadata.Size = segment.size
+
+	if segment.encrypted != nil {
+		metadata.Encryption.Segments = []core.EncryptedSegment{*segment.encrypted}
+
+		if err := sealChecksum(metadata, dataKey); err != nil {
+			return "", err
+		}
+	}
+
+	if segment.compressed != nil {
+		metadata.Compression, err = objectCompression(segment.compressed)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return segment.storedSHA256, nil
+}
+
+// encryptedUploadMetadata describes the blob of a completed encrypted upload: the encrypted parts, one segment
+// each. Its checksums are the ones of the encrypted blob until encryptedUploadChecksum replaces them.
+func encryptedUploadMetadata(metadata *core.ObjectMetadata, segments []*core.EncryptedSegment) error {
+	metadata.Size = 0
+	metadata.Encryption.Segments = make([]core.EncryptedSegment, 0, len(segments))
+
+	for _, segment := range segments {
+		if segment == nil {
+			return fmt.Errorf("%w: unencrypted part of an encrypted upload", core.ErrSSEInvalidRequest)
+		}
+
+		metadata.Size += segment.Size
+		metadata.Encryption.Segments = append(metadata.Encryption.Segments, *segment)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package folder
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/smartio"
+	"github.com/zhulik/d3/pkg/zstdseek"
+)
+
+// compresses tells whether new objects are compressed, the bucket override takes precedence over the backend
+// config.
+func (b *Bucket) compresses() bool {
+	algorithm := b.config.FolderStorageCompression
+	if b.compression != nil {
+		algorithm = b.compression.Algorithm
+	}
+
+	return algorithm == core.CompressionZstd
+}
+
+// objectCompression describes the compression of a blob made of segments, nil when none of them is compressed.
+func objectCompression(segments ...*core.CompressedSegment) (*core.ObjectCompression, error) {
+	compression := &core.ObjectCompression{
+		Algorithm: core.CompressionZstd,
+		Segments:  make([]core.CompressedSegment, 0, len(segments)),
+	}
+
+	for _, segment := range segments {
+		if segment == nil {
+			return nil, fmt.Errorf("%w: uncompressed part of a compressed upload", zstdseek.ErrCorrupted)
+		}
+
+		compression.Segments = append(compression.Segments, *segment)
+	}
+
+	if lo.EveryBy(compression.Segments, func(s core.CompressedSegment) bool { return s.Raw }) {
+		return nil, nil //nolint:nilnil
+	}
+
+	return compression, nil
+}
+
+// compressedUploadMetadata describes the blob of a completed compressed upload: the parts, one segment each.
+// Unless the blob is encrypted, it is read back to compute the checksum of the uncompressed content.
+func compressedUploadMetadata(ctx context.Context, metadata *core.ObjectMetadata, blob io.ReadSeeker,
+	segments []*core.CompressedSegment) error {
+	compression, err := objectCompression(segments...)
+	if err != nil {
+		return err
+	}
+
+	metadata.Compression = compression
+	if compression == nil {
+		return nil
+	}
+
+	metadata.Size = lo.SumBy(compression.Segments, func(s core.CompressedSegment) int64 { return s.Size })
+
+	if metadata.Encryption != nil {
+		return nil
+	}
+
+	reader, err := newDecompressingReader(blob, compression)
+	if err != nil {
+		return err
+	}
+
+	_, sha256sum, err := smartio.Copy(ctx, io.Discard, reader)
+	if err != nil {
+		return err
+	}
+
+	rawSha256, err := hex.DecodeString(sha256sum)
+	if err != nil {
+		return err
+	}
+
+	metadata.SHA256 = sha256sum
+	metadata.SHA256Base64 = base64.StdEncoding.EncodeToString(rawSha256)
+
+	return nil
+}
+
+// newDecompressingReader returns the uncompressed content of a compressed blob.
+func newDecompressingReader(blob io.ReadSeeker, compression *core.ObjectCompression) (*zstdseek.Reader, error) {
+	if compression.Algorithm != core.CompressionZstd {
+		return nil, fmt.Errorf("%w: unknown algorithm %q", core.ErrBucketCompressionInvalid, compression.Algorithm)
+	}
+
+	segments := lo.Map(compression.Segments, func(s core.CompressedSegment, _ int) zstdseek.Segment {
+		return zstdseek.Segment{Size: s.Size, StoredSize: s.StoredSize, Raw: s.Raw}
+	})
+
+	return zstdseek.NewReader(blob, segments)
+}
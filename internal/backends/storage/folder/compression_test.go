@@ -0,0 +1,133 @@
+package folder //nolint:testpackage
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/zstdseek"
+)
+
+var _ = Describe("Compression at rest", func() {
+	var (
+		tmpDir string
+		bucket core.Bucket
+		logs   []byte
+		random []byte
+	)
+
+	BeforeEach(func(ctx SpecContext) {
+		tmpDir = lo.Must(os.MkdirTemp("", "compression-*"))
+
+		DeferCleanup(func() { _ = os.RemoveAll(tmpDir) })
+
+		backend := &Backend{
+			Cfg:    &core.Config{FolderStorageBackendPath: tmpDir, FolderStorageCompression: core.CompressionZstd},
+			Locker: noopLocker{},
+		}
+		lo.Must0(backend.Init(ctx))
+		lo.Must0(backend.CreateBucket(ctx, "bucket"))
+		bucket = lo.Must(backend.HeadBucket(ctx, "bucket"))
+
+		buf := &bytes.Buffer{}
+		for i := 0; buf.Len() < 2*zstdseek.FrameSize; i++ {
+			fmt.Fprintf(buf, `{"line":%d,"level":"info","message":"request served"}`+"\n", i)
+		}
+
+		logs = buf.Bytes()
+		random = make([]byte, zstdseek.FrameSize+10)
+		lo.Must(rand.Read(random))
+	})
+
+	blobSize := func(key string) int64 {
+		return lo.Must(os.Stat(filepath.Join(tmpDir, bucketsFolder, "bucket", objectsFolder, key, blobFilename))).Size()
+	}
+
+	read := func(ctx SpecContext, key string) []byte {
+		object := lo.Must(bucket.GetObject(ctx, key))
+		defer object.Close()
+
+		return lo.Must(io.ReadAll(object))
+	}
+
+	checksum := func(content []byte) string {
+		sum := sha256.Sum256(content)
+
+		return hex.EncodeToString(sum[:])
+	}
+
+	It("compresses data that compresses well, keeping the uncompressed size and checksum", func(ctx SpecContext) {
+		lo.Must0(bucket.PutObject(ctx, "logs.json", core.PutObjectInput{Reader: bytes.NewReader(logs)}))
+
+		object := lo.Must(bucket.HeadObject(ctx, "logs.json"))
+		Expect(object.Size()).To(Equal(int64(len(logs))))
+		Expect(object.Metadata().SHA256).To(Equal(checksum(logs)))
+		Expect(object.Metadata().Compression.Algorithm).To(Equal(core.CompressionZstd))
+		Expect(blobSize("logs.json")).To(BeNumerically("<", len(logs)/5))
+
+		Expect(read(ctx, "logs.json")).To(Equal(logs))
+	})
+
+	It("stores data that does not compress well as is", func(ctx SpecContext) {
+		lo.Must0(bucket.PutObject(ctx, "random.bin", core.PutObjectInput{Reader: bytes.NewReader(random)}))
+
+		Expect(lo.Must(bucket.HeadObject(ctx, "random.bin")).Metadata().Compression).To(BeNil())
+		Expect(blobSize("random.bin")).To(Equal(int64(len(random))))
+	})
+
+	It("compresses multipart uploads part by part", func(ctx SpecContext) {
+		uploadID := lo.Must(bucket.CreateMultipartUpload(ctx, "mixed.bin", core.ObjectMetadata{}, nil))
+
+		first := lo.Must(bucket.UploadPart(ctx, "mixed.bin", uploadID, 1, bytes.NewReader(logs), nil))
+		second := lo.Must(bucket.UploadPart(ctx, "mixed.bin", uploadID, 2, bytes.NewReader(random), nil))
+
+		parts := lo.Must(bucket.ListParts(ctx, "mixed.bin", core.ListPartsInput{UploadID: uploadID}))
+		Expect(parts.Parts[0].Size).To(Equal(int64(len(logs))))
+
+		metadata := lo.Must(bucket.CompleteMultipartUpload(ctx, "mixed.bin", uploadID,
+			[]core.CompletePart{{PartNumber: 1, ETag: first}, {PartNumber: 2, ETag: second}}))
+
+		content := append(bytes.Clone(logs), random...)
+		Expect(metadata.Size).To(Equal(int64(len(content))))
+		Expect(metadata.SHA256).To(Equal(checksum(content)))
+		Expect(metadata.Compression.Segments).To(HaveLen(2))
+		Expect(metadata.Compression.Segments[1].Raw).To(BeTrue())
+
+		object := lo.Must(bucket.GetObject(ctx, "mixed.bin"))
+		defer object.Close()
+
+		lo.Must(object.Seek(int64(len(logs))-3, io.SeekStart))
+
+		buf := make([]byte, 6)
+		lo.Must(io.ReadFull(object, buf))
+		Expect(buf).To(Equal(content[len(logs)-3 : len(logs)+3]))
+	})
+
+	It("shares the compressed blob with copies", func(ctx SpecContext) {
+		lo.Must0(bucket.PutObject(ctx, "logs.json", core.PutObjectInput{Reader: bytes.NewReader(logs)}))
+
+		source := lo.Must(bucket.GetObject(ctx, "logs.json"))
+		defer source.Close()
+
+		result := lo.Must(bucket.CopyObject(ctx, "copy.json", core.CopyObjectInput{Source: source}))
+		Expect(result.Metadata.Compression).To(Equal(source.Metadata().Compression))
+
+		Expect(read(ctx, "copy.json")).To(Equal(logs))
+	})
+
+	It("stops compressing new objects when the bucket opts out", func(ctx SpecContext) {
+		lo.Must0(bucket.PutCompression(ctx, &core.BucketCompression{Algorithm: core.CompressionNone}))
+		lo.Must0(bucket.PutObject(ctx, "logs.json", core.PutObjectInput{Reader: bytes.NewReader(logs)}))
+
+		Expect(blobSize("logs.json")).To(Equal(int64(len(logs))))
+	})
+})
@@ -1,16 +1,19 @@
 package folder
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"path/filepath"
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/samber/lo"
 	"github.com/zhulik/d3/internal/core"
 )
 
 const (
-	configYamlFilename   = "d3.yaml"
+	ConfigYamlFilename   = "d3.yaml"
 	bucketsFolder        = "buckets"
 	objectsFolder        = "objects"
 	TmpFolder            = "tmp"
@@ -18,16 +21,28 @@ const (
 	regularUploadsFolder = "regular"
 	multipartFolder      = "multipart"
 	metadataYamlFilename = "metadata.yaml"
+	bucketYamlFilename   = "bucket.yaml"
+	usageYamlFilename    = "usage.yaml"
 	blobFilename         = "blob"
 	binFolder            = "bin"
+	contentFolder        = "blobs"
+	movesFolder          = "moves"
+	movingYamlFilename   = "moving.yaml"
 )
 
 type Config struct {
 	*core.Config
+
+	// Root is the storage root the paths are in, FolderStorageBackendPath when empty.
+	Root string
+}
+
+func (c *Config) root() string {
+	return lo.CoalesceOrEmpty(c.Root, c.FolderStorageBackendPath)
 }
 
 func (c *Config) bucketPath(bucket string) (string, error) {
-	path := filepath.Join(c.FolderStorageBackendPath, bucketsFolder, bucket)
+	path := filepath.Join(c.root(), bucketsFolder, bucket)
 
 	return path, EnsureContained(path, c.bucketsPath())
 }
@@ -45,8 +60,21 @@ func (c *Config) objectPath(bucket, key string) (string, error) {
 	return path, EnsureContained(path, objectsRoot)
 }
 
+func (c *Config) bucketMetadataPath(bucket string) string {
+	return filepath.Join(c.bucketsPath(), bucket, bucketYamlFilename)
+}
+
+func (c *Config) bucketUsagePath(bucket string) string {
+	return filepath.Join(c.bucketsPath(), bucket, usageYamlFilename)
+}
+
+// bucketMovingPath marks a bucket that is being moved to another root.
+func (c *Config) bucketMovingPath(bucket string) string {
+	return filepath.Join(c.bucketsPath(), bucket, movingYamlFilename)
+}
+
 func (c *Config) bucketsPath() string {
-	return filepath.Join(c.FolderStorageBackendPath, bucketsFolder)
+	return filepath.Join(c.root(), bucketsFolder)
 }
 
 func (c *Config) bucketUploadsPath(bucket string) (string, error) {
@@ -72,15 +100,33 @@ func (c *Config) multipartUploadsRoot(bucket string) (string, error) {
 }
 
 func (c *Config) binPath() string {
-	return filepath.Join(c.FolderStorageBackendPath, TmpFolder, binFolder)
+	return filepath.Join(c.root(), TmpFolder, binFolder)
 }
 
 func (c *Config) newBinPath() string {
 	return filepath.Join(c.binPath(), uuid.NewString())
 }
 
+// movesPath is where buckets moved to the root are copied to, before they are renamed into the buckets folder.
+func (c *Config) movesPath() string {
+	return filepath.Join(c.root(), TmpFolder, movesFolder)
+}
+
+func (c *Config) contentStorePath() string {
+	return filepath.Join(c.root(), contentFolder)
+}
+
+// contentPath is where the blob with the given checksum is kept in the content-addressed store.
+func (c *Config) contentPath(sha256sum string) (string, error) {
+	if _, err := hex.DecodeString(sha256sum); err != nil || len(sha256sum) != 2*sha256.Size {
+		return "", fmt.Errorf("%w: invalid blob checksum %q", core.ErrPathTraversal, sha256sum)
+	}
+
+	return filepath.Join(c.contentStorePath(), sha256sum[:2], sha256sum), nil
+}
+
 func (c *Config) configYamlPath() string {
-	return filepath.Join(c.FolderStorageBackendPath, configYamlFilename)
+	return filepath.Join(c.root(), ConfigYamlFilename)
 }
 
 func (c *Config) newUploadPath(bucket string) (string, error) {
@@ -92,17 +138,15 @@ func (c *Config) newUploadPath(bucket string) (string, error) {
 	return filepath.Join(uploadsRoot, regularUploadsFolder, uuid.NewString()), nil
 }
 
-func (c *Config) newMultipartUploadPath(bucket, key string) (string, string, error) {
+func (c *Config) newMultipartUploadPath(bucket, key, id string) (string, error) {
 	root, err := c.multipartUploadsRoot(bucket)
 	if err != nil {
-		return "", "", err
+		return "", err
 	}
 
-	id := uuid.NewString()
-
 	path := filepath.Join(root, key, id)
 
-	return id, path, EnsureContained(path, root)
+	return path, EnsureContained(path, root)
 }
 
 func EnsureContained(path, parent string) error {
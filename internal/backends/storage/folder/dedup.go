@@ -0,0 +1,188 @@
+//go:build unix
+
+package folder
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/yaml"
+)
+
+// The content-addressed store keeps one hard link to every shared blob under its checksum, objects sharing it hold
+// the other links. The link count is the reference count, it is only changed under the lock of the stored blob.
+
+// dedups tells whether new unencrypted blobs are shared through the content-addressed store.
+func (b *Bucket) dedups() bool {
+	return b.config.FolderStorageDedup
+}
+
+// storeBlob shares the blob at path, which must not be visible to readers yet, through the content-addressed store:
+// a new content is added to the store, a known one replaces the blob with a hard link to the stored copy. It tells
+// whether the blob is shared, it is not when the stored blob with the same checksum has a different size.
+func (b *Bucket) storeBlob(ctx context.Context, path, sha256sum string) (bool, error) {
+	contentPath, err := b.config.contentPath(sha256sum)
+	if err != nil {
+		return false, err
+	}
+
+	_, cancel, err := b.Locker.Lock(ctx, contentPath)
+	if err != nil {
+		return false, err
+	}
+	defer cancel()
+
+	if err := mkdirAllNoFollow(filepath.Dir(contentPath), 0755); err != nil {
+		return false, err
+	}
+
+	err = os.Link(path, contentPath)
+	if err == nil {
+		return true, nil
+	}
+
+	if !errors.Is(err, os.ErrExist) {
+		return false, err
+	}
+
+	stored, err := os.Lstat(contentPath)
+	if err != nil {
+		return false, err
+	}
+
+	blob, err := os.Lstat(path)
+	if err != nil {
+		return false, err
+	}
+
+	if !stored.Mode().IsRegular() || stored.Size() != blob.Size() {
+		return false, nil
+	}
+
+	sharedPath := path + ".shared"
+	if err := os.Link(contentPath, sharedPath); err != nil {
+		return false, err
+	}
+
+	if err := os.Rename(sharedPath, path); err != nil {
+		os.Remove(sharedPath)
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// sharedBlobSHA256 shares the blob at path like storeBlob, it returns the checksum to record in the metadata of the
+// object, empty when the blob is not shared.
+func (b *Bucket) sharedBlobSHA256(ctx context.Context, path, sha256sum string) (string, error) {
+	shared, err := b.storeBlob(ctx, path, sha256sum)
+	if err != nil || !shared {
+		return "", err
+	}
+
+	return sha256sum, nil
+}
+
+// releaseBlob drops the reference of a deleted object, moved to objectPath, to a blob of the content-addressed
+// store. The blob is removed from the store along with its last reference. Objects with unreadable metadata are
+// left alone, nothing tells whether they share their blob.
+func (b *Bucket) releaseBlob(ctx context.Context, objectPath string) error {
+	metadata, err := yaml.UnmarshalFromFile[core.ObjectMetadata](filepath.Join(objectPath, metadataYamlFilename))
+	if err != nil || metadata.BlobSHA256 == "" {
+		return nil //nolint:nilerr
+	}
+
+	contentPath, err := b.config.contentPath(metadata.BlobSHA256)
+	if err != nil {
+		return err
+	}
+
+	_, cancel, err := b.Locker.Lock(ctx, contentPath)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	if err := os.Remove(filepath.Join(objectPath, blobFilename)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	stored, err := os.Lstat(contentPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	if linkCount(stored) <= 1 {
+		return os.Remove(contentPath)
+	}
+
+	return nil
+}
+
+// DedupStats sums the content-addressed stores of all storage roots up, every root has a store of its own.
+func (b *Backend) DedupStats(ctx context.Context) (*core.DedupStats, error) {
+	stats := &core.DedupStats{}
+
+	for _, root := range b.roots {
+		if err := addDedupStats(ctx, stats, root); err != nil {
+			return nil, err
+		}
+	}
+
+	return stats, nil
+}
+
+func addDedupStats(ctx context.Context, stats *core.DedupStats, root *Config) error {
+	return filepath.WalkDir(root.contentStorePath(), func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		// The link of the store itself is not a reference.
+		references := int64(linkCount(info)) - 1 //nolint:gosec
+
+		stats.Blobs++
+		stats.References += references
+		stats.StoredBytes += info.Size()
+		stats.ReferencedBytes += references * info.Size()
+		stats.SavedBytes += max(references-1, 0) * info.Size()
+
+		return nil
+	})
+}
+
+func linkCount(info os.FileInfo) uint64 {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 1
+	}
+
+	return uint64(stat.Nlink) //nolint:unconvert // Nlink is narrower on some platforms
+}
@@ -0,0 +1,128 @@
+package folder //nolint:testpackage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+)
+
+var _ = Describe("Deduplication", func() {
+	var (
+		tmpDir  string
+		backend *Backend
+		bucket  core.Bucket
+	)
+
+	content := bytes.Repeat([]byte("deduplicated content "), 1_000)
+
+	newBackend := func(ctx SpecContext, cfg *core.Config) {
+		cfg.FolderStorageBackendPath = tmpDir
+
+		backend = &Backend{Cfg: cfg, Locker: noopLocker{}}
+		lo.Must0(backend.Init(ctx))
+
+		if err := backend.CreateBucket(ctx, "bucket"); !errors.Is(err, core.ErrBucketAlreadyExists) {
+			lo.Must0(err)
+		}
+
+		bucket = lo.Must(backend.HeadBucket(ctx, "bucket"))
+	}
+
+	BeforeEach(func(ctx SpecContext) {
+		tmpDir = lo.Must(os.MkdirTemp("", "dedup-*"))
+
+		DeferCleanup(func() { _ = os.RemoveAll(tmpDir) })
+
+		newBackend(ctx, &core.Config{FolderStorageDedup: true})
+	})
+
+	blob := func(key string) os.FileInfo {
+		return lo.Must(os.Stat(filepath.Join(tmpDir, bucketsFolder, "bucket", objectsFolder, key, blobFilename)))
+	}
+
+	put := func(ctx SpecContext, key string, content []byte) {
+		lo.Must0(bucket.PutObject(ctx, key, core.PutObjectInput{Reader: bytes.NewReader(content)}))
+	}
+
+	It("stores identical objects once", func(ctx SpecContext) {
+		put(ctx, "first.txt", content)
+		put(ctx, "second.txt", content)
+		put(ctx, "other.txt", []byte("other content"))
+
+		Expect(os.SameFile(blob("first.txt"), blob("second.txt"))).To(BeTrue())
+
+		stats := lo.Must(backend.DedupStats(ctx))
+		Expect(*stats).To(Equal(core.DedupStats{
+			Blobs:           2,
+			References:      3,
+			StoredBytes:     int64(len(content) + len("other content")),
+			ReferencedBytes: int64(2*len(content) + len("other content")),
+			SavedBytes:      int64(len(content)),
+		}))
+	})
+
+	It("removes a blob from the store with its last reference", func(ctx SpecContext) {
+		put(ctx, "first.txt", content)
+		put(ctx, "second.txt", content)
+
+		lo.Must(bucket.DeleteObjects(ctx, core.DeleteObjectsInput{Keys: []string{"first.txt"}}))
+		Expect(lo.Must(backend.DedupStats(ctx)).References).To(Equal(int64(1)))
+
+		put(ctx, "second.txt", []byte("overwritten"))
+		Expect(lo.Must(backend.DedupStats(ctx)).Blobs).To(Equal(int64(1)))
+
+		lo.Must(bucket.DeleteObjects(ctx, core.DeleteObjectsInput{Keys: []string{"second.txt"}}))
+		Expect(*lo.Must(backend.DedupStats(ctx))).To(BeZero())
+	})
+
+	It("shares the blob of completed multipart uploads and copies", func(ctx SpecContext) {
+		put(ctx, "single.txt", content)
+
+		uploadID := lo.Must(bucket.CreateMultipartUpload(ctx, "multipart.txt", core.ObjectMetadata{}, nil))
+		etag := lo.Must(bucket.UploadPart(ctx, "multipart.txt", uploadID, 1, bytes.NewReader(content), nil))
+		lo.Must(bucket.CompleteMultipartUpload(ctx, "multipart.txt", uploadID,
+			[]core.CompletePart{{PartNumber: 1, ETag: etag}}))
+
+		source := lo.Must(bucket.HeadObject(ctx, "multipart.txt"))
+		lo.Must(bucket.CopyObject(ctx, "copy.txt", core.CopyObjectInput{Source: source}))
+
+		Expect(os.SameFile(blob("single.txt"), blob("multipart.txt"))).To(BeTrue())
+		Expect(os.SameFile(blob("single.txt"), blob("copy.txt"))).To(BeTrue())
+		Expect(lo.Must(backend.DedupStats(ctx)).References).To(Equal(int64(3)))
+	})
+
+	It("shares compressed blobs", func(ctx SpecContext) {
+		newBackend(ctx, &core.Config{FolderStorageDedup: true, FolderStorageCompression: core.CompressionZstd})
+
+		put(ctx, "first.txt", content)
+		put(ctx, "second.txt", content)
+
+		Expect(os.SameFile(blob("first.txt"), blob("second.txt"))).To(BeTrue())
+		Expect(blob("first.txt").Size()).To(BeNumerically("<", len(content)))
+
+		object := lo.Must(bucket.GetObject(ctx, "second.txt"))
+		defer object.Close()
+
+		Expect(object.Metadata().Compression).NotTo(BeNil())
+		Expect(lo.Must(io.ReadAll(object))).To(Equal(content))
+	})
+
+	It("does not share blobs while disabled, releasing the shared ones", func(ctx SpecContext) {
+		put(ctx, "shared.txt", content)
+
+		newBackend(ctx, &core.Config{})
+
+		put(ctx, "private.txt", content)
+		Expect(os.SameFile(blob("shared.txt"), blob("private.txt"))).To(BeFalse())
+
+		lo.Must(bucket.DeleteObjects(ctx, core.DeleteObjectsInput{Keys: []string{"shared.txt"}}))
+		Expect(*lo.Must(backend.DedupStats(ctx))).To(BeZero())
+	})
+})
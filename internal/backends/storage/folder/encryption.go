@@ -0,0 +1,218 @@
+package folder
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/smartio"
+	"github.com/zhulik/d3/pkg/sse"
+	"github.com/zhulik/d3/pkg/yaml"
+)
+
+// loadKeyring loads the SSE-S3 master keys, it returns nil when none are configured.
+func loadKeyring(cfg *core.Config) (*sse.Keyring, error) {
+	keys := cfg.SSEMasterKey
+
+	if cfg.SSEMasterKeyFile != "" {
+		data, err := os.ReadFile(cfg.SSEMasterKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to read SSEMasterKeyFile: %w", core.ErrInvalidConfig, err)
+		}
+
+		keys = string(data)
+	}
+
+	if keys == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	keyring, err := sse.ParseKeyring(keys)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", core.ErrInvalidConfig, err)
+	}
+
+	return keyring, nil
+}
+
+// newEncryption resolves the encryption of a new object, the requested one or the default of the bucket. It
+// returns the description to store along with the object and its data key, or nils when it is not encrypted.
+func (b *Bucket) newEncryption(requested *core.ServerSideEncryption) (*core.ObjectEncryption, []byte, error) {
+	if requested == nil {
+		if b.encryption == nil {
+			return nil, nil, nil
+		}
+
+		requested = &core.ServerSideEncryption{}
+	}
+
+	encryption := &core.ObjectEncryption{}
+
+	var kek []byte
+
+	if requested.CustomerKey != nil {
+		kek = requested.CustomerKey
+		encryption.CustomerKeyMD5 = requested.CustomerKeyMD5()
+	} else {
+		if b.keyring == nil {
+			return nil, nil, core.ErrSSENotConfigured
+		}
+
+		encryption.MasterKeyID, kek = b.keyring.Current()
+	}
+
+	dataKey, err := sse.NewKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryption.WrappedKey, err = sse.WrapKey(kek, dataKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", core.ErrSSEInvalidRequest, err)
+	}
+
+	return encryption, dataKey, nil
+}
+
+// dataKey unwraps the data key of an encrypted object or upload, customerKey is required for SSE-C.
+func (b *Bucket) dataKey(encryption *core.ObjectEncryption, customerKey []byte) ([]byte, error) {
+	if encryption.IsCustomer() {
+		if err := checkCustomerKey(encryption, customerKey); err != nil {
+			return nil, err
+		}
+
+		return sse.UnwrapKey(customerKey, encryption.WrappedKey)
+	}
+
+	if b.keyring == nil {
+		return nil, core.ErrSSENotConfigured
+	}
+
+	kek, ok := b.keyring.Key(encryption.MasterKeyID)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown master key %q", core.ErrSSENotConfigured, encryption.MasterKeyID)
+	}
+
+	return sse.UnwrapKey(kek, encryption.WrappedKey)
+}
+
+func checkCustomerKey(encryption *core.ObjectEncryption, customerKey []byte) error {
+	if customerKey == nil {
+		return core.ErrSSECustomerKeyRequired
+	}
+
+	if (&core.ServerSideEncryption{CustomerKey: customerKey}).CustomerKeyMD5() != encryption.CustomerKeyMD5 {
+		return core.ErrSSECustomerKeyMismatch
+	}
+
+	return nil
+}
+
+// customerKey returns the customer key of a request, nil for SSE-S3 and unencrypted requests.
+func customerKey(encryption *core.ServerSideEncryption) []byte {
+	if encryption == nil {
+		return nil
+	}
+
+	return encryption.CustomerKey
+}
+
+// newDecryptingReader returns the plaintext of an encrypted blob.
+func newDecryptingReader(blob io.ReadSeeker, key []byte, encryption *core.ObjectEncryption) (*sse.Reader, error) {
+	segments := make([]sse.Segment, 0, len(encryption.Segments))
+
+	for _, segment := range encryption.Segments {
+		nonce, err := base64.StdEncoding.DecodeString(segment.Nonce)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", sse.ErrInvalidNonce, err)
+		}
+
+		segments = append(segments, sse.Segment{Nonce: nonce, Size: segment.Size})
+	}
+
+	return sse.NewReader(blob, key, segments)
+}
+
+// sealChecksum seals the checksum of the plaintext of an encrypted object with its data key. writeObjectMetadata
+// leaves the checksums themselves out of the metadata file.
+func sealChecksum(metadata *core.ObjectMetadata, dataKey []byte) error {
+	rawSha256, err := hex.DecodeString(metadata.SHA256)
+	if err != nil {
+		return err
+	}
+
+	metadata.Encryption.SHA256, err = sse.Seal(dataKey, rawSha256)
+
+	return err
+}
+
+// openChecksum sets the checksums of an encrypted object from the one sealed with its data key.
+func openChecksum(metadata *core.ObjectMetadata, dataKey []byte) error {
+	rawSha256, err := sse.Open(dataKey, metadata.Encryption.SHA256)
+	if err != nil {
+		return err
+	}
+
+	metadata.SHA256 = hex.EncodeToString(rawSha256)
+	metadata.SHA256Base64 = base64.StdEncoding.EncodeToString(rawSha256)
+
+	return nil
+}
+
+// checksumPlaintext sets and seals the checksums of an encrypted object by reading its plaintext through. The
+// parts of multipart uploads are encrypted separately, the checksum of the whole object is only known this way.
+func checksumPlaintext(ctx context.Context, blob io.ReadSeeker, metadata *core.ObjectMetadata, dataKey []byte) error {
+	decrypting, err := newDecryptingReader(blob, dataKey, metadata.Encryption)
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader = decrypting
+
+	if metadata.Compression != nil {
+		reader, err = newDecompressingReader(decrypting, metadata.Compression)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, sha256sum, err := smartio.Copy(ctx, io.Discard, reader)
+	if err != nil {
+		return err
+	}
+
+	rawSha256, err := hex.DecodeString(sha256sum)
+	if err != nil {
+		return err
+	}
+
+	metadata.SHA256 = sha256sum
+	metadata.SHA256Base64 = base64.StdEncoding.EncodeToString(rawSha256)
+
+	return sealChecksum(metadata, dataKey)
+}
+
+// encryptedPartETag is the ETag of a part of an encrypted upload. The checksum of the plaintext of the part would
+// reveal it, it is keyed with the data key of the upload instead.
+func encryptedPartETag(dataKey []byte, sha256sum string) string {
+	mac := hmac.New(sha256.New, dataKey)
+	mac.Write([]byte(sha256sum))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// writeObjectMetadata writes the metadata file of an object. The checksums of encrypted objects are left out, they
+// are only stored sealed, see sealChecksum.
+func writeObjectMetadata(metadata core.ObjectMetadata, path string) error {
+	if metadata.Encryption != nil {
+		metadata.SHA256, metadata.SHA256Base64 = "", ""
+	}
+
+	return yaml.MarshalToFile(metadata, path)
+}
@@ -0,0 +1,147 @@
+package folder //nolint:testpackage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/sse"
+)
+
+var _ = Describe("Encryption at rest", func() {
+	var (
+		tmpDir     string
+		oldKey     string
+		newBackend func(ctx SpecContext, masterKeys string) *Backend
+	)
+
+	BeforeEach(func() {
+		tmpDir = lo.Must(os.MkdirTemp("", "encryption-*"))
+
+		DeferCleanup(func() { _ = os.RemoveAll(tmpDir) })
+
+		oldKey = "old:" + base64.StdEncoding.EncodeToString(lo.Must(sse.NewKey()))
+
+		newBackend = func(ctx SpecContext, masterKeys string) *Backend {
+			backend := &Backend{
+				Cfg:    &core.Config{FolderStorageBackendPath: tmpDir, SSEMasterKey: masterKeys},
+				Locker: noopLocker{},
+			}
+
+			lo.Must0(backend.Init(ctx))
+
+			return backend
+		}
+	})
+
+	putEncrypted := func(ctx SpecContext, backend *Backend, content []byte) {
+		lo.Must0(backend.CreateBucket(ctx, "bucket"))
+		bucket := lo.Must(backend.HeadBucket(ctx, "bucket"))
+
+		lo.Must0(bucket.PutObject(ctx, "secret.txt", core.PutObjectInput{
+			Reader:     bytes.NewReader(content),
+			Encryption: &core.ServerSideEncryption{},
+		}))
+	}
+
+	readSecret := func(ctx SpecContext, backend *Backend) ([]byte, error) {
+		object := lo.Must(lo.Must(backend.HeadBucket(ctx, "bucket")).GetObject(ctx, "secret.txt"))
+		defer object.Close()
+
+		return io.ReadAll(object)
+	}
+
+	It("does not store the plaintext", func(ctx SpecContext) {
+		content := []byte("top secret content")
+		putEncrypted(ctx, newBackend(ctx, oldKey), content)
+
+		blob := lo.Must(os.ReadFile(filepath.Join(tmpDir, bucketsFolder, "bucket", objectsFolder, "secret.txt",
+			blobFilename)))
+		Expect(blob).NotTo(ContainSubstring(string(content)))
+		Expect(blob).To(HaveLen(int(sse.EncryptedSize(int64(len(content))))))
+	})
+
+	It("does not store the checksum of the plaintext", func(ctx SpecContext) {
+		content := []byte("top secret content")
+		putEncrypted(ctx, newBackend(ctx, oldKey), content)
+
+		sum := sha256.Sum256(content)
+		metadata := lo.Must(os.ReadFile(filepath.Join(tmpDir, bucketsFolder, "bucket", objectsFolder, "secret.txt",
+			metadataYamlFilename)))
+		Expect(metadata).NotTo(ContainSubstring(hex.EncodeToString(sum[:])))
+		Expect(metadata).NotTo(ContainSubstring(base64.StdEncoding.EncodeToString(sum[:])))
+
+		object := lo.Must(lo.Must(newBackend(ctx, oldKey).HeadBucket(ctx, "bucket")).HeadObject(ctx, "secret.txt"))
+		Expect(object.Metadata().SHA256).To(Equal(hex.EncodeToString(sum[:])))
+	})
+
+	When("an SSE-C multipart upload is completed", func() {
+		It("stores the checksum of the plaintext the first time it is read with the key", func(ctx SpecContext) {
+			backend := newBackend(ctx, oldKey)
+			lo.Must0(backend.CreateBucket(ctx, "bucket"))
+			bucket := lo.Must(backend.HeadBucket(ctx, "bucket"))
+
+			sseC := &core.ServerSideEncryption{CustomerKey: lo.Must(sse.NewKey())}
+			uploadID := lo.Must(bucket.CreateMultipartUpload(ctx, "secret.txt", core.ObjectMetadata{}, sseC))
+			etag := lo.Must(bucket.UploadPart(ctx, "secret.txt", uploadID, 1, bytes.NewReader([]byte("content")), sseC))
+			lo.Must(bucket.CompleteMultipartUpload(ctx, "secret.txt", uploadID,
+				[]core.CompletePart{{PartNumber: 1, ETag: etag}}))
+
+			sealedChecksum := func() string {
+				object := lo.Must(bucket.HeadObject(ctx, "secret.txt"))
+				defer object.Close()
+
+				return object.Metadata().Encryption.SHA256
+			}
+
+			Expect(sealedChecksum()).To(BeEmpty())
+
+			object := lo.Must(bucket.GetObject(ctx, "secret.txt"))
+			defer object.Close()
+
+			sum := sha256.Sum256([]byte("content"))
+			Expect(object.SetCustomerKey(sseC.CustomerKey)).To(Succeed())
+			Expect(object.Metadata().SHA256).To(Equal(hex.EncodeToString(sum[:])))
+
+			Expect(sealedChecksum()).NotTo(BeEmpty())
+		})
+	})
+
+	When("the master key is rotated", func() {
+		It("reads objects wrapped with the previous key while it is kept", func(ctx SpecContext) {
+			putEncrypted(ctx, newBackend(ctx, oldKey), []byte("content"))
+
+			newKey := "new:" + base64.StdEncoding.EncodeToString(lo.Must(sse.NewKey()))
+
+			Expect(readSecret(ctx, newBackend(ctx, newKey+"\n"+oldKey))).To(Equal([]byte("content")))
+
+			_, err := readSecret(ctx, newBackend(ctx, newKey))
+			Expect(err).To(MatchError(core.ErrSSENotConfigured))
+		})
+	})
+
+	When("no master key is configured", func() {
+		It("refuses SSE-S3 and bucket default encryption", func(ctx SpecContext) {
+			backend := newBackend(ctx, "")
+			lo.Must0(backend.CreateBucket(ctx, "bucket"))
+			bucket := lo.Must(backend.HeadBucket(ctx, "bucket"))
+
+			err := bucket.PutObject(ctx, "secret.txt", core.PutObjectInput{
+				Reader:     bytes.NewReader([]byte("content")),
+				Encryption: &core.ServerSideEncryption{},
+			})
+			Expect(err).To(MatchError(core.ErrSSENotConfigured))
+
+			err = bucket.PutEncryption(ctx, &core.BucketEncryption{Algorithm: core.SSEAlgorithmAES256})
+			Expect(err).To(MatchError(core.ErrSSENotConfigured))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package folder
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -21,6 +22,8 @@ type Object struct {
 	bucket   *Bucket
 	path     string
 	metadata *core.ObjectMetadata
+
+	customerKey []byte
 }
 
 func (o *Object) Key() string {
@@ -77,33 +80,42 @@ func ObjectFromPath(bucket *Bucket, key string) (*Object, error) {
 }
 
 func (o *Object) Read(p []byte) (int, error) {
-	if o.ReadSeekCloser == nil {
-		blobPath := filepath.Join(o.path, blobFilename)
-
-		rc, err := openFileNoFollow(blobPath)
-		if err != nil {
-			return 0, err
-		}
-
-		o.ReadSeekCloser = rc
+	if err := o.open(); err != nil {
+		return 0, err
 	}
 
 	return o.ReadSeekCloser.Read(p)
 }
 
 func (o *Object) Seek(offset int64, whence int) (int64, error) {
-	if o.ReadSeekCloser == nil {
-		blobPath := filepath.Join(o.path, blobFilename)
+	if err := o.open(); err != nil {
+		return 0, err
+	}
 
-		rc, err := openFileNoFollow(blobPath)
-		if err != nil {
-			return 0, err
-		}
+	return o.ReadSeekCloser.Seek(offset, whence)
+}
 
-		o.ReadSeekCloser = rc
+// open lazily opens the blob, decrypting and decompressing it as needed.
+func (o *Object) open() error {
+	if o.ReadSeekCloser != nil {
+		return nil
 	}
 
-	return o.ReadSeekCloser.Seek(offset, whence)
+	blob, err := openFileNoFollow(filepath.Join(o.path, blobFilename))
+	if err != nil {
+		return err
+	}
+
+	reader, err := o.bucket.openBlob(blob, o.Metadata(), o.customerKey)
+	if err != nil {
+		blob.Close()
+
+		return err
+	}
+
+	o.ReadSeekCloser = reader
+
+	return nil
 }
 
 func (o *Object) Close() error {
@@ -115,24 +127,99 @@ func (o *Object) Close() error {
 }
 
 func (o *Object) Metadata() *core.ObjectMetadata {
+	return lo.Must(o.LoadMetadata())
+}
+
+// LoadMetadata returns the metadata of the object like Metadata, failing instead of panicking when it is not
+// readable.
+func (o *Object) LoadMetadata() (*core.ObjectMetadata, error) {
 	if o.metadata == nil {
-		metadata := lo.Must(yaml.UnmarshalFromFile[core.ObjectMetadata](filepath.Join(o.path, metadataYamlFilename)))
+		metadata, err := yaml.UnmarshalFromFile[core.ObjectMetadata](filepath.Join(o.path, metadataYamlFilename))
+		if err != nil {
+			return nil, err
+		}
+
+		// The checksums of SSE-S3 objects are unsealed right away, the ones of SSE-C objects once their key is
+		// provided. Objects whose master key is unknown are left without them, they cannot be read anyway.
+		if encryption := metadata.Encryption; encryption != nil && !encryption.IsCustomer() {
+			if dataKey, err := o.bucket.dataKey(encryption, nil); err == nil {
+				_ = openChecksum(&metadata, dataKey)
+			}
+		}
+
 		o.metadata = &metadata
 	}
 
-	return o.metadata
+	return o.metadata, nil
 }
 
-func (o *Object) Delete() error {
+func (o *Object) SetCustomerKey(key []byte) error {
+	encryption := o.Metadata().Encryption
+	if encryption == nil || !encryption.IsCustomer() {
+		return fmt.Errorf("%w: the object is not encrypted with a customer-provided key", core.ErrSSEInvalidRequest)
+	}
+
+	if err := checkCustomerKey(encryption, key); err != nil {
+		return err
+	}
+
+	o.customerKey = key
+
+	return o.openCustomerChecksum()
+}
+
+// openCustomerChecksum unseals the checksums of an SSE-C object with its data key. SSE-C multipart uploads are
+// completed without the key, their checksum is computed and stored the first time the key is provided.
+func (o *Object) openCustomerChecksum() error {
+	metadata := o.Metadata()
+
+	dataKey, err := o.bucket.dataKey(metadata.Encryption, o.customerKey)
+	if err != nil {
+		return err
+	}
+
+	if metadata.Encryption.SHA256 != "" {
+		return openChecksum(metadata, dataKey)
+	}
+
+	blob, err := openFileNoFollow(filepath.Join(o.path, blobFilename))
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	ctx := context.Background()
+
+	if err := checksumPlaintext(ctx, blob, metadata, dataKey); err != nil {
+		return err
+	}
+
+	return o.bucket.updateObjectMetadata(ctx, o.key, func(stored *core.ObjectMetadata) error {
+		// The object may have been replaced in the meantime.
+		if stored.Encryption != nil && stored.Encryption.WrappedKey == metadata.Encryption.WrappedKey {
+			stored.Encryption.SHA256 = metadata.Encryption.SHA256
+		}
+
+		return nil
+	})
+}
+
+func (o *Object) Delete(ctx context.Context) error {
 	if err := rejectSymlink(o.path); err != nil {
 		return err
 	}
 
-	err := renameNoFollow(o.path, o.bucket.config.newBinPath())
+	binPath := o.bucket.config.newBinPath()
+
+	err := renameNoFollow(o.path, binPath)
 	if err != nil {
 		return err
 	}
 
+	if err := o.bucket.releaseBlob(ctx, binPath); err != nil {
+		return err
+	}
+
 	root, err := o.bucket.rootPath()
 	if err != nil {
 		return err
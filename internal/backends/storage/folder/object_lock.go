@@ -0,0 +1,123 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zhulik/d3/internal/core"
+)
+
+func (b *Bucket) ObjectLock() *core.BucketObjectLock {
+	return b.objectLock
+}
+
+func (b *Bucket) PutObjectLock(ctx context.Context, lock core.BucketObjectLock) error {
+	if err := lock.Validate(); err != nil {
+		return err
+	}
+
+	err := b.updateMetadata(ctx, func(metadata *bucketMetadata) { metadata.ObjectLock = &lock })
+	if err != nil {
+		return err
+	}
+
+	b.objectLock = &lock
+
+	return nil
+}
+
+func (b *Bucket) PutObjectRetention(ctx context.Context, key string, retention *core.ObjectRetention,
+	bypassGovernance bool) error {
+	if b.objectLock == nil {
+		return core.ErrObjectLockNotEnabled
+	}
+
+	now := time.Now()
+
+	if retention != nil {
+		if err := validateRetention(retention, now); err != nil {
+			return err
+		}
+	}
+
+	return b.updateObjectMetadata(ctx, key, func(metadata *core.ObjectMetadata) error {
+		current := metadata.Retention
+
+		if current.Active(now) && !extendsRetention(current, retention) {
+			if current.Mode == core.ObjectLockModeCompliance || !bypassGovernance {
+				return fmt.Errorf("%w: the retention can only be extended", core.ErrObjectLocked)
+			}
+		}
+
+		metadata.Retention = retention
+
+		return nil
+	})
+}
+
+func (b *Bucket) PutObjectLegalHold(ctx context.Context, key string, hold bool) error {
+	if b.objectLock == nil {
+		return core.ErrObjectLockNotEnabled
+	}
+
+	return b.updateObjectMetadata(ctx, key, func(metadata *core.ObjectMetadata) error {
+		metadata.LegalHold = hold
+
+		return nil
+	})
+}
+
+// newRetention returns the retention of an object written now: the one requested for it, or the default retention
+// of the bucket. Object Lock must be enabled for the bucket to request a retention or a legal hold.
+func (b *Bucket) newRetention(retention *core.ObjectRetention, legalHold bool,
+	now time.Time) (*core.ObjectRetention, error) {
+	if b.objectLock == nil {
+		if retention != nil || legalHold {
+			return nil, core.ErrObjectLockNotEnabled
+		}
+
+		return nil, nil //nolint:nilnil
+	}
+
+	if retention != nil {
+		return retention, validateRetention(retention, now)
+	}
+
+	if b.objectLock.DefaultRetention != nil {
+		return b.objectLock.DefaultRetention.Retention(now), nil
+	}
+
+	return nil, nil //nolint:nilnil
+}
+
+// checkOverwrite returns ErrObjectLocked when the object at key exists and may not be overwritten.
+func (b *Bucket) checkOverwrite(key string, bypassGovernance bool) error {
+	existing, err := ObjectFromPath(b, key)
+	if err != nil || existing == nil {
+		return nil //nolint:nilerr // there is nothing to overwrite
+	}
+
+	return existing.Metadata().CheckLock(time.Now(), bypassGovernance)
+}
+
+func validateRetention(retention *core.ObjectRetention, now time.Time) error {
+	if err := retention.Mode.Validate(); err != nil {
+		return err
+	}
+
+	if !retention.RetainUntil.After(now) {
+		return fmt.Errorf("%w: the retain until date must be in the future", core.ErrObjectLockInvalid)
+	}
+
+	return nil
+}
+
+// extendsRetention tells whether retention protects the object at least as long and as strictly as current.
+func extendsRetention(current, retention *core.ObjectRetention) bool {
+	if retention == nil || retention.RetainUntil.Before(current.RetainUntil) {
+		return false
+	}
+
+	return retention.Mode == current.Mode || retention.Mode == core.ObjectLockModeCompliance
+}
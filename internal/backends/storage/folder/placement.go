@@ -0,0 +1,473 @@
+//go:build unix
+
+package folder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/smartio"
+	"github.com/zhulik/d3/pkg/yaml"
+	"golang.org/x/sys/unix"
+)
+
+// A bucket is stored on one of the storage roots. Moving it copies it to a staging directory of the target root
+// while holding its usage lock, so no change to it can commit in the meantime, and marks it with moving.yaml so
+// changes fail fast instead of waiting. The copy is then renamed into the buckets folder of the target, which owns
+// the bucket from then on, and the bucket is removed from the root it was moved from.
+
+var partFilenameRegexp = regexp.MustCompile(`^part-\d+$`) //nolint:gochecknoglobals
+
+type movingYaml struct {
+	To string `yaml:"to"`
+}
+
+// bucketLockKey is held while a bucket is created or moved.
+func bucketLockKey(name string) string {
+	return "folder-storage-bucket:" + name
+}
+
+// bucketConfig returns the config of the root owning the bucket.
+func (b *Backend) bucketConfig(name string) (*Config, error) {
+	return owningRoot(b.roots, name)
+}
+
+// owningRoot returns the root the bucket is stored on. A bucket that is being moved is on two roots, the marked one
+// only owns it until the copy is renamed into the other. A bucket without bucket.yaml, created before bucket
+// metadata support or recreated by a write racing a move, only owns it when no other root has one.
+func owningRoot(roots []*Config, name string) (*Config, error) {
+	var (
+		owner     *Config
+		ownerRank int
+	)
+
+	for _, root := range roots {
+		rank, err := bucketRank(root, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if rank > ownerRank {
+			owner, ownerRank = root, rank
+		}
+	}
+
+	if owner == nil {
+		return nil, core.ErrBucketNotFound
+	}
+
+	return owner, nil
+}
+
+// bucketRank ranks the bucket directories of the roots: 0 when there is none, 1 when it is marked as being moved,
+// 2 without bucket.yaml and 3 otherwise.
+func bucketRank(root *Config, name string) (int, error) {
+	path, err := root.bucketPath(name)
+	if err != nil {
+		return 0, err
+	}
+
+	exists, err := lstatExists(path)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	moving, err := lstatExists(root.bucketMovingPath(name))
+	if err != nil {
+		return 0, err
+	}
+
+	if moving {
+		return 1, nil
+	}
+
+	hasMetadata, err := lstatExists(root.bucketMetadataPath(name))
+	if err != nil {
+		return 0, err
+	}
+
+	return lo.Ternary(hasMetadata, 3, 2), nil //nolint:mnd
+}
+
+func lstatExists(path string) (bool, error) {
+	_, err := os.Lstat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// checkNotMoving returns ErrBucketMoving while the bucket is moved to another root, and once it is moved, so the
+// client retries the change on the new root. It returns ErrBucketNotFound when the bucket is deleted.
+func (b *Bucket) checkNotMoving() error {
+	moving, err := lstatExists(b.config.bucketMovingPath(b.name))
+	if err != nil {
+		return err
+	}
+
+	if moving {
+		return core.ErrBucketMoving
+	}
+
+	owner, err := owningRoot(lo.CoalesceSliceOrEmpty(b.roots, []*Config{b.config}), b.name)
+	if err != nil {
+		return err
+	}
+
+	if owner != b.config {
+		return core.ErrBucketMoving
+	}
+
+	return nil
+}
+
+// placeBucket picks the root of a new bucket.
+func (b *Backend) placeBucket(name string) (*Config, error) {
+	if path, ok := b.Cfg.FolderStorageBucketRoots[name]; ok {
+		return b.findRoot(path)
+	}
+
+	if len(b.roots) == 1 {
+		return b.roots[0], nil
+	}
+
+	if b.Cfg.FolderStoragePlacement == core.PlacementRoundRobin {
+		return b.roots[(b.nextRoot.Add(1)-1)%uint64(len(b.roots))], nil
+	}
+
+	var (
+		placed   *Config
+		mostFree uint64
+	)
+
+	for _, root := range b.roots {
+		_, free, err := diskSpace(root)
+		if err != nil {
+			return nil, err
+		}
+
+		if placed == nil || free > mostFree {
+			placed, mostFree = root, free
+		}
+	}
+
+	return placed, nil
+}
+
+func (b *Backend) findRoot(path string) (*Config, error) {
+	root, ok := lo.Find(b.roots, func(root *Config) bool { return root.root() == filepath.Clean(path) })
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", core.ErrStorageRootNotFound, path)
+	}
+
+	return root, nil
+}
+
+// diskSpace returns the size of the filesystem of the root and the space available to unprivileged users on it.
+func diskSpace(root *Config) (uint64, uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(root.root(), &stat); err != nil {
+		return 0, 0, err
+	}
+
+	blockSize := uint64(stat.Bsize) //nolint:gosec,unconvert // Bsize is signed on some platforms
+
+	return stat.Blocks * blockSize, stat.Bavail * blockSize, nil
+}
+
+func (b *Backend) StorageRoots(ctx context.Context) ([]core.StorageRoot, error) {
+	buckets, err := b.ListBuckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := make([]core.StorageRoot, 0, len(b.roots))
+
+	for _, root := range b.roots {
+		total, free, err := diskSpace(root)
+		if err != nil {
+			return nil, err
+		}
+
+		names := lo.FilterMap(buckets, func(bucket core.Bucket, _ int) (string, bool) {
+			return bucket.Name(), bucket.(*Bucket).config == root //nolint:forcetypeassert
+		})
+
+		roots = append(roots, core.StorageRoot{Path: root.root(), TotalBytes: total, FreeBytes: free, Buckets: names})
+	}
+
+	return roots, nil
+}
+
+// MoveBucket moves the bucket to the root at path. Moving a bucket to the root it is on clears the mark left by an
+// interrupted move.
+func (b *Backend) MoveBucket(ctx context.Context, name, path string) error {
+	target, err := b.findRoot(path)
+	if err != nil {
+		return err
+	}
+
+	_, cancel, err := b.Locker.Lock(ctx, bucketLockKey(name))
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	bucket, err := b.headBucket(name)
+	if err != nil {
+		return err
+	}
+
+	source := bucket.config
+
+	_, cancelUsage, err := b.Locker.Lock(ctx, source.bucketUsagePath(name))
+	if err != nil {
+		return err
+	}
+	defer cancelUsage()
+
+	if source == target {
+		return removeIfExists(source.bucketMovingPath(name))
+	}
+
+	if err := yaml.MarshalToFile(movingYaml{To: target.root()}, source.bucketMovingPath(name)); err != nil {
+		return err
+	}
+
+	if err := b.copyBucket(ctx, bucket, target); err != nil {
+		return errors.Join(err, removeIfExists(source.bucketMovingPath(name)))
+	}
+
+	return removeMovedBucket(ctx, bucket)
+}
+
+// copyBucket copies the objects, the incomplete multipart uploads and the metadata of the bucket to the target
+// root. Blobs shared through the content-addressed store of the source root are shared through the one of the
+// target.
+func (b *Backend) copyBucket(ctx context.Context, bucket *Bucket, target *Config) error { //nolint:funlen
+	sourcePath, err := bucket.rootPath()
+	if err != nil {
+		return err
+	}
+
+	stagingPath := filepath.Join(target.movesPath(), bucket.name)
+
+	if err := os.RemoveAll(stagingPath); err != nil {
+		return err
+	}
+
+	if err := mkdirAllNoFollow(target.movesPath(), 0755); err != nil {
+		return err
+	}
+
+	targetBucket := &Bucket{name: bucket.name, config: target, Locker: b.Locker}
+	objectsRoot := filepath.Join(sourcePath, objectsFolder) + string(filepath.Separator)
+	multipartRoot := filepath.Join(sourcePath, uploadsFolder, multipartFolder) + string(filepath.Separator)
+
+	err = filepath.WalkDir(sourcePath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		rel, err := filepath.Rel(sourcePath, path)
+		if err != nil {
+			return err
+		}
+
+		dst := filepath.Join(stagingPath, rel)
+
+		switch {
+		case entry.IsDir() && rel == filepath.Join(uploadsFolder, regularUploadsFolder):
+			// Objects being uploaded are not committed yet.
+			return filepath.SkipDir
+		case entry.IsDir():
+			return os.Mkdir(dst, 0755)
+		case !entry.Type().IsRegular() || rel == movingYamlFilename:
+			return nil
+		}
+
+		if strings.HasPrefix(path, multipartRoot) {
+			// Uploads being completed and parts being uploaded are not committed yet.
+			committed, err := committedUploadFile(path)
+			if err != nil || !committed {
+				return err
+			}
+		}
+
+		if err := copyFile(path, dst); err != nil {
+			return err
+		}
+
+		if filepath.Base(path) != metadataYamlFilename || !strings.HasPrefix(path, objectsRoot) {
+			return nil
+		}
+
+		return shareCopiedBlob(ctx, targetBucket, filepath.Dir(dst))
+	})
+	if err == nil {
+		err = ensureBucketMetadata(bucket, stagingPath)
+	}
+
+	if err == nil {
+		err = renameNoFollow(stagingPath, filepath.Join(target.bucketsPath(), bucket.name))
+	}
+
+	if err != nil {
+		return errors.Join(err, os.RemoveAll(stagingPath))
+	}
+
+	return nil
+}
+
+// committedUploadFile tells whether a file of a multipart upload is committed: the blob of an upload being completed
+// is not, a part is once its metadata is written.
+func committedUploadFile(path string) (bool, error) {
+	name := filepath.Base(path)
+
+	switch {
+	case name == blobFilename:
+		return false, nil
+	case partFilenameRegexp.MatchString(name):
+		return existsAndIsFile(path + ".yaml")
+	default:
+		return true, nil
+	}
+}
+
+// shareCopiedBlob shares the blob of an object copied to objectPath through the content-addressed store of the
+// bucket, when the object shared it on the root it was copied from.
+func shareCopiedBlob(ctx context.Context, bucket *Bucket, objectPath string) error {
+	metadataPath := filepath.Join(objectPath, metadataYamlFilename)
+
+	metadata, err := yaml.UnmarshalFromFile[core.ObjectMetadata](metadataPath)
+	if err != nil || metadata.BlobSHA256 == "" {
+		return err
+	}
+
+	shared, err := bucket.storeBlob(ctx, filepath.Join(objectPath, blobFilename), metadata.BlobSHA256)
+	if err != nil || shared {
+		return err
+	}
+
+	metadata.BlobSHA256 = ""
+
+	info, err := os.Lstat(metadataPath)
+	if err != nil {
+		return err
+	}
+
+	if err := yaml.MarshalToFile(metadata, metadataPath); err != nil {
+		return err
+	}
+
+	return os.Chtimes(metadataPath, info.ModTime(), info.ModTime())
+}
+
+// ensureBucketMetadata writes the bucket.yaml of buckets created before bucket metadata support to their copy, it
+// makes the copy own the bucket.
+func ensureBucketMetadata(bucket *Bucket, stagingPath string) error {
+	path := filepath.Join(stagingPath, bucketYamlFilename)
+
+	exists, err := existsAndIsFile(path)
+	if err != nil || exists {
+		return err
+	}
+
+	return yaml.MarshalToFile(bucketMetadata{CreationDate: bucket.creationDate}, path)
+}
+
+// copyFile copies the regular file at src to dst, keeping its modification time.
+func copyFile(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	srcFile, err := openFileNoFollow(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := createFileNoFollow(dst, uint32(info.Mode().Perm()))
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := smartio.Copy(context.Background(), dstFile, srcFile); err != nil {
+		dstFile.Close()
+
+		return err
+	}
+
+	if err := dstFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// removeMovedBucket removes the bucket from the root it was moved from, dropping the references of its objects to
+// the content-addressed store of that root.
+func removeMovedBucket(ctx context.Context, bucket *Bucket) error {
+	path, err := bucket.rootPath()
+	if err != nil {
+		return err
+	}
+
+	binPath := bucket.config.newBinPath()
+
+	if err := renameNoFollow(path, binPath); err != nil {
+		return err
+	}
+
+	var objectPaths []string
+
+	err = filepath.WalkDir(filepath.Join(binPath, objectsFolder), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return lo.Ternary(errors.Is(err, os.ErrNotExist), nil, err)
+		}
+
+		if !entry.IsDir() && entry.Name() == metadataYamlFilename {
+			objectPaths = append(objectPaths, filepath.Dir(path))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, path := range objectPaths {
+		if err := bucket.releaseBlob(ctx, path); err != nil {
+			return err
+		}
+	}
+
+	return os.RemoveAll(binPath)
+}
+
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
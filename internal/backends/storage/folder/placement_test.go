@@ -0,0 +1,162 @@
+package folder //nolint:testpackage
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/yaml"
+)
+
+var _ = Describe("Storage roots", func() {
+	var (
+		first, second string
+		backend       *Backend
+	)
+
+	content := bytes.Repeat([]byte("moved content "), 1_000)
+
+	newBackend := func(ctx SpecContext, cfg *core.Config) {
+		cfg.FolderStorageBackendPath = first
+		cfg.FolderStorageExtraPaths = []string{second}
+
+		backend = &Backend{Cfg: cfg, Locker: noopLocker{}}
+		lo.Must0(backend.Init(ctx))
+	}
+
+	BeforeEach(func(ctx SpecContext) {
+		first = lo.Must(os.MkdirTemp("", "roots-first-*"))
+		second = lo.Must(os.MkdirTemp("", "roots-second-*"))
+
+		DeferCleanup(func() {
+			_ = os.RemoveAll(first)
+			_ = os.RemoveAll(second)
+		})
+
+		newBackend(ctx, &core.Config{FolderStoragePlacement: core.PlacementRoundRobin})
+	})
+
+	bucketPath := func(root, bucket string) string {
+		return filepath.Join(root, bucketsFolder, bucket)
+	}
+
+	put := func(ctx SpecContext, bucket core.Bucket, key string, content []byte) {
+		lo.Must0(bucket.PutObject(ctx, key, core.PutObjectInput{Reader: bytes.NewReader(content)}))
+	}
+
+	read := func(ctx SpecContext, bucket core.Bucket, key string) []byte {
+		object := lo.Must(bucket.GetObject(ctx, key))
+		defer object.Close()
+
+		return lo.Must(io.ReadAll(object))
+	}
+
+	rootBuckets := func(ctx SpecContext) map[string][]string {
+		roots := lo.Must(backend.StorageRoots(ctx))
+
+		return lo.SliceToMap(roots, func(root core.StorageRoot) (string, []string) { return root.Path, root.Buckets })
+	}
+
+	It("places buckets on the roots in turn and lists them together", func(ctx SpecContext) {
+		for _, name := range []string{"a", "b", "c"} {
+			lo.Must0(backend.CreateBucket(ctx, name))
+		}
+
+		Expect(bucketPath(first, "a")).To(BeADirectory())
+		Expect(bucketPath(second, "b")).To(BeADirectory())
+		Expect(bucketPath(first, "c")).To(BeADirectory())
+
+		buckets := lo.Must(backend.ListBuckets(ctx))
+		Expect(lo.Map(buckets, func(bucket core.Bucket, _ int) string { return bucket.Name() })).
+			To(Equal([]string{"a", "b", "c"}))
+
+		Expect(rootBuckets(ctx)).To(Equal(map[string][]string{first: {"a", "c"}, second: {"b"}}))
+		Expect(backend.CreateBucket(ctx, "b")).To(MatchError(core.ErrBucketAlreadyExists))
+	})
+
+	It("places pinned buckets on their root", func(ctx SpecContext) {
+		newBackend(ctx, &core.Config{FolderStorageBucketRoots: map[string]string{"pinned": second}})
+
+		lo.Must0(backend.CreateBucket(ctx, "pinned"))
+		Expect(bucketPath(second, "pinned")).To(BeADirectory())
+	})
+
+	It("moves buckets with their objects, uploads and shared blobs", func(ctx SpecContext) {
+		newBackend(ctx, &core.Config{FolderStorageDedup: true, FolderStorageBucketRoots: map[string]string{"bucket": first}})
+
+		lo.Must0(backend.CreateBucket(ctx, "bucket"))
+		bucket := lo.Must(backend.HeadBucket(ctx, "bucket"))
+
+		put(ctx, bucket, "a.txt", content)
+		put(ctx, bucket, "dir/b.txt", content)
+		lo.Must0(bucket.PutObjectTagging(ctx, "a.txt", map[string]string{"tag": "value"}))
+
+		uploadID := lo.Must(bucket.CreateMultipartUpload(ctx, "upload.txt", core.ObjectMetadata{}, nil))
+		etag := lo.Must(bucket.UploadPart(ctx, "upload.txt", uploadID, 1, bytes.NewReader([]byte("part")), nil))
+
+		lo.Must0(backend.MoveBucket(ctx, "bucket", second))
+
+		Expect(bucketPath(first, "bucket")).NotTo(BeAnExistingFile())
+		Expect(rootBuckets(ctx)).To(Equal(map[string][]string{first: {}, second: {"bucket"}}))
+
+		moved := lo.Must(backend.HeadBucket(ctx, "bucket"))
+		Expect(moved.CreationDate()).To(Equal(bucket.CreationDate()))
+		Expect(read(ctx, moved, "a.txt")).To(Equal(content))
+		Expect(read(ctx, moved, "dir/b.txt")).To(Equal(content))
+		Expect(lo.Must(moved.HeadObject(ctx, "a.txt")).Metadata().Tags).To(Equal(map[string]string{"tag": "value"}))
+		Expect(*lo.Must(moved.Usage(ctx))).To(Equal(core.BucketUsage{Bytes: int64(2 * len(content)), Objects: 2}))
+
+		stats := lo.Must(backend.DedupStats(ctx))
+		Expect(stats.Blobs).To(Equal(int64(1)))
+		Expect(stats.References).To(Equal(int64(2)))
+		Expect(lo.Must(directorySize(ctx, filepath.Join(first, contentFolder)))).To(BeZero())
+
+		lo.Must(moved.CompleteMultipartUpload(ctx, "upload.txt", uploadID,
+			[]core.CompletePart{{PartNumber: 1, ETag: etag}}))
+		Expect(read(ctx, moved, "upload.txt")).To(Equal([]byte("part")))
+	})
+
+	It("rejects changes to a bucket handle once the bucket is moved", func(ctx SpecContext) {
+		lo.Must0(backend.CreateBucket(ctx, "bucket"))
+		bucket := lo.Must(backend.HeadBucket(ctx, "bucket"))
+		put(ctx, bucket, "a.txt", content)
+
+		lo.Must0(backend.MoveBucket(ctx, "bucket", second))
+
+		err := bucket.PutObject(ctx, "b.txt", core.PutObjectInput{Reader: bytes.NewReader(content)})
+		Expect(err).To(MatchError(core.ErrBucketMoving))
+
+		put(ctx, lo.Must(backend.HeadBucket(ctx, "bucket")), "b.txt", content)
+	})
+
+	It("rejects changes while the bucket is moved and clears an interrupted move", func(ctx SpecContext) {
+		lo.Must0(backend.CreateBucket(ctx, "bucket"))
+		bucket := lo.Must(backend.HeadBucket(ctx, "bucket"))
+		put(ctx, bucket, "a.txt", content)
+
+		movingPath := filepath.Join(bucketPath(first, "bucket"), movingYamlFilename)
+		lo.Must0(yaml.MarshalToFile(movingYaml{To: second}, movingPath))
+
+		err := bucket.PutObject(ctx, "b.txt", core.PutObjectInput{Reader: bytes.NewReader(content)})
+		Expect(err).To(MatchError(core.ErrBucketMoving))
+		Expect(bucket.PutObjectTagging(ctx, "a.txt", map[string]string{"tag": "value"})).To(MatchError(core.ErrBucketMoving))
+		Expect(backend.DeleteBucket(ctx, "bucket")).To(MatchError(core.ErrBucketMoving))
+		Expect(read(ctx, bucket, "a.txt")).To(Equal(content))
+
+		lo.Must0(backend.MoveBucket(ctx, "bucket", first))
+
+		Expect(movingPath).NotTo(BeAnExistingFile())
+		put(ctx, bucket, "b.txt", content)
+	})
+
+	It("rejects unknown roots", func(ctx SpecContext) {
+		lo.Must0(backend.CreateBucket(ctx, "bucket"))
+
+		Expect(backend.MoveBucket(ctx, "bucket", "/nonexistent")).To(MatchError(core.ErrStorageRootNotFound))
+	})
+})
@@ -0,0 +1,332 @@
+package folder
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/yaml"
+)
+
+// The usage of a bucket is kept in its usage.yaml and only changed under the lock of that file: writes check the
+// quota and commit their change to the objects of the bucket while holding it, so concurrent writes cannot
+// overshoot the quota together. Writes already hold the lock of the object they change, the usage lock is always
+// taken after it. Changes that do not change the usage commit under it too, moving a bucket to another storage
+// root holds it while the bucket is copied.
+//
+// Usage is only tracked while the bucket has a quota, so writes to other buckets do not pay for it: setting a
+// quota rescans the bucket into usage.yaml, removing it removes the file, and writes to buckets without the file
+// leave the usage alone.
+
+func (b *Bucket) Quota() *core.BucketQuota {
+	return b.quota
+}
+
+func (b *Bucket) PutQuota(ctx context.Context, quota *core.BucketQuota) error {
+	if quota != nil {
+		if err := quota.Validate(); err != nil {
+			return err
+		}
+	}
+
+	err := b.updateMetadata(ctx, func(metadata *bucketMetadata) { metadata.Quota = quota })
+	if err != nil {
+		return err
+	}
+
+	b.quota = quota
+
+	return b.updateUsageTracking(ctx)
+}
+
+// Usage returns the tracked usage, or sums the objects of buckets without a quota up.
+func (b *Bucket) Usage(ctx context.Context) (*core.BucketUsage, error) {
+	var usage core.BucketUsage
+
+	err := b.withUsage(ctx, func(current *core.BucketUsage) error {
+		if current != nil {
+			usage = *current
+
+			return nil
+		}
+
+		var err error
+
+		usage, err = b.scanUsage(ctx)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &usage, nil
+}
+
+func (b *Bucket) RescanUsage(ctx context.Context) (*core.BucketUsage, error) {
+	path := b.config.bucketUsagePath(b.name)
+
+	_, cancel, err := b.Locker.Lock(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	if err := b.checkNotMoving(); err != nil {
+		return nil, err
+	}
+
+	tracked, err := b.usageTracked()
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := b.scanUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if tracked {
+		if err := b.writeUsage(usage); err != nil {
+			return nil, err
+		}
+	}
+
+	return &usage, nil
+}
+
+// updateUsageTracking starts tracking the usage of a bucket that got a quota and stops tracking it for a bucket
+// whose quota was removed.
+func (b *Bucket) updateUsageTracking(ctx context.Context) error {
+	path := b.config.bucketUsagePath(b.name)
+
+	_, cancel, err := b.Locker.Lock(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	if err := b.checkNotMoving(); err != nil {
+		return err
+	}
+
+	if err := rejectSymlink(path); err != nil {
+		return err
+	}
+
+	if b.quota == nil {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		return nil
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	_, err = b.rescanUsage(ctx)
+
+	return err
+}
+
+// checkQuota returns ErrQuotaExceeded when changing the usage by delta would exceed the quota, without changing it.
+func (b *Bucket) checkQuota(ctx context.Context, delta core.BucketUsage) error {
+	if b.quota == nil {
+		return nil
+	}
+
+	return b.withUsage(ctx, func(usage *core.BucketUsage) error {
+		if usage == nil {
+			// The quota was removed in the meantime.
+			return nil
+		}
+
+		return b.quota.Check(*usage, delta)
+	})
+}
+
+// checkUploadQuota returns ErrQuotaExceeded when the object assembled from the parts of an upload would exceed the
+// quota.
+func (b *Bucket) checkUploadQuota(ctx context.Context, key, uploadPath string, parts []core.CompletePart) error {
+	if b.quota == nil {
+		return nil
+	}
+
+	infos, err := collectPartInfos(ctx, uploadPath)
+	if err != nil {
+		return err
+	}
+
+	sizes := lo.SliceToMap(infos, func(info core.PartInfo) (int, int64) { return info.PartNumber, info.Size })
+	size := lo.SumBy(parts, func(part core.CompletePart) int64 { return sizes[part.PartNumber] })
+
+	existing, err := ObjectFromPath(b, key)
+	if err != nil && !errors.Is(err, core.ErrObjectNotFound) {
+		return err
+	}
+
+	return b.checkQuota(ctx, usageDelta(existing, size))
+}
+
+// commitUsage runs commit, which changes the usage of the bucket by delta, unless the change exceeds the quota.
+// The usage is only updated when commit succeeds and it is tracked.
+func (b *Bucket) commitUsage(ctx context.Context, delta core.BucketUsage, commit func() error) error {
+	return b.withUsage(ctx, func(usage *core.BucketUsage) error {
+		if usage == nil {
+			return commit()
+		}
+
+		if err := b.quota.Check(*usage, delta); err != nil {
+			return err
+		}
+
+		if err := commit(); err != nil {
+			return err
+		}
+
+		if delta == (core.BucketUsage{}) {
+			return nil
+		}
+
+		return b.writeUsage(usage.Add(delta))
+	})
+}
+
+// commitChange runs commit, which does not change the usage of the bucket, under the usage lock.
+func (b *Bucket) commitChange(ctx context.Context, commit func() error) error {
+	return b.commitUsage(ctx, core.BucketUsage{}, commit)
+}
+
+// withUsage calls fn with the current usage under the usage lock, nil when the usage is not tracked. Buckets with a
+// quota but without a usage file, created before usage was tracked or whose deletion failed, are rescanned first.
+// It fails with ErrBucketMoving while the bucket is moved to another storage root, without waiting for the move to
+// release the lock when it can.
+func (b *Bucket) withUsage(ctx context.Context, fn func(*core.BucketUsage) error) error {
+	path := b.config.bucketUsagePath(b.name)
+
+	if err := b.checkNotMoving(); err != nil {
+		return err
+	}
+
+	_, cancel, err := b.Locker.Lock(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	if err := b.checkNotMoving(); err != nil {
+		return err
+	}
+
+	if err := rejectSymlink(path); err != nil {
+		return err
+	}
+
+	usage, err := yaml.UnmarshalFromFile[core.BucketUsage](path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		tracked, err := b.usageTracked()
+		if err != nil {
+			return err
+		}
+
+		if !tracked {
+			return fn(nil)
+		}
+
+		usage, err = b.rescanUsage(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return fn(&usage)
+}
+
+// usageTracked tells whether the bucket has a usage file or a quota, the caller must hold the usage lock. The
+// quota is read from the metadata file, the bucket may have been loaded before it was changed.
+func (b *Bucket) usageTracked() (bool, error) {
+	path := b.config.bucketUsagePath(b.name)
+
+	if err := rejectSymlink(path); err != nil {
+		return false, err
+	}
+
+	_, err := os.Stat(path)
+
+	switch {
+	case err == nil:
+		return true, nil
+	case !errors.Is(err, os.ErrNotExist):
+		return false, err
+	}
+
+	metadataPath := b.config.bucketMetadataPath(b.name)
+	if err := rejectSymlink(metadataPath); err != nil {
+		return false, err
+	}
+
+	metadata, err := yaml.UnmarshalFromFile[bucketMetadata](metadataPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return metadata.Quota != nil, nil
+}
+
+// rescanUsage sums the objects of the bucket up and saves the result, the caller must hold the usage lock.
+func (b *Bucket) rescanUsage(ctx context.Context) (core.BucketUsage, error) {
+	usage, err := b.scanUsage(ctx)
+	if err != nil {
+		return core.BucketUsage{}, err
+	}
+
+	return usage, b.writeUsage(usage)
+}
+
+// scanUsage sums the objects of the bucket up.
+func (b *Bucket) scanUsage(ctx context.Context) (core.BucketUsage, error) {
+	usage := core.BucketUsage{}
+
+	err := WalkBucket(ctx, b, "", nil, func(_ context.Context, object core.Object) error {
+		usage.Bytes += object.Metadata().Size
+		usage.Objects++
+
+		return nil
+	})
+	if err != nil {
+		return core.BucketUsage{}, err
+	}
+
+	return usage, nil
+}
+
+func (b *Bucket) writeUsage(usage core.BucketUsage) error {
+	path := b.config.bucketUsagePath(b.name)
+
+	if err := rejectSymlink(path); err != nil {
+		return err
+	}
+
+	return yaml.MarshalToFile(usage, path)
+}
+
+// usageDelta is the change of usage when an object of size replaces existing, which is nil for new objects.
+func usageDelta(existing *Object, size int64) core.BucketUsage {
+	if existing == nil {
+		return core.BucketUsage{Bytes: size, Objects: 1}
+	}
+
+	return core.BucketUsage{Bytes: size - existing.Metadata().Size}
+}
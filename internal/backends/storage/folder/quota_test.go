@@ -0,0 +1,79 @@
+package folder //nolint:testpackage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/yaml"
+)
+
+var _ = Describe("Bucket usage", func() {
+	var (
+		tmpDir string
+		bucket core.Bucket
+	)
+
+	usagePath := func() string {
+		return filepath.Join(tmpDir, bucketsFolder, "bucket", usageYamlFilename)
+	}
+
+	BeforeEach(func(ctx SpecContext) {
+		tmpDir = lo.Must(os.MkdirTemp("", "quota-*"))
+
+		DeferCleanup(func() { _ = os.RemoveAll(tmpDir) })
+
+		backend := &Backend{Cfg: &core.Config{FolderStorageBackendPath: tmpDir}, Locker: noopLocker{}}
+		lo.Must0(backend.Init(ctx))
+		lo.Must0(backend.CreateBucket(ctx, "bucket"))
+
+		bucket = lo.Must(backend.HeadBucket(ctx, "bucket"))
+
+		for _, key := range []string{"a.txt", "dir/b.txt"} {
+			lo.Must0(bucket.PutObject(ctx, key, core.PutObjectInput{Reader: bytes.NewReader([]byte("content"))}))
+		}
+	})
+
+	It("fixes drift when rescanned", func(ctx SpecContext) {
+		lo.Must0(yaml.MarshalToFile(core.BucketUsage{Bytes: 1_000, Objects: 42}, usagePath()))
+		Expect(*lo.Must(bucket.Usage(ctx))).To(Equal(core.BucketUsage{Bytes: 1_000, Objects: 42}))
+
+		Expect(*lo.Must(bucket.RescanUsage(ctx))).To(Equal(core.BucketUsage{Bytes: 14, Objects: 2}))
+		Expect(*lo.Must(bucket.Usage(ctx))).To(Equal(core.BucketUsage{Bytes: 14, Objects: 2}))
+	})
+
+	It("does not track the usage of buckets without a quota", func(ctx SpecContext) {
+		Expect(usagePath()).NotTo(BeAnExistingFile())
+		Expect(*lo.Must(bucket.Usage(ctx))).To(Equal(core.BucketUsage{Bytes: 14, Objects: 2}))
+		Expect(*lo.Must(bucket.RescanUsage(ctx))).To(Equal(core.BucketUsage{Bytes: 14, Objects: 2}))
+		Expect(usagePath()).NotTo(BeAnExistingFile())
+	})
+
+	It("rescans the bucket when a quota is set and tracks the usage until it is removed", func(ctx SpecContext) {
+		lo.Must0(bucket.PutQuota(ctx, &core.BucketQuota{MaxObjects: 3}))
+		Expect(lo.Must(yaml.UnmarshalFromFile[core.BucketUsage](usagePath()))).To(Equal(
+			core.BucketUsage{Bytes: 14, Objects: 2},
+		))
+
+		lo.Must0(bucket.PutObject(ctx, "c.txt", core.PutObjectInput{Reader: bytes.NewReader([]byte("more"))}))
+		Expect(*lo.Must(bucket.Usage(ctx))).To(Equal(core.BucketUsage{Bytes: 18, Objects: 3}))
+
+		err := bucket.PutObject(ctx, "d.txt", core.PutObjectInput{Reader: bytes.NewReader([]byte("more"))})
+		Expect(err).To(MatchError(core.ErrQuotaExceeded))
+
+		lo.Must0(bucket.PutQuota(ctx, nil))
+		Expect(usagePath()).NotTo(BeAnExistingFile())
+	})
+
+	It("rescans buckets with a quota but without tracked usage", func(ctx SpecContext) {
+		lo.Must0(bucket.PutQuota(ctx, &core.BucketQuota{MaxObjects: 3}))
+		lo.Must0(os.Remove(usagePath()))
+
+		Expect(*lo.Must(bucket.Usage(ctx))).To(Equal(core.BucketUsage{Bytes: 14, Objects: 2}))
+		Expect(usagePath()).To(BeAnExistingFile())
+	})
+})
@@ -0,0 +1,145 @@
+package folder
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+)
+
+func (b *Backend) StorageStats(ctx context.Context) (*core.StorageStats, error) {
+	stats := &core.StorageStats{ScannedAt: time.Now(), Buckets: []core.BucketStats{}}
+
+	buckets, err := b.ListBuckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, bucket := range buckets {
+		bucketStats, err := scanBucket(ctx, bucket.(*Bucket)) //nolint:forcetypeassert
+		if err != nil {
+			return nil, err
+		}
+
+		stats.Buckets = append(stats.Buckets, bucketStats)
+	}
+
+	for _, root := range b.roots {
+		binBytes, err := directorySize(ctx, root.binPath())
+		if err != nil {
+			return nil, err
+		}
+
+		stats.BinBytes += binBytes
+	}
+
+	return stats, nil
+}
+
+// scanBucket walks the objects and the incomplete multipart uploads of the bucket.
+func scanBucket(ctx context.Context, bucket *Bucket) (core.BucketStats, error) {
+	stats := core.BucketStats{Name: bucket.name}
+	prefixes := map[string]*core.PrefixStats{}
+
+	prefixStats := func(key string) *core.PrefixStats {
+		prefix := topLevelPrefix(key)
+
+		if prefixes[prefix] == nil {
+			prefixes[prefix] = &core.PrefixStats{Prefix: prefix}
+		}
+
+		return prefixes[prefix]
+	}
+
+	err := WalkBucket(ctx, bucket, "", nil, func(_ context.Context, object core.Object) error {
+		summary := core.ObjectSummary{
+			Key:          object.Key(),
+			Size:         object.Metadata().Size,
+			LastModified: object.LastModified(),
+		}
+
+		stats.AddObject(summary)
+		prefixStats(summary.Key).AddObject(summary)
+
+		return nil
+	})
+	if err != nil {
+		return core.BucketStats{}, err
+	}
+
+	walkUpload := func(ctx context.Context, upload *IncompleteMultipartUpload) error {
+		infos, err := collectPartInfos(ctx, upload.path)
+		if err != nil {
+			return err
+		}
+
+		size := lo.SumBy(infos, func(info core.PartInfo) int64 { return info.Size })
+		stats.MultipartBytes += size
+		prefixStats(upload.Key()).MultipartBytes += size
+
+		return nil
+	}
+
+	err = WalkMultipartUploads(ctx, bucket, "", "", "", walkUpload)
+	if err != nil {
+		return core.BucketStats{}, err
+	}
+
+	for _, prefix := range slices.Sorted(maps.Keys(prefixes)) {
+		stats.Prefixes = append(stats.Prefixes, *prefixes[prefix])
+	}
+
+	return stats, nil
+}
+
+// topLevelPrefix is the part of the key up to and including its first slash, empty when it has none.
+func topLevelPrefix(key string) string {
+	index := strings.Index(key, "/")
+	if index < 0 {
+		return ""
+	}
+
+	return key[:index+1]
+}
+
+// directorySize sums the sizes of the regular files under path up, a missing path is empty.
+func directorySize(ctx context.Context, path string) (int64, error) {
+	var size int64
+
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		size += info.Size()
+
+		return nil
+	})
+
+	return size, err
+}
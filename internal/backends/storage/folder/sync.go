@@ -0,0 +1,210 @@
+//go:build unix
+
+package folder
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/yaml"
+)
+
+// A backend can be repaired from another one holding a copy of the same buckets, like the replicas of a mirror.
+// The copy being repaired is meant to be identical to the source, so Object Lock and quotas are not enforced, and
+// the caller must keep the source from changing while it is copied.
+
+// SyncObject makes the object at key a copy of the one of the bucket of the same name of source, another backend,
+// or deletes it when source has none.
+func (b *Bucket) SyncObject(ctx context.Context, key string, source *Backend) error {
+	path, err := b.config.objectPath(b.name, key)
+	if err != nil {
+		return err
+	}
+
+	_, cancel, err := b.Locker.Lock(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	if err := rejectSymlinkInPath(path); err != nil {
+		return err
+	}
+
+	existing, err := objectOrNil(b, key)
+	if err != nil {
+		return err
+	}
+
+	sourceObject, err := source.objectOf(b.name, key)
+	if err != nil {
+		return err
+	}
+
+	if sourceObject == nil {
+		if existing == nil {
+			return nil
+		}
+
+		return b.commitSyncedUsage(ctx, syncedUsageDelta(existing, nil), func() error { return existing.Delete(ctx) })
+	}
+
+	uploadPath, err := b.config.newUploadPath(b.name)
+	if err != nil {
+		return err
+	}
+
+	if err := mkdirAllNoFollow(uploadPath, 0755); err != nil {
+		return err
+	}
+	defer os.RemoveAll(uploadPath)
+
+	for _, name := range []string{blobFilename, metadataYamlFilename} {
+		if err := copyFile(filepath.Join(sourceObject.path, name), filepath.Join(uploadPath, name)); err != nil {
+			return err
+		}
+	}
+
+	if err := shareCopiedBlob(ctx, b, uploadPath); err != nil {
+		return err
+	}
+
+	metadata, err := yaml.UnmarshalFromFile[core.ObjectMetadata](filepath.Join(uploadPath, metadataYamlFilename))
+	if err != nil {
+		return err
+	}
+
+	return b.commitSyncedUsage(ctx, syncedUsageDelta(existing, &metadata), func() error {
+		return b.replaceObject(ctx, existing, uploadPath, path)
+	})
+}
+
+// objectOf returns the object at key of the bucket, nil when either of them is missing.
+func (b *Backend) objectOf(bucket, key string) (*Object, error) {
+	sourceBucket, err := b.headBucket(bucket)
+	if err != nil {
+		if errors.Is(err, core.ErrBucketNotFound) {
+			return nil, nil //nolint:nilnil
+		}
+
+		return nil, err
+	}
+
+	return objectOrNil(sourceBucket, key)
+}
+
+// objectOrNil is ObjectFromPath, with nil for a missing object.
+func objectOrNil(bucket *Bucket, key string) (*Object, error) {
+	object, err := ObjectFromPath(bucket, key)
+	if errors.Is(err, core.ErrObjectNotFound) {
+		return nil, nil //nolint:nilnil
+	}
+
+	return object, err
+}
+
+// commitSyncedUsage is commitUsage without the quota check.
+func (b *Bucket) commitSyncedUsage(ctx context.Context, delta core.BucketUsage, commit func() error) error {
+	return b.withUsage(ctx, func(usage *core.BucketUsage) error {
+		if err := commit(); err != nil {
+			return err
+		}
+
+		if usage == nil {
+			return nil
+		}
+
+		if delta == (core.BucketUsage{}) {
+			return nil
+		}
+
+		return b.writeUsage(usage.Add(delta))
+	})
+}
+
+// syncedUsageDelta is the change of usage when a copy replaces existing, nil metadata stands for a deletion. The
+// metadata of the object being repaired may be unreadable, it then counts as empty.
+func syncedUsageDelta(existing *Object, metadata *core.ObjectMetadata) core.BucketUsage {
+	var delta core.BucketUsage
+
+	if metadata != nil {
+		delta = core.BucketUsage{Bytes: metadata.Size, Objects: 1}
+	}
+
+	if existing != nil {
+		delta.Objects--
+
+		if existingMetadata, err := existing.LoadMetadata(); err == nil {
+			delta.Bytes -= existingMetadata.Size
+		}
+	}
+
+	return delta
+}
+
+// SyncBucket creates the bucket, or updates its settings, as a copy of the one of the same name of source, or
+// removes it along with all of its content when source has none. The objects are synced one by one.
+func (b *Backend) SyncBucket(ctx context.Context, name string, source *Backend) error {
+	_, cancel, err := b.Locker.Lock(ctx, bucketLockKey(name))
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	sourceBucket, err := source.headBucket(name)
+	if err != nil && !errors.Is(err, core.ErrBucketNotFound) {
+		return err
+	}
+
+	// The metadata of the bucket being repaired may be unreadable, it is not loaded.
+	root, err := b.bucketConfig(name)
+	if err != nil && !errors.Is(err, core.ErrBucketNotFound) {
+		return err
+	}
+
+	if sourceBucket == nil {
+		if root == nil {
+			return nil
+		}
+
+		return b.removeBucket(ctx, &Bucket{name: name, config: root, roots: b.roots, Locker: b.Locker})
+	}
+
+	metadata := bucketMetadata{
+		CreationDate: sourceBucket.creationDate,
+		Logging:      sourceBucket.logging,
+		Encryption:   sourceBucket.encryption,
+		Compression:  sourceBucket.compression,
+		ObjectLock:   sourceBucket.objectLock,
+		Quota:        sourceBucket.quota,
+	}
+
+	if root == nil {
+		return b.createBucket(name, metadata)
+	}
+
+	bucket := &Bucket{name: name, config: root, roots: b.roots, Locker: b.Locker}
+
+	return bucket.commitChange(ctx, func() error {
+		path := root.bucketMetadataPath(name)
+		if err := rejectSymlink(path); err != nil {
+			return err
+		}
+
+		return yaml.MarshalToFile(metadata, path)
+	})
+}
+
+// removeBucket removes the bucket whatever it holds, under its usage lock.
+func (b *Backend) removeBucket(ctx context.Context, bucket *Bucket) error {
+	_, cancel, err := b.Locker.Lock(ctx, bucket.config.bucketUsagePath(bucket.name))
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return removeMovedBucket(ctx, bucket)
+}
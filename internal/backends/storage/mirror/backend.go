@@ -0,0 +1,381 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/backends/storage/folder"
+	"github.com/zhulik/d3/internal/core"
+)
+
+// Backend keeps a copy of every bucket and object on each of the MirrorStoragePaths roots, each root is managed by
+// a folder backend of its own. Writes go to all roots at once and succeed once MirrorWriteQuorumSize of them have
+// them, reads are served by the first root that has the object intact. Objects a root missed or serves corrupted
+// are recorded as dirty and copied to it from a healthy root by the resilver, which also copies everything to
+// roots that were replaced by an empty disk.
+type Backend struct {
+	Cfg    *core.Config
+	Locker core.Locker
+	Logger *slog.Logger
+
+	replicas []*replica
+	quorum   int
+
+	dirtyMu sync.Mutex
+	dirty   map[string]*dirtyEntry
+
+	// resilverNow wakes Run up when an entry is marked dirty.
+	resilverNow chan struct{}
+}
+
+// replica is one of the roots of the mirror. Roots that fail to initialize are offline until the next start,
+// roots replaced by an empty disk are resilvering until all buckets are copied to them and serve no reads.
+type replica struct {
+	path        string
+	backend     *folder.Backend
+	online      bool
+	resilvering atomic.Bool
+}
+
+var errReplicaOffline = errors.New("the storage root is offline") //nolint:gochecknoglobals
+
+func (b *Backend) Init(ctx context.Context) error {
+	b.quorum = b.Cfg.MirrorWriteQuorumSize()
+	b.dirty = map[string]*dirtyEntry{}
+	b.resilverNow = make(chan struct{}, 1)
+
+	ctx, cancel, err := b.Locker.Lock(ctx, "mirror-storage-backend-init")
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	// A root without d3.yaml next to initialized ones is a replaced disk, it is marked before it is initialized.
+	initialized := map[string]bool{}
+
+	for _, path := range b.Cfg.MirrorStoragePaths {
+		exists, err := fileExists(filepath.Join(path, folder.ConfigYamlFilename))
+		if err != nil {
+			b.Logger.Error("failed to access mirror storage root", "root", path, "error", err)
+		}
+
+		initialized[path] = exists
+	}
+
+	anyInitialized := lo.SomeBy(lo.Values(initialized), func(exists bool) bool { return exists })
+
+	for _, path := range b.Cfg.MirrorStoragePaths {
+		replica := &replica{path: path, backend: &folder.Backend{Cfg: replicaConfig(b.Cfg, path), Locker: b.Locker}}
+		b.replicas = append(b.replicas, replica)
+
+		if err := b.initReplica(ctx, replica, anyInitialized && !initialized[path]); err != nil {
+			b.Logger.Error("mirror storage root is offline", "root", path, "error", err)
+		}
+	}
+
+	online := lo.CountBy(b.replicas, func(replica *replica) bool { return replica.online })
+	if online < b.quorum {
+		return fmt.Errorf("%w: %d of %d storage roots are online, %d are required", core.ErrWriteQuorum, online,
+			len(b.replicas), b.quorum)
+	}
+
+	b.loadDirty()
+
+	return nil
+}
+
+func (b *Backend) initReplica(ctx context.Context, replica *replica, replaced bool) error {
+	if err := replica.backend.Init(ctx); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dirtyPath(replica.path), 0755); err != nil {
+		return err
+	}
+
+	if replaced {
+		if err := os.WriteFile(resilverMarkerPath(replica.path), nil, 0600); err != nil {
+			return err
+		}
+	}
+
+	resilvering, err := fileExists(resilverMarkerPath(replica.path))
+	if err != nil {
+		return err
+	}
+
+	replica.resilvering.Store(resilvering)
+	replica.online = true
+
+	return nil
+}
+
+// replicaConfig is the config of the folder backend of a root: the mirror config with the root as the only
+// storage root.
+func replicaConfig(cfg *core.Config, path string) *core.Config {
+	replicaCfg := *cfg
+	replicaCfg.FolderStorageBackendPath = path
+	replicaCfg.FolderStorageExtraPaths = nil
+	replicaCfg.FolderStorageBucketRoots = nil
+
+	return &replicaCfg
+}
+
+func (b *Backend) ListBuckets(ctx context.Context) ([]core.Bucket, error) {
+	return read(ctx, b.readers("", ""), func(ctx context.Context, replica *replica) ([]core.Bucket, error) {
+		buckets, err := replica.backend.ListBuckets(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return lo.Map(buckets, func(bucket core.Bucket, _ int) core.Bucket {
+			return &Bucket{backend: b, name: bucket.Name(), primary: bucket}
+		}), nil
+	})
+}
+
+func (b *Backend) CreateBucket(ctx context.Context, name string) error {
+	_, err := write(ctx, b, name, "", func(ctx context.Context, replica *replica) (struct{}, error) {
+		return struct{}{}, replica.backend.CreateBucket(ctx, name)
+	})
+
+	return err
+}
+
+func (b *Backend) DeleteBucket(ctx context.Context, name string) error {
+	_, err := write(ctx, b, name, "", func(ctx context.Context, replica *replica) (struct{}, error) {
+		return struct{}{}, replica.backend.DeleteBucket(ctx, name)
+	})
+
+	return err
+}
+
+func (b *Backend) HeadBucket(ctx context.Context, name string) (core.Bucket, error) {
+	var failed bool
+
+	bucket, err := read(ctx, b.readers(name, ""), func(ctx context.Context, replica *replica) (*Bucket, error) {
+		primary, err := replica.backend.HeadBucket(ctx, name)
+		if err != nil {
+			failed = true
+
+			return nil, err
+		}
+
+		if failed {
+			b.markDirty(dirtyEntry{Bucket: name, From: []string{replica.path}}, false)
+		}
+
+		return &Bucket{backend: b, name: name, primary: primary}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bucket, nil
+}
+
+// DedupStats describes the content-addressed store of the first healthy root, every root has one of its own.
+func (b *Backend) DedupStats(ctx context.Context) (*core.DedupStats, error) {
+	return read(ctx, b.readers("", ""), func(ctx context.Context, replica *replica) (*core.DedupStats, error) {
+		return replica.backend.DedupStats(ctx)
+	})
+}
+
+// StorageStats scans the first healthy root.
+func (b *Backend) StorageStats(ctx context.Context) (*core.StorageStats, error) {
+	return read(ctx, b.readers("", ""), func(ctx context.Context, replica *replica) (*core.StorageStats, error) {
+		return replica.backend.StorageStats(ctx)
+	})
+}
+
+// StorageRoots describes every root of the mirror, offline roots only by their path.
+func (b *Backend) StorageRoots(ctx context.Context) ([]core.StorageRoot, error) {
+	roots := make([]core.StorageRoot, 0, len(b.replicas))
+
+	for _, replica := range b.replicas {
+		if !replica.online {
+			roots = append(roots, core.StorageRoot{Path: replica.path, Buckets: []string{}})
+
+			continue
+		}
+
+		replicaRoots, err := replica.backend.StorageRoots(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		roots = append(roots, replicaRoots...)
+	}
+
+	return roots, nil
+}
+
+func (b *Backend) MoveBucket(_ context.Context, _, root string) error {
+	return fmt.Errorf("%w: %s, the buckets of a mirror are stored on all of its roots", core.ErrStorageRootNotFound,
+		root)
+}
+
+// readers returns the roots that serve reads of the object at key of the bucket, or of the bucket when key is
+// empty. The roots known to have the latest version of a dirty one come first.
+func (b *Backend) readers(bucket, key string) []*replica {
+	readers := lo.Filter(b.replicas, func(replica *replica, _ int) bool {
+		return replica.online && !replica.resilvering.Load()
+	})
+
+	entry := b.dirtyEntry(bucket, key)
+	if entry == nil {
+		return readers
+	}
+
+	from, others := lo.FilterReject(readers, func(replica *replica, _ int) bool {
+		return lo.Contains(entry.From, replica.path)
+	})
+
+	return append(from, others...)
+}
+
+// read calls fn with the given roots in turn until it succeeds, it returns the first error when it never does.
+func read[T any](ctx context.Context, replicas []*replica, fn func(context.Context, *replica) (T, error)) (T, error) {
+	var (
+		zero     T
+		firstErr error
+	)
+
+	for _, replica := range replicas {
+		result, err := fn(ctx, replica)
+		if err == nil {
+			return result, nil
+		}
+
+		if ctx.Err() != nil {
+			return zero, ctx.Err()
+		}
+
+		firstErr = lo.CoalesceOrEmpty(firstErr, err)
+	}
+
+	return zero, lo.CoalesceOrEmpty(firstErr, errReplicaOffline)
+}
+
+// write changes the object at key of the bucket, or the bucket itself when key is empty, by calling fn with every
+// root at once. It returns the result of the first root fn succeeded with, see settle. A pending repair of the
+// object is done first, so the roots start from the same state.
+func write[T any](ctx context.Context, b *Backend, bucket, key string,
+	fn func(context.Context, *replica) (T, error)) (T, error) {
+	var zero T
+
+	_, cancel, err := b.Locker.Lock(ctx, lockKey(bucket, key))
+	if err != nil {
+		return zero, err
+	}
+	defer cancel()
+
+	if entry := b.dirtyEntry(bucket, key); entry != nil {
+		if err := b.repair(ctx, entry); err != nil {
+			b.Logger.Warn("failed to repair before a write", "bucket", bucket, "key", key, "error", err)
+		}
+	}
+
+	results, errs := fanOut(ctx, b.replicas, fn)
+	if err := b.settle(&dirtyEntry{Bucket: bucket, Key: key}, errs); err != nil {
+		return zero, err
+	}
+
+	return results[lo.IndexOf(errs, nil)], nil
+}
+
+// fanOut calls fn with every online root at once, offline roots fail with errReplicaOffline.
+func fanOut[T any](ctx context.Context, replicas []*replica,
+	fn func(context.Context, *replica) (T, error)) ([]T, []error) {
+	results := make([]T, len(replicas))
+	errs := make([]error, len(replicas))
+
+	var wg sync.WaitGroup
+
+	for i, replica := range replicas {
+		if !replica.online {
+			errs[i] = errReplicaOffline
+
+			continue
+		}
+
+		wg.Go(func() {
+			results[i], errs[i] = fn(ctx, replica)
+		})
+	}
+
+	wg.Wait()
+
+	return results, errs
+}
+
+// settle decides the outcome of a write from the errors of the roots. It succeeds when at least the quorum of
+// roots succeeded, the other roots are then marked dirty to be repaired from the successful ones. When fewer
+// succeeded the write fails, and the roots that succeeded are repaired from the failed ones, which kept the state
+// the client expects after a failure. An entry without a bucket marks nothing dirty, for changes the resilver
+// does not repair.
+func (b *Backend) settle(entry *dirtyEntry, errs []error) error {
+	succeeded := lo.Filter(b.replicas, func(_ *replica, i int) bool { return errs[i] == nil })
+	failed := lo.Filter(b.replicas, func(_ *replica, i int) bool { return errs[i] != nil })
+
+	firstErr, _ := lo.Find(errs, func(err error) bool { return err != nil && !errors.Is(err, errReplicaOffline) })
+	firstErr = lo.CoalesceOrEmpty(firstErr, errReplicaOffline)
+
+	paths := func(replicas []*replica) []string {
+		return lo.Map(replicas, func(replica *replica, _ int) string { return replica.path })
+	}
+
+	switch {
+	case len(failed) == 0:
+		if entry.Bucket != "" {
+			b.clearDirty(entry)
+		}
+
+		return nil
+	case len(succeeded) >= b.quorum:
+		if entry.Bucket != "" {
+			b.markDirty(dirtyEntry{Bucket: entry.Bucket, Key: entry.Key, From: paths(succeeded)}, true)
+		}
+
+		return nil
+	case len(succeeded) > 0:
+		if entry.Bucket != "" {
+			b.markDirty(dirtyEntry{Bucket: entry.Bucket, Key: entry.Key, From: paths(failed)}, true)
+		}
+
+		return fmt.Errorf("%w: %d of %d storage roots, %d are required: %w", core.ErrWriteQuorum, len(succeeded),
+			len(b.replicas), b.quorum, firstErr)
+	default:
+		return firstErr
+	}
+}
+
+// lockKey is held by the writes of the object at key of the bucket, or of the bucket itself when key is empty,
+// and by their repairs.
+func lockKey(bucket, key string) string {
+	if key == "" {
+		return "mirror-storage-bucket:" + bucket
+	}
+
+	return "mirror-storage-object:" + bucket + "/" + key
+}
+
+func fileExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,54 @@
+package mirror_test
+
+import (
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+
+	"github.com/zhulik/d3/internal/backends/storage/mirror"
+	"github.com/zhulik/d3/internal/backends/storage/storagetest"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/sse"
+)
+
+func TestMirror(t *testing.T) {
+	t.Parallel()
+
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Mirror Suite")
+}
+
+func newConformanceBackend(compression core.CompressionAlgorithm) storagetest.BackendFactory {
+	return func(ctx context.Context) core.StorageBackend {
+		tmpDir := lo.Must(os.MkdirTemp("", "mirror-conformance-*"))
+		DeferCleanup(func() {
+			os.RemoveAll(tmpDir)
+		})
+
+		backend := &mirror.Backend{
+			Cfg: &core.Config{
+				MirrorStoragePaths:       []string{filepath.Join(tmpDir, "a"), filepath.Join(tmpDir, "b")},
+				MirrorResilverInterval:   time.Minute,
+				FolderStorageCompression: compression,
+				SSEMasterKey:             "conformance:" + base64.StdEncoding.EncodeToString(make([]byte, sse.KeySize)),
+			},
+			Locker: storagetest.NewLocker(),
+			Logger: slog.New(slog.DiscardHandler),
+		}
+		lo.Must0(backend.Init(ctx))
+
+		return backend
+	}
+}
+
+var _ = storagetest.DescribeStorageBackend("mirror", newConformanceBackend(core.CompressionNone))
+
+var _ = storagetest.DescribeStorageBackend("mirror with zstd compression", newConformanceBackend(core.CompressionZstd))
@@ -0,0 +1,152 @@
+package mirror //nolint:testpackage
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+
+	"github.com/zhulik/d3/internal/backends/storage/storagetest"
+	"github.com/zhulik/d3/internal/core"
+)
+
+var _ = Describe("Backend", func() {
+	var (
+		tmpDir  string
+		roots   []string
+		cfg     *core.Config
+		backend *Backend
+	)
+
+	newBackend := func(ctx SpecContext) {
+		backend = &Backend{Cfg: cfg, Locker: storagetest.NewLocker(), Logger: slog.New(slog.DiscardHandler)}
+		lo.Must0(backend.Init(ctx))
+	}
+
+	bucketPath := func(root string) string {
+		return filepath.Join(root, "buckets", "bucket")
+	}
+
+	blobPath := func(root, key string) string {
+		return filepath.Join(bucketPath(root), "objects", key, "blob")
+	}
+
+	putObject := func(ctx SpecContext, key, content string) error {
+		bucket := lo.Must(backend.HeadBucket(ctx, "bucket"))
+
+		return bucket.PutObject(ctx, key, core.PutObjectInput{Reader: strings.NewReader(content)})
+	}
+
+	getObject := func(ctx SpecContext, key string) (string, error) {
+		object, err := lo.Must(backend.HeadBucket(ctx, "bucket")).GetObject(ctx, key)
+		if err != nil {
+			return "", err
+		}
+		defer object.Close()
+
+		content, err := io.ReadAll(object)
+
+		return string(content), err
+	}
+
+	BeforeEach(func(ctx SpecContext) {
+		tmpDir = lo.Must(os.MkdirTemp("", "mirror-*"))
+		DeferCleanup(func() {
+			os.RemoveAll(tmpDir)
+		})
+
+		roots = []string{filepath.Join(tmpDir, "a"), filepath.Join(tmpDir, "b")}
+		cfg = &core.Config{MirrorStoragePaths: roots, MirrorResilverInterval: time.Minute}
+
+		newBackend(ctx)
+		lo.Must0(backend.CreateBucket(ctx, "bucket"))
+		lo.Must0(putObject(ctx, "key", "content"))
+	})
+
+	It("stores every object on every root", func() {
+		for _, root := range roots {
+			Expect(os.ReadFile(blobPath(root, "key"))).To(Equal([]byte("content")))
+		}
+
+		Expect(backend.dirtyEntries()).To(BeEmpty())
+	})
+
+	When("a root has a corrupted copy", func() {
+		BeforeEach(func() {
+			lo.Must0(os.WriteFile(blobPath(roots[0], "key"), []byte("CONTENT"), 0600))
+		})
+
+		It("serves the copy of another root and repairs it", func(ctx SpecContext) {
+			Expect(getObject(ctx, "key")).To(Equal("content"))
+			Expect(backend.dirtyEntry("bucket", "key")).NotTo(BeNil())
+
+			Expect(backend.Resilver(ctx)).To(Succeed())
+
+			Expect(os.ReadFile(blobPath(roots[0], "key"))).To(Equal([]byte("content")))
+			Expect(backend.dirtyEntries()).To(BeEmpty())
+		})
+
+		It("keeps the object dirty across restarts", func(ctx SpecContext) {
+			Expect(getObject(ctx, "key")).To(Equal("content"))
+
+			newBackend(ctx)
+			Expect(backend.dirtyEntry("bucket", "key")).NotTo(BeNil())
+
+			Expect(backend.Resilver(ctx)).To(Succeed())
+			Expect(os.ReadFile(blobPath(roots[0], "key"))).To(Equal([]byte("content")))
+		})
+	})
+
+	When("a root fails a write", func() {
+		BeforeEach(func() {
+			lo.Must0(os.RemoveAll(bucketPath(roots[0])))
+		})
+
+		It("succeeds with the quorum and repairs the root", func(ctx SpecContext) {
+			Expect(putObject(ctx, "other", "other content")).To(Succeed())
+			Expect(backend.dirtyEntry("bucket", "other").From).To(Equal([]string{roots[1]}))
+			Expect(getObject(ctx, "other")).To(Equal("other content"))
+
+			Expect(backend.Resilver(ctx)).To(Succeed())
+
+			Expect(os.ReadFile(blobPath(roots[0], "other"))).To(Equal([]byte("other content")))
+			Expect(backend.dirtyEntries()).To(BeEmpty())
+		})
+
+		It("fails without the quorum and reverts the other roots", func(ctx SpecContext) {
+			cfg.MirrorWriteQuorum = 2
+			newBackend(ctx)
+
+			Expect(putObject(ctx, "other", "other content")).To(MatchError(core.ErrWriteQuorum))
+
+			Expect(backend.Resilver(ctx)).To(Succeed())
+
+			Expect(blobPath(roots[1], "other")).NotTo(BeAnExistingFile())
+			Expect(backend.dirtyEntries()).To(BeEmpty())
+		})
+	})
+
+	When("a root is replaced by an empty disk", func() {
+		BeforeEach(func(ctx SpecContext) {
+			lo.Must0(os.RemoveAll(roots[1]))
+			newBackend(ctx)
+		})
+
+		It("serves reads from the other roots and resilvers it", func(ctx SpecContext) {
+			Expect(backend.replicas[1].resilvering.Load()).To(BeTrue())
+			Expect(getObject(ctx, "key")).To(Equal("content"))
+
+			Expect(backend.Resilver(ctx)).To(Succeed())
+
+			Expect(backend.replicas[1].resilvering.Load()).To(BeFalse())
+			Expect(resilverMarkerPath(roots[1])).NotTo(BeAnExistingFile())
+			Expect(os.ReadFile(blobPath(roots[1], "key"))).To(Equal([]byte("content")))
+		})
+	})
+})
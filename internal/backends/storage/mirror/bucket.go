@@ -0,0 +1,381 @@
+package mirror
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/backends/storage/folder"
+	"github.com/zhulik/d3/internal/core"
+)
+
+// Bucket is a bucket of the mirror. Its name and settings are the ones of the root it was looked up on, changes
+// are written to every root.
+type Bucket struct {
+	backend *Backend
+	name    string
+	primary core.Bucket
+}
+
+func (b *Bucket) Name() string {
+	return b.name
+}
+
+func (b *Bucket) ARN() string {
+	return b.primary.ARN()
+}
+
+func (b *Bucket) Region() string {
+	return b.primary.Region()
+}
+
+func (b *Bucket) CreationDate() time.Time {
+	return b.primary.CreationDate()
+}
+
+func (b *Bucket) HeadObject(ctx context.Context, key string) (core.Object, error) {
+	return b.getObject(ctx, key)
+}
+
+func (b *Bucket) GetObject(ctx context.Context, key string) (core.Object, error) {
+	return b.getObject(ctx, key)
+}
+
+func (b *Bucket) PutObject(ctx context.Context, key string, input core.PutObjectInput) error {
+	bodies := newTee(input.Reader, b.backend.replicas)
+	defer bodies.close()
+
+	cancel, err := b.lockQuota(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	_, err = write(ctx, b.backend, b.name, key, feed(bodies, inBucket(b.name, func(ctx context.Context,
+		replica *replica, bucket core.Bucket) (struct{}, error) {
+		replicaInput := input
+		replicaInput.Reader = bodies.body(replica)
+
+		return struct{}{}, bucket.PutObject(ctx, key, replicaInput)
+	})))
+
+	return err
+}
+
+// CopyObject copies the source to every root from the copy of the source on that root.
+func (b *Bucket) CopyObject(ctx context.Context, dstKey string, input core.CopyObjectInput) (*core.CopyObjectResult, error) { //nolint:lll
+	source := input.Source.(*Object) //nolint:forcetypeassert
+
+	return writeObject(ctx, b, dstKey, func(ctx context.Context, replica *replica,
+		bucket core.Bucket) (*core.CopyObjectResult, error) {
+		object, _, err := openReplica(ctx, replica, source.bucket.name, source.key, source.customerKey)
+		if err != nil {
+			return nil, err
+		}
+		defer object.Close()
+
+		replicaInput := input
+		replicaInput.Source = object
+
+		return bucket.CopyObject(ctx, dstKey, replicaInput)
+	})
+}
+
+func (b *Bucket) ListObjectsV2(ctx context.Context, input core.ListObjectsV2Input) (*core.ListV2Result, error) {
+	return readBucket(ctx, b, func(ctx context.Context, bucket core.Bucket) (*core.ListV2Result, error) {
+		return bucket.ListObjectsV2(ctx, input)
+	})
+}
+
+// DeleteObjects deletes the objects one by one, so every object that some roots fail to delete is repaired on its
+// own.
+func (b *Bucket) DeleteObjects(ctx context.Context, input core.DeleteObjectsInput) ([]core.DeleteResult, error) {
+	results := []core.DeleteResult{}
+
+	for _, key := range input.Keys {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		_, err := writeObject(ctx, b, key, func(ctx context.Context, _ *replica, bucket core.Bucket) (struct{}, error) {
+			results, err := bucket.DeleteObjects(ctx, core.DeleteObjectsInput{
+				Keys:             []string{key},
+				BypassGovernance: input.BypassGovernance,
+			})
+			if err != nil {
+				return struct{}{}, err
+			}
+
+			return struct{}{}, results[0].Error
+		})
+		if err != nil {
+			results = append(results, core.DeleteResult{Key: key, Error: err})
+		} else if !input.Quiet {
+			results = append(results, core.DeleteResult{Key: key, Error: nil})
+		}
+	}
+
+	return results, nil
+}
+
+// CreateMultipartUpload starts the upload on every root with the same ID. Incomplete uploads are not repaired,
+// the object of an upload some roots fail to complete is.
+func (b *Bucket) CreateMultipartUpload(ctx context.Context, key string, metadata core.ObjectMetadata,
+	encryption *core.ServerSideEncryption) (string, error) {
+	id := uuid.NewString()
+
+	_, err := writeUpload(ctx, b, inBucket(b.name, func(ctx context.Context, _ *replica,
+		bucket core.Bucket) (struct{}, error) {
+		folderBucket := bucket.(*folder.Bucket) //nolint:forcetypeassert
+
+		return struct{}{}, folderBucket.CreateMultipartUploadWithID(ctx, key, id, metadata, encryption)
+	}))
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (b *Bucket) UploadPart(ctx context.Context, key string, uploadID string, partNumber int, body io.Reader,
+	encryption *core.ServerSideEncryption) (string, error) {
+	bodies := newTee(body, b.backend.replicas)
+	defer bodies.close()
+
+	return writeUpload(ctx, b, feed(bodies, inBucket(b.name, func(ctx context.Context, replica *replica,
+		bucket core.Bucket) (string, error) {
+		return bucket.UploadPart(ctx, key, uploadID, partNumber, bodies.body(replica), encryption)
+	})))
+}
+
+// CompleteMultipartUpload completes the upload on every root, it is aborted on the roots that fail to complete it
+// once it is completed on enough of them.
+func (b *Bucket) CompleteMultipartUpload(ctx context.Context, key string, uploadID string,
+	parts []core.CompletePart) (*core.ObjectMetadata, error) {
+	metadata, err := writeObject(ctx, b, key, func(ctx context.Context, _ *replica,
+		bucket core.Bucket) (*core.ObjectMetadata, error) {
+		return bucket.CompleteMultipartUpload(ctx, key, uploadID, parts)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The roots that completed the upload no longer have it.
+	fanOut(ctx, b.backend.replicas, inBucket(b.name, func(ctx context.Context, _ *replica,
+		bucket core.Bucket) (struct{}, error) {
+		return struct{}{}, bucket.AbortMultipartUpload(ctx, key, uploadID)
+	}))
+
+	return metadata, nil
+}
+
+func (b *Bucket) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
+	_, err := writeUpload(ctx, b, inBucket(b.name, func(ctx context.Context, _ *replica,
+		bucket core.Bucket) (struct{}, error) {
+		return struct{}{}, bucket.AbortMultipartUpload(ctx, key, uploadID)
+	}))
+
+	return err
+}
+
+func (b *Bucket) ListMultipartUploads(ctx context.Context, input core.ListMultipartUploadsInput) (*core.ListMultipartUploadsResult, error) { //nolint:lll
+	return readBucket(ctx, b, func(ctx context.Context, bucket core.Bucket) (*core.ListMultipartUploadsResult, error) {
+		return bucket.ListMultipartUploads(ctx, input)
+	})
+}
+
+func (b *Bucket) ListParts(ctx context.Context, key string, input core.ListPartsInput) (*core.ListPartsResult, error) {
+	return readBucket(ctx, b, func(ctx context.Context, bucket core.Bucket) (*core.ListPartsResult, error) {
+		return bucket.ListParts(ctx, key, input)
+	})
+}
+
+func (b *Bucket) PutObjectTagging(ctx context.Context, key string, tags map[string]string) error {
+	_, err := writeObject(ctx, b, key, func(ctx context.Context, _ *replica, bucket core.Bucket) (struct{}, error) {
+		return struct{}{}, bucket.PutObjectTagging(ctx, key, tags)
+	})
+
+	return err
+}
+
+func (b *Bucket) DeleteObjectTagging(ctx context.Context, key string) error {
+	_, err := writeObject(ctx, b, key, func(ctx context.Context, _ *replica, bucket core.Bucket) (struct{}, error) {
+		return struct{}{}, bucket.DeleteObjectTagging(ctx, key)
+	})
+
+	return err
+}
+
+func (b *Bucket) PutObjectRetention(ctx context.Context, key string, retention *core.ObjectRetention,
+	bypassGovernance bool) error {
+	_, err := writeObject(ctx, b, key, func(ctx context.Context, _ *replica, bucket core.Bucket) (struct{}, error) {
+		return struct{}{}, bucket.PutObjectRetention(ctx, key, retention, bypassGovernance)
+	})
+
+	return err
+}
+
+func (b *Bucket) PutObjectLegalHold(ctx context.Context, key string, hold bool) error {
+	_, err := writeObject(ctx, b, key, func(ctx context.Context, _ *replica, bucket core.Bucket) (struct{}, error) {
+		return struct{}{}, bucket.PutObjectLegalHold(ctx, key, hold)
+	})
+
+	return err
+}
+
+func (b *Bucket) Logging() *core.BucketLogging {
+	return b.primary.Logging()
+}
+
+func (b *Bucket) PutLogging(ctx context.Context, logging *core.BucketLogging) error {
+	return writeSettings(ctx, b, func(ctx context.Context, bucket core.Bucket) error {
+		return bucket.PutLogging(ctx, logging)
+	})
+}
+
+func (b *Bucket) Encryption() *core.BucketEncryption {
+	return b.primary.Encryption()
+}
+
+func (b *Bucket) PutEncryption(ctx context.Context, encryption *core.BucketEncryption) error {
+	return writeSettings(ctx, b, func(ctx context.Context, bucket core.Bucket) error {
+		return bucket.PutEncryption(ctx, encryption)
+	})
+}
+
+func (b *Bucket) Compression() *core.BucketCompression {
+	return b.primary.Compression()
+}
+
+func (b *Bucket) PutCompression(ctx context.Context, compression *core.BucketCompression) error {
+	return writeSettings(ctx, b, func(ctx context.Context, bucket core.Bucket) error {
+		return bucket.PutCompression(ctx, compression)
+	})
+}
+
+func (b *Bucket) ObjectLock() *core.BucketObjectLock {
+	return b.primary.ObjectLock()
+}
+
+func (b *Bucket) PutObjectLock(ctx context.Context, lock core.BucketObjectLock) error {
+	return writeSettings(ctx, b, func(ctx context.Context, bucket core.Bucket) error {
+		return bucket.PutObjectLock(ctx, lock)
+	})
+}
+
+func (b *Bucket) Quota() *core.BucketQuota {
+	return b.primary.Quota()
+}
+
+func (b *Bucket) PutQuota(ctx context.Context, quota *core.BucketQuota) error {
+	return writeSettings(ctx, b, func(ctx context.Context, bucket core.Bucket) error {
+		return bucket.PutQuota(ctx, quota)
+	})
+}
+
+func (b *Bucket) Usage(ctx context.Context) (*core.BucketUsage, error) {
+	return readBucket(ctx, b, func(ctx context.Context, bucket core.Bucket) (*core.BucketUsage, error) {
+		return bucket.Usage(ctx)
+	})
+}
+
+// RescanUsage rescans the usage on every root and returns the one of the first.
+func (b *Bucket) RescanUsage(ctx context.Context) (*core.BucketUsage, error) {
+	return write(ctx, b.backend, b.name, "", func(ctx context.Context, replica *replica) (*core.BucketUsage, error) {
+		bucket, err := replica.backend.HeadBucket(ctx, b.name)
+		if err != nil {
+			return nil, err
+		}
+
+		return bucket.RescanUsage(ctx)
+	})
+}
+
+// writeObject writes the object at key to every root with fn, see write.
+func writeObject[T any](ctx context.Context, b *Bucket, key string,
+	fn func(context.Context, *replica, core.Bucket) (T, error)) (T, error) {
+	cancel, err := b.lockQuota(ctx, key)
+	if err != nil {
+		var zero T
+
+		return zero, err
+	}
+	defer cancel()
+
+	return write(ctx, b.backend, b.name, key, inBucket(b.name, fn))
+}
+
+// lockQuota serializes the writes of the objects of a bucket that has a quota. Each root checks the quota on its
+// own, concurrent writes of different objects could otherwise be accepted by different roots and overshoot it.
+func (b *Bucket) lockQuota(ctx context.Context, key string) (context.CancelFunc, error) {
+	if key == "" || b.primary.Quota() == nil {
+		return func() {}, nil
+	}
+
+	_, cancel, err := b.backend.Locker.Lock(ctx, "mirror-storage-quota:"+b.name)
+
+	return cancel, err
+}
+
+// writeSettings changes the settings of the bucket on every root with fn, the bucket then reflects the change.
+func writeSettings(ctx context.Context, b *Bucket, fn func(context.Context, core.Bucket) error) error {
+	_, err := writeObject(ctx, b, "", func(ctx context.Context, _ *replica, bucket core.Bucket) (struct{}, error) {
+		return struct{}{}, fn(ctx, bucket)
+	})
+	if err != nil {
+		return err
+	}
+
+	bucket, err := b.backend.HeadBucket(ctx, b.name)
+	if err != nil {
+		return err
+	}
+
+	b.primary = bucket.(*Bucket).primary //nolint:forcetypeassert
+
+	return nil
+}
+
+// writeUpload changes an incomplete multipart upload on every root with fn. It succeeds like write does, without
+// marking anything dirty.
+func writeUpload[T any](ctx context.Context, b *Bucket, fn func(context.Context, *replica) (T, error)) (T, error) {
+	var zero T
+
+	results, errs := fanOut(ctx, b.backend.replicas, fn)
+	if err := b.backend.settle(&dirtyEntry{}, errs); err != nil {
+		return zero, err
+	}
+
+	return results[lo.IndexOf(errs, nil)], nil
+}
+
+// inBucket calls fn with the bucket of the root.
+func inBucket[T any](name string,
+	fn func(context.Context, *replica, core.Bucket) (T, error)) func(context.Context, *replica) (T, error) {
+	return func(ctx context.Context, replica *replica) (T, error) {
+		bucket, err := replica.backend.HeadBucket(ctx, name)
+		if err != nil {
+			var zero T
+
+			return zero, err
+		}
+
+		return fn(ctx, replica, bucket)
+	}
+}
+
+// readBucket calls fn with the bucket of the readable roots in turn until it succeeds.
+func readBucket[T any](ctx context.Context, b *Bucket, fn func(context.Context, core.Bucket) (T, error)) (T, error) {
+	return read(ctx, b.backend.readers(b.name, ""), func(ctx context.Context, replica *replica) (T, error) {
+		bucket, err := replica.backend.HeadBucket(ctx, b.name)
+		if err != nil {
+			var zero T
+
+			return zero, err
+		}
+
+		return fn(ctx, bucket)
+	})
+}
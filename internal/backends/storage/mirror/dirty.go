@@ -0,0 +1,178 @@
+package mirror
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/zhulik/d3/internal/backends/storage/folder"
+	"github.com/zhulik/d3/pkg/yaml"
+)
+
+// The objects and buckets the roots disagree on are recorded as dirty entries, in memory and as a file per entry
+// in the tmp folder of every online root, so they are repaired after a restart too. The file of an entry is named
+// after its bucket and key, a later write to the same object replaces it.
+
+const (
+	mirrorFolder     = "mirror"
+	dirtyFolder      = "dirty"
+	resilverFilename = "resilver"
+)
+
+// dirtyEntry is an object, or a bucket when Key is empty, that some roots missed the latest version of.
+type dirtyEntry struct {
+	Bucket string `yaml:"bucket"`
+	Key    string `yaml:"key,omitempty"`
+	// From are the roots that have the latest version, the others are repaired from the first intact one.
+	From []string `yaml:"from"`
+}
+
+func (e *dirtyEntry) id() string {
+	sum := sha256.Sum256([]byte(e.Bucket + "\x00" + e.Key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func dirtyPath(root string) string {
+	return filepath.Join(root, folder.TmpFolder, mirrorFolder, dirtyFolder)
+}
+
+// resilverMarkerPath marks a root that is copied everything to, as long as it exists.
+func resilverMarkerPath(root string) string {
+	return filepath.Join(root, folder.TmpFolder, mirrorFolder, resilverFilename)
+}
+
+// dirtyEntry returns a copy of the entry of the object at key of the bucket, nil when it is not dirty.
+func (b *Backend) dirtyEntry(bucket, key string) *dirtyEntry {
+	b.dirtyMu.Lock()
+	defer b.dirtyMu.Unlock()
+
+	entry, ok := b.dirty[(&dirtyEntry{Bucket: bucket, Key: key}).id()]
+	if !ok {
+		return nil
+	}
+
+	return &dirtyEntry{Bucket: entry.Bucket, Key: entry.Key, From: slices.Clone(entry.From)}
+}
+
+// dirtyEntries returns copies of all entries.
+func (b *Backend) dirtyEntries() []*dirtyEntry {
+	b.dirtyMu.Lock()
+	defer b.dirtyMu.Unlock()
+
+	entries := make([]*dirtyEntry, 0, len(b.dirty))
+
+	for _, entry := range b.dirty {
+		entries = append(entries, &dirtyEntry{Bucket: entry.Bucket, Key: entry.Key, From: slices.Clone(entry.From)})
+	}
+
+	return entries
+}
+
+// markDirty records the entry and wakes the resilver up. An existing entry of the same object is only replaced
+// when replace is set, writes know which roots have the latest version, reads that fail over only guess.
+func (b *Backend) markDirty(entry dirtyEntry, replace bool) {
+	if !b.recordDirty(&entry, replace) {
+		return
+	}
+
+	select {
+	case b.resilverNow <- struct{}{}:
+	default:
+	}
+}
+
+// recordDirty stores and saves the entry, it tells whether it did.
+func (b *Backend) recordDirty(entry *dirtyEntry, replace bool) bool {
+	b.dirtyMu.Lock()
+
+	if _, ok := b.dirty[entry.id()]; ok && !replace {
+		b.dirtyMu.Unlock()
+
+		return false
+	}
+
+	b.dirty[entry.id()] = entry
+	b.dirtyMu.Unlock()
+
+	b.saveDirty(entry)
+
+	return true
+}
+
+// saveDirty writes the entry to every online root, it is lost with the roots it could not be written to.
+func (b *Backend) saveDirty(entry *dirtyEntry) {
+	for _, replica := range b.replicas {
+		if !replica.online {
+			continue
+		}
+
+		if err := yaml.MarshalToFile(entry, filepath.Join(dirtyPath(replica.path), entry.id()+".yaml")); err != nil {
+			b.Logger.Error("failed to save dirty mirror entry", "root", replica.path, "bucket", entry.Bucket,
+				"key", entry.Key, "error", err)
+		}
+	}
+}
+
+func (b *Backend) clearDirty(entry *dirtyEntry) {
+	b.dirtyMu.Lock()
+	_, ok := b.dirty[entry.id()]
+	delete(b.dirty, entry.id())
+	b.dirtyMu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	for _, replica := range b.replicas {
+		if !replica.online {
+			continue
+		}
+
+		err := os.Remove(filepath.Join(dirtyPath(replica.path), entry.id()+".yaml"))
+		if err != nil && !os.IsNotExist(err) {
+			b.Logger.Error("failed to remove dirty mirror entry", "root", replica.path, "bucket", entry.Bucket,
+				"key", entry.Key, "error", err)
+		}
+	}
+}
+
+// loadDirty adds the entries saved to the online roots that are not known yet, the ones of other instances
+// sharing the roots or of a previous run.
+func (b *Backend) loadDirty() {
+	for _, replica := range b.replicas {
+		if !replica.online {
+			continue
+		}
+
+		entries, err := os.ReadDir(dirtyPath(replica.path))
+		if err != nil {
+			b.Logger.Error("failed to list dirty mirror entries", "root", replica.path, "error", err)
+
+			continue
+		}
+
+		for _, dirEntry := range entries {
+			if !strings.HasSuffix(dirEntry.Name(), ".yaml") {
+				continue
+			}
+
+			entry, err := yaml.UnmarshalFromFile[dirtyEntry](filepath.Join(dirtyPath(replica.path), dirEntry.Name()))
+			if err != nil {
+				b.Logger.Error("failed to load dirty mirror entry", "root", replica.path, "file", dirEntry.Name(),
+					"error", err)
+
+				continue
+			}
+
+			b.dirtyMu.Lock()
+			if _, ok := b.dirty[entry.id()]; !ok {
+				b.dirty[entry.id()] = &entry
+			}
+			b.dirtyMu.Unlock()
+		}
+	}
+}
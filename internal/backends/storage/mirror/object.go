@@ -0,0 +1,299 @@
+package mirror
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/backends/storage/folder"
+	"github.com/zhulik/d3/internal/core"
+)
+
+// verifiedReadSize is the size up to which objects are read through and verified before their first byte is
+// served, a corrupted copy is then replaced by the one of another root without the client noticing. Larger
+// objects are verified as they are streamed, a mismatch then fails the read at its end. Either way, a copy that
+// fails to be read is replaced by the one of another root from where the read stopped.
+const verifiedReadSize = 64 << 20
+
+var errOtherVersion = errors.New("the storage root has another version of the object") //nolint:gochecknoglobals
+
+// Object reads the copy of an object of one of the readable roots, switching to the copy of another one when it
+// turns out to be unreadable or corrupted. The roots that fail are marked dirty to be repaired.
+type Object struct {
+	bucket *Bucket
+	key    string
+
+	// candidates are the roots the object may be read from, in order, next is the one to try next.
+	candidates []*replica
+	next       int
+	replica    *replica
+	current    *folder.Object
+	failed     bool
+
+	metadata    *core.ObjectMetadata
+	customerKey []byte
+
+	started bool
+	offset  int64
+	// hash sums the content up as it is read from the start, nil when it is not verified as it is read.
+	hash hash.Hash
+}
+
+func (b *Bucket) getObject(ctx context.Context, key string) (*Object, error) {
+	object := &Object{bucket: b, key: key, candidates: b.backend.readers(b.name, key)}
+
+	if err := object.open(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	return object, nil
+}
+
+func (o *Object) Key() string {
+	return o.key
+}
+
+func (o *Object) LastModified() time.Time {
+	return o.metadata.LastModified
+}
+
+func (o *Object) Size() int64 {
+	return o.metadata.Size
+}
+
+func (o *Object) Metadata() *core.ObjectMetadata {
+	return o.metadata
+}
+
+func (o *Object) SetCustomerKey(key []byte) error {
+	if err := o.current.SetCustomerKey(key); err != nil {
+		return err
+	}
+
+	o.customerKey = key
+
+	return nil
+}
+
+func (o *Object) Read(p []byte) (int, error) {
+	if err := o.start(); err != nil {
+		return 0, err
+	}
+
+	for {
+		n, err := o.current.Read(p)
+		o.offset += int64(n)
+
+		if o.hash != nil {
+			o.hash.Write(p[:n])
+		}
+
+		switch {
+		case errors.Is(err, io.EOF):
+			return n, o.finish()
+		case err == nil || n > 0:
+			// A failed read is reported by the next one.
+			return n, nil
+		}
+
+		if err := o.failover(err); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Seek seeks the copy being read. The content is only verified as it is read when it is read from the start.
+func (o *Object) Seek(offset int64, whence int) (int64, error) {
+	if err := o.start(); err != nil {
+		return 0, err
+	}
+
+	position, err := o.current.Seek(offset, whence)
+	if err != nil {
+		return position, err
+	}
+
+	if position != o.offset {
+		o.hash = nil
+	}
+
+	o.offset = position
+
+	if position == 0 {
+		o.resetHash()
+	}
+
+	return position, nil
+}
+
+func (o *Object) Close() error {
+	return o.current.Close()
+}
+
+// open opens the copy of the next candidate root that has a readable one, of the same version as match unless it
+// is nil. Once a copy is opened after another root failed, the object is marked dirty.
+func (o *Object) open(ctx context.Context, match *core.ObjectMetadata) error {
+	var firstErr error
+
+	for o.next < len(o.candidates) {
+		replica := o.candidates[o.next]
+		o.next++
+
+		object, metadata, err := openReplica(ctx, replica, o.bucket.name, o.key, o.customerKey)
+		if err == nil && match != nil && !sameVersion(metadata, match) {
+			object.Close()
+
+			err = errOtherVersion
+		}
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			firstErr = lo.CoalesceOrEmpty(firstErr, err)
+			o.failed = true
+
+			continue
+		}
+
+		o.replica, o.current = replica, object
+		if o.metadata == nil {
+			o.metadata = metadata
+		}
+
+		if o.failed {
+			o.bucket.backend.markDirty(dirtyEntry{Bucket: o.bucket.name, Key: o.key, From: []string{replica.path}}, false)
+		}
+
+		return nil
+	}
+
+	return lo.CoalesceOrEmpty(firstErr, core.ErrObjectNotFound)
+}
+
+// failover switches to the copy of the next root, from where the read stopped. It returns cause when no other
+// root has a readable copy.
+func (o *Object) failover(cause error) error {
+	// The copies of the other roots cannot be read without the right key either.
+	if errors.Is(cause, core.ErrSSECustomerKeyRequired) || errors.Is(cause, core.ErrSSECustomerKeyMismatch) {
+		return cause
+	}
+
+	o.bucket.backend.Logger.Warn("failed to read object from mirror storage root", "root", o.replica.path,
+		"bucket", o.bucket.name, "key", o.key, "error", cause)
+
+	o.current.Close()
+	o.failed = true
+
+	for {
+		if err := o.open(context.Background(), o.metadata); err != nil {
+			return cause
+		}
+
+		if _, err := o.current.Seek(o.offset, io.SeekStart); err == nil {
+			return nil
+		}
+
+		o.current.Close()
+	}
+}
+
+// start verifies small objects before they are first read or seeked, and starts summing large ones up.
+func (o *Object) start() error {
+	if o.started {
+		return nil
+	}
+
+	o.started = true
+
+	if o.metadata.Size > verifiedReadSize {
+		o.resetHash()
+
+		return nil
+	}
+
+	for {
+		err := verifyContent(context.Background(), o.current, o.metadata)
+		if err == nil {
+			_, err = o.current.Seek(0, io.SeekStart)
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		if err := o.failover(err); err != nil {
+			return err
+		}
+	}
+}
+
+func (o *Object) resetHash() {
+	o.hash = nil
+
+	if o.metadata.Size > verifiedReadSize && checksummed(o.metadata) {
+		o.hash = sha256.New()
+	}
+}
+
+// finish checks the checksum of an object read from the start to its end, it returns io.EOF when it matches.
+func (o *Object) finish() error {
+	if o.hash == nil {
+		return io.EOF
+	}
+
+	sha256sum := hex.EncodeToString(o.hash.Sum(nil))
+	o.hash = nil
+
+	if sha256sum == o.metadata.SHA256 {
+		return io.EOF
+	}
+
+	// The roots that have an intact copy are found by the repair.
+	o.bucket.backend.markDirty(dirtyEntry{Bucket: o.bucket.name, Key: o.key}, false)
+
+	return fmt.Errorf("%w: the copy of %s is corrupted: %s != %s", core.ErrObjectChecksumMismatch, o.replica.path,
+		sha256sum, o.metadata.SHA256)
+}
+
+// openReplica opens the copy of the object of the root, unlocked with customerKey unless it is nil.
+func openReplica(ctx context.Context, replica *replica, bucket, key string,
+	customerKey []byte) (*folder.Object, *core.ObjectMetadata, error) {
+	replicaBucket, err := replica.backend.HeadBucket(ctx, bucket)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	object, err := replicaBucket.GetObject(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	folderObject := object.(*folder.Object) //nolint:forcetypeassert
+
+	metadata, err := folderObject.LoadMetadata()
+	if err == nil && customerKey != nil {
+		err = folderObject.SetCustomerKey(customerKey)
+	}
+
+	if err != nil {
+		folderObject.Close()
+
+		return nil, nil, err
+	}
+
+	return folderObject, metadata, nil
+}
+
+// sameVersion tells whether two copies of an object are of the same version. Copies of SSE-C multipart uploads
+// that were never read with their key are only known by their size, they are never switched between.
+func sameVersion(a, b *core.ObjectMetadata) bool {
+	return a.Size == b.Size && checksummed(a) && a.SHA256 == b.SHA256
+}
@@ -0,0 +1,385 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/backends/storage/folder"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/smartio"
+)
+
+var errNoIntactCopy = errors.New("no online storage root has an intact copy") //nolint:gochecknoglobals
+
+// Run resilvers the mirror every MirrorResilverInterval, and as soon as an object or a bucket is marked dirty.
+func (b *Backend) Run(ctx context.Context) error {
+	ticker := time.NewTicker(b.Cfg.MirrorResilverInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := b.Resilver(ctx); err != nil && ctx.Err() == nil {
+			b.Logger.Error("failed to resilver the mirror", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-b.resilverNow:
+		}
+	}
+}
+
+// Resilver copies everything to the roots replaced by an empty disk, then repairs the dirty objects and buckets.
+// Entries that cannot be repaired yet, because a root is offline or no root has an intact copy, are kept.
+func (b *Backend) Resilver(ctx context.Context) error {
+	ctx, cancel, err := b.Locker.Lock(ctx, "mirror-storage-resilver")
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	var errs []error
+
+	for _, replica := range b.replicas {
+		if replica.online && replica.resilvering.Load() {
+			if err := b.resilverReplica(ctx, replica); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", replica.path, err))
+			}
+		}
+	}
+
+	b.loadDirty()
+
+	for _, entry := range b.dirtyEntries() {
+		err := b.withLock(ctx, lockKey(entry.Bucket, entry.Key), func() error {
+			// The entry may have been repaired, or replaced by a write, meanwhile.
+			entry := b.dirtyEntry(entry.Bucket, entry.Key)
+			if entry == nil {
+				return nil
+			}
+
+			return b.repair(ctx, entry)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s/%s: %w", entry.Bucket, entry.Key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// repair copies the object or the bucket of the entry from a root that has an intact copy to the other online
+// roots, the caller must hold its lock. The entry is cleared once all roots have the latest version.
+func (b *Backend) repair(ctx context.Context, entry *dirtyEntry) error {
+	source, err := b.repairSource(ctx, entry)
+	if err != nil {
+		return err
+	}
+
+	var (
+		errs     []error
+		repaired bool
+	)
+
+	if !lo.Contains(entry.From, source.path) {
+		entry.From = append(entry.From, source.path)
+		repaired = true
+	}
+
+	for _, target := range b.replicas {
+		if !target.online || lo.Contains(entry.From, target.path) {
+			continue
+		}
+
+		if err := syncReplica(ctx, target, source, entry.Bucket, entry.Key); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target.path, err))
+
+			continue
+		}
+
+		entry.From = append(entry.From, target.path)
+		repaired = true
+	}
+
+	switch {
+	case lo.EveryBy(b.replicas, func(replica *replica) bool { return lo.Contains(entry.From, replica.path) }):
+		b.clearDirty(entry)
+	case repaired:
+		b.recordDirty(entry, true)
+	}
+
+	return errors.Join(errs...)
+}
+
+// repairSource returns the first root of the entry that has an intact copy, which may be missing as the latest
+// version may be a deletion. Reads that fail over only guess the roots of their entries, when none of them has an
+// intact copy the first readable root that has one is used instead.
+func (b *Backend) repairSource(ctx context.Context, entry *dirtyEntry) (*replica, error) {
+	from, others := lo.FilterReject(b.replicas, func(replica *replica, _ int) bool {
+		return lo.Contains(entry.From, replica.path)
+	})
+
+	source, err := firstIntact(ctx, lo.Filter(from, isOnline), entry.Bucket, entry.Key, false)
+	if err == nil || ctx.Err() != nil {
+		return source, err
+	}
+
+	readable := lo.Filter(others, func(replica *replica, _ int) bool {
+		return replica.online && !replica.resilvering.Load()
+	})
+
+	source, otherErr := firstIntact(ctx, readable, entry.Bucket, entry.Key, true)
+	if otherErr != nil {
+		return nil, errors.Join(err, otherErr)
+	}
+
+	return source, nil
+}
+
+func isOnline(replica *replica, _ int) bool {
+	return replica.online
+}
+
+// resilverReplica copies every bucket and object to a root replaced by an empty disk, each from the first healthy
+// root that has an intact copy, and removes what the healthy roots do not have. Dirty objects and buckets are left
+// to their repair.
+func (b *Backend) resilverReplica(ctx context.Context, target *replica) error {
+	sources := lo.Filter(b.replicas, func(replica *replica, _ int) bool {
+		return replica != target && replica.online && !replica.resilvering.Load()
+	})
+
+	names, err := read(ctx, sources, func(ctx context.Context, replica *replica) ([]string, error) {
+		return bucketNames(ctx, replica)
+	})
+	if err != nil {
+		return err
+	}
+
+	targetNames, err := bucketNames(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	names = append(names, targetNames...)
+	slices.Sort(names)
+
+	for _, name := range slices.Compact(names) {
+		if err := b.resilverBucket(ctx, target, sources, name); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	if err := os.Remove(resilverMarkerPath(target.path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	target.resilvering.Store(false)
+	b.Logger.Info("mirror storage root is resilvered", "root", target.path)
+
+	return nil
+}
+
+func (b *Backend) resilverBucket(ctx context.Context, target *replica, sources []*replica, name string) error {
+	err := b.withLock(ctx, lockKey(name, ""), func() error {
+		return b.resilverEntry(ctx, target, sources, name, "")
+	})
+	if err != nil {
+		return err
+	}
+
+	keys, err := read(ctx, sources, func(ctx context.Context, replica *replica) ([]string, error) {
+		return objectKeys(ctx, replica, name)
+	})
+	if err != nil {
+		return err
+	}
+
+	targetKeys, err := objectKeys(ctx, target, name)
+	if err != nil {
+		return err
+	}
+
+	keys = append(keys, targetKeys...)
+	slices.Sort(keys)
+
+	for _, key := range slices.Compact(keys) {
+		err := b.withLock(ctx, lockKey(name, key), func() error {
+			return b.resilverEntry(ctx, target, sources, name, key)
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// resilverEntry copies the object at key of the bucket, or the bucket when key is empty, to the target unless it
+// is dirty. The caller must hold its lock.
+func (b *Backend) resilverEntry(ctx context.Context, target *replica, sources []*replica, bucket, key string) error {
+	if b.dirtyEntry(bucket, key) != nil {
+		return nil
+	}
+
+	source, err := firstIntact(ctx, sources, bucket, key, false)
+	if err != nil {
+		return err
+	}
+
+	return syncReplica(ctx, target, source, bucket, key)
+}
+
+func (b *Backend) withLock(ctx context.Context, key string, fn func() error) error {
+	_, cancel, err := b.Locker.Lock(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return fn()
+}
+
+// syncReplica copies the object at key of the bucket, or the bucket when key is empty, from source to target.
+// Objects are copied along with their bucket when the target does not have it.
+func syncReplica(ctx context.Context, target, source *replica, bucket, key string) error {
+	if key == "" {
+		return target.backend.SyncBucket(ctx, bucket, source.backend)
+	}
+
+	targetBucket, err := target.backend.HeadBucket(ctx, bucket)
+	if errors.Is(err, core.ErrBucketNotFound) {
+		if err := target.backend.SyncBucket(ctx, bucket, source.backend); err != nil {
+			return err
+		}
+
+		targetBucket, err = target.backend.HeadBucket(ctx, bucket)
+		if errors.Is(err, core.ErrBucketNotFound) {
+			return nil
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return targetBucket.(*folder.Bucket).SyncObject(ctx, key, source.backend) //nolint:forcetypeassert
+}
+
+// firstIntact returns the first of the roots that has an intact copy of the object at key of the bucket, or of
+// the bucket when key is empty. A missing copy is only intact unless present is set.
+func firstIntact(ctx context.Context, replicas []*replica, bucket, key string, present bool) (*replica, error) {
+	errs := []error{errNoIntactCopy}
+
+	for _, replica := range replicas {
+		exists, err := verify(ctx, replica, bucket, key)
+		if err == nil && (exists || !present) {
+			return replica, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", replica.path, err))
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// verify checks whether the root has an intact copy of the object at key of the bucket, or of the bucket when key
+// is empty, and whether it has one at all. A missing copy is intact. The content of SSE-C objects cannot be read
+// without the key of the client, only their metadata is checked.
+func verify(ctx context.Context, replica *replica, bucket, key string) (bool, error) {
+	replicaBucket, err := replica.backend.HeadBucket(ctx, bucket)
+	if errors.Is(err, core.ErrBucketNotFound) {
+		return false, nil
+	}
+
+	if err != nil || key == "" {
+		return err == nil, err
+	}
+
+	object, err := replicaBucket.HeadObject(ctx, key)
+	if errors.Is(err, core.ErrObjectNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	folderObject := object.(*folder.Object) //nolint:forcetypeassert
+	defer folderObject.Close()
+
+	metadata, err := folderObject.LoadMetadata()
+	if err != nil {
+		return false, err
+	}
+
+	if metadata.Encryption != nil && metadata.Encryption.IsCustomer() {
+		return true, nil
+	}
+
+	return true, verifyContent(ctx, folderObject, metadata)
+}
+
+// verifyContent reads the object through. It fails when the content does not match the checksum of the object,
+// or when it cannot be decrypted or decompressed.
+func verifyContent(ctx context.Context, object io.Reader, metadata *core.ObjectMetadata) error {
+	_, sha256sum, err := smartio.Copy(ctx, io.Discard, object)
+	if err != nil {
+		return err
+	}
+
+	if checksummed(metadata) && sha256sum != metadata.SHA256 {
+		return fmt.Errorf("%w: %s != %s", core.ErrObjectChecksumMismatch, sha256sum, metadata.SHA256)
+	}
+
+	return nil
+}
+
+// checksummed tells whether the SHA256 checksum of the object is known. SSE-C objects only have it once unlocked
+// with their key, and SSE-C multipart uploads only once they were first read with it.
+func checksummed(metadata *core.ObjectMetadata) bool {
+	return metadata.SHA256 != "" && metadata.SHA256Base64 != ""
+}
+
+func bucketNames(ctx context.Context, replica *replica) ([]string, error) {
+	buckets, err := replica.backend.ListBuckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(buckets, func(bucket core.Bucket, _ int) string { return bucket.Name() }), nil
+}
+
+// objectKeys lists the keys of the objects of the bucket of the root, none when it does not have the bucket.
+func objectKeys(ctx context.Context, replica *replica, name string) ([]string, error) {
+	bucket, err := replica.backend.HeadBucket(ctx, name)
+	if err != nil {
+		if errors.Is(err, core.ErrBucketNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var keys []string
+
+	err = folder.WalkBucket(ctx, bucket.(*folder.Bucket), "", nil, func(_ context.Context, object core.Object) error { //nolint:forcetypeassert,lll
+		keys = append(keys, object.Key())
+
+		return nil
+	})
+
+	return keys, err
+}
@@ -0,0 +1,83 @@
+package mirror
+
+import (
+	"context"
+	"io"
+
+	"github.com/samber/lo"
+)
+
+// tee streams a request body to every online root through a pipe each, the slowest root sets the pace. A root
+// that stops reading its pipe is left out of the rest of the body.
+type tee struct {
+	bodies map[*replica]*io.PipeReader
+	done   chan struct{}
+}
+
+func newTee(body io.Reader, replicas []*replica) *tee {
+	t := &tee{bodies: map[*replica]*io.PipeReader{}, done: make(chan struct{})}
+
+	var writers []*io.PipeWriter
+
+	for _, replica := range replicas {
+		if replica.online {
+			reader, writer := io.Pipe()
+			t.bodies[replica] = reader
+			writers = append(writers, writer)
+		}
+	}
+
+	go func() {
+		defer close(t.done)
+
+		_, err := io.Copy(&fanOutWriter{writers: writers}, body)
+
+		for _, writer := range writers {
+			writer.CloseWithError(err)
+		}
+	}()
+
+	return t
+}
+
+// body returns the body of the root.
+func (t *tee) body(replica *replica) *io.PipeReader {
+	return t.bodies[replica]
+}
+
+// feed closes the body of the root once fn returns, also when it fails before reading it, so that the root does
+// not hold the others up.
+func feed[T any](t *tee, fn func(context.Context, *replica) (T, error)) func(context.Context, *replica) (T, error) {
+	return func(ctx context.Context, replica *replica) (T, error) {
+		defer t.body(replica).Close()
+
+		return fn(ctx, replica)
+	}
+}
+
+// close closes the bodies the roots did not read to the end, and waits for the body to stop being read.
+func (t *tee) close() {
+	for _, body := range t.bodies {
+		body.Close()
+	}
+
+	<-t.done
+}
+
+type fanOutWriter struct {
+	writers []*io.PipeWriter
+}
+
+func (w *fanOutWriter) Write(p []byte) (int, error) {
+	w.writers = lo.Filter(w.writers, func(writer *io.PipeWriter, _ int) bool {
+		_, err := writer.Write(p)
+
+		return err == nil
+	})
+
+	if len(w.writers) == 0 {
+		return 0, io.ErrClosedPipe
+	}
+
+	return len(p), nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/zhulik/d3/internal/backends/storage/folder"
+	"github.com/zhulik/d3/internal/backends/storage/mirror"
 	"github.com/zhulik/d3/internal/core"
 	"github.com/zhulik/pal"
 )
@@ -12,6 +13,8 @@ func Provide(config *core.Config) pal.ServiceDef {
 	switch config.StorageBackend {
 	case core.StorageBackendFolder:
 		return folder.Provide()
+	case core.StorageBackendMirror:
+		return mirror.Provide()
 	default:
 		panic(fmt.Sprintf("unknown storage backend: %s", config.StorageBackend))
 	}
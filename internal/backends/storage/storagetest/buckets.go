@@ -0,0 +1,109 @@
+package storagetest
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:revive
+	. "github.com/onsi/gomega"    //nolint:revive
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+)
+
+func describeBuckets(state *suiteState) {
+	Describe("buckets", func() {
+		When("a bucket is created", func() {
+			It("is returned by HeadBucket and ListBuckets", func(ctx context.Context) {
+				Expect(state.backend.CreateBucket(ctx, "second-bucket")).To(Succeed())
+
+				bucket, err := state.backend.HeadBucket(ctx, "second-bucket")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bucket.Name()).To(Equal("second-bucket"))
+				Expect(bucket.ARN()).To(Equal("arn:aws:s3:::second-bucket"))
+				Expect(bucket.CreationDate()).NotTo(BeZero())
+
+				buckets := lo.Must(state.backend.ListBuckets(ctx))
+				names := lo.Map(buckets, func(b core.Bucket, _ int) string { return b.Name() })
+				Expect(names).To(ConsistOf(bucketName, "second-bucket"))
+			})
+		})
+
+		When("the bucket already exists", func() {
+			It("returns ErrBucketAlreadyExists", func(ctx context.Context) {
+				Expect(state.backend.CreateBucket(ctx, bucketName)).To(MatchError(core.ErrBucketAlreadyExists))
+			})
+		})
+
+		When("the bucket does not exist", func() {
+			It("returns ErrBucketNotFound from HeadBucket and DeleteBucket", func(ctx context.Context) {
+				_, err := state.backend.HeadBucket(ctx, "missing-bucket")
+				Expect(err).To(MatchError(core.ErrBucketNotFound))
+
+				Expect(state.backend.DeleteBucket(ctx, "missing-bucket")).To(MatchError(core.ErrBucketNotFound))
+			})
+		})
+
+		When("the bucket is not empty", func() {
+			It("refuses to delete it", func(ctx context.Context) {
+				putObject(ctx, state.bucket, "file.txt", []byte("content"))
+
+				Expect(state.backend.DeleteBucket(ctx, bucketName)).To(MatchError(core.ErrBucketNotEmpty))
+			})
+		})
+
+		When("the bucket is emptied", func() {
+			It("deletes it", func(ctx context.Context) {
+				putObject(ctx, state.bucket, "dir/file.txt", []byte("content"))
+
+				deleteObjects(ctx, state.bucket, "dir/file.txt")
+
+				Expect(state.backend.DeleteBucket(ctx, bucketName)).To(Succeed())
+
+				_, err := state.backend.HeadBucket(ctx, bucketName)
+				Expect(err).To(MatchError(core.ErrBucketNotFound))
+			})
+		})
+
+		When("logging is configured", func() {
+			It("keeps the config across lookups until it is disabled", func(ctx context.Context) {
+				Expect(state.bucket.Logging()).To(BeNil())
+
+				logging := &core.BucketLogging{TargetBucket: "logs", TargetPrefix: "access/"}
+				Expect(state.bucket.PutLogging(ctx, logging)).To(Succeed())
+				Expect(state.bucket.Logging()).To(Equal(logging))
+
+				bucket := lo.Must(state.backend.HeadBucket(ctx, bucketName))
+				Expect(bucket.Logging()).To(Equal(logging))
+				Expect(bucket.CreationDate()).To(BeTemporally("==", state.bucket.CreationDate()))
+
+				Expect(bucket.PutLogging(ctx, nil)).To(Succeed())
+				Expect(lo.Must(state.backend.HeadBucket(ctx, bucketName)).Logging()).To(BeNil())
+			})
+		})
+
+		When("compression is overridden", func() {
+			It("keeps the override across lookups, still reading objects written before", func(ctx context.Context) {
+				content := bytes.Repeat([]byte("compressible content "), 20_000)
+				putObject(ctx, state.bucket, "before.txt", content)
+
+				Expect(state.bucket.Compression()).To(BeNil())
+
+				compression := &core.BucketCompression{Algorithm: core.CompressionZstd}
+				Expect(state.bucket.PutCompression(ctx, compression)).To(Succeed())
+
+				bucket := lo.Must(state.backend.HeadBucket(ctx, bucketName))
+				Expect(bucket.Compression()).To(Equal(compression))
+
+				putObject(ctx, bucket, "after.txt", content)
+				Expect(readObject(ctx, bucket, "before.txt")).To(Equal(content))
+				Expect(readObject(ctx, bucket, "after.txt")).To(Equal(content))
+
+				Expect(bucket.PutCompression(ctx, &core.BucketCompression{Algorithm: "lz4"})).
+					To(MatchError(core.ErrBucketCompressionInvalid))
+
+				Expect(bucket.PutCompression(ctx, nil)).To(Succeed())
+				Expect(lo.Must(state.backend.HeadBucket(ctx, bucketName)).Compression()).To(BeNil())
+			})
+		})
+	})
+}
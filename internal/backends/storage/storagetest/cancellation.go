@@ -0,0 +1,110 @@
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:revive
+	. "github.com/onsi/gomega"    //nolint:revive
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+)
+
+// cancellingReader cancels the context after the first chunk is read, simulating a client that
+// disconnects in the middle of an upload.
+type cancellingReader struct {
+	reader io.Reader
+	cancel context.CancelFunc
+}
+
+func (r *cancellingReader) Read(p []byte) (int, error) {
+	defer r.cancel()
+
+	return r.reader.Read(p[:min(len(p), 4)])
+}
+
+func describeCancellation(state *suiteState) { //nolint:funlen
+	Describe("context cancellation", func() {
+		When("the context is cancelled while an object is uploaded", func() {
+			It("fails and leaves no object behind", func(ctx context.Context) {
+				content := bytes.Repeat([]byte("payload"), 1024)
+
+				uploadCtx, cancel := context.WithCancel(ctx)
+				defer cancel()
+
+				input := putInput(content)
+				input.Reader = &cancellingReader{reader: bytes.NewReader(content), cancel: cancel}
+
+				Expect(state.bucket.PutObject(uploadCtx, "cancelled.bin", input)).To(MatchError(context.Canceled))
+
+				_, err := state.bucket.HeadObject(ctx, "cancelled.bin")
+				Expect(err).To(MatchError(core.ErrObjectNotFound))
+
+				result := lo.Must(state.bucket.ListObjectsV2(ctx, core.ListObjectsV2Input{MaxKeys: core.MaxKeys}))
+				Expect(result.Objects).To(BeEmpty())
+			})
+		})
+
+		When("the context is cancelled while a part is uploaded", func() {
+			It("fails", func(ctx context.Context) {
+				uploadID := lo.Must(state.bucket.CreateMultipartUpload(ctx, "cancelled.bin", core.ObjectMetadata{}, nil))
+				content := bytes.Repeat([]byte("payload"), 1024)
+
+				uploadCtx, cancel := context.WithCancel(ctx)
+				defer cancel()
+
+				reader := &cancellingReader{reader: bytes.NewReader(content), cancel: cancel}
+
+				_, err := state.bucket.UploadPart(uploadCtx, "cancelled.bin", uploadID, 1, reader, nil)
+				Expect(err).To(MatchError(context.Canceled))
+			})
+		})
+
+		When("the context is already cancelled", func() {
+			var cancelled context.Context
+
+			BeforeEach(func(ctx context.Context) {
+				putObjects(ctx, state.bucket, "a.txt", "b.txt")
+
+				var cancel context.CancelFunc
+
+				cancelled, cancel = context.WithCancel(ctx)
+				cancel()
+			})
+
+			It("fails ListObjectsV2", func() {
+				_, err := state.bucket.ListObjectsV2(cancelled, core.ListObjectsV2Input{MaxKeys: core.MaxKeys})
+				Expect(err).To(MatchError(context.Canceled))
+			})
+
+			It("fails DeleteObjects and keeps the objects", func(ctx context.Context) {
+				_, err := state.bucket.DeleteObjects(cancelled, core.DeleteObjectsInput{
+					Keys: []string{"a.txt", "b.txt"},
+				})
+				Expect(err).To(MatchError(context.Canceled))
+
+				result := lo.Must(state.bucket.ListObjectsV2(ctx, core.ListObjectsV2Input{MaxKeys: core.MaxKeys}))
+				Expect(objectKeys(result.Objects)).To(Equal([]string{"a.txt", "b.txt"}))
+			})
+
+			It("fails PutObject and keeps the existing object", func(ctx context.Context) {
+				Expect(state.bucket.PutObject(cancelled, "a.txt", putInput([]byte("new")))).
+					To(MatchError(context.Canceled))
+
+				Expect(readObject(ctx, state.bucket, "a.txt")).To(Equal([]byte("a.txt")))
+			})
+
+			It("fails CompleteMultipartUpload and does not create the object", func(ctx context.Context) {
+				uploadID := lo.Must(state.bucket.CreateMultipartUpload(ctx, "multi.bin", core.ObjectMetadata{}, nil))
+				part := uploadPart(ctx, state.bucket, "multi.bin", uploadID, 1, []byte("content"))
+
+				_, err := state.bucket.CompleteMultipartUpload(cancelled, "multi.bin", uploadID, []core.CompletePart{part})
+				Expect(err).To(MatchError(context.Canceled))
+
+				_, err = state.bucket.HeadObject(ctx, "multi.bin")
+				Expect(err).To(MatchError(core.ErrObjectNotFound))
+			})
+		})
+	})
+}
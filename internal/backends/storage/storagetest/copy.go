@@ -0,0 +1,100 @@
+package storagetest
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:revive
+	. "github.com/onsi/gomega"    //nolint:revive
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+)
+
+func describeCopyObject(state *suiteState) { //nolint:funlen
+	Describe("CopyObject", func() {
+		var source core.Object
+
+		BeforeEach(func(ctx context.Context) {
+			input := putInput([]byte("source content"))
+			input.Metadata.ContentType = "text/plain"
+			input.Metadata.Meta = map[string]string{"author": "source"}
+			input.Metadata.Tags = map[string]string{"env": "source"}
+
+			Expect(state.bucket.PutObject(ctx, "src.txt", input)).To(Succeed())
+
+			source = lo.Must(state.bucket.HeadObject(ctx, "src.txt"))
+		})
+
+		When("directives are COPY", func() {
+			It("copies content, metadata and tags", func(ctx context.Context) {
+				result := lo.Must(state.bucket.CopyObject(ctx, "dst/copy.txt", core.CopyObjectInput{
+					Source:            source,
+					MetadataDirective: core.CopyDirectiveCopy,
+					TaggingDirective:  core.CopyDirectiveCopy,
+				}))
+
+				Expect(result.Metadata.SHA256).To(Equal(checksum([]byte("source content"))))
+				Expect(result.Metadata.ContentType).To(Equal("text/plain"))
+
+				object := lo.Must(state.bucket.HeadObject(ctx, "dst/copy.txt"))
+				Expect(object.Metadata().ContentType).To(Equal("text/plain"))
+				Expect(object.Metadata().Meta).To(Equal(map[string]string{"author": "source"}))
+				Expect(object.Metadata().Tags).To(Equal(map[string]string{"env": "source"}))
+				Expect(object.Size()).To(Equal(int64(len("source content"))))
+
+				Expect(readObject(ctx, state.bucket, "dst/copy.txt")).To(Equal([]byte("source content")))
+			})
+		})
+
+		When("directives are REPLACE", func() {
+			It("takes metadata and tags from the input", func(ctx context.Context) {
+				lo.Must(state.bucket.CopyObject(ctx, "replaced.txt", core.CopyObjectInput{
+					Source:            source,
+					MetadataDirective: core.CopyDirectiveReplace,
+					TaggingDirective:  core.CopyDirectiveReplace,
+					ContentType:       "application/json",
+					ReplacementMeta:   map[string]string{"author": "copy"},
+					ReplacementTags:   map[string]string{"env": "copy"},
+				}))
+
+				object := lo.Must(state.bucket.HeadObject(ctx, "replaced.txt"))
+				Expect(object.Metadata().ContentType).To(Equal("application/json"))
+				Expect(object.Metadata().Meta).To(Equal(map[string]string{"author": "copy"}))
+				Expect(object.Metadata().Tags).To(Equal(map[string]string{"env": "copy"}))
+
+				Expect(readObject(ctx, state.bucket, "replaced.txt")).To(Equal([]byte("source content")))
+			})
+		})
+
+		When("the destination exists", func() {
+			BeforeEach(func(ctx context.Context) {
+				putObject(ctx, state.bucket, "existing.txt", []byte("old content"))
+			})
+
+			It("overwrites it", func(ctx context.Context) {
+				lo.Must(state.bucket.CopyObject(ctx, "existing.txt", core.CopyObjectInput{Source: source}))
+
+				Expect(readObject(ctx, state.bucket, "existing.txt")).To(Equal([]byte("source content")))
+			})
+
+			It("returns ErrPreconditionFailed with IfNoneMatch and keeps the destination", func(ctx context.Context) {
+				_, err := state.bucket.CopyObject(ctx, "existing.txt", core.CopyObjectInput{
+					Source:      source,
+					IfNoneMatch: true,
+				})
+				Expect(err).To(MatchError(core.ErrPreconditionFailed))
+
+				Expect(readObject(ctx, state.bucket, "existing.txt")).To(Equal([]byte("old content")))
+			})
+		})
+
+		When("the source is overwritten after the copy", func() {
+			It("keeps the copied content", func(ctx context.Context) {
+				lo.Must(state.bucket.CopyObject(ctx, "snapshot.txt", core.CopyObjectInput{Source: source}))
+
+				putObject(ctx, state.bucket, "src.txt", []byte("new source content"))
+
+				Expect(readObject(ctx, state.bucket, "snapshot.txt")).To(Equal([]byte("source content")))
+			})
+		})
+	})
+}
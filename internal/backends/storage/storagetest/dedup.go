@@ -0,0 +1,40 @@
+package storagetest
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:revive
+	. "github.com/onsi/gomega"    //nolint:revive
+	"github.com/samber/lo"
+)
+
+func describeDedup(state *suiteState) {
+	Describe("identical objects", func() {
+		content := []byte("the same content")
+
+		BeforeEach(func(ctx context.Context) {
+			putObject(ctx, state.bucket, "first.txt", content)
+			putObject(ctx, state.bucket, "second.txt", content)
+		})
+
+		It("are deleted independently", func(ctx context.Context) {
+			deleteObjects(ctx, state.bucket, "first.txt")
+
+			Expect(readObject(ctx, state.bucket, "second.txt")).To(Equal(content))
+
+			putObject(ctx, state.bucket, "second.txt", []byte("new content"))
+			Expect(readObject(ctx, state.bucket, "second.txt")).To(Equal([]byte("new content")))
+		})
+
+		It("release what they share once all of them are deleted", func(ctx context.Context) {
+			stats := lo.Must(state.backend.DedupStats(ctx))
+			Expect(stats.SavedBytes).To(BeNumerically("<=", stats.ReferencedBytes))
+
+			deleteObjects(ctx, state.bucket, "first.txt", "second.txt")
+
+			stats = lo.Must(state.backend.DedupStats(ctx))
+			Expect(stats.References).To(BeZero())
+			Expect(stats.StoredBytes).To(BeZero())
+		})
+	})
+}
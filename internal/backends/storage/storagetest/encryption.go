@@ -0,0 +1,183 @@
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:revive
+	. "github.com/onsi/gomega"    //nolint:revive
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+)
+
+func readObjectRange(ctx context.Context, object core.Object, start, length int64) []byte {
+	GinkgoHelper()
+
+	lo.Must(object.Seek(start, io.SeekStart))
+
+	content := make([]byte, length)
+	lo.Must(io.ReadFull(object, content))
+
+	return content
+}
+
+func describeEncryption(state *suiteState) { //nolint:funlen
+	Describe("server-side encryption", func() {
+		customerKey := bytes.Repeat([]byte{7}, 32)
+		sseC := &core.ServerSideEncryption{CustomerKey: customerKey}
+		content := bytes.Repeat([]byte("0123456789abcdef"), 10_000)
+
+		When("SSE-S3 is requested", func() {
+			It("stores the object encrypted and reads it back from any offset", func(ctx context.Context) {
+				input := putInput(content)
+				input.Encryption = &core.ServerSideEncryption{}
+				Expect(state.bucket.PutObject(ctx, "sse-s3.bin", input)).To(Succeed())
+
+				object := lo.Must(state.bucket.GetObject(ctx, "sse-s3.bin"))
+				defer object.Close()
+
+				Expect(object.Metadata().Encryption).NotTo(BeNil())
+				Expect(object.Metadata().Encryption.IsCustomer()).To(BeFalse())
+				Expect(object.Metadata().SHA256).To(Equal(checksum(content)))
+				Expect(object.Size()).To(Equal(int64(len(content))))
+
+				Expect(readObjectRange(ctx, object, 70_000, 100)).To(Equal(content[70_000:70_100]))
+				Expect(readObject(ctx, state.bucket, "sse-s3.bin")).To(Equal(content))
+			})
+		})
+
+		When("the bucket has a default encryption", func() {
+			It("keeps it across lookups and encrypts objects uploaded without encryption", func(ctx context.Context) {
+				Expect(state.bucket.Encryption()).To(BeNil())
+
+				encryption := &core.BucketEncryption{Algorithm: core.SSEAlgorithmAES256}
+				Expect(state.bucket.PutEncryption(ctx, encryption)).To(Succeed())
+
+				bucket := lo.Must(state.backend.HeadBucket(ctx, bucketName))
+				Expect(bucket.Encryption()).To(Equal(encryption))
+
+				putObject(ctx, bucket, "default.txt", []byte("content"))
+				Expect(lo.Must(bucket.HeadObject(ctx, "default.txt")).Metadata().Encryption).NotTo(BeNil())
+				Expect(readObject(ctx, bucket, "default.txt")).To(Equal([]byte("content")))
+
+				Expect(bucket.PutEncryption(ctx, nil)).To(Succeed())
+				Expect(lo.Must(state.backend.HeadBucket(ctx, bucketName)).Encryption()).To(BeNil())
+			})
+		})
+
+		When("SSE-C is requested", func() {
+			BeforeEach(func(ctx context.Context) {
+				input := putInput(content)
+				input.Encryption = sseC
+				Expect(state.bucket.PutObject(ctx, "sse-c.bin", input)).To(Succeed())
+			})
+
+			It("reads the object with the customer key only", func(ctx context.Context) {
+				object := lo.Must(state.bucket.GetObject(ctx, "sse-c.bin"))
+				defer object.Close()
+
+				Expect(object.Metadata().Encryption.IsCustomer()).To(BeTrue())
+				Expect(object.Metadata().Encryption.CustomerKeyMD5).To(Equal(sseC.CustomerKeyMD5()))
+
+				_, err := io.ReadAll(object)
+				Expect(err).To(MatchError(core.ErrSSECustomerKeyRequired))
+
+				wrongKey := bytes.Repeat([]byte{8}, 32)
+				Expect(object.SetCustomerKey(wrongKey)).To(MatchError(core.ErrSSECustomerKeyMismatch))
+
+				Expect(object.SetCustomerKey(customerKey)).To(Succeed())
+				Expect(readObjectRange(ctx, object, 1, 10)).To(Equal(content[1:11]))
+			})
+
+			It("copies the object once the source is unlocked", func(ctx context.Context) {
+				source := lo.Must(state.bucket.GetObject(ctx, "sse-c.bin"))
+				defer source.Close()
+
+				Expect(source.SetCustomerKey(customerKey)).To(Succeed())
+
+				result := lo.Must(state.bucket.CopyObject(ctx, "sse-s3-copy.bin", core.CopyObjectInput{
+					Source:     source,
+					Encryption: &core.ServerSideEncryption{},
+				}))
+				Expect(result.Metadata.SHA256).To(Equal(checksum(content)))
+				Expect(result.Metadata.Encryption.IsCustomer()).To(BeFalse())
+
+				Expect(readObject(ctx, state.bucket, "sse-s3-copy.bin")).To(Equal(content))
+			})
+		})
+
+		When("an unencrypted object is copied with encryption", func() {
+			It("encrypts the copy", func(ctx context.Context) {
+				putObject(ctx, state.bucket, "plain.txt", []byte("plain content"))
+
+				source := lo.Must(state.bucket.GetObject(ctx, "plain.txt"))
+				defer source.Close()
+
+				lo.Must(state.bucket.CopyObject(ctx, "encrypted.txt", core.CopyObjectInput{
+					Source:     source,
+					Encryption: sseC,
+				}))
+
+				object := lo.Must(state.bucket.GetObject(ctx, "encrypted.txt"))
+				defer object.Close()
+
+				Expect(object.SetCustomerKey(customerKey)).To(Succeed())
+				Expect(io.ReadAll(object)).To(Equal([]byte("plain content")))
+			})
+		})
+
+		When("a multipart upload is encrypted with a customer key", func() {
+			It("requires the key for every part and reads the parts back as one object", func(ctx context.Context) {
+				uploadID := lo.Must(state.bucket.CreateMultipartUpload(ctx, "sse-c-multipart.bin",
+					core.ObjectMetadata{}, sseC))
+
+				_, err := state.bucket.UploadPart(ctx, "sse-c-multipart.bin", uploadID, 1,
+					bytes.NewReader(content), nil)
+				Expect(err).To(MatchError(core.ErrSSECustomerKeyRequired))
+
+				first := lo.Must(state.bucket.UploadPart(ctx, "sse-c-multipart.bin", uploadID, 1,
+					bytes.NewReader(content), sseC))
+				second := lo.Must(state.bucket.UploadPart(ctx, "sse-c-multipart.bin", uploadID, 2,
+					bytes.NewReader([]byte("tail")), sseC))
+
+				parts := lo.Must(state.bucket.ListParts(ctx, "sse-c-multipart.bin",
+					core.ListPartsInput{UploadID: uploadID}))
+				Expect(parts.Parts[0].Size).To(Equal(int64(len(content))))
+
+				metadata := lo.Must(state.bucket.CompleteMultipartUpload(ctx, "sse-c-multipart.bin", uploadID,
+					[]core.CompletePart{{PartNumber: 1, ETag: first}, {PartNumber: 2, ETag: second}}))
+				Expect(metadata.Size).To(Equal(int64(len(content) + 4)))
+
+				object := lo.Must(state.bucket.GetObject(ctx, "sse-c-multipart.bin"))
+				defer object.Close()
+
+				Expect(object.SetCustomerKey(customerKey)).To(Succeed())
+				Expect(object.Metadata().SHA256).To(Equal(checksum(append(slices.Clone(content), "tail"...))))
+				Expect(readObjectRange(ctx, object, int64(len(content)-2), 6)).To(Equal([]byte("eftail")))
+			})
+		})
+
+		When("a multipart upload is encrypted with SSE-S3", func() {
+			It("has the checksum of the plaintext", func(ctx context.Context) {
+				uploadID := lo.Must(state.bucket.CreateMultipartUpload(ctx, "sse-s3-multipart.bin",
+					core.ObjectMetadata{}, &core.ServerSideEncryption{}))
+
+				first := lo.Must(state.bucket.UploadPart(ctx, "sse-s3-multipart.bin", uploadID, 1,
+					bytes.NewReader(content), nil))
+				second := lo.Must(state.bucket.UploadPart(ctx, "sse-s3-multipart.bin", uploadID, 2,
+					bytes.NewReader([]byte("tail")), nil))
+				Expect(first).NotTo(Equal(checksum(content)))
+
+				metadata := lo.Must(state.bucket.CompleteMultipartUpload(ctx, "sse-s3-multipart.bin", uploadID,
+					[]core.CompletePart{{PartNumber: 1, ETag: first}, {PartNumber: 2, ETag: second}}))
+
+				plaintextChecksum := checksum(append(slices.Clone(content), "tail"...))
+				Expect(metadata.SHA256).To(Equal(plaintextChecksum))
+				Expect(lo.Must(state.bucket.HeadObject(ctx, "sse-s3-multipart.bin")).Metadata().SHA256).
+					To(Equal(plaintextChecksum))
+			})
+		})
+	})
+}
@@ -0,0 +1,194 @@
+package storagetest
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:revive
+	. "github.com/onsi/gomega"    //nolint:revive
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+)
+
+type listPage struct {
+	keys           []string
+	commonPrefixes []string
+}
+
+// listAllPages follows continuation tokens until the listing is exhausted and returns every page.
+func listAllPages(ctx context.Context, bucket core.Bucket, input core.ListObjectsV2Input) []listPage {
+	GinkgoHelper()
+
+	pages := []listPage{}
+
+	for range core.MaxKeys {
+		result := lo.Must(bucket.ListObjectsV2(ctx, input))
+
+		pages = append(pages, listPage{
+			keys:           objectKeys(result.Objects),
+			commonPrefixes: result.CommonPrefixes,
+		})
+
+		if !result.IsTruncated {
+			Expect(result.ContinuationToken).To(BeNil())
+
+			return pages
+		}
+
+		Expect(result.ContinuationToken).NotTo(BeNil())
+		Expect(*result.ContinuationToken).NotTo(BeEmpty())
+
+		input.ContinuationToken = *result.ContinuationToken
+	}
+
+	Fail("listing did not terminate")
+
+	return nil
+}
+
+func describeListObjectsV2(state *suiteState) { //nolint:funlen
+	Describe("ListObjectsV2", func() {
+		When("the bucket is empty", func() {
+			It("returns an empty, non-truncated result", func(ctx context.Context) {
+				result := lo.Must(state.bucket.ListObjectsV2(ctx, core.ListObjectsV2Input{MaxKeys: core.MaxKeys}))
+
+				Expect(result.Objects).To(BeEmpty())
+				Expect(result.CommonPrefixes).To(BeEmpty())
+				Expect(result.IsTruncated).To(BeFalse())
+				Expect(result.ContinuationToken).To(BeNil())
+			})
+		})
+
+		When("objects exist", func() {
+			BeforeEach(func(ctx context.Context) {
+				putObjects(ctx, state.bucket,
+					"docs/a.txt", "docs/b.txt", "docs/nested/c.txt", "images/x.png", "readme.md",
+				)
+			})
+
+			It("lists all keys in lexical order", func(ctx context.Context) {
+				result := lo.Must(state.bucket.ListObjectsV2(ctx, core.ListObjectsV2Input{MaxKeys: core.MaxKeys}))
+
+				Expect(objectKeys(result.Objects)).To(Equal([]string{
+					"docs/a.txt", "docs/b.txt", "docs/nested/c.txt", "images/x.png", "readme.md",
+				}))
+				Expect(result.IsTruncated).To(BeFalse())
+			})
+
+			It("reports object sizes", func(ctx context.Context) {
+				result := lo.Must(state.bucket.ListObjectsV2(ctx, core.ListObjectsV2Input{
+					Prefix:  "readme",
+					MaxKeys: core.MaxKeys,
+				}))
+
+				Expect(result.Objects).To(HaveLen(1))
+				Expect(result.Objects[0].Size()).To(Equal(int64(len("readme.md"))))
+			})
+
+			DescribeTable("filters by prefix",
+				func(ctx context.Context, prefix string, expected []string) {
+					result := lo.Must(state.bucket.ListObjectsV2(ctx, core.ListObjectsV2Input{
+						Prefix:  prefix,
+						MaxKeys: core.MaxKeys,
+					}))
+
+					Expect(objectKeys(result.Objects)).To(Equal(expected))
+				},
+				Entry("directory-like prefix", "docs/", []string{"docs/a.txt", "docs/b.txt", "docs/nested/c.txt"}),
+				Entry("partial segment prefix", "doc", []string{"docs/a.txt", "docs/b.txt", "docs/nested/c.txt"}),
+				Entry("partial file name prefix", "docs/a", []string{"docs/a.txt"}),
+				Entry("full key as prefix", "readme.md", []string{"readme.md"}),
+				Entry("nothing matches", "missing/", []string{}),
+			)
+
+			DescribeTable("paginates with continuation tokens",
+				func(ctx context.Context, maxKeys int, expected [][]string) {
+					pages := listAllPages(ctx, state.bucket, core.ListObjectsV2Input{MaxKeys: maxKeys})
+
+					Expect(lo.Map(pages, func(p listPage, _ int) []string { return p.keys })).To(Equal(expected))
+				},
+				Entry("one key per page", 1, [][]string{
+					{"docs/a.txt"}, {"docs/b.txt"}, {"docs/nested/c.txt"}, {"images/x.png"}, {"readme.md"},
+				}),
+				Entry("uneven last page", 2, [][]string{
+					{"docs/a.txt", "docs/b.txt"}, {"docs/nested/c.txt", "images/x.png"}, {"readme.md"},
+				}),
+				Entry("page size equals key count", 5, [][]string{
+					{"docs/a.txt", "docs/b.txt", "docs/nested/c.txt", "images/x.png", "readme.md"},
+				}),
+				Entry("page size exceeds key count", core.MaxKeys, [][]string{
+					{"docs/a.txt", "docs/b.txt", "docs/nested/c.txt", "images/x.png", "readme.md"},
+				}),
+			)
+
+			It("paginates within a prefix", func(ctx context.Context) {
+				pages := listAllPages(ctx, state.bucket, core.ListObjectsV2Input{Prefix: "docs/", MaxKeys: 2})
+
+				Expect(lo.Map(pages, func(p listPage, _ int) []string { return p.keys })).To(Equal([][]string{
+					{"docs/a.txt", "docs/b.txt"}, {"docs/nested/c.txt"},
+				}))
+			})
+
+			DescribeTable("groups keys by delimiter",
+				func(ctx context.Context, prefix string, expectedKeys, expectedPrefixes []string) {
+					result := lo.Must(state.bucket.ListObjectsV2(ctx, core.ListObjectsV2Input{
+						Prefix:    prefix,
+						Delimiter: core.Delimiter,
+						MaxKeys:   core.MaxKeys,
+					}))
+
+					Expect(objectKeys(result.Objects)).To(Equal(expectedKeys))
+					Expect(result.CommonPrefixes).To(Equal(expectedPrefixes))
+				},
+				Entry("at the root", "", []string{"readme.md"}, []string{"docs/", "images/"}),
+				Entry("inside a prefix", "docs/", []string{"docs/a.txt", "docs/b.txt"}, []string{"docs/nested/"}),
+				Entry("at the deepest level", "docs/nested/", []string{"docs/nested/c.txt"}, []string{}),
+				Entry("with a prefix that is not a directory", "d", []string{}, []string{"docs/"}),
+			)
+
+			It("counts common prefixes towards MaxKeys", func(ctx context.Context) {
+				pages := listAllPages(ctx, state.bucket, core.ListObjectsV2Input{
+					Delimiter: core.Delimiter,
+					MaxKeys:   1,
+				})
+
+				Expect(pages).To(Equal([]listPage{
+					{keys: []string{}, commonPrefixes: []string{"docs/"}},
+					{keys: []string{}, commonPrefixes: []string{"images/"}},
+					{keys: []string{"readme.md"}, commonPrefixes: []string{}},
+				}))
+			})
+
+			It("never repeats a common prefix across pages", func(ctx context.Context) {
+				pages := listAllPages(ctx, state.bucket, core.ListObjectsV2Input{
+					Prefix:    "docs/",
+					Delimiter: core.Delimiter,
+					MaxKeys:   2,
+				})
+
+				all := lo.FlatMap(pages, func(p listPage, _ int) []string { return p.commonPrefixes })
+				Expect(all).To(Equal([]string{"docs/nested/"}))
+
+				keys := lo.FlatMap(pages, func(p listPage, _ int) []string { return p.keys })
+				Expect(keys).To(Equal([]string{"docs/a.txt", "docs/b.txt"}))
+			})
+		})
+
+		When("a listed page is followed by a deletion", func() {
+			It("continues from the token without skipping remaining keys", func(ctx context.Context) {
+				putObjects(ctx, state.bucket, "a.txt", "b.txt", "c.txt")
+
+				first := lo.Must(state.bucket.ListObjectsV2(ctx, core.ListObjectsV2Input{MaxKeys: 1}))
+				Expect(objectKeys(first.Objects)).To(Equal([]string{"a.txt"}))
+				Expect(first.IsTruncated).To(BeTrue())
+
+				deleteObjects(ctx, state.bucket, "a.txt")
+
+				rest := lo.Must(state.bucket.ListObjectsV2(ctx, core.ListObjectsV2Input{
+					MaxKeys:           core.MaxKeys,
+					ContinuationToken: *first.ContinuationToken,
+				}))
+				Expect(objectKeys(rest.Objects)).To(Equal([]string{"b.txt", "c.txt"}))
+			})
+		})
+	})
+}
@@ -0,0 +1,61 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+)
+
+// Locker is an in-process core.Locker implementation for tests. Unlike a no-op locker it really
+// serializes holders of the same key, so race-sensitive specs (e.g. concurrent IfNoneMatch writes)
+// behave the same way they do with the Redis-backed locker.
+type Locker struct {
+	mu   sync.Mutex
+	held map[string]chan struct{}
+}
+
+func NewLocker() *Locker {
+	return &Locker{
+		held: map[string]chan struct{}{},
+	}
+}
+
+func (l *Locker) Lock(ctx context.Context, key string) (context.Context, context.CancelFunc, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		l.mu.Lock()
+
+		released, busy := l.held[key]
+		if !busy {
+			released = make(chan struct{})
+			l.held[key] = released
+			l.mu.Unlock()
+
+			lockCtx, cancel := context.WithCancel(ctx)
+
+			var once sync.Once
+
+			return lockCtx, func() {
+				once.Do(func() {
+					cancel()
+
+					l.mu.Lock()
+					delete(l.held, key)
+					l.mu.Unlock()
+
+					close(released)
+				})
+			}, nil
+		}
+
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-released:
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package storagetest
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:revive
+	. "github.com/onsi/gomega"    //nolint:revive
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+)
+
+func uploadPart(ctx context.Context, bucket core.Bucket, key, uploadID string, partNumber int, content []byte) core.CompletePart { //nolint:lll
+	GinkgoHelper()
+
+	etag := lo.Must(bucket.UploadPart(ctx, key, uploadID, partNumber, bytes.NewReader(content), nil))
+	Expect(etag).To(Equal(checksum(content)))
+
+	return core.CompletePart{PartNumber: partNumber, ETag: etag}
+}
+
+func describeMultipart(state *suiteState) { //nolint:funlen
+	Describe("multipart uploads", func() {
+		const key = "uploads/large.bin"
+
+		var uploadID string
+
+		BeforeEach(func(ctx context.Context) {
+			uploadID = lo.Must(state.bucket.CreateMultipartUpload(ctx, key, core.ObjectMetadata{
+				ContentType: "application/x-test",
+				Meta:        map[string]string{"origin": "multipart"},
+			}, nil))
+			Expect(uploadID).NotTo(BeEmpty())
+		})
+
+		When("all parts are uploaded", func() {
+			It("assembles them in part number order regardless of completion order", func(ctx context.Context) {
+				part2 := uploadPart(ctx, state.bucket, key, uploadID, 2, []byte("second-"))
+				part1 := uploadPart(ctx, state.bucket, key, uploadID, 1, []byte("first-"))
+				part3 := uploadPart(ctx, state.bucket, key, uploadID, 3, []byte("third"))
+
+				metadata := lo.Must(state.bucket.CompleteMultipartUpload(ctx, key, uploadID,
+					[]core.CompletePart{part3, part1, part2},
+				))
+
+				expected := []byte("first-second-third")
+				Expect(metadata.SHA256).To(Equal(checksum(expected)))
+				Expect(metadata.Size).To(Equal(int64(len(expected))))
+				Expect(metadata.ContentType).To(Equal("application/x-test"))
+				Expect(metadata.Meta).To(Equal(map[string]string{"origin": "multipart"}))
+
+				Expect(readObject(ctx, state.bucket, key)).To(Equal(expected))
+
+				object := lo.Must(state.bucket.HeadObject(ctx, key))
+				Expect(object.Metadata().SHA256).To(Equal(checksum(expected)))
+			})
+
+			It("accepts quoted ETags", func(ctx context.Context) {
+				part := uploadPart(ctx, state.bucket, key, uploadID, 1, []byte("content"))
+				part.ETag = `"` + part.ETag + `"`
+
+				lo.Must(state.bucket.CompleteMultipartUpload(ctx, key, uploadID, []core.CompletePart{part}))
+
+				Expect(readObject(ctx, state.bucket, key)).To(Equal([]byte("content")))
+			})
+
+			It("removes the upload once completed", func(ctx context.Context) {
+				part := uploadPart(ctx, state.bucket, key, uploadID, 1, []byte("content"))
+
+				lo.Must(state.bucket.CompleteMultipartUpload(ctx, key, uploadID, []core.CompletePart{part}))
+
+				uploads := lo.Must(state.bucket.ListMultipartUploads(ctx, core.ListMultipartUploadsInput{}))
+				Expect(uploads.Uploads).To(BeEmpty())
+
+				_, err := state.bucket.ListParts(ctx, key, core.ListPartsInput{UploadID: uploadID})
+				Expect(err).To(MatchError(core.ErrInvalidUploadID))
+			})
+		})
+
+		When("the upload id is unknown", func() {
+			It("returns ErrInvalidUploadID", func(ctx context.Context) {
+				_, err := state.bucket.UploadPart(ctx, key, "missing-upload", 1, bytes.NewReader([]byte("x")), nil)
+				Expect(err).To(MatchError(core.ErrInvalidUploadID))
+
+				_, err = state.bucket.CompleteMultipartUpload(ctx, key, "missing-upload", []core.CompletePart{})
+				Expect(err).To(MatchError(core.ErrInvalidUploadID))
+
+				Expect(state.bucket.AbortMultipartUpload(ctx, key, "missing-upload")).
+					To(MatchError(core.ErrInvalidUploadID))
+			})
+		})
+
+		When("the upload id belongs to another key", func() {
+			It("returns ErrInvalidUploadID", func(ctx context.Context) {
+				_, err := state.bucket.UploadPart(ctx, "other.bin", uploadID, 1, bytes.NewReader([]byte("x")), nil)
+				Expect(err).To(MatchError(core.ErrInvalidUploadID))
+
+				_, err = state.bucket.ListParts(ctx, "other.bin", core.ListPartsInput{UploadID: uploadID})
+				Expect(err).To(MatchError(core.ErrInvalidUploadID))
+			})
+		})
+
+		When("a part ETag does not match", func() {
+			It("returns ErrObjectChecksumMismatch and does not create the object", func(ctx context.Context) {
+				part := uploadPart(ctx, state.bucket, key, uploadID, 1, []byte("content"))
+				part.ETag = checksum([]byte("something else"))
+
+				_, err := state.bucket.CompleteMultipartUpload(ctx, key, uploadID, []core.CompletePart{part})
+				Expect(err).To(MatchError(core.ErrObjectChecksumMismatch))
+
+				_, err = state.bucket.HeadObject(ctx, key)
+				Expect(err).To(MatchError(core.ErrObjectNotFound))
+			})
+		})
+
+		When("a listed part was never uploaded", func() {
+			It("fails and does not create the object", func(ctx context.Context) {
+				part := uploadPart(ctx, state.bucket, key, uploadID, 1, []byte("content"))
+
+				_, err := state.bucket.CompleteMultipartUpload(ctx, key, uploadID, []core.CompletePart{
+					part, {PartNumber: 2, ETag: checksum([]byte("missing"))},
+				})
+				Expect(err).To(HaveOccurred())
+
+				_, err = state.bucket.HeadObject(ctx, key)
+				Expect(err).To(MatchError(core.ErrObjectNotFound))
+			})
+		})
+
+		When("parts are listed", func() {
+			BeforeEach(func(ctx context.Context) {
+				for partNumber := 1; partNumber <= 5; partNumber++ {
+					uploadPart(ctx, state.bucket, key, uploadID, partNumber, bytes.Repeat([]byte("x"), partNumber))
+				}
+			})
+
+			It("returns every part with its ETag and size", func(ctx context.Context) {
+				result := lo.Must(state.bucket.ListParts(ctx, key, core.ListPartsInput{UploadID: uploadID}))
+
+				Expect(result.IsTruncated).To(BeFalse())
+				Expect(result.Parts).To(HaveLen(5))
+
+				for i, part := range result.Parts {
+					content := bytes.Repeat([]byte("x"), i+1)
+
+					Expect(part.PartNumber).To(Equal(i + 1))
+					Expect(part.ETag).To(Equal(checksum(content)))
+					Expect(part.Size).To(Equal(int64(len(content))))
+				}
+			})
+
+			It("paginates with MaxParts and PartNumberMarker", func(ctx context.Context) {
+				pages := [][]int{}
+				marker := 0
+
+				for range 5 {
+					result := lo.Must(state.bucket.ListParts(ctx, key, core.ListPartsInput{
+						UploadID:         uploadID,
+						MaxParts:         2,
+						PartNumberMarker: marker,
+					}))
+
+					pages = append(pages, lo.Map(result.Parts, func(p core.PartInfo, _ int) int { return p.PartNumber }))
+
+					if !result.IsTruncated {
+						break
+					}
+
+					marker = result.NextPartNumberMarker
+				}
+
+				Expect(pages).To(Equal([][]int{{1, 2}, {3, 4}, {5}}))
+			})
+		})
+
+		When("the upload is aborted", func() {
+			It("discards the upload and its parts", func(ctx context.Context) {
+				uploadPart(ctx, state.bucket, key, uploadID, 1, []byte("content"))
+
+				Expect(state.bucket.AbortMultipartUpload(ctx, key, uploadID)).To(Succeed())
+
+				_, err := state.bucket.ListParts(ctx, key, core.ListPartsInput{UploadID: uploadID})
+				Expect(err).To(MatchError(core.ErrInvalidUploadID))
+
+				_, err = state.bucket.HeadObject(ctx, key)
+				Expect(err).To(MatchError(core.ErrObjectNotFound))
+			})
+		})
+
+		When("several uploads are in progress", func() {
+			It("lists them sorted by key", func(ctx context.Context) {
+				otherID := lo.Must(state.bucket.CreateMultipartUpload(ctx, "archive.bin", core.ObjectMetadata{}, nil))
+
+				result := lo.Must(state.bucket.ListMultipartUploads(ctx, core.ListMultipartUploadsInput{}))
+
+				Expect(result.IsTruncated).To(BeFalse())
+				Expect(result.Uploads).To(HaveLen(2))
+				Expect(result.Uploads[0].Key).To(Equal("archive.bin"))
+				Expect(result.Uploads[0].UploadID).To(Equal(otherID))
+				Expect(result.Uploads[1].Key).To(Equal(key))
+				Expect(result.Uploads[1].UploadID).To(Equal(uploadID))
+			})
+
+			It("filters them by prefix", func(ctx context.Context) {
+				lo.Must(state.bucket.CreateMultipartUpload(ctx, "archive.bin", core.ObjectMetadata{}, nil))
+
+				result := lo.Must(state.bucket.ListMultipartUploads(ctx, core.ListMultipartUploadsInput{
+					Prefix: "uploads/",
+				}))
+
+				Expect(lo.Map(result.Uploads, func(u core.MultipartUploadInfo, _ int) string { return u.Key })).
+					To(Equal([]string{key}))
+			})
+		})
+	})
+}
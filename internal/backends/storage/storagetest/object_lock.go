@@ -0,0 +1,163 @@
+package storagetest
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:revive
+	. "github.com/onsi/gomega"    //nolint:revive
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+)
+
+func describeObjectLock(state *suiteState) { //nolint:funlen
+	Describe("object lock", func() {
+		retainFor := func(mode core.ObjectLockMode, duration time.Duration) *core.ObjectRetention {
+			return &core.ObjectRetention{Mode: mode, RetainUntil: time.Now().Add(duration).UTC().Truncate(time.Second)}
+		}
+
+		deleteError := func(ctx context.Context, key string, bypassGovernance bool) error {
+			GinkgoHelper()
+
+			results := lo.Must(state.bucket.DeleteObjects(ctx, core.DeleteObjectsInput{
+				Keys:             []string{key},
+				Quiet:            true,
+				BypassGovernance: bypassGovernance,
+			}))
+			if len(results) == 0 {
+				return nil
+			}
+
+			return results[0].Error
+		}
+
+		When("object lock is not enabled", func() {
+			It("has no configuration and rejects retention and legal holds", func(ctx context.Context) {
+				Expect(state.bucket.ObjectLock()).To(BeNil())
+
+				input := putInput([]byte("content"))
+				input.Metadata.LegalHold = true
+				Expect(state.bucket.PutObject(ctx, "held.txt", input)).To(MatchError(core.ErrObjectLockNotEnabled))
+
+				putObject(ctx, state.bucket, "plain.txt", []byte("content"))
+				Expect(state.bucket.PutObjectLegalHold(ctx, "plain.txt", true)).
+					To(MatchError(core.ErrObjectLockNotEnabled))
+				Expect(state.bucket.PutObjectRetention(ctx, "plain.txt",
+					retainFor(core.ObjectLockModeGovernance, time.Hour), false)).
+					To(MatchError(core.ErrObjectLockNotEnabled))
+			})
+		})
+
+		When("object lock is enabled", func() {
+			BeforeEach(func(ctx context.Context) {
+				Expect(state.bucket.PutObjectLock(ctx, core.BucketObjectLock{})).To(Succeed())
+			})
+
+			It("persists the configuration", func(ctx context.Context) {
+				lock := core.BucketObjectLock{DefaultRetention: &core.DefaultRetention{
+					Mode: core.ObjectLockModeGovernance,
+					Days: 1,
+				}}
+				Expect(state.bucket.PutObjectLock(ctx, lock)).To(Succeed())
+
+				bucket := lo.Must(state.backend.HeadBucket(ctx, bucketName))
+				Expect(bucket.ObjectLock()).To(Equal(&lock))
+			})
+
+			It("rejects invalid configurations", func(ctx context.Context) {
+				Expect(state.bucket.PutObjectLock(ctx, core.BucketObjectLock{DefaultRetention: &core.DefaultRetention{
+					Mode: core.ObjectLockModeCompliance,
+					Days: 1, Years: 1,
+				}})).To(MatchError(core.ErrObjectLockInvalid))
+				Expect(state.bucket.PutObjectLock(ctx, core.BucketObjectLock{DefaultRetention: &core.DefaultRetention{
+					Mode: "FOREVER",
+					Days: 1,
+				}})).To(MatchError(core.ErrObjectLockInvalid))
+			})
+
+			It("applies the default retention to new objects", func(ctx context.Context) {
+				Expect(state.bucket.PutObjectLock(ctx, core.BucketObjectLock{DefaultRetention: &core.DefaultRetention{
+					Mode: core.ObjectLockModeCompliance,
+					Days: 1,
+				}})).To(Succeed())
+
+				putObject(ctx, state.bucket, "retained.txt", []byte("content"))
+
+				retention := lo.Must(state.bucket.HeadObject(ctx, "retained.txt")).Metadata().Retention
+				Expect(retention).NotTo(BeNil())
+				Expect(retention.Mode).To(Equal(core.ObjectLockModeCompliance))
+				Expect(retention.RetainUntil).To(BeTemporally("~", time.Now().AddDate(0, 0, 1), time.Minute))
+			})
+
+			It("protects objects under legal hold until it is released", func(ctx context.Context) {
+				putObject(ctx, state.bucket, "held.txt", []byte("content"))
+				Expect(state.bucket.PutObjectLegalHold(ctx, "held.txt", true)).To(Succeed())
+
+				Expect(deleteError(ctx, "held.txt", true)).To(MatchError(core.ErrObjectLocked))
+				Expect(state.bucket.PutObject(ctx, "held.txt", putInput([]byte("new")))).
+					To(MatchError(core.ErrObjectLocked))
+
+				Expect(state.bucket.PutObjectLegalHold(ctx, "held.txt", false)).To(Succeed())
+				Expect(deleteError(ctx, "held.txt", false)).To(Succeed())
+			})
+
+			It("lets GOVERNANCE retention be bypassed", func(ctx context.Context) {
+				input := putInput([]byte("content"))
+				input.Metadata.Retention = retainFor(core.ObjectLockModeGovernance, time.Hour)
+				Expect(state.bucket.PutObject(ctx, "governed.txt", input)).To(Succeed())
+
+				Expect(deleteError(ctx, "governed.txt", false)).To(MatchError(core.ErrObjectLocked))
+				Expect(state.bucket.PutObjectRetention(ctx, "governed.txt", nil, false)).
+					To(MatchError(core.ErrObjectLocked))
+
+				Expect(state.bucket.PutObjectRetention(ctx, "governed.txt", nil, true)).To(Succeed())
+				Expect(deleteError(ctx, "governed.txt", false)).To(Succeed())
+			})
+
+			It("never lets COMPLIANCE retention be shortened or bypassed", func(ctx context.Context) {
+				retention := retainFor(core.ObjectLockModeCompliance, time.Hour)
+
+				input := putInput([]byte("content"))
+				input.Metadata.Retention = retention
+				Expect(state.bucket.PutObject(ctx, "compliant.txt", input)).To(Succeed())
+
+				Expect(deleteError(ctx, "compliant.txt", true)).To(MatchError(core.ErrObjectLocked))
+				Expect(state.bucket.PutObjectRetention(ctx, "compliant.txt",
+					retainFor(core.ObjectLockModeCompliance, time.Minute), true)).To(MatchError(core.ErrObjectLocked))
+				Expect(state.bucket.PutObjectRetention(ctx, "compliant.txt",
+					retainFor(core.ObjectLockModeGovernance, 2*time.Hour), true)).To(MatchError(core.ErrObjectLocked))
+
+				extended := retainFor(core.ObjectLockModeCompliance, 2*time.Hour)
+				Expect(state.bucket.PutObjectRetention(ctx, "compliant.txt", extended, false)).To(Succeed())
+
+				object := lo.Must(state.bucket.HeadObject(ctx, "compliant.txt"))
+				Expect(object.Metadata().Retention.RetainUntil).To(BeTemporally("==", extended.RetainUntil))
+				Expect(readObject(ctx, state.bucket, "compliant.txt")).To(Equal([]byte("content")))
+			})
+
+			It("does not let copies overwrite protected objects", func(ctx context.Context) {
+				putObject(ctx, state.bucket, "source.txt", []byte("source"))
+
+				input := putInput([]byte("content"))
+				input.Metadata.Retention = retainFor(core.ObjectLockModeGovernance, time.Hour)
+				Expect(state.bucket.PutObject(ctx, "destination.txt", input)).To(Succeed())
+
+				source := lo.Must(state.bucket.HeadObject(ctx, "source.txt"))
+				_, err := state.bucket.CopyObject(ctx, "destination.txt", core.CopyObjectInput{Source: source})
+				Expect(err).To(MatchError(core.ErrObjectLocked))
+
+				lo.Must(state.bucket.CopyObject(ctx, "destination.txt", core.CopyObjectInput{
+					Source:           source,
+					BypassGovernance: true,
+				}))
+				Expect(readObject(ctx, state.bucket, "destination.txt")).To(Equal([]byte("source")))
+			})
+
+			It("rejects retention in the past", func(ctx context.Context) {
+				input := putInput([]byte("content"))
+				input.Metadata.Retention = retainFor(core.ObjectLockModeGovernance, -time.Hour)
+				Expect(state.bucket.PutObject(ctx, "past.txt", input)).To(MatchError(core.ErrObjectLockInvalid))
+			})
+		})
+	})
+}
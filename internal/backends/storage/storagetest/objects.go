@@ -0,0 +1,159 @@
+package storagetest
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:revive
+	. "github.com/onsi/gomega"    //nolint:revive
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+)
+
+func describeObjects(state *suiteState) {
+	Describe("objects", func() {
+		When("an object is written", func() {
+			It("returns its content and metadata", func(ctx context.Context) {
+				content := []byte("hello world")
+				input := putInput(content)
+				input.Metadata.ContentType = "text/plain"
+				input.Metadata.Meta = map[string]string{"x-amz-meta-owner": "team"}
+
+				Expect(state.bucket.PutObject(ctx, "dir/hello.txt", input)).To(Succeed())
+
+				object := lo.Must(state.bucket.HeadObject(ctx, "dir/hello.txt"))
+				defer object.Close()
+
+				Expect(object.Key()).To(Equal("dir/hello.txt"))
+				Expect(object.Size()).To(Equal(int64(len(content))))
+				Expect(object.LastModified()).NotTo(BeZero())
+				Expect(object.Metadata().SHA256).To(Equal(checksum(content)))
+				Expect(object.Metadata().SHA256Base64).NotTo(BeEmpty())
+				Expect(object.Metadata().ContentType).To(Equal("text/plain"))
+				Expect(object.Metadata().Meta).To(HaveKeyWithValue("x-amz-meta-owner", "team"))
+
+				Expect(readObject(ctx, state.bucket, "dir/hello.txt")).To(Equal(content))
+			})
+
+			It("supports seeking in the object body", func(ctx context.Context) {
+				putObject(ctx, state.bucket, "seek.txt", []byte("0123456789"))
+
+				object := lo.Must(state.bucket.GetObject(ctx, "seek.txt"))
+				defer object.Close()
+
+				lo.Must(object.Seek(5, 0))
+
+				buf := make([]byte, 3)
+				lo.Must(object.Read(buf))
+				Expect(string(buf)).To(Equal("567"))
+			})
+		})
+
+		When("an object is overwritten", func() {
+			It("returns the new content", func(ctx context.Context) {
+				putObject(ctx, state.bucket, "file.txt", []byte("first"))
+				putObject(ctx, state.bucket, "file.txt", []byte("second version"))
+
+				Expect(readObject(ctx, state.bucket, "file.txt")).To(Equal([]byte("second version")))
+			})
+		})
+
+		When("the checksum does not match the content", func() {
+			It("returns ErrObjectChecksumMismatch and stores nothing", func(ctx context.Context) {
+				input := putInput([]byte("content"))
+				input.Metadata.SHA256 = checksum([]byte("other content"))
+
+				Expect(state.bucket.PutObject(ctx, "file.txt", input)).To(MatchError(core.ErrObjectChecksumMismatch))
+
+				_, err := state.bucket.HeadObject(ctx, "file.txt")
+				Expect(err).To(MatchError(core.ErrObjectNotFound))
+			})
+		})
+
+		When("the object does not exist", func() {
+			It("returns ErrObjectNotFound", func(ctx context.Context) {
+				_, err := state.bucket.HeadObject(ctx, "missing.txt")
+				Expect(err).To(MatchError(core.ErrObjectNotFound))
+
+				_, err = state.bucket.GetObject(ctx, "missing.txt")
+				Expect(err).To(MatchError(core.ErrObjectNotFound))
+			})
+		})
+
+		When("IfNoneMatch is set and the object exists", func() {
+			It("returns ErrPreconditionFailed and keeps the original", func(ctx context.Context) {
+				putObject(ctx, state.bucket, "file.txt", []byte("original"))
+
+				input := putInput([]byte("replacement"))
+				input.IfNoneMatch = true
+
+				Expect(state.bucket.PutObject(ctx, "file.txt", input)).To(MatchError(core.ErrPreconditionFailed))
+				Expect(readObject(ctx, state.bucket, "file.txt")).To(Equal([]byte("original")))
+			})
+		})
+
+		When("many writers race with IfNoneMatch", func() {
+			It("lets exactly one of them win", func(ctx context.Context) {
+				const writers = 8
+
+				errs := make(chan error, writers)
+
+				for i := range writers {
+					go func() {
+						defer GinkgoRecover()
+
+						input := putInput([]byte{byte('a' + i)})
+						input.IfNoneMatch = true
+
+						errs <- state.bucket.PutObject(ctx, "race.txt", input)
+					}()
+				}
+
+				succeeded := 0
+
+				for range writers {
+					err := <-errs
+					if err == nil {
+						succeeded++
+
+						continue
+					}
+
+					Expect(err).To(MatchError(core.ErrPreconditionFailed))
+				}
+
+				Expect(succeeded).To(Equal(1))
+				Expect(readObject(ctx, state.bucket, "race.txt")).To(HaveLen(1))
+			})
+		})
+
+		When("objects are deleted", func() {
+			It("reports per-key results", func(ctx context.Context) {
+				putObjects(ctx, state.bucket, "a.txt", "b.txt")
+
+				results := lo.Must(state.bucket.DeleteObjects(ctx, core.DeleteObjectsInput{
+					Keys: []string{"a.txt", "missing.txt", "b.txt"},
+				}))
+				Expect(results).To(HaveLen(3))
+
+				Expect(results[0]).To(Equal(core.DeleteResult{Key: "a.txt"}))
+				Expect(results[1].Key).To(Equal("missing.txt"))
+				Expect(results[1].Error).To(MatchError(core.ErrObjectNotFound))
+				Expect(results[2]).To(Equal(core.DeleteResult{Key: "b.txt"}))
+
+				_, err := state.bucket.HeadObject(ctx, "a.txt")
+				Expect(err).To(MatchError(core.ErrObjectNotFound))
+			})
+
+			It("omits successful deletions in quiet mode", func(ctx context.Context) {
+				putObjects(ctx, state.bucket, "a.txt")
+
+				results := lo.Must(state.bucket.DeleteObjects(ctx, core.DeleteObjectsInput{
+					Keys:  []string{"a.txt", "missing.txt"},
+					Quiet: true,
+				}))
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Key).To(Equal("missing.txt"))
+			})
+		})
+	})
+}
@@ -0,0 +1,189 @@
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:revive
+	. "github.com/onsi/gomega"    //nolint:revive
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+)
+
+func describeQuota(state *suiteState) { //nolint:funlen
+	Describe("bucket quotas", func() {
+		usage := func(ctx context.Context) core.BucketUsage {
+			GinkgoHelper()
+
+			return *lo.Must(state.bucket.Usage(ctx))
+		}
+
+		It("tracks the usage of writes and deletes", func(ctx context.Context) {
+			Expect(usage(ctx)).To(BeZero())
+
+			putObject(ctx, state.bucket, "a.txt", []byte("12345"))
+			putObject(ctx, state.bucket, "dir/b.txt", []byte("1234567890"))
+			Expect(usage(ctx)).To(Equal(core.BucketUsage{Bytes: 15, Objects: 2}))
+
+			putObject(ctx, state.bucket, "a.txt", []byte("123"))
+			Expect(usage(ctx)).To(Equal(core.BucketUsage{Bytes: 13, Objects: 2}))
+
+			source := lo.Must(state.bucket.HeadObject(ctx, "dir/b.txt"))
+			lo.Must(state.bucket.CopyObject(ctx, "c.txt", core.CopyObjectInput{Source: source}))
+			Expect(usage(ctx)).To(Equal(core.BucketUsage{Bytes: 23, Objects: 3}))
+
+			deleteObjects(ctx, state.bucket, "a.txt", "missing.txt")
+			Expect(usage(ctx)).To(Equal(core.BucketUsage{Bytes: 20, Objects: 2}))
+
+			Expect(*lo.Must(state.bucket.RescanUsage(ctx))).To(Equal(usage(ctx)))
+		})
+
+		It("persists the quota", func(ctx context.Context) {
+			quota := &core.BucketQuota{MaxBytes: 100, MaxObjects: 10}
+			Expect(state.bucket.PutQuota(ctx, quota)).To(Succeed())
+			Expect(lo.Must(state.backend.HeadBucket(ctx, bucketName)).Quota()).To(Equal(quota))
+
+			Expect(state.bucket.PutQuota(ctx, nil)).To(Succeed())
+			Expect(lo.Must(state.backend.HeadBucket(ctx, bucketName)).Quota()).To(BeNil())
+
+			Expect(state.bucket.PutQuota(ctx, &core.BucketQuota{MaxBytes: -1})).
+				To(MatchError(core.ErrBucketQuotaInvalid))
+		})
+
+		When("the bucket has a quota", func() {
+			BeforeEach(func(ctx context.Context) {
+				Expect(state.bucket.PutQuota(ctx, &core.BucketQuota{MaxBytes: 20, MaxObjects: 2})).To(Succeed())
+			})
+
+			It("rejects writes over the object limit but not overwrites", func(ctx context.Context) {
+				putObjects(ctx, state.bucket, "a.txt", "b.txt")
+
+				Expect(state.bucket.PutObject(ctx, "c.txt", putInput([]byte("c")))).
+					To(MatchError(core.ErrQuotaExceeded))
+				Expect(state.bucket.PutObject(ctx, "a.txt", putInput([]byte("new a")))).To(Succeed())
+
+				source := lo.Must(state.bucket.HeadObject(ctx, "a.txt"))
+				_, err := state.bucket.CopyObject(ctx, "c.txt", core.CopyObjectInput{Source: source})
+				Expect(err).To(MatchError(core.ErrQuotaExceeded))
+
+				Expect(usage(ctx)).To(Equal(core.BucketUsage{Bytes: 10, Objects: 2}))
+				Expect(objectKeys(lo.Must(state.bucket.ListObjectsV2(ctx, core.ListObjectsV2Input{MaxKeys: 10})).Objects)).
+					To(Equal([]string{"a.txt", "b.txt"}))
+			})
+
+			It("rejects writes over the byte limit and keeps the existing object", func(ctx context.Context) {
+				putObject(ctx, state.bucket, "a.txt", bytes.Repeat([]byte("a"), 15))
+
+				Expect(state.bucket.PutObject(ctx, "a.txt", putInput(bytes.Repeat([]byte("b"), 21)))).
+					To(MatchError(core.ErrQuotaExceeded))
+				Expect(readObject(ctx, state.bucket, "a.txt")).To(Equal(bytes.Repeat([]byte("a"), 15)))
+
+				Expect(state.bucket.PutObject(ctx, "b.txt", putInput([]byte("123456")))).
+					To(MatchError(core.ErrQuotaExceeded))
+				putObject(ctx, state.bucket, "b.txt", []byte("12345"))
+
+				Expect(usage(ctx)).To(Equal(core.BucketUsage{Bytes: 20, Objects: 2}))
+			})
+
+			It("lets a bucket over a lowered quota shrink", func(ctx context.Context) {
+				putObjects(ctx, state.bucket, "a.txt", "b.txt")
+				Expect(state.bucket.PutQuota(ctx, &core.BucketQuota{MaxObjects: 1})).To(Succeed())
+
+				putObject(ctx, state.bucket, "a.txt", []byte("a"))
+				deleteObjects(ctx, state.bucket, "a.txt")
+				Expect(usage(ctx)).To(Equal(core.BucketUsage{Bytes: 5, Objects: 1}))
+			})
+
+			It("rejects parts and completed uploads over the byte limit", func(ctx context.Context) {
+				uploadID := lo.Must(state.bucket.CreateMultipartUpload(ctx, "multipart.txt", core.ObjectMetadata{}, nil))
+
+				_, err := state.bucket.UploadPart(ctx, "multipart.txt", uploadID, 1,
+					bytes.NewReader(bytes.Repeat([]byte("a"), 21)), nil)
+				Expect(err).To(MatchError(core.ErrQuotaExceeded))
+
+				etag1 := lo.Must(state.bucket.UploadPart(ctx, "multipart.txt", uploadID, 1,
+					bytes.NewReader(bytes.Repeat([]byte("a"), 15)), nil))
+				etag2 := lo.Must(state.bucket.UploadPart(ctx, "multipart.txt", uploadID, 2,
+					bytes.NewReader(bytes.Repeat([]byte("b"), 15)), nil))
+
+				_, err = state.bucket.CompleteMultipartUpload(ctx, "multipart.txt", uploadID, []core.CompletePart{
+					{PartNumber: 1, ETag: etag1},
+					{PartNumber: 2, ETag: etag2},
+				})
+				Expect(err).To(MatchError(core.ErrQuotaExceeded))
+
+				Expect(state.bucket.PutQuota(ctx, &core.BucketQuota{MaxBytes: 30})).To(Succeed())
+				lo.Must(state.bucket.CompleteMultipartUpload(ctx, "multipart.txt", uploadID, []core.CompletePart{
+					{PartNumber: 1, ETag: etag1},
+					{PartNumber: 2, ETag: etag2},
+				}))
+				Expect(usage(ctx)).To(Equal(core.BucketUsage{Bytes: 30, Objects: 1}))
+			})
+
+			It("rejects completed uploads whose parts only fit one by one", func(ctx context.Context) {
+				putObject(ctx, state.bucket, "a.txt", bytes.Repeat([]byte("a"), 10))
+
+				uploadID := lo.Must(state.bucket.CreateMultipartUpload(ctx, "multipart.txt", core.ObjectMetadata{}, nil))
+				etag1 := lo.Must(state.bucket.UploadPart(ctx, "multipart.txt", uploadID, 1,
+					bytes.NewReader(bytes.Repeat([]byte("b"), 6)), nil))
+				etag2 := lo.Must(state.bucket.UploadPart(ctx, "multipart.txt", uploadID, 2,
+					bytes.NewReader(bytes.Repeat([]byte("c"), 6)), nil))
+
+				_, err := state.bucket.CompleteMultipartUpload(ctx, "multipart.txt", uploadID, []core.CompletePart{
+					{PartNumber: 1, ETag: etag1},
+					{PartNumber: 2, ETag: etag2},
+				})
+				Expect(err).To(MatchError(core.ErrQuotaExceeded))
+
+				_, err = state.bucket.HeadObject(ctx, "multipart.txt")
+				Expect(err).To(MatchError(core.ErrObjectNotFound))
+				Expect(usage(ctx)).To(Equal(core.BucketUsage{Bytes: 10, Objects: 1}))
+			})
+
+			It("does not let concurrent writes overshoot the quota", func(ctx context.Context) {
+				var (
+					wg        sync.WaitGroup
+					succeeded int
+					mu        sync.Mutex
+				)
+
+				for i := range 8 {
+					wg.Go(func() {
+						defer GinkgoRecover()
+
+						err := state.bucket.PutObject(ctx, fmt.Sprintf("%d.txt", i), putInput([]byte("1")))
+						if err == nil {
+							mu.Lock()
+							succeeded++
+							mu.Unlock()
+
+							return
+						}
+
+						Expect(err).To(MatchError(core.ErrQuotaExceeded))
+					})
+				}
+
+				wg.Wait()
+
+				Expect(succeeded).To(Equal(2))
+				Expect(usage(ctx)).To(Equal(core.BucketUsage{Bytes: 2, Objects: 2}))
+			})
+		})
+
+		It("replaces existing objects with completed uploads", func(ctx context.Context) {
+			putObject(ctx, state.bucket, "multipart.txt", []byte("old content"))
+
+			uploadID := lo.Must(state.bucket.CreateMultipartUpload(ctx, "multipart.txt", core.ObjectMetadata{}, nil))
+			etag := lo.Must(state.bucket.UploadPart(ctx, "multipart.txt", uploadID, 1,
+				bytes.NewReader([]byte("new")), nil))
+			lo.Must(state.bucket.CompleteMultipartUpload(ctx, "multipart.txt", uploadID,
+				[]core.CompletePart{{PartNumber: 1, ETag: etag}}))
+
+			Expect(readObject(ctx, state.bucket, "multipart.txt")).To(Equal([]byte("new")))
+			Expect(usage(ctx)).To(Equal(core.BucketUsage{Bytes: 3, Objects: 1}))
+		})
+	})
+}
@@ -0,0 +1,80 @@
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:revive
+	. "github.com/onsi/gomega"    //nolint:revive
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+)
+
+func describeStorageStats(state *suiteState) {
+	Describe("storage stats", func() {
+		bucketStats := func(ctx context.Context) core.BucketStats {
+			GinkgoHelper()
+
+			stats := lo.Must(state.backend.StorageStats(ctx))
+			Expect(stats.ScannedAt).NotTo(BeZero())
+
+			bucket, ok := lo.Find(stats.Buckets, func(bucket core.BucketStats) bool { return bucket.Name == bucketName })
+			Expect(ok).To(BeTrue())
+
+			return bucket
+		}
+
+		summaryKeys := func(summaries []core.ObjectSummary) []string {
+			return lo.Map(summaries, func(summary core.ObjectSummary, _ int) string { return summary.Key })
+		}
+
+		It("reports an empty bucket", func(ctx context.Context) {
+			stats := bucketStats(ctx)
+			Expect(stats.UsageStats).To(BeZero())
+			Expect(stats.Prefixes).To(BeEmpty())
+		})
+
+		It("reports objects and incomplete uploads by top-level prefix", func(ctx context.Context) {
+			putObject(ctx, state.bucket, "root.txt", []byte("1"))
+			putObject(ctx, state.bucket, "logs/a.txt", []byte("12"))
+			putObject(ctx, state.bucket, "logs/nested/b.txt", []byte("123"))
+			putObject(ctx, state.bucket, "photos/c.jpg", []byte("1234"))
+
+			uploadID := lo.Must(state.bucket.CreateMultipartUpload(ctx, "photos/d.jpg", core.ObjectMetadata{}, nil))
+			lo.Must(state.bucket.UploadPart(ctx, "photos/d.jpg", uploadID, 1, bytes.NewReader([]byte("12345")), nil))
+
+			stats := bucketStats(ctx)
+			Expect(stats.Objects).To(Equal(int64(4)))
+			Expect(stats.Bytes).To(Equal(int64(10)))
+			Expect(stats.MultipartBytes).To(Equal(int64(5)))
+			Expect(summaryKeys(stats.Largest)).To(Equal([]string{"photos/c.jpg", "logs/nested/b.txt", "logs/a.txt", "root.txt"}))
+			Expect(summaryKeys(stats.Oldest)).To(ConsistOf("photos/c.jpg", "logs/nested/b.txt", "logs/a.txt", "root.txt"))
+
+			Expect(lo.Map(stats.Prefixes, func(prefix core.PrefixStats, _ int) string { return prefix.Prefix })).
+				To(Equal([]string{"", "logs/", "photos/"}))
+
+			logs := stats.Prefixes[1]
+			Expect(logs.Objects).To(Equal(int64(2)))
+			Expect(logs.Bytes).To(Equal(int64(5)))
+			Expect(logs.MultipartBytes).To(BeZero())
+			Expect(summaryKeys(logs.Largest)).To(Equal([]string{"logs/nested/b.txt", "logs/a.txt"}))
+
+			photos := stats.Prefixes[2]
+			Expect(photos.Objects).To(Equal(int64(1)))
+			Expect(photos.MultipartBytes).To(Equal(int64(5)))
+		})
+
+		It("lists a limited number of the largest objects", func(ctx context.Context) {
+			for i := range core.StatsTopObjects + 2 {
+				putObject(ctx, state.bucket, fmt.Sprintf("%02d.txt", i), bytes.Repeat([]byte("a"), i+1))
+			}
+
+			stats := bucketStats(ctx)
+			Expect(stats.Objects).To(Equal(int64(core.StatsTopObjects + 2)))
+			Expect(stats.Largest).To(HaveLen(core.StatsTopObjects))
+			Expect(stats.Largest[0].Key).To(Equal(fmt.Sprintf("%02d.txt", core.StatsTopObjects+1)))
+			Expect(stats.Oldest).To(HaveLen(core.StatsTopObjects))
+		})
+	})
+}
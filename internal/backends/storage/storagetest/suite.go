@@ -0,0 +1,121 @@
+// Package storagetest provides a reusable Ginkgo conformance suite for core.StorageBackend implementations.
+//
+// A backend package registers the suite from one of its test files:
+//
+//	var _ = storagetest.DescribeStorageBackend("folder", func(ctx context.Context) core.StorageBackend {
+//		backend := &folder.Backend{Cfg: cfg, Locker: storagetest.NewLocker()}
+//		lo.Must0(backend.Init(ctx))
+//
+//		return backend
+//	})
+//
+// The suite talks to core.Bucket directly, without the HTTP layer, so it pins down backend
+// semantics that the S3 API relies on: pagination, delimiters, multipart validation,
+// conditional writes, tagging, copy directives, bucket logging and compression configs, server-side encryption,
+// deduplication, Object Lock, bucket quotas, storage stats and context cancellation.
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:revive
+	. "github.com/onsi/gomega"    //nolint:revive
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+)
+
+const bucketName = "conformance"
+
+// BackendFactory builds a fresh, initialized backend for a single spec. It is called from a
+// BeforeEach node, so implementations may use DeferCleanup to remove temporary state.
+// Backends that take a core.Locker must be given one that really serializes holders of the same key,
+// such as the one returned by NewLocker. A SSE-S3 master key must be configured.
+type BackendFactory func(ctx context.Context) core.StorageBackend
+
+type suiteState struct {
+	backend core.StorageBackend
+	bucket  core.Bucket
+}
+
+// DescribeStorageBackend registers the storage backend conformance specs under a top-level container
+// named after the backend.
+func DescribeStorageBackend(name string, factory BackendFactory) bool {
+	return Describe(name+" storage backend conformance", Label("storage-backend"), func() {
+		state := &suiteState{}
+
+		BeforeEach(func(ctx context.Context) {
+			state.backend = factory(ctx)
+
+			lo.Must0(state.backend.CreateBucket(ctx, bucketName))
+			state.bucket = lo.Must(state.backend.HeadBucket(ctx, bucketName))
+		})
+
+		describeBuckets(state)
+		describeObjects(state)
+		describeListObjectsV2(state)
+		describeMultipart(state)
+		describeTagging(state)
+		describeCopyObject(state)
+		describeEncryption(state)
+		describeDedup(state)
+		describeObjectLock(state)
+		describeQuota(state)
+		describeStorageStats(state)
+		describeCancellation(state)
+	})
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func putInput(content []byte) core.PutObjectInput {
+	return core.PutObjectInput{
+		Reader: bytes.NewReader(content),
+		Metadata: core.ObjectMetadata{
+			ContentType: "application/octet-stream",
+			SHA256:      checksum(content),
+			Size:        int64(len(content)),
+		},
+	}
+}
+
+func putObject(ctx context.Context, bucket core.Bucket, key string, content []byte) {
+	GinkgoHelper()
+
+	Expect(bucket.PutObject(ctx, key, putInput(content))).To(Succeed())
+}
+
+func putObjects(ctx context.Context, bucket core.Bucket, keys ...string) {
+	GinkgoHelper()
+
+	for _, key := range keys {
+		putObject(ctx, bucket, key, []byte(key))
+	}
+}
+
+// deleteObjects deletes the objects quietly, failing the spec on error.
+func deleteObjects(ctx context.Context, bucket core.Bucket, keys ...string) {
+	GinkgoHelper()
+
+	lo.Must(bucket.DeleteObjects(ctx, core.DeleteObjectsInput{Keys: keys, Quiet: true}))
+}
+
+func readObject(ctx context.Context, bucket core.Bucket, key string) []byte {
+	GinkgoHelper()
+
+	object := lo.Must(bucket.GetObject(ctx, key))
+	defer object.Close()
+
+	return lo.Must(io.ReadAll(object))
+}
+
+func objectKeys(objects []core.Object) []string {
+	return lo.Map(objects, func(object core.Object, _ int) string { return object.Key() })
+}
@@ -0,0 +1,44 @@
+package storagetest
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:revive
+	. "github.com/onsi/gomega"    //nolint:revive
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+)
+
+func describeTagging(state *suiteState) {
+	Describe("object tagging", func() {
+		When("the object exists", func() {
+			BeforeEach(func(ctx context.Context) {
+				putObject(ctx, state.bucket, "tagged.txt", []byte("content"))
+			})
+
+			It("replaces and deletes tags without touching the content", func(ctx context.Context) {
+				Expect(state.bucket.PutObjectTagging(ctx, "tagged.txt", map[string]string{"env": "dev"})).To(Succeed())
+				Expect(state.bucket.PutObjectTagging(ctx, "tagged.txt", map[string]string{"team": "storage"})).
+					To(Succeed())
+
+				object := lo.Must(state.bucket.HeadObject(ctx, "tagged.txt"))
+				Expect(object.Metadata().Tags).To(Equal(map[string]string{"team": "storage"}))
+
+				Expect(state.bucket.DeleteObjectTagging(ctx, "tagged.txt")).To(Succeed())
+
+				object = lo.Must(state.bucket.HeadObject(ctx, "tagged.txt"))
+				Expect(object.Metadata().Tags).To(BeEmpty())
+				Expect(object.Metadata().SHA256).To(Equal(checksum([]byte("content"))))
+				Expect(readObject(ctx, state.bucket, "tagged.txt")).To(Equal([]byte("content")))
+			})
+		})
+
+		When("the object does not exist", func() {
+			It("returns ErrObjectNotFound", func(ctx context.Context) {
+				Expect(state.bucket.PutObjectTagging(ctx, "missing.txt", map[string]string{"env": "dev"})).
+					To(MatchError(core.ErrObjectNotFound))
+				Expect(state.bucket.DeleteObjectTagging(ctx, "missing.txt")).To(MatchError(core.ErrObjectNotFound))
+			})
+		})
+	})
+}
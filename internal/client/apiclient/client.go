@@ -10,11 +10,15 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/zhulik/d3/internal/backends/management/yaml"
 	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/internal/ratelimit"
 	"github.com/zhulik/d3/pkg/iampol"
 )
 
@@ -36,6 +40,16 @@ type Client struct {
 func (c *Client) Init(_ context.Context) error {
 	c.signer = v4.NewSigner()
 	c.httpClient = &http.Client{}
+
+	transport, err := c.Config.HTTPTransport()
+	if err != nil {
+		return err
+	}
+
+	if transport != nil {
+		c.httpClient.Transport = transport
+	}
+
 	c.creds = aws.Credentials{
 		AccessKeyID:     c.Config.AccessKeyID,
 		SecretAccessKey: c.Config.AccessKeySecret,
@@ -94,16 +108,20 @@ func (c *Client) CreateUser(ctx context.Context, name string) (core.User, error)
 	}
 
 	return core.User{
-		Name:            response.Name,
-		AccessKeyID:     response.AccessKeyID,
-		SecretAccessKey: response.SecretAccessKey,
+		Name: response.Name,
+		AccessKeys: []core.AccessKey{{
+			AccessKeyID:     response.AccessKeyID,
+			SecretAccessKey: response.SecretAccessKey,
+			Status:          core.AccessKeyStatusActive,
+		}},
 	}, nil
 }
 
+// UpdateUser replaces all the access keys of the user with a new one.
+//
+// Deprecated: use CreateAccessKey and DeleteAccessKey, they rotate keys without downtime.
 func (c *Client) UpdateUser(ctx context.Context, name string) (core.User, error) {
-	url := c.Config.ServerURL + "/users/" + name
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.Config.ServerURL+"/users/"+name, nil)
 	if err != nil {
 		return core.User{}, err
 	}
@@ -123,9 +141,12 @@ func (c *Client) UpdateUser(ctx context.Context, name string) (core.User, error)
 	}
 
 	return core.User{
-		Name:            response.Name,
-		AccessKeyID:     response.AccessKeyID,
-		SecretAccessKey: response.SecretAccessKey,
+		Name: response.Name,
+		AccessKeys: []core.AccessKey{{
+			AccessKeyID:     response.AccessKeyID,
+			SecretAccessKey: response.SecretAccessKey,
+			Status:          core.AccessKeyStatusActive,
+		}},
 	}, nil
 }
 
@@ -147,6 +168,102 @@ func (c *Client) DeleteUser(ctx context.Context, name string) error {
 	return nil
 }
 
+func (c *Client) ListAccessKeys(ctx context.Context, userName string) ([]core.AccessKey, error) {
+	url := c.Config.ServerURL + "/users/" + userName + "/keys"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var keys []core.AccessKey
+
+	err = json.NewDecoder(resp.Body).Decode(&keys)
+
+	return keys, err
+}
+
+func (c *Client) CreateAccessKey(ctx context.Context, userName string, expiresAt *time.Time) (core.AccessKey, error) {
+	jsonBody, err := json.Marshal(map[string]*time.Time{"expires_at": expiresAt})
+	if err != nil {
+		return core.AccessKey{}, err
+	}
+
+	url := c.Config.ServerURL + "/users/" + userName + "/keys"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return core.AccessKey{}, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusCreated)
+	if err != nil {
+		return core.AccessKey{}, err
+	}
+
+	defer resp.Body.Close()
+
+	var key core.AccessKey
+
+	err = json.NewDecoder(resp.Body).Decode(&key)
+
+	return key, err
+}
+
+func (c *Client) SetAccessKeyStatus(ctx context.Context, userName, accessKeyID string,
+	status core.AccessKeyStatus,
+) error {
+	jsonBody, err := json.Marshal(map[string]core.AccessKeyStatus{"status": status})
+	if err != nil {
+		return err
+	}
+
+	url := c.Config.ServerURL + "/users/" + userName + "/keys/" + accessKeyID
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+func (c *Client) DeleteAccessKey(ctx context.Context, userName, accessKeyID string) error {
+	url := c.Config.ServerURL + "/users/" + userName + "/keys/" + accessKeyID
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
 func (c *Client) ListPolicies(ctx context.Context) ([]string, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Config.ServerURL+"/policies", nil)
 	if err != nil {
@@ -368,6 +485,751 @@ func (c *Client) DeleteBinding(ctx context.Context, userName, policyID string) e
 	return nil
 }
 
+func (c *Client) ListGroups(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Config.ServerURL+"/groups", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var groups []string
+
+	err = json.NewDecoder(resp.Body).Decode(&groups)
+
+	return groups, err
+}
+
+func (c *Client) GetGroup(ctx context.Context, name string) (core.Group, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Config.ServerURL+"/groups/"+name, nil)
+	if err != nil {
+		return core.Group{}, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return core.Group{}, err
+	}
+
+	defer resp.Body.Close()
+
+	var group core.Group
+
+	err = json.NewDecoder(resp.Body).Decode(&group)
+
+	return group, err
+}
+
+func (c *Client) CreateGroup(ctx context.Context, name string) error {
+	jsonBody, err := json.Marshal(map[string]string{"name": name})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Config.ServerURL+"/groups", bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+func (c *Client) DeleteGroup(ctx context.Context, name string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.Config.ServerURL+"/groups/"+name, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+func (c *Client) AddGroupMember(ctx context.Context, groupName, userName string) error {
+	jsonBody, err := json.Marshal(map[string]string{"user_name": userName})
+	if err != nil {
+		return err
+	}
+
+	url := c.Config.ServerURL + "/groups/" + groupName + "/members"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+func (c *Client) RemoveGroupMember(ctx context.Context, groupName, userName string) error {
+	url := c.Config.ServerURL + "/groups/" + groupName + "/members/" + userName
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+func (c *Client) GetBindingsByGroup(ctx context.Context, groupName string) ([]core.GroupBinding, error) {
+	url := c.Config.ServerURL + "/groups/" + groupName + "/policies"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var bindings []core.GroupBinding
+
+	err = json.NewDecoder(resp.Body).Decode(&bindings)
+
+	return bindings, err
+}
+
+func (c *Client) CreateGroupBinding(ctx context.Context, groupName, policyID string) error {
+	jsonBody, err := json.Marshal(map[string]string{"policy_id": policyID})
+	if err != nil {
+		return err
+	}
+
+	url := c.Config.ServerURL + "/groups/" + groupName + "/policies"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+func (c *Client) DeleteGroupBinding(ctx context.Context, groupName, policyID string) error {
+	url := c.Config.ServerURL + "/groups/" + groupName + "/policies/" + policyID
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+func (c *Client) ListRoles(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Config.ServerURL+"/roles", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var roles []string
+
+	err = json.NewDecoder(resp.Body).Decode(&roles)
+
+	return roles, err
+}
+
+func (c *Client) GetRole(ctx context.Context, name string) (core.Role, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Config.ServerURL+"/roles/"+name, nil)
+	if err != nil {
+		return core.Role{}, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return core.Role{}, err
+	}
+
+	defer resp.Body.Close()
+
+	var role core.Role
+
+	err = json.NewDecoder(resp.Body).Decode(&role)
+
+	return role, err
+}
+
+func (c *Client) CreateRole(ctx context.Context, role *core.Role) error {
+	jsonBody, err := json.Marshal(role)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Config.ServerURL+"/roles", bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+func (c *Client) DeleteRole(ctx context.Context, name string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.Config.ServerURL+"/roles/"+name, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// SimulatePolicy returns how the server would authorize the user for every action and resource pair.
+func (c *Client) SimulatePolicy(
+	ctx context.Context, simulation *core.PolicySimulation,
+) ([]core.PolicySimulationResult, error) {
+	jsonBody, err := json.Marshal(simulation)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Config.ServerURL+"/simulate", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var results []core.PolicySimulationResult
+
+	err = json.NewDecoder(resp.Body).Decode(&results)
+
+	return results, err
+}
+
+// Apply converges the server's users, policies and bindings to cfg and returns the planned changes. Nothing is
+// changed on a dry run, and resources missing from cfg are only deleted when pruning.
+func (c *Client) Apply(
+	ctx context.Context, cfg *yaml.ManagementConfig, dryRun, prune bool,
+) ([]core.ManagementChange, error) {
+	jsonBody, err := json.Marshal(map[string]any{"config": cfg, "dry_run": dryRun, "prune": prune})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Config.ServerURL+"/apply", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var changes []core.ManagementChange
+
+	err = json.NewDecoder(resp.Body).Decode(&changes)
+
+	return changes, err
+}
+
+// QueryAudit returns the audit events matching the query, oldest first.
+func (c *Client) QueryAudit(ctx context.Context, query core.AuditQuery) ([]core.AuditEvent, error) {
+	params := url.Values{}
+
+	if !query.From.IsZero() {
+		params.Set("from", query.From.Format(time.RFC3339))
+	}
+
+	if !query.To.IsZero() {
+		params.Set("to", query.To.Format(time.RFC3339))
+	}
+
+	if query.User != "" {
+		params.Set("user", query.User)
+	}
+
+	if query.Action != "" {
+		params.Set("action", query.Action)
+	}
+
+	if query.Limit > 0 {
+		params.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Config.ServerURL+"/audit?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var events []core.AuditEvent
+
+	err = json.NewDecoder(resp.Body).Decode(&events)
+
+	return events, err
+}
+
+// GetLimits returns the rate limits of a user, or of a group or a role when kind is "groups" or "roles".
+func (c *Client) GetLimits(ctx context.Context, kind, name string) (core.RateLimits, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.limitsURL(kind, name), nil)
+	if err != nil {
+		return core.RateLimits{}, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return core.RateLimits{}, err
+	}
+
+	defer resp.Body.Close()
+
+	var limits core.RateLimits
+
+	err = json.NewDecoder(resp.Body).Decode(&limits)
+
+	return limits, err
+}
+
+// SetLimits replaces the rate limits of a user, or of a group or a role when kind is "groups" or "roles".
+func (c *Client) SetLimits(ctx context.Context, kind, name string, limits core.RateLimits) error {
+	jsonBody, err := json.Marshal(limits)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.limitsURL(kind, name), bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// DeleteLimits removes the rate limits of a user, or of a group or a role when kind is "groups" or "roles".
+func (c *Client) DeleteLimits(ctx context.Context, kind, name string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.limitsURL(kind, name), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+func (c *Client) GetLimitStats(ctx context.Context) ([]ratelimit.Stats, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Config.ServerURL+"/limits/stats", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var stats []ratelimit.Stats
+
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+
+	return stats, err
+}
+
+func (c *Client) limitsURL(kind, name string) string {
+	return c.Config.ServerURL + "/" + kind + "/" + name + "/limits"
+}
+
+// GetBucketCompression returns the compression override of a bucket, with an empty algorithm when it has none.
+func (c *Client) GetBucketCompression(ctx context.Context, bucket string) (core.BucketCompression, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.bucketCompressionURL(bucket), nil)
+	if err != nil {
+		return core.BucketCompression{}, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return core.BucketCompression{}, err
+	}
+
+	defer resp.Body.Close()
+
+	var compression core.BucketCompression
+
+	err = json.NewDecoder(resp.Body).Decode(&compression)
+
+	return compression, err
+}
+
+// SetBucketCompression replaces the compression override of a bucket.
+func (c *Client) SetBucketCompression(ctx context.Context, bucket string, compression core.BucketCompression) error {
+	jsonBody, err := json.Marshal(compression)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.bucketCompressionURL(bucket),
+		bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// DeleteBucketCompression removes the compression override of a bucket.
+func (c *Client) DeleteBucketCompression(ctx context.Context, bucket string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.bucketCompressionURL(bucket), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+func (c *Client) bucketCompressionURL(bucket string) string {
+	return c.Config.ServerURL + "/buckets/" + bucket + "/compression"
+}
+
+// GetBucketQuota returns the quota of a bucket, with zero limits when it has none.
+func (c *Client) GetBucketQuota(ctx context.Context, bucket string) (core.BucketQuota, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.bucketQuotaURL(bucket), nil)
+	if err != nil {
+		return core.BucketQuota{}, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return core.BucketQuota{}, err
+	}
+
+	defer resp.Body.Close()
+
+	var quota core.BucketQuota
+
+	err = json.NewDecoder(resp.Body).Decode(&quota)
+
+	return quota, err
+}
+
+// SetBucketQuota replaces the quota of a bucket.
+func (c *Client) SetBucketQuota(ctx context.Context, bucket string, quota core.BucketQuota) error {
+	jsonBody, err := json.Marshal(quota)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.bucketQuotaURL(bucket), bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// DeleteBucketQuota removes the quota of a bucket.
+func (c *Client) DeleteBucketQuota(ctx context.Context, bucket string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.bucketQuotaURL(bucket), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// GetBucketUsage returns the bytes and objects a bucket holds.
+func (c *Client) GetBucketUsage(ctx context.Context, bucket string) (*core.BucketUsage, error) {
+	return c.bucketUsage(ctx, http.MethodGet, c.Config.ServerURL+"/buckets/"+bucket+"/usage")
+}
+
+// RescanBucketUsage recomputes the usage of a bucket from its objects and returns it.
+func (c *Client) RescanBucketUsage(ctx context.Context, bucket string) (*core.BucketUsage, error) {
+	return c.bucketUsage(ctx, http.MethodPost, c.Config.ServerURL+"/buckets/"+bucket+"/usage/rescan")
+}
+
+func (c *Client) bucketUsage(ctx context.Context, method, url string) (*core.BucketUsage, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var usage core.BucketUsage
+
+	err = json.NewDecoder(resp.Body).Decode(&usage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &usage, nil
+}
+
+func (c *Client) bucketQuotaURL(bucket string) string {
+	return c.Config.ServerURL + "/buckets/" + bucket + "/quota"
+}
+
+// GetStorageRoots describes the directories buckets are stored in.
+func (c *Client) GetStorageRoots(ctx context.Context) ([]core.StorageRoot, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Config.ServerURL+"/storage/roots", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var roots []core.StorageRoot
+
+	err = json.NewDecoder(resp.Body).Decode(&roots)
+	if err != nil {
+		return nil, err
+	}
+
+	return roots, nil
+}
+
+// MoveBucket moves a bucket to another storage root, it returns once the bucket is moved.
+func (c *Client) MoveBucket(ctx context.Context, bucket, root string) error {
+	jsonBody, err := json.Marshal(map[string]string{"root": root})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.Config.ServerURL+"/buckets/"+bucket+"/root",
+		bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// GetDedupStats returns how much storage the deduplication of blobs saves.
+func (c *Client) GetDedupStats(ctx context.Context) (*core.DedupStats, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Config.ServerURL+"/stats/dedup", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var stats core.DedupStats
+
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// GetStorageStats returns the statistics of the last storage scan, scanning first when refresh is set. Buckets are
+// broken down by top-level prefix when prefixes is set.
+func (c *Client) GetStorageStats(ctx context.Context, prefixes, refresh bool) (*core.StorageStats, error) {
+	params := url.Values{}
+	params.Set("prefixes", strconv.FormatBool(prefixes))
+	params.Set("refresh", strconv.FormatBool(refresh))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Config.ServerURL+"/stats/storage?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var stats core.StorageStats
+
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// GetTransferStats returns the bytes every user uploaded and downloaded with S3 requests.
+func (c *Client) GetTransferStats(ctx context.Context) ([]core.TransferStats, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Config.ServerURL+"/stats/transfers", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doSignedRequest(ctx, req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var stats []core.TransferStats
+
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+
+	return stats, err
+}
+
 // doSignedRequest signs the provided HTTP request using the client's signer and credentials,
 // performs the request using the client's httpClient and verifies the response status code
 // matches expectedStatus. On success, it returns the *http.Response (caller must close body).
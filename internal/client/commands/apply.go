@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+	"github.com/zhulik/d3/internal/backends/management/yaml"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/core"
+)
+
+const (
+	secretFromEnvPrefix  = "env:"
+	secretFromFilePrefix = "file:"
+)
+
+var (
+	ApplyCommand = &cli.Command{ //nolint:gochecknoglobals
+		Name:  "apply",
+		Usage: "Converge users, policies and bindings to a management config file",
+		Description: "The file has the format of the YAML management backend config. Secret access keys may be " +
+			"given as env:NAME or file:PATH to read them from an environment variable or a file.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "file",
+				Aliases:  []string{"f"},
+				Usage:    "desired management config",
+				Required: true,
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "print the changes without applying them",
+			},
+			&cli.BoolFlag{
+				Name:  "prune",
+				Usage: "delete users, policies and bindings missing from the file",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			cfg, err := readManagementConfig(cmd.String("file"))
+			if err != nil {
+				return err
+			}
+
+			return invokeClient(ctx, func(client *apiclient.Client) error {
+				changes, err := client.Apply(ctx, cfg, cmd.Bool("dry-run"), cmd.Bool("prune"))
+				if err != nil {
+					return err
+				}
+
+				for _, change := range changes {
+					fmt.Println(formatChange(change)) //nolint:forbidigo
+
+					if key := change.GeneratedAccessKey; key != nil {
+						fmt.Printf("  Access Key ID: %s\n", key.AccessKeyID)         //nolint:forbidigo
+						fmt.Printf("  Secret Access Key: %s\n", key.SecretAccessKey) //nolint:forbidigo
+					}
+				}
+
+				switch {
+				case len(changes) == 0:
+					fmt.Println("No changes") //nolint:forbidigo
+				case cmd.Bool("dry-run"):
+					fmt.Printf("%d changes planned, nothing applied\n", len(changes)) //nolint:forbidigo
+				default:
+					fmt.Printf("%d changes applied\n", len(changes)) //nolint:forbidigo
+				}
+
+				return nil
+			})
+		},
+	}
+)
+
+// readManagementConfig loads the desired config and resolves the secret references of its access keys.
+func readManagementConfig(path string) (*yaml.ManagementConfig, error) {
+	cfg, err := yaml.LoadManagementConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, user := range cfg.Users {
+		if user == nil {
+			continue
+		}
+
+		for i, key := range user.AccessKeys {
+			user.AccessKeys[i].SecretAccessKey, err = resolveSecret(key.SecretAccessKey)
+			if err != nil {
+				return nil, fmt.Errorf("user %s, access key %s: %w", name, key.AccessKeyID, err)
+			}
+		}
+	}
+
+	return &cfg, nil
+}
+
+// resolveSecret reads env:NAME secrets from the environment and file:PATH secrets from the file, without the
+// trailing newline. Other values are literal secrets.
+func resolveSecret(value string) (string, error) {
+	if name, ok := strings.CutPrefix(value, secretFromEnvPrefix); ok {
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("%w: environment variable %s is not set", ErrInvalidArgument, name)
+		}
+
+		return secret, nil
+	}
+
+	if path, ok := strings.CutPrefix(value, secretFromFilePrefix); ok {
+		secret, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+
+		return strings.TrimRight(string(secret), "\r\n"), nil
+	}
+
+	return value, nil
+}
+
+func formatChange(change core.ManagementChange) string {
+	sign := map[core.ManagementChangeOperation]string{
+		core.ManagementChangeCreate: "+",
+		core.ManagementChangeUpdate: "~",
+		core.ManagementChangeDelete: "-",
+	}[change.Operation]
+
+	return fmt.Sprintf("%s %s %s", sign, change.Kind, change.Name)
+}
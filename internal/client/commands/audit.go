@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/urfave/cli/v3"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/core"
+)
+
+var (
+	auditTimeConfig = cli.TimestampConfig{Layouts: []string{time.RFC3339}} //nolint:gochecknoglobals
+
+	AuditCommand = &cli.Command{ //nolint:gochecknoglobals
+		Name:  "audit",
+		Usage: "Query the audit log",
+		Flags: []cli.Flag{
+			&cli.TimestampFlag{
+				Name:   "from",
+				Usage:  "only events at or after this RFC 3339 time",
+				Config: auditTimeConfig,
+			},
+			&cli.TimestampFlag{
+				Name:   "to",
+				Usage:  "only events before this RFC 3339 time",
+				Config: auditTimeConfig,
+			},
+			&cli.DurationFlag{
+				Name:  "since",
+				Usage: "only events of the last duration, e.g. 24h, overrides --from",
+			},
+			&cli.StringFlag{
+				Name:  "user",
+				Usage: "only events of this user",
+			},
+			&cli.StringFlag{
+				Name:  "action",
+				Usage: "only events of this action, e.g. s3:DeleteObject or d3:CreateUser",
+			},
+			&cli.IntFlag{
+				Name:  "limit",
+				Usage: "maximum number of events",
+				Value: 100, //nolint:mnd
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			query := core.AuditQuery{
+				From:   cmd.Timestamp("from"),
+				To:     cmd.Timestamp("to"),
+				User:   cmd.String("user"),
+				Action: cmd.String("action"),
+				Limit:  cmd.Int("limit"),
+			}
+
+			if since := cmd.Duration("since"); since > 0 {
+				query.From = time.Now().Add(-since)
+			}
+
+			return invokeClient(ctx, func(client *apiclient.Client) error {
+				events, err := client.QueryAudit(ctx, query)
+				if err != nil {
+					return err
+				}
+
+				for _, event := range events {
+					fmt.Println(formatAuditEvent(event)) //nolint:forbidigo
+				}
+
+				return nil
+			})
+		},
+	}
+)
+
+func formatAuditEvent(event core.AuditEvent) string {
+	line := fmt.Sprintf("%s %s %s %s %s %d %s",
+		event.Time.Format(time.RFC3339), event.RemoteIP, lo.CoalesceOrEmpty(event.User, "-"), event.Action, event.Resource,
+		event.Status, event.Outcome)
+
+	if event.Source != "" {
+		line += " source=" + event.Source
+	}
+
+	if event.Error != "" {
+		line += fmt.Sprintf(" error=%q", event.Error)
+	}
+
+	return line
+}
@@ -0,0 +1,287 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"github.com/urfave/cli/v3"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/json"
+	"github.com/zhulik/pal"
+)
+
+var (
+	BucketCommand = &cli.Command{ //nolint:gochecknoglobals
+		Name:    "bucket",
+		Aliases: []string{"b"},
+		Usage:   "manage d3-specific bucket settings",
+		Commands: []*cli.Command{
+			bucketCompression,
+			bucketQuota,
+			bucketMove,
+			bucketRoots,
+		},
+	}
+
+	bucketCompression = &cli.Command{ //nolint:gochecknoglobals
+		Name:  "compression",
+		Usage: "manage the compression of objects written to a bucket",
+		Commands: []*cli.Command{
+			bucketCompressionShow,
+			bucketCompressionSet,
+			bucketCompressionClear,
+		},
+	}
+
+	bucketCompressionShow = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "show",
+		Aliases:   []string{"s"},
+		Usage:     "Show the compression override of a bucket",
+		Arguments: bucketNameArg,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateBucketNameAndInvokeClient(ctx, cmd, func(bucket string, client *apiclient.Client) error {
+				compression, err := client.GetBucketCompression(ctx, bucket)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(lo.CoalesceOrEmpty(string(compression.Algorithm), "server default")) //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	bucketCompressionSet = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "set",
+		Usage:     "Compress objects later written to a bucket with an algorithm, zstd or none",
+		Arguments: bucketAndAlgorithmArgs,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			algorithm := core.CompressionAlgorithm(cmd.StringArg("algorithm"))
+			if algorithm == "" {
+				return fmt.Errorf("%w: algorithm", ErrMissingArgument)
+			}
+
+			return validateBucketNameAndInvokeClient(ctx, cmd, func(bucket string, client *apiclient.Client) error {
+				err := client.SetBucketCompression(ctx, bucket, core.BucketCompression{Algorithm: algorithm})
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Compression set successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	bucketCompressionClear = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "clear",
+		Usage:     "Remove the compression override of a bucket, the server default applies again",
+		Arguments: bucketNameArg,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateBucketNameAndInvokeClient(ctx, cmd, func(bucket string, client *apiclient.Client) error {
+				err := client.DeleteBucketCompression(ctx, bucket)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Compression override removed successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	bucketQuota = &cli.Command{ //nolint:gochecknoglobals
+		Name:  "quota",
+		Usage: "manage the quota of a bucket",
+		Commands: []*cli.Command{
+			bucketQuotaShow,
+			bucketQuotaSet,
+			bucketQuotaClear,
+			bucketQuotaRescan,
+		},
+	}
+
+	bucketQuotaShow = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "show",
+		Aliases:   []string{"s"},
+		Usage:     "Show the quota and the usage of a bucket",
+		Arguments: bucketNameArg,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateBucketNameAndInvokeClient(ctx, cmd, func(bucket string, client *apiclient.Client) error {
+				quota, err := client.GetBucketQuota(ctx, bucket)
+				if err != nil {
+					return err
+				}
+
+				usage, err := client.GetBucketUsage(ctx, bucket)
+				if err != nil {
+					return err
+				}
+
+				output, err := json.MarshalIndent(map[string]any{"quota": quota, "usage": usage})
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(output)) //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	bucketQuotaSet = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "set",
+		Usage:     "Replace the quota of a bucket, omitted limits are unlimited",
+		Arguments: bucketNameArg,
+		Flags: []cli.Flag{
+			&cli.Int64Flag{Name: "max-bytes", Usage: "total size of the objects in bytes"},
+			&cli.Int64Flag{Name: "max-objects", Usage: "number of objects"},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			quota := core.BucketQuota{MaxBytes: cmd.Int64("max-bytes"), MaxObjects: cmd.Int64("max-objects")}
+
+			return validateBucketNameAndInvokeClient(ctx, cmd, func(bucket string, client *apiclient.Client) error {
+				err := client.SetBucketQuota(ctx, bucket, quota)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Quota set successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	bucketQuotaClear = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "clear",
+		Usage:     "Remove the quota of a bucket",
+		Arguments: bucketNameArg,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateBucketNameAndInvokeClient(ctx, cmd, func(bucket string, client *apiclient.Client) error {
+				err := client.DeleteBucketQuota(ctx, bucket)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Quota removed successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	bucketQuotaRescan = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "rescan",
+		Usage:     "Recompute the usage of a bucket from its objects, fixing drift of the tracked usage",
+		Arguments: bucketNameArg,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateBucketNameAndInvokeClient(ctx, cmd, func(bucket string, client *apiclient.Client) error {
+				usage, err := client.RescanBucketUsage(ctx, bucket)
+				if err != nil {
+					return err
+				}
+
+				output, err := json.MarshalIndent(usage)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(output)) //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	bucketMove = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "move",
+		Usage:     "Move a bucket to another storage root, it stays readable while it is moved",
+		Arguments: bucketAndRootArgs,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			root := cmd.StringArg("root")
+			if root == "" {
+				return fmt.Errorf("%w: root", ErrMissingArgument)
+			}
+
+			return validateBucketNameAndInvokeClient(ctx, cmd, func(bucket string, client *apiclient.Client) error {
+				err := client.MoveBucket(ctx, bucket, root)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Bucket moved successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	bucketRoots = &cli.Command{ //nolint:gochecknoglobals
+		Name:  "roots",
+		Usage: "Show the storage roots, their free space and the buckets on each of them",
+		Action: func(ctx context.Context, _ *cli.Command) error {
+			return invokeClient(ctx, func(client *apiclient.Client) error {
+				roots, err := client.GetStorageRoots(ctx)
+				if err != nil {
+					return err
+				}
+
+				output, err := json.MarshalIndent(roots)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(output)) //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	bucketNameArg = []cli.Argument{ //nolint:gochecknoglobals
+		&cli.StringArg{
+			Name:   "bucket",
+			Config: cli.StringConfig{},
+		},
+	}
+
+	bucketAndRootArgs = []cli.Argument{ //nolint:gochecknoglobals
+		&cli.StringArg{
+			Name:   "bucket",
+			Config: cli.StringConfig{},
+		},
+		&cli.StringArg{
+			Name:   "root",
+			Config: cli.StringConfig{},
+		},
+	}
+
+	bucketAndAlgorithmArgs = []cli.Argument{ //nolint:gochecknoglobals
+		&cli.StringArg{
+			Name:   "bucket",
+			Config: cli.StringConfig{},
+		},
+		&cli.StringArg{
+			Name:   "algorithm",
+			Config: cli.StringConfig{},
+		},
+	}
+)
+
+func validateBucketNameAndInvokeClient(ctx context.Context, cmd *cli.Command, f clientFn) error {
+	bucket := cmd.StringArg("bucket")
+	if bucket == "" {
+		return fmt.Errorf("%w: bucket", ErrMissingArgument)
+	}
+
+	client := pal.MustInvoke[*apiclient.Client](ctx, nil)
+
+	return f(bucket, client)
+}
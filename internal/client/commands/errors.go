@@ -9,4 +9,5 @@ var (
 	ErrCLIError = errors.New("CLI error")
 
 	ErrMissingArgument = fmt.Errorf("%w: missing argument", ErrCLIError)
+	ErrInvalidArgument = fmt.Errorf("%w: invalid argument", ErrCLIError)
 )
@@ -0,0 +1,253 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/pal"
+)
+
+var (
+	GroupCommand = &cli.Command{ //nolint:gochecknoglobals
+		Name:    "group",
+		Aliases: []string{"g"},
+		Usage:   "manage user groups",
+		Commands: []*cli.Command{
+			groupList,
+			groupShow,
+			groupAdd,
+			groupDelete,
+			groupAddMember,
+			groupRemoveMember,
+			groupBind,
+			groupUnbind,
+		},
+	}
+
+	groupList = &cli.Command{ //nolint:gochecknoglobals
+		Name:    "list",
+		Aliases: []string{"ls", "l"},
+		Usage:   "List groups",
+		Action: func(ctx context.Context, _ *cli.Command) error {
+			return invokeClient(ctx, func(client *apiclient.Client) error {
+				groups, err := client.ListGroups(ctx)
+				if err != nil {
+					return err
+				}
+
+				for _, group := range groups {
+					fmt.Println(group) //nolint:forbidigo
+				}
+
+				return nil
+			})
+		},
+	}
+
+	groupShow = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "show",
+		Aliases:   []string{"s"},
+		Usage:     "Show group members and policies",
+		Arguments: groupNameArg,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateGroupNameAndInvokeClient(ctx, cmd, func(groupName string, client *apiclient.Client) error {
+				group, err := client.GetGroup(ctx, groupName)
+				if err != nil {
+					return err
+				}
+
+				bindings, err := client.GetBindingsByGroup(ctx, groupName)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Members:") //nolint:forbidigo
+
+				for _, member := range group.Members {
+					fmt.Printf("\t%s\n", member) //nolint:forbidigo
+				}
+
+				fmt.Println("Policies:") //nolint:forbidigo
+
+				for _, binding := range bindings {
+					fmt.Printf("\t%s\n", binding.PolicyID) //nolint:forbidigo
+				}
+
+				return nil
+			})
+		},
+	}
+
+	groupAdd = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "add",
+		Aliases:   []string{"a"},
+		Usage:     "Create a group",
+		Arguments: groupNameArg,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateGroupNameAndInvokeClient(ctx, cmd, func(groupName string, client *apiclient.Client) error {
+				err := client.CreateGroup(ctx, groupName)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Group created successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	groupDelete = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "delete",
+		Aliases:   []string{"d"},
+		Usage:     "Delete a group and its policy bindings",
+		Arguments: groupNameArg,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateGroupNameAndInvokeClient(ctx, cmd, func(groupName string, client *apiclient.Client) error {
+				err := client.DeleteGroup(ctx, groupName)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Group deleted successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	groupAddMember = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "add-member",
+		Usage:     "Add a user to a group",
+		Arguments: groupAndUserArgs,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateGroupAndArgAndInvoke(ctx, cmd, "username",
+				func(groupName, userName string, client *apiclient.Client) error {
+					err := client.AddGroupMember(ctx, groupName, userName)
+					if err != nil {
+						return err
+					}
+
+					fmt.Println("Member added successfully") //nolint:forbidigo
+
+					return nil
+				})
+		},
+	}
+
+	groupRemoveMember = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "remove-member",
+		Usage:     "Remove a user from a group",
+		Arguments: groupAndUserArgs,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateGroupAndArgAndInvoke(ctx, cmd, "username",
+				func(groupName, userName string, client *apiclient.Client) error {
+					err := client.RemoveGroupMember(ctx, groupName, userName)
+					if err != nil {
+						return err
+					}
+
+					fmt.Println("Member removed successfully") //nolint:forbidigo
+
+					return nil
+				})
+		},
+	}
+
+	groupBind = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "bind",
+		Usage:     "Bind a policy to a group",
+		Arguments: groupAndPolicyArgs,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateGroupAndArgAndInvoke(ctx, cmd, "policy-id",
+				func(groupName, policyID string, client *apiclient.Client) error {
+					err := client.CreateGroupBinding(ctx, groupName, policyID)
+					if err != nil {
+						return err
+					}
+
+					fmt.Println("Binding created successfully") //nolint:forbidigo
+
+					return nil
+				})
+		},
+	}
+
+	groupUnbind = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "unbind",
+		Usage:     "Remove a policy binding from a group",
+		Arguments: groupAndPolicyArgs,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateGroupAndArgAndInvoke(ctx, cmd, "policy-id",
+				func(groupName, policyID string, client *apiclient.Client) error {
+					err := client.DeleteGroupBinding(ctx, groupName, policyID)
+					if err != nil {
+						return err
+					}
+
+					fmt.Println("Binding deleted successfully") //nolint:forbidigo
+
+					return nil
+				})
+		},
+	}
+
+	groupNameArg = []cli.Argument{ //nolint:gochecknoglobals
+		&cli.StringArg{
+			Name:   "group",
+			Config: cli.StringConfig{},
+		},
+	}
+
+	groupAndUserArgs = []cli.Argument{ //nolint:gochecknoglobals
+		&cli.StringArg{
+			Name:   "group",
+			Config: cli.StringConfig{},
+		},
+		&cli.StringArg{
+			Name:   "username",
+			Config: cli.StringConfig{},
+		},
+	}
+
+	groupAndPolicyArgs = []cli.Argument{ //nolint:gochecknoglobals
+		&cli.StringArg{
+			Name:   "group",
+			Config: cli.StringConfig{},
+		},
+		&cli.StringArg{
+			Name:   "policy-id",
+			Config: cli.StringConfig{},
+		},
+	}
+)
+
+func validateGroupNameAndInvokeClient(ctx context.Context, cmd *cli.Command, f clientFn) error {
+	groupName := cmd.StringArg("group")
+	if groupName == "" {
+		return fmt.Errorf("%w: group", ErrMissingArgument)
+	}
+
+	client := pal.MustInvoke[*apiclient.Client](ctx, nil)
+
+	return f(groupName, client)
+}
+
+// validateGroupAndArgAndInvoke checks the group and the named second argument before calling f with both.
+func validateGroupAndArgAndInvoke(ctx context.Context, cmd *cli.Command, argName string, f bindingAddDeleteFn) error {
+	groupName := cmd.StringArg("group")
+	if groupName == "" {
+		return fmt.Errorf("%w: group", ErrMissingArgument)
+	}
+
+	arg := cmd.StringArg(argName)
+	if arg == "" {
+		return fmt.Errorf("%w: %s", ErrMissingArgument, argName)
+	}
+
+	client := pal.MustInvoke[*apiclient.Client](ctx, nil)
+
+	return f(groupName, arg, client)
+}
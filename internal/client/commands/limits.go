@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"github.com/urfave/cli/v3"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/json"
+)
+
+var (
+	limitsTargetFlags = []cli.Flag{ //nolint:gochecknoglobals
+		&cli.StringFlag{
+			Name:  "user",
+			Usage: "limits of this user",
+		},
+		&cli.StringFlag{
+			Name:  "group",
+			Usage: "limits of the members of this group",
+		},
+		&cli.StringFlag{
+			Name:  "role",
+			Usage: "limits of the web identity sessions of this role",
+		},
+	}
+
+	LimitsCommand = &cli.Command{ //nolint:gochecknoglobals
+		Name:  "limits",
+		Usage: "manage rate limits of users, groups and roles",
+		Commands: []*cli.Command{
+			limitsShow,
+			limitsSet,
+			limitsClear,
+			limitsStats,
+		},
+	}
+
+	limitsShow = &cli.Command{ //nolint:gochecknoglobals
+		Name:    "show",
+		Aliases: []string{"s"},
+		Usage:   "Show the rate limits of a user, a group or a role",
+		Flags:   limitsTargetFlags,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateLimitsTargetAndInvokeClient(ctx, cmd, func(kind, name string, client *apiclient.Client) error {
+				limits, err := client.GetLimits(ctx, kind, name)
+				if err != nil {
+					return err
+				}
+
+				output, err := json.MarshalIndent(limits)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(output)) //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	limitsSet = &cli.Command{ //nolint:gochecknoglobals
+		Name:  "set",
+		Usage: "Replace the rate limits of a user, a group or a role, omitted limits are unlimited",
+		Flags: append([]cli.Flag{
+			&cli.FloatFlag{Name: "read-rps", Usage: "object GET and HEAD requests per second"},
+			&cli.FloatFlag{Name: "list-rps", Usage: "service and bucket GET and HEAD requests per second"},
+			&cli.FloatFlag{Name: "write-rps", Usage: "PUT and POST requests per second"},
+			&cli.FloatFlag{Name: "delete-rps", Usage: "DELETE requests per second"},
+			&cli.IntFlag{Name: "max-concurrent", Usage: "concurrent requests"},
+			&cli.Int64Flag{Name: "upload-bps", Usage: "upload bytes per second"},
+			&cli.Int64Flag{Name: "download-bps", Usage: "download bytes per second"},
+		}, limitsTargetFlags...),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			limits := core.RateLimits{
+				RequestsPerSecond:      map[core.RequestClass]float64{},
+				MaxConcurrentRequests:  cmd.Int("max-concurrent"),
+				UploadBytesPerSecond:   cmd.Int64("upload-bps"),
+				DownloadBytesPerSecond: cmd.Int64("download-bps"),
+			}
+
+			for _, class := range core.RequestClasses {
+				if rps := cmd.Float(string(class) + "-rps"); rps > 0 {
+					limits.RequestsPerSecond[class] = rps
+				}
+			}
+
+			return validateLimitsTargetAndInvokeClient(ctx, cmd, func(kind, name string, client *apiclient.Client) error {
+				err := client.SetLimits(ctx, kind, name, limits)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Limits set successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	limitsClear = &cli.Command{ //nolint:gochecknoglobals
+		Name:  "clear",
+		Usage: "Remove the rate limits of a user, a group or a role",
+		Flags: limitsTargetFlags,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateLimitsTargetAndInvokeClient(ctx, cmd, func(kind, name string, client *apiclient.Client) error {
+				err := client.DeleteLimits(ctx, kind, name)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Limits removed successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	limitsStats = &cli.Command{ //nolint:gochecknoglobals
+		Name:  "stats",
+		Usage: "Show request and throttling counters of recently seen users and anonymous clients",
+		Action: func(ctx context.Context, _ *cli.Command) error {
+			return invokeClient(ctx, func(client *apiclient.Client) error {
+				stats, err := client.GetLimitStats(ctx)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("PRINCIPAL\tIN FLIGHT\tADMITTED\tRATE LIMITED\tCONCURRENCY LIMITED") //nolint:forbidigo
+
+				for _, s := range stats {
+					fmt.Printf("%s\t%d\t%d\t%d\t%d\n", //nolint:forbidigo
+						s.Principal, s.InFlight, s.Admitted, s.RateLimited, s.ConcurrencyLimited)
+				}
+
+				return nil
+			})
+		},
+	}
+)
+
+type limitsTargetFn func(kind, name string, client *apiclient.Client) error
+
+// validateLimitsTargetAndInvokeClient requires exactly one of --user, --group and --role.
+func validateLimitsTargetAndInvokeClient(ctx context.Context, cmd *cli.Command, f limitsTargetFn) error {
+	user, group, role := cmd.String("user"), cmd.String("group"), cmd.String("role")
+
+	if len(lo.Compact([]string{user, group, role})) > 1 {
+		return fmt.Errorf("%w: --user, --group and --role are mutually exclusive", ErrInvalidArgument)
+	}
+
+	switch {
+	case user != "":
+		return invokeClient(ctx, func(client *apiclient.Client) error { return f("users", user, client) })
+	case group != "":
+		return invokeClient(ctx, func(client *apiclient.Client) error { return f("groups", group, client) })
+	case role != "":
+		return invokeClient(ctx, func(client *apiclient.Client) error { return f("roles", role, client) })
+	default:
+		return fmt.Errorf("%w: --user, --group or --role", ErrMissingArgument)
+	}
+}
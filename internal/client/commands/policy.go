@@ -0,0 +1,272 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/urfave/cli/v3"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/json"
+	"github.com/zhulik/d3/pkg/s3actions"
+)
+
+var (
+	PolicyCommand = &cli.Command{ //nolint:gochecknoglobals
+		Name:    "policy",
+		Aliases: []string{"p"},
+		Usage:   "manage policies",
+		Commands: []*cli.Command{
+			policyList,
+			policyGet,
+			policyCreate,
+			policyUpdate,
+			policyDelete,
+			policyValidate,
+			policySimulate,
+		},
+	}
+
+	policyList = &cli.Command{ //nolint:gochecknoglobals
+		Name:    "list",
+		Aliases: []string{"ls", "l"},
+		Usage:   "List policies",
+		Action: func(ctx context.Context, _ *cli.Command) error {
+			return invokeClient(ctx, func(client *apiclient.Client) error {
+				policies, err := client.ListPolicies(ctx)
+				if err != nil {
+					return err
+				}
+
+				for _, policyID := range policies {
+					fmt.Println(policyID) //nolint:forbidigo
+				}
+
+				return nil
+			})
+		},
+	}
+
+	policyGet = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "get",
+		Aliases:   []string{"show", "s"},
+		Usage:     "Print the policy document",
+		Arguments: policyIDArg,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validatePolicyIDAndInvokeClient(ctx, cmd, func(policyID string, client *apiclient.Client) error {
+				policy, err := client.GetPolicy(ctx, policyID)
+				if err != nil {
+					return err
+				}
+
+				document, err := json.MarshalIndent(policy)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(document)) //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	policyCreate = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "create",
+		Aliases:   []string{"add", "a"},
+		Usage:     "Create a policy from a file, or from stdin if the file is omitted or \"-\"",
+		Arguments: policyFileArg,
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "attach-to",
+				Usage: "user to bind the created policy to, can be repeated",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			policy, err := readPolicy(cmd)
+			if err != nil {
+				return err
+			}
+
+			return invokeClient(ctx, func(client *apiclient.Client) error {
+				err := client.CreatePolicy(ctx, policy)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Policy created successfully") //nolint:forbidigo
+
+				for _, userName := range cmd.StringSlice("attach-to") {
+					err := client.CreateBinding(ctx, &core.PolicyBinding{UserName: userName, PolicyID: policy.ID})
+					if err != nil {
+						return err
+					}
+
+					fmt.Printf("Policy attached to %s\n", userName) //nolint:forbidigo
+				}
+
+				return nil
+			})
+		},
+	}
+
+	policyUpdate = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "update",
+		Aliases:   []string{"u"},
+		Usage:     "Replace the policy with the Id of the document read from a file, or from stdin",
+		Arguments: policyFileArg,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			policy, err := readPolicy(cmd)
+			if err != nil {
+				return err
+			}
+
+			return invokeClient(ctx, func(client *apiclient.Client) error {
+				err := client.UpdatePolicy(ctx, policy.ID, policy)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Policy updated successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	policyDelete = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "delete",
+		Aliases:   []string{"d"},
+		Usage:     "Delete a policy",
+		Arguments: policyIDArg,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validatePolicyIDAndInvokeClient(ctx, cmd, func(policyID string, client *apiclient.Client) error {
+				err := client.DeletePolicy(ctx, policyID)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Policy deleted successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	policyValidate = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "validate",
+		Aliases:   []string{"v"},
+		Usage:     "Check a policy document without contacting the server",
+		Arguments: policyFileArg,
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			policy, err := readPolicy(cmd)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Policy %s is valid\n", policy.ID) //nolint:forbidigo
+
+			return nil
+		},
+	}
+
+	policySimulate = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "simulate",
+		Aliases:   []string{"sim"},
+		Usage:     "Show whether the user is allowed to perform the actions on the resources, and why",
+		Arguments: usernameArg,
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "action",
+				Usage: "action to simulate, e.g. s3:GetObject, can be repeated",
+			},
+			&cli.StringSliceFlag{
+				Name:  "resource",
+				Usage: "resource to simulate, e.g. arn:aws:s3:::bucket/key, can be repeated",
+			},
+			&cli.BoolFlag{
+				Name:  "secure-transport",
+				Usage: "simulate requests received over TLS, for aws:SecureTransport conditions",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateUsernameAndInvokeClient(ctx, cmd, func(username string, client *apiclient.Client) error {
+				actions := cmd.StringSlice("action")
+				if len(actions) == 0 {
+					return fmt.Errorf("%w: action", ErrMissingArgument)
+				}
+
+				resources := cmd.StringSlice("resource")
+				if len(resources) == 0 {
+					return fmt.Errorf("%w: resource", ErrMissingArgument)
+				}
+
+				results, err := client.SimulatePolicy(ctx, &core.PolicySimulation{
+					UserName:        username,
+					Actions:         lo.Map(actions, func(action string, _ int) s3actions.Action { return s3actions.Action(action) }),
+					Resources:       resources,
+					SecureTransport: cmd.Bool("secure-transport"),
+				})
+				if err != nil {
+					return err
+				}
+
+				for _, result := range results {
+					fmt.Println(formatSimulationResult(result)) //nolint:forbidigo
+				}
+
+				return nil
+			})
+		},
+	}
+
+	policyFileArg = []cli.Argument{ //nolint:gochecknoglobals
+		&cli.StringArg{
+			Name:   "file",
+			Config: cli.StringConfig{},
+		},
+	}
+)
+
+// readPolicy reads and parses the policy document from the file argument, or from stdin when it is omitted or "-".
+func readPolicy(cmd *cli.Command) (*iampol.IAMPolicy, error) {
+	var (
+		document []byte
+		err      error
+	)
+
+	switch path := cmd.StringArg("file"); path {
+	case "", "-":
+		document, err = io.ReadAll(os.Stdin)
+	default:
+		document, err = os.ReadFile(path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return iampol.Parse(document)
+}
+
+func formatSimulationResult(result core.PolicySimulationResult) string {
+	fields := []string{
+		lo.Ternary(result.Allowed, "ALLOW", "DENY"), string(result.Action), result.Resource, string(result.Reason),
+	}
+
+	if result.Statement != nil {
+		fields = append(fields,
+			lo.Ternary(result.PolicyID == "", "<session policy>", result.PolicyID),
+			fmt.Sprintf("%s %s on %s", result.Statement.Effect,
+				strings.Join(lo.Map(result.Statement.Action, func(a s3actions.Action, _ int) string { return string(a) }), ","),
+				strings.Join(result.Statement.Resource, ",")),
+		)
+	}
+
+	return strings.Join(fields, "\t")
+}
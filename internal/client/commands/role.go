@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/pal"
+)
+
+var (
+	RoleCommand = &cli.Command{ //nolint:gochecknoglobals
+		Name:    "role",
+		Aliases: []string{"r"},
+		Usage:   "manage roles assumable with STS AssumeRole",
+		Commands: []*cli.Command{
+			roleList,
+			roleShow,
+			roleAdd,
+			roleDelete,
+		},
+	}
+
+	roleList = &cli.Command{ //nolint:gochecknoglobals
+		Name:    "list",
+		Aliases: []string{"ls", "l"},
+		Usage:   "List roles",
+		Action: func(ctx context.Context, _ *cli.Command) error {
+			return invokeClient(ctx, func(client *apiclient.Client) error {
+				roles, err := client.ListRoles(ctx)
+				if err != nil {
+					return err
+				}
+
+				for _, role := range roles {
+					fmt.Println(role) //nolint:forbidigo
+				}
+
+				return nil
+			})
+		},
+	}
+
+	roleShow = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "show",
+		Aliases:   []string{"s"},
+		Usage:     "Show role policies and trusted users",
+		Arguments: roleNameArg,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateRoleNameAndInvokeClient(ctx, cmd, func(roleName string, client *apiclient.Client) error {
+				role, err := client.GetRole(ctx, roleName)
+				if err != nil {
+					return err
+				}
+
+				fmt.Printf("ARN: %s\n", role.ARN()) //nolint:forbidigo
+				fmt.Println("Policies:")            //nolint:forbidigo
+
+				for _, policyID := range role.PolicyIDs {
+					fmt.Printf("\t%s\n", policyID) //nolint:forbidigo
+				}
+
+				fmt.Println("Trusted users:") //nolint:forbidigo
+
+				for _, userName := range role.TrustedUsers {
+					fmt.Printf("\t%s\n", userName) //nolint:forbidigo
+				}
+
+				fmt.Println("Web identity conditions:") //nolint:forbidigo
+
+				for _, condition := range role.WebIdentityConditions {
+					fmt.Printf("\t%s\n", formatWebIdentityCondition(condition)) //nolint:forbidigo
+				}
+
+				return nil
+			})
+		},
+	}
+
+	roleAdd = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "add",
+		Aliases:   []string{"a"},
+		Usage:     "Create a role",
+		Arguments: roleNameArg,
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "policy",
+				Usage: "policy granted to the role, can be repeated",
+			},
+			&cli.StringSliceFlag{
+				Name:  "trusted-user",
+				Usage: "user allowed to assume the role, can be repeated",
+			},
+			&cli.StringSliceFlag{
+				Name: "web-identity-condition",
+				Usage: "claim patterns a web identity token must match to assume the role, " +
+					"e.g. 'sub=repo:org/*,ref=refs/heads/main', can be repeated",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateRoleNameAndInvokeClient(ctx, cmd, func(roleName string, client *apiclient.Client) error {
+				conditions, err := parseWebIdentityConditions(cmd.StringSlice("web-identity-condition"))
+				if err != nil {
+					return err
+				}
+
+				err = client.CreateRole(ctx, &core.Role{
+					Name:                  roleName,
+					PolicyIDs:             cmd.StringSlice("policy"),
+					TrustedUsers:          cmd.StringSlice("trusted-user"),
+					WebIdentityConditions: conditions,
+				})
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Role created successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	roleDelete = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "delete",
+		Aliases:   []string{"d"},
+		Usage:     "Delete a role, revoking its sessions",
+		Arguments: roleNameArg,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateRoleNameAndInvokeClient(ctx, cmd, func(roleName string, client *apiclient.Client) error {
+				err := client.DeleteRole(ctx, roleName)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Role deleted successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	roleNameArg = []cli.Argument{ //nolint:gochecknoglobals
+		&cli.StringArg{
+			Name:   "role",
+			Config: cli.StringConfig{},
+		},
+	}
+)
+
+func validateRoleNameAndInvokeClient(ctx context.Context, cmd *cli.Command, f clientFn) error {
+	roleName := cmd.StringArg("role")
+	if roleName == "" {
+		return fmt.Errorf("%w: role", ErrMissingArgument)
+	}
+
+	client := pal.MustInvoke[*apiclient.Client](ctx, nil)
+
+	return f(roleName, client)
+}
+
+// parseWebIdentityConditions parses conditions written as comma-separated claim=pattern pairs.
+func parseWebIdentityConditions(values []string) ([]map[string]string, error) {
+	conditions := make([]map[string]string, 0, len(values))
+
+	for _, value := range values {
+		condition := map[string]string{}
+
+		for pair := range strings.SplitSeq(value, ",") {
+			claim, pattern, ok := strings.Cut(pair, "=")
+			if !ok || claim == "" {
+				return nil, fmt.Errorf("%w: invalid web identity condition %q", ErrInvalidArgument, value)
+			}
+
+			condition[claim] = pattern
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	return conditions, nil
+}
+
+func formatWebIdentityCondition(condition map[string]string) string {
+	pairs := make([]string, 0, len(condition))
+
+	for _, claim := range slices.Sorted(maps.Keys(condition)) {
+		pairs = append(pairs, claim+"="+condition[claim])
+	}
+
+	return strings.Join(pairs, ",")
+}
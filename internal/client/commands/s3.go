@@ -0,0 +1,364 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+	"github.com/zhulik/d3/internal/client/s3client"
+	"github.com/zhulik/pal"
+)
+
+var (
+	S3Command = &cli.Command{ //nolint:gochecknoglobals
+		Name:  "s3",
+		Usage: "work with buckets and objects, locations are local paths or s3://bucket/key URLs",
+		Commands: []*cli.Command{
+			s3List,
+			s3Copy,
+			s3Move,
+			s3Remove,
+			s3Sync,
+			s3Presign,
+		},
+	}
+
+	s3List = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "ls",
+		Usage:     "List buckets, or objects under an s3://bucket/prefix URL",
+		Arguments: s3URLArg,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "recursive", Aliases: []string{"r"}, Usage: "list keys under all prefixes"},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			client := pal.MustInvoke[*s3client.Client](ctx, nil)
+
+			if cmd.StringArg("url") == "" {
+				return listBuckets(ctx, client)
+			}
+
+			bucket, prefix, err := s3client.ParseURL(cmd.StringArg("url"))
+			if err != nil {
+				return err
+			}
+
+			objects, err := client.ListObjects(ctx, bucket, prefix, cmd.Bool("recursive"))
+			if err != nil {
+				return err
+			}
+
+			for _, object := range objects {
+				if object.IsPrefix {
+					fmt.Printf("%19s %12s %s\n", "", "PRE", object.Key) //nolint:forbidigo
+
+					continue
+				}
+
+				modified := object.LastModified.Local().Format(time.DateTime)
+				fmt.Printf("%s %12d %s\n", modified, object.Size, object.Key) //nolint:forbidigo
+			}
+
+			return nil
+		},
+	}
+
+	s3Copy = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "cp",
+		Usage:     "Upload, download or copy an object",
+		Arguments: sourceAndDestinationArgs,
+		Flags:     transferFlags,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateLocationsAndTransfer(ctx, cmd, false)
+		},
+	}
+
+	s3Move = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "mv",
+		Usage:     "Upload, download or copy an object, then delete the source",
+		Arguments: sourceAndDestinationArgs,
+		Flags:     transferFlags,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateLocationsAndTransfer(ctx, cmd, true)
+		},
+	}
+
+	s3Remove = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "rm",
+		Usage:     "Delete an object, or every object under a prefix",
+		Arguments: s3URLArg,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "recursive", Aliases: []string{"r"}, Usage: "delete every object under the prefix"},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			bucket, key, err := s3client.ParseURL(cmd.StringArg("url"))
+			if err != nil {
+				return err
+			}
+
+			client := pal.MustInvoke[*s3client.Client](ctx, nil)
+
+			if !cmd.Bool("recursive") {
+				err = client.Remove(ctx, bucket, key)
+				if err != nil {
+					return err
+				}
+
+				fmt.Printf("delete: s3://%s/%s\n", bucket, key) //nolint:forbidigo
+
+				return nil
+			}
+
+			keys, err := client.RemovePrefix(ctx, bucket, key)
+			for _, removed := range keys {
+				fmt.Printf("delete: s3://%s/%s\n", bucket, removed) //nolint:forbidigo
+			}
+
+			return err
+		},
+	}
+
+	s3Sync = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "sync",
+		Usage:     "Make a local directory and an s3://bucket/prefix URL hold the same files, in either direction",
+		Arguments: sourceAndDestinationArgs,
+		Flags: append([]cli.Flag{
+			&cli.BoolFlag{Name: "delete", Usage: "delete files or objects that are missing from the source"},
+		}, transferFlags...),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			src, dst, err := sourceAndDestination(cmd)
+			if err != nil {
+				return err
+			}
+
+			client := pal.MustInvoke[*s3client.Client](ctx, nil)
+			opts := s3client.SyncOptions{TransferOptions: transferOptions(cmd), Delete: cmd.Bool("delete")}
+
+			changes, err := syncLocations(ctx, client, src, dst, opts)
+
+			for _, change := range changes {
+				fmt.Printf("%s: %s\n", change.Operation, change.Name) //nolint:forbidigo
+			}
+
+			return err
+		},
+	}
+
+	s3Presign = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "presign",
+		Usage:     "Print a URL granting access to an object without credentials",
+		Arguments: s3URLArg,
+		Flags: []cli.Flag{
+			&cli.DurationFlag{Name: "expires-in", Value: time.Hour, Usage: "how long the URL is valid"},
+			&cli.StringFlag{Name: "method", Value: http.MethodGet, Usage: "GET to download or PUT to upload"},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			bucket, key, err := s3client.ParseURL(cmd.StringArg("url"))
+			if err != nil {
+				return err
+			}
+
+			client := pal.MustInvoke[*s3client.Client](ctx, nil)
+
+			presigned, err := client.Presign(ctx, strings.ToUpper(cmd.String("method")), bucket, key,
+				cmd.Duration("expires-in"))
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(presigned) //nolint:forbidigo
+
+			return nil
+		},
+	}
+
+	s3URLArg = []cli.Argument{ //nolint:gochecknoglobals
+		&cli.StringArg{
+			Name:   "url",
+			Config: cli.StringConfig{},
+		},
+	}
+
+	sourceAndDestinationArgs = []cli.Argument{ //nolint:gochecknoglobals
+		&cli.StringArg{
+			Name:   "source",
+			Config: cli.StringConfig{},
+		},
+		&cli.StringArg{
+			Name:   "destination",
+			Config: cli.StringConfig{},
+		},
+	}
+
+	transferFlags = []cli.Flag{ //nolint:gochecknoglobals
+		&cli.Uint64Flag{
+			Name:  "part-size",
+			Value: s3client.DefaultPartSize,
+			Usage: "size in bytes of multipart upload parts and ranged download chunks",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Value: s3client.DefaultConcurrency,
+			Usage: "number of parts transferred in parallel",
+		},
+	}
+)
+
+func listBuckets(ctx context.Context, client *s3client.Client) error {
+	buckets, err := client.ListBuckets(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, bucket := range buckets {
+		fmt.Printf("%s %s\n", bucket.CreatedAt.Local().Format(time.DateTime), bucket.Name) //nolint:forbidigo
+	}
+
+	return nil
+}
+
+func sourceAndDestination(cmd *cli.Command) (string, string, error) {
+	src := cmd.StringArg("source")
+	if src == "" {
+		return "", "", fmt.Errorf("%w: source", ErrMissingArgument)
+	}
+
+	dst := cmd.StringArg("destination")
+	if dst == "" {
+		return "", "", fmt.Errorf("%w: destination", ErrMissingArgument)
+	}
+
+	return src, dst, nil
+}
+
+// syncLocations syncs up or down depending on which of the locations is an s3:// URL.
+func syncLocations(ctx context.Context, client *s3client.Client, src, dst string,
+	opts s3client.SyncOptions) ([]s3client.SyncChange, error) {
+	if s3client.IsS3URL(src) == s3client.IsS3URL(dst) {
+		return nil, fmt.Errorf("%w: sync needs one local directory and one s3:// URL", ErrInvalidArgument)
+	}
+
+	if s3client.IsS3URL(dst) {
+		bucket, prefix, err := s3client.ParseURL(dst)
+		if err != nil {
+			return nil, err
+		}
+
+		return client.SyncUp(ctx, src, bucket, prefix, opts)
+	}
+
+	bucket, prefix, err := s3client.ParseURL(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.SyncDown(ctx, bucket, prefix, dst, opts)
+}
+
+func transferOptions(cmd *cli.Command) s3client.TransferOptions {
+	return s3client.TransferOptions{
+		PartSize:    cmd.Uint64("part-size"),
+		Concurrency: cmd.Int("concurrency"),
+	}
+}
+
+// validateLocationsAndTransfer uploads, downloads or copies depending on which locations are s3:// URLs. A
+// destination that is a directory, or a key ending with "/", receives the source's base name.
+func validateLocationsAndTransfer(ctx context.Context, cmd *cli.Command, move bool) error {
+	src, dst, err := sourceAndDestination(cmd)
+	if err != nil {
+		return err
+	}
+
+	client := pal.MustInvoke[*s3client.Client](ctx, nil)
+	opts := transferOptions(cmd)
+
+	switch {
+	case !s3client.IsS3URL(src) && !s3client.IsS3URL(dst):
+		return fmt.Errorf("%w: source or destination must be an s3:// URL", ErrInvalidArgument)
+	case !s3client.IsS3URL(src):
+		bucket, key, err := s3client.ParseURL(dst)
+		if err != nil {
+			return err
+		}
+
+		key = objectKeyFor(key, filepath.Base(src))
+
+		err = client.Upload(ctx, src, bucket, key, opts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("upload: %s to s3://%s/%s\n", src, bucket, key) //nolint:forbidigo
+
+		if move {
+			return os.Remove(src)
+		}
+
+		return nil
+	default:
+		srcBucket, srcKey, err := s3client.ParseURL(src)
+		if err != nil {
+			return err
+		}
+
+		if s3client.IsS3URL(dst) {
+			err = copyObject(ctx, client, srcBucket, srcKey, dst)
+		} else {
+			err = downloadObject(ctx, client, srcBucket, srcKey, dst, opts)
+		}
+
+		if err != nil || !move {
+			return err
+		}
+
+		return client.Remove(ctx, srcBucket, srcKey)
+	}
+}
+
+func copyObject(ctx context.Context, client *s3client.Client, srcBucket, srcKey, dst string) error {
+	dstBucket, dstKey, err := s3client.ParseURL(dst)
+	if err != nil {
+		return err
+	}
+
+	dstKey = objectKeyFor(dstKey, path.Base(srcKey))
+
+	err = client.Copy(ctx, srcBucket, srcKey, dstBucket, dstKey)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("copy: s3://%s/%s to s3://%s/%s\n", srcBucket, srcKey, dstBucket, dstKey) //nolint:forbidigo
+
+	return nil
+}
+
+func downloadObject(ctx context.Context, client *s3client.Client, bucket, key, dst string,
+	opts s3client.TransferOptions) error {
+	if info, err := os.Stat(dst); (err == nil && info.IsDir()) || strings.HasSuffix(dst, string(filepath.Separator)) {
+		dst = filepath.Join(dst, path.Base(key))
+	}
+
+	err := client.Download(ctx, bucket, key, dst, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("download: s3://%s/%s to %s\n", bucket, key, dst) //nolint:forbidigo
+
+	return nil
+}
+
+func objectKeyFor(key, baseName string) string {
+	if key == "" || strings.HasSuffix(key, "/") {
+		return key + baseName
+	}
+
+	return key
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/pkg/json"
+)
+
+var (
+	StatsCommand = &cli.Command{ //nolint:gochecknoglobals
+		Name:  "stats",
+		Usage: "show storage statistics",
+		Commands: []*cli.Command{
+			statsDedup,
+			statsStorage,
+			statsTransfers,
+		},
+	}
+
+	statsDedup = &cli.Command{ //nolint:gochecknoglobals
+		Name:  "dedup",
+		Usage: "Show how much storage the deduplication of blobs saves",
+		Action: func(ctx context.Context, _ *cli.Command) error {
+			return invokeClient(ctx, func(client *apiclient.Client) error {
+				stats, err := client.GetDedupStats(ctx)
+				if err != nil {
+					return err
+				}
+
+				output, err := json.MarshalIndent(stats)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(output)) //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	statsStorage = &cli.Command{ //nolint:gochecknoglobals
+		Name:  "storage",
+		Usage: "Show the objects, bytes and incomplete multipart uploads of every bucket",
+		Description: "Statistics come from the last scan of the storage, which runs every STATS_SCAN_INTERVAL. " +
+			"Use --refresh to scan now.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "prefixes",
+				Usage: "break buckets down by top-level prefix",
+			},
+			&cli.BoolFlag{
+				Name:  "refresh",
+				Usage: "scan the storage instead of showing the last scan",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return invokeClient(ctx, func(client *apiclient.Client) error {
+				stats, err := client.GetStorageStats(ctx, cmd.Bool("prefixes"), cmd.Bool("refresh"))
+				if err != nil {
+					return err
+				}
+
+				output, err := json.MarshalIndent(stats)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(output)) //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	statsTransfers = &cli.Command{ //nolint:gochecknoglobals
+		Name:  "transfers",
+		Usage: "Show the bytes every user uploaded and downloaded",
+		Action: func(ctx context.Context, _ *cli.Command) error {
+			return invokeClient(ctx, func(client *apiclient.Client) error {
+				stats, err := client.GetTransferStats(ctx)
+				if err != nil {
+					return err
+				}
+
+				output, err := json.MarshalIndent(stats)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(output)) //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+)
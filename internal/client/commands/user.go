@@ -16,8 +16,8 @@ var (
 		Usage:   "manage users",
 		Commands: []*cli.Command{
 			userAdd,
-			userUpdate,
 			userDelete,
+			userKey,
 		},
 	}
 
@@ -33,30 +33,8 @@ var (
 					return err
 				}
 
-				fmt.Println("User created successfully")                 //nolint:forbidigo
-				fmt.Printf("Access key: \"%s\"\n", user.AccessKeyID)     //nolint:forbidigo
-				fmt.Printf("Secret key: \"%s\"\n", user.SecretAccessKey) //nolint:forbidigo
-
-				return nil
-			})
-		},
-	}
-
-	userUpdate = &cli.Command{ //nolint:gochecknoglobals
-		Name:      "update",
-		Aliases:   []string{"u"},
-		Usage:     "Update user credentials",
-		Arguments: usernameArg,
-		Action: func(ctx context.Context, cmd *cli.Command) error {
-			return validateUsernameAndInvokeClient(ctx, cmd, func(username string, client *apiclient.Client) error {
-				user, err := client.UpdateUser(ctx, username)
-				if err != nil {
-					return err
-				}
-
-				fmt.Println("User updated successfully")                 //nolint:forbidigo
-				fmt.Printf("Access key: \"%s\"\n", user.AccessKeyID)     //nolint:forbidigo
-				fmt.Printf("Secret key: \"%s\"\n", user.SecretAccessKey) //nolint:forbidigo
+				fmt.Println("User created successfully") //nolint:forbidigo
+				printAccessKeyCredentials(user.AccessKeys[0])
 
 				return nil
 			})
@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/urfave/cli/v3"
+	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/pal"
+)
+
+var (
+	userKey = &cli.Command{ //nolint:gochecknoglobals
+		Name:    "key",
+		Aliases: []string{"k"},
+		Usage:   "manage user access keys",
+		Commands: []*cli.Command{
+			userKeyList,
+			userKeyCreate,
+			userKeyActivate,
+			userKeyDeactivate,
+			userKeyDelete,
+		},
+	}
+
+	userKeyList = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "list",
+		Aliases:   []string{"ls", "l"},
+		Usage:     "List user access keys",
+		Arguments: usernameArg,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateUsernameAndInvokeClient(ctx, cmd, func(username string, client *apiclient.Client) error {
+				keys, err := client.ListAccessKeys(ctx, username)
+				if err != nil {
+					return err
+				}
+
+				printAccessKeys(keys)
+
+				return nil
+			})
+		},
+	}
+
+	userKeyCreate = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "create",
+		Aliases:   []string{"c"},
+		Usage:     "Create an access key for a user",
+		Arguments: usernameArg,
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "expires-in",
+				Usage: "expire the key after this duration, e.g. 720h",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateUsernameAndInvokeClient(ctx, cmd, func(username string, client *apiclient.Client) error {
+				var expiresAt *time.Time
+
+				if expiresIn := cmd.Duration("expires-in"); expiresIn > 0 {
+					expiresAt = lo.ToPtr(time.Now().Add(expiresIn).UTC())
+				}
+
+				key, err := client.CreateAccessKey(ctx, username, expiresAt)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Access key created successfully") //nolint:forbidigo
+				printAccessKeyCredentials(key)
+
+				return nil
+			})
+		},
+	}
+
+	userKeyActivate = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "activate",
+		Usage:     "Activate an access key",
+		Arguments: userAndAccessKeyArgs,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateUserAndAccessKeyAndInvoke(ctx, cmd, func(userName, keyID string, client *apiclient.Client) error {
+				err := client.SetAccessKeyStatus(ctx, userName, keyID, core.AccessKeyStatusActive)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Access key activated successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	userKeyDeactivate = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "deactivate",
+		Usage:     "Deactivate an access key",
+		Arguments: userAndAccessKeyArgs,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateUserAndAccessKeyAndInvoke(ctx, cmd, func(userName, keyID string, client *apiclient.Client) error {
+				err := client.SetAccessKeyStatus(ctx, userName, keyID, core.AccessKeyStatusInactive)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Access key deactivated successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	userKeyDelete = &cli.Command{ //nolint:gochecknoglobals
+		Name:      "delete",
+		Aliases:   []string{"d"},
+		Usage:     "Delete an access key",
+		Arguments: userAndAccessKeyArgs,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return validateUserAndAccessKeyAndInvoke(ctx, cmd, func(userName, keyID string, client *apiclient.Client) error {
+				err := client.DeleteAccessKey(ctx, userName, keyID)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Access key deleted successfully") //nolint:forbidigo
+
+				return nil
+			})
+		},
+	}
+
+	userAndAccessKeyArgs = []cli.Argument{ //nolint:gochecknoglobals
+		&cli.StringArg{
+			Name:   "username",
+			Config: cli.StringConfig{},
+		},
+		&cli.StringArg{
+			Name:   "access-key-id",
+			Config: cli.StringConfig{},
+		},
+	}
+)
+
+func printAccessKeyCredentials(key core.AccessKey) {
+	fmt.Printf("Access key: \"%s\"\n", key.AccessKeyID)     //nolint:forbidigo
+	fmt.Printf("Secret key: \"%s\"\n", key.SecretAccessKey) //nolint:forbidigo
+
+	if key.ExpiresAt != nil {
+		fmt.Printf("Expires at: %s\n", key.ExpiresAt.Format(time.RFC3339)) //nolint:forbidigo
+	}
+}
+
+func printAccessKeys(keys []core.AccessKey) {
+	for _, key := range keys {
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\n", //nolint:forbidigo
+			key.AccessKeyID, key.Status, formatTime(&key.CreatedAt), formatTime(key.ExpiresAt), formatTime(key.LastUsedAt))
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+type accessKeyFn func(string, string, *apiclient.Client) error
+
+func validateUserAndAccessKeyAndInvoke(ctx context.Context, cmd *cli.Command, f accessKeyFn) error {
+	userName := cmd.StringArg("username")
+	if userName == "" {
+		return fmt.Errorf("%w: username", ErrMissingArgument)
+	}
+
+	accessKeyID := cmd.StringArg("access-key-id")
+	if accessKeyID == "" {
+		return fmt.Errorf("%w: access-key-id", ErrMissingArgument)
+	}
+
+	client := pal.MustInvoke[*apiclient.Client](ctx, nil)
+
+	return f(userName, accessKeyID, client)
+}
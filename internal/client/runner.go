@@ -17,6 +17,15 @@ func (c *Runner) Run(ctx context.Context) error {
 		Commands: []*cli.Command{
 			commands.UserCommand,
 			commands.BindingCommand,
+			commands.GroupCommand,
+			commands.RoleCommand,
+			commands.PolicyCommand,
+			commands.S3Command,
+			commands.ApplyCommand,
+			commands.AuditCommand,
+			commands.LimitsCommand,
+			commands.BucketCommand,
+			commands.StatsCommand,
 		},
 	}).Run(ctx, os.Args)
 }
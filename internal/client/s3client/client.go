@@ -0,0 +1,304 @@
+// Package s3client implements the S3 data plane commands of d3-client on top of the minio SDK, which signs
+// requests with SigV4 the same way pkg/sigv4 validates them.
+package s3client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/zhulik/d3/internal/core"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// DefaultPartSize is the size of multipart upload parts and ranged download chunks.
+	DefaultPartSize = 16 << 20
+	// DefaultConcurrency is the number of parts transferred in parallel.
+	DefaultConcurrency = 4
+
+	region    = "local"
+	urlScheme = "s3://"
+)
+
+var (
+	ErrInvalidS3URL      = errors.New("invalid S3 URL")
+	ErrUnsupportedMethod = errors.New("unsupported presign method")
+	ErrUnsafeKey         = errors.New("object key is not a safe local path")
+)
+
+// Bucket is a bucket as listed by ListBuckets.
+type Bucket struct {
+	Name      string
+	CreatedAt time.Time
+}
+
+// Object is an object or, with IsPrefix set, a common prefix as listed by ListObjects.
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+	ETag         string
+	IsPrefix     bool
+}
+
+// TransferOptions control how uploads and downloads are split into parts, zero values mean defaults.
+type TransferOptions struct {
+	PartSize    uint64
+	Concurrency int
+}
+
+func (o TransferOptions) partSize() uint64 {
+	if o.PartSize == 0 {
+		return DefaultPartSize
+	}
+
+	return o.PartSize
+}
+
+func (o TransferOptions) concurrency() int {
+	if o.Concurrency <= 0 {
+		return DefaultConcurrency
+	}
+
+	return o.Concurrency
+}
+
+// Client talks to the S3 port of d3 with the credentials of core.ClientConfig.
+type Client struct {
+	Config *core.ClientConfig
+
+	minio *minio.Client
+}
+
+func (c *Client) Init(_ context.Context) error {
+	endpoint, err := url.Parse(c.Config.S3URL)
+	if err != nil {
+		return fmt.Errorf("%w: invalid S3 URL: %w", core.ErrInvalidConfig, err)
+	}
+
+	options := &minio.Options{
+		Creds:        credentials.NewStaticV4(c.Config.AccessKeyID, c.Config.AccessKeySecret, ""),
+		Secure:       endpoint.Scheme == "https",
+		Region:       region,
+		BucketLookup: minio.BucketLookupPath,
+	}
+
+	transport, err := c.Config.HTTPTransport()
+	if err != nil {
+		return err
+	}
+
+	if transport != nil {
+		options.Transport = transport
+	}
+
+	c.minio, err = minio.New(endpoint.Host, options)
+
+	return err
+}
+
+// ParseURL splits an s3://bucket/key URL, the key may be empty or a prefix.
+func ParseURL(s3URL string) (string, string, error) {
+	path, ok := strings.CutPrefix(s3URL, urlScheme)
+	if !ok {
+		return "", "", fmt.Errorf("%w: %q must start with %s", ErrInvalidS3URL, s3URL, urlScheme)
+	}
+
+	bucket, key, _ := strings.Cut(path, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("%w: %q has no bucket", ErrInvalidS3URL, s3URL)
+	}
+
+	return bucket, key, nil
+}
+
+// IsS3URL reports whether the location is an s3:// URL rather than a local path.
+func IsS3URL(location string) bool {
+	return strings.HasPrefix(location, urlScheme)
+}
+
+func (c *Client) ListBuckets(ctx context.Context) ([]Bucket, error) {
+	buckets, err := c.minio.ListBuckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Bucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		result = append(result, Bucket{Name: bucket.Name, CreatedAt: bucket.CreationDate})
+	}
+
+	return result, nil
+}
+
+// ListObjects lists the objects under the prefix. Unless recursive, keys are grouped by "/" into prefixes.
+func (c *Client) ListObjects(ctx context.Context, bucket, prefix string, recursive bool) ([]Object, error) {
+	var objects []Object
+
+	for info := range c.minio.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: recursive}) {
+		if info.Err != nil {
+			return nil, info.Err
+		}
+
+		objects = append(objects, Object{
+			Key:          info.Key,
+			Size:         info.Size,
+			LastModified: info.LastModified,
+			ETag:         info.ETag,
+			IsPrefix:     strings.HasSuffix(info.Key, "/") && info.LastModified.IsZero(),
+		})
+	}
+
+	return objects, nil
+}
+
+// Upload uploads the file, files larger than the part size are uploaded in parallel parts.
+func (c *Client) Upload(ctx context.Context, path, bucket, key string, opts TransferOptions) error {
+	_, err := c.minio.FPutObject(ctx, bucket, key, path, minio.PutObjectOptions{
+		PartSize:   opts.partSize(),
+		NumThreads: uint(opts.concurrency()), //nolint:gosec
+	})
+
+	return err
+}
+
+// Download downloads the object to path, objects larger than the part size are downloaded in parallel ranges.
+// The file is replaced only once the download completes.
+func (c *Client) Download(ctx context.Context, bucket, key, path string, opts TransferOptions) error {
+	info, err := c.minio.StatObject(ctx, bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0750)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".d3-download-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(file.Name())
+
+	err = c.downloadRanges(ctx, bucket, key, info.Size, file, opts)
+	if err != nil {
+		file.Close()
+
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func (c *Client) downloadRanges(ctx context.Context, bucket, key string, size int64, file *os.File,
+	opts TransferOptions) error {
+	partSize := int64(opts.partSize()) //nolint:gosec
+
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(opts.concurrency())
+
+	for start := int64(0); start == 0 || start < size; start += partSize {
+		group.Go(func() error {
+			getOpts := minio.GetObjectOptions{}
+
+			if size > partSize {
+				err := getOpts.SetRange(start, min(start+partSize, size)-1)
+				if err != nil {
+					return err
+				}
+			}
+
+			object, err := c.minio.GetObject(ctx, bucket, key, getOpts)
+			if err != nil {
+				return err
+			}
+
+			defer object.Close()
+
+			_, err = io.Copy(io.NewOffsetWriter(file, start), object)
+
+			return err
+		})
+	}
+
+	return group.Wait()
+}
+
+// Copy copies an object on the server side.
+func (c *Client) Copy(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string) error {
+	_, err := c.minio.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: dstBucket, Object: dstKey},
+		minio.CopySrcOptions{Bucket: srcBucket, Object: srcKey},
+	)
+
+	return err
+}
+
+func (c *Client) Remove(ctx context.Context, bucket, key string) error {
+	return c.minio.RemoveObject(ctx, bucket, key, minio.RemoveObjectOptions{})
+}
+
+// RemovePrefix deletes every object under the prefix with batched DeleteObjects calls and returns their keys.
+func (c *Client) RemovePrefix(ctx context.Context, bucket, prefix string) ([]string, error) {
+	objects, err := c.ListObjects(ctx, bucket, prefix, true)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(objects))
+	toRemove := make(chan minio.ObjectInfo, len(objects))
+
+	for _, object := range objects {
+		keys = append(keys, object.Key)
+		toRemove <- minio.ObjectInfo{Key: object.Key}
+	}
+
+	close(toRemove)
+
+	var errs []error
+	for removeErr := range c.minio.RemoveObjects(ctx, bucket, toRemove, minio.RemoveObjectsOptions{}) {
+		errs = append(errs, fmt.Errorf("%s: %w", removeErr.ObjectName, removeErr.Err))
+	}
+
+	return keys, errors.Join(errs...)
+}
+
+// Presign returns a URL allowing anyone to GET or PUT the object until it expires.
+func (c *Client) Presign(ctx context.Context, method, bucket, key string, expires time.Duration) (string, error) {
+	var (
+		presigned *url.URL
+		err       error
+	)
+
+	switch method {
+	case http.MethodGet:
+		presigned, err = c.minio.PresignedGetObject(ctx, bucket, key, expires, nil)
+	case http.MethodPut:
+		presigned, err = c.minio.PresignedPutObject(ctx, bucket, key, expires)
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedMethod, method)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return presigned.String(), nil
+}
@@ -0,0 +1,216 @@
+package s3client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type SyncOperation string
+
+const (
+	SyncUpload   SyncOperation = "upload"
+	SyncDownload SyncOperation = "download"
+	SyncDelete   SyncOperation = "delete"
+)
+
+// SyncChange is a change made by a sync: Name is the object key for uploads and remote deletes, the local path
+// for downloads and local deletes.
+type SyncChange struct {
+	Operation SyncOperation
+	Name      string
+}
+
+// SyncOptions control a sync, Delete removes files or objects missing from the source.
+type SyncOptions struct {
+	TransferOptions
+
+	Delete bool
+}
+
+// SyncUp uploads the files of dir whose SHA256 differs from the ETag of the object under prefix. d3 uses the
+// SHA256 of the whole content as ETag, multipart objects included.
+func (c *Client) SyncUp(ctx context.Context, dir, bucket, prefix string, opts SyncOptions) ([]SyncChange, error) {
+	prefix = directoryPrefix(prefix)
+
+	remote, err := c.remoteETags(ctx, bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []SyncChange
+
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		key := prefix + filepath.ToSlash(rel)
+
+		etag, exists := remote[key]
+		delete(remote, key)
+
+		if exists {
+			upToDate, err := matchesETag(path, etag)
+			if err != nil || upToDate {
+				return err
+			}
+		}
+
+		err = c.Upload(ctx, path, bucket, key, opts.TransferOptions)
+		if err != nil {
+			return err
+		}
+
+		changes = append(changes, SyncChange{Operation: SyncUpload, Name: key})
+
+		return nil
+	})
+	if err != nil {
+		return changes, err
+	}
+
+	if !opts.Delete {
+		return changes, nil
+	}
+
+	for key := range remote {
+		err := c.Remove(ctx, bucket, key)
+		if err != nil {
+			return changes, err
+		}
+
+		changes = append(changes, SyncChange{Operation: SyncDelete, Name: key})
+	}
+
+	return changes, nil
+}
+
+// SyncDown downloads the objects under prefix whose ETag differs from the SHA256 of the file in dir.
+func (c *Client) SyncDown(ctx context.Context, bucket, prefix, dir string, opts SyncOptions) ([]SyncChange, error) {
+	prefix = directoryPrefix(prefix)
+
+	objects, err := c.ListObjects(ctx, bucket, prefix, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []SyncChange
+
+	synced := map[string]bool{}
+
+	for _, object := range objects {
+		rel := strings.TrimPrefix(object.Key, prefix)
+		if strings.HasSuffix(rel, "/") {
+			// Folder markers have nothing to download.
+			continue
+		}
+
+		if !filepath.IsLocal(filepath.FromSlash(rel)) {
+			return changes, fmt.Errorf("%w: %q escapes %s", ErrUnsafeKey, object.Key, dir)
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		synced[path] = true
+
+		upToDate, err := matchesETag(path, object.ETag)
+		if err != nil {
+			return changes, err
+		}
+
+		if upToDate {
+			continue
+		}
+
+		err = c.Download(ctx, bucket, object.Key, path, opts.TransferOptions)
+		if err != nil {
+			return changes, err
+		}
+
+		changes = append(changes, SyncChange{Operation: SyncDownload, Name: path})
+	}
+
+	if !opts.Delete {
+		return changes, nil
+	}
+
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == dir {
+			// Nothing was downloaded and there is nothing to delete.
+			return nil
+		}
+
+		if err != nil || entry.IsDir() || synced[path] {
+			return err
+		}
+
+		err = os.Remove(path)
+		if err != nil {
+			return err
+		}
+
+		changes = append(changes, SyncChange{Operation: SyncDelete, Name: path})
+
+		return nil
+	})
+
+	return changes, err
+}
+
+// remoteETags returns the ETags of the objects under prefix by key.
+func (c *Client) remoteETags(ctx context.Context, bucket, prefix string) (map[string]string, error) {
+	objects, err := c.ListObjects(ctx, bucket, prefix, true)
+	if err != nil {
+		return nil, err
+	}
+
+	etags := make(map[string]string, len(objects))
+	for _, object := range objects {
+		etags[object.Key] = object.ETag
+	}
+
+	return etags, nil
+}
+
+// matchesETag reports whether the file at path exists and its SHA256 is etag.
+func matchesETag(path, etag string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	defer file.Close()
+
+	hash := sha256.New()
+
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return false, err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)) == strings.Trim(etag, `"`), nil
+}
+
+func directoryPrefix(prefix string) string {
+	if prefix == "" || strings.HasSuffix(prefix, "/") {
+		return prefix
+	}
+
+	return prefix + "/"
+}
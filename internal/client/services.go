@@ -2,6 +2,7 @@ package client
 
 import (
 	"github.com/zhulik/d3/internal/client/apiclient"
+	"github.com/zhulik/d3/internal/client/s3client"
 	"github.com/zhulik/pal"
 )
 
@@ -9,5 +10,6 @@ func Provide() pal.ServiceDef {
 	return pal.ProvideList(
 		pal.Provide(&Runner{}),
 		pal.Provide(&apiclient.Client{}),
+		pal.Provide(&s3client.Client{}),
 	)
 }
@@ -2,15 +2,26 @@ package core
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net/http"
+	"os"
 
 	"github.com/caarlos0/env/v11"
 )
 
 type ClientConfig struct {
-	ServerURL       string `env:"D3_SERVER_URL"         envDefault:"http://localhost:8082"`
+	ServerURL string `env:"D3_SERVER_URL" envDefault:"http://localhost:8082"`
+	// S3URL is the S3 endpoint used by the data plane commands.
+	S3URL           string `env:"D3_S3_URL"             envDefault:"http://localhost:8080"`
 	AccessKeyID     string `env:"AWS_ACCESS_KEY_ID"     envRequired:"true"`
 	AccessKeySecret string `env:"AWS_ACCESS_KEY_SECRET" envRequired:"true"`
+	// CAFile is a PEM file with the CAs that sign the server certificates, the system CAs are used when it is
+	// empty. ClientCertFile and ClientKeyFile are the client certificate for mutual TLS.
+	CAFile         string `env:"D3_CA_FILE"          envDefault:""`
+	ClientCertFile string `env:"D3_CLIENT_CERT_FILE" envDefault:""`
+	ClientKeyFile  string `env:"D3_CLIENT_KEY_FILE"  envDefault:""`
 }
 
 func (c *ClientConfig) Init(_ context.Context) error {
@@ -26,3 +37,39 @@ func (c *ClientConfig) Init(_ context.Context) error {
 
 	return nil
 }
+
+// HTTPTransport returns a transport that trusts CAFile and presents the client certificate, or nil when neither
+// is configured.
+func (c *ClientConfig) HTTPTransport() (*http.Transport, error) {
+	if c.CAFile == "" && c.ClientCertFile == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to read CA file: %w", ErrInvalidConfig, err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: no certificates found in %s", ErrInvalidConfig, c.CAFile)
+		}
+	}
+
+	if c.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to load client certificate: %w", ErrInvalidConfig, err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
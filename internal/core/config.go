@@ -2,21 +2,49 @@ package core
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"path/filepath"
+	"time"
 
 	"github.com/caarlos0/env/v11"
+	"github.com/samber/lo"
 )
 
 type StorageBackendType string
 
 const (
 	StorageBackendFolder StorageBackendType = "folder"
+	StorageBackendMirror StorageBackendType = "mirror"
+)
+
+type AuditLogSinkType string
+
+const (
+	AuditLogSinkNone   AuditLogSinkType = ""
+	AuditLogSinkFile   AuditLogSinkType = "file"
+	AuditLogSinkBucket AuditLogSinkType = "bucket"
+	AuditLogSinkRedis  AuditLogSinkType = "redis"
+)
+
+var tlsVersions = map[string]uint16{ //nolint:gochecknoglobals
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// PlacementPolicy picks the storage root of new buckets.
+type PlacementPolicy string
+
+const (
+	PlacementRoundRobin    PlacementPolicy = "round-robin"
+	PlacementMostFreeSpace PlacementPolicy = "most-free-space"
 )
 
 type ManagementBackendType string
 
 const (
-	ManagementBackendYAML ManagementBackendType = "YAML"
+	ManagementBackendYAML   ManagementBackendType = "YAML"
+	ManagementBackendSQLite ManagementBackendType = "sqlite"
 )
 
 type Config struct {
@@ -24,9 +52,38 @@ type Config struct {
 
 	StorageBackend           StorageBackendType `env:"STORAGE_BACKEND"             envDefault:"folder"`
 	FolderStorageBackendPath string             `env:"FOLDER_STORAGE_BACKEND_PATH" envDefault:"./d3_data"`
+	// FolderStorageExtraPaths are more storage roots next to FolderStorageBackendPath, usually on other disks. New
+	// buckets are placed on a root by FolderStoragePlacement, unless FolderStorageBucketRoots pins them to one as
+	// bucket:path pairs.
+	FolderStorageExtraPaths  []string          `env:"FOLDER_STORAGE_EXTRA_PATHS"  envDefault:""`
+	FolderStoragePlacement   PlacementPolicy   `env:"FOLDER_STORAGE_PLACEMENT"    envDefault:"most-free-space"`
+	FolderStorageBucketRoots map[string]string `env:"FOLDER_STORAGE_BUCKET_ROOTS" envDefault:""`
+	// FolderStorageCompression is the compression of new objects in buckets without their own, none by default.
+	FolderStorageCompression CompressionAlgorithm `env:"FOLDER_STORAGE_COMPRESSION" envDefault:"none"`
+	// FolderStorageDedup stores identical unencrypted blobs once, objects hard link them from a shared store.
+	FolderStorageDedup bool `env:"FOLDER_STORAGE_DEDUP" envDefault:"false"`
+	// MirrorStoragePaths are the roots the mirror backend keeps a copy of every bucket on, usually on other disks.
+	// The folder settings above apply to every root, except for the paths and placement. A write succeeds once
+	// MirrorWriteQuorum roots have it, half of them rounded up when zero, so a mirror of two disks keeps taking
+	// writes when one fails. The roots that missed a write are resilvered right away and every
+	// MirrorResilverInterval.
+	MirrorStoragePaths     []string      `env:"MIRROR_STORAGE_PATHS"     envDefault:""`
+	MirrorWriteQuorum      int           `env:"MIRROR_WRITE_QUORUM"      envDefault:"0"`
+	MirrorResilverInterval time.Duration `env:"MIRROR_RESILVER_INTERVAL" envDefault:"1m"`
+	// SSEMasterKeyFile or SSEMasterKey hold the master keys SSE-S3 data keys are wrapped with, one per line or
+	// comma-separated, as <id>:<base64 encoded 32 bytes>. The first key is current, the others only unwrap the keys
+	// of existing objects. SSE-S3 is unavailable when both are empty.
+	SSEMasterKeyFile string `env:"SSE_MASTER_KEY_FILE" envDefault:""`
+	SSEMasterKey     string `env:"SSE_MASTER_KEY"      envDefault:""`
+	// SSECustomerKeysOverHTTP accepts SSE-C keys on plain HTTP requests, for deployments behind a proxy that
+	// terminates TLS. They are rejected otherwise, the key would travel in the clear.
+	SSECustomerKeysOverHTTP bool `env:"SSE_CUSTOMER_KEYS_OVER_HTTP" envDefault:"false"`
 
 	ManagementBackend         ManagementBackendType `env:"MANAGEMENT_BACKEND"           envDefault:"YAML"`
 	ManagementBackendYAMLPath string                `env:"MANAGEMENT_BACKEND_YAML_PATH" envDefault:"./d3_data/management.yaml"` //nolint:lll
+	// ManagementBackendSQLitePath is the database file of the sqlite management backend. When the database is
+	// created and ManagementBackendYAMLPath exists, its users, policies and bindings are imported once.
+	ManagementBackendSQLitePath string `env:"MANAGEMENT_BACKEND_SQLITE_PATH" envDefault:"./d3_data/management.db"`
 	// ManagementBackendTmpPath specifies where to store temporary files for management backend operations.
 	// It should be on the same disk as the main storage to ensure atomicity. Only relevand for the YAML backend.
 	ManagementBackendTmpPath string `env:"MANAGEMENT_BACKEND_TMP_PATH" envDefault:"./d3_data/tmp"`
@@ -40,6 +97,62 @@ type Config struct {
 	RedisUsername string `env:"REDIS_USERNAME" envDefault:""`
 	RedisPassword string `env:"REDIS_PASSWORD" envDefault:""`
 
+	// WebIdentityJWKS is the path or http(s) URL of the JSON Web Key Set that tokens exchanged with
+	// AssumeRoleWithWebIdentity must be signed with. Web identity federation is disabled when it is empty.
+	// WebIdentityIssuer and WebIdentityAudience are the iss and aud claims tokens must carry.
+	WebIdentityJWKS     string `env:"WEB_IDENTITY_JWKS"     envDefault:""`
+	WebIdentityIssuer   string `env:"WEB_IDENTITY_ISSUER"   envDefault:""`
+	WebIdentityAudience string `env:"WEB_IDENTITY_AUDIENCE" envDefault:""`
+
+	// AuditLogSink selects where audit events are written: file, bucket or redis. Auditing is disabled when empty.
+	AuditLogSink AuditLogSinkType `env:"AUDIT_LOG_SINK" envDefault:""`
+	// AuditLogFilePath is the JSON-lines file of the file sink. When it grows past AuditLogFileMaxSize bytes it is
+	// renamed with a timestamp suffix, rotated files are never deleted.
+	AuditLogFilePath    string `env:"AUDIT_LOG_FILE_PATH"     envDefault:"./d3_data/audit/audit.jsonl"`
+	AuditLogFileMaxSize int64  `env:"AUDIT_LOG_FILE_MAX_SIZE" envDefault:"104857600"`
+	// AuditLogBucket is the existing bucket the bucket sink writes batches of events to, every
+	// AuditLogBucketFlushInterval. The bucket is append-only, S3 requests can only read it. Events are dropped once
+	// AuditLogBucketMaxPending of them are waiting to be written.
+	AuditLogBucket              string        `env:"AUDIT_LOG_BUCKET"                envDefault:""`
+	AuditLogBucketFlushInterval time.Duration `env:"AUDIT_LOG_BUCKET_FLUSH_INTERVAL" envDefault:"10s"`
+	AuditLogBucketMaxPending    int           `env:"AUDIT_LOG_BUCKET_MAX_PENDING"    envDefault:"100000"`
+	// AuditLogRedisStream is the Redis stream the redis sink appends events to.
+	AuditLogRedisStream string `env:"AUDIT_LOG_REDIS_STREAM" envDefault:"d3:audit"`
+	// AuditS3Actions are the S3 actions that are audited, s3:CopyObject stands for PutObject requests that copy.
+	// Requests denied by the authorizer are audited regardless of their action.
+	AuditS3Actions []string `env:"AUDIT_S3_ACTIONS" envDefault:"s3:DeleteBucket,s3:DeleteObject,s3:DeleteObjects,s3:CopyObject"` //nolint:lll
+
+	// SigV2Enabled accepts requests signed with the legacy AWS Signature Version 2 when they carry no SigV4
+	// signature.
+	SigV2Enabled bool `env:"SIGV2_ENABLED" envDefault:"true"`
+
+	// AccessLogFlushInterval is how often buffered S3 server access logs are written to their target buckets.
+	AccessLogFlushInterval time.Duration `env:"ACCESS_LOG_FLUSH_INTERVAL" envDefault:"1m"`
+
+	// StatsScanInterval is how often the storage statistics are recomputed by scanning all buckets, zero only scans
+	// when they are asked for. TransferStatsFlushInterval is how often the bytes users transferred are added to
+	// their totals in Redis.
+	StatsScanInterval          time.Duration `env:"STATS_SCAN_INTERVAL"           envDefault:"1h"`
+	TransferStatsFlushInterval time.Duration `env:"TRANSFER_STATS_FLUSH_INTERVAL" envDefault:"10s"`
+
+	// AnonymousRequestsPerSecond and AnonymousMaxConcurrentRequests limit the unsigned requests of every client IP,
+	// zero is unlimited. Users and groups are limited with the management API.
+	AnonymousRequestsPerSecond     float64 `env:"ANONYMOUS_REQUESTS_PER_SECOND"     envDefault:"0"`
+	AnonymousMaxConcurrentRequests int     `env:"ANONYMOUS_MAX_CONCURRENT_REQUESTS" envDefault:"0"`
+
+	// S3TLSCertFile and S3TLSKeyFile are the PEM certificate chain and key of the S3 listener, which serves plain
+	// HTTP when they are empty. The same goes for the management listener. Certificates are reloaded when the
+	// files change.
+	S3TLSCertFile         string `env:"S3_TLS_CERT_FILE"         envDefault:""`
+	S3TLSKeyFile          string `env:"S3_TLS_KEY_FILE"          envDefault:""`
+	ManagementTLSCertFile string `env:"MANAGEMENT_TLS_CERT_FILE" envDefault:""`
+	ManagementTLSKeyFile  string `env:"MANAGEMENT_TLS_KEY_FILE"  envDefault:""`
+	// ManagementTLSClientCAFile enables mutual TLS on the management listener: clients must present a certificate
+	// signed by one of the CAs in this PEM file.
+	ManagementTLSClientCAFile string `env:"MANAGEMENT_TLS_CLIENT_CA_FILE" envDefault:""`
+	// TLSMinVersion is the minimum TLS version of both listeners, 1.2 or 1.3.
+	TLSMinVersion string `env:"TLS_MIN_VERSION" envDefault:"1.2"`
+
 	Port            int `env:"PORT"              envDefault:"8080"`
 	HealthCheckPort int `env:"HEALTH_CHECK_PORT" envDefault:"8081"`
 	ManagementPort  int `env:"MANAGEMENT_PORT"   envDefault:"8082"`
@@ -56,14 +169,119 @@ func (c *Config) Init(_ context.Context) error {
 		return fmt.Errorf("%w: failed to parse config: %w", ErrInvalidConfig, err)
 	}
 
-	if c.StorageBackend == StorageBackendFolder {
+	switch c.StorageBackend {
+	case StorageBackendFolder:
 		if c.FolderStorageBackendPath == "" {
 			return fmt.Errorf("%w: FolderStorageBackendPath is not set", ErrInvalidConfig)
 		}
-	} else {
+	case StorageBackendMirror:
+		if err := c.validateMirror(); err != nil {
+			return err
+		}
+	default:
 		return fmt.Errorf("%w: unknown backend: %s", ErrInvalidConfig, c.StorageBackend)
 	}
 
+	if err := c.validatePlacement(); err != nil {
+		return err
+	}
+
+	if c.FolderStorageCompression != "" {
+		if err := (BucketCompression{Algorithm: c.FolderStorageCompression}).Validate(); err != nil {
+			return fmt.Errorf("%w: FolderStorageCompression: %w", ErrInvalidConfig, err)
+		}
+	}
+
+	if c.SSEMasterKeyFile != "" && c.SSEMasterKey != "" {
+		return fmt.Errorf("%w: SSEMasterKeyFile and SSEMasterKey are mutually exclusive", ErrInvalidConfig)
+	}
+
+	switch c.AuditLogSink {
+	case AuditLogSinkNone, AuditLogSinkFile, AuditLogSinkRedis:
+	case AuditLogSinkBucket:
+		if c.AuditLogBucket == "" {
+			return fmt.Errorf("%w: AuditLogBucket is not set", ErrInvalidConfig)
+		}
+	default:
+		return fmt.Errorf("%w: unknown audit log sink: %s", ErrInvalidConfig, c.AuditLogSink)
+	}
+
+	return c.validateTLS()
+}
+
+// FolderStorageRoots returns FolderStorageBackendPath followed by FolderStorageExtraPaths, without duplicates.
+func (c *Config) FolderStorageRoots() []string {
+	roots := append([]string{c.FolderStorageBackendPath}, c.FolderStorageExtraPaths...)
+
+	return lo.Uniq(lo.Map(roots, func(root string, _ int) string { return filepath.Clean(root) }))
+}
+
+func (c *Config) validatePlacement() error {
+	switch c.FolderStoragePlacement {
+	case "", PlacementRoundRobin, PlacementMostFreeSpace:
+	default:
+		return fmt.Errorf("%w: unknown placement policy: %s", ErrInvalidConfig, c.FolderStoragePlacement)
+	}
+
+	roots := c.FolderStorageRoots()
+
+	for bucket, root := range c.FolderStorageBucketRoots {
+		if !lo.Contains(roots, filepath.Clean(root)) {
+			return fmt.Errorf("%w: bucket %s is pinned to %s, which is not a storage root", ErrInvalidConfig, bucket, root)
+		}
+	}
+
+	return nil
+}
+
+// MirrorWriteQuorumSize returns the number of mirror roots a write must succeed on.
+func (c *Config) MirrorWriteQuorumSize() int {
+	if c.MirrorWriteQuorum > 0 {
+		return c.MirrorWriteQuorum
+	}
+
+	return (len(c.MirrorStoragePaths) + 1) / 2 //nolint:mnd
+}
+
+func (c *Config) validateMirror() error {
+	paths := lo.Uniq(lo.Map(c.MirrorStoragePaths, func(path string, _ int) string { return filepath.Clean(path) }))
+	if len(paths) < 2 || len(paths) != len(c.MirrorStoragePaths) {
+		return fmt.Errorf("%w: MirrorStoragePaths must list at least two distinct roots", ErrInvalidConfig)
+	}
+
+	if c.MirrorWriteQuorum < 0 || c.MirrorWriteQuorum > len(paths) {
+		return fmt.Errorf("%w: MirrorWriteQuorum must be between 0 and %d", ErrInvalidConfig, len(paths))
+	}
+
+	if c.MirrorResilverInterval <= 0 {
+		return fmt.Errorf("%w: MirrorResilverInterval must be positive", ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// MinTLSVersion returns the tls package constant of TLSMinVersion, TLS 1.2 when it is empty.
+func (c *Config) MinTLSVersion() uint16 {
+	return tlsVersions[lo.CoalesceOrEmpty(c.TLSMinVersion, "1.2")]
+}
+
+func (c *Config) validateTLS() error {
+	if (c.S3TLSCertFile == "") != (c.S3TLSKeyFile == "") {
+		return fmt.Errorf("%w: S3TLSCertFile and S3TLSKeyFile must be set together", ErrInvalidConfig)
+	}
+
+	if (c.ManagementTLSCertFile == "") != (c.ManagementTLSKeyFile == "") {
+		return fmt.Errorf("%w: ManagementTLSCertFile and ManagementTLSKeyFile must be set together", ErrInvalidConfig)
+	}
+
+	if c.ManagementTLSClientCAFile != "" && c.ManagementTLSCertFile == "" {
+		return fmt.Errorf("%w: ManagementTLSClientCAFile requires ManagementTLSCertFile", ErrInvalidConfig)
+	}
+
+	if _, ok := tlsVersions[c.TLSMinVersion]; !ok {
+		return fmt.Errorf("%w: unknown TLS version: %s", ErrInvalidConfig, c.TLSMinVersion)
+	}
+
 	return nil
 }
 
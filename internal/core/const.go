@@ -1,5 +1,7 @@
 package core
 
+import "time"
+
 const (
 	MaxKeys       = 1000
 	MaxParts      = 1000  // AWS S3 ListParts default/limit per request
@@ -12,3 +14,10 @@ const (
 	// SizeLimit1Mb is the max request body size for Management API (JSON/YAML payloads).
 	SizeLimit1Mb = 1024 * 1024
 )
+
+// Duration bounds of sessions issued by AssumeRole, the same as in AWS STS.
+const (
+	MinSessionDuration     = 15 * time.Minute
+	DefaultSessionDuration = time.Hour
+	MaxSessionDuration     = 12 * time.Hour
+)
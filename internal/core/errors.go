@@ -17,6 +17,32 @@ var (
 	ErrUserInvalid       = errors.New("invalid user")
 	ErrUserNameReserved  = errors.New("username \"admin\" is reserved")
 
+	ErrAccessKeyNotFound = errors.New("access key not found")
+	ErrAccessKeyInactive = errors.New("access key is inactive")
+	ErrAccessKeyExpired  = errors.New("access key is expired")
+	ErrAccessKeyInvalid  = errors.New("invalid access key")
+
+	ErrGroupNotFound            = errors.New("group not found")
+	ErrGroupAlreadyExists       = errors.New("group already exists")
+	ErrGroupInvalid             = errors.New("invalid group")
+	ErrGroupMemberNotFound      = errors.New("user is not a member of the group")
+	ErrGroupMemberAlreadyExists = errors.New("user is already a member of the group")
+
+	ErrRateLimitsInvalid = errors.New("invalid rate limits")
+	ErrSlowDown          = errors.New("SlowDown: please reduce your request rate")
+
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleAlreadyExists = errors.New("role already exists")
+	ErrRoleInvalid       = errors.New("invalid role")
+
+	ErrSessionNotFound      = errors.New("session not found")
+	ErrSessionTokenInvalid  = errors.New("invalid session token")
+	ErrInvalidSTSRequest    = errors.New("invalid STS request")
+	ErrSTSActionUnsupported = errors.New("unsupported STS action")
+
+	ErrWebIdentityDisabled     = errors.New("web identity federation is not configured")
+	ErrWebIdentityTokenInvalid = errors.New("invalid web identity token")
+
 	ErrInvalidConfig          = errors.New("invalid config")
 	ErrAdminCredentialsNotSet = errors.New("admin credentials not set")
 
@@ -35,6 +61,15 @@ var (
 
 	ErrUnauthorized = errors.New("unauthorized")
 
+	ErrPolicySimulationInvalid = errors.New("invalid policy simulation")
+
+	ErrManagementConfigInvalid = errors.New("invalid management config")
+
+	ErrAuditLogDisabled  = errors.New("audit log is not configured")
+	ErrAuditQueryInvalid = errors.New("invalid audit query")
+
+	ErrStatsQueryInvalid = errors.New("invalid stats query")
+
 	ErrInvalidBucketName = errors.New("invalid bucket name")
 	ErrInvalidObjectKey  = errors.New("invalid object key")
 	ErrInvalidUploadID   = errors.New("invalid upload ID")
@@ -43,4 +78,27 @@ var (
 	ErrPathTraversal     = errors.New("path traversal detected")
 	ErrSymlinkNotAllowed = errors.New("symlinks are not allowed")
 	ErrInvalidTag        = errors.New("invalid tag")
+
+	ErrInvalidLoggingTarget = errors.New("invalid target bucket for logging")
+
+	ErrSSEInvalidRequest        = errors.New("invalid server-side encryption request")
+	ErrSSENotConfigured         = errors.New("server-side encryption master key is not configured")
+	ErrSSECustomerKeyRequired   = errors.New("the object is encrypted with a customer-provided key")
+	ErrSSECustomerKeyMismatch   = errors.New("the customer-provided key does not match the object's key")
+	ErrBucketEncryptionNotFound = errors.New("the server side encryption configuration was not found")
+
+	ErrBucketCompressionInvalid = errors.New("invalid bucket compression")
+
+	ErrObjectLocked                    = errors.New("the object is protected by Object Lock")
+	ErrObjectLockInvalid               = errors.New("invalid Object Lock request")
+	ErrObjectLockNotEnabled            = errors.New("the bucket does not have Object Lock enabled")
+	ErrObjectLockConfigurationNotFound = errors.New("the bucket has no Object Lock configuration")
+	ErrObjectRetentionNotFound         = errors.New("the object has no retention")
+
+	ErrBucketQuotaInvalid = errors.New("invalid bucket quota")
+	ErrQuotaExceeded      = errors.New("QuotaExceeded: the bucket quota is exceeded")
+
+	ErrStorageRootNotFound = errors.New("unknown storage root")
+	ErrBucketMoving        = errors.New("the bucket is being moved to another storage root")
+	ErrWriteQuorum         = errors.New("the write did not reach enough storage roots")
 )
@@ -2,10 +2,17 @@ package core
 
 import (
 	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/base64"
+	"fmt"
 	"io"
+	"slices"
 	"time"
 
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/pkg/credentials"
 	"github.com/zhulik/d3/pkg/iampol"
+	"github.com/zhulik/d3/pkg/jwt"
 	"github.com/zhulik/d3/pkg/s3actions"
 )
 
@@ -19,12 +26,336 @@ type ObjectMetadata struct {
 	Size         int64             `yaml:"size"`
 	Tags         map[string]string `yaml:"tags"`
 	Meta         map[string]string `yaml:"meta"`
+	// Encryption is set for objects encrypted at rest.
+	Encryption *ObjectEncryption `yaml:"encryption,omitempty"`
+	// Compression is set for objects stored compressed, Size and the checksums describe the uncompressed content.
+	Compression *ObjectCompression `yaml:"compression,omitempty"`
+	// BlobSHA256 is set for objects whose blob is shared through the content-addressed store, it is the checksum
+	// of the stored blob, compressed if the object is compressed.
+	BlobSHA256 string `yaml:"blob_sha256,omitempty"`
+	// Retention and LegalHold protect the object from deletion and overwrites, see CheckLock.
+	Retention *ObjectRetention `yaml:"retention,omitempty"`
+	LegalHold bool             `yaml:"legal_hold,omitempty"`
+}
+
+// CheckLock returns ErrObjectLocked when the object may not be deleted or overwritten: it is under legal hold, or
+// its retention has not expired yet. bypassGovernance lifts GOVERNANCE retention, COMPLIANCE retention cannot be
+// lifted by anyone.
+func (m *ObjectMetadata) CheckLock(now time.Time, bypassGovernance bool) error {
+	if m.LegalHold {
+		return fmt.Errorf("%w: the object is under legal hold", ErrObjectLocked)
+	}
+
+	if !m.Retention.Active(now) {
+		return nil
+	}
+
+	if m.Retention.Mode == ObjectLockModeGovernance && bypassGovernance {
+		return nil
+	}
+
+	return fmt.Errorf("%w: the object is retained in %s mode until %s", ErrObjectLocked, m.Retention.Mode,
+		m.Retention.RetainUntil.Format(time.RFC3339))
+}
+
+// SSEAlgorithmAES256 is the only server-side encryption algorithm supported, for SSE-S3 and SSE-C alike.
+const SSEAlgorithmAES256 = "AES256"
+
+// ServerSideEncryption asks for an object to be encrypted at rest. Without CustomerKey d3 manages the key (SSE-S3),
+// with it the key is provided by the client (SSE-C), which must provide it again to read the object.
+type ServerSideEncryption struct {
+	CustomerKey []byte
+}
+
+// CustomerKeyMD5 is the base64 encoded MD5 of the customer key that SSE-C objects are matched against.
+func (s *ServerSideEncryption) CustomerKeyMD5() string {
+	sum := md5.Sum(s.CustomerKey) //nolint:gosec
+
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ObjectEncryption describes how the data of an object is encrypted: with a random data key, wrapped with the
+// master key MasterKeyID for SSE-S3, or with the customer key whose MD5 is CustomerKeyMD5 for SSE-C.
+type ObjectEncryption struct {
+	MasterKeyID    string `yaml:"master_key_id,omitempty"`
+	CustomerKeyMD5 string `yaml:"customer_key_md5,omitempty"`
+	WrappedKey     string `yaml:"wrapped_key"`
+	// SHA256 is the checksum of the plaintext sealed with the data key, the metadata of encrypted objects does not
+	// store it in the clear. It is empty for SSE-C multipart uploads until they are first read with their key.
+	SHA256 string `yaml:"sha256,omitempty"`
+	// Segments are the separately encrypted streams the data consists of, one per part for multipart uploads.
+	Segments []EncryptedSegment `yaml:"segments,omitempty"`
+}
+
+// IsCustomer reports whether the object is encrypted with a customer-provided key.
+func (e *ObjectEncryption) IsCustomer() bool {
+	return e.CustomerKeyMD5 != ""
+}
+
+type EncryptedSegment struct {
+	Nonce string `yaml:"nonce"` // base64 encoded
+	Size  int64  `yaml:"size"`  // of the data before encryption, compressed when the object is
+}
+
+type CompressionAlgorithm string
+
+const (
+	CompressionNone CompressionAlgorithm = "none"
+	CompressionZstd CompressionAlgorithm = "zstd"
+)
+
+// ObjectCompression describes how the data of an object is compressed, before it is encrypted.
+type ObjectCompression struct {
+	Algorithm CompressionAlgorithm `yaml:"algorithm"`
+	// Segments are the separately compressed streams the data consists of, one per part for multipart uploads.
+	Segments []CompressedSegment `yaml:"segments"`
+}
+
+type CompressedSegment struct {
+	Size       int64 `yaml:"size"`
+	StoredSize int64 `yaml:"stored_size"`
+	// Raw segments did not compress well and are stored as is.
+	Raw bool `yaml:"raw,omitempty"`
+}
+
+// BucketCompression overrides the compression of new objects configured for the whole storage backend.
+type BucketCompression struct {
+	Algorithm CompressionAlgorithm `json:"algorithm" yaml:"algorithm"`
+}
+
+func (c BucketCompression) Validate() error {
+	switch c.Algorithm {
+	case CompressionNone, CompressionZstd:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown algorithm %q", ErrBucketCompressionInvalid, c.Algorithm)
+	}
+}
+
+// ObjectLockMode tells who may lift the retention of an object before it expires: users allowed to bypass
+// GOVERNANCE retention may, nobody may lift COMPLIANCE retention.
+type ObjectLockMode string
+
+const (
+	ObjectLockModeGovernance ObjectLockMode = "GOVERNANCE"
+	ObjectLockModeCompliance ObjectLockMode = "COMPLIANCE"
+)
+
+func (m ObjectLockMode) Validate() error {
+	switch m {
+	case ObjectLockModeGovernance, ObjectLockModeCompliance:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrObjectLockInvalid, m)
+	}
+}
+
+// ObjectRetention protects an object from deletion and overwrites until RetainUntil.
+type ObjectRetention struct {
+	Mode        ObjectLockMode `yaml:"mode"`
+	RetainUntil time.Time      `yaml:"retain_until"`
+}
+
+// Active tells whether the retention still protects the object, a nil retention does not.
+func (r *ObjectRetention) Active(now time.Time) bool {
+	return r != nil && now.Before(r.RetainUntil)
+}
+
+// BucketObjectLock is the Object Lock config of a bucket, once enabled Object Lock cannot be disabled.
+type BucketObjectLock struct {
+	// DefaultRetention applies to the objects written without a retention of their own.
+	DefaultRetention *DefaultRetention `yaml:"default_retention,omitempty"`
+}
+
+// DefaultRetention retains new objects for either Days or Years.
+type DefaultRetention struct {
+	Mode  ObjectLockMode `yaml:"mode"`
+	Days  int            `yaml:"days,omitempty"`
+	Years int            `yaml:"years,omitempty"`
+}
+
+func (l BucketObjectLock) Validate() error {
+	retention := l.DefaultRetention
+	if retention == nil {
+		return nil
+	}
+
+	if err := retention.Mode.Validate(); err != nil {
+		return err
+	}
+
+	if (retention.Days > 0) == (retention.Years > 0) || retention.Days < 0 || retention.Years < 0 {
+		return fmt.Errorf("%w: the default retention needs either a positive number of days or of years",
+			ErrObjectLockInvalid)
+	}
+
+	return nil
+}
+
+// Retention is the retention of an object written at now.
+func (r DefaultRetention) Retention(now time.Time) *ObjectRetention {
+	return &ObjectRetention{Mode: r.Mode, RetainUntil: now.AddDate(r.Years, 0, r.Days)}
+}
+
+// BucketQuota limits the logical size and the number of objects of a bucket, zero limits are unlimited.
+type BucketQuota struct {
+	MaxBytes   int64 `json:"max_bytes,omitempty"   yaml:"max_bytes,omitempty"`
+	MaxObjects int64 `json:"max_objects,omitempty" yaml:"max_objects,omitempty"`
+}
+
+func (q BucketQuota) Validate() error {
+	if q.MaxBytes < 0 || q.MaxObjects < 0 {
+		return fmt.Errorf("%w: limits must not be negative", ErrBucketQuotaInvalid)
+	}
+
+	return nil
+}
+
+// Check returns ErrQuotaExceeded when changing usage by delta grows it past the quota. Changes that do not grow
+// usage are always allowed, so that a bucket over a lowered quota can still shrink. A nil quota allows everything.
+func (q *BucketQuota) Check(usage, delta BucketUsage) error {
+	if q == nil {
+		return nil
+	}
+
+	if q.MaxBytes > 0 && delta.Bytes > 0 && usage.Bytes+delta.Bytes > q.MaxBytes {
+		return fmt.Errorf("%w: %d bytes would exceed the limit of %d bytes", ErrQuotaExceeded,
+			usage.Bytes+delta.Bytes, q.MaxBytes)
+	}
+
+	if q.MaxObjects > 0 && delta.Objects > 0 && usage.Objects+delta.Objects > q.MaxObjects {
+		return fmt.Errorf("%w: %d objects would exceed the limit of %d objects", ErrQuotaExceeded,
+			usage.Objects+delta.Objects, q.MaxObjects)
+	}
+
+	return nil
+}
+
+// BucketUsage is the logical size and the number of objects of a bucket.
+type BucketUsage struct {
+	Bytes   int64 `json:"bytes"   yaml:"bytes"`
+	Objects int64 `json:"objects" yaml:"objects"`
+}
+
+func (u BucketUsage) Add(delta BucketUsage) BucketUsage {
+	return BucketUsage{Bytes: u.Bytes + delta.Bytes, Objects: u.Objects + delta.Objects}
+}
+
+// DedupStats describes the content-addressed store of deduplicated blobs.
+type DedupStats struct {
+	// Blobs is the number of distinct blobs in the store, References the number of objects sharing them.
+	Blobs      int64 `json:"blobs"`
+	References int64 `json:"references"`
+	// StoredBytes is the size of the distinct blobs, ReferencedBytes the size they would take if every object had
+	// its own copy, SavedBytes what sharing them saves.
+	StoredBytes     int64 `json:"stored_bytes"`
+	ReferencedBytes int64 `json:"referenced_bytes"`
+	SavedBytes      int64 `json:"saved_bytes"`
+}
+
+// StorageStats is the result of a scan of the whole storage.
+type StorageStats struct {
+	ScannedAt time.Time     `json:"scanned_at"`
+	Buckets   []BucketStats `json:"buckets"`
+	// BinBytes is the size of deleted objects and replaced blobs waiting in the bin to be removed.
+	BinBytes int64 `json:"bin_bytes"`
+}
+
+// BucketStats describes the objects and incomplete multipart uploads of a bucket. Prefixes break them down by
+// top-level prefix: the part of the key up to and including its first slash, empty for keys without one.
+type BucketStats struct {
+	Name string `json:"name"`
+	UsageStats
+
+	Prefixes []PrefixStats `json:"prefixes,omitempty"`
+}
+
+type PrefixStats struct {
+	Prefix string `json:"prefix"`
+	UsageStats
+}
+
+type UsageStats struct {
+	Objects int64 `json:"objects"`
+	Bytes   int64 `json:"bytes"`
+	// MultipartBytes is the size of the parts uploaded to incomplete multipart uploads.
+	MultipartBytes int64 `json:"multipart_bytes"`
+	// Largest and Oldest are the largest and the least recently modified objects, at most StatsTopObjects of each.
+	Largest []ObjectSummary `json:"largest"`
+	Oldest  []ObjectSummary `json:"oldest"`
+}
+
+// StatsTopObjects is how many of the largest and oldest objects UsageStats lists.
+const StatsTopObjects = 10
+
+// AddObject counts the object in and keeps it when it is among the largest or the oldest ones.
+func (s *UsageStats) AddObject(object ObjectSummary) {
+	s.Objects++
+	s.Bytes += object.Size
+	s.Largest = insertTop(s.Largest, object, func(a, b ObjectSummary) bool { return a.Size > b.Size })
+	s.Oldest = insertTop(s.Oldest, object, func(a, b ObjectSummary) bool { return a.LastModified.Before(b.LastModified) })
+}
+
+// insertTop inserts object into the list sorted by before, keeping at most StatsTopObjects of them.
+func insertTop(list []ObjectSummary, object ObjectSummary, before func(a, b ObjectSummary) bool) []ObjectSummary {
+	index, _ := slices.BinarySearchFunc(list, object, func(item, target ObjectSummary) int {
+		if before(target, item) {
+			return 1
+		}
+
+		return -1
+	})
+
+	if index >= StatsTopObjects {
+		return list
+	}
+
+	list = slices.Insert(list, index, object)
+
+	return list[:min(len(list), StatsTopObjects)]
+}
+
+type ObjectSummary struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// StorageRoot is a directory buckets are stored in, usually a disk of its own.
+type StorageRoot struct {
+	Path       string   `json:"path"`
+	TotalBytes uint64   `json:"total_bytes"`
+	FreeBytes  uint64   `json:"free_bytes"`
+	Buckets    []string `json:"buckets"`
+}
+
+// TransferStats are the bytes a user uploaded in request bodies and downloaded in response bodies of S3 requests.
+type TransferStats struct {
+	User            string `json:"user"`
+	UploadedBytes   int64  `json:"uploaded_bytes"`
+	DownloadedBytes int64  `json:"downloaded_bytes"`
+}
+
+// BucketEncryption is the default encryption of objects uploaded to a bucket without encryption headers.
+type BucketEncryption struct {
+	Algorithm string `yaml:"algorithm"`
+}
+
+// BucketLogging is the server access logging config of a bucket: access log objects are written to TargetBucket,
+// with keys starting with TargetPrefix.
+type BucketLogging struct {
+	TargetBucket string `yaml:"target_bucket"`
+	TargetPrefix string `yaml:"target_prefix"`
 }
 
 type PutObjectInput struct {
 	Reader      io.Reader
 	Metadata    ObjectMetadata
 	IfNoneMatch bool
+	// Encryption overrides the default encryption of the bucket.
+	Encryption *ServerSideEncryption
+	// BypassGovernance allows overwriting an object under GOVERNANCE retention.
+	BypassGovernance bool
 }
 
 type CopyDirective string
@@ -42,6 +373,14 @@ type CopyObjectInput struct {
 	ReplacementMeta   map[string]string
 	ContentType       string
 	IfNoneMatch       bool
+	// Encryption of the copy, it overrides the default encryption of the bucket. SSE-C sources must be unlocked
+	// with SetCustomerKey.
+	Encryption *ServerSideEncryption
+	// Retention and LegalHold of the copy, the lock of the source is not copied.
+	Retention *ObjectRetention
+	LegalHold bool
+	// BypassGovernance allows overwriting an object under GOVERNANCE retention.
+	BypassGovernance bool
 }
 
 type CopyObjectResult struct {
@@ -62,6 +401,14 @@ type ListV2Result struct {
 	IsTruncated       bool
 }
 
+type DeleteObjectsInput struct {
+	Keys []string
+	// Quiet leaves the successfully deleted keys out of the results.
+	Quiet bool
+	// BypassGovernance allows deleting objects under GOVERNANCE retention.
+	BypassGovernance bool
+}
+
 type DeleteResult struct {
 	Key   string
 	Error error
@@ -118,21 +465,198 @@ type ListMultipartUploadsResult struct {
 	MaxUploads         int
 }
 
+type AccessKeyStatus string
+
+const (
+	AccessKeyStatusActive   AccessKeyStatus = "Active"
+	AccessKeyStatusInactive AccessKeyStatus = "Inactive"
+)
+
+type AccessKey struct {
+	AccessKeyID     string          `json:"access_key_id"           yaml:"access_key_id"`
+	SecretAccessKey string          `json:"secret_access_key"       yaml:"secret_access_key"`
+	Status          AccessKeyStatus `json:"status"                  yaml:"status"`
+	CreatedAt       time.Time       `json:"created_at"              yaml:"created_at"`
+	ExpiresAt       *time.Time      `json:"expires_at,omitempty"    yaml:"expires_at,omitempty"`
+	LastUsedAt      *time.Time      `json:"last_used_at,omitempty"  yaml:"last_used_at,omitempty"`
+}
+
+// NewAccessKey generates an active access key with fresh credentials.
+func NewAccessKey(expiresAt *time.Time) AccessKey {
+	accessKeyID, secretAccessKey := credentials.GenerateCredentials()
+
+	return AccessKey{
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		Status:          AccessKeyStatusActive,
+		CreatedAt:       time.Now().UTC(),
+		ExpiresAt:       expiresAt,
+	}
+}
+
+// Usable reports whether the key can authenticate requests at the given time.
+func (k AccessKey) Usable(now time.Time) error {
+	if k.Status != AccessKeyStatusActive {
+		return fmt.Errorf("%w: %s", ErrAccessKeyInactive, k.AccessKeyID)
+	}
+
+	if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+		return fmt.Errorf("%w: %s", ErrAccessKeyExpired, k.AccessKeyID)
+	}
+
+	return nil
+}
+
 type User struct {
-	Name            string `json:"name"              yaml:"name"`
-	AccessKeyID     string `json:"access_key_id"     yaml:"access_key_id"`
-	SecretAccessKey string `json:"secret_access_key" yaml:"secret_access_key"`
+	Name       string      `json:"name"             yaml:"name"`
+	AccessKeys []AccessKey `json:"access_keys"      yaml:"access_keys"`
+	Limits     *RateLimits `json:"limits,omitempty" yaml:"limits,omitempty"`
+
+	// Session is set when the request was signed with temporary credentials.
+	Session *Session `json:"-" yaml:"-"`
 }
 
 func (u User) ARN() string {
 	return "arn:aws:iam:::user/" + u.Name
 }
 
+// AccessKey returns the user's key with the given ID.
+func (u User) AccessKey(accessKeyID string) (AccessKey, bool) {
+	return lo.Find(u.AccessKeys, func(key AccessKey) bool { return key.AccessKeyID == accessKeyID })
+}
+
 type PolicyBinding struct {
 	UserName string `json:"user_name" yaml:"user_name"`
 	PolicyID string `json:"policy_id" yaml:"policy_id"`
 }
 
+// Group lets several users share the policies bound to it.
+type Group struct {
+	Name    string   `json:"name"    yaml:"name"`
+	Members []string `json:"members" yaml:"members"`
+	// Limits apply to each member on its own, members do not share them.
+	Limits *RateLimits `json:"limits,omitempty" yaml:"limits,omitempty"`
+}
+
+// RequestClass groups S3 requests that are rate limited together.
+type RequestClass string
+
+const (
+	// RequestClassRead is GET and HEAD of objects.
+	RequestClassRead RequestClass = "read"
+	// RequestClassList is GET and HEAD of the service and of buckets, like ListBuckets and ListObjectsV2.
+	RequestClassList RequestClass = "list"
+	// RequestClassWrite is PUT and POST, like PutObject, CopyObject, multipart uploads and DeleteObjects.
+	RequestClassWrite RequestClass = "write"
+	// RequestClassDelete is DELETE of objects, buckets and multipart uploads.
+	RequestClassDelete RequestClass = "delete"
+)
+
+var RequestClasses = []RequestClass{ //nolint:gochecknoglobals
+	RequestClassRead, RequestClassList, RequestClassWrite, RequestClassDelete,
+}
+
+// RateLimits caps the load a user puts on d3. Zero and missing values are unlimited.
+type RateLimits struct {
+	RequestsPerSecond      map[RequestClass]float64 `json:"requests_per_second,omitempty"       yaml:"requests_per_second,omitempty"`       //nolint:lll
+	MaxConcurrentRequests  int                      `json:"max_concurrent_requests,omitempty"   yaml:"max_concurrent_requests,omitempty"`   //nolint:lll
+	UploadBytesPerSecond   int64                    `json:"upload_bytes_per_second,omitempty"   yaml:"upload_bytes_per_second,omitempty"`   //nolint:lll
+	DownloadBytesPerSecond int64                    `json:"download_bytes_per_second,omitempty" yaml:"download_bytes_per_second,omitempty"` //nolint:lll
+}
+
+func (l RateLimits) Validate() error {
+	for class, rps := range l.RequestsPerSecond {
+		if !lo.Contains(RequestClasses, class) {
+			return fmt.Errorf("%w: unknown request class %q", ErrRateLimitsInvalid, class)
+		}
+
+		if rps < 0 {
+			return fmt.Errorf("%w: negative rate for %s requests", ErrRateLimitsInvalid, class)
+		}
+	}
+
+	if l.MaxConcurrentRequests < 0 || l.UploadBytesPerSecond < 0 || l.DownloadBytesPerSecond < 0 {
+		return fmt.Errorf("%w: negative limit", ErrRateLimitsInvalid)
+	}
+
+	return nil
+}
+
+type GroupBinding struct {
+	GroupName string `json:"group_name" yaml:"group_name"`
+	PolicyID  string `json:"policy_id"  yaml:"policy_id"`
+}
+
+// Role is a set of policies that trusted users can assume with AssumeRole to get temporary credentials.
+// Admin may assume any role.
+type Role struct {
+	Name         string   `json:"name"          yaml:"name"`
+	PolicyIDs    []string `json:"policy_ids"    yaml:"policy_ids"`
+	TrustedUsers []string `json:"trusted_users" yaml:"trusted_users"`
+	// WebIdentityConditions let holders of a web identity token assume the role with AssumeRoleWithWebIdentity.
+	// Each condition maps claim names to wildcard patterns; a token matching every pattern of any condition is
+	// trusted.
+	WebIdentityConditions []map[string]string `json:"web_identity_conditions,omitempty" yaml:"web_identity_conditions,omitempty"` //nolint:lll
+	// Limits apply to the web identity sessions of the role, each subject on its own. Sessions of users share the
+	// limits of the user.
+	Limits *RateLimits `json:"limits,omitempty" yaml:"limits,omitempty"`
+}
+
+func (r Role) ARN() string {
+	return "arn:aws:iam:::role/" + r.Name
+}
+
+// Validate checks the role is well-formed, references to policies and users are checked by the backends.
+// An empty web identity condition would trust any token, so it is rejected.
+func (r Role) Validate() error {
+	if r.Name == "" {
+		return ErrRoleInvalid
+	}
+
+	for _, condition := range r.WebIdentityConditions {
+		if len(condition) == 0 || lo.HasKey(condition, "") {
+			return fmt.Errorf("%w: empty web identity condition", ErrRoleInvalid)
+		}
+	}
+
+	return lo.FromPtr(r.Limits).Validate()
+}
+
+// Session holds temporary credentials issued by AssumeRole or AssumeRoleWithWebIdentity. Requests signed with
+// them are authorized by the role's policies, narrowed down by the optional session policy. UserName is set
+// for sessions issued to users, Subject to the sub claim of the token for web identity sessions.
+type Session struct {
+	AccessKeyID     string            `json:"access_key_id"`
+	SecretAccessKey string            `json:"secret_access_key"`
+	SessionToken    string            `json:"session_token"`
+	RoleName        string            `json:"role_name"`
+	SessionName     string            `json:"session_name"`
+	UserName        string            `json:"user_name,omitempty"`
+	Subject         string            `json:"subject,omitempty"`
+	Policy          *iampol.IAMPolicy `json:"policy,omitempty"`
+	ExpiresAt       time.Time         `json:"expires_at"`
+}
+
+// ARN identifies the assumed role session, the way AWS reports it.
+func (s *Session) ARN() string {
+	return "arn:aws:sts:::assumed-role/" + s.RoleName + "/" + s.SessionName
+}
+
+// User returns the identity requests signed with the session's credentials act as. Its name can never
+// collide with a real user's, so it is never mistaken for admin.
+func (s *Session) User() *User {
+	return &User{
+		Name: "assumed-role/" + s.RoleName + "/" + s.SessionName,
+		AccessKeys: []AccessKey{{
+			AccessKeyID:     s.AccessKeyID,
+			SecretAccessKey: s.SecretAccessKey,
+			Status:          AccessKeyStatusActive,
+			ExpiresAt:       &s.ExpiresAt,
+		}},
+		Session: s,
+	}
+}
+
 type Bucket interface { //nolint:interfacebloat
 	Name() string
 	ARN() string
@@ -144,10 +668,14 @@ type Bucket interface { //nolint:interfacebloat
 	CopyObject(ctx context.Context, dstKey string, input CopyObjectInput) (*CopyObjectResult, error)
 	GetObject(ctx context.Context, key string) (Object, error)
 	ListObjectsV2(ctx context.Context, input ListObjectsV2Input) (*ListV2Result, error)
-	DeleteObjects(ctx context.Context, quiet bool, keys ...string) ([]DeleteResult, error)
-
-	CreateMultipartUpload(ctx context.Context, key string, metadata ObjectMetadata) (string, error)
-	UploadPart(ctx context.Context, key string, uploadID string, partNumber int, body io.Reader) (string, error)
+	DeleteObjects(ctx context.Context, input DeleteObjectsInput) ([]DeleteResult, error)
+
+	// CreateMultipartUpload starts an upload, encryption overrides the default encryption of the bucket.
+	CreateMultipartUpload(ctx context.Context, key string, metadata ObjectMetadata,
+		encryption *ServerSideEncryption) (string, error)
+	// UploadPart stores a part, encryption must carry the customer key of SSE-C uploads.
+	UploadPart(ctx context.Context, key string, uploadID string, partNumber int, body io.Reader,
+		encryption *ServerSideEncryption) (string, error)
 	CompleteMultipartUpload(ctx context.Context, key string,
 		uploadID string, parts []CompletePart) (*ObjectMetadata, error)
 	AbortMultipartUpload(ctx context.Context, key string, uploadID string) error
@@ -156,6 +684,40 @@ type Bucket interface { //nolint:interfacebloat
 
 	PutObjectTagging(ctx context.Context, key string, tags map[string]string) error
 	DeleteObjectTagging(ctx context.Context, key string) error
+
+	// PutObjectRetention replaces the retention of an object, nil removes it. Active COMPLIANCE retention can only
+	// be extended, active GOVERNANCE retention can only be shortened, removed or have its mode changed with
+	// bypassGovernance. Object Lock must be enabled for the bucket.
+	PutObjectRetention(ctx context.Context, key string, retention *ObjectRetention, bypassGovernance bool) error
+	// PutObjectLegalHold places or removes the legal hold of an object, Object Lock must be enabled for the bucket.
+	PutObjectLegalHold(ctx context.Context, key string, hold bool) error
+
+	// Logging returns the server access logging config the bucket had when it was looked up, nil when logging
+	// is disabled.
+	Logging() *BucketLogging
+	// PutLogging replaces the server access logging config, nil disables logging.
+	PutLogging(ctx context.Context, logging *BucketLogging) error
+	// Encryption returns the default encryption the bucket had when it was looked up, nil when there is none.
+	Encryption() *BucketEncryption
+	// PutEncryption replaces the default encryption, nil removes it.
+	PutEncryption(ctx context.Context, encryption *BucketEncryption) error
+	// Compression returns the compression override the bucket had when it was looked up, nil when it has none.
+	Compression() *BucketCompression
+	// PutCompression replaces the compression override, nil removes it. Existing objects are left as they are.
+	PutCompression(ctx context.Context, compression *BucketCompression) error
+	// ObjectLock returns the Object Lock config the bucket had when it was looked up, nil when Object Lock is not
+	// enabled.
+	ObjectLock() *BucketObjectLock
+	// PutObjectLock enables Object Lock and replaces the default retention. Existing objects are left as they are.
+	PutObjectLock(ctx context.Context, lock BucketObjectLock) error
+	// Quota returns the quota the bucket had when it was looked up, nil when it has none.
+	Quota() *BucketQuota
+	// PutQuota replaces the quota, nil removes it. Objects already over the quota are left as they are.
+	PutQuota(ctx context.Context, quota *BucketQuota) error
+	// Usage returns the usage of the bucket, kept up to date by every write and delete.
+	Usage(ctx context.Context) (*BucketUsage, error)
+	// RescanUsage recomputes the usage from the objects of the bucket, fixing any drift of the tracked one.
+	RescanUsage(ctx context.Context) (*BucketUsage, error)
 }
 
 type Object interface {
@@ -165,6 +727,8 @@ type Object interface {
 	LastModified() time.Time
 	Size() int64
 	Metadata() *ObjectMetadata
+	// SetCustomerKey provides the key of an SSE-C object, it must be called before the object is read.
+	SetCustomerKey(key []byte) error
 }
 
 type StorageBackend interface {
@@ -172,15 +736,36 @@ type StorageBackend interface {
 	CreateBucket(ctx context.Context, name string) error
 	DeleteBucket(ctx context.Context, name string) error
 	HeadBucket(ctx context.Context, name string) (Bucket, error)
+	// DedupStats describes the deduplicated blobs, they are counted even when deduplication is disabled.
+	DedupStats(ctx context.Context) (*DedupStats, error)
+	// StorageStats scans all buckets, it reads the metadata of every object and takes as long as that does.
+	StorageStats(ctx context.Context) (*StorageStats, error)
+	// StorageRoots describes the directories buckets are stored in.
+	StorageRoots(ctx context.Context) ([]StorageRoot, error)
+	// MoveBucket copies the bucket to another storage root and removes it from its current one. The bucket stays
+	// readable during the move, changes to it fail with ErrBucketMoving until the move is done.
+	MoveBucket(ctx context.Context, name, root string) error
 }
 
 type ManagementBackend interface { //nolint:interfacebloat
 	GetUsers(ctx context.Context) ([]string, error)
 	GetUserByName(ctx context.Context, name string) (*User, error)
+	// GetUserByAccessKeyID returns the owner of a usable key: unknown keys yield ErrUserNotFound,
+	// inactive and expired ones ErrAccessKeyInactive and ErrAccessKeyExpired.
 	GetUserByAccessKeyID(ctx context.Context, accessKeyID string) (*User, error)
+	// CreateUser creates a user with a single active access key.
 	CreateUser(ctx context.Context, name string) (*User, error)
-	UpdateUser(ctx context.Context, user *User) error
 	DeleteUser(ctx context.Context, name string) error
+	// SetUserLimits replaces the rate limits of the user, nil removes them.
+	SetUserLimits(ctx context.Context, name string, limits *RateLimits) error
+
+	CreateAccessKey(ctx context.Context, userName string, expiresAt *time.Time) (*AccessKey, error)
+	SetAccessKeyStatus(ctx context.Context, userName string, accessKeyID string, status AccessKeyStatus) error
+	DeleteAccessKey(ctx context.Context, userName string, accessKeyID string) error
+	// ReplaceAccessKeys replaces all the access keys of the user with a new active one in a single change.
+	ReplaceAccessKeys(ctx context.Context, userName string) (*AccessKey, error)
+	// TouchAccessKey records when a key was last used to authenticate a request.
+	TouchAccessKey(ctx context.Context, accessKeyID string, usedAt time.Time) error
 
 	GetPolicies(ctx context.Context) ([]string, error)
 	GetPolicyByID(ctx context.Context, id string) (*iampol.IAMPolicy, error)
@@ -193,14 +778,186 @@ type ManagementBackend interface { //nolint:interfacebloat
 	GetBindingsByPolicy(ctx context.Context, policyID string) ([]*PolicyBinding, error)
 	CreateBinding(ctx context.Context, binding *PolicyBinding) error
 	DeleteBinding(ctx context.Context, binding *PolicyBinding) error
+
+	GetGroups(ctx context.Context) ([]string, error)
+	GetGroupByName(ctx context.Context, name string) (*Group, error)
+	// GetGroupsByUser returns the names of the groups the user is a member of.
+	GetGroupsByUser(ctx context.Context, userName string) ([]string, error)
+	CreateGroup(ctx context.Context, name string) error
+	// DeleteGroup deletes the group together with its bindings.
+	DeleteGroup(ctx context.Context, name string) error
+	AddGroupMember(ctx context.Context, groupName string, userName string) error
+	RemoveGroupMember(ctx context.Context, groupName string, userName string) error
+	// SetGroupLimits replaces the rate limits of the group's members, nil removes them.
+	SetGroupLimits(ctx context.Context, name string, limits *RateLimits) error
+
+	GetGroupBindings(ctx context.Context) ([]*GroupBinding, error)
+	GetBindingsByGroup(ctx context.Context, groupName string) ([]*GroupBinding, error)
+	CreateGroupBinding(ctx context.Context, binding *GroupBinding) error
+	DeleteGroupBinding(ctx context.Context, binding *GroupBinding) error
+
+	GetRoles(ctx context.Context) ([]string, error)
+	GetRoleByName(ctx context.Context, name string) (*Role, error)
+	CreateRole(ctx context.Context, role *Role) error
+	DeleteRole(ctx context.Context, name string) error
+	// SetRoleLimits replaces the rate limits of the role's web identity sessions, nil removes them.
+	SetRoleLimits(ctx context.Context, name string, limits *RateLimits) error
+
+	// ApplyChanges applies the changes in order, all of them or none. Like the single resource methods, creates
+	// fail if the resource exists, updates and deletes if it does not.
+	ApplyChanges(ctx context.Context, changes []ManagementChange) error
 }
 
 type Locker interface {
 	Lock(ctx context.Context, key string) (context.Context, context.CancelFunc, error)
 }
 
+// Notifier broadcasts messages to every d3 instance connected to the same Redis server.
+// Delivery is best effort: subscribers that are disconnected when a message is published never receive it.
+type Notifier interface {
+	Publish(ctx context.Context, channel string, message string) error
+	// Subscribe calls handler for every message published to channel until ctx is done or the subscription
+	// breaks. It blocks, and always returns a non-nil error.
+	Subscribe(ctx context.Context, channel string, handler func(message string)) error
+}
+
+// WebIdentityVerifier validates the tokens exchanged for temporary credentials with AssumeRoleWithWebIdentity.
+type WebIdentityVerifier interface {
+	// Verify returns the token's claims. It returns ErrWebIdentityTokenInvalid for tokens it does not accept and
+	// ErrWebIdentityDisabled if no identity provider is configured.
+	Verify(ctx context.Context, token string) (jwt.Claims, error)
+}
+
+// SessionStore keeps temporary credentials until they expire.
+type SessionStore interface {
+	Put(ctx context.Context, session *Session) error
+	// Get returns ErrSessionNotFound for unknown and expired sessions.
+	Get(ctx context.Context, accessKeyID string) (*Session, error)
+}
+
+// AuditLog is an append-only record of management changes and sensitive S3 requests.
+type AuditLog interface {
+	Record(ctx context.Context, event *AuditEvent) error
+	// Query returns the recorded events matching the query, oldest first. It returns ErrAuditLogDisabled if no
+	// audit log sink is configured.
+	Query(ctx context.Context, query AuditQuery) ([]AuditEvent, error)
+}
+
 // Authorizer decides if a user is allowed to perform an action on a resource.
 // The key is the S3 resource identifier: bucket name for bucket operations, or "bucket/key" for object operations.
 type Authorizer interface {
 	IsAllowed(ctx context.Context, user *User, action s3actions.Action, key string) (bool, error)
+	// Evaluate is IsAllowed that also tells which policy statement decided.
+	Evaluate(ctx context.Context, user *User, action s3actions.Action, key string) (*AuthorizationDecision, error)
+}
+
+// AuthorizationReason tells what decided an authorization check.
+type AuthorizationReason string
+
+const (
+	AuthorizationReasonAdmin         AuthorizationReason = "admin"
+	AuthorizationReasonAnonymous     AuthorizationReason = "anonymous"
+	AuthorizationReasonExplicitAllow AuthorizationReason = "explicit allow"
+	AuthorizationReasonExplicitDeny  AuthorizationReason = "explicit deny"
+	AuthorizationReasonImplicitDeny  AuthorizationReason = "implicit deny"
+	AuthorizationReasonAuditLog      AuthorizationReason = "append-only audit log"
+)
+
+// AuthorizationDecision is the outcome of an authorization check. PolicyID and Statement are set for explicit
+// allows and denies, PolicyID is empty when the statement comes from a session policy.
+type AuthorizationDecision struct {
+	Allowed   bool                `json:"allowed"`
+	Reason    AuthorizationReason `json:"reason"`
+	PolicyID  string              `json:"policy_id,omitempty"`
+	Statement *iampol.Statement   `json:"statement,omitempty"`
+}
+
+// PolicySimulation asks how requests of the user would be authorized, for every action and resource pair.
+// Resources are S3 resource identifiers, optionally prefixed with "arn:aws:s3:::".
+type PolicySimulation struct {
+	UserName  string             `json:"user_name"`
+	Actions   []s3actions.Action `json:"actions"`
+	Resources []string           `json:"resources"`
+	// SecureTransport is the aws:SecureTransport condition key value of the simulated requests.
+	SecureTransport bool `json:"secure_transport,omitempty"`
+}
+
+// PolicySimulationResult is the decision for one action and resource pair of a PolicySimulation.
+type PolicySimulationResult struct {
+	Action   s3actions.Action `json:"action"`
+	Resource string           `json:"resource"`
+
+	AuthorizationDecision
+}
+
+type ManagementChangeOperation string
+
+const (
+	ManagementChangeCreate ManagementChangeOperation = "create"
+	ManagementChangeUpdate ManagementChangeOperation = "update"
+	ManagementChangeDelete ManagementChangeOperation = "delete"
+)
+
+type ManagementResourceKind string
+
+const (
+	ManagementResourceUser    ManagementResourceKind = "user"
+	ManagementResourcePolicy  ManagementResourceKind = "policy"
+	ManagementResourceBinding ManagementResourceKind = "binding"
+)
+
+// ManagementChange is a single step of a declarative apply. The field matching Kind holds the desired resource:
+// User for users, Policy for policies and Binding for bindings. Deletes of users and policies only need Name.
+type ManagementChange struct {
+	Operation ManagementChangeOperation `json:"operation"`
+	Kind      ManagementResourceKind    `json:"kind"`
+	// Name is the user name, the policy ID, or "user/policy" for bindings.
+	Name string `json:"name"`
+	// GeneratedAccessKey is the key created for a new user that was declared without access keys.
+	GeneratedAccessKey *AccessKey `json:"generated_access_key,omitempty"`
+
+	User    *User             `json:"-"`
+	Policy  *iampol.IAMPolicy `json:"-"`
+	Binding *PolicyBinding    `json:"-"`
+}
+
+type AuditOutcome string
+
+const (
+	AuditOutcomeSuccess AuditOutcome = "success"
+	AuditOutcomeDenied  AuditOutcome = "denied"
+	AuditOutcomeFailure AuditOutcome = "failure"
+)
+
+// AuditEvent records who performed an action on a resource and how it ended. Actions are S3 actions such as
+// s3:DeleteObject, or management API operations such as d3:CreateUser.
+type AuditEvent struct {
+	Time      time.Time    `json:"time"`
+	User      string       `json:"user"`
+	Action    string       `json:"action"`
+	Resource  string       `json:"resource"`
+	RemoteIP  string       `json:"remote_ip"`
+	RequestID string       `json:"request_id,omitempty"`
+	Outcome   AuditOutcome `json:"outcome"`
+	Status    int          `json:"status"`
+	Error     string       `json:"error,omitempty"`
+	// Source is the copied object of copy requests.
+	Source string `json:"source,omitempty"`
+}
+
+// AuditQuery selects audit events. Zero fields match everything, From is inclusive and To exclusive.
+type AuditQuery struct {
+	From   time.Time
+	To     time.Time
+	User   string
+	Action string
+	// Limit caps the number of returned events, keeping the oldest ones.
+	Limit int
+}
+
+func (q AuditQuery) Matches(event AuditEvent) bool {
+	return (q.From.IsZero() || !event.Time.Before(q.From)) &&
+		(q.To.IsZero() || event.Time.Before(q.To)) &&
+		(q.User == "" || event.User == q.User) &&
+		(q.Action == "" || event.Action == q.Action)
 }
@@ -112,3 +112,156 @@ func (_c *MockLocker_Lock_Call) RunAndReturn(run func(ctx context.Context, key s
 	_c.Call.Return(run)
 	return _c
 }
+
+// NewMockNotifier creates a new instance of MockNotifier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockNotifier(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockNotifier {
+	mock := &MockNotifier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockNotifier is an autogenerated mock type for the Notifier type
+type MockNotifier struct {
+	mock.Mock
+}
+
+type MockNotifier_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockNotifier) EXPECT() *MockNotifier_Expecter {
+	return &MockNotifier_Expecter{mock: &_m.Mock}
+}
+
+// Publish provides a mock function for the type MockNotifier
+func (_mock *MockNotifier) Publish(ctx context.Context, channel string, message string) error {
+	ret := _mock.Called(ctx, channel, message)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Publish")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, channel, message)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockNotifier_Publish_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Publish'
+type MockNotifier_Publish_Call struct {
+	*mock.Call
+}
+
+// Publish is a helper method to define mock.On call
+//   - ctx context.Context
+//   - channel string
+//   - message string
+func (_e *MockNotifier_Expecter) Publish(ctx interface{}, channel interface{}, message interface{}) *MockNotifier_Publish_Call {
+	return &MockNotifier_Publish_Call{Call: _e.mock.On("Publish", ctx, channel, message)}
+}
+
+func (_c *MockNotifier_Publish_Call) Run(run func(ctx context.Context, channel string, message string)) *MockNotifier_Publish_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockNotifier_Publish_Call) Return(err error) *MockNotifier_Publish_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockNotifier_Publish_Call) RunAndReturn(run func(ctx context.Context, channel string, message string) error) *MockNotifier_Publish_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Subscribe provides a mock function for the type MockNotifier
+func (_mock *MockNotifier) Subscribe(ctx context.Context, channel string, handler func(message string)) error {
+	ret := _mock.Called(ctx, channel, handler)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Subscribe")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, func(message string)) error); ok {
+		r0 = returnFunc(ctx, channel, handler)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockNotifier_Subscribe_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Subscribe'
+type MockNotifier_Subscribe_Call struct {
+	*mock.Call
+}
+
+// Subscribe is a helper method to define mock.On call
+//   - ctx context.Context
+//   - channel string
+//   - handler func(message string)
+func (_e *MockNotifier_Expecter) Subscribe(ctx interface{}, channel interface{}, handler interface{}) *MockNotifier_Subscribe_Call {
+	return &MockNotifier_Subscribe_Call{Call: _e.mock.On("Subscribe", ctx, channel, handler)}
+}
+
+func (_c *MockNotifier_Subscribe_Call) Run(run func(ctx context.Context, channel string, handler func(message string))) *MockNotifier_Subscribe_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 func(message string)
+		if args[2] != nil {
+			arg2 = args[2].(func(message string))
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockNotifier_Subscribe_Call) Return(err error) *MockNotifier_Subscribe_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockNotifier_Subscribe_Call) RunAndReturn(run func(ctx context.Context, channel string, handler func(message string)) error) *MockNotifier_Subscribe_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/zhulik/d3/pkg/credentials"
@@ -52,27 +53,36 @@ func ValidateUploadID(uploadID string) error {
 	return nil
 }
 
-func ValidateAdminUser(user *User) error {
-	if user == nil {
-		return fmt.Errorf("%w: admin user is nil", ErrInvalidAdminCredentials)
+// ValidateAccessKeyExpiry rejects expiry times of new access keys that are not in the future.
+func ValidateAccessKeyExpiry(expiresAt *time.Time) error {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return fmt.Errorf("%w: expires_at %s is not in the future", ErrAccessKeyInvalid, expiresAt.Format(time.RFC3339))
 	}
 
-	if user.AccessKeyID == "" {
+	return nil
+}
+
+func ValidateAdminAccessKey(key *AccessKey) error {
+	if key == nil {
+		return fmt.Errorf("%w: admin access key is nil", ErrInvalidAdminCredentials)
+	}
+
+	if key.AccessKeyID == "" {
 		return fmt.Errorf("%w: access_key_id is empty", ErrInvalidAdminCredentials)
 	}
 
-	if len(user.AccessKeyID) != credentials.AccessKeyIDLength {
+	if len(key.AccessKeyID) != credentials.AccessKeyIDLength {
 		return fmt.Errorf("%w: access_key_id must be %d characters, got %d",
-			ErrInvalidAdminCredentials, credentials.AccessKeyIDLength, len(user.AccessKeyID))
+			ErrInvalidAdminCredentials, credentials.AccessKeyIDLength, len(key.AccessKeyID))
 	}
 
-	if user.SecretAccessKey == "" {
+	if key.SecretAccessKey == "" {
 		return fmt.Errorf("%w: secret_access_key is empty", ErrInvalidAdminCredentials)
 	}
 
-	if len(user.SecretAccessKey) != credentials.SecretAccessKeyLength {
+	if len(key.SecretAccessKey) != credentials.SecretAccessKeyLength {
 		return fmt.Errorf("%w: secret_access_key must be %d characters, got %d",
-			ErrInvalidAdminCredentials, credentials.SecretAccessKeyLength, len(user.SecretAccessKey))
+			ErrInvalidAdminCredentials, credentials.SecretAccessKeyLength, len(key.SecretAccessKey))
 	}
 
 	return nil
@@ -0,0 +1,51 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/redis/rueidis"
+	"github.com/zhulik/d3/internal/core"
+)
+
+type Notifier struct {
+	Config *core.Config
+
+	client rueidis.Client
+}
+
+func (n *Notifier) Init(_ context.Context) error {
+	client, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress: []string{n.Config.RedisAddress},
+		Username:    n.Config.RedisUsername,
+		Password:    n.Config.RedisPassword,
+	})
+	if err != nil {
+		return err
+	}
+
+	n.client = client
+
+	return nil
+}
+
+func (n *Notifier) Shutdown(_ context.Context) error {
+	n.client.Close()
+
+	return nil
+}
+
+func (n *Notifier) Publish(ctx context.Context, channel string, message string) error {
+	return n.client.Do(ctx, n.client.B().Publish().Channel(channel).Message(message).Build()).Error()
+}
+
+func (n *Notifier) Subscribe(ctx context.Context, channel string, handler func(message string)) error {
+	err := n.client.Receive(ctx, n.client.B().Subscribe().Channel(channel).Build(), func(msg rueidis.PubSubMessage) {
+		handler(msg.Message)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Receive returns nil when the subscription is closed from the server side
+	return rueidis.ErrClosing
+}
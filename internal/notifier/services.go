@@ -0,0 +1,10 @@
+package notifier
+
+import (
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/pal"
+)
+
+func Provide() pal.ServiceDef {
+	return pal.Provide[core.Notifier](&Notifier{})
+}
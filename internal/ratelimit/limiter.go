@@ -0,0 +1,305 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// idleTimeout is how long the state of a principal without requests is kept. Dropping it resets its counters.
+	idleTimeout = 10 * time.Minute
+	// minByteBurst keeps byte throttling from splitting reads and writes into tiny pieces under low rates.
+	minByteBurst = 32 * 1024
+	// limitsTTL is how long the limits a user gets from its groups, its role or through a session are cached.
+	// Changes to them take up to that long to apply.
+	limitsTTL = 10 * time.Second
+)
+
+// Stats are the counters of a principal since its state was created.
+type Stats struct {
+	Principal string `json:"principal"`
+	InFlight  int    `json:"in_flight"`
+	Admitted  uint64 `json:"admitted"`
+	// RateLimited counts the requests rejected by RequestsPerSecond, ConcurrencyLimited the ones rejected by
+	// MaxConcurrentRequests.
+	RateLimited        uint64 `json:"rate_limited"`
+	ConcurrencyLimited uint64 `json:"concurrency_limited"`
+}
+
+// Limiter enforces rate limits on the requests of principals: users or the IPs of anonymous clients. The state is
+// kept in memory, every d3 instance enforces the limits on its own.
+type Limiter struct {
+	ManagementBackend core.ManagementBackend
+
+	mu         sync.Mutex
+	principals map[string]*principal
+	limits     map[string]cachedLimits
+}
+
+type cachedLimits struct {
+	limits    core.RateLimits
+	expiresAt time.Time
+}
+
+type principal struct {
+	limits   core.RateLimits
+	requests map[core.RequestClass]*rate.Limiter
+	upload   *rate.Limiter
+	download *rate.Limiter
+	lastSeen time.Time
+	stats    Stats
+}
+
+func (l *Limiter) Init(_ context.Context) error {
+	l.principals = map[string]*principal{}
+	l.limits = map[string]cachedLimits{}
+
+	return nil
+}
+
+// Run drops the state of idle principals until ctx is done.
+func (l *Limiter) Run(ctx context.Context) error {
+	ticker := time.NewTicker(idleTimeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			l.evict(now)
+		}
+	}
+}
+
+// Limits returns the limits of the user: its own ones, or the strictest of its groups' ones when it has none.
+// Sessions share the limits of the user that assumed the role, the returned principal is the name of that user.
+// Web identity sessions get the limits of their role, the principal is the role and the token's subject: the
+// session name is picked by the caller, so it cannot tell callers apart. Limits that need management backend
+// lookups are cached for limitsTTL.
+func (l *Limiter) Limits(ctx context.Context, user *core.User) (string, core.RateLimits, error) {
+	name := user.Name
+
+	switch {
+	case user.Session != nil && user.Session.UserName != "":
+		name = user.Session.UserName
+	case user.Session != nil:
+		name = "role/" + user.Session.RoleName + "/" + user.Session.Subject
+	case user.Limits != nil:
+		return name, *user.Limits, nil
+	}
+
+	l.mu.Lock()
+	cached, ok := l.limits[name]
+	l.mu.Unlock()
+
+	now := time.Now()
+	if ok && now.Before(cached.expiresAt) {
+		return name, cached.limits, nil
+	}
+
+	limits, err := l.loadLimits(ctx, user)
+	if err != nil {
+		return "", core.RateLimits{}, err
+	}
+
+	l.mu.Lock()
+	l.limits[name] = cachedLimits{limits: limits, expiresAt: now.Add(limitsTTL)}
+	l.mu.Unlock()
+
+	return name, limits, nil
+}
+
+// loadLimits reads the limits of Limits from the management backend.
+func (l *Limiter) loadLimits(ctx context.Context, user *core.User) (core.RateLimits, error) {
+	if user.Session != nil && user.Session.UserName == "" {
+		role, err := l.ManagementBackend.GetRoleByName(ctx, user.Session.RoleName)
+		if err != nil {
+			if errors.Is(err, core.ErrRoleNotFound) {
+				// Deleting a role revokes its sessions, the authorizer denies their requests.
+				return core.RateLimits{}, nil
+			}
+
+			return core.RateLimits{}, err
+		}
+
+		return lo.FromPtr(role.Limits), nil
+	}
+
+	if user.Session != nil {
+		var err error
+
+		user, err = l.ManagementBackend.GetUserByName(ctx, user.Session.UserName)
+		if err != nil {
+			return core.RateLimits{}, err
+		}
+
+		if user.Limits != nil {
+			return *user.Limits, nil
+		}
+	}
+
+	groupNames, err := l.ManagementBackend.GetGroupsByUser(ctx, user.Name)
+	if err != nil {
+		return core.RateLimits{}, err
+	}
+
+	var limits core.RateLimits
+
+	for _, name := range groupNames {
+		group, err := l.ManagementBackend.GetGroupByName(ctx, name)
+		if err != nil {
+			return core.RateLimits{}, err
+		}
+
+		limits = strictest(limits, lo.FromPtr(group.Limits))
+	}
+
+	return limits, nil
+}
+
+// Acquire admits a request of the class made by the principal, or returns core.ErrSlowDown. The permit must be
+// released when the request is done.
+func (l *Limiter) Acquire(name string, limits core.RateLimits, class core.RequestClass) (*Permit, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p := l.principal(name, limits)
+	p.lastSeen = time.Now()
+
+	if limits.MaxConcurrentRequests > 0 && p.stats.InFlight >= limits.MaxConcurrentRequests {
+		p.stats.ConcurrencyLimited++
+
+		return nil, fmt.Errorf("%w: too many concurrent requests", core.ErrSlowDown)
+	}
+
+	if limiter := p.requests[class]; limiter != nil && !limiter.Allow() {
+		p.stats.RateLimited++
+
+		return nil, fmt.Errorf("%w: too many %s requests", core.ErrSlowDown, class)
+	}
+
+	p.stats.InFlight++
+	p.stats.Admitted++
+
+	return &Permit{limiter: l, principal: p, upload: p.upload, download: p.download}, nil
+}
+
+// Stats returns the counters of the principals, sorted by name.
+func (l *Limiter) Stats() []Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := make([]Stats, 0, len(l.principals))
+	for _, p := range l.principals {
+		stats = append(stats, p.stats)
+	}
+
+	slices.SortFunc(stats, func(a, b Stats) int { return strings.Compare(a.Principal, b.Principal) })
+
+	return stats
+}
+
+// principal returns the state of the principal, created or updated to the limits. Changed limits start with
+// full buckets.
+func (l *Limiter) principal(name string, limits core.RateLimits) *principal {
+	p, ok := l.principals[name]
+	if !ok {
+		p = &principal{stats: Stats{Principal: name}}
+		l.principals[name] = p
+	}
+
+	if ok && reflect.DeepEqual(p.limits, limits) {
+		return p
+	}
+
+	p.limits = limits
+	p.requests = map[core.RequestClass]*rate.Limiter{}
+
+	for class, rps := range limits.RequestsPerSecond {
+		if rps > 0 {
+			p.requests[class] = rate.NewLimiter(rate.Limit(rps), max(1, int(math.Ceil(rps))))
+		}
+	}
+
+	p.upload = bytesLimiter(limits.UploadBytesPerSecond)
+	p.download = bytesLimiter(limits.DownloadBytesPerSecond)
+
+	return p
+}
+
+func (l *Limiter) release(p *principal) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p.stats.InFlight--
+	p.lastSeen = time.Now()
+}
+
+func (l *Limiter) evict(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for name, p := range l.principals {
+		if p.stats.InFlight == 0 && now.Sub(p.lastSeen) > idleTimeout {
+			delete(l.principals, name)
+		}
+	}
+
+	for name, cached := range l.limits {
+		if now.After(cached.expiresAt) {
+			delete(l.limits, name)
+		}
+	}
+}
+
+func bytesLimiter(bytesPerSecond int64) *rate.Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), int(max(bytesPerSecond, minByteBurst)))
+}
+
+// strictest combines two sets of limits into the lowest of each, zero values are unlimited.
+func strictest(a, b core.RateLimits) core.RateLimits {
+	limits := core.RateLimits{
+		MaxConcurrentRequests:  lowest(a.MaxConcurrentRequests, b.MaxConcurrentRequests),
+		UploadBytesPerSecond:   lowest(a.UploadBytesPerSecond, b.UploadBytesPerSecond),
+		DownloadBytesPerSecond: lowest(a.DownloadBytesPerSecond, b.DownloadBytesPerSecond),
+	}
+
+	for _, class := range core.RequestClasses {
+		if rps := lowest(a.RequestsPerSecond[class], b.RequestsPerSecond[class]); rps > 0 {
+			if limits.RequestsPerSecond == nil {
+				limits.RequestsPerSecond = map[core.RequestClass]float64{}
+			}
+
+			limits.RequestsPerSecond[class] = rps
+		}
+	}
+
+	return limits
+}
+
+func lowest[T int | int64 | float64](a, b T) T {
+	switch {
+	case a <= 0:
+		return b
+	case b <= 0:
+		return a
+	default:
+		return min(a, b)
+	}
+}
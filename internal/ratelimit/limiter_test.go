@@ -0,0 +1,188 @@
+package ratelimit_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/backends/management/sqlite"
+	"github.com/zhulik/d3/internal/backends/storage/storagetest"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/internal/ratelimit"
+)
+
+var _ = Describe("Limiter", func() {
+	var (
+		backend *sqlite.Backend
+		limiter *ratelimit.Limiter
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		dir := GinkgoT().TempDir()
+
+		backend = &sqlite.Backend{
+			Config: &core.Config{
+				ManagementBackendSQLitePath: filepath.Join(dir, "management.db"),
+				ManagementBackendYAMLPath:   filepath.Join(dir, "management.yaml"),
+				Environment:                 "test",
+			},
+			Locker: storagetest.NewLocker(),
+			Logger: slog.New(slog.DiscardHandler),
+		}
+		Expect(backend.Init(ctx)).To(Succeed())
+		DeferCleanup(backend.Shutdown)
+
+		limiter = &ratelimit.Limiter{ManagementBackend: backend}
+		Expect(limiter.Init(ctx)).To(Succeed())
+	})
+
+	Describe("Acquire", func() {
+		It("rejects requests over the rate of their class", func() {
+			limits := core.RateLimits{RequestsPerSecond: map[core.RequestClass]float64{core.RequestClassWrite: 2}}
+
+			for range 2 {
+				lo.Must(limiter.Acquire("alice", limits, core.RequestClassWrite)).Release()
+			}
+
+			_, err := limiter.Acquire("alice", limits, core.RequestClassWrite)
+			Expect(err).To(MatchError(core.ErrSlowDown))
+
+			lo.Must(limiter.Acquire("alice", limits, core.RequestClassRead)).Release()
+			lo.Must(limiter.Acquire("bob", limits, core.RequestClassWrite)).Release()
+
+			Expect(limiter.Stats()).To(Equal([]ratelimit.Stats{
+				{Principal: "alice", Admitted: 3, RateLimited: 1},
+				{Principal: "bob", Admitted: 1},
+			}))
+		})
+
+		It("rejects requests over the concurrency limit until one is released", func() {
+			limits := core.RateLimits{MaxConcurrentRequests: 1}
+
+			permit := lo.Must(limiter.Acquire("alice", limits, core.RequestClassRead))
+
+			_, err := limiter.Acquire("alice", limits, core.RequestClassRead)
+			Expect(err).To(MatchError(core.ErrSlowDown))
+			Expect(limiter.Stats()[0]).To(HaveField("InFlight", 1))
+
+			permit.Release()
+			permit.Release()
+
+			lo.Must(limiter.Acquire("alice", limits, core.RequestClassRead)).Release()
+			Expect(limiter.Stats()).To(Equal([]ratelimit.Stats{
+				{Principal: "alice", Admitted: 2, ConcurrencyLimited: 1},
+			}))
+		})
+
+		It("throttles uploads and downloads", func(ctx context.Context) {
+			// The first 32KiB are the burst, the remaining 16KiB take half a second.
+			limits := core.RateLimits{UploadBytesPerSecond: 32 * 1024, DownloadBytesPerSecond: 32 * 1024}
+			content := bytes.Repeat([]byte("x"), 48*1024)
+
+			permit := lo.Must(limiter.Acquire("alice", limits, core.RequestClassWrite))
+			defer permit.Release()
+
+			start := time.Now()
+			body := permit.ThrottleUpload(ctx, io.NopCloser(bytes.NewReader(content)))
+			Expect(io.ReadAll(body)).To(Equal(content))
+			Expect(time.Since(start)).To(BeNumerically(">=", 400*time.Millisecond))
+
+			start = time.Now()
+			recorder := httptest.NewRecorder()
+			Expect(permit.ThrottleDownload(ctx, recorder).Write(content)).To(Equal(len(content)))
+			Expect(recorder.Body.Bytes()).To(Equal(content))
+			Expect(time.Since(start)).To(BeNumerically(">=", 400*time.Millisecond))
+		})
+	})
+
+	Describe("Limits", func() {
+		BeforeEach(func(ctx context.Context) {
+			lo.Must(backend.CreateUser(ctx, "alice"))
+			lo.Must0(backend.CreateGroup(ctx, "backups"))
+			lo.Must0(backend.CreateGroup(ctx, "uploaders"))
+			lo.Must0(backend.AddGroupMember(ctx, "backups", "alice"))
+			lo.Must0(backend.AddGroupMember(ctx, "uploaders", "alice"))
+
+			lo.Must0(backend.SetGroupLimits(ctx, "backups", &core.RateLimits{
+				RequestsPerSecond:     map[core.RequestClass]float64{core.RequestClassWrite: 10},
+				MaxConcurrentRequests: 8,
+			}))
+			lo.Must0(backend.SetGroupLimits(ctx, "uploaders", &core.RateLimits{
+				RequestsPerSecond:    map[core.RequestClass]float64{core.RequestClassWrite: 50, core.RequestClassRead: 5},
+				UploadBytesPerSecond: 1024,
+			}))
+		})
+
+		It("combines the strictest limits of the user's groups", func(ctx context.Context) {
+			principal, limits, err := limiter.Limits(ctx, lo.Must(backend.GetUserByName(ctx, "alice")))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(principal).To(Equal("alice"))
+			Expect(limits).To(Equal(core.RateLimits{
+				RequestsPerSecond:     map[core.RequestClass]float64{core.RequestClassWrite: 10, core.RequestClassRead: 5},
+				MaxConcurrentRequests: 8,
+				UploadBytesPerSecond:  1024,
+			}))
+		})
+
+		It("prefers the user's own limits", func(ctx context.Context) {
+			own := &core.RateLimits{MaxConcurrentRequests: 100}
+			lo.Must0(backend.SetUserLimits(ctx, "alice", own))
+
+			_, limits, err := limiter.Limits(ctx, lo.Must(backend.GetUserByName(ctx, "alice")))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limits).To(Equal(*own))
+		})
+
+		It("caches the limits of the user's groups", func(ctx context.Context) {
+			alice := lo.Must(backend.GetUserByName(ctx, "alice"))
+			_, cached := lo.Must2(limiter.Limits(ctx, alice))
+
+			lo.Must0(backend.SetGroupLimits(ctx, "backups", nil))
+
+			_, limits, err := limiter.Limits(ctx, alice)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limits).To(Equal(cached))
+		})
+
+		It("limits sessions as the user that assumed the role", func(ctx context.Context) {
+			own := &core.RateLimits{MaxConcurrentRequests: 3}
+			lo.Must0(backend.SetUserLimits(ctx, "alice", own))
+
+			session := &core.Session{RoleName: "deployer", SessionName: "ci", UserName: "alice"}
+
+			principal, limits, err := limiter.Limits(ctx, session.User())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(principal).To(Equal("alice"))
+			Expect(limits).To(Equal(*own))
+		})
+
+		It("limits web identity sessions by role and subject, whatever their name", func(ctx context.Context) {
+			roleLimits := &core.RateLimits{MaxConcurrentRequests: 2}
+			lo.Must0(backend.CreateRole(ctx, &core.Role{Name: "ci", Limits: roleLimits}))
+
+			for _, sessionName := range []string{"build-1", "build-2"} {
+				session := &core.Session{RoleName: "ci", SessionName: sessionName, Subject: "repo:zhulik/d3"}
+
+				principal, limits, err := limiter.Limits(ctx, session.User())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(principal).To(Equal("role/ci/repo:zhulik/d3"))
+				Expect(limits).To(Equal(*roleLimits))
+			}
+		})
+
+		It("does not fail web identity sessions of deleted roles", func(ctx context.Context) {
+			session := &core.Session{RoleName: "deleted", SessionName: "build", Subject: "repo:zhulik/d3"}
+
+			_, limits, err := limiter.Limits(ctx, session.User())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limits).To(Equal(core.RateLimits{}))
+		})
+	})
+})
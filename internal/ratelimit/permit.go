@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// Permit is an admitted request. It throttles the request body and the response to the byte rates of the
+// principal, shared by all of its requests.
+type Permit struct {
+	limiter   *Limiter
+	principal *principal
+	upload    *rate.Limiter
+	download  *rate.Limiter
+
+	releaseOnce sync.Once
+}
+
+// Release ends the request, it no longer counts towards the concurrency limit.
+func (p *Permit) Release() {
+	p.releaseOnce.Do(func() { p.limiter.release(p.principal) })
+}
+
+// ThrottleUpload returns body limited to the upload rate.
+func (p *Permit) ThrottleUpload(ctx context.Context, body io.ReadCloser) io.ReadCloser {
+	if p.upload == nil || body == nil {
+		return body
+	}
+
+	return &throttledReader{ReadCloser: body, ctx: ctx, limiter: p.upload}
+}
+
+// ThrottleDownload returns w limited to the download rate.
+func (p *Permit) ThrottleDownload(ctx context.Context, w http.ResponseWriter) http.ResponseWriter {
+	if p.download == nil {
+		return w
+	}
+
+	return &throttledResponseWriter{ResponseWriter: w, ctx: ctx, limiter: p.download}
+}
+
+type throttledReader struct {
+	io.ReadCloser
+
+	ctx     context.Context //nolint:containedctx
+	limiter *rate.Limiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p[:min(len(p), r.limiter.Burst())])
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
+
+type throttledResponseWriter struct {
+	http.ResponseWriter
+
+	ctx     context.Context //nolint:containedctx
+	limiter *rate.Limiter
+}
+
+func (w *throttledResponseWriter) Write(p []byte) (int, error) {
+	var written int
+
+	for len(p) > 0 {
+		chunk := p[:min(len(p), w.limiter.Burst())]
+
+		if err := w.limiter.WaitN(w.ctx, len(chunk)); err != nil {
+			return written, err
+		}
+
+		n, err := w.ResponseWriter.Write(chunk)
+		written += n
+
+		if err != nil {
+			return written, err
+		}
+
+		p = p[n:]
+	}
+
+	return written, nil
+}
+
+// Unwrap lets echo and http.ResponseController reach the original writer.
+func (w *throttledResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
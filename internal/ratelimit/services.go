@@ -0,0 +1,9 @@
+package ratelimit
+
+import (
+	"github.com/zhulik/pal"
+)
+
+func Provide() pal.ServiceDef {
+	return pal.Provide(&Limiter{})
+}
@@ -0,0 +1,36 @@
+package redisclient
+
+import (
+	"context"
+
+	"github.com/redis/rueidis"
+	"github.com/zhulik/d3/internal/core"
+)
+
+// Client is the Redis client shared by the services that keep their state in Redis.
+type Client struct {
+	rueidis.Client
+
+	Config *core.Config
+}
+
+func (c *Client) Init(_ context.Context) error {
+	client, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress: []string{c.Config.RedisAddress},
+		Username:    c.Config.RedisUsername,
+		Password:    c.Config.RedisPassword,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Client = client
+
+	return nil
+}
+
+func (c *Client) Shutdown(_ context.Context) error {
+	c.Close()
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package sessions
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/internal/redisclient"
+	"github.com/zhulik/d3/pkg/json"
+)
+
+// keyPrefix namespaces session keys in the Redis instance shared with the other services.
+const keyPrefix = "d3:sts:sessions:"
+
+// Store keeps sessions in Redis, which drops them when they expire.
+type Store struct {
+	Config *core.Config
+	Redis  *redisclient.Client
+}
+
+func (s *Store) Put(ctx context.Context, session *core.Session) error {
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	cmd := s.Redis.B().Set().Key(keyPrefix + session.AccessKeyID).Value(string(data)).Px(ttl).Build()
+
+	return s.Redis.Do(ctx, cmd).Error()
+}
+
+func (s *Store) Get(ctx context.Context, accessKeyID string) (*core.Session, error) {
+	data, err := s.Redis.Do(ctx, s.Redis.B().Get().Key(keyPrefix+accessKeyID).Build()).AsBytes()
+	if err != nil {
+		if rueidis.IsRedisNil(err) {
+			return nil, core.ErrSessionNotFound
+		}
+
+		return nil, err
+	}
+
+	session, err := json.Unmarshal[core.Session](data)
+	if err != nil {
+		return nil, err
+	}
+
+	// The key's TTL was computed on the instance that issued the session, ExpiresAt is what the client was told.
+	if !time.Now().Before(session.ExpiresAt) {
+		return nil, core.ErrSessionNotFound
+	}
+
+	return &session, nil
+}
@@ -0,0 +1,84 @@
+package stats
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/zhulik/d3/internal/core"
+)
+
+// Scanner keeps the storage statistics of the last scan, scanning every StatsScanInterval.
+type Scanner struct {
+	Config  *core.Config
+	Backend core.StorageBackend
+	Logger  *slog.Logger
+
+	// scanMu makes concurrent requests for a scan wait for the running one instead of starting their own.
+	scanMu sync.Mutex
+	mu     sync.Mutex
+	last   *core.StorageStats
+}
+
+func (s *Scanner) Run(ctx context.Context) error {
+	if s.Config.StatsScanInterval <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(s.Config.StatsScanInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Scan(ctx); err != nil && ctx.Err() == nil {
+			s.Logger.Error("failed to scan storage statistics", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stats returns the statistics of the last scan, scanning first when there was none yet or refresh is set.
+func (s *Scanner) Stats(ctx context.Context, refresh bool) (*core.StorageStats, error) {
+	s.mu.Lock()
+	last := s.last
+	s.mu.Unlock()
+
+	if last != nil && !refresh {
+		return last, nil
+	}
+
+	return s.Scan(ctx)
+}
+
+// Scan scans the storage and keeps the result. A scan that finished while waiting for the running one is returned
+// as is.
+func (s *Scanner) Scan(ctx context.Context) (*core.StorageStats, error) {
+	requestedAt := time.Now()
+
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	s.mu.Lock()
+	last := s.last
+	s.mu.Unlock()
+
+	if last != nil && !last.ScannedAt.Before(requestedAt) {
+		return last, nil
+	}
+
+	stats, err := s.Backend.StorageStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.last = stats
+	s.mu.Unlock()
+
+	return stats, nil
+}
@@ -0,0 +1,12 @@
+package stats
+
+import (
+	"github.com/zhulik/pal"
+)
+
+func Provide() pal.ServiceDef {
+	return pal.ProvideList(
+		pal.Provide(&Scanner{}),
+		pal.Provide(&Transfers{}),
+	)
+}
@@ -0,0 +1,148 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/internal/redisclient"
+)
+
+const (
+	// uploadedKey and downloadedKey are Redis hashes of the byte totals by user, shared by all d3 instances.
+	uploadedKey          = "d3:stats:uploaded"
+	downloadedKey        = "d3:stats:downloaded"
+	defaultFlushInterval = 10 * time.Second
+)
+
+// Transfers counts the bytes users upload and download. Counts are buffered and added to the totals in Redis every
+// TransferStatsFlushInterval.
+type Transfers struct {
+	Config *core.Config
+	Logger *slog.Logger
+	Redis  *redisclient.Client
+
+	mu      sync.Mutex
+	pending map[string]*core.TransferStats
+}
+
+func (t *Transfers) Init(_ context.Context) error {
+	t.pending = map[string]*core.TransferStats{}
+
+	return nil
+}
+
+func (t *Transfers) Run(ctx context.Context) error {
+	ticker := time.NewTicker(lo.CoalesceOrEmpty(t.Config.TransferStatsFlushInterval, defaultFlushInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := t.Flush(ctx); err != nil {
+				t.Logger.Error("failed to write transfer statistics", "error", err)
+			}
+		}
+	}
+}
+
+func (t *Transfers) Shutdown(ctx context.Context) error {
+	return t.Flush(ctx)
+}
+
+// Record counts the bytes the user uploaded and downloaded.
+func (t *Transfers) Record(user string, uploaded, downloaded int64) {
+	if uploaded == 0 && downloaded == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.pending[user]
+	if stats == nil {
+		stats = &core.TransferStats{User: user}
+		t.pending[user] = stats
+	}
+
+	stats.UploadedBytes += uploaded
+	stats.DownloadedBytes += downloaded
+}
+
+// Flush adds the buffered counts to the totals.
+func (t *Transfers) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = map[string]*core.TransferStats{}
+	t.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	cmds := make(rueidis.Commands, 0, 2*len(pending)) //nolint:mnd
+
+	for user, stats := range pending {
+		cmds = append(cmds,
+			t.Redis.B().Hincrby().Key(uploadedKey).Field(user).Increment(stats.UploadedBytes).Build(),
+			t.Redis.B().Hincrby().Key(downloadedKey).Field(user).Increment(stats.DownloadedBytes).Build(),
+		)
+	}
+
+	errs := lo.Map(t.Redis.DoMulti(ctx, cmds...), func(result rueidis.RedisResult, _ int) error {
+		return result.Error()
+	})
+
+	// Increments are not idempotent, retrying the ones that failed could count the ones that did not twice.
+	return errors.Join(errs...)
+}
+
+// Totals returns the totals of all users, including the counts of this instance not flushed yet, by user name.
+func (t *Transfers) Totals(ctx context.Context) ([]core.TransferStats, error) {
+	uploaded, err := t.Redis.Do(ctx, t.Redis.B().Hgetall().Key(uploadedKey).Build()).AsStrMap()
+	if err != nil {
+		return nil, err
+	}
+
+	downloaded, err := t.Redis.Do(ctx, t.Redis.B().Hgetall().Key(downloadedKey).Build()).AsStrMap()
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	totals := lo.MapValues(t.pending, func(stats *core.TransferStats, _ string) core.TransferStats { return *stats })
+	t.mu.Unlock()
+
+	for user, value := range uploaded {
+		total := lo.ValueOr(totals, user, core.TransferStats{User: user})
+		total.UploadedBytes += parseCount(value)
+		totals[user] = total
+	}
+
+	for user, value := range downloaded {
+		total := lo.ValueOr(totals, user, core.TransferStats{User: user})
+		total.DownloadedBytes += parseCount(value)
+		totals[user] = total
+	}
+
+	return slices.SortedFunc(maps.Values(totals), func(a, b core.TransferStats) int {
+		return strings.Compare(a.User, b.User)
+	}), nil
+}
+
+func parseCount(value string) int64 {
+	count, _ := strconv.ParseInt(value, 10, 64)
+
+	return count
+}
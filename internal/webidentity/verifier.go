@@ -0,0 +1,141 @@
+package webidentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhulik/d3/internal/core"
+	"github.com/zhulik/d3/pkg/jwt"
+)
+
+const (
+	// refreshInterval limits how often a token signed with an unknown key can trigger reloading the key set,
+	// which is how identity providers' key rotations are picked up.
+	refreshInterval = time.Minute
+	// clockLeeway tolerates clock skew between the identity provider and d3.
+	clockLeeway = time.Minute
+	// maxKeySetSize bounds the key set document.
+	maxKeySetSize = core.SizeLimit1Mb
+)
+
+// Verifier validates web identity tokens against the configured JSON Web Key Set.
+type Verifier struct {
+	Config *core.Config
+	Logger *slog.Logger
+
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	keys      *jwt.KeySet
+	fetchedAt time.Time
+}
+
+func (v *Verifier) Init(ctx context.Context) error {
+	if v.Config.WebIdentityJWKS == "" {
+		return nil
+	}
+
+	if v.Config.WebIdentityIssuer == "" || v.Config.WebIdentityAudience == "" {
+		return fmt.Errorf("%w: web identity issuer and audience must be set along with the JWKS",
+			core.ErrInvalidConfig)
+	}
+
+	v.httpClient = &http.Client{Timeout: 10 * time.Second} //nolint:mnd
+
+	return v.refresh(ctx)
+}
+
+func (v *Verifier) Verify(ctx context.Context, token string) (jwt.Claims, error) {
+	if v.Config.WebIdentityJWKS == "" {
+		return nil, core.ErrWebIdentityDisabled
+	}
+
+	claims, err := v.verify(token)
+	if errors.Is(err, jwt.ErrUnknownKey) && v.refreshDue() {
+		if err := v.refresh(ctx); err != nil {
+			v.Logger.Error("failed to refresh web identity key set", "error", err)
+		}
+
+		claims, err = v.verify(token)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", core.ErrWebIdentityTokenInvalid, err)
+	}
+
+	return claims, nil
+}
+
+func (v *Verifier) verify(token string) (jwt.Claims, error) {
+	v.mu.Lock()
+	keys := v.keys
+	v.mu.Unlock()
+
+	return jwt.Verify(token, keys, jwt.Expectations{
+		Issuer:   v.Config.WebIdentityIssuer,
+		Audience: v.Config.WebIdentityAudience,
+		Leeway:   clockLeeway,
+	})
+}
+
+func (v *Verifier) refreshDue() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return time.Since(v.fetchedAt) >= refreshInterval
+}
+
+func (v *Verifier) refresh(ctx context.Context) error {
+	v.mu.Lock()
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+
+	data, err := v.load(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: failed to load web identity key set: %w", core.ErrInvalidConfig, err)
+	}
+
+	keys, err := jwt.ParseKeySet(data)
+	if err != nil {
+		return fmt.Errorf("%w: %w", core.ErrInvalidConfig, err)
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.mu.Unlock()
+
+	return nil
+}
+
+func (v *Verifier) load(ctx context.Context) ([]byte, error) {
+	source := v.Config.WebIdentityJWKS
+
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, source)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxKeySetSize))
+}
@@ -0,0 +1,33 @@
+package backoff
+
+import "time"
+
+// Backoff yields exponentially growing delays between Min and Max for retrying failing operations.
+// The zero value is not usable, construct it with New.
+type Backoff struct {
+	Min time.Duration
+	Max time.Duration
+
+	next time.Duration
+}
+
+func New(minDelay, maxDelay time.Duration) *Backoff {
+	return &Backoff{
+		Min:  minDelay,
+		Max:  maxDelay,
+		next: minDelay,
+	}
+}
+
+// Next returns the delay before the next attempt and doubles the following one, up to Max.
+func (b *Backoff) Next() time.Duration {
+	delay := b.next
+	b.next = min(b.next*2, b.Max)
+
+	return delay
+}
+
+// Reset starts over from Min, call it after a successful attempt.
+func (b *Backoff) Reset() {
+	b.next = b.Min
+}
@@ -0,0 +1,38 @@
+package backoff_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/zhulik/d3/pkg/backoff"
+)
+
+var _ = Describe("Backoff", func() {
+	It("doubles the delay up to the maximum", func() {
+		b := backoff.New(100*time.Millisecond, time.Second)
+
+		delays := []time.Duration{b.Next(), b.Next(), b.Next(), b.Next(), b.Next(), b.Next()}
+
+		Expect(delays).To(Equal([]time.Duration{
+			100 * time.Millisecond,
+			200 * time.Millisecond,
+			400 * time.Millisecond,
+			800 * time.Millisecond,
+			time.Second,
+			time.Second,
+		}))
+	})
+
+	When("reset", func() {
+		It("starts over from the minimum", func() {
+			b := backoff.New(100*time.Millisecond, time.Second)
+			b.Next()
+			b.Next()
+
+			b.Reset()
+
+			Expect(b.Next()).To(Equal(100 * time.Millisecond))
+		})
+	})
+})
@@ -0,0 +1,178 @@
+// Package batchwriter buffers items and writes them in batches, with a bound on the items it keeps while they
+// cannot be written.
+package batchwriter
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Writer buffers items per key and writes them one batch per key, every flush. Run also writes the batch of a key
+// as soon as it has MaxBatch items, in the background rather than in Add. A batch that fails to be written is kept
+// for the next flush, unless Retry tells otherwise. Up to MaxPending items are kept, later ones are dropped and
+// counted, the count is logged by the next flush.
+type Writer[K comparable, T any] struct {
+	MaxBatch   int
+	MaxPending int
+	Write      func(ctx context.Context, key K, items []T) error
+	// Retry tells whether a batch that failed with err is kept for the next flush, all of them are when nil.
+	Retry  func(err error) bool
+	Logger *slog.Logger
+
+	mu      sync.Mutex
+	pending map[K][]T
+	size    int
+	dropped int
+	full    chan struct{}
+}
+
+// Run flushes every interval, and writes full batches when Add signals them, until ctx is done. Failures are
+// logged.
+func (w *Writer[K, T]) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	w.mu.Lock()
+	full := w.fullSignal()
+	w.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.Flush(ctx); err != nil {
+				w.Logger.Error("failed to write batches", "error", err)
+			}
+		case <-full:
+			if err := w.flushFull(ctx); err != nil {
+				w.Logger.Error("failed to write full batches", "error", err)
+			}
+		}
+	}
+}
+
+// Add buffers the item and signals Run when the batch of the key is full.
+func (w *Writer[K, T]) Add(key K, item T) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.pending == nil {
+		w.pending = map[K][]T{}
+	}
+
+	if w.size >= w.MaxPending {
+		w.dropped++
+
+		return
+	}
+
+	w.pending[key] = append(w.pending[key], item)
+	w.size++
+
+	// Batches that grew past MaxBatch because they could not be written are retried by the next flush.
+	if len(w.pending[key]) == w.MaxBatch {
+		select {
+		case w.fullSignal() <- struct{}{}:
+		default:
+			// Run has a signal to handle already, it writes all the full batches.
+		}
+	}
+}
+
+// Flush writes the batches of all keys.
+func (w *Writer[K, T]) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = map[K][]T{}
+	w.size = 0
+	dropped := w.dropped
+	w.dropped = 0
+	w.mu.Unlock()
+
+	if dropped > 0 {
+		w.Logger.Warn("dropped items, too many were waiting to be written", "count", dropped)
+	}
+
+	return w.writeAll(ctx, pending)
+}
+
+// Pending returns a copy of the items buffered for the key.
+func (w *Writer[K, T]) Pending(key K) []T {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]T(nil), w.pending[key]...)
+}
+
+// flushFull writes the batches of the keys that have at least MaxBatch items.
+func (w *Writer[K, T]) flushFull(ctx context.Context) error {
+	w.mu.Lock()
+	full := map[K][]T{}
+
+	for key, batch := range w.pending {
+		if len(batch) >= w.MaxBatch {
+			full[key] = batch
+			w.take(key)
+		}
+	}
+	w.mu.Unlock()
+
+	return w.writeAll(ctx, full)
+}
+
+func (w *Writer[K, T]) writeAll(ctx context.Context, batches map[K][]T) error {
+	var errs []error
+
+	for key, batch := range batches {
+		errs = append(errs, w.writeOrRequeue(ctx, key, batch))
+	}
+
+	return errors.Join(errs...)
+}
+
+// fullSignal returns the channel Add signals full batches on, the caller must hold the lock.
+func (w *Writer[K, T]) fullSignal() chan struct{} {
+	if w.full == nil {
+		w.full = make(chan struct{}, 1)
+	}
+
+	return w.full
+}
+
+// take removes the batch of the key, the caller must hold the lock.
+func (w *Writer[K, T]) take(key K) {
+	w.size -= len(w.pending[key])
+	delete(w.pending, key)
+}
+
+// writeOrRequeue keeps the batch for the next flush when it cannot be written, ahead of the items added since.
+// The latest items are dropped when there are too many of them.
+func (w *Writer[K, T]) writeOrRequeue(ctx context.Context, key K, batch []T) error {
+	err := w.Write(ctx, key, batch)
+	if err == nil || (w.Retry != nil && !w.Retry(err)) {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	requeued := append(batch, w.pending[key]...)
+	w.take(key)
+
+	if overflow := w.size + len(requeued) - w.MaxPending; overflow > 0 {
+		kept := max(len(requeued)-overflow, 0)
+		w.dropped += len(requeued) - kept
+		requeued = requeued[:kept]
+	}
+
+	if len(requeued) > 0 {
+		w.pending[key] = requeued
+		w.size += len(requeued)
+	}
+
+	return err
+}
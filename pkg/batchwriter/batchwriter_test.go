@@ -0,0 +1,119 @@
+package batchwriter_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/zhulik/d3/pkg/batchwriter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var (
+	errUnavailable = errors.New("unavailable")
+	errGone        = errors.New("gone")
+)
+
+var _ = Describe("Writer", func() {
+	var (
+		writer  *batchwriter.Writer[string, int]
+		mu      sync.Mutex
+		written map[string][][]int
+		failure error
+	)
+
+	getWritten := func() map[string][][]int {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return maps.Clone(written)
+	}
+
+	BeforeEach(func() {
+		written = map[string][][]int{}
+		failure = nil
+
+		writer = &batchwriter.Writer[string, int]{
+			MaxBatch:   3,
+			MaxPending: 4,
+			Write: func(_ context.Context, key string, items []int) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if failure != nil {
+					return failure
+				}
+
+				written[key] = append(written[key], items)
+
+				return nil
+			},
+			Retry:  func(err error) bool { return !errors.Is(err, errGone) },
+			Logger: slog.New(slog.DiscardHandler),
+		}
+	})
+
+	It("writes one batch per key and flush", func(ctx context.Context) {
+		writer.Add("a", 1)
+		writer.Add("b", 2)
+		writer.Add("a", 3)
+		Expect(written).To(BeEmpty())
+		Expect(writer.Pending("a")).To(Equal([]int{1, 3}))
+
+		Expect(writer.Flush(ctx)).To(Succeed())
+		Expect(written).To(Equal(map[string][][]int{"a": {{1, 3}}, "b": {{2}}}))
+		Expect(writer.Pending("a")).To(BeEmpty())
+	})
+
+	It("writes full batches in the background", func(ctx context.Context) {
+		for i := range 4 {
+			writer.Add("a", i)
+		}
+
+		// Add does not write, Run does.
+		Expect(written).To(BeEmpty())
+
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		go writer.Run(runCtx, time.Hour)
+
+		Eventually(getWritten).Should(Equal(map[string][][]int{"a": {{0, 1, 2, 3}}}))
+		Expect(writer.Pending("a")).To(BeEmpty())
+	})
+
+	When("batches cannot be written", func() {
+		It("keeps up to MaxPending items in order", func(ctx context.Context) {
+			failure = errUnavailable
+
+			writer.Add("a", 1)
+			writer.Add("a", 2)
+			writer.Add("a", 3)
+			writer.Add("b", 4)
+			writer.Add("b", 5)
+
+			Expect(writer.Flush(ctx)).To(MatchError(errUnavailable))
+			Expect(writer.Pending("a")).To(Equal([]int{1, 2, 3}))
+			Expect(writer.Pending("b")).To(Equal([]int{4}))
+
+			failure = nil
+
+			Expect(writer.Flush(ctx)).To(Succeed())
+			Expect(written).To(Equal(map[string][][]int{"a": {{1, 2, 3}}, "b": {{4}}}))
+		})
+
+		It("drops the batches that are not retried", func(ctx context.Context) {
+			failure = errGone
+
+			writer.Add("a", 1)
+			Expect(writer.Flush(ctx)).To(MatchError(errGone))
+
+			Expect(writer.Pending("a")).To(BeEmpty())
+		})
+	})
+})
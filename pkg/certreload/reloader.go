@@ -0,0 +1,180 @@
+// Package certreload serves TLS certificates that are reloaded from their files while the server runs, so
+// renewed certificates are picked up without a restart.
+package certreload
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/samber/lo"
+)
+
+// pollInterval bounds staleness when file events are missed, e.g. on network filesystems without inotify support.
+const pollInterval = time.Minute
+
+var ErrNoClientCAs = errors.New("no certificates found in client CA file")
+
+// Reloader holds a certificate and, for mutual TLS, the CAs client certificates must be signed by. Both are
+// read from PEM files. A failed reload, e.g. when only one of the certificate and the key is renewed yet, keeps
+// the previous certificate in use.
+type Reloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	logger       *slog.Logger
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// New loads the certificate and the key, and the client CAs when clientCAFile is not empty.
+func New(certFile, keyFile, clientCAFile string, logger *slog.Logger) (*Reloader, error) {
+	r := &Reloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+		logger:       logger,
+	}
+
+	return r, r.Reload()
+}
+
+// Reload reads the files again.
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client CAs: %w", err)
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%w: %s", ErrNoClientCAs, r.clientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = &cert
+	r.clientCAs = clientCAs
+
+	return nil
+}
+
+// TLSConfig returns a server config that uses the current certificate and client CAs for every handshake.
+// Client certificates are required and verified when there are client CAs.
+func (r *Reloader) TLSConfig(minVersion uint16) *tls.Config {
+	return &tls.Config{
+		MinVersion: minVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+
+			return &tls.Config{
+				MinVersion:   minVersion,
+				Certificates: []tls.Certificate{*r.cert},
+				ClientCAs:    r.clientCAs,
+				ClientAuth:   lo.Ternary(r.clientCAs != nil, tls.RequireAndVerifyClientCert, tls.NoClientCert),
+				NextProtos:   []string{"http/1.1"},
+			}, nil
+		},
+	}
+}
+
+// Run reloads the files when they change until ctx is done. The directories are watched instead of the files
+// because certificates are usually replaced with a rename, e.g. by Kubernetes secret volumes, which would
+// silently end a watch on the old inode.
+func (r *Reloader) Run(ctx context.Context) {
+	events := r.watch(ctx)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-events:
+		}
+
+		if err := r.Reload(); err != nil {
+			r.logger.Error("failed to reload TLS certificate, keeping the previous one", "error", err)
+		}
+	}
+}
+
+// watch returns a channel that receives a value when the files may have changed, or nil when watching is
+// unavailable.
+func (r *Reloader) watch(ctx context.Context) <-chan struct{} {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		r.logger.Warn("certificate file watching is unavailable, relying on polling", "error", err)
+
+		return nil
+	}
+
+	files := lo.Compact([]string{r.certFile, r.keyFile, r.clientCAFile})
+	dirs := lo.Uniq(lo.Map(files, func(file string, _ int) string { return filepath.Dir(file) }))
+
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			r.logger.Warn("certificate file watching is unavailable, relying on polling", "error", err)
+			watcher.Close()
+
+			return nil
+		}
+	}
+
+	events := make(chan struct{}, 1)
+
+	go func() {
+		defer watcher.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if event.Has(fsnotify.Chmod) {
+					continue
+				}
+
+				select {
+				case events <- struct{}{}:
+				default:
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				r.logger.Error("certificate watcher error", "error", err)
+			}
+		}
+	}()
+
+	return events
+}
@@ -0,0 +1,134 @@
+package certreload_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/pkg/certreload"
+)
+
+// writeCert writes a self-signed certificate for commonName and its key to the files.
+func writeCert(certFile, keyFile, commonName string) {
+	key := lo.Must(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+
+	der := lo.Must(x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key))
+
+	Expect(os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)).
+		To(Succeed())
+	Expect(os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{
+		Type: "EC PRIVATE KEY", Bytes: lo.Must(x509.MarshalECPrivateKey(key)),
+	}), 0o600)).To(Succeed())
+}
+
+func servedCommonName(config *tls.Config) string {
+	served := lo.Must(config.GetConfigForClient(&tls.ClientHelloInfo{}))
+
+	return lo.Must(x509.ParseCertificate(served.Certificates[0].Certificate[0])).Subject.CommonName
+}
+
+var _ = Describe("Reloader", func() {
+	var (
+		certFile string
+		keyFile  string
+		reloader *certreload.Reloader
+	)
+
+	BeforeEach(func() {
+		dir := GinkgoT().TempDir()
+		certFile = filepath.Join(dir, "tls.crt")
+		keyFile = filepath.Join(dir, "tls.key")
+
+		writeCert(certFile, keyFile, "first")
+
+		reloader = lo.Must(certreload.New(certFile, keyFile, "", slog.Default()))
+	})
+
+	It("serves the certificate without requesting client certificates", func() {
+		config := reloader.TLSConfig(tls.VersionTLS13)
+
+		served := lo.Must(config.GetConfigForClient(&tls.ClientHelloInfo{}))
+		Expect(served.MinVersion).To(Equal(uint16(tls.VersionTLS13)))
+		Expect(served.ClientAuth).To(Equal(tls.NoClientCert))
+		Expect(servedCommonName(config)).To(Equal("first"))
+	})
+
+	When("the files are replaced", func() {
+		It("serves the new certificate after a reload", func() {
+			config := reloader.TLSConfig(tls.VersionTLS12)
+
+			writeCert(certFile, keyFile, "second")
+			Expect(reloader.Reload()).To(Succeed())
+
+			Expect(servedCommonName(config)).To(Equal("second"))
+		})
+
+		It("reloads them while running", func(ctx context.Context) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			go reloader.Run(ctx)
+
+			config := reloader.TLSConfig(tls.VersionTLS12)
+
+			Eventually(func() string {
+				writeCert(certFile, keyFile, "second")
+
+				return servedCommonName(config)
+			}).Should(Equal("second"))
+		})
+	})
+
+	When("the new key does not match the certificate", func() {
+		It("keeps the previous certificate", func() {
+			config := reloader.TLSConfig(tls.VersionTLS12)
+
+			writeCert(certFile, filepath.Join(filepath.Dir(keyFile), "other.key"), "second")
+
+			Expect(reloader.Reload()).To(HaveOccurred())
+			Expect(servedCommonName(config)).To(Equal("first"))
+		})
+	})
+
+	When("client CAs are configured", func() {
+		It("requires and verifies client certificates", func() {
+			caFile := filepath.Join(filepath.Dir(certFile), "ca.crt")
+			writeCert(caFile, filepath.Join(filepath.Dir(certFile), "ca.key"), "ca")
+
+			reloader := lo.Must(certreload.New(certFile, keyFile, caFile, slog.Default()))
+
+			served := lo.Must(reloader.TLSConfig(tls.VersionTLS12).GetConfigForClient(&tls.ClientHelloInfo{}))
+			Expect(served.ClientAuth).To(Equal(tls.RequireAndVerifyClientCert))
+			Expect(served.ClientCAs).NotTo(BeNil())
+		})
+
+		It("fails when the file has no certificates", func() {
+			caFile := filepath.Join(filepath.Dir(certFile), "ca.crt")
+			Expect(os.WriteFile(caFile, []byte("not a certificate"), 0o600)).To(Succeed())
+
+			_, err := certreload.New(certFile, keyFile, caFile, slog.Default())
+			Expect(err).To(MatchError(certreload.ErrNoClientCAs))
+		})
+	})
+})
@@ -2,13 +2,16 @@ package credentials
 
 import (
 	"crypto/rand"
+	"strings"
 )
 
 const (
-	AccessKeyIDPrefix       = "AKIA"
-	AccessKeyIDLength       = 20
-	AccessKeyIDSuffixLength = AccessKeyIDLength - len(AccessKeyIDPrefix)
-	SecretAccessKeyLength   = 40
+	AccessKeyIDPrefix          = "AKIA"
+	TemporaryAccessKeyIDPrefix = "ASIA"
+	AccessKeyIDLength          = 20
+	AccessKeyIDSuffixLength    = AccessKeyIDLength - len(AccessKeyIDPrefix)
+	SecretAccessKeyLength      = 40
+	SessionTokenLength         = 128
 
 	AccessKeyIDCharset     = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
 	SecretAccessKeyCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
@@ -20,6 +23,19 @@ func GenerateCredentials() (string, string) {
 		generateRandomString(SecretAccessKeyLength, SecretAccessKeyCharset)
 }
 
+// GenerateTemporaryCredentials generates an access key ID, a secret access key and a session token for
+// temporary credentials. Their access key IDs use a distinct prefix, like in AWS.
+func GenerateTemporaryCredentials() (string, string, string) {
+	return TemporaryAccessKeyIDPrefix + generateRandomString(AccessKeyIDSuffixLength, AccessKeyIDCharset),
+		generateRandomString(SecretAccessKeyLength, SecretAccessKeyCharset),
+		generateRandomString(SessionTokenLength, SecretAccessKeyCharset)
+}
+
+// IsTemporary reports whether the access key ID belongs to temporary credentials.
+func IsTemporary(accessKeyID string) bool {
+	return strings.HasPrefix(accessKeyID, TemporaryAccessKeyIDPrefix)
+}
+
 // generateRandomString generates a random string of the specified length
 // using characters from the provided charset.
 func generateRandomString(length int, charset string) string {
@@ -24,4 +24,23 @@ var _ = Describe("Credentials Generator", func() {
 			Expect(creds12).NotTo(Equal(creds22))
 		})
 	})
+
+	Describe("GenerateTemporaryCredentials", func() {
+		It("generates temporary credentials with a session token", func() {
+			accessKeyID, secretAccessKey, sessionToken := credentials.GenerateTemporaryCredentials()
+			Expect(accessKeyID).To(HavePrefix("ASIA"))
+			Expect(accessKeyID).To(HaveLen(credentials.AccessKeyIDLength))
+			Expect(secretAccessKey).To(HaveLen(credentials.SecretAccessKeyLength))
+			Expect(sessionToken).To(HaveLen(credentials.SessionTokenLength))
+
+			Expect(credentials.IsTemporary(accessKeyID)).To(BeTrue())
+		})
+	})
+
+	Describe("IsTemporary", func() {
+		It("rejects long-lived access key IDs", func() {
+			accessKeyID, _ := credentials.GenerateCredentials()
+			Expect(credentials.IsTemporary(accessKeyID)).To(BeFalse())
+		})
+	})
 })
@@ -0,0 +1,112 @@
+package iampol
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/pkg/json"
+	libyaml "go.yaml.in/yaml/v3"
+)
+
+const (
+	// ConditionOperatorBool matches boolean condition keys, it is the only supported operator.
+	ConditionOperatorBool = "Bool"
+
+	// ConditionKeySecureTransport tells whether the request was received over TLS.
+	ConditionKeySecureTransport = "aws:SecureTransport"
+)
+
+var (
+	conditionKeys = []string{ //nolint:gochecknoglobals
+		ConditionKeySecureTransport,
+	}
+)
+
+// Condition maps condition operators to the condition keys they test and the values the keys may have.
+type Condition map[string]map[string]ConditionValues
+
+// ConditionValues are the values a condition key may have. Policies may spell them as a single value or as a
+// list, booleans may be strings or JSON booleans.
+type ConditionValues []string
+
+func (v *ConditionValues) UnmarshalJSON(data []byte) error {
+	raw, err := json.Unmarshal[any](data)
+	if err != nil {
+		return err
+	}
+
+	values, ok := raw.([]any)
+	if !ok {
+		values = []any{raw}
+	}
+
+	*v = lo.Map(values, func(value any, _ int) string { return fmt.Sprint(value) })
+
+	return nil
+}
+
+func (v *ConditionValues) UnmarshalYAML(node *libyaml.Node) error {
+	if node.Kind == libyaml.ScalarNode {
+		*v = ConditionValues{node.Value}
+
+		return nil
+	}
+
+	var values []string
+	if err := node.Decode(&values); err != nil {
+		return err
+	}
+
+	*v = values
+
+	return nil
+}
+
+// Matches tells whether the request's condition key values satisfy every condition. A key missing from values
+// does not satisfy its condition.
+func (c Condition) Matches(values map[string]string) bool {
+	for _, keys := range c {
+		for key, accepted := range keys {
+			// Condition keys are case-insensitive.
+			requestKey, ok := lo.FindKeyBy(values, func(k string, _ string) bool { return strings.EqualFold(k, key) })
+			if !ok {
+				return false
+			}
+
+			if !lo.ContainsBy(accepted, func(a string) bool { return strings.EqualFold(a, values[requestKey]) }) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func validateCondition(condition Condition, stmtIndex int) error {
+	for operator, keys := range condition {
+		if operator != ConditionOperatorBool {
+			return fmt.Errorf("%w: unsupported Condition operator in Statement %d, operator %s",
+				ErrInvalidPolicy, stmtIndex, operator)
+		}
+
+		for key, values := range keys {
+			if !lo.ContainsBy(conditionKeys, func(k string) bool { return strings.EqualFold(k, key) }) {
+				return fmt.Errorf("%w: unsupported Condition key in Statement %d, key %s", ErrInvalidPolicy, stmtIndex, key)
+			}
+
+			if len(values) == 0 {
+				return fmt.Errorf("%w: missing Condition value in Statement %d, key %s", ErrInvalidPolicy, stmtIndex, key)
+			}
+
+			for _, value := range values {
+				if !strings.EqualFold(value, "true") && !strings.EqualFold(value, "false") {
+					return fmt.Errorf("%w: invalid Condition value in Statement %d, value %s",
+						ErrInvalidPolicy, stmtIndex, value)
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -14,6 +14,9 @@ var (
 	ErrInvalidPolicy = errors.New("invalid policy")
 )
 
+// ResourcePrefix prefixes the S3 resources of policy statements.
+const ResourcePrefix = "arn:aws:s3:::"
+
 type Effect string
 
 const (
@@ -36,11 +39,12 @@ type IAMPolicy struct {
 // Statement represents a single statement in an IAM policy.
 // it only implements a subset of the full IAM policy statement structure,
 // for instance it only supports arrays of Actions and Resources,
-// and does not support Conditions or Principals.
+// and does not support Principals. Conditions are limited to the Bool operator on aws:SecureTransport.
 type Statement struct {
-	Effect   Effect             `json:"Effect"`
-	Action   []s3actions.Action `json:"Action"`
-	Resource []string           `json:"Resource"`
+	Effect    Effect             `json:"Effect"`
+	Action    []s3actions.Action `json:"Action"`
+	Resource  []string           `json:"Resource"`
+	Condition Condition          `json:"Condition,omitempty" yaml:"condition,omitempty"`
 }
 
 func Parse(policyBytes []byte) (*IAMPolicy, error) {
@@ -53,35 +57,62 @@ func Parse(policyBytes []byte) (*IAMPolicy, error) {
 		return nil, fmt.Errorf("%w: missing Id", ErrInvalidPolicy)
 	}
 
+	if err := validateStatements(policy); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// ParseInline parses a policy that is passed along with a request instead of being stored, such as an
+// AssumeRole session policy. Such policies are not referenced by Id, so it is optional.
+func ParseInline(policyBytes []byte) (*IAMPolicy, error) {
+	policy, err := json.Unmarshal[IAMPolicy](policyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPolicy, err)
+	}
+
+	if err := validateStatements(policy); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+func validateStatements(policy IAMPolicy) error {
 	if len(policy.Statement) == 0 {
-		return nil, fmt.Errorf("%w: missing Statement", ErrInvalidPolicy)
+		return fmt.Errorf("%w: missing Statement", ErrInvalidPolicy)
 	}
 
 	for i, stmt := range policy.Statement {
 		if !lo.Contains(effects, stmt.Effect) {
-			return nil, fmt.Errorf("%w: invalid Effect in Statement %d", ErrInvalidPolicy, i)
+			return fmt.Errorf("%w: invalid Effect in Statement %d", ErrInvalidPolicy, i)
 		}
 
 		if len(stmt.Action) == 0 {
-			return nil, fmt.Errorf("%w: missing Action in Statement %d", ErrInvalidPolicy, i)
+			return fmt.Errorf("%w: missing Action in Statement %d", ErrInvalidPolicy, i)
 		}
 
 		for _, action := range stmt.Action {
 			if !lo.Contains(s3actions.Actions, action) {
-				return nil, fmt.Errorf("%w: invalid Action in Statement %d, Action %s", ErrInvalidPolicy, i, action)
+				return fmt.Errorf("%w: invalid Action in Statement %d, Action %s", ErrInvalidPolicy, i, action)
 			}
 		}
 
 		if len(stmt.Resource) == 0 {
-			return nil, fmt.Errorf("%w: missing Resource in Statement %d", ErrInvalidPolicy, i)
+			return fmt.Errorf("%w: missing Resource in Statement %d", ErrInvalidPolicy, i)
 		}
 
 		for _, resource := range stmt.Resource {
-			if _, ok := strings.CutPrefix(resource, "arn:aws:s3:::"); !ok {
-				return nil, fmt.Errorf("%w: invalid Resource in Statement %d, Resource %s", ErrInvalidPolicy, i, resource)
+			if _, ok := strings.CutPrefix(resource, ResourcePrefix); !ok {
+				return fmt.Errorf("%w: invalid Resource in Statement %d, Resource %s", ErrInvalidPolicy, i, resource)
 			}
 		}
+
+		if err := validateCondition(stmt.Condition, i); err != nil {
+			return err
+		}
 	}
 
-	return &policy, nil
+	return nil
 }
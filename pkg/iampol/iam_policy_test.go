@@ -12,6 +12,8 @@ import (
 
 	"github.com/zhulik/d3/pkg/iampol"
 	"github.com/zhulik/d3/pkg/json"
+	"github.com/zhulik/d3/pkg/s3actions"
+	"github.com/zhulik/d3/pkg/yaml"
 )
 
 var _ = Describe("Parse", func() {
@@ -46,3 +48,83 @@ var _ = Describe("Parse", func() {
 
 	DescribeTable("table-driven Parse tests", entries...)
 })
+
+var _ = Describe("ParseInline", func() {
+	It("does not require an Id", func() {
+		policy, err := iampol.ParseInline([]byte(`{"Statement": [
+			{"Effect": "Allow", "Action": ["s3:GetObject"], "Resource": ["arn:aws:s3:::bucket/*"]}
+		]}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policy.Statement).To(HaveLen(1))
+	})
+
+	It("validates statements", func() {
+		_, err := iampol.ParseInline([]byte(`{"Statement": [
+			{"Effect": "Allow", "Action": ["s3:GetObject"], "Resource": ["arn:aws:iam:::user/bob"]}
+		]}`))
+		Expect(err).To(MatchError(iampol.ErrInvalidPolicy))
+	})
+
+	It("rejects malformed JSON", func() {
+		_, err := iampol.ParseInline([]byte(`{`))
+		Expect(err).To(MatchError(iampol.ErrInvalidPolicy))
+	})
+})
+
+var _ = Describe("Condition", func() {
+	secureOnly := iampol.Condition{
+		iampol.ConditionOperatorBool: {iampol.ConditionKeySecureTransport: {"true"}},
+	}
+
+	It("matches when the request has an accepted value", func() {
+		Expect(secureOnly.Matches(map[string]string{iampol.ConditionKeySecureTransport: "true"})).To(BeTrue())
+	})
+
+	It("does not match other values", func() {
+		Expect(secureOnly.Matches(map[string]string{iampol.ConditionKeySecureTransport: "false"})).To(BeFalse())
+	})
+
+	It("does not match when the request lacks the key", func() {
+		Expect(secureOnly.Matches(map[string]string{})).To(BeFalse())
+	})
+
+	It("compares keys and values case-insensitively", func() {
+		condition := iampol.Condition{iampol.ConditionOperatorBool: {"AWS:securetransport": {"TRUE"}}}
+		Expect(condition.Matches(map[string]string{iampol.ConditionKeySecureTransport: "true"})).To(BeTrue())
+	})
+
+	It("matches anything when empty", func() {
+		Expect(iampol.Condition(nil).Matches(map[string]string{})).To(BeTrue())
+	})
+
+	DescribeTable("accepts single values and lists",
+		func(document string) {
+			policy, err := iampol.ParseInline([]byte(document))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy.Statement[0].Condition).To(Equal(secureOnly))
+		},
+		Entry("string", `{"Statement": [{"Effect": "Allow", "Action": ["s3:GetObject"],
+			"Resource": ["arn:aws:s3:::b/*"], "Condition": {"Bool": {"aws:SecureTransport": "true"}}}]}`),
+		Entry("boolean", `{"Statement": [{"Effect": "Allow", "Action": ["s3:GetObject"],
+			"Resource": ["arn:aws:s3:::b/*"], "Condition": {"Bool": {"aws:SecureTransport": true}}}]}`),
+		Entry("list", `{"Statement": [{"Effect": "Allow", "Action": ["s3:GetObject"],
+			"Resource": ["arn:aws:s3:::b/*"], "Condition": {"Bool": {"aws:SecureTransport": ["true"]}}}]}`),
+	)
+
+	It("survives a YAML round trip", func() {
+		stmt := iampol.Statement{
+			Effect:    iampol.EffectAllow,
+			Action:    []s3actions.Action{s3actions.GetObject},
+			Resource:  []string{"arn:aws:s3:::b/*"},
+			Condition: secureOnly,
+		}
+
+		data := lo.Must(yaml.Marshal(stmt))
+		Expect(lo.Must(yaml.Unmarshal[iampol.Statement](data))).To(Equal(stmt))
+	})
+
+	It("reads single YAML values", func() {
+		stmt := lo.Must(yaml.Unmarshal[iampol.Statement]([]byte("condition:\n  Bool:\n    aws:SecureTransport: true\n")))
+		Expect(stmt.Condition).To(Equal(secureOnly))
+	})
+})
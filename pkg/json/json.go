@@ -12,6 +12,11 @@ func Marshal[T any](v T) ([]byte, error) {
 	return libjson.Marshal(v)
 }
 
+// MarshalIndent is Marshal for humans, objects are indented with two spaces.
+func MarshalIndent[T any](v T) ([]byte, error) {
+	return libjson.MarshalIndent(v, "", "  ")
+}
+
 func Unmarshal[T any](data []byte) (T, error) {
 	var v T
 
@@ -0,0 +1,171 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+
+	"github.com/zhulik/d3/pkg/json"
+)
+
+// p256CoordinateSize is the size of the X and Y coordinates of a P-256 point, and of the R and S halves of an
+// ES256 signature.
+const p256CoordinateSize = 32
+
+// JSONWebKey is a public key in the JWK format (RFC 7517). Only RSA and P-256 EC keys are supported.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is the document served by OIDC providers at their jwks_uri.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// KeySet holds the public keys tokens may be signed with, indexed by key ID.
+type KeySet struct {
+	keys map[string]crypto.PublicKey
+}
+
+// ParseKeySet parses a JWKS document. Keys of unsupported types and signature keys meant for other uses are
+// skipped, so a provider adding e.g. an encryption key does not break verification.
+func ParseKeySet(data []byte) (*KeySet, error) {
+	jwks, err := json.Unmarshal[JSONWebKeySet](data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKeySet, err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+
+		if key != nil {
+			keys[jwk.Kid] = key
+		}
+	}
+
+	return &KeySet{keys: keys}, nil
+}
+
+// Key returns the key with the given ID. Tokens without a key ID can only be verified by a set of one key.
+func (s *KeySet) Key(kid string) (crypto.PublicKey, bool) {
+	if key, ok := s.keys[kid]; ok {
+		return key, true
+	}
+
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+// PublicKey decodes the key. It returns nil for key types it does not support.
+func (k JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("%w: invalid RSA exponent in key %q", ErrInvalidKeySet, k.Kid)
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, nil //nolint:nilnil
+		}
+
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+
+		if errX != nil || errY != nil || len(x) != p256CoordinateSize || len(y) != p256CoordinateSize {
+			return nil, fmt.Errorf("%w: invalid EC coordinates in key %q", ErrInvalidKeySet, k.Kid)
+		}
+
+		// Uncompressed SEC 1 point: 0x04 || X || Y.
+		key, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid EC point in key %q: %w", ErrInvalidKeySet, k.Kid, err)
+		}
+
+		return key, nil
+	default:
+		return nil, nil //nolint:nilnil
+	}
+}
+
+// NewJSONWebKey encodes an RSA or P-256 EC public key as a JWK.
+func NewJSONWebKey(kid string, key crypto.PublicKey) (JSONWebKey, error) {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return JSONWebKey{
+			Kty: "RSA", Kid: kid, Alg: AlgRS256, Use: "sig",
+			N: encodeBigInt(key.N), E: encodeBigInt(big.NewInt(int64(key.E))),
+		}, nil
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return JSONWebKey{}, ErrUnsupportedAlgorithm
+		}
+
+		point, err := key.Bytes()
+		if err != nil {
+			return JSONWebKey{}, err
+		}
+
+		return JSONWebKey{
+			Kty: "EC", Kid: kid, Alg: AlgES256, Use: "sig", Crv: "P-256",
+			X: base64.RawURLEncoding.EncodeToString(point[1 : 1+p256CoordinateSize]),
+			Y: base64.RawURLEncoding.EncodeToString(point[1+p256CoordinateSize:]),
+		}, nil
+	default:
+		return JSONWebKey{}, ErrUnsupportedAlgorithm
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("%w: invalid key parameter", ErrInvalidKeySet)
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
+
+func encodeBigInt(value *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(value.Bytes())
+}
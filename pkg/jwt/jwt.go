@@ -0,0 +1,267 @@
+// Package jwt verifies JSON Web Tokens (RFC 7519) signed with RS256 or ES256, as issued by OIDC providers.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/zhulik/d3/pkg/json"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+var (
+	ErrMalformedToken       = errors.New("malformed token")
+	ErrUnsupportedAlgorithm = errors.New("unsupported algorithm")
+	ErrUnknownKey           = errors.New("unknown key")
+	ErrInvalidSignature     = errors.New("invalid signature")
+	ErrTokenExpired         = errors.New("token expired")
+	ErrTokenNotYetValid     = errors.New("token not yet valid")
+	ErrInvalidIssuer        = errors.New("invalid issuer")
+	ErrInvalidAudience      = errors.New("invalid audience")
+	ErrInvalidKeySet        = errors.New("invalid key set")
+)
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// Claims is the decoded payload of a token. Numbers are decoded as float64.
+type Claims map[string]any
+
+// String returns a string claim, or "" if it is missing or not a string.
+func (c Claims) String(name string) string {
+	value, _ := c[name].(string)
+
+	return value
+}
+
+// Audience returns the aud claim, which may be a single string or a list of them.
+func (c Claims) Audience() []string {
+	switch aud := c["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []any:
+		audience := make([]string, 0, len(aud))
+
+		for _, value := range aud {
+			if value, ok := value.(string); ok {
+				audience = append(audience, value)
+			}
+		}
+
+		return audience
+	default:
+		return nil
+	}
+}
+
+func (c Claims) time(name string) (time.Time, bool) {
+	value, ok := c[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(value), 0), true
+}
+
+// Expectations are what a token must satisfy besides a valid signature.
+type Expectations struct {
+	Issuer   string
+	Audience string
+	Now      time.Time
+	// Leeway tolerates clock skew between the issuer and this host.
+	Leeway time.Duration
+}
+
+// Verify checks the token's signature with the key it names, its issuer, audience and validity period, and
+// returns its claims. Tokens without an exp claim are rejected.
+func Verify(token string, keys *KeySet, expected Expectations) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 { //nolint:mnd
+		return nil, ErrMalformedToken
+	}
+
+	hdr, err := decodeSegment[header](parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := keys.Key(hdr.Kid)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, hdr.Kid)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedToken, err)
+	}
+
+	err = verifySignature(hdr.Alg, key, parts[0]+"."+parts[1], signature)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := decodeSegment[Claims](parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, validateClaims(claims, expected)
+}
+
+// Sign issues a token signed with an RSA (RS256) or P-256 EC (ES256) private key.
+func Sign(claims Claims, kid string, key crypto.Signer) (string, error) {
+	var alg string
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		alg = AlgRS256
+	case *ecdsa.PrivateKey:
+		if key.Curve.Params().Name != "P-256" {
+			return "", ErrUnsupportedAlgorithm
+		}
+
+		alg = AlgES256
+	default:
+		return "", ErrUnsupportedAlgorithm
+	}
+
+	hdr, err := encodeSegment(header{Alg: alg, Kid: kid, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := encodeSegment(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := hdr + "." + payload
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+
+		r, s, err = ecdsa.Sign(rand.Reader, key, digest[:])
+		if err == nil {
+			signature = append(r.FillBytes(make([]byte, p256CoordinateSize)), s.FillBytes(make([]byte, p256CoordinateSize))...)
+		}
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// verifySignature requires the algorithm to match the key type, so a token cannot pick a weaker check.
+func verifySignature(alg string, key crypto.PublicKey, signingInput string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if alg != AlgRS256 {
+			return fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+		}
+
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+			return ErrInvalidSignature
+		}
+	case *ecdsa.PublicKey:
+		if alg != AlgES256 {
+			return fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+		}
+
+		if len(signature) != 2*p256CoordinateSize {
+			return ErrInvalidSignature
+		}
+
+		r := new(big.Int).SetBytes(signature[:p256CoordinateSize])
+		s := new(big.Int).SetBytes(signature[p256CoordinateSize:])
+
+		if !ecdsa.Verify(key, digest[:], r, s) {
+			return ErrInvalidSignature
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+	}
+
+	return nil
+}
+
+func validateClaims(claims Claims, expected Expectations) error {
+	if expected.Issuer != "" && claims.String("iss") != expected.Issuer {
+		return fmt.Errorf("%w: %q", ErrInvalidIssuer, claims.String("iss"))
+	}
+
+	if expected.Audience != "" && !slices.Contains(claims.Audience(), expected.Audience) {
+		return fmt.Errorf("%w: %q", ErrInvalidAudience, claims.Audience())
+	}
+
+	now := expected.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	expiresAt, ok := claims.time("exp")
+	if !ok {
+		return fmt.Errorf("%w: missing exp claim", ErrMalformedToken)
+	}
+
+	if !now.Before(expiresAt.Add(expected.Leeway)) {
+		return ErrTokenExpired
+	}
+
+	if notBefore, ok := claims.time("nbf"); ok && now.Add(expected.Leeway).Before(notBefore) {
+		return ErrTokenNotYetValid
+	}
+
+	return nil
+}
+
+func decodeSegment[T any](segment string) (T, error) {
+	var value T
+
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return value, fmt.Errorf("%w: %w", ErrMalformedToken, err)
+	}
+
+	value, err = json.Unmarshal[T](data)
+	if err != nil {
+		return value, fmt.Errorf("%w: %w", ErrMalformedToken, err)
+	}
+
+	return value, nil
+}
+
+func encodeSegment[T any](value T) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,134 @@
+package jwt_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/pkg/json"
+	"github.com/zhulik/d3/pkg/jwt"
+)
+
+var _ = Describe("JWT", func() {
+	var (
+		rsaKey   *rsa.PrivateKey
+		ecKey    *ecdsa.PrivateKey
+		keySet   *jwt.KeySet
+		expected jwt.Expectations
+	)
+
+	claims := func() jwt.Claims {
+		return jwt.Claims{
+			"iss": "https://ci.example.com",
+			"aud": []string{"d3", "other"},
+			"sub": "repo:org/app:ref:refs/heads/main",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	BeforeEach(func() {
+		rsaKey = lo.Must(rsa.GenerateKey(rand.Reader, 2048))
+		ecKey = lo.Must(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+
+		keySet = lo.Must(jwt.ParseKeySet(lo.Must(json.Marshal(jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{
+			lo.Must(jwt.NewJSONWebKey("rsa", &rsaKey.PublicKey)),
+			lo.Must(jwt.NewJSONWebKey("ec", &ecKey.PublicKey)),
+			{Kty: "oct", Kid: "symmetric"},
+		}}))))
+
+		expected = jwt.Expectations{Issuer: "https://ci.example.com", Audience: "d3"}
+	})
+
+	DescribeTable("verifies signed tokens",
+		func(kid string, key func() crypto.Signer) {
+			token := lo.Must(jwt.Sign(claims(), kid, key()))
+
+			verified, err := jwt.Verify(token, keySet, expected)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(verified.String("sub")).To(Equal("repo:org/app:ref:refs/heads/main"))
+			Expect(verified.Audience()).To(Equal([]string{"d3", "other"}))
+		},
+		Entry("RS256", "rsa", func() crypto.Signer { return rsaKey }),
+		Entry("ES256", "ec", func() crypto.Signer { return ecKey }),
+	)
+
+	DescribeTable("rejects invalid tokens",
+		func(token func() string, expectedErr error) {
+			_, err := jwt.Verify(token(), keySet, expected)
+			Expect(err).To(MatchError(expectedErr))
+		},
+		Entry("garbage", func() string { return "not-a-token" }, jwt.ErrMalformedToken),
+		Entry("unknown key", func() string {
+			return lo.Must(jwt.Sign(claims(), "missing", rsaKey))
+		}, jwt.ErrUnknownKey),
+		Entry("signed with another key", func() string {
+			return lo.Must(jwt.Sign(claims(), "rsa", lo.Must(rsa.GenerateKey(rand.Reader, 2048))))
+		}, jwt.ErrInvalidSignature),
+		Entry("algorithm not matching the key", func() string {
+			return lo.Must(jwt.Sign(claims(), "ec", rsaKey))
+		}, jwt.ErrUnsupportedAlgorithm),
+		Entry("tampered payload", func() string {
+			parts := strings.Split(lo.Must(jwt.Sign(claims(), "rsa", rsaKey)), ".")
+			forged := claims()
+			forged["sub"] = "repo:org/other"
+			parts[1] = strings.Split(lo.Must(jwt.Sign(forged, "rsa", rsaKey)), ".")[1]
+
+			return strings.Join(parts, ".")
+		}, jwt.ErrInvalidSignature),
+		Entry("wrong issuer", func() string {
+			c := claims()
+			c["iss"] = "https://evil.example.com"
+
+			return lo.Must(jwt.Sign(c, "rsa", rsaKey))
+		}, jwt.ErrInvalidIssuer),
+		Entry("wrong audience", func() string {
+			c := claims()
+			c["aud"] = "someone-else"
+
+			return lo.Must(jwt.Sign(c, "rsa", rsaKey))
+		}, jwt.ErrInvalidAudience),
+		Entry("expired", func() string {
+			c := claims()
+			c["exp"] = time.Now().Add(-time.Minute).Unix()
+
+			return lo.Must(jwt.Sign(c, "rsa", rsaKey))
+		}, jwt.ErrTokenExpired),
+		Entry("without expiration", func() string {
+			c := claims()
+			delete(c, "exp")
+
+			return lo.Must(jwt.Sign(c, "rsa", rsaKey))
+		}, jwt.ErrMalformedToken),
+		Entry("not yet valid", func() string {
+			c := claims()
+			c["nbf"] = time.Now().Add(time.Minute).Unix()
+
+			return lo.Must(jwt.Sign(c, "rsa", rsaKey))
+		}, jwt.ErrTokenNotYetValid),
+	)
+
+	When("the key set has a single key", func() {
+		It("verifies tokens without a key ID", func() {
+			keySet = lo.Must(jwt.ParseKeySet(lo.Must(json.Marshal(jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{
+				lo.Must(jwt.NewJSONWebKey("", &ecKey.PublicKey)),
+			}}))))
+
+			_, err := jwt.Verify(lo.Must(jwt.Sign(claims(), "", ecKey)), keySet, expected)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	When("the key set is malformed", func() {
+		It("returns invalid key set error", func() {
+			_, err := jwt.ParseKeySet([]byte(`{"keys": [{"kty": "RSA", "n": "!", "e": "AQAB"}]}`))
+			Expect(err).To(MatchError(jwt.ErrInvalidKeySet))
+		})
+	})
+})
@@ -10,6 +10,16 @@ const (
 	ListBuckets       Action = "s3:ListBuckets"
 	DeleteBucket      Action = "s3:DeleteBucket"
 	GetBucketLocation Action = "s3:GetBucketLocation"
+	GetBucketLogging  Action = "s3:GetBucketLogging"
+	PutBucketLogging  Action = "s3:PutBucketLogging"
+	// GetEncryptionConfiguration and PutEncryptionConfiguration cover the default encryption of buckets, the
+	// latter also authorizes removing it.
+	GetEncryptionConfiguration Action = "s3:GetEncryptionConfiguration"
+	PutEncryptionConfiguration Action = "s3:PutEncryptionConfiguration"
+	// GetBucketObjectLockConfiguration and PutBucketObjectLockConfiguration cover enabling Object Lock and the
+	// default retention of buckets.
+	GetBucketObjectLockConfiguration Action = "s3:GetBucketObjectLockConfiguration"
+	PutBucketObjectLockConfiguration Action = "s3:PutBucketObjectLockConfiguration"
 
 	PutObject               Action = "s3:PutObject"
 	GetObject               Action = "s3:GetObject"
@@ -26,6 +36,13 @@ const (
 	GetObjectTagging        Action = "s3:GetObjectTagging"
 	PutObjectTagging        Action = "s3:PutObjectTagging"
 	DeleteObjectTagging     Action = "s3:DeleteObjectTagging"
+	GetObjectRetention      Action = "s3:GetObjectRetention"
+	PutObjectRetention      Action = "s3:PutObjectRetention"
+	GetObjectLegalHold      Action = "s3:GetObjectLegalHold"
+	PutObjectLegalHold      Action = "s3:PutObjectLegalHold"
+	// BypassGovernanceRetention is never the action of a request, it is checked on top of it when the request asks
+	// to delete, overwrite or shorten the retention of objects under GOVERNANCE retention.
+	BypassGovernanceRetention Action = "s3:BypassGovernanceRetention"
 )
 
 var (
@@ -36,6 +53,12 @@ var (
 		DeleteBucket,
 		CreateBucket,
 		GetBucketLocation,
+		GetBucketLogging,
+		PutBucketLogging,
+		GetEncryptionConfiguration,
+		PutEncryptionConfiguration,
+		GetBucketObjectLockConfiguration,
+		PutBucketObjectLockConfiguration,
 		PutObject,
 		GetObject,
 		HeadObject,
@@ -51,5 +74,10 @@ var (
 		GetObjectTagging,
 		PutObjectTagging,
 		DeleteObjectTagging,
+		GetObjectRetention,
+		PutObjectRetention,
+		GetObjectLegalHold,
+		PutObjectLegalHold,
+		BypassGovernanceRetention,
 	}
 )
@@ -0,0 +1,150 @@
+package sigv2_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	minioSigner "github.com/minio/minio-go/v7/pkg/signer"
+	"github.com/zhulik/d3/pkg/sigv2"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var errUnknownKey = errors.New("unknown key")
+
+func resolveSecret(_ context.Context, accessKey string) (string, error) {
+	if accessKey != "test" {
+		return "", errUnknownKey
+	}
+
+	return "secret", nil
+}
+
+func sign(stringToSign string) string {
+	mac := hmac.New(sha1.New, []byte("secret"))
+	mac.Write([]byte(stringToSign))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+var _ = Describe("Validate", func() {
+	When("the request is signed in the Authorization header", func() {
+		DescribeTable("validates a valid request", func(ctx context.Context, method, url string) {
+			req := httptest.NewRequest(method, url, nil)
+			req.Header.Set("Content-Type", "text/plain")
+			req.Header.Set("X-Amz-Meta-Foo", "bar")
+
+			req = minioSigner.SignV2(*req, "test", "secret", false)
+
+			params, err := sigv2.Validate(ctx, req, resolveSecret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(params).To(Equal(&sigv2.AuthParameters{AccessKey: "test"}))
+		},
+			Entry("GET object", http.MethodGet, "http://127.0.0.1:8080/bucket/key.txt"),
+			Entry("PUT object", http.MethodPut, "http://127.0.0.1:8080/bucket/dir/key.txt"),
+			Entry("list objects", http.MethodGet, "http://127.0.0.1:8080/bucket/?prefix=dir&max-keys=10"),
+			Entry("subresources", http.MethodGet, "http://127.0.0.1:8080/bucket/key.txt?uploadId=abc&partNumber=2"),
+			Entry("list buckets", http.MethodGet, "http://127.0.0.1:8080/"),
+		)
+
+		It("signs an empty date when X-Amz-Date is set", func(ctx context.Context) {
+			now := time.Now().UTC().Format(http.TimeFormat)
+
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8080/bucket/key.txt", nil)
+			req.Header.Set("X-Amz-Date", now)
+			req.Header.Set("Date", "ignored")
+			req.Header.Set("Authorization", "AWS test:"+sign("GET\n\n\n\nx-amz-date:"+now+"\n/bucket/key.txt"))
+
+			_, err := sigv2.Validate(ctx, req, resolveSecret)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects a tampered request", func(ctx context.Context) {
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8080/bucket/key.txt", nil)
+			req = minioSigner.SignV2(*req, "test", "secret", false)
+			req.URL.Path = "/bucket/other.txt"
+
+			_, err := sigv2.Validate(ctx, req, resolveSecret)
+			Expect(err).To(MatchError(sigv2.ErrSignatureDoesNotMatch))
+		})
+
+		It("rejects a wrong secret", func(ctx context.Context) {
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8080/bucket/key.txt", nil)
+			req = minioSigner.SignV2(*req, "test", "wrong", false)
+
+			_, err := sigv2.Validate(ctx, req, resolveSecret)
+			Expect(err).To(MatchError(sigv2.ErrSignatureDoesNotMatch))
+		})
+
+		It("rejects unknown access keys", func(ctx context.Context) {
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8080/bucket/key.txt", nil)
+			req = minioSigner.SignV2(*req, "unknown", "secret", false)
+
+			_, err := sigv2.Validate(ctx, req, resolveSecret)
+			Expect(err).To(MatchError(sigv2.ErrInvalidAccessKeyID))
+		})
+
+		It("rejects stale requests", func(ctx context.Context) {
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8080/bucket/key.txt", nil)
+			req.Header.Set("Date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+			req = minioSigner.SignV2(*req, "test", "secret", false)
+
+			_, err := sigv2.Validate(ctx, req, resolveSecret)
+			Expect(err).To(MatchError(sigv2.ErrRequestTimeTooSkewed))
+		})
+
+		It("rejects malformed headers", func(ctx context.Context) {
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8080/bucket/key.txt", nil)
+			req.Header.Set("Authorization", "AWS test")
+
+			_, err := sigv2.Validate(ctx, req, resolveSecret)
+			Expect(err).To(MatchError(sigv2.ErrAuthHeaderMalformed))
+		})
+	})
+
+	When("the request is presigned", func() {
+		It("validates a valid URL", func(ctx context.Context) {
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8080/bucket/key.txt?versionId=1", nil)
+			req = minioSigner.PreSignV2(*req, "test", "secret", 60, false)
+
+			params, err := sigv2.Validate(ctx, httptest.NewRequest(http.MethodGet, req.URL.String(), nil), resolveSecret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(params).To(Equal(&sigv2.AuthParameters{AccessKey: "test", Presigned: true}))
+		})
+
+		It("rejects an expired URL", func(ctx context.Context) {
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8080/bucket/key.txt", nil)
+			req = minioSigner.PreSignV2(*req, "test", "secret", -60, false)
+
+			_, err := sigv2.Validate(ctx, httptest.NewRequest(http.MethodGet, req.URL.String(), nil), resolveSecret)
+			Expect(err).To(MatchError(sigv2.ErrExpiredPresignRequest))
+		})
+
+		It("rejects a URL used for another object", func(ctx context.Context) {
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8080/bucket/key.txt", nil)
+			req = minioSigner.PreSignV2(*req, "test", "secret", 60, false)
+
+			url := strings.Replace(req.URL.String(), "key.txt", "other.txt", 1)
+
+			_, err := sigv2.Validate(ctx, httptest.NewRequest(http.MethodGet, url, nil), resolveSecret)
+			Expect(err).To(MatchError(sigv2.ErrSignatureDoesNotMatch))
+		})
+	})
+
+	When("the request is not signed", func() {
+		It("returns ErrRequestNotSigned", func(ctx context.Context) {
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8080/bucket/key.txt", nil)
+
+			_, err := sigv2.Validate(ctx, req, resolveSecret)
+			Expect(err).To(MatchError(sigv2.ErrRequestNotSigned))
+		})
+	})
+})
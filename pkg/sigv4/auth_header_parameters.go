@@ -21,13 +21,17 @@ type AuthHeaderParameters struct {
 }
 
 func (hp *AuthHeaderParameters) Validate() error {
+	return hp.validate(serviceS3)
+}
+
+func (hp *AuthHeaderParameters) validate(service string) error {
 	if hp.Algo != "AWS4-HMAC-SHA256" || hp.AccessKey == "" || hp.SignedHeaders == "" ||
 		len(hp.Signature) == 0 || hp.RequestTime.IsZero() {
 		return fmt.Errorf("%w: signature or request time missing", ErrSignatureDoesNotMatch)
 	}
 
 	// Minimal validation of scope
-	if hp.ScopeDate.IsZero() || hp.ScopeRegion == "" || hp.ScopeService != "s3" {
+	if hp.ScopeDate.IsZero() || hp.ScopeRegion == "" || hp.ScopeService != service {
 		return ErrCredMalformed
 	}
 
@@ -1,6 +1,7 @@
 package sigv4
 
 import (
+	"crypto/sha256"
 	"encoding/hex"
 	"hash"
 	"strings"
@@ -47,6 +48,24 @@ func (s *ChunkSigner) GetSignatureByHash(payloadHash hash.Hash) []byte {
 	return signature
 }
 
+// GetTrailerSignature takes the trailing headers of a payload and returns their signature.
+func (s *ChunkSigner) GetTrailerSignature(trailer []byte) []byte {
+	sigKey := deriveSigningKey(s.region, s.service, s.secretAccessKey, s.seedDate)
+	scope := buildSigningScope(s.region, s.service, s.seedDate)
+	trailerHash := sha256.Sum256(trailer)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256-TRAILER",
+		s.seedDate.UTC().Format(TimeFormat),
+		scope,
+		hex.EncodeToString(s.prevSig),
+		hex.EncodeToString(trailerHash[:]),
+	}, "\n")
+	signature := hmacSHA256(sigKey, stringToSign)
+	s.prevSig = signature
+
+	return signature
+}
+
 func buildStringToSign(payloadHash, prevSig []byte, scope string, date time.Time) string {
 	return strings.Join([]string{
 		"AWS4-HMAC-SHA256-PAYLOAD",
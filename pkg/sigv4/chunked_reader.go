@@ -57,8 +57,11 @@ type chunkedReader struct {
 	n              uint64 // unread bytes in chunk
 	err            error
 	buf            [2]byte
-	checkEnd       bool // whether need to check for \r\n chunk footer
-	streamSigner   *ChunkSigner
+	checkEnd       bool         // whether need to check for \r\n chunk footer
+	streamSigner   *ChunkSigner // nil for unsigned payloads
+	trailer        bool         // whether trailing headers follow the final chunk
+	checksumName   string       // name of the trailing checksum header
+	checksum       hash.Hash    // running checksum of the data, verified against the trailer
 }
 
 // Read gets data from reader. Implements [io.ReadCloser].
@@ -120,10 +123,16 @@ func (cr *chunkedReader) Read(b []uint8) (int, error) { //nolint:gocognit,funlen
 		cr.n -= uint64(n0) //nolint:gosec
 		// Hashing chunk data to calculate the signature.
 		// rbuf may contain payload and empty bytes, taking only payload.
-		if _, err := cr.chunkHash.Write(rbuf[:n0]); err != nil {
-			cr.err = err
+		if cr.chunkHash != nil {
+			if _, err := cr.chunkHash.Write(rbuf[:n0]); err != nil {
+				cr.err = err
 
-			break
+				break
+			}
+		}
+
+		if cr.checksum != nil {
+			cr.checksum.Write(rbuf[:n0])
 		}
 
 		// If we're at the end of a chunk, read the next two
@@ -147,7 +156,7 @@ func (cr *chunkedReader) beginChunk() {
 	// chunk-size CRLF
 	var line, chunkSignature []byte
 
-	line, chunkSignature, cr.err = readChunkLine(cr.r)
+	line, chunkSignature, cr.err = readChunkLine(cr.r, cr.streamSigner != nil)
 	if cr.err != nil {
 		return
 	}
@@ -164,10 +173,13 @@ func (cr *chunkedReader) beginChunk() {
 	}
 
 	// creating instance here to avoid validating non-existent chunk in the first validatePreviousChunkData call.
-	if cr.chunkHash == nil {
-		cr.chunkHash = sha256.New()
-	} else {
-		cr.chunkHash.Reset()
+	// Unsigned chunks have no signature to validate.
+	if cr.streamSigner != nil {
+		if cr.chunkHash == nil {
+			cr.chunkHash = sha256.New()
+		} else {
+			cr.chunkHash.Reset()
+		}
 	}
 
 	cr.chunkSignature = string(chunkSignature)
@@ -179,6 +191,14 @@ func (cr *chunkedReader) beginChunk() {
 			return
 		}
 
+		if cr.trailer {
+			if err := cr.readTrailer(); err != nil {
+				cr.err = err
+
+				return
+			}
+		}
+
 		cr.err = io.EOF
 	}
 }
@@ -206,32 +226,21 @@ func (cr *chunkedReader) chunkHeaderAvailable() bool {
 	return false
 }
 
-// Read a line of bytes (up to \n) from b.
-// Give up if the line exceeds maxLineLength.
-// The returned bytes are owned by the bufio.Reader
-// so they are only valid until the next bufio read.
-func readChunkLine(b *bufio.Reader) ([]byte, []byte, error) {
-	p, err := b.ReadSlice('\n')
+// Read a chunk header line from b, its size and its signature. Unsigned chunks have no signature.
+func readChunkLine(b *bufio.Reader, signed bool) ([]byte, []byte, error) {
+	p, err := readLine(b)
 	if err != nil {
-		// We always know when EOF is coming.
-		// If the caller asked for a line, there should be a line.
-		if errors.Is(err, io.EOF) {
-			err = io.ErrUnexpectedEOF
-		} else if errors.Is(err, bufio.ErrBufferFull) {
-			err = ErrLineTooLong
-		}
-
 		return nil, nil, err
 	}
 
-	if len(p) >= maxLineLength {
-		return nil, nil, ErrLineTooLong
+	if !signed {
+		p, _, _ = bytes.Cut(p, []byte(";"))
+
+		return p, nil, nil
 	}
 
 	var signaturePart []byte
 
-	p = trimTrailingWhitespace(p)
-
 	p, signaturePart, err = removeChunkExtension(p)
 	if err != nil {
 		return nil, nil, err
@@ -246,6 +255,31 @@ func readChunkLine(b *bufio.Reader) ([]byte, []byte, error) {
 	return p, after, nil
 }
 
+// Read a line of bytes (up to \n) from b, without trailing whitespace.
+// Give up if the line exceeds maxLineLength.
+// The returned bytes are owned by the bufio.Reader
+// so they are only valid until the next bufio read.
+func readLine(b *bufio.Reader) ([]byte, error) {
+	p, err := b.ReadSlice('\n')
+	if err != nil {
+		// We always know when EOF is coming.
+		// If the caller asked for a line, there should be a line.
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		} else if errors.Is(err, bufio.ErrBufferFull) {
+			err = ErrLineTooLong
+		}
+
+		return nil, err
+	}
+
+	if len(p) >= maxLineLength {
+		return nil, ErrLineTooLong
+	}
+
+	return trimTrailingWhitespace(p), nil
+}
+
 func trimTrailingWhitespace(b []byte) []byte {
 	for len(b) > 0 && isASCIISpace(b[len(b)-1]) {
 		b = b[:len(b)-1]
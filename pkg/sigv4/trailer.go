@@ -0,0 +1,146 @@
+package sigv4
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+	"strings"
+)
+
+const (
+	trailerSignatureHeader = "x-amz-trailer-signature"
+
+	// maxTrailerLines bounds the trailing headers: SDKs send the checksum, the signature and a few blank lines.
+	maxTrailerLines = 8
+
+	// crc64NVME is the reversed NVMe polynomial, as crc64.MakeTable expects it.
+	crc64NVME = 0x9a6c9329ac4bc9b5
+)
+
+var (
+	// ErrInvalidTrailerSignature appears if passed trailer signature differs from calculated.
+	ErrInvalidTrailerSignature = errors.New("invalid trailer signature")
+
+	ErrTrailerMalformed = errors.New("trailer is malformed")
+
+	ErrUnsupportedChecksum = errors.New("unsupported checksum algorithm")
+
+	// ErrChecksumMismatch appears if the trailing checksum differs from the checksum of the payload.
+	ErrChecksumMismatch = errors.New("trailing checksum does not match the payload")
+)
+
+// NewTrailerChunkedReader returns a new chunkedReader for aws-chunked payloads followed by trailing headers:
+// STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER ones, or STREAMING-UNSIGNED-PAYLOAD-TRAILER ones when streamSigner
+// is nil. trailer is the X-Amz-Trailer header, the name of the trailing checksum the decoded data is verified
+// against. The trailer signature of signed payloads is verified as well.
+func NewTrailerChunkedReader(r io.ReadCloser, streamSigner *ChunkSigner, trailer string) (io.ReadCloser, error) {
+	cr := &chunkedReader{
+		r:            bufio.NewReader(r),
+		origReader:   r,
+		streamSigner: streamSigner,
+		trailer:      true,
+	}
+
+	if trailer != "" {
+		checksum, err := newChecksum(trailer)
+		if err != nil {
+			return nil, err
+		}
+
+		cr.checksumName = strings.ToLower(trailer)
+		cr.checksum = checksum
+	}
+
+	return cr, nil
+}
+
+func newChecksum(name string) (hash.Hash, error) {
+	switch strings.ToLower(name) {
+	case "x-amz-checksum-crc32":
+		return crc32.NewIEEE(), nil
+	case "x-amz-checksum-crc32c":
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli)), nil
+	case "x-amz-checksum-crc64nvme":
+		return crc64.New(crc64.MakeTable(crc64NVME)), nil
+	case "x-amz-checksum-sha1":
+		return sha1.New(), nil //nolint:gosec
+	case "x-amz-checksum-sha256":
+		return sha256.New(), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChecksum, name)
+	}
+}
+
+// readTrailer reads the headers following the final chunk, verifies the trailer signature of signed payloads and
+// the trailing checksum. Unsigned trailers end with a blank line, signed ones with their signature.
+func (cr *chunkedReader) readTrailer() error {
+	var (
+		signed   bytes.Buffer
+		checksum string
+	)
+
+	for range maxTrailerLines {
+		line, err := readLine(cr.r)
+		if err != nil {
+			return err
+		}
+
+		if len(line) == 0 {
+			if cr.streamSigner == nil {
+				return cr.verifyChecksum(checksum)
+			}
+
+			continue
+		}
+
+		rawName, rawValue, ok := bytes.Cut(line, []byte{':'})
+		if !ok {
+			return fmt.Errorf("%w: missing ':' separator", ErrTrailerMalformed)
+		}
+
+		name := strings.ToLower(strings.TrimSpace(string(rawName)))
+		value := strings.TrimSpace(string(rawValue))
+
+		if name == trailerSignatureHeader && cr.streamSigner != nil {
+			if value != hex.EncodeToString(cr.streamSigner.GetTrailerSignature(signed.Bytes())) {
+				return ErrInvalidTrailerSignature
+			}
+
+			return cr.verifyChecksum(checksum)
+		}
+
+		// The trailer signature covers the headers as "name:value\n" lines.
+		signed.WriteString(name + ":" + value + "\n")
+
+		if name == cr.checksumName {
+			checksum = value
+		}
+	}
+
+	return fmt.Errorf("%w: too many lines", ErrTrailerMalformed)
+}
+
+func (cr *chunkedReader) verifyChecksum(value string) error {
+	if cr.checksum == nil {
+		return nil
+	}
+
+	if value == "" {
+		return fmt.Errorf("%w: missing %s", ErrTrailerMalformed, cr.checksumName)
+	}
+
+	if value != base64.StdEncoding.EncodeToString(cr.checksum.Sum(nil)) {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, cr.checksumName)
+	}
+
+	return nil
+}
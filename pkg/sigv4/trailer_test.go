@@ -0,0 +1,155 @@
+package sigv4_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	minioSigner "github.com/minio/minio-go/v7/pkg/signer"
+	"github.com/samber/lo"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/zhulik/d3/pkg/sigv4"
+)
+
+// sha256Hasher adapts sha256 to the hasher minio's streaming signer expects.
+type sha256Hasher struct {
+	hash.Hash
+}
+
+func (sha256Hasher) Close() {}
+
+// unsignedTrailerBody encodes content the way aws-sdk-go-v2 does: unsigned chunks, the trailer, a blank line.
+func unsignedTrailerBody(trailer string, chunks ...string) io.ReadCloser {
+	var body strings.Builder
+
+	for _, chunk := range chunks {
+		fmt.Fprintf(&body, "%x\r\n%s\r\n", len(chunk), chunk)
+	}
+
+	fmt.Fprintf(&body, "0\r\n%s\r\n\r\n", trailer)
+
+	return io.NopCloser(strings.NewReader(body.String()))
+}
+
+func binaryCRC32(content []byte) []byte {
+	h := crc32.NewIEEE()
+	h.Write(content)
+
+	return h.Sum(nil)
+}
+
+var _ = Describe("NewTrailerChunkedReader", func() {
+	credentialStore := credentialStore{}
+
+	When("the payload is unsigned", func() {
+		DescribeTable("verifies the trailing checksum",
+			func(trailer, checksum string) {
+				reader := lo.Must(sigv4.NewTrailerChunkedReader(
+					unsignedTrailerBody(trailer+":"+checksum, "hello", " world"), nil, trailer,
+				))
+
+				Expect(io.ReadAll(reader)).To(Equal([]byte("hello world")))
+			},
+			Entry("crc32", "x-amz-checksum-crc32", "DUoRhQ=="),
+			Entry("crc32c", "x-amz-checksum-crc32c", "yZRlqg=="),
+			Entry("crc64nvme", "x-amz-checksum-crc64nvme", "jSnVw/bqjr4="),
+			Entry("sha1", "x-amz-checksum-sha1", "Kq5sNclPz7QV2+lfQIuc6R7oRu0="),
+			Entry("sha256", "x-amz-checksum-sha256", "uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek="),
+		)
+
+		It("rejects a checksum that does not match the data", func() {
+			reader := lo.Must(sigv4.NewTrailerChunkedReader(
+				unsignedTrailerBody("x-amz-checksum-crc32:yZRlqg==", "hello world"), nil, "x-amz-checksum-crc32",
+			))
+
+			_, err := io.ReadAll(reader)
+			Expect(err).To(MatchError(sigv4.ErrChecksumMismatch))
+		})
+
+		It("rejects a missing checksum", func() {
+			reader := lo.Must(sigv4.NewTrailerChunkedReader(
+				unsignedTrailerBody("x-amz-meta-foo:bar", "hello world"), nil, "x-amz-checksum-crc32",
+			))
+
+			_, err := io.ReadAll(reader)
+			Expect(err).To(MatchError(sigv4.ErrTrailerMalformed))
+		})
+
+		It("rejects unsupported checksums", func() {
+			_, err := sigv4.NewTrailerChunkedReader(unsignedTrailerBody(""), nil, "x-amz-checksum-md5")
+			Expect(err).To(MatchError(sigv4.ErrUnsupportedChecksum))
+		})
+
+		It("decodes payloads encoded by minio", func() {
+			content := bytes.Repeat([]byte("minio"), 30000)
+
+			req := httptest.NewRequest(http.MethodPut, "http://127.0.0.1:8080/bucket/key", bytes.NewReader(content))
+			req = minioSigner.UnsignedTrailer(*req, http.Header{
+				"X-Amz-Checksum-Crc32": {base64.StdEncoding.EncodeToString(binaryCRC32(content))},
+			})
+
+			reader := lo.Must(sigv4.NewTrailerChunkedReader(req.Body, nil, req.Header.Get("X-Amz-Trailer")))
+			Expect(io.ReadAll(reader)).To(Equal(content))
+		})
+	})
+
+	When("the payload is signed", func() {
+		content := bytes.Repeat([]byte("signed"), 30000)
+
+		signedRequest := func(ctx context.Context, checksum string) (*http.Request, *sigv4.ChunkSigner) {
+			req := httptest.NewRequest(http.MethodPut, "http://127.0.0.1:8080/bucket/key", bytes.NewReader(content))
+			req.Header.Set("Host", "127.0.0.1:8080")
+			req.Trailer = http.Header{"X-Amz-Checksum-Crc32": {checksum}}
+
+			req = minioSigner.StreamingSignV4(req, "test", "test", "", "local", int64(len(content)),
+				time.Now().UTC(), sha256Hasher{sha256.New()})
+
+			authParams := lo.Must(sigv4.Validate(ctx, req, credentialStore.getAccessKeySecret))
+
+			return req, sigv4.NewChunkSigner(authParams.ScopeRegion, authParams.ScopeService,
+				authParams.RawSignature(), authParams.RequestTime, "test", "test")
+		}
+
+		It("verifies the chunk and trailer signatures and the checksum", func(ctx context.Context) {
+			req, signer := signedRequest(ctx, base64.StdEncoding.EncodeToString(binaryCRC32(content)))
+			Expect(req.Header.Get("X-Amz-Content-Sha256")).To(Equal("STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"))
+
+			reader := lo.Must(sigv4.NewTrailerChunkedReader(req.Body, signer, req.Header.Get("X-Amz-Trailer")))
+			Expect(io.ReadAll(reader)).To(Equal(content))
+		})
+
+		It("rejects a signed checksum that does not match the data", func(ctx context.Context) {
+			req, signer := signedRequest(ctx, "AAAAAA==")
+
+			reader := lo.Must(sigv4.NewTrailerChunkedReader(req.Body, signer, req.Header.Get("X-Amz-Trailer")))
+
+			_, err := io.ReadAll(reader)
+			Expect(err).To(MatchError(sigv4.ErrChecksumMismatch))
+		})
+
+		It("rejects a tampered trailer", func(ctx context.Context) {
+			checksum := base64.StdEncoding.EncodeToString(binaryCRC32(content))
+			req, signer := signedRequest(ctx, checksum)
+
+			body := bytes.Replace(lo.Must(io.ReadAll(req.Body)), []byte(checksum), []byte("AAAAAA=="), 1)
+
+			reader := lo.Must(sigv4.NewTrailerChunkedReader(
+				io.NopCloser(bytes.NewReader(body)), signer, req.Header.Get("X-Amz-Trailer"),
+			))
+
+			_, err := io.ReadAll(reader)
+			Expect(err).To(MatchError(sigv4.ErrInvalidTrailerSignature))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package sigv4
 
 import (
+	"cmp"
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
@@ -19,6 +20,8 @@ const (
 	ScopeAWS4Request = "aws4_request"
 	TimeFormat       = "20060102T150405Z"
 	ShortTimeFormat  = "20060102"
+
+	serviceS3 = "s3"
 )
 
 var (
@@ -36,7 +39,7 @@ var (
 type AccessKeyResolver func(ctx context.Context, accessKey string) (string, error)
 
 func Validate(ctx context.Context, r *http.Request, accessKeyResolver AccessKeyResolver) (*AuthHeaderParameters, error) { //nolint:lll
-	keyID, err := validate(ctx, r.Method, r.URL, r.Header, r.Host, accessKeyResolver)
+	keyID, err := validate(ctx, r.Method, r.URL, r.Header, r.Host, serviceS3, "", accessKeyResolver)
 	if errors.Is(err, ErrSignatureDoesNotMatch) {
 		// we want to retry the validation with a trailing slash
 
@@ -49,14 +52,21 @@ func Validate(ctx context.Context, r *http.Request, accessKeyResolver AccessKeyR
 		newURL := *r.URL
 		newURL.Path += "/"
 
-		return validate(ctx, r.Method, &newURL, r.Header, r.Host, accessKeyResolver)
+		return validate(ctx, r.Method, &newURL, r.Header, r.Host, serviceS3, "", accessKeyResolver)
 	}
 
-	return keyID, nil
+	return keyID, err
+}
+
+// ValidateService validates a request signed for an AWS service other than S3, such as STS. Those services
+// do not send X-Amz-Content-Sha256, so the caller hashes the body itself and passes it as payloadHash.
+func ValidateService(ctx context.Context, r *http.Request, service string, payloadHash string,
+	accessKeyResolver AccessKeyResolver) (*AuthHeaderParameters, error) {
+	return validate(ctx, r.Method, r.URL, r.Header, r.Host, service, payloadHash, accessKeyResolver)
 }
 
-func validate(ctx context.Context, method string, u *url.URL, header http.Header, host string, accessKeyResolver AccessKeyResolver) (*AuthHeaderParameters, error) { //nolint:lll
-	hp, err := extractAuthHeaderParameters(u, header)
+func validate(ctx context.Context, method string, u *url.URL, header http.Header, host string, service string, payloadHash string, accessKeyResolver AccessKeyResolver) (*AuthHeaderParameters, error) { //nolint:lll
+	hp, err := extractAuthHeaderParameters(u, header, service, payloadHash)
 	if err != nil {
 		return nil, err
 	}
@@ -102,7 +112,8 @@ func validate(ctx context.Context, method string, u *url.URL, header http.Header
 	return hp, nil
 }
 
-func extractAuthHeaderParameters(u *url.URL, header http.Header) (*AuthHeaderParameters, error) {
+func extractAuthHeaderParameters(u *url.URL, header http.Header, service string,
+	payloadHash string) (*AuthHeaderParameters, error) {
 	var headerParams *AuthHeaderParameters
 
 	qs := u.Query()
@@ -110,7 +121,7 @@ func extractAuthHeaderParameters(u *url.URL, header http.Header) (*AuthHeaderPar
 	var err error
 
 	if auth := header.Get("Authorization"); strings.HasPrefix(auth, "AWS4-HMAC-SHA256 ") {
-		headerParams, err = extractAuthHeadersParamsFromAuthHeader(header)
+		headerParams, err = extractAuthHeadersParamsFromAuthHeader(header, payloadHash)
 		if err != nil {
 			return nil, err
 		}
@@ -119,18 +130,22 @@ func extractAuthHeaderParameters(u *url.URL, header http.Header) (*AuthHeaderPar
 		if err != nil {
 			return nil, err
 		}
+
+		if payloadHash != "" {
+			headerParams.HashedPayload = payloadHash
+		}
 	} else {
 		return nil, ErrRequestNotSigned
 	}
 
-	if err := headerParams.Validate(); err != nil {
+	if err := headerParams.validate(service); err != nil {
 		return nil, err
 	}
 
 	return headerParams, nil
 }
 
-func extractAuthHeadersParamsFromAuthHeader(header http.Header) (*AuthHeaderParameters, error) {
+func extractAuthHeadersParamsFromAuthHeader(header http.Header, payloadHash string) (*AuthHeaderParameters, error) {
 	auth := header.Get("Authorization")
 
 	requestTime, err := time.Parse(TimeFormat, header.Get("X-Amz-Date"))
@@ -140,7 +155,7 @@ func extractAuthHeadersParamsFromAuthHeader(header http.Header) (*AuthHeaderPara
 
 	headerParams := &AuthHeaderParameters{
 		Algo:          AlgoHMAC256,
-		HashedPayload: header.Get("X-Amz-Content-Sha256"),
+		HashedPayload: cmp.Or(payloadHash, header.Get("X-Amz-Content-Sha256")),
 		RequestTime:   requestTime,
 	}
 
@@ -2,10 +2,17 @@ package sigv4_test
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/samber/lo"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -74,3 +81,74 @@ var _ = Describe("Validate", func() {
 		})
 	})
 })
+
+var _ = Describe("Validate errors", func() {
+	credentialStore := credentialStore{}
+
+	When("the request is not signed", func() {
+		It("returns request not signed error", func(ctx context.Context) {
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8080/foo/bar", nil)
+
+			authParams, err := sigv4.Validate(ctx, req, credentialStore.getAccessKeySecret)
+			Expect(err).To(MatchError(sigv4.ErrRequestNotSigned))
+			Expect(authParams).To(BeNil())
+		})
+	})
+
+	When("the request was tampered with", func() {
+		It("returns signature does not match error", func(ctx context.Context) {
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8080/foo/bar", nil)
+			req.Header.Set("Host", "127.0.0.1:8080")
+			signRequestAWS(ctx, req, "UNSIGNED-PAYLOAD")
+			req.URL.Path = "/foo/other"
+
+			authParams, err := sigv4.Validate(ctx, req, credentialStore.getAccessKeySecret)
+			Expect(err).To(MatchError(sigv4.ErrSignatureDoesNotMatch))
+			Expect(authParams).To(BeNil())
+		})
+	})
+})
+
+var _ = Describe("ValidateService", func() {
+	credentialStore := credentialStore{}
+
+	body := "Action=AssumeRole&Version=2011-06-15"
+	bodySum := fmt.Sprintf("%x", sha256.Sum256([]byte(body)))
+
+	signSTS := func(ctx context.Context) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://127.0.0.1:8080/", strings.NewReader(body))
+		// The server keeps Content-Length among the headers, httptest does not.
+		req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+
+		lo.Must0(awsSigner.SignHTTP(ctx, aws.Credentials{
+			AccessKeyID:     "test",
+			SecretAccessKey: "test",
+		}, req, bodySum, "sts", "local", time.Now()))
+
+		return req
+	}
+
+	When("the request is signed for the service", func() {
+		It("validates it without X-Amz-Content-Sha256", func(ctx context.Context) {
+			authParams, err := sigv4.ValidateService(ctx, signSTS(ctx), "sts", bodySum, credentialStore.getAccessKeySecret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(authParams.ScopeService).To(Equal("sts"))
+		})
+	})
+
+	When("the body does not match the signature", func() {
+		It("returns signature does not match error", func(ctx context.Context) {
+			otherSum := fmt.Sprintf("%x", sha256.Sum256([]byte("Action=Other")))
+
+			_, err := sigv4.ValidateService(ctx, signSTS(ctx), "sts", otherSum, credentialStore.getAccessKeySecret)
+			Expect(err).To(MatchError(sigv4.ErrSignatureDoesNotMatch))
+		})
+	})
+
+	When("the request is signed for another service", func() {
+		It("returns credential malformed error", func(ctx context.Context) {
+			_, err := sigv4.ValidateService(ctx, signSTS(ctx), "iam", bodySum, credentialStore.getAccessKeySecret)
+			Expect(err).To(MatchError(sigv4.ErrCredMalformed))
+		})
+	})
+})
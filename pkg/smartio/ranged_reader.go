@@ -51,17 +51,24 @@ func (r *RangedReader) Read(p []byte) (int, error) {
 		p = p[:maxRead]
 	}
 
-	n, err := r.reader.Read(p)
+	// Readers like the decrypting and decompressing ones return short reads at their chunk boundaries, only
+	// running out of data ends the range early.
+	var (
+		n   int
+		err error
+	)
+
+	for n < len(p) && err == nil {
+		var read int
+
+		read, err = r.reader.Read(p[n:])
+		n += read
+	}
+
 	r.current += int64(n)
 
 	// If we've reached the end of the range, return EOF
-	if r.current > r.end {
-		if err == nil {
-			err = io.EOF
-		}
-	} else if err == nil && n < len(p) {
-		// If underlying reader returned nil but read fewer bytes than requested,
-		// we've hit the end of the underlying data, so return EOF
+	if r.current > r.end && err == nil {
 		err = io.EOF
 	}
 
@@ -122,6 +122,18 @@ var _ = Describe("RangedReader", func() {
 			})
 		})
 
+		Context("short reads", func() {
+			It("keeps reading until the range is read", func() {
+				rr, err := smartio.NewRangedReader(&oneByteReader{bytes.NewReader(data)}, 5, 10)
+				Expect(err).NotTo(HaveOccurred())
+
+				buf := make([]byte, 10)
+				n, err := rr.Read(buf)
+				Expect(err).To(Equal(io.EOF))
+				Expect(string(buf[:n])).To(Equal("56789a"))
+			})
+		})
+
 		Context("edge cases", func() {
 			DescribeTable("handles range boundaries",
 				func(start, end int64, expectedN int, expectedContent string, _ string) {
@@ -271,6 +283,19 @@ func (f *failingSeeker) Seek(_ int64, _ int) (int64, error) {
 	return 0, io.ErrUnexpectedEOF
 }
 
+// oneByteReader reads a byte at a time, like readers that return short reads at their chunk boundaries.
+type oneByteReader struct {
+	io.ReadSeeker
+}
+
+func (o *oneByteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	return o.ReadSeeker.Read(p[:1])
+}
+
 type errorReader struct {
 	reader    io.ReadSeeker
 	readCount int
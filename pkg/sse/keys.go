@@ -0,0 +1,117 @@
+package sse
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidKeyring = errors.New("invalid master keyring")
+
+// WrapKey encrypts a data key with a key encryption key, the result is base64 encoded.
+func WrapKey(kek, key []byte) (string, error) {
+	return Seal(kek, key)
+}
+
+// UnwrapKey decrypts a data key wrapped with WrapKey, ErrDecryptionFailed means the key encryption key is wrong.
+func UnwrapKey(kek []byte, wrapped string) ([]byte, error) {
+	return Open(kek, wrapped)
+}
+
+// Seal encrypts a small value, like a data key or a checksum, with key. The result is base64 encoded.
+func Seal(key, value []byte) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce, err := random(aead.NonceSize())
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, value, nil)), nil
+}
+
+// Open decrypts a value sealed with Seal, ErrDecryptionFailed means the key is wrong.
+func Open(key []byte, sealed string) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecryptionFailed, err)
+	}
+
+	if len(raw) < aead.NonceSize() {
+		return nil, ErrDecryptionFailed
+	}
+
+	value, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+
+	return value, nil
+}
+
+// Keyring holds the master keys data keys are wrapped with. New data keys are wrapped with the current one, the
+// others are kept to unwrap the keys of existing data, which is how master keys are rotated.
+type Keyring struct {
+	currentID string
+	keys      map[string][]byte
+}
+
+// ParseKeyring parses master keys separated by newlines or commas, each as <id>:<base64 encoded 32 bytes>. The
+// first one is the current key. Blank lines and lines starting with # are ignored.
+func ParseKeyring(s string) (*Keyring, error) {
+	keyring := &Keyring{keys: map[string][]byte{}}
+
+	for entry := range strings.FieldsFuncSeq(s, func(r rune) bool { return r == '\n' || r == ',' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("%w: expected <id>:<base64 key>", ErrInvalidKeyring)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != KeySize {
+			return nil, fmt.Errorf("%w: key %q must be %d base64 encoded bytes", ErrInvalidKeyring, id, KeySize)
+		}
+
+		if _, ok := keyring.keys[id]; ok {
+			return nil, fmt.Errorf("%w: duplicate key %q", ErrInvalidKeyring, id)
+		}
+
+		if keyring.currentID == "" {
+			keyring.currentID = id
+		}
+
+		keyring.keys[id] = key
+	}
+
+	if keyring.currentID == "" {
+		return nil, fmt.Errorf("%w: no keys", ErrInvalidKeyring)
+	}
+
+	return keyring, nil
+}
+
+// Current returns the id and the value of the key new data keys are wrapped with.
+func (k *Keyring) Current() (string, []byte) {
+	return k.currentID, k.keys[k.currentID]
+}
+
+// Key returns the key with the given id.
+func (k *Keyring) Key(id string) ([]byte, bool) {
+	key, ok := k.keys[id]
+
+	return key, ok
+}
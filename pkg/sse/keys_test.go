@@ -0,0 +1,96 @@
+package sse_test
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/pkg/sse"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WrapKey and UnwrapKey", func() {
+	It("round-trips a data key", func() {
+		kek, key := lo.Must(sse.NewKey()), lo.Must(sse.NewKey())
+
+		wrapped := lo.Must(sse.WrapKey(kek, key))
+		Expect(sse.UnwrapKey(kek, wrapped)).To(Equal(key))
+	})
+
+	When("the key encryption key is wrong", func() {
+		It("fails to unwrap", func() {
+			wrapped := lo.Must(sse.WrapKey(lo.Must(sse.NewKey()), lo.Must(sse.NewKey())))
+
+			_, err := sse.UnwrapKey(lo.Must(sse.NewKey()), wrapped)
+			Expect(err).To(MatchError(sse.ErrDecryptionFailed))
+		})
+	})
+})
+
+var _ = Describe("Seal and Open", func() {
+	It("round-trips a value", func() {
+		key := lo.Must(sse.NewKey())
+
+		sealed := lo.Must(sse.Seal(key, []byte("checksum")))
+		Expect(sealed).NotTo(ContainSubstring(base64.StdEncoding.EncodeToString([]byte("checksum"))))
+		Expect(sse.Open(key, sealed)).To(Equal([]byte("checksum")))
+	})
+
+	It("seals the same value differently every time", func() {
+		key := lo.Must(sse.NewKey())
+
+		Expect(sse.Seal(key, []byte("checksum"))).NotTo(Equal(lo.Must(sse.Seal(key, []byte("checksum")))))
+	})
+
+	When("the sealed value is not base64", func() {
+		It("fails to open", func() {
+			_, err := sse.Open(lo.Must(sse.NewKey()), "not base64!")
+			Expect(err).To(MatchError(sse.ErrDecryptionFailed))
+		})
+	})
+})
+
+var _ = Describe("ParseKeyring", func() {
+	encodedKey := func() string {
+		return base64.StdEncoding.EncodeToString(lo.Must(sse.NewKey()))
+	}
+
+	It("makes the first key current and keeps the others", func() {
+		newKey, oldKey := encodedKey(), encodedKey()
+
+		keyring := lo.Must(sse.ParseKeyring(strings.Join([]string{
+			"# rotated on 2026-10-01", "new:" + newKey, "", "old:" + oldKey,
+		}, "\n")))
+
+		id, key := keyring.Current()
+		Expect(id).To(Equal("new"))
+		Expect(base64.StdEncoding.EncodeToString(key)).To(Equal(newKey))
+
+		key, ok := keyring.Key("old")
+		Expect(ok).To(BeTrue())
+		Expect(base64.StdEncoding.EncodeToString(key)).To(Equal(oldKey))
+
+		_, ok = keyring.Key("missing")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("accepts comma-separated keys", func() {
+		keyring := lo.Must(sse.ParseKeyring("a:" + encodedKey() + ",b:" + encodedKey()))
+
+		id, _ := keyring.Current()
+		Expect(id).To(Equal("a"))
+	})
+
+	DescribeTable("rejects invalid keyrings", func(keyring string) {
+		_, err := sse.ParseKeyring(keyring)
+		Expect(err).To(MatchError(sse.ErrInvalidKeyring))
+	},
+		Entry("empty", ""),
+		Entry("without id", encodedKey()),
+		Entry("not base64", "a:not-base64"),
+		Entry("wrong key size", "a:"+base64.StdEncoding.EncodeToString([]byte("short"))),
+		Entry("duplicate ids", "a:"+encodedKey()+",a:"+encodedKey()),
+	)
+})
@@ -0,0 +1,343 @@
+// Package sse encrypts data at rest with AES-256-GCM in a format that can be read from any offset.
+//
+// A stream is split into ChunkSize chunks of plaintext that are sealed separately, each with the stream nonce
+// followed by the big-endian index of the chunk, so decrypting a range only needs the chunks it overlaps. The
+// last chunk of a stream is sealed with different additional data, truncating a stream at a chunk boundary is
+// detected. An empty stream is a single empty chunk.
+//
+// Streams written separately with the same key, like the parts of a multipart upload, can be concatenated and
+// read back as one with NewReader, as long as their nonces differ.
+package sse
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+const (
+	// KeySize is the size of data and master keys, AES-256.
+	KeySize = 32
+	// NonceSize is the size of stream nonces, the rest of the GCM nonce is the chunk index.
+	NonceSize = 8
+	// ChunkSize is the amount of plaintext sealed at once.
+	ChunkSize = 64 * 1024
+	// Overhead is the size of the authentication tag added to every chunk.
+	Overhead = 16
+)
+
+var (
+	ErrInvalidKey       = errors.New("invalid encryption key")
+	ErrInvalidNonce     = errors.New("invalid nonce")
+	ErrDecryptionFailed = errors.New("decryption failed: data is corrupted or the key is wrong")
+	ErrWriterClosed     = errors.New("write to a closed encrypting writer")
+)
+
+//nolint:gochecknoglobals
+var (
+	additionalData      = []byte{0}
+	finalAdditionalData = []byte{1}
+)
+
+// NewKey returns a random key.
+func NewKey() ([]byte, error) {
+	return random(KeySize)
+}
+
+// NewNonce returns a random stream nonce.
+func NewNonce() ([]byte, error) {
+	return random(NonceSize)
+}
+
+// EncryptedSize is the size of a stream of size bytes of plaintext once encrypted.
+func EncryptedSize(size int64) int64 {
+	return size + chunkCount(size)*Overhead
+}
+
+// Writer encrypts what is written to it into a stream, Close seals the last chunk and must be called.
+type Writer struct {
+	dst    io.Writer
+	aead   cipher.AEAD
+	nonce  []byte
+	buf    []byte
+	index  uint32
+	closed bool
+}
+
+func NewWriter(dst io.Writer, key, nonce []byte) (*Writer, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nonce) != NonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	return &Writer{
+		dst:   dst,
+		aead:  aead,
+		nonce: slices.Clone(nonce),
+		buf:   make([]byte, 0, ChunkSize),
+	}, nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+
+	written := 0
+
+	for len(p) > 0 {
+		// A full chunk is only sealed once more data arrives, it may be the last one.
+		if len(w.buf) == ChunkSize {
+			if err := w.seal(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := copy(w.buf[len(w.buf):ChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+	}
+
+	return written, nil
+}
+
+// Close seals the last chunk, it does not close the destination.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+
+	return w.seal(true)
+}
+
+func (w *Writer) seal(final bool) error {
+	ad := additionalData
+	if final {
+		ad = finalAdditionalData
+	}
+
+	sealed := w.aead.Seal(nil, chunkNonce(w.nonce, w.index), w.buf, ad)
+
+	if _, err := w.dst.Write(sealed); err != nil {
+		return err
+	}
+
+	w.buf = w.buf[:0]
+	w.index++
+
+	return nil
+}
+
+// Segment is a stream within the data read by Reader.
+type Segment struct {
+	Nonce []byte
+	// Size is the size of the plaintext of the stream.
+	Size int64
+}
+
+type segment struct {
+	Segment
+
+	plainStart  int64
+	cipherStart int64
+}
+
+// Reader decrypts concatenated streams, sealed with the same key. It is an io.ReadSeeker over the plaintext,
+// the underlying reader is only read from the chunks that are needed.
+type Reader struct {
+	src      io.ReadSeeker
+	aead     cipher.AEAD
+	segments []segment
+	size     int64
+	pos      int64
+
+	// chunk is the plaintext of the chunk chunkIndex of the segment chunkSegment, it is kept between reads.
+	chunk        []byte
+	chunkSegment int
+	chunkIndex   int64
+	cipherBuf    []byte
+}
+
+func NewReader(src io.ReadSeeker, key []byte, segments []Segment) (*Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reader{
+		src:          src,
+		aead:         aead,
+		segments:     make([]segment, 0, len(segments)),
+		chunkSegment: -1,
+	}
+
+	var cipherStart int64
+
+	for _, s := range segments {
+		if len(s.Nonce) != NonceSize || s.Size < 0 {
+			return nil, ErrInvalidNonce
+		}
+
+		r.segments = append(r.segments, segment{Segment: s, plainStart: r.size, cipherStart: cipherStart})
+		r.size += s.Size
+		cipherStart += EncryptedSize(s.Size)
+	}
+
+	return r, nil
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.pos >= r.size {
+		return 0, io.EOF
+	}
+
+	segmentIndex := r.segmentAt(r.pos)
+	s := r.segments[segmentIndex]
+	offset := r.pos - s.plainStart
+	chunkIndex := offset / ChunkSize
+
+	if segmentIndex != r.chunkSegment || chunkIndex != r.chunkIndex {
+		if err := r.open(segmentIndex, chunkIndex); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.chunk[offset%ChunkSize:])
+	r.pos += int64(n)
+
+	return n, nil
+}
+
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = r.pos + offset
+	case io.SeekEnd:
+		pos = r.size + offset
+	default:
+		return 0, fmt.Errorf("sse: invalid whence %d", whence)
+	}
+
+	if pos < 0 {
+		return 0, fmt.Errorf("sse: negative position %d", pos)
+	}
+
+	r.pos = pos
+
+	return pos, nil
+}
+
+// Close closes the underlying reader when it is an io.Closer.
+func (r *Reader) Close() error {
+	if closer, ok := r.src.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// segmentAt returns the index of the non-empty segment holding the byte at pos, pos must be below r.size.
+func (r *Reader) segmentAt(pos int64) int {
+	i, _ := slices.BinarySearchFunc(r.segments, pos, func(s segment, pos int64) int {
+		switch {
+		case pos < s.plainStart:
+			return 1
+		case pos >= s.plainStart+s.Size:
+			return -1
+		default:
+			return 0
+		}
+	})
+
+	return i
+}
+
+func (r *Reader) open(segmentIndex int, chunkIndex int64) error {
+	s := r.segments[segmentIndex]
+
+	plainSize := min(ChunkSize, s.Size-chunkIndex*ChunkSize)
+
+	if _, err := r.src.Seek(s.cipherStart+chunkIndex*(ChunkSize+Overhead), io.SeekStart); err != nil {
+		return err
+	}
+
+	if cap(r.cipherBuf) < int(plainSize)+Overhead {
+		r.cipherBuf = make([]byte, ChunkSize+Overhead)
+	}
+
+	sealed := r.cipherBuf[:plainSize+Overhead]
+
+	if _, err := io.ReadFull(r.src, sealed); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("%w: stream is truncated", ErrDecryptionFailed)
+		}
+
+		return err
+	}
+
+	ad := additionalData
+	if chunkIndex == chunkCount(s.Size)-1 {
+		ad = finalAdditionalData
+	}
+
+	chunk, err := r.aead.Open(r.chunk[:0], chunkNonce(s.Nonce, uint32(chunkIndex)), sealed, ad) //nolint:gosec
+	if err != nil {
+		r.chunkSegment = -1
+
+		return ErrDecryptionFailed
+	}
+
+	r.chunk = chunk
+	r.chunkSegment = segmentIndex
+	r.chunkIndex = chunkIndex
+
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(nonce []byte, index uint32) []byte {
+	return binary.BigEndian.AppendUint32(slices.Clone(nonce), index)
+}
+
+// chunkCount is the number of chunks of a stream, an empty one still has its final chunk.
+func chunkCount(size int64) int64 {
+	return max(1, (size+ChunkSize-1)/ChunkSize)
+}
+
+func random(size int) ([]byte, error) {
+	b := make([]byte, size)
+
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
@@ -0,0 +1,136 @@
+package sse_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/pkg/sse"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func encrypt(key, nonce, plaintext []byte) []byte {
+	buf := &bytes.Buffer{}
+
+	writer := lo.Must(sse.NewWriter(buf, key, nonce))
+	lo.Must(writer.Write(plaintext))
+	lo.Must0(writer.Close())
+
+	return buf.Bytes()
+}
+
+func randomBytes(size int) []byte {
+	b := make([]byte, size)
+	lo.Must(rand.Read(b))
+
+	return b
+}
+
+var _ = Describe("Writer and Reader", func() {
+	var key, nonce []byte
+
+	BeforeEach(func() {
+		key = lo.Must(sse.NewKey())
+		nonce = lo.Must(sse.NewNonce())
+	})
+
+	DescribeTable("round-trips streams of any size", func(size int) {
+		plaintext := randomBytes(size)
+
+		ciphertext := encrypt(key, nonce, plaintext)
+		Expect(ciphertext).To(HaveLen(int(sse.EncryptedSize(int64(size)))))
+
+		reader := lo.Must(sse.NewReader(bytes.NewReader(ciphertext), key, []sse.Segment{{Nonce: nonce, Size: int64(size)}}))
+		Expect(io.ReadAll(reader)).To(Equal(plaintext))
+	},
+		Entry("empty", 0),
+		Entry("smaller than a chunk", 100),
+		Entry("exactly one chunk", sse.ChunkSize),
+		Entry("several chunks", 3*sse.ChunkSize+17),
+	)
+
+	It("reads from any offset", func() {
+		plaintext := randomBytes(2*sse.ChunkSize + 100)
+		ciphertext := encrypt(key, nonce, plaintext)
+
+		reader := lo.Must(sse.NewReader(bytes.NewReader(ciphertext), key,
+			[]sse.Segment{{Nonce: nonce, Size: int64(len(plaintext))}}))
+
+		for _, offset := range []int{0, 1, sse.ChunkSize - 1, sse.ChunkSize, 2*sse.ChunkSize + 50} {
+			Expect(reader.Seek(int64(offset), io.SeekStart)).To(Equal(int64(offset)))
+
+			chunk := make([]byte, 40)
+			lo.Must(io.ReadFull(reader, chunk))
+			Expect(chunk).To(Equal(plaintext[offset : offset+len(chunk)]))
+		}
+
+		Expect(reader.Seek(-10, io.SeekEnd)).To(Equal(int64(len(plaintext) - 10)))
+		Expect(io.ReadAll(reader)).To(Equal(plaintext[len(plaintext)-10:]))
+	})
+
+	It("reads concatenated streams as one", func() {
+		first, second := randomBytes(sse.ChunkSize+1), randomBytes(10)
+		secondNonce := lo.Must(sse.NewNonce())
+
+		ciphertext := append(encrypt(key, nonce, first), encrypt(key, secondNonce, nil)...)
+		ciphertext = append(ciphertext, encrypt(key, secondNonce, second)...)
+
+		reader := lo.Must(sse.NewReader(bytes.NewReader(ciphertext), key, []sse.Segment{
+			{Nonce: nonce, Size: int64(len(first))},
+			{Nonce: secondNonce, Size: 0},
+			{Nonce: secondNonce, Size: int64(len(second))},
+		}))
+
+		Expect(io.ReadAll(reader)).To(Equal(append(first, second...)))
+
+		lo.Must(reader.Seek(int64(len(first)-1), io.SeekStart))
+		Expect(io.ReadAll(reader)).To(Equal(append(first[len(first)-1:], second...)))
+	})
+
+	When("the key is wrong", func() {
+		It("fails to decrypt", func() {
+			ciphertext := encrypt(key, nonce, []byte("secret"))
+
+			reader := lo.Must(sse.NewReader(bytes.NewReader(ciphertext), lo.Must(sse.NewKey()),
+				[]sse.Segment{{Nonce: nonce, Size: 6}}))
+
+			_, err := io.ReadAll(reader)
+			Expect(err).To(MatchError(sse.ErrDecryptionFailed))
+		})
+	})
+
+	When("the ciphertext is tampered with", func() {
+		It("fails to decrypt", func() {
+			ciphertext := encrypt(key, nonce, []byte("secret"))
+			ciphertext[0] ^= 1
+
+			reader := lo.Must(sse.NewReader(bytes.NewReader(ciphertext), key, []sse.Segment{{Nonce: nonce, Size: 6}}))
+
+			_, err := io.ReadAll(reader)
+			Expect(err).To(MatchError(sse.ErrDecryptionFailed))
+		})
+	})
+
+	When("the stream is truncated at a chunk boundary", func() {
+		It("fails to decrypt the last remaining chunk", func() {
+			plaintext := randomBytes(2 * sse.ChunkSize)
+			ciphertext := encrypt(key, nonce, plaintext)[:sse.ChunkSize+sse.Overhead]
+
+			reader := lo.Must(sse.NewReader(bytes.NewReader(ciphertext), key,
+				[]sse.Segment{{Nonce: nonce, Size: sse.ChunkSize}}))
+
+			_, err := io.ReadAll(reader)
+			Expect(err).To(MatchError(sse.ErrDecryptionFailed))
+		})
+	})
+
+	When("the key has the wrong size", func() {
+		It("is rejected", func() {
+			_, err := sse.NewWriter(&bytes.Buffer{}, []byte("short"), nonce)
+			Expect(err).To(MatchError(sse.ErrInvalidKey))
+		})
+	})
+})
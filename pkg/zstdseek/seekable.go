@@ -0,0 +1,472 @@
+// Package zstdseek compresses data with zstd in the seekable format, so it can be read back from any offset.
+//
+// A stream is split into FrameSize frames of uncompressed data that are compressed separately, followed by a
+// seek table with the compressed and decompressed size of every frame, stored in a skippable frame. Reading a
+// range only decompresses the frames it overlaps, and streams remain readable by any zstd decoder. The format
+// is the one of the zstd contrib/seekable_format, without frame checksums.
+//
+// Streams written separately, like the parts of a multipart upload, can be concatenated and read back as one
+// with NewReader, along with segments stored uncompressed.
+package zstdseek
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// FrameSize is the amount of data compressed at once.
+	FrameSize = 256 * 1024
+
+	skippableMagic = 0x184D2A5E
+	seekableMagic  = 0x8F92EAB1
+	// footerSize is the size of the number of frames, the seek table descriptor and the seekable magic number.
+	footerSize = 9
+	// headerSize is the size of the skippable frame magic number and frame size.
+	headerSize = 8
+	entrySize  = 8
+	// checksumEntrySize is the size of seek table entries with a checksum, which are read but not verified.
+	checksumEntrySize = 12
+	checksumFlag      = 1 << 7
+	reservedFlags     = 0b0111_1100
+)
+
+var (
+	ErrInvalidSeekTable = errors.New("invalid zstd seek table")
+	ErrCorrupted        = errors.New("compressed data is corrupted")
+	ErrWriterClosed     = errors.New("write to a closed compressing writer")
+)
+
+//nolint:gochecknoglobals
+var (
+	encoder = sync.OnceValues(func() (*zstd.Encoder, error) {
+		return zstd.NewWriter(nil)
+	})
+	decoder = sync.OnceValues(func() (*zstd.Decoder, error) {
+		return zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(FrameSize))
+	})
+)
+
+// Compressible tells whether a sample of data, usually its first FrameSize bytes, compresses well enough to be
+// worth compressing. Already compressed data like archives, images and videos does not.
+func Compressible(sample []byte) (bool, error) {
+	if len(sample) == 0 {
+		return false, nil
+	}
+
+	enc, err := encoder()
+	if err != nil {
+		return false, err
+	}
+
+	compressed := enc.EncodeAll(sample, nil)
+
+	// Compression must save at least a tenth of the sample.
+	return len(compressed)*10 <= len(sample)*9, nil
+}
+
+type frame struct {
+	compressed   uint32
+	decompressed uint32
+}
+
+// Writer compresses what is written to it into a stream, Close writes the last frame and the seek table and must
+// be called.
+type Writer struct {
+	dst     io.Writer
+	encoder *zstd.Encoder
+	buf     []byte
+	frames  []frame
+	size    int64
+	closed  bool
+}
+
+func NewWriter(dst io.Writer) (*Writer, error) {
+	enc, err := encoder()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		dst:     dst,
+		encoder: enc,
+		buf:     make([]byte, 0, FrameSize),
+	}, nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+
+	written := 0
+
+	for len(p) > 0 {
+		n := copy(w.buf[len(w.buf):FrameSize], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+
+		if len(w.buf) == FrameSize {
+			if err := w.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+
+	return written, nil
+}
+
+// Close writes the last frame and the seek table, it does not close the destination.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+
+	if len(w.buf) > 0 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+
+	tableSize := len(w.frames)*entrySize + footerSize
+	table := make([]byte, 0, headerSize+tableSize)
+
+	table = binary.LittleEndian.AppendUint32(table, skippableMagic)
+	table = binary.LittleEndian.AppendUint32(table, uint32(tableSize)) //nolint:gosec
+
+	for _, f := range w.frames {
+		table = binary.LittleEndian.AppendUint32(table, f.compressed)
+		table = binary.LittleEndian.AppendUint32(table, f.decompressed)
+	}
+
+	table = binary.LittleEndian.AppendUint32(table, uint32(len(w.frames))) //nolint:gosec
+	table = append(table, 0)
+	table = binary.LittleEndian.AppendUint32(table, seekableMagic)
+
+	return w.write(table)
+}
+
+// Size is the number of bytes written to the destination, the size of the stream once the writer is closed.
+func (w *Writer) Size() int64 {
+	return w.size
+}
+
+func (w *Writer) flush() error {
+	compressed := w.encoder.EncodeAll(w.buf, nil)
+
+	w.frames = append(w.frames, frame{
+		compressed:   uint32(len(compressed)), //nolint:gosec
+		decompressed: uint32(len(w.buf)),      //nolint:gosec
+	})
+	w.buf = w.buf[:0]
+
+	return w.write(compressed)
+}
+
+func (w *Writer) write(p []byte) error {
+	n, err := w.dst.Write(p)
+	w.size += int64(n)
+
+	return err
+}
+
+// Segment is a stream or uncompressed data within the data read by Reader.
+type Segment struct {
+	// Size is the size of the uncompressed content of the segment.
+	Size int64
+	// StoredSize is the size of the segment in the underlying reader.
+	StoredSize int64
+	// Raw segments are stored uncompressed, StoredSize is then equal to Size.
+	Raw bool
+}
+
+type segment struct {
+	Segment
+
+	start       int64
+	storedStart int64
+	// frames is loaded from the seek table when the segment is first read.
+	frames []frameEntry
+}
+
+type frameEntry struct {
+	frame
+
+	start       int64
+	storedStart int64
+}
+
+// Reader decompresses concatenated streams and raw segments. It is an io.ReadSeeker over the uncompressed data,
+// the underlying reader is only read from the seek tables and the frames that are needed.
+type Reader struct {
+	src      io.ReadSeeker
+	decoder  *zstd.Decoder
+	segments []segment
+	size     int64
+	pos      int64
+
+	// frame is the content of the frame frameIndex of the segment frameSegment, it is kept between reads.
+	frame         []byte
+	frameSegment  int
+	frameIndex    int
+	compressedBuf []byte
+}
+
+func NewReader(src io.ReadSeeker, segments []Segment) (*Reader, error) {
+	dec, err := decoder()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reader{
+		src:          src,
+		decoder:      dec,
+		segments:     make([]segment, 0, len(segments)),
+		frameSegment: -1,
+	}
+
+	var storedStart int64
+
+	for _, s := range segments {
+		if s.Size < 0 || s.StoredSize < 0 || (s.Raw && s.Size != s.StoredSize) {
+			return nil, fmt.Errorf("%w: invalid segment sizes", ErrCorrupted)
+		}
+
+		r.segments = append(r.segments, segment{Segment: s, start: r.size, storedStart: storedStart})
+		r.size += s.Size
+		storedStart += s.StoredSize
+	}
+
+	return r, nil
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.pos >= r.size {
+		return 0, io.EOF
+	}
+
+	segmentIndex := r.segmentAt(r.pos)
+	s := &r.segments[segmentIndex]
+	offset := r.pos - s.start
+
+	if s.Raw {
+		return r.readRaw(p, s, offset)
+	}
+
+	if s.frames == nil {
+		if err := r.loadSeekTable(s); err != nil {
+			return 0, err
+		}
+	}
+
+	frameIndex := frameAt(s.frames, offset)
+	f := s.frames[frameIndex]
+
+	if segmentIndex != r.frameSegment || frameIndex != r.frameIndex {
+		if err := r.decode(segmentIndex, frameIndex); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.frame[offset-f.start:])
+	r.pos += int64(n)
+
+	return n, nil
+}
+
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = r.pos + offset
+	case io.SeekEnd:
+		pos = r.size + offset
+	default:
+		return 0, fmt.Errorf("zstdseek: invalid whence %d", whence)
+	}
+
+	if pos < 0 {
+		return 0, fmt.Errorf("zstdseek: negative position %d", pos)
+	}
+
+	r.pos = pos
+
+	return pos, nil
+}
+
+// Close closes the underlying reader when it is an io.Closer.
+func (r *Reader) Close() error {
+	if closer, ok := r.src.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// segmentAt returns the index of the non-empty segment holding the byte at pos, pos must be below r.size.
+func (r *Reader) segmentAt(pos int64) int {
+	i, _ := slices.BinarySearchFunc(r.segments, pos, func(s segment, pos int64) int {
+		switch {
+		case pos < s.start:
+			return 1
+		case pos >= s.start+s.Size:
+			return -1
+		default:
+			return 0
+		}
+	})
+
+	return i
+}
+
+// frameAt returns the index of the non-empty frame holding the byte at offset of a segment.
+func frameAt(frames []frameEntry, offset int64) int {
+	i, _ := slices.BinarySearchFunc(frames, offset, func(f frameEntry, offset int64) int {
+		switch {
+		case offset < f.start:
+			return 1
+		case offset >= f.start+int64(f.decompressed):
+			return -1
+		default:
+			return 0
+		}
+	})
+
+	return i
+}
+
+func (r *Reader) readRaw(p []byte, s *segment, offset int64) (int, error) {
+	if _, err := r.src.Seek(s.storedStart+offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	n, err := r.src.Read(p[:min(int64(len(p)), s.Size-offset)])
+	r.pos += int64(n)
+
+	if n == 0 && errors.Is(err, io.EOF) {
+		return 0, fmt.Errorf("%w: stream is truncated", ErrCorrupted)
+	}
+
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+
+	return n, err
+}
+
+func (r *Reader) loadSeekTable(s *segment) error {
+	if s.StoredSize < headerSize+footerSize {
+		return fmt.Errorf("%w: segment is too small", ErrInvalidSeekTable)
+	}
+
+	footer := make([]byte, footerSize)
+	if err := r.readAt(footer, s.storedStart+s.StoredSize-footerSize); err != nil {
+		return err
+	}
+
+	descriptor := footer[4]
+	if binary.LittleEndian.Uint32(footer[5:]) != seekableMagic || descriptor&reservedFlags != 0 {
+		return fmt.Errorf("%w: bad footer", ErrInvalidSeekTable)
+	}
+
+	size := entrySize
+	if descriptor&checksumFlag != 0 {
+		size = checksumEntrySize
+	}
+
+	count := int64(binary.LittleEndian.Uint32(footer))
+	tableSize := headerSize + count*int64(size) + footerSize
+
+	if tableSize > s.StoredSize {
+		return fmt.Errorf("%w: %d frames do not fit in the segment", ErrInvalidSeekTable, count)
+	}
+
+	table := make([]byte, tableSize-footerSize)
+	if err := r.readAt(table, s.storedStart+s.StoredSize-tableSize); err != nil {
+		return err
+	}
+
+	if binary.LittleEndian.Uint32(table) != skippableMagic ||
+		int64(binary.LittleEndian.Uint32(table[4:])) != tableSize-headerSize {
+		return fmt.Errorf("%w: bad header", ErrInvalidSeekTable)
+	}
+
+	frames := make([]frameEntry, 0, count)
+
+	var start, storedStart int64
+
+	for entry := table[headerSize:]; len(entry) > 0; entry = entry[size:] {
+		f := frame{
+			compressed:   binary.LittleEndian.Uint32(entry),
+			decompressed: binary.LittleEndian.Uint32(entry[4:]),
+		}
+
+		frames = append(frames, frameEntry{frame: f, start: start, storedStart: storedStart})
+		start += int64(f.decompressed)
+		storedStart += int64(f.compressed)
+	}
+
+	if start != s.Size || storedStart != s.StoredSize-tableSize {
+		return fmt.Errorf("%w: frame sizes do not match the segment", ErrInvalidSeekTable)
+	}
+
+	s.frames = frames
+
+	return nil
+}
+
+func (r *Reader) decode(segmentIndex, frameIndex int) error {
+	s := r.segments[segmentIndex]
+	f := s.frames[frameIndex]
+
+	if cap(r.compressedBuf) < int(f.compressed) {
+		r.compressedBuf = make([]byte, f.compressed)
+	}
+
+	compressed := r.compressedBuf[:f.compressed]
+
+	if err := r.readAt(compressed, s.storedStart+f.storedStart); err != nil {
+		return err
+	}
+
+	decompressed, err := r.decoder.DecodeAll(compressed, r.frame[:0])
+	if err != nil || len(decompressed) != int(f.decompressed) {
+		r.frameSegment = -1
+
+		return fmt.Errorf("%w: frame %d does not decompress to its size", ErrCorrupted, frameIndex)
+	}
+
+	r.frame = decompressed
+	r.frameSegment = segmentIndex
+	r.frameIndex = frameIndex
+
+	return nil
+}
+
+func (r *Reader) readAt(p []byte, offset int64) error {
+	if _, err := r.src.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := io.ReadFull(r.src, p); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("%w: stream is truncated", ErrCorrupted)
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package zstdseek_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/samber/lo"
+	"github.com/zhulik/d3/pkg/zstdseek"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func compress(data []byte) []byte {
+	buf := &bytes.Buffer{}
+
+	writer := lo.Must(zstdseek.NewWriter(buf))
+	lo.Must(writer.Write(data))
+	lo.Must0(writer.Close())
+	Expect(writer.Size()).To(Equal(int64(buf.Len())))
+
+	return buf.Bytes()
+}
+
+func compressible(size int) []byte {
+	buf := &bytes.Buffer{}
+
+	for i := 0; buf.Len() < size; i++ {
+		fmt.Fprintf(buf, `{"line":%d,"level":"info","message":"request served"}`+"\n", i)
+	}
+
+	return buf.Bytes()[:size]
+}
+
+func randomBytes(size int) []byte {
+	b := make([]byte, size)
+	lo.Must(rand.Read(b))
+
+	return b
+}
+
+func segment(data, stored []byte) zstdseek.Segment {
+	return zstdseek.Segment{Size: int64(len(data)), StoredSize: int64(len(stored))}
+}
+
+var _ = Describe("Writer and Reader", func() {
+	DescribeTable("round-trips streams of any size", func(size int) {
+		data := compressible(size)
+		compressed := compress(data)
+
+		reader := lo.Must(zstdseek.NewReader(bytes.NewReader(compressed), []zstdseek.Segment{segment(data, compressed)}))
+		Expect(io.ReadAll(reader)).To(Equal(data))
+	},
+		Entry("empty", 0),
+		Entry("smaller than a frame", 100),
+		Entry("exactly one frame", zstdseek.FrameSize),
+		Entry("several frames", 3*zstdseek.FrameSize+17),
+	)
+
+	It("writes streams any zstd decoder reads", func() {
+		data := compressible(2*zstdseek.FrameSize + 100)
+
+		decoder := lo.Must(zstd.NewReader(bytes.NewReader(compress(data))))
+		defer decoder.Close()
+
+		Expect(io.ReadAll(decoder)).To(Equal(data))
+	})
+
+	It("reads from any offset", func() {
+		data := compressible(2*zstdseek.FrameSize + 100)
+		compressed := compress(data)
+		Expect(len(compressed)).To(BeNumerically("<", len(data)/5))
+
+		reader := lo.Must(zstdseek.NewReader(bytes.NewReader(compressed), []zstdseek.Segment{segment(data, compressed)}))
+
+		for _, offset := range []int64{zstdseek.FrameSize + 5, 3, 2*zstdseek.FrameSize - 20} {
+			lo.Must(reader.Seek(offset, io.SeekStart))
+
+			buf := make([]byte, 40)
+			lo.Must(io.ReadFull(reader, buf))
+			Expect(buf).To(Equal(data[offset : offset+40]))
+		}
+
+		lo.Must(reader.Seek(-10, io.SeekEnd))
+		Expect(io.ReadAll(reader)).To(Equal(data[len(data)-10:]))
+	})
+
+	It("reads concatenated streams and raw segments as one", func() {
+		first := compressible(zstdseek.FrameSize + 10)
+		raw := randomBytes(1000)
+		last := compressible(50)
+
+		firstCompressed, lastCompressed := compress(first), compress(last)
+		stored := concat(firstCompressed, raw, lastCompressed)
+
+		reader := lo.Must(zstdseek.NewReader(bytes.NewReader(stored), []zstdseek.Segment{
+			segment(first, firstCompressed),
+			{Size: int64(len(raw)), StoredSize: int64(len(raw)), Raw: true},
+			segment(last, lastCompressed),
+		}))
+
+		lo.Must(reader.Seek(int64(len(first))-5, io.SeekStart))
+
+		buf := make([]byte, 1010)
+		lo.Must(io.ReadFull(reader, buf))
+		Expect(buf).To(Equal(concat(first[len(first)-5:], raw, last[:5])))
+
+		lo.Must(reader.Seek(0, io.SeekStart))
+		Expect(io.ReadAll(reader)).To(Equal(concat(first, raw, last)))
+	})
+
+	It("detects corrupted seek tables and frames", func() {
+		data := compressible(2 * zstdseek.FrameSize)
+		compressed := compress(data)
+
+		badTable := bytes.Clone(compressed)
+		badTable[len(badTable)-1] ^= 0xff
+
+		reader := lo.Must(zstdseek.NewReader(bytes.NewReader(badTable), []zstdseek.Segment{segment(data, badTable)}))
+		_, err := io.ReadAll(reader)
+		Expect(err).To(MatchError(zstdseek.ErrInvalidSeekTable))
+
+		reader = lo.Must(zstdseek.NewReader(bytes.NewReader(compressed),
+			[]zstdseek.Segment{{Size: int64(len(data)) + 1, StoredSize: int64(len(compressed))}}))
+		_, err = io.ReadAll(reader)
+		Expect(err).To(MatchError(zstdseek.ErrInvalidSeekTable))
+
+		badFrame := bytes.Clone(compressed)
+		badFrame[20] ^= 0xff
+
+		reader = lo.Must(zstdseek.NewReader(bytes.NewReader(badFrame), []zstdseek.Segment{segment(data, badFrame)}))
+		_, err = io.ReadAll(reader)
+		Expect(err).To(MatchError(zstdseek.ErrCorrupted))
+	})
+
+	It("detects truncated raw segments", func() {
+		reader := lo.Must(zstdseek.NewReader(bytes.NewReader([]byte("short")),
+			[]zstdseek.Segment{{Size: 10, StoredSize: 10, Raw: true}}))
+
+		_, err := io.ReadAll(reader)
+		Expect(err).To(MatchError(zstdseek.ErrCorrupted))
+	})
+})
+
+var _ = Describe("Compressible", func() {
+	It("accepts text and rejects random data", func() {
+		Expect(zstdseek.Compressible(compressible(zstdseek.FrameSize))).To(BeTrue())
+		Expect(zstdseek.Compressible(randomBytes(zstdseek.FrameSize))).To(BeFalse())
+		Expect(zstdseek.Compressible(nil)).To(BeFalse())
+	})
+})
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}